@@ -38,11 +38,22 @@ type Mode struct {
 
 type (
 	DummyEngine struct {
-		clock         *mockable.Clock
-		consensusMode Mode
+		clock             *mockable.Clock
+		consensusMode     Mode
+		rewardDistributor RewardDistributor
 	}
 )
 
+// NewEngine returns an engine that uses [distributor], which may be nil, to
+// distribute the rewards of blocks after the RewardDistribution upgrade.
+func NewEngine(clock *mockable.Clock, mode Mode, distributor RewardDistributor) *DummyEngine {
+	return &DummyEngine{
+		clock:             clock,
+		consensusMode:     mode,
+		rewardDistributor: distributor,
+	}
+}
+
 func NewETHFaker() *DummyEngine {
 	return &DummyEngine{
 		clock:         &mockable.Clock{},
@@ -370,6 +381,9 @@ func (self *DummyEngine) Finalize(chain consensus.ChainHeaderReader, block *type
 			return err
 		}
 	}
+	if err := self.distributeRewards(chain.Config(), block.Header(), state, block.Transactions(), receipts); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -403,6 +417,9 @@ func (self *DummyEngine) FinalizeAndAssemble(chain consensus.ChainHeaderReader,
 			return nil, err
 		}
 	}
+	if err := self.distributeRewards(chain.Config(), header, state, txs, receipts); err != nil {
+		return nil, err
+	}
 	// commit the final state root
 	header.Root = state.IntermediateRoot(chain.Config().IsEIP158(header.Number))
 
@@ -0,0 +1,125 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package dummy
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/luxdefi/evm/core/state"
+	"github.com/luxdefi/evm/core/types"
+	"github.com/luxdefi/evm/metrics"
+	"github.com/luxdefi/evm/params"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var (
+	errNilNetIssuance = errors.New("reward distributor returned nil net issuance")
+
+	// netIssuanceGauge is the net change in supply, in wei, made by the
+	// reward distributor in the last block finalized.
+	netIssuanceGauge = metrics.NewRegisteredGaugeFloat64("consensus/rewards/issuance", nil)
+)
+
+// BlockFees are the transaction fees collected by a block. The state
+// transition credits both parts to the block's coinbase.
+type BlockFees struct {
+	// BaseFees is the part of the fees paid at the block's base fee.
+	BaseFees *big.Int
+	// Tips is the part of the fees paid above the block's base fee.
+	Tips *big.Int
+}
+
+// Total returns the sum of the base fees and tips.
+func (f *BlockFees) Total() *big.Int {
+	return new(big.Int).Add(f.BaseFees, f.Tips)
+}
+
+// RewardDistributor controls the issuance and fee burning of a chain with
+// custom economics. It is called while finalizing every block after the
+// RewardDistribution network upgrade, both when building and when verifying
+// blocks, so it is consensus critical: it must only depend on its arguments
+// and must produce the same state changes on every node.
+type RewardDistributor interface {
+	// DistributeRewards may mint, burn or redistribute native coin by adjusting
+	// balances in [state], given the [fees] collected by the block of [header].
+	// [header] must not be modified. Returns the net change in supply made by
+	// the distribution, which is negative if more coin is burned than minted.
+	DistributeRewards(header *types.Header, state *state.StateDB, fees *BlockFees) (*big.Int, error)
+}
+
+// collectedFees returns the fees paid by [txs] with [receipts] in a block with [baseFee].
+func collectedFees(baseFee *big.Int, txs []*types.Transaction, receipts []*types.Receipt) (*BlockFees, error) {
+	var (
+		fees = &BlockFees{
+			BaseFees: new(big.Int),
+			Tips:     new(big.Int),
+		}
+		gasUsed = new(big.Int)
+		fee     = new(big.Int)
+	)
+	for i, receipt := range receipts {
+		gasUsed.SetUint64(receipt.GasUsed)
+		tip, err := txs[i].EffectiveGasTip(baseFee)
+		if err != nil {
+			return nil, err
+		}
+		fees.Tips.Add(fees.Tips, fee.Mul(tip, gasUsed))
+		if baseFee != nil {
+			fees.BaseFees.Add(fees.BaseFees, fee.Mul(baseFee, gasUsed))
+		}
+	}
+	return fees, nil
+}
+
+// distributeRewards calls the reward distributor of the engine, if any, for
+// the block of [header] if the RewardDistribution upgrade is active.
+func (self *DummyEngine) distributeRewards(
+	config *params.ChainConfig,
+	header *types.Header,
+	state *state.StateDB,
+	txs []*types.Transaction,
+	receipts []*types.Receipt,
+) error {
+	if self.rewardDistributor == nil || !config.IsRewardDistribution(header.Time) {
+		return nil
+	}
+	fees, err := collectedFees(header.BaseFee, txs, receipts)
+	if err != nil {
+		return err
+	}
+	issuance, err := self.rewardDistributor.DistributeRewards(header, state, fees)
+	if err != nil {
+		return fmt.Errorf("failed to distribute rewards: %w", err)
+	}
+	if issuance == nil {
+		return errNilNetIssuance
+	}
+	log.Trace("Distributed block rewards", "number", header.Number, "baseFees", fees.BaseFees, "tips", fees.Tips, "issuance", issuance)
+	f, _ := new(big.Float).SetInt(issuance).Float64()
+	netIssuanceGauge.Update(f)
+	return nil
+}
+
+// BaseFeeBurner is a RewardDistributor burning a percentage of the base fees
+// of every block from its coinbase.
+type BaseFeeBurner struct {
+	percentage uint64
+}
+
+// NewBaseFeeBurner returns a RewardDistributor burning [percentage], which
+// must be at most 100, of the base fees of every block.
+func NewBaseFeeBurner(percentage uint64) *BaseFeeBurner {
+	return &BaseFeeBurner{percentage: percentage}
+}
+
+func (b *BaseFeeBurner) DistributeRewards(header *types.Header, state *state.StateDB, fees *BlockFees) (*big.Int, error) {
+	burned := new(big.Int).SetUint64(b.percentage)
+	burned.Mul(burned, fees.BaseFees)
+	burned.Div(burned, big.NewInt(100))
+	state.SubBalance(header.Coinbase, burned)
+	return new(big.Int).Neg(burned), nil
+}
+
@@ -0,0 +1,9 @@
+//SPDX-License-Identifier: MIT
+pragma solidity ^0.8.0;
+
+interface IBlockHashes {
+  // getBlockHash returns the hash of one of the 8192 most recent blocks,
+  // including blocks older than the 256 blocks available to blockhash. Returns
+  // zero for the current block, future blocks and older blocks.
+  function getBlockHash(uint256 blockNumber) external view returns (bytes32 blockHash);
+}
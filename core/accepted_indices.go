@@ -0,0 +1,128 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package core
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/luxdefi/evm/core/rawdb"
+	"github.com/luxdefi/evm/core/types"
+	"github.com/luxdefi/evm/ethdb"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// acceptedIndexWriter writes the transaction lookup entries and the acceptor
+// tip of accepted blocks to disk. If batching is enabled, the writes of
+// several blocks are grouped into one batch, which is flushed once it holds
+// [maxBlocks] blocks, once [interval] has passed since its first block, or once
+// it reaches [ethdb.IdealBatchSize].
+//
+// The acceptor tip is written in the same batch as the indices, so a crash
+// before a flush only loses indices that are regenerated from the acceptor
+// tip on startup. The last accepted block reported upstream is persisted
+// independently and is never delayed. Until a batch is flushed, its lookup
+// entries are served from memory.
+type acceptedIndexWriter struct {
+	maxBlocks int
+	interval  time.Duration
+
+	lock    sync.RWMutex
+	batch   ethdb.Batch
+	blocks  int       // Number of blocks in [batch]
+	since   time.Time // Time the first block was added to [batch]
+	lookups map[common.Hash]*rawdb.LegacyTxLookupEntry
+}
+
+func newAcceptedIndexWriter(db ethdb.Database, maxBlocks int, interval time.Duration) *acceptedIndexWriter {
+	return &acceptedIndexWriter{
+		maxBlocks: maxBlocks,
+		interval:  interval,
+		batch:     db.NewBatch(),
+		lookups:   make(map[common.Hash]*rawdb.LegacyTxLookupEntry),
+	}
+}
+
+// batching returns whether the writes of multiple blocks may be grouped.
+func (w *acceptedIndexWriter) batching() bool {
+	return w.maxBlocks > 1 || w.interval > 0
+}
+
+// write adds the accepted indices of [b] to the pending batch and flushes it
+// if it is due.
+func (w *acceptedIndexWriter) write(b *types.Block) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	rawdb.WriteTxLookupEntriesByBlock(w.batch, b)
+	if err := rawdb.WriteAcceptorTip(w.batch, b.Hash()); err != nil {
+		return fmt.Errorf("%w: failed to write acceptor tip key", err)
+	}
+	if !w.batching() {
+		return w.flushLocked()
+	}
+	for i, tx := range b.Transactions() {
+		w.lookups[tx.Hash()] = &rawdb.LegacyTxLookupEntry{BlockHash: b.Hash(), BlockIndex: b.NumberU64(), Index: uint64(i)}
+	}
+	if w.blocks == 0 {
+		w.since = time.Now()
+	}
+	w.blocks++
+	if w.dueLocked() {
+		return w.flushLocked()
+	}
+	return nil
+}
+
+// flushIfDue flushes the pending batch if it has been held for [interval].
+func (w *acceptedIndexWriter) flushIfDue() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.blocks == 0 || !w.dueLocked() {
+		return nil
+	}
+	return w.flushLocked()
+}
+
+// flush writes any pending indices to disk.
+func (w *acceptedIndexWriter) flush() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return w.flushLocked()
+}
+
+// lookup returns the lookup entry of the transaction [hash] if it is held in
+// the pending batch.
+func (w *acceptedIndexWriter) lookup(hash common.Hash) *rawdb.LegacyTxLookupEntry {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	return w.lookups[hash]
+}
+
+// dueLocked must be called with [w.lock] held.
+func (w *acceptedIndexWriter) dueLocked() bool {
+	return (w.maxBlocks > 0 && w.blocks >= w.maxBlocks) ||
+		(w.interval > 0 && time.Since(w.since) >= w.interval) ||
+		w.batch.ValueSize() >= ethdb.IdealBatchSize
+}
+
+// flushLocked must be called with [w.lock] held.
+func (w *acceptedIndexWriter) flushLocked() error {
+	if w.batch.ValueSize() == 0 {
+		return nil
+	}
+	if err := w.batch.Write(); err != nil {
+		return fmt.Errorf("%w: failed to write tx lookup entries batch", err)
+	}
+	w.batch.Reset()
+	w.blocks = 0
+	if len(w.lookups) > 0 {
+		w.lookups = make(map[common.Hash]*rawdb.LegacyTxLookupEntry)
+	}
+	return nil
+}
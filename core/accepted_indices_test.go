@@ -0,0 +1,79 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/luxdefi/evm/core/rawdb"
+	"github.com/luxdefi/evm/core/types"
+	"github.com/luxdefi/evm/params"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchedAcceptedIndicesCrash(t *testing.T) {
+	var (
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		key2, _ = crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+		addr1   = crypto.PubkeyToAddress(key1.PublicKey)
+		addr2   = crypto.PubkeyToAddress(key2.PublicKey)
+		signer  = types.HomesteadSigner{}
+		chainDB = rawdb.NewMemoryDatabase()
+		gspec   = &Genesis{
+			Config: &params.ChainConfig{HomesteadBlock: new(big.Int)},
+			Alloc:  GenesisAlloc{addr1: {Balance: big.NewInt(1000000000)}},
+		}
+	)
+	cacheConfig := *archiveConfig
+	cacheConfig.AcceptedIndexBatchBlocks = 100
+
+	blockchain, err := createBlockChain(chainDB, &cacheConfig, gspec, common.Hash{})
+	require.NoError(t, err)
+	genesisHash := blockchain.Genesis().Hash()
+
+	_, chain, _, err := GenerateChainWithGenesis(gspec, blockchain.engine, 3, 10, func(i int, gen *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(addr1), addr2, big.NewInt(10000), params.TxGas, nil, nil), signer, key1)
+		gen.AddTx(tx)
+	})
+	require.NoError(t, err)
+	_, err = blockchain.InsertChain(chain)
+	require.NoError(t, err)
+	for _, block := range chain {
+		require.NoError(t, blockchain.Accept(block))
+	}
+	blockchain.DrainAcceptorQueue()
+
+	// The indices are held in the pending batch, but are already served.
+	require.Equal(t, chain[2].Hash(), blockchain.LastAcceptedBlock().Hash())
+	acceptorTip, err := rawdb.ReadAcceptorTip(chainDB)
+	require.NoError(t, err)
+	require.Equal(t, genesisHash, acceptorTip)
+	for _, block := range chain {
+		tx := block.Transactions()[0]
+		require.Nil(t, rawdb.ReadTxLookupEntry(chainDB, tx.Hash()))
+		lookup := blockchain.GetTransactionLookup(tx.Hash())
+		require.NotNil(t, lookup)
+		require.Equal(t, block.Hash(), lookup.BlockHash)
+	}
+
+	// Crash without flushing the batch.
+	blockchain.stopWithoutSaving()
+
+	// On restart, the accepted block is kept and the lost indices are
+	// regenerated from the persisted acceptor tip.
+	blockchain, err = createBlockChain(chainDB, &cacheConfig, gspec, chain[2].Hash())
+	require.NoError(t, err)
+	defer blockchain.Stop()
+
+	require.Equal(t, chain[2].Hash(), blockchain.LastAcceptedBlock().Hash())
+	acceptorTip, err = rawdb.ReadAcceptorTip(chainDB)
+	require.NoError(t, err)
+	require.Equal(t, chain[2].Hash(), acceptorTip)
+	for _, block := range chain {
+		require.Equal(t, block.NumberU64(), *rawdb.ReadTxLookupEntry(chainDB, block.Transactions()[0].Hash()))
+	}
+}
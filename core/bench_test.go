@@ -28,6 +28,7 @@ package core
 
 import (
 	"crypto/ecdsa"
+	"fmt"
 	"math/big"
 	"testing"
 
@@ -184,6 +185,131 @@ func benchInsertChain(b *testing.B, disk bool, gen func(int, *BlockGen)) {
 	}
 }
 
+func BenchmarkInsertChain_pipeline(b *testing.B) {
+	for _, window := range []int{0, 1, 4, 16} {
+		b.Run(fmt.Sprintf("window=%d", window), func(b *testing.B) {
+			benchInsertChainPipeline(b, window, genTxRing(200))
+		})
+	}
+}
+
+// benchInsertChainPipeline times the insertion of a batch of 256 blocks, with
+// up to [window] blocks verified ahead of the blocks being committed.
+func benchInsertChainPipeline(b *testing.B, window int, gen func(int, *BlockGen)) {
+	gspec := &Genesis{
+		Config: params.TestChainConfig,
+		Alloc:  GenesisAlloc{benchRootAddr: {Balance: benchRootFunds}},
+	}
+	_, chain, _, err := GenerateChainWithGenesis(gspec, dummy.NewCoinbaseFaker(), 256, 10, gen)
+	if err != nil {
+		b.Fatal(err)
+	}
+	cacheConfig := *DefaultCacheConfig
+	cacheConfig.VerificationPipelineWindow = window
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		db, err := rawdb.NewLevelDBDatabase(b.TempDir(), 128, 128, "", false)
+		if err != nil {
+			b.Fatalf("cannot create temporary database: %v", err)
+		}
+		chainman, _ := NewBlockChain(db, &cacheConfig, gspec, dummy.NewCoinbaseFaker(), vm.Config{}, common.Hash{}, false)
+		b.StartTimer()
+
+		if n, err := chainman.InsertChain(chain); err != nil {
+			b.Fatalf("insert error (block %d): %v\n", n, err)
+		}
+
+		b.StopTimer()
+		chainman.Stop()
+		db.Close()
+		b.StartTimer()
+	}
+}
+
+func BenchmarkStatePrewarm(b *testing.B) {
+	b.Run("cold", func(b *testing.B) { benchStatePrewarm(b, false) })
+	b.Run("prewarmed", func(b *testing.B) { benchStatePrewarm(b, true) })
+}
+
+// benchStatePrewarm times the insertion of a block reading the same storage
+// slots as its accepted parent, after a restart emptied the caches, with or
+// without [prewarm]ing the state accessed by the parent first.
+func benchStatePrewarm(b *testing.B, prewarm bool) {
+	const numSlots = 512
+	var (
+		contract = common.Address{0xc0}
+		storage  = make(map[common.Hash]common.Hash, numSlots)
+		code     []byte
+	)
+	for i := 0; i < numSlots; i++ {
+		storage[common.BigToHash(big.NewInt(int64(i)))] = common.BigToHash(big.NewInt(int64(i + 1)))
+		code = append(code, byte(vm.PUSH2), byte(i>>8), byte(i), byte(vm.SLOAD), byte(vm.POP))
+	}
+	gspec := &Genesis{
+		Config: params.TestChainConfig,
+		Alloc: GenesisAlloc{
+			benchRootAddr: {Balance: benchRootFunds},
+			contract:      {Code: code, Storage: storage, Balance: common.Big0},
+		},
+	}
+	signer := types.LatestSigner(gspec.Config)
+	_, chain, _, err := GenerateChainWithGenesis(gspec, dummy.NewCoinbaseFaker(), 2, 10, func(i int, gen *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(benchRootAddr), contract, common.Big0, 2_000_000, big.NewInt(225000000000), nil), signer, benchRootKey)
+		gen.AddTx(tx)
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	cacheConfig := *archiveConfig
+	cacheConfig.StatePrewarmLimit = 2 * numSlots
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		db, err := rawdb.NewLevelDBDatabase(b.TempDir(), 128, 128, "", false)
+		if err != nil {
+			b.Fatalf("cannot create temporary database: %v", err)
+		}
+		// Accept the parent block, recording the state it accessed.
+		chainman, err := NewBlockChain(db, &cacheConfig, gspec, dummy.NewCoinbaseFaker(), vm.Config{}, common.Hash{}, false)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if n, err := chainman.InsertChain(chain[:1]); err != nil {
+			b.Fatalf("insert error (block %d): %v\n", n, err)
+		}
+		if err := chainman.Accept(chain[0]); err != nil {
+			b.Fatal(err)
+		}
+		chainman.DrainAcceptorQueue()
+		keys := chainman.prewarmer.top()
+		chainman.Stop()
+
+		// Restart with empty caches, as if they had been evicted.
+		chainman, err = NewBlockChain(db, &cacheConfig, gspec, dummy.NewCoinbaseFaker(), vm.Config{}, chain[0].Hash(), false)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if prewarm {
+			chainman.prewarm(chain[0].Root(), keys)
+		}
+		b.StartTimer()
+
+		if n, err := chainman.InsertChain(chain[1:]); err != nil {
+			b.Fatalf("insert error (block %d): %v\n", n, err)
+		}
+
+		b.StopTimer()
+		chainman.Stop()
+		db.Close()
+		b.StartTimer()
+	}
+}
+
 func BenchmarkChainRead_header_10k(b *testing.B) {
 	benchReadChain(b, false, 10000)
 }
@@ -77,7 +77,9 @@ func (v *BlockValidator) ValidateBody(block *types.Block) error {
 	if hash := types.DeriveSha(block.Transactions(), trie.NewStackTrie(nil)); hash != header.TxHash {
 		return fmt.Errorf("transaction root hash mismatch (header value %x, calculated %x)", header.TxHash, hash)
 	}
-	if !v.bc.HasBlockAndState(block.ParentHash(), block.NumberU64()-1) {
+	// The state of the parent may still be waiting for the commit stage of
+	// the verification pipeline, in which case [block] is executed on it.
+	if !v.bc.HasBlockAndState(block.ParentHash(), block.NumberU64()-1) && !v.bc.pipelineHasState(block.ParentHash()) {
 		if !v.bc.HasBlock(block.ParentHash(), block.NumberU64()-1) {
 			return consensus.ErrUnknownAncestor
 		}
@@ -84,13 +84,18 @@ var (
 	acceptorQueueGauge            = metrics.NewRegisteredGauge("chain/acceptor/queue/size", nil)
 	acceptorWorkTimer             = metrics.NewRegisteredCounter("chain/acceptor/work", nil)
 	acceptorWorkCount             = metrics.NewRegisteredCounter("chain/acceptor/work/count", nil)
+	statePrewarmTimer             = metrics.NewRegisteredCounter("chain/prewarm/work", nil)
+	statePrewarmKeysCounter       = metrics.NewRegisteredCounter("chain/prewarm/keys", nil)
+	statePrewarmDroppedCounter    = metrics.NewRegisteredCounter("chain/prewarm/dropped", nil)
 	lastAcceptedBlockBaseFeeGauge = metrics.NewRegisteredGauge("chain/block/fee/basefee", nil)
 	blockTotalFeesGauge           = metrics.NewRegisteredGauge("chain/block/fee/total", nil)
 	processedBlockGasUsedCounter  = metrics.NewRegisteredCounter("chain/block/gas/used/processed", nil)
 	acceptedBlockGasUsedCounter   = metrics.NewRegisteredCounter("chain/block/gas/used/accepted", nil)
 	badBlockCounter               = metrics.NewRegisteredCounter("chain/block/bad/count", nil)
+	slowBlockCounter              = metrics.NewRegisteredCounter("chain/block/slow/count", nil)
 
 	txUnindexTimer      = metrics.NewRegisteredCounter("chain/txs/unindex", nil)
+	txReindexTimer      = metrics.NewRegisteredCounter("chain/txs/reindex", nil)
 	acceptedTxsCounter  = metrics.NewRegisteredCounter("chain/txs/accepted", nil)
 	processedTxsCounter = metrics.NewRegisteredCounter("chain/txs/processed", nil)
 
@@ -177,9 +182,25 @@ type CacheConfig struct {
 	SnapshotDelayInit               bool          // Whether to initialize snapshots on startup or wait for external call
 	SnapshotLimit                   int           // Memory allowance (MB) to use for caching snapshot entries in memory
 	SnapshotVerify                  bool          // Verify generated snapshots
+	SnapshotVerifySampleRate        float64       // Fraction of accounts to check when verifying snapshots (0 = all)
 	Preimages                       bool          // Whether to store preimage of trie key to the disk
 	AcceptedCacheSize               int           // Depth of accepted headers cache and accepted logs cache at the accepted tip
 	TxLookupLimit                   uint64        // Number of recent blocks for which to maintain transaction lookup indices
+	MaxReorgDepth                   uint64        // Maximum number of canonical blocks a reorg may drop (0 = unlimited)
+	TriePinnedLimit                 int           // Memory allowance (MB) to use for trie nodes pinned by PinContract
+	AcceptedIndexBatchBlocks        int           // Number of accepted blocks whose indices are written in one batch (<= 1 = write every block)
+	AcceptedIndexFlushInterval      time.Duration // Maximum time accepted indices are held before being written (0 = no limit)
+	StateIntegrityCheckDepth        uint64        // Number of blocks from the head whose state is checked on startup (0 = disabled)
+	StateIntegrityCheckHalt         bool          // Whether a failed startup state integrity check prevents the chain from starting
+	SpeculativeExecutionLimit       int           // Maximum number of blocks pre-executed concurrently by Speculate (0 = disabled)
+	SnapshotCheckpointKeys          int           // Number of keys generated between snapshot generation checkpoints (0 = disabled)
+	SnapshotCheckpointInterval      time.Duration // Time between snapshot generation checkpoints (0 = disabled)
+	SnapshotRecoveryLimit           uint64        // Maximum number of blocks replayed onto the snapshot on startup before rebuilding it instead (0 = unlimited)
+	SnapshotRepairGaps              bool          // Whether to repair a snapshot persisted for another block from the trie instead of rebuilding it
+	SnapshotGenerationRateLimit     uint64        // Bytes of snapshot data generated per second (0 = unlimited)
+	BlockExecutionBudget            time.Duration // Execution time above which a block is reported as slow (0 = disabled)
+	VerificationPipelineWindow      int           // Number of blocks that may be verified ahead of committing their state, receipts and head updates (0 = disabled)
+	StatePrewarmLimit               int           // Number of most frequently accessed accounts and slots preloaded while each block is verified (0 = disabled)
 
 	SnapshotNoBuild bool // Whether the background generation is allowed
 	SnapshotWait    bool // Wait for snapshot construction on startup. TODO(karalabe): This is a dirty hack for testing, nuke it
@@ -292,12 +313,44 @@ type BlockChain struct {
 	acceptorTip     *types.Block
 	acceptorTipLock sync.Mutex
 
+	// [acceptedIndices] writes the indices of blocks processed by the
+	// acceptor, possibly batching the writes of several blocks.
+	acceptedIndices *acceptedIndexWriter
+
 	// [flattenLock] prevents the [acceptor] from flattening snapshots while
 	// a block is being verified.
 	flattenLock sync.Mutex
 
 	// [acceptedLogsCache] stores recently accepted logs to improve the performance of eth_getLogs.
 	acceptedLogsCache FIFOCache[common.Hash, [][]*types.Log]
+
+	// [pinnedContracts] maps each contract pinned with PinContract to the
+	// storage root of its pinned storage trie. [pinnedRoot] is the root of
+	// the last accepted state, and [pinnedAge] the number of blocks accepted
+	// since the pinned nodes were last refreshed. All are protected by
+	// [pinnedLock].
+	pinnedContracts map[common.Address]common.Hash
+	pinnedRoot      common.Hash
+	pinnedAge       int
+	pinnedLock      sync.Mutex
+
+	// [prewarmer] selects the state preloaded while each block is verified,
+	// nil if prewarming is disabled.
+	prewarmer *statePrewarmer
+
+	// [pipeline] commits the blocks inserted into the chain in the background,
+	// nil if VerificationPipelineWindow is 0. It is written to with [chainmu]
+	// held, and waited for before accepting, rejecting or preferring a block.
+	pipeline *commitPipeline
+
+	// [speculations] tracks the blocks being pre-executed by Speculate, by
+	// hash. It is protected by [speculationLock].
+	speculations    map[common.Hash]*speculation
+	speculationLock sync.Mutex
+
+	// [snapThrottle] limits the rate of snapshot generation. It is shared
+	// with the snapshot tree so the limit can be changed at runtime.
+	snapThrottle *snapshot.GeneratorThrottle
 }
 
 // NewBlockChain returns a fully initialised block chain using information
@@ -316,6 +369,7 @@ func NewBlockChain(
 		Journal:     cacheConfig.TrieCleanJournal,
 		Preimages:   cacheConfig.Preimages,
 		StatsPrefix: trieCleanCacheStatsNamespace,
+		PinnedCache: cacheConfig.TriePinnedLimit,
 	})
 	// Setup the genesis block, commit the provided genesis specification
 	// to database if the genesis block is not present yet, or load the
@@ -353,6 +407,11 @@ func NewBlockChain(
 		acceptorQueue:       make(chan *types.Block, cacheConfig.AcceptorQueueLimit),
 		quit:                make(chan struct{}),
 		acceptedLogsCache:   NewFIFOCache[common.Hash, [][]*types.Log](cacheConfig.AcceptedCacheSize),
+		pinnedContracts:     make(map[common.Address]common.Hash),
+		speculations:        make(map[common.Hash]*speculation),
+		acceptedIndices:     newAcceptedIndexWriter(db, cacheConfig.AcceptedIndexBatchBlocks, cacheConfig.AcceptedIndexFlushInterval),
+		snapThrottle:        snapshot.NewGeneratorThrottle(cacheConfig.SnapshotGenerationRateLimit),
+		prewarmer:           newStatePrewarmer(cacheConfig.StatePrewarmLimit),
 	}
 	bc.stateCache = state.NewDatabaseWithNodeDB(bc.db, bc.triedb)
 	bc.validator = NewBlockValidator(chainConfig, bc, engine)
@@ -384,6 +443,13 @@ func NewBlockChain(
 	// It is critical to update this vaue before performing any state repairs so
 	// that all accepted blocks can be considered.
 	bc.acceptorTip = bc.lastAccepted
+	bc.pinnedRoot = bc.lastAccepted.Root()
+
+	// Persist the acceptor tip, so that if batched accepted indices are lost
+	// in a crash, they are regenerated from this point on startup.
+	if err := rawdb.WriteAcceptorTip(bc.db, bc.acceptorTip.Hash()); err != nil {
+		return nil, err
+	}
 
 	// Make sure the state associated with the block is available
 	head := bc.CurrentBlock()
@@ -409,12 +475,42 @@ func NewBlockChain(
 		bc.initSnapshot(head)
 	}
 
+	if depth := bc.cacheConfig.StateIntegrityCheckDepth; depth > 0 {
+		if err := bc.checkStateIntegrity(depth); err != nil {
+			if bc.cacheConfig.StateIntegrityCheckHalt {
+				return nil, err
+			}
+			log.Error("Continuing despite failed state integrity check", "err", err)
+		}
+	}
+
 	// Warm up [hc.acceptedNumberCache] and [acceptedLogsCache]
 	bc.warmAcceptedCaches()
 
 	// Start processing accepted blocks effects in the background
 	go bc.startAcceptor()
 
+	// Commit the state of inserted blocks in the background
+	if window := cacheConfig.VerificationPipelineWindow; window > 0 {
+		bc.pipeline = newCommitPipeline(bc, window)
+	}
+
+	// Preload the state accessed by recent blocks in the background
+	if bc.prewarmer != nil {
+		bc.wg.Add(1)
+		go bc.prewarmLoop()
+	}
+
+	// If accepted indices are batched by time, flush them even when no new
+	// blocks are accepted.
+	if interval := bc.cacheConfig.AcceptedIndexFlushInterval; interval > 0 {
+		bc.wg.Add(1)
+		go func() {
+			defer bc.wg.Done()
+			bc.flushAcceptedIndicesPeriodically(interval)
+		}()
+	}
+
 	// If periodic cache journal is required, spin it up.
 	if bc.cacheConfig.TrieCleanRejournal > 0 && len(bc.cacheConfig.TrieCleanJournal) > 0 {
 		log.Info("Starting to save trie clean cache periodically", "journalDir", bc.cacheConfig.TrieCleanJournal, "freq", bc.cacheConfig.TrieCleanRejournal)
@@ -426,8 +522,9 @@ func NewBlockChain(
 		}()
 	}
 
-	// Start tx indexer/unindexer if required.
-	if bc.cacheConfig.TxLookupLimit != 0 {
+	// Start tx indexer/unindexer if required. This is also needed without a
+	// limit if the index was previously pruned, so that it is filled back in.
+	if bc.cacheConfig.TxLookupLimit != 0 || bc.TxIndexTail() > 0 {
 		bc.wg.Add(1)
 		go bc.dispatchTxUnindexer()
 	}
@@ -449,18 +546,39 @@ func (bc *BlockChain) unindexBlocks(tail uint64, head uint64, done chan struct{}
 	}
 }
 
+// txIndexFrom returns the number of the oldest block whose transactions
+// should be indexed when [head] is the last accepted block.
+func (bc *BlockChain) txIndexFrom(head uint64) uint64 {
+	txLookupLimit := bc.cacheConfig.TxLookupLimit
+	if txLookupLimit == 0 || head < txLookupLimit {
+		return 0
+	}
+	return head - txLookupLimit + 1
+}
+
 // dispatchTxUnindexer is responsible for the deletion of the
 // transaction index.
-// Invariant: If TxLookupLimit is 0, it means all tx indices will be preserved.
-// Meaning that this function should never be called.
+// If the index window was widened since the index was last pruned, the
+// missing range below the current tail is indexed again first. If
+// TxLookupLimit is 0, it means all tx indices will be preserved, so the
+// function returns once that is done.
 func (bc *BlockChain) dispatchTxUnindexer() {
 	defer bc.wg.Done()
 	txLookupLimit := bc.cacheConfig.TxLookupLimit
 
 	// If the user just upgraded to a new version which supports transaction
 	// index pruning, write the new tail and remove anything older.
-	if rawdb.ReadTxIndexTail(bc.db) == nil {
+	tail := rawdb.ReadTxIndexTail(bc.db)
+	if tail == nil {
 		rawdb.WriteTxIndexTail(bc.db, 0)
+	} else if from := bc.txIndexFrom(bc.lastAccepted.NumberU64()); from < *tail {
+		start := time.Now()
+		log.Info("Reindexing transactions", "from", from, "tail", *tail)
+		rawdb.IndexTransactions(bc.db, from, *tail, bc.quit)
+		txReindexTimer.Inc(time.Since(start).Milliseconds())
+	}
+	if txLookupLimit == 0 {
+		return
 	}
 
 	// Any reindexing done, start listening to chain events and moving the index window
@@ -597,8 +715,15 @@ func (bc *BlockChain) startAcceptor() {
 			log.Crit("unable to flatten snapshot from acceptor", "blockHash", next.Hash(), "err", err)
 		}
 
+		// Keep pinned contracts in memory at the new accepted state
+		bc.refreshPinnedContracts(next.Root())
+
+		// Count the state accessed by the block, to select the state
+		// preloaded for the next blocks
+		bc.prewarmer.accept(next.Hash())
+
 		// Update last processed and transaction lookup index
-		if err := bc.writeBlockAcceptedIndices(next); err != nil {
+		if err := bc.acceptedIndices.write(next); err != nil {
 			log.Crit("failed to write accepted block effects", "err", err)
 		}
 
@@ -630,6 +755,24 @@ func (bc *BlockChain) startAcceptor() {
 	}
 }
 
+// flushAcceptedIndicesPeriodically flushes batched accepted indices that have
+// been pending for at least [interval] until the blockchain is stopped.
+func (bc *BlockChain) flushAcceptedIndicesPeriodically(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := bc.acceptedIndices.flushIfDue(); err != nil {
+				log.Crit("failed to write accepted block effects", "err", err)
+			}
+		case <-bc.quit:
+			return
+		}
+	}
+}
+
 // addAcceptorQueue adds a new *types.Block to the [acceptorQueue]. This will
 // block if there are [AcceptorQueueLimit] items in [acceptorQueue].
 func (bc *BlockChain) addAcceptorQueue(b *types.Block) {
@@ -948,6 +1091,14 @@ func (bc *BlockChain) stopWithoutSaving() {
 
 	log.Info("Closing quit channel")
 	close(bc.quit)
+	bc.discardSpeculations(func(common.Hash, *speculation) bool { return true })
+	if bc.pipeline != nil {
+		log.Info("Draining verification pipeline")
+		bc.chainmu.Lock()
+		bc.pipeline.close()
+		bc.pipeline = nil
+		bc.chainmu.Unlock()
+	}
 	// Wait for accepted feed to process all remaining items
 	log.Info("Stopping Acceptor")
 	start := time.Now()
@@ -972,6 +1123,11 @@ func (bc *BlockChain) stopWithoutSaving() {
 func (bc *BlockChain) Stop() {
 	bc.stopWithoutSaving()
 
+	// Write any batched indices of accepted blocks
+	if err := bc.acceptedIndices.flush(); err != nil {
+		log.Error("Failed to flush accepted block indices", "err", err)
+	}
+
 	log.Info("Shutting down state manager")
 	start := time.Now()
 	if err := bc.stateManager.Shutdown(); err != nil {
@@ -998,6 +1154,9 @@ func (bc *BlockChain) SetPreference(block *types.Block) error {
 	bc.chainmu.Lock()
 	defer bc.chainmu.Unlock()
 
+	if err := bc.waitPipeline(); err != nil {
+		return err
+	}
 	return bc.setPreference(block)
 }
 
@@ -1059,6 +1218,10 @@ func (bc *BlockChain) Accept(block *types.Block) error {
 	bc.chainmu.Lock()
 	defer bc.chainmu.Unlock()
 
+	if err := bc.waitPipeline(); err != nil {
+		return err
+	}
+
 	// The parent of [block] must be the last accepted block.
 	if bc.lastAccepted.Hash() != block.ParentHash() {
 		return fmt.Errorf(
@@ -1083,6 +1246,7 @@ func (bc *BlockChain) Accept(block *types.Block) error {
 	// Enqueue block in the acceptor
 	bc.lastAccepted = block
 	bc.addAcceptorQueue(block)
+	bc.discardStaleSpeculations(block)
 	acceptedBlockGasUsedCounter.Inc(int64(block.GasUsed()))
 	acceptedTxsCounter.Inc(int64(len(block.Transactions())))
 	if baseFee := block.BaseFee(); baseFee != nil {
@@ -1130,6 +1294,12 @@ func (bc *BlockChain) Reject(block *types.Block) error {
 	bc.chainmu.Lock()
 	defer bc.chainmu.Unlock()
 
+	if err := bc.waitPipeline(); err != nil {
+		return err
+	}
+
+	bc.discardSpeculations(func(hash common.Hash, _ *speculation) bool { return hash == block.Hash() })
+
 	// Reject Trie
 	if err := bc.stateManager.RejectTrie(block); err != nil {
 		return fmt.Errorf("unable to reject trie: %w", err)
@@ -1154,6 +1324,10 @@ func (bc *BlockChain) Reject(block *types.Block) error {
 // writeKnownBlock updates the head block flag with a known block
 // and introduces chain reorg if necessary.
 func (bc *BlockChain) writeKnownBlock(block *types.Block) error {
+	// If [block] is an ancestor of the current head, its logs were announced
+	// when it became canonical and are not removed by rewinding to it.
+	wasCanonical := bc.GetCanonicalHash(block.NumberU64()) == block.Hash()
+
 	current := bc.CurrentBlock()
 	if block.ParentHash() != current.Hash() {
 		if err := bc.reorg(current, block); err != nil {
@@ -1161,6 +1335,16 @@ func (bc *BlockChain) writeKnownBlock(block *types.Block) error {
 		}
 	}
 	bc.writeHeadBlock(block)
+
+	// Otherwise [block] was written as a side chain block when it was
+	// inserted, so its logs have not been announced yet. Announce them now
+	// that it is canonical, after any logs removed or reborn by the reorg.
+	if wasCanonical {
+		return nil
+	}
+	if logs := bc.collectLogs(block, false); len(logs) > 0 {
+		bc.logsFeed.Send(logs)
+	}
 	return nil
 }
 
@@ -1190,7 +1374,13 @@ func (bc *BlockChain) writeBlockAndSetHead(block *types.Block, receipts []*types
 	if err := bc.writeBlockWithState(block, receipts, state); err != nil {
 		return err
 	}
+	bc.setInsertedHead(block, logs)
+	return nil
+}
 
+// setInsertedHead emits the events for the newly inserted [block], setting
+// it as the head block if it extends the current canonical chain.
+func (bc *BlockChain) setInsertedHead(block *types.Block, logs []*types.Log) {
 	// If [block] represents a new tip of the canonical chain, we optimistically add it before
 	// setPreference is called. Otherwise, we consider it a side chain block.
 	if bc.newTip(block) {
@@ -1198,8 +1388,6 @@ func (bc *BlockChain) writeBlockAndSetHead(block *types.Block, receipts []*types
 	} else {
 		bc.chainSideFeed.Send(ChainSideEvent{Block: block})
 	}
-
-	return nil
 }
 
 // writeBlockWithState writes the block and all associated state to the database,
@@ -1216,7 +1404,12 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	if err := blockBatch.Write(); err != nil {
 		log.Crit("Failed to write block into disk", "err", err)
 	}
+	return bc.commitBlockState(block, state)
+}
 
+// commitBlockState commits the state changes made by [block] to the trie
+// database, and the snapshot tree if it is enabled.
+func (bc *BlockChain) commitBlockState(block *types.Block, state *state.StateDB) error {
 	// Commit all cached state changes into underlying memory database.
 	// If snapshots are enabled, call CommitWithSnaps to explicitly create a snapshot
 	// diff layer for the block.
@@ -1280,13 +1473,18 @@ func (bc *BlockChain) InsertChain(chain types.Blocks) (int, error) {
 	// Pre-checks passed, start the full block imports
 	bc.chainmu.Lock()
 	defer bc.chainmu.Unlock()
+
+	// Every block written to the pipeline is committed before returning,
+	// including the blocks before a block that fails verification.
 	for n, block := range chain {
 		if err := bc.insertBlock(block, true); err != nil {
+			if waitErr := bc.waitPipeline(); waitErr != nil {
+				return n, waitErr
+			}
 			return n, err
 		}
 	}
-
-	return len(chain), nil
+	return len(chain), bc.waitPipeline()
 }
 
 func (bc *BlockChain) InsertBlock(block *types.Block) error {
@@ -1304,8 +1502,31 @@ func (bc *BlockChain) InsertBlockManual(block *types.Block, writes bool) error {
 	return err
 }
 
+// waitPipeline waits for the state of every block inserted into the chain to
+// be committed, if the verification pipeline is enabled.
+//
+// Assumes [bc.chainmu] is held by the caller.
+func (bc *BlockChain) waitPipeline() error {
+	if bc.pipeline == nil {
+		return nil
+	}
+	if err := bc.pipeline.wait(); err != nil {
+		return fmt.Errorf("failed to commit verified block: %w", err)
+	}
+	return nil
+}
+
+// insertBlock verifies [block] and, if [writes] is set, writes it and its
+// state. If the verification pipeline is enabled, committing the state of
+// [block], writing its receipts and updating the head of the chain is left
+// to the commit stage of the pipeline, and [block] is executed on the state
+// of its parent even if it is not committed yet.
+//
+// Assumes [bc.chainmu] is held by the caller.
 func (bc *BlockChain) insertBlock(block *types.Block, writes bool) error {
 	start := time.Now()
+	// The block is about to be executed, so its speculation is no longer useful.
+	bc.discardSpeculations(func(hash common.Hash, _ *speculation) bool { return hash == block.Hash() })
 	bc.senderCacher.Recover(types.MakeSigner(bc.chainConfig, block.Number(), block.Time()), block.Transactions())
 
 	substart := time.Now()
@@ -1364,15 +1585,25 @@ func (bc *BlockChain) insertBlock(block *types.Block, writes bool) error {
 	// entries directly from the trie (much slower).
 	bc.flattenLock.Lock()
 	defer bc.flattenLock.Unlock()
-	statedb, err := state.New(parent.Root, bc.stateCache, bc.snaps)
-	if err != nil {
-		return err
+	var statedb *state.StateDB
+	if bc.pipeline != nil {
+		statedb = bc.pipeline.parentState(block.ParentHash())
+	}
+	if statedb == nil {
+		statedb, err = state.New(parent.Root, bc.stateCache, bc.snaps)
+		if err != nil {
+			return err
+		}
+		// Enable prefetching to pull in trie node paths while processing
+		// transactions. The tries of an uncommitted parent state cannot be
+		// prefetched.
+		statedb.StartPrefetcher("chain")
+		activeState = statedb
 	}
 	blockStateInitTimer.Inc(time.Since(substart).Milliseconds())
 
-	// Enable prefetching to pull in trie node paths while processing transactions
-	statedb.StartPrefetcher("chain")
-	activeState = statedb
+	// Preload the state frequently accessed by recent blocks alongside
+	bc.prewarmState(parent.Root)
 
 	// If we have a followup block, run that against the current state to pre-cache
 	// transactions and probabilistically some of the account/storage trie nodes.
@@ -1395,6 +1626,7 @@ func (bc *BlockChain) insertBlock(block *types.Block, writes bool) error {
 		return err
 	}
 	vtime := time.Since(vstart)
+	bc.reportSlowBlock(block, ptime+vtime)
 
 	// Update the metrics touched during block processing and validation
 	accountReadTimer.Inc(statedb.AccountReads.Milliseconds())                  // Account reads are complete(in processing)
@@ -1419,21 +1651,28 @@ func (bc *BlockChain) insertBlock(block *types.Block, writes bool) error {
 	if !writes {
 		return nil
 	}
+	bc.prewarmer.record(block.Hash(), statedb)
 
 	// Write the block to the chain and get the status.
 	// writeBlockWithState (called within writeBlockAndSethead) creates a reference that
 	// will be cleaned up in Accept/Reject so we need to ensure an error cannot occur
 	// later in verification, since that would cause the referenced root to never be dereferenced.
-	wstart := time.Now()
-	if err := bc.writeBlockAndSetHead(block, receipts, logs, statedb); err != nil {
-		return err
+	// The commit stage of the pipeline updates the metrics touched during
+	// block commit once the state is committed.
+	if bc.pipeline != nil {
+		// The commit stage owns [statedb] from now on.
+		statedb.StopPrefetcher()
+		activeState = nil
+		if err := bc.pipeline.write(block, receipts, logs, statedb); err != nil {
+			return err
+		}
+	} else {
+		wstart := time.Now()
+		if err := bc.writeBlockAndSetHead(block, receipts, logs, statedb); err != nil {
+			return err
+		}
+		updateCommitTimers(statedb, time.Since(wstart))
 	}
-	// Update the metrics touched during block commit
-	accountCommitTimer.Inc(statedb.AccountCommits.Milliseconds())   // Account commits are complete, we can mark them
-	storageCommitTimer.Inc(statedb.StorageCommits.Milliseconds())   // Storage commits are complete, we can mark them
-	snapshotCommitTimer.Inc(statedb.SnapshotCommits.Milliseconds()) // Snapshot commits are complete, we can mark them
-	triedbCommitTimer.Inc(statedb.TrieDBCommits.Milliseconds())     // Trie database commits are complete, we can mark them
-	blockWriteTimer.Inc((time.Since(wstart) - statedb.AccountCommits - statedb.StorageCommits - statedb.SnapshotCommits - statedb.TrieDBCommits).Milliseconds())
 	blockInsertTimer.Inc(time.Since(start).Milliseconds())
 
 	log.Debug("Inserted new block", "number", block.Number(), "hash", block.Hash(),
@@ -1450,6 +1689,30 @@ func (bc *BlockChain) insertBlock(block *types.Block, writes bool) error {
 	return nil
 }
 
+// updateCommitTimers updates the metrics touched during the commit of
+// [statedb], which took [elapsed] along with writing its block.
+func updateCommitTimers(statedb *state.StateDB, elapsed time.Duration) {
+	accountCommitTimer.Inc(statedb.AccountCommits.Milliseconds())   // Account commits are complete, we can mark them
+	storageCommitTimer.Inc(statedb.StorageCommits.Milliseconds())   // Storage commits are complete, we can mark them
+	snapshotCommitTimer.Inc(statedb.SnapshotCommits.Milliseconds()) // Snapshot commits are complete, we can mark them
+	triedbCommitTimer.Inc(statedb.TrieDBCommits.Milliseconds())     // Trie database commits are complete, we can mark them
+	blockWriteTimer.Inc((elapsed - statedb.AccountCommits - statedb.StorageCommits - statedb.SnapshotCommits - statedb.TrieDBCommits).Milliseconds())
+}
+
+// reportSlowBlock increments the slow block counter and logs [block] if its
+// execution, which took [elapsed], exceeded [BlockExecutionBudget].
+func (bc *BlockChain) reportSlowBlock(block *types.Block, elapsed time.Duration) {
+	budget := bc.cacheConfig.BlockExecutionBudget
+	if budget <= 0 || elapsed <= budget {
+		return
+	}
+	slowBlockCounter.Inc(1)
+	log.Warn("Slow block execution", "number", block.Number(), "hash", block.Hash(),
+		"txs", len(block.Transactions()), "gas", block.GasUsed(),
+		"elapsed", common.PrettyDuration(elapsed), "budget", common.PrettyDuration(budget),
+	)
+}
+
 // collectUnflattenedLogs collects the logs that were generated or removed during
 // the processing of a block.
 func (bc *BlockChain) collectUnflattenedLogs(b *types.Block, removed bool) [][]*types.Log {
@@ -1543,6 +1806,15 @@ func (bc *BlockChain) reorg(oldHead *types.Header, newHead *types.Block) error {
 		return fmt.Errorf("cannot orphan finalized block at height: %d to common block at height: %d", bc.lastAccepted.NumberU64(), commonBlock.NumberU64())
 	}
 
+	// Refuse to switch heads if the reorg would drop more blocks than the
+	// operator is willing to tolerate. This is surfaced as an error to the
+	// consensus engine, which halts the chain until someone intervenes.
+	if maxDepth := bc.cacheConfig.MaxReorgDepth; maxDepth > 0 && uint64(len(oldChain)) > maxDepth {
+		log.Error("Refusing chain preference change exceeding max reorg depth", "number", commonBlock.Number(), "hash", commonBlock.Hash(),
+			"drop", len(oldChain), "add", len(newChain), "max", maxDepth)
+		return fmt.Errorf("%w: dropping %d blocks to common block at height %d exceeds limit of %d", ErrReorgTooDeep, len(oldChain), commonBlock.NumberU64(), maxDepth)
+	}
+
 	// Ensure the user sees large reorgs
 	if len(oldChain) > 0 && len(newChain) > 0 {
 		logFn := log.Info
@@ -1792,6 +2064,14 @@ func (bc *BlockChain) initSnapshot(b *types.Header) {
 		NoBuild:    noBuild,
 		AsyncBuild: asyncBuild,
 		SkipVerify: !bc.cacheConfig.SnapshotVerify,
+
+		VerifySampleRate: bc.cacheConfig.SnapshotVerifySampleRate,
+
+		CheckpointKeys:     bc.cacheConfig.SnapshotCheckpointKeys,
+		CheckpointInterval: bc.cacheConfig.SnapshotCheckpointInterval,
+		Throttle:           bc.snapThrottle,
+
+		RepairGaps: bc.cacheConfig.SnapshotRepairGaps,
 	}
 	var err error
 	bc.snaps, err = snapshot.New(snapconfig, bc.db, bc.triedb, b.Hash(), b.Root)
@@ -1800,6 +2080,19 @@ func (bc *BlockChain) initSnapshot(b *types.Header) {
 	}
 }
 
+// SnapshotGenerationRateLimit returns the bytes of snapshot data that may be
+// generated per second, 0 if snapshot generation is not limited.
+func (bc *BlockChain) SnapshotGenerationRateLimit() uint64 {
+	return bc.snapThrottle.Limit()
+}
+
+// SetSnapshotGenerationRateLimit changes the bytes of snapshot data that may
+// be generated per second, taking effect immediately if the snapshot is being
+// generated. 0 removes the limit.
+func (bc *BlockChain) SetSnapshotGenerationRateLimit(bytesPerSecond uint64) {
+	bc.snapThrottle.SetLimit(bytesPerSecond)
+}
+
 // reprocessState reprocesses the state up to [block], iterating through its ancestors until
 // it reaches a block with a state committed to the database. reprocessState does not use
 // snapshots since the disk layer for snapshots will most likely be above the last committed
@@ -1890,13 +2183,20 @@ func (bc *BlockChain) reprocessState(current *types.Block, reexec uint64) error
 		// Initialize snapshot if required (prevents full snapshot re-generation in
 		// the case of unclean shutdown)
 		if parent.Hash() == acceptorTip {
-			log.Info("Recovering snapshot", "hash", parent.Hash(), "index", parent.NumberU64())
 			// TODO: switch to checking the snapshot block hash markers here to ensure that when we re-process the block, we have the opportunity to apply
 			// a snapshot diff layer that we may have been in the middle of committing during shutdown. This will prevent snapshot re-generation in the case
 			// that the node stops mid-way through snapshot flattening (performed across multiple DB batches).
 			// If snapshot initialization is delayed due to state sync, skip initializing snaps here
+			// If more blocks than [SnapshotRecoveryLimit] would be replayed onto the snapshot, skip
+			// recovering it so that it is rebuilt from the trie once the state is regenerated.
 			if !bc.cacheConfig.SnapshotDelayInit {
-				bc.initSnapshot(parent.Header())
+				replay, limit := origin-parent.NumberU64(), bc.cacheConfig.SnapshotRecoveryLimit
+				if limit > 0 && replay > limit {
+					log.Info("Skipping snapshot recovery, rebuilding instead", "hash", parent.Hash(), "index", parent.NumberU64(), "replay", replay, "limit", limit)
+				} else {
+					log.Info("Recovering snapshot", "hash", parent.Hash(), "index", parent.NumberU64(), "replay", replay)
+					bc.initSnapshot(parent.Header())
+				}
 			}
 			writeIndices = true // Set [writeIndices] to true, so that the indices will be updated from the last accepted tip onwards.
 		}
@@ -2114,6 +2414,11 @@ func (bc *BlockChain) ResetToStateSyncedBlock(block *types.Block) error {
 	bc.chainmu.Lock()
 	defer bc.chainmu.Unlock()
 
+	// Write any batched indices before the acceptor tip is overwritten
+	if err := bc.acceptedIndices.flush(); err != nil {
+		return err
+	}
+
 	// Update head block and snapshot pointers on disk
 	batch := bc.db.NewBatch()
 	rawdb.WriteAcceptorTip(batch, block.Hash())
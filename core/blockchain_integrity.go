@@ -0,0 +1,62 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/luxdefi/evm/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// checkStateIntegrity verifies that the state roots of the last [depth]
+// blocks, starting at the head, match the root nodes stored in the trie
+// database, and that the snapshot of the head block (if snapshots are
+// enabled) has the same state root. The state of older blocks may have been
+// pruned, in which case those blocks are skipped.
+func (bc *BlockChain) checkStateIntegrity(depth uint64) error {
+	var (
+		start   = time.Now()
+		head    = bc.CurrentBlock()
+		checked int
+		skipped int
+	)
+	for i := uint64(0); i < depth && i <= head.Number.Uint64(); i++ {
+		header := bc.GetHeaderByNumber(head.Number.Uint64() - i)
+		if header == nil {
+			return fmt.Errorf("%w: missing header at height %d", ErrStateIntegrity, head.Number.Uint64()-i)
+		}
+		if header.Root == types.EmptyRootHash {
+			checked++
+			continue
+		}
+		if i > 0 && !bc.HasState(header.Root) {
+			skipped++
+			continue
+		}
+		blob, err := bc.triedb.Node(header.Root)
+		if err != nil {
+			return fmt.Errorf("%w: failed to read state root of block %d (%s): %w", ErrStateIntegrity, header.Number, header.Hash(), err)
+		}
+		if got := crypto.Keccak256Hash(blob); got != header.Root {
+			log.Error("State root does not match trie", "number", header.Number, "hash", header.Hash(), "root", header.Root, "trieRoot", got)
+			return fmt.Errorf("%w: block %d (%s) has state root %s, but its trie root node hashes to %s", ErrStateIntegrity, header.Number, header.Hash(), header.Root, got)
+		}
+		checked++
+	}
+	if bc.snaps != nil {
+		layers := bc.snaps.Snapshots(head.Hash(), 1, false)
+		if len(layers) == 0 {
+			return fmt.Errorf("%w: no snapshot for head block %d (%s)", ErrStateIntegrity, head.Number, head.Hash())
+		}
+		if root := layers[0].Root(); root != head.Root {
+			log.Error("Snapshot root does not match head state root", "number", head.Number, "hash", head.Hash(), "root", head.Root, "snapshotRoot", root)
+			return fmt.Errorf("%w: head block %d (%s) has state root %s, but its snapshot has root %s", ErrStateIntegrity, head.Number, head.Hash(), head.Root, root)
+		}
+	}
+	log.Info("Verified state integrity", "checked", checked, "skipped", skipped, "snapshot", bc.snaps != nil, "elapsed", time.Since(start))
+	return nil
+}
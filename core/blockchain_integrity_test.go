@@ -0,0 +1,77 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/luxdefi/evm/core/rawdb"
+	"github.com/luxdefi/evm/core/types"
+	"github.com/luxdefi/evm/params"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateIntegrityCheck(t *testing.T) {
+	var (
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		key2, _ = crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+		addr1   = crypto.PubkeyToAddress(key1.PublicKey)
+		addr2   = crypto.PubkeyToAddress(key2.PublicKey)
+		signer  = types.HomesteadSigner{}
+		chainDB = rawdb.NewMemoryDatabase()
+		gspec   = &Genesis{
+			Config: &params.ChainConfig{HomesteadBlock: new(big.Int)},
+			Alloc:  GenesisAlloc{addr1: {Balance: big.NewInt(1000000000)}},
+		}
+	)
+	cacheConfig := *archiveConfig
+	cacheConfig.StateIntegrityCheckDepth = 3
+	cacheConfig.StateIntegrityCheckHalt = true
+
+	blockchain, err := createBlockChain(chainDB, &cacheConfig, gspec, common.Hash{})
+	require.NoError(t, err)
+
+	_, chain, _, err := GenerateChainWithGenesis(gspec, blockchain.engine, 3, 10, func(i int, gen *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(addr1), addr2, big.NewInt(10000), params.TxGas, nil, nil), signer, key1)
+		gen.AddTx(tx)
+	})
+	require.NoError(t, err)
+	_, err = blockchain.InsertChain(chain)
+	require.NoError(t, err)
+	for _, block := range chain {
+		require.NoError(t, blockchain.Accept(block))
+	}
+	blockchain.DrainAcceptorQueue()
+	lastAcceptedHash := blockchain.LastConsensusAcceptedBlock().Hash()
+	blockchain.Stop()
+
+	// An intact database passes the check.
+	blockchain, err = createBlockChain(chainDB, &cacheConfig, gspec, lastAcceptedHash)
+	require.NoError(t, err)
+	genesisRoot := blockchain.Genesis().Root()
+	blockchain.Stop()
+
+	// Replace the state root node of a block below the head with a valid node
+	// from another state.
+	rawdb.WriteLegacyTrieNode(chainDB, chain[1].Root(), rawdb.ReadLegacyTrieNode(chainDB, genesisRoot))
+
+	_, err = createBlockChain(chainDB, &cacheConfig, gspec, lastAcceptedHash)
+	require.ErrorIs(t, err, ErrStateIntegrity)
+
+	// Without halting, the node starts despite the mismatch.
+	cacheConfig.StateIntegrityCheckHalt = false
+	blockchain, err = createBlockChain(chainDB, &cacheConfig, gspec, lastAcceptedHash)
+	require.NoError(t, err)
+	blockchain.Stop()
+
+	// If only the head block is checked, the mismatch is not reached.
+	cacheConfig.StateIntegrityCheckDepth = 1
+	cacheConfig.StateIntegrityCheckHalt = true
+	blockchain, err = createBlockChain(chainDB, &cacheConfig, gspec, lastAcceptedHash)
+	require.NoError(t, err)
+	blockchain.Stop()
+}
@@ -0,0 +1,183 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package core
+
+import (
+	"fmt"
+
+	"github.com/luxdefi/evm/core/types"
+	"github.com/luxdefi/evm/trie"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// pinnedRefreshBlocks is the number of accepted blocks between refreshes of
+// the pinned storage tries. Walking a storage trie costs O(storage), so it is
+// not done on every block; in between, reads of modified slots fall through
+// to the clean cache and disk.
+const pinnedRefreshBlocks = 32
+
+// nodeCollector gathers the trie nodes written by [trie.StateTrie.Prove].
+type nodeCollector map[common.Hash][]byte
+
+func (c nodeCollector) Put(key []byte, value []byte) error {
+	c[common.BytesToHash(key)] = value
+	return nil
+}
+
+func (c nodeCollector) Delete(key []byte) error {
+	delete(c, common.BytesToHash(key))
+	return nil
+}
+
+// PinContract preloads the account and storage trie nodes of [addr] at the
+// last accepted state and keeps them in memory, exempt from clean cache
+// eviction, until UnpinContract is called. The pinned nodes are refreshed every
+// [pinnedRefreshBlocks] accepted blocks. An error is returned if the pinned nodes would exceed
+// the configured memory budget.
+func (bc *BlockChain) PinContract(addr common.Address) error {
+	bc.pinnedLock.Lock()
+	defer bc.pinnedLock.Unlock()
+
+	if _, ok := bc.pinnedContracts[addr]; ok {
+		return nil
+	}
+	addrHash := crypto.Keccak256Hash(addr.Bytes())
+	storageRoot, err := bc.pinStorageTrie(bc.pinnedRoot, addr)
+	if err != nil {
+		return err
+	}
+	bc.pinnedContracts[addr] = storageRoot
+	if err := bc.pinAccountTrie(bc.pinnedRoot); err != nil {
+		delete(bc.pinnedContracts, addr)
+		bc.triedb.Unpin(addrHash)
+		return err
+	}
+	log.Info("Pinned contract storage", "address", addr, "pinned", bc.triedb.PinnedSize())
+	return nil
+}
+
+// UnpinContract releases the trie nodes pinned for [addr] and reports whether
+// it was pinned.
+func (bc *BlockChain) UnpinContract(addr common.Address) (bool, error) {
+	bc.pinnedLock.Lock()
+	defer bc.pinnedLock.Unlock()
+
+	if _, ok := bc.pinnedContracts[addr]; !ok {
+		return false, nil
+	}
+	delete(bc.pinnedContracts, addr)
+	bc.triedb.Unpin(crypto.Keccak256Hash(addr.Bytes()))
+	if err := bc.pinAccountTrie(bc.pinnedRoot); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// refreshPinnedContracts re-pins the trie nodes of all pinned contracts at
+// [root] once every [pinnedRefreshBlocks] calls. Storage tries are only walked
+// again if their root changed.
+func (bc *BlockChain) refreshPinnedContracts(root common.Hash) {
+	bc.pinnedLock.Lock()
+	defer bc.pinnedLock.Unlock()
+
+	bc.pinnedRoot = root
+	if len(bc.pinnedContracts) == 0 {
+		return
+	}
+	bc.pinnedAge++
+	if bc.pinnedAge < pinnedRefreshBlocks {
+		return
+	}
+	bc.pinnedAge = 0
+	tr, err := trie.NewStateTrie(trie.StateTrieID(root), bc.triedb)
+	if err != nil {
+		log.Warn("Failed to open state trie to refresh pinned contracts", "root", root, "err", err)
+		return
+	}
+	for addr, pinnedRoot := range bc.pinnedContracts {
+		account, err := tr.GetAccount(addr)
+		if err != nil {
+			log.Warn("Failed to read pinned contract", "address", addr, "root", root, "err", err)
+			continue
+		}
+		storageRoot := types.EmptyRootHash
+		if account != nil {
+			storageRoot = account.Root
+		}
+		if storageRoot == pinnedRoot {
+			continue
+		}
+		// If the new storage trie does not fit, the stale nodes stay
+		// pinned until the contract is unpinned or shrinks.
+		storageRoot, err = bc.pinStorageTrie(root, addr)
+		if err != nil {
+			log.Warn("Failed to refresh pinned contract storage", "address", addr, "root", root, "err", err)
+			continue
+		}
+		bc.pinnedContracts[addr] = storageRoot
+	}
+	if err := bc.pinAccountTrie(root); err != nil {
+		log.Warn("Failed to refresh pinned account trie nodes", "root", root, "err", err)
+	}
+}
+
+// pinStorageTrie pins every node of the storage trie of [addr] at [root] and
+// returns the root of the storage trie.
+// Assumes [pinnedLock] is held.
+func (bc *BlockChain) pinStorageTrie(root common.Hash, addr common.Address) (common.Hash, error) {
+	tr, err := trie.NewStateTrie(trie.StateTrieID(root), bc.triedb)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	account, err := tr.GetAccount(addr)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if account == nil {
+		return common.Hash{}, fmt.Errorf("account %s not found at root %s", addr, root)
+	}
+	addrHash := crypto.Keccak256Hash(addr.Bytes())
+	nodes := make(nodeCollector)
+	if account.Root != types.EmptyRootHash {
+		storageTrie, err := trie.NewStateTrie(trie.StorageTrieID(root, addrHash, account.Root), bc.triedb)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		it := storageTrie.NodeIterator(nil)
+		for it.Next(true) {
+			if hash := it.Hash(); hash != (common.Hash{}) {
+				nodes[hash] = it.NodeBlob()
+			}
+		}
+		if err := it.Error(); err != nil {
+			return common.Hash{}, err
+		}
+	}
+	if err := bc.triedb.Pin(addrHash, nodes); err != nil {
+		return common.Hash{}, err
+	}
+	return account.Root, nil
+}
+
+// pinAccountTrie pins the account trie nodes on the path to each pinned
+// contract at [root].
+// Assumes [pinnedLock] is held.
+func (bc *BlockChain) pinAccountTrie(root common.Hash) error {
+	nodes := make(nodeCollector)
+	if len(bc.pinnedContracts) > 0 {
+		tr, err := trie.NewStateTrie(trie.StateTrieID(root), bc.triedb)
+		if err != nil {
+			return err
+		}
+		for addr := range bc.pinnedContracts {
+			if err := tr.Prove(crypto.Keccak256(addr.Bytes()), 0, nodes); err != nil {
+				return err
+			}
+		}
+	}
+	// The account trie has the zero owner.
+	return bc.triedb.Pin(common.Hash{}, nodes)
+}
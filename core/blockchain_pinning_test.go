@@ -0,0 +1,115 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/luxdefi/evm/core/rawdb"
+	"github.com/luxdefi/evm/core/state"
+	"github.com/luxdefi/evm/core/types"
+	"github.com/luxdefi/evm/ethdb"
+	"github.com/luxdefi/evm/params"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// deleteTrieNodes removes every hash-keyed trie node from [db], so that
+// only nodes held in memory can be read.
+func deleteTrieNodes(t *testing.T, db ethdb.Database) {
+	var keys [][]byte
+	it := db.NewIterator(nil, nil)
+	for it.Next() {
+		if len(it.Key()) == common.HashLength && crypto.Keccak256Hash(it.Value()) == common.BytesToHash(it.Key()) {
+			keys = append(keys, common.CopyBytes(it.Key()))
+		}
+	}
+	it.Release()
+	require.NoError(t, it.Error())
+	for _, key := range keys {
+		rawdb.DeleteLegacyTrieNode(db, common.BytesToHash(key))
+	}
+}
+
+func TestPinContract(t *testing.T) {
+	var (
+		key1, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr1    = crypto.PubkeyToAddress(key1.PublicKey)
+		contract = common.Address{0xc0}
+		signer   = types.HomesteadSigner{}
+		storage  = make(map[common.Hash]common.Hash)
+	)
+	for i := 1; i <= 100; i++ {
+		storage[common.BigToHash(big.NewInt(int64(i)))] = common.BigToHash(big.NewInt(int64(i * 7)))
+	}
+	gspec := &Genesis{
+		Config: &params.ChainConfig{HomesteadBlock: new(big.Int)},
+		Alloc: GenesisAlloc{
+			addr1: {Balance: big.NewInt(params.Ether)},
+			// PUSH1 0x2a PUSH1 0 SSTORE STOP: stores 42 in slot 0
+			contract: {Code: common.Hex2Bytes("602a60005500"), Storage: storage, Balance: common.Big0},
+		},
+	}
+	cacheConfig := *archiveConfig
+	cacheConfig.TrieCleanLimit = 0 // Ensure reads are not served by the clean cache
+	cacheConfig.SnapshotLimit = 0
+	cacheConfig.TriePinnedLimit = 1
+
+	chainDB := rawdb.NewMemoryDatabase()
+	blockchain, err := createBlockChain(chainDB, &cacheConfig, gspec, common.Hash{})
+	require.NoError(t, err)
+	defer blockchain.Stop()
+
+	require.NoError(t, blockchain.PinContract(contract))
+	require.NotZero(t, blockchain.triedb.PinnedSize())
+
+	// Process blocks where the first modifies the pinned storage, so that the
+	// pinned nodes must be refreshed once enough blocks are accepted.
+	_, chain, _, err := GenerateChainWithGenesis(gspec, blockchain.engine, pinnedRefreshBlocks, 10, func(i int, gen *BlockGen) {
+		if i == 0 {
+			tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(addr1), contract, common.Big0, 100000, nil, nil), signer, key1)
+			gen.AddTx(tx)
+		}
+	})
+	require.NoError(t, err)
+	_, err = blockchain.InsertChain(chain)
+	require.NoError(t, err)
+	genesisStorageRoot := blockchain.pinnedContracts[contract]
+	for i, block := range chain {
+		require.NoError(t, blockchain.Accept(block))
+		blockchain.DrainAcceptorQueue()
+		if i < len(chain)-1 {
+			// The storage trie is not walked again on every block.
+			require.Equal(t, genesisStorageRoot, blockchain.pinnedContracts[contract])
+		}
+	}
+	require.NotEqual(t, genesisStorageRoot, blockchain.pinnedContracts[contract])
+	head := chain[len(chain)-1]
+
+	deleteTrieNodes(t, chainDB)
+
+	// Every read of the pinned contract must be served from memory.
+	sdb := state.NewDatabaseWithNodeDB(chainDB, blockchain.triedb)
+	statedb, err := state.New(head.Root(), sdb, nil)
+	require.NoError(t, err)
+	require.Equal(t, common.BigToHash(big.NewInt(42)), statedb.GetState(contract, common.Hash{}))
+	for slot, value := range storage {
+		require.Equal(t, value, statedb.GetState(contract, slot))
+	}
+	require.NoError(t, statedb.Error())
+
+	// Once unpinned, the nodes are gone.
+	unpinned, err := blockchain.UnpinContract(contract)
+	require.NoError(t, err)
+	require.True(t, unpinned)
+	require.Zero(t, blockchain.triedb.PinnedSize())
+	_, err = state.New(head.Root(), sdb, nil)
+	require.Error(t, err)
+
+	unpinned, err = blockchain.UnpinContract(contract)
+	require.NoError(t, err)
+	require.False(t, unpinned)
+}
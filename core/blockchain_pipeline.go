@@ -0,0 +1,202 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package core
+
+import (
+	"sync"
+	"time"
+
+	"github.com/luxdefi/evm/core/rawdb"
+	"github.com/luxdefi/evm/core/state"
+	"github.com/luxdefi/evm/core/types"
+	"github.com/luxdefi/evm/metrics"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var pipelineQueueGauge = metrics.NewRegisteredGauge("chain/pipeline/queue/size", nil)
+
+// commitPipeline overlaps the execution of the blocks inserted into the chain
+// with the commit of the blocks verified before them.
+//
+// Each block is executed and validated in order by the inserting goroutine,
+// which also writes its header and body so its children can be verified.
+// Committing its state to the trie database and the snapshot tree, writing
+// its receipts and updating the head of the chain are left to the commit
+// stage, which handles the blocks in the order they were verified, at most
+// [window] blocks behind. Until then, the children of a block are executed on
+// a copy of its uncommitted state.
+type commitPipeline struct {
+	bc      *BlockChain
+	pending chan *pipelinedBlock
+	done    chan struct{}
+
+	// inflight counts the blocks written to the pipeline and not yet committed
+	inflight sync.WaitGroup
+
+	lock   sync.Mutex
+	states map[common.Hash]*state.StateDB // uncommitted state of each pending block
+	err    error                          // first error of the commit stage
+
+	// onCommit, if set, is called by the commit stage before committing each
+	// block. It is only set by tests to hold the commit stage.
+	onCommit func(block *types.Block)
+}
+
+// pipelinedBlock is a verified block waiting for the commit stage.
+type pipelinedBlock struct {
+	block    *types.Block
+	receipts []*types.Receipt
+	logs     []*types.Log
+	state    *state.StateDB
+}
+
+// newCommitPipeline starts the commit stage of a pipeline for [bc] holding
+// up to [window] verified blocks.
+func newCommitPipeline(bc *BlockChain, window int) *commitPipeline {
+	p := &commitPipeline{
+		bc:      bc,
+		pending: make(chan *pipelinedBlock, window),
+		done:    make(chan struct{}),
+		states:  make(map[common.Hash]*state.StateDB),
+	}
+	go p.commit()
+	return p
+}
+
+// parentState returns a copy of the uncommitted state of the block [hash],
+// or nil if the state of the block is not waiting for the commit stage.
+func (p *commitPipeline) parentState(hash common.Hash) *state.StateDB {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if statedb, ok := p.states[hash]; ok {
+		return statedb.CopyForChild()
+	}
+	return nil
+}
+
+// hasState returns whether the uncommitted state of the block [hash] is
+// waiting for the commit stage.
+func (p *commitPipeline) hasState(hash common.Hash) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	_, ok := p.states[hash]
+	return ok
+}
+
+// write is used in place of writeBlockAndSetHead for blocks inserted through
+// the pipeline. It blocks if [window] blocks are waiting for the commit stage.
+//
+// Since the block and its receipts are not written atomically, a block may be
+// left without receipts on an unclean shutdown. This only affects blocks that
+// were not accepted, which are verified again after a restart.
+func (p *commitPipeline) write(block *types.Block, receipts []*types.Receipt, logs []*types.Log, statedb *state.StateDB) error {
+	blockBatch := p.bc.db.NewBatch()
+	rawdb.WriteBlock(blockBatch, block)
+	if err := blockBatch.Write(); err != nil {
+		log.Crit("Failed to write block into disk", "err", err)
+	}
+
+	// The children of [block] are executed on a copy of its state, since the
+	// commit stage consumes [statedb].
+	p.lock.Lock()
+	p.states[block.Hash()] = statedb.CopyForChild()
+	p.lock.Unlock()
+
+	p.inflight.Add(1)
+	pipelineQueueGauge.Inc(1)
+	p.pending <- &pipelinedBlock{
+		block:    block,
+		receipts: receipts,
+		logs:     logs,
+		state:    statedb,
+	}
+	return nil
+}
+
+// commit is the commit stage of the pipeline. It exits once the pipeline is
+// closed and every pending block is committed.
+//
+// The blocks written after a block whose state failed to commit are dropped,
+// and the error is returned by wait.
+func (p *commitPipeline) commit() {
+	defer close(p.done)
+
+	for next := range p.pending {
+		pipelineQueueGauge.Dec(1)
+		if p.onCommit != nil {
+			p.onCommit(next.block)
+		}
+		if err := p.commitBlock(next); err != nil {
+			log.Error("Failed to commit pipelined block", "number", next.block.Number(), "hash", next.block.Hash(), "err", err)
+			p.lock.Lock()
+			if p.err == nil {
+				p.err = err
+			}
+			p.lock.Unlock()
+		}
+		p.lock.Lock()
+		delete(p.states, next.block.Hash())
+		p.lock.Unlock()
+		p.inflight.Done()
+	}
+}
+
+// commitBlock commits the state and writes the receipts of [next], and sets
+// it as the head block if it extends the current canonical chain.
+func (p *commitPipeline) commitBlock(next *pipelinedBlock) error {
+	p.lock.Lock()
+	err := p.err
+	p.lock.Unlock()
+	if err != nil {
+		return err
+	}
+
+	wstart := time.Now()
+	if err := p.bc.commitBlockState(next.block, next.state); err != nil {
+		return err
+	}
+	batch := p.bc.db.NewBatch()
+	rawdb.WriteReceipts(batch, next.block.Hash(), next.block.NumberU64(), next.receipts)
+	rawdb.WritePreimages(batch, next.state.Preimages())
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to write block receipts into disk", "err", err)
+	}
+	updateCommitTimers(next.state, time.Since(wstart))
+
+	p.bc.setInsertedHead(next.block, next.logs)
+	return nil
+}
+
+// wait waits for the commit stage to commit every block written to the
+// pipeline, and returns the error of the first block that failed to commit.
+// Since the state of a block is committed after it is verified, the error is
+// fatal to the chain.
+//
+// Assumes [bc.chainmu] is held by the caller, so no blocks are written while
+// waiting.
+func (p *commitPipeline) wait() error {
+	p.inflight.Wait()
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.err
+}
+
+// close waits for the commit stage to commit every block written to the
+// pipeline. No blocks may be written once it is called.
+func (p *commitPipeline) close() {
+	close(p.pending)
+	<-p.done
+}
+
+// pipelineHasState returns whether the uncommitted state of the block [hash]
+// is waiting for the commit stage of the verification pipeline.
+//
+// Assumes [bc.chainmu] is held by the caller.
+func (bc *BlockChain) pipelineHasState(hash common.Hash) bool {
+	return bc.pipeline != nil && bc.pipeline.hasState(hash)
+}
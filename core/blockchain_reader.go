@@ -203,6 +203,16 @@ func (bc *BlockChain) GetCanonicalHash(number uint64) common.Hash {
 	return bc.hc.GetCanonicalHash(number)
 }
 
+// GetAcceptedHash returns the hash of the accepted block at [number] and
+// whether the block at [number] has been accepted. The returned hash is empty
+// if the block is accepted but not available locally.
+func (bc *BlockChain) GetAcceptedHash(number uint64) (common.Hash, bool) {
+	if number > bc.LastAcceptedBlock().NumberU64() {
+		return common.Hash{}, false
+	}
+	return bc.GetCanonicalHash(number), true
+}
+
 // GetTransactionLookup retrieves the lookup associate with the given transaction
 // hash from the cache or database.
 func (bc *BlockChain) GetTransactionLookup(hash common.Hash) *rawdb.LegacyTxLookupEntry {
@@ -210,6 +220,10 @@ func (bc *BlockChain) GetTransactionLookup(hash common.Hash) *rawdb.LegacyTxLook
 	if lookup, exist := bc.txLookupCache.Get(hash); exist {
 		return lookup
 	}
+	// Indices of recently accepted blocks may not have been written yet
+	if lookup := bc.acceptedIndices.lookup(hash); lookup != nil {
+		return lookup
+	}
 	tx, blockHash, blockNumber, txIndex := rawdb.ReadTransaction(bc.db, hash)
 	if tx == nil {
 		return nil
@@ -219,6 +233,22 @@ func (bc *BlockChain) GetTransactionLookup(hash common.Hash) *rawdb.LegacyTxLook
 	return lookup
 }
 
+// TxIndexTail returns the number of the oldest block whose transactions are
+// indexed. It is zero if the transaction index has never been pruned.
+func (bc *BlockChain) TxIndexTail() uint64 {
+	if tail := rawdb.ReadTxIndexTail(bc.db); tail != nil {
+		return *tail
+	}
+	return 0
+}
+
+// TxIndexPending returns whether the transactions of some of the blocks
+// within the lookup window are not indexed yet, as is the case while the
+// index is filled back in after the window was widened.
+func (bc *BlockChain) TxIndexPending() bool {
+	return bc.txIndexFrom(bc.LastAcceptedBlock().NumberU64()) < bc.TxIndexTail()
+}
+
 // HasState checks if state trie is fully present in the database or not.
 func (bc *BlockChain) HasState(hash common.Hash) bool {
 	_, err := bc.stateCache.OpenTrie(hash)
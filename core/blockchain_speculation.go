@@ -0,0 +1,113 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package core
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/luxdefi/evm/core/state"
+	"github.com/luxdefi/evm/core/types"
+	"github.com/luxdefi/evm/metrics"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var (
+	speculationStartedCounter   = metrics.NewRegisteredCounter("chain/speculation/started", nil)
+	speculationDiscardedCounter = metrics.NewRegisteredCounter("chain/speculation/discarded", nil)
+	speculationTimer            = metrics.NewRegisteredCounter("chain/speculation/executions", nil)
+)
+
+// speculation is the pre-execution of a block that has not been inserted
+// yet, running in the background.
+type speculation struct {
+	parent    common.Hash
+	number    uint64
+	interrupt atomic.Bool
+}
+
+// Speculate begins executing the transactions of [block] on top of the
+// state of its parent in the background, so that the state it reads is
+// already cached when the block is inserted. The results of the execution
+// are discarded.
+//
+// Nothing is done if speculative execution is disabled, if the limit of
+// concurrently speculated blocks is reached, if [block] is already known,
+// if the state of its parent is not available or if its header is invalid.
+func (bc *BlockChain) Speculate(block *types.Block) {
+	limit := bc.cacheConfig.SpeculativeExecutionLimit
+	if limit <= 0 || block.NumberU64() == 0 || bc.HasBlock(block.Hash(), block.NumberU64()) {
+		return
+	}
+	parent := bc.GetHeader(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil || !bc.HasState(parent.Root) {
+		return
+	}
+	// Verifying the header bounds the work done for blocks that will fail
+	// verification anyway.
+	if err := bc.engine.VerifyHeader(bc, block.Header()); err != nil {
+		return
+	}
+
+	bc.speculationLock.Lock()
+	defer bc.speculationLock.Unlock()
+
+	// [stopping] is checked while holding [speculationLock] so that no
+	// speculation is started after they have been interrupted on shutdown.
+	if bc.stopping.Load() || len(bc.speculations) >= limit {
+		return
+	}
+	if _, ok := bc.speculations[block.Hash()]; ok {
+		return
+	}
+	s := &speculation{parent: block.ParentHash(), number: block.NumberU64()}
+	bc.speculations[block.Hash()] = s
+	speculationStartedCounter.Inc(1)
+
+	bc.wg.Add(1)
+	go func() {
+		defer bc.wg.Done()
+		bc.speculate(block, parent.Root, s)
+	}()
+}
+
+func (bc *BlockChain) speculate(block *types.Block, root common.Hash, s *speculation) {
+	defer func() {
+		bc.speculationLock.Lock()
+		delete(bc.speculations, block.Hash())
+		bc.speculationLock.Unlock()
+	}()
+
+	statedb, err := state.New(root, bc.stateCache, bc.snaps)
+	if err != nil {
+		log.Debug("Failed to open state for speculative execution", "number", block.Number(), "hash", block.Hash(), "err", err)
+		return
+	}
+	start := time.Now()
+	bc.prefetcher.Prefetch(block, statedb, bc.vmConfig, &s.interrupt)
+	speculationTimer.Inc(time.Since(start).Milliseconds())
+}
+
+// discardSpeculations interrupts the speculations for which [stale] returns
+// true.
+func (bc *BlockChain) discardSpeculations(stale func(hash common.Hash, s *speculation) bool) {
+	bc.speculationLock.Lock()
+	defer bc.speculationLock.Unlock()
+
+	for hash, s := range bc.speculations {
+		if stale(hash, s) && !s.interrupt.Swap(true) {
+			speculationDiscardedCounter.Inc(1)
+		}
+	}
+}
+
+// discardStaleSpeculations interrupts the speculations of blocks that can no
+// longer be accepted once [accepted] is: blocks at or below its height and
+// blocks at the next height that are not its children.
+func (bc *BlockChain) discardStaleSpeculations(accepted *types.Block) {
+	bc.discardSpeculations(func(_ common.Hash, s *speculation) bool {
+		return s.number <= accepted.NumberU64() || (s.number == accepted.NumberU64()+1 && s.parent != accepted.Hash())
+	})
+}
@@ -0,0 +1,164 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package core
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/luxdefi/evm/consensus/dummy"
+	"github.com/luxdefi/evm/core/rawdb"
+	"github.com/luxdefi/evm/core/types"
+	"github.com/luxdefi/evm/params"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/require"
+)
+
+// waitForSpeculations blocks until [bc] has no speculation in progress.
+func waitForSpeculations(bc *BlockChain) {
+	for {
+		bc.speculationLock.Lock()
+		n := len(bc.speculations)
+		bc.speculationLock.Unlock()
+		if n == 0 {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestDiscardStaleSpeculations(t *testing.T) {
+	bc := &BlockChain{speculations: make(map[common.Hash]*speculation)}
+	accepted := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(10)})
+
+	var (
+		sibling  = &speculation{parent: common.Hash{0x01}, number: 10}
+		child    = &speculation{parent: accepted.Hash(), number: 11}
+		orphan   = &speculation{parent: common.Hash{0x02}, number: 11}
+		upcoming = &speculation{parent: common.Hash{0x03}, number: 12}
+	)
+	bc.speculations[common.Hash{0x0a}] = sibling
+	bc.speculations[common.Hash{0x0b}] = child
+	bc.speculations[common.Hash{0x0c}] = orphan
+	bc.speculations[common.Hash{0x0d}] = upcoming
+
+	bc.discardStaleSpeculations(accepted)
+	require.True(t, sibling.interrupt.Load())
+	require.False(t, child.interrupt.Load())
+	require.True(t, orphan.interrupt.Load())
+	require.False(t, upcoming.interrupt.Load())
+}
+
+func TestSpeculate(t *testing.T) {
+	var (
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr1   = crypto.PubkeyToAddress(key1.PublicKey)
+		addr2   = common.Address{0x02}
+		signer  = types.HomesteadSigner{}
+		gspec   = &Genesis{
+			Config: &params.ChainConfig{HomesteadBlock: new(big.Int)},
+			Alloc:  GenesisAlloc{addr1: {Balance: big.NewInt(params.Ether)}},
+		}
+	)
+	cacheConfig := *archiveConfig
+	cacheConfig.SpeculativeExecutionLimit = 1
+
+	blockchain, err := createBlockChain(rawdb.NewMemoryDatabase(), &cacheConfig, gspec, common.Hash{})
+	require.NoError(t, err)
+	defer blockchain.Stop()
+
+	_, chain, _, err := GenerateChainWithGenesis(gspec, blockchain.engine, 2, 10, func(i int, gen *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(addr1), addr2, big.NewInt(1), params.TxGas, nil, nil), signer, key1)
+		gen.AddTx(tx)
+	})
+	require.NoError(t, err)
+
+	// The second block cannot be speculated until its parent is inserted.
+	blockchain.Speculate(chain[1])
+	require.Empty(t, blockchain.speculations)
+
+	// Speculation does not modify the chain.
+	blockchain.Speculate(chain[0])
+	waitForSpeculations(blockchain)
+	require.Equal(t, gspec.ToBlock().Hash(), blockchain.CurrentBlock().Hash())
+
+	_, err = blockchain.InsertChain(chain)
+	require.NoError(t, err)
+	state, err := blockchain.State()
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(2), state.GetBalance(addr2))
+}
+
+// BenchmarkSpeculativeExecution measures the time to insert a block with and
+// without speculatively executing it first.
+func BenchmarkSpeculativeExecution(b *testing.B) {
+	for _, limit := range []int{0, 1} {
+		b.Run(fmt.Sprintf("limit=%d", limit), func(b *testing.B) {
+			benchmarkSpeculativeExecution(b, limit)
+		})
+	}
+}
+
+func benchmarkSpeculativeExecution(b *testing.B, limit int) {
+	const numTxs = 100
+	var (
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr1   = crypto.PubkeyToAddress(key1.PublicKey)
+		signer  = types.HomesteadSigner{}
+		alloc   = GenesisAlloc{addr1: {Balance: big.NewInt(params.Ether)}}
+	)
+	recipients := make([]common.Address, numTxs)
+	for i := range recipients {
+		recipients[i] = common.BigToAddress(big.NewInt(int64(i + 1)))
+		alloc[recipients[i]] = GenesisAccount{Balance: big.NewInt(1)}
+	}
+	gspec := &Genesis{
+		Config: &params.ChainConfig{HomesteadBlock: new(big.Int)},
+		Alloc:  alloc,
+	}
+	_, chain, _, err := GenerateChainWithGenesis(gspec, dummy.NewCoinbaseFaker(), 1, 10, func(i int, gen *BlockGen) {
+		for _, to := range recipients {
+			tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(addr1), to, big.NewInt(1), params.TxGas, nil, nil), signer, key1)
+			gen.AddTx(tx)
+		}
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	// Blocks are decoded on every iteration so that transaction senders
+	// are not cached, as is the case for blocks received from the network.
+	encoded, err := rlp.EncodeToBytes(chain[0])
+	if err != nil {
+		b.Fatal(err)
+	}
+	cacheConfig := *archiveConfig
+	cacheConfig.SpeculativeExecutionLimit = limit
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		blockchain, err := createBlockChain(rawdb.NewMemoryDatabase(), &cacheConfig, gspec, common.Hash{})
+		if err != nil {
+			b.Fatal(err)
+		}
+		block := new(types.Block)
+		if err := rlp.DecodeBytes(encoded, block); err != nil {
+			b.Fatal(err)
+		}
+		blockchain.Speculate(block)
+		waitForSpeculations(blockchain)
+		b.StartTimer()
+
+		if err := blockchain.InsertBlock(block); err != nil {
+			b.Fatal(err)
+		}
+
+		b.StopTimer()
+		blockchain.Stop()
+	}
+}
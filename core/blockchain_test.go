@@ -10,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/luxdefi/node/utils/timer/mockable"
 	"github.com/luxdefi/evm/consensus/dummy"
 	"github.com/luxdefi/evm/core/rawdb"
 	"github.com/luxdefi/evm/core/state"
@@ -18,7 +19,11 @@ import (
 	"github.com/luxdefi/evm/core/vm"
 	"github.com/luxdefi/evm/eth/tracers/logger"
 	"github.com/luxdefi/evm/ethdb"
+	"github.com/luxdefi/evm/metrics"
 	"github.com/luxdefi/evm/params"
+	"github.com/luxdefi/evm/precompile/contracts/blockhashes"
+	"github.com/luxdefi/evm/trie"
+	"github.com/luxdefi/evm/utils"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/fsnotify/fsnotify"
@@ -743,6 +748,68 @@ func TestTransactionIndices(t *testing.T) {
 	}
 }
 
+// TestTxIndexWidenedWindow tests that raising the tx lookup limit after the
+// index was pruned only reindexes the blocks missing from the new window.
+func TestTxIndexWidenedWindow(t *testing.T) {
+	require := require.New(t)
+	var (
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr1   = crypto.PubkeyToAddress(key1.PublicKey)
+		addr2   = common.Address{0x02}
+		gspec   = &Genesis{
+			Config: &params.ChainConfig{HomesteadBlock: new(big.Int)},
+			Alloc:  GenesisAlloc{addr1: {Balance: big.NewInt(params.Ether)}},
+		}
+		signer = types.HomesteadSigner{}
+	)
+	_, blocks, _, err := GenerateChainWithGenesis(gspec, dummy.NewFaker(), 10, 10, func(i int, block *BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(addr1), addr2, big.NewInt(10000), params.TxGas, nil, nil), signer, key1)
+		require.NoError(err)
+		block.AddTx(tx)
+	})
+	require.NoError(err)
+
+	chainDB := rawdb.NewMemoryDatabase()
+	chain, err := createBlockChain(chainDB, pruningConfig, gspec, common.Hash{})
+	require.NoError(err)
+	_, err = chain.InsertChain(blocks)
+	require.NoError(err)
+	for _, block := range blocks {
+		require.NoError(chain.Accept(block))
+	}
+	chain.DrainAcceptorQueue()
+	chain.Stop()
+	lastAcceptedHash := chain.CurrentHeader().Hash()
+
+	// Prune the index down to blocks [8, 10].
+	rawdb.UnindexTransactions(chainDB, 0, 8, nil)
+	require.EqualValues(8, *rawdb.ReadTxIndexTail(chainDB))
+	require.True(chain.TxIndexPending())
+
+	// Widening the window to 5 blocks indexes [6, 8) again.
+	conf := *pruningConfig
+	conf.TxLookupLimit = 5
+	chain, err = createBlockChain(chainDB, &conf, gspec, lastAcceptedHash)
+	require.NoError(err)
+	defer chain.Stop()
+	require.Eventually(func() bool { return chain.TxIndexTail() == 6 }, 5*time.Second, 10*time.Millisecond)
+	// Once the window is indexed, unknown transactions are no longer reported
+	// as possibly not indexed.
+	require.False(chain.TxIndexPending())
+
+	for _, block := range blocks {
+		for _, tx := range block.Transactions() {
+			lookup := chain.GetTransactionLookup(tx.Hash())
+			if block.NumberU64() >= 6 {
+				require.NotNil(lookup, "block %d", block.NumberU64())
+				require.Equal(block.Hash(), lookup.BlockHash)
+			} else {
+				require.Nil(lookup, "block %d", block.NumberU64())
+			}
+		}
+	}
+}
+
 // TestCanonicalHashMarker tests all the canonical hash markers are updated/deleted
 // correctly in case reorg is called.
 func TestCanonicalHashMarker(t *testing.T) {
@@ -1172,3 +1239,506 @@ func TestEIP3651(t *testing.T) {
 		t.Fatalf("sender balance incorrect: expected %d, got %d", expected, actual)
 	}
 }
+
+func TestMaxReorgDepth(t *testing.T) {
+	var (
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		key2, _ = crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+		addr1   = crypto.PubkeyToAddress(key1.PublicKey)
+		addr2   = crypto.PubkeyToAddress(key2.PublicKey)
+		signer  = types.HomesteadSigner{}
+		gspec   = &Genesis{
+			Config: &params.ChainConfig{HomesteadBlock: new(big.Int)},
+			Alloc:  GenesisAlloc{addr1: {Balance: big.NewInt(1000000000)}},
+		}
+	)
+	cacheConfig := *pruningConfig
+	cacheConfig.MaxReorgDepth = 2
+
+	blockchain, err := createBlockChain(rawdb.NewMemoryDatabase(), &cacheConfig, gspec, common.Hash{})
+	require.NoError(t, err)
+	defer blockchain.Stop()
+
+	transfer := func(amount int64) func(int, *BlockGen) {
+		return func(i int, gen *BlockGen) {
+			tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(addr1), addr2, big.NewInt(amount), params.TxGas, nil, nil), signer, key1)
+			gen.AddTx(tx)
+		}
+	}
+	genDB, chain1, _, err := GenerateChainWithGenesis(gspec, blockchain.engine, 3, 10, transfer(10000))
+	require.NoError(t, err)
+	// Fork off of the first block in [chain1], so that preferring the tip of
+	// [chain2] drops 2 blocks and switching back drops 3.
+	chain2, _, err := GenerateChain(gspec.Config, chain1[0], blockchain.engine, genDB, 3, 10, transfer(20000))
+	require.NoError(t, err)
+
+	_, err = blockchain.InsertChain(chain1)
+	require.NoError(t, err)
+	_, err = blockchain.InsertChain(chain2)
+	require.NoError(t, err)
+	require.Equal(t, chain1[2].Hash(), blockchain.CurrentBlock().Hash())
+
+	// A reorg at the configured depth is allowed.
+	require.NoError(t, blockchain.SetPreference(chain2[2]))
+	require.Equal(t, chain2[2].Hash(), blockchain.CurrentBlock().Hash())
+	require.NoError(t, blockchain.ValidateCanonicalChain())
+
+	// A reorg one block deeper than the limit is refused and the head is kept.
+	err = blockchain.SetPreference(chain1[2])
+	require.ErrorIs(t, err, ErrReorgTooDeep)
+	require.Equal(t, chain2[2].Hash(), blockchain.CurrentBlock().Hash())
+	require.NoError(t, blockchain.ValidateCanonicalChain())
+}
+
+func TestReorgLogNotifications(t *testing.T) {
+	var (
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr1   = crypto.PubkeyToAddress(key1.PublicKey)
+		addr2   = common.Address{0x02}
+		signer  = types.HomesteadSigner{}
+		gspec   = &Genesis{
+			Config: &params.ChainConfig{HomesteadBlock: new(big.Int)},
+			Alloc:  GenesisAlloc{addr1: {Balance: big.NewInt(params.Ether)}},
+		}
+		// PUSH1 0 PUSH1 0 LOG0: init code that emits an empty log
+		logCode = common.Hex2Bytes("60006000a0")
+	)
+	blockchain, err := createBlockChain(rawdb.NewMemoryDatabase(), pruningConfig, gspec, common.Hash{})
+	require.NoError(t, err)
+	defer blockchain.Stop()
+
+	emitLog := func(gen *BlockGen) {
+		tx, _ := types.SignTx(types.NewContractCreation(gen.TxNonce(addr1), new(big.Int), 100000, nil, logCode), signer, key1)
+		gen.AddTx(tx)
+	}
+	genDB, chain1, _, err := GenerateChainWithGenesis(gspec, blockchain.engine, 1, 10, func(i int, gen *BlockGen) {
+		emitLog(gen)
+	})
+	require.NoError(t, err)
+	chain2, _, err := GenerateChain(gspec.Config, blockchain.Genesis(), blockchain.engine, genDB, 2, 10, func(i int, gen *BlockGen) {
+		if i == 0 {
+			tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(addr1), addr2, big.NewInt(10000), params.TxGas, nil, nil), signer, key1)
+			gen.AddTx(tx)
+			return
+		}
+		emitLog(gen)
+	})
+	require.NoError(t, err)
+
+	logsCh := make(chan []*types.Log, 10)
+	rmLogsCh := make(chan RemovedLogsEvent, 10)
+	defer blockchain.SubscribeLogsEvent(logsCh).Unsubscribe()
+	defer blockchain.SubscribeRemovedLogsEvent(rmLogsCh).Unsubscribe()
+
+	// Inserting [chain1] extends the canonical chain and announces its log.
+	_, err = blockchain.InsertChain(chain1)
+	require.NoError(t, err)
+	logs := <-logsCh
+	require.Len(t, logs, 1)
+	require.Equal(t, chain1[0].Hash(), logs[0].BlockHash)
+	require.False(t, logs[0].Removed)
+
+	// [chain2] is a side chain, so none of its logs are announced yet.
+	_, err = blockchain.InsertChain(chain2)
+	require.NoError(t, err)
+	require.Empty(t, logsCh)
+
+	// Preferring [chain2] first re-emits the orphaned log as removed, then
+	// announces the log of the new canonical head.
+	require.NoError(t, blockchain.SetPreference(chain2[1]))
+	removed := <-rmLogsCh
+	require.Len(t, removed.Logs, 1)
+	require.Equal(t, chain1[0].Hash(), removed.Logs[0].BlockHash)
+	require.True(t, removed.Logs[0].Removed)
+
+	logs = <-logsCh
+	require.Len(t, logs, 1)
+	require.Equal(t, chain2[1].Hash(), logs[0].BlockHash)
+	require.False(t, logs[0].Removed)
+	require.Empty(t, logsCh)
+	require.Empty(t, rmLogsCh)
+}
+
+func TestRewindLogNotifications(t *testing.T) {
+	var (
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr1   = crypto.PubkeyToAddress(key1.PublicKey)
+		signer  = types.HomesteadSigner{}
+		gspec   = &Genesis{
+			Config: &params.ChainConfig{HomesteadBlock: new(big.Int)},
+			Alloc:  GenesisAlloc{addr1: {Balance: big.NewInt(params.Ether)}},
+		}
+		// PUSH1 0 PUSH1 0 LOG0: init code that emits an empty log
+		logCode = common.Hex2Bytes("60006000a0")
+	)
+	blockchain, err := createBlockChain(rawdb.NewMemoryDatabase(), pruningConfig, gspec, common.Hash{})
+	require.NoError(t, err)
+	defer blockchain.Stop()
+
+	_, chain, _, err := GenerateChainWithGenesis(gspec, blockchain.engine, 2, 10, func(i int, gen *BlockGen) {
+		tx, _ := types.SignTx(types.NewContractCreation(gen.TxNonce(addr1), new(big.Int), 100000, nil, logCode), signer, key1)
+		gen.AddTx(tx)
+	})
+	require.NoError(t, err)
+
+	logsCh := make(chan []*types.Log, 10)
+	rmLogsCh := make(chan RemovedLogsEvent, 10)
+	defer blockchain.SubscribeLogsEvent(logsCh).Unsubscribe()
+	defer blockchain.SubscribeRemovedLogsEvent(rmLogsCh).Unsubscribe()
+
+	_, err = blockchain.InsertChain(chain)
+	require.NoError(t, err)
+	for _, block := range chain {
+		logs := <-logsCh
+		require.Len(t, logs, 1)
+		require.Equal(t, block.Hash(), logs[0].BlockHash)
+	}
+
+	// Rewinding to an ancestor removes the logs of the blocks after it, but
+	// does not announce the logs of the ancestor again.
+	require.NoError(t, blockchain.SetPreference(chain[0]))
+	removed := <-rmLogsCh
+	require.Len(t, removed.Logs, 1)
+	require.Equal(t, chain[1].Hash(), removed.Logs[0].BlockHash)
+	require.Empty(t, logsCh)
+
+	// Preferring the removed block again announces its logs again.
+	require.NoError(t, blockchain.SetPreference(chain[1]))
+	logs := <-logsCh
+	require.Len(t, logs, 1)
+	require.Equal(t, chain[1].Hash(), logs[0].BlockHash)
+	require.Empty(t, logsCh)
+	require.Empty(t, rmLogsCh)
+}
+
+func TestSnapshotRecoveryLimit(t *testing.T) {
+	var (
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		key2, _ = crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+		addr1   = crypto.PubkeyToAddress(key1.PublicKey)
+		addr2   = crypto.PubkeyToAddress(key2.PublicKey)
+		// sentinel is an account absent from the state, which survives only
+		// if the snapshot is recovered rather than rebuilt.
+		sentinel = common.Hash{0xff}
+	)
+	gspec := &Genesis{
+		Config: &params.ChainConfig{HomesteadBlock: new(big.Int), FeeConfig: params.DefaultFeeConfig},
+		Alloc:  GenesisAlloc{addr1: {Balance: big.NewInt(1000000)}},
+	}
+	signer := types.HomesteadSigner{}
+
+	for name, test := range map[string]struct {
+		limit   uint64
+		rebuilt bool
+	}{
+		"unlimited":     {limit: 0, rebuilt: false},
+		"within limit":  {limit: 5, rebuilt: false},
+		"exceeds limit": {limit: 4, rebuilt: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			require := require.New(t)
+			chainDB := rawdb.NewMemoryDatabase()
+
+			blockchain, err := createBlockChain(chainDB, pruningConfig, gspec, common.Hash{})
+			require.NoError(err)
+			// Don't commit the last accepted trie on shutdown, to force
+			// reprocessing on restart.
+			blockchain.stateManager = &wrappedStateManager{TrieWriter: blockchain.stateManager}
+
+			_, chain, _, err := GenerateChainWithGenesis(gspec, blockchain.engine, 10, 10, func(i int, gen *BlockGen) {
+				tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(addr1), addr2, big.NewInt(10000), params.TxGas, nil, nil), signer, key1)
+				gen.AddTx(tx)
+			})
+			require.NoError(err)
+			_, err = blockchain.InsertChain(chain)
+			require.NoError(err)
+			for _, block := range chain {
+				require.NoError(blockchain.Accept(block))
+			}
+			blockchain.DrainAcceptorQueue()
+			blockchain.Stop()
+
+			// Simulate an unclean shutdown after the acceptor and snapshot
+			// processed block 5, leaving 5 blocks to replay on startup.
+			tip := chain[4]
+			require.NoError(rawdb.WriteAcceptorTip(chainDB, tip.Hash()))
+			rawdb.WriteSnapshotBlockHash(chainDB, tip.Hash())
+			rawdb.WriteSnapshotRoot(chainDB, tip.Root())
+			rawdb.WriteAccountSnapshot(chainDB, sentinel, []byte{0x01})
+
+			config := *pruningConfig
+			config.SnapshotWait = true
+			config.SnapshotRecoveryLimit = test.limit
+			head := chain[len(chain)-1]
+			blockchain, err = createBlockChain(chainDB, &config, gspec, head.Hash())
+			require.NoError(err)
+			defer blockchain.Stop()
+
+			require.NotNil(blockchain.Snapshots().Snapshot(head.Root()))
+			require.Equal(test.rebuilt, len(rawdb.ReadAccountSnapshot(chainDB, sentinel)) == 0)
+		})
+	}
+}
+
+func (p *slowProcessor) Process(block *types.Block, parent *types.Header, statedb *state.StateDB, cfg vm.Config) (types.Receipts, []*types.Log, uint64, error) {
+	if _, ok := p.slow[block.Hash()]; ok {
+		time.Sleep(p.delay)
+	}
+	return p.Processor.Process(block, parent, statedb, cfg)
+}
+
+func TestBlockExecutionBudget(t *testing.T) {
+	require := require.New(t)
+
+	// Count slow blocks even if metrics are disabled.
+	defer func(counter metrics.Counter) { slowBlockCounter = counter }(slowBlockCounter)
+	slowBlockCounter = metrics.NewCounterForced()
+
+	var (
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr1   = crypto.PubkeyToAddress(key1.PublicKey)
+		addr2   = common.Address{0x02}
+		gspec   = &Genesis{
+			Config: &params.ChainConfig{HomesteadBlock: new(big.Int), FeeConfig: params.DefaultFeeConfig},
+			Alloc:  GenesisAlloc{addr1: {Balance: big.NewInt(1000000)}},
+		}
+		signer = types.HomesteadSigner{}
+	)
+	config := *archiveConfig
+	config.BlockExecutionBudget = 100 * time.Millisecond
+	blockchain, err := createBlockChain(rawdb.NewMemoryDatabase(), &config, gspec, common.Hash{})
+	require.NoError(err)
+	defer blockchain.Stop()
+
+	_, chain, _, err := GenerateChainWithGenesis(gspec, blockchain.engine, 3, 10, func(i int, gen *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(addr1), addr2, big.NewInt(10000), params.TxGas, nil, nil), signer, key1)
+		gen.AddTx(tx)
+	})
+	require.NoError(err)
+
+	// Only the block whose execution exceeds the budget is reported.
+	blockchain.processor = &slowProcessor{
+		Processor: blockchain.processor,
+		slow:      map[common.Hash]struct{}{chain[1].Hash(): {}},
+		delay:     2 * config.BlockExecutionBudget,
+	}
+	_, err = blockchain.InsertChain(chain)
+	require.NoError(err)
+	require.Equal(int64(1), slowBlockCounter.Count())
+}
+
+func TestRewardDistributor(t *testing.T) {
+	require := require.New(t)
+
+	var (
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr1   = crypto.PubkeyToAddress(key1.PublicKey)
+		addr2   = common.Address{0x02}
+		mode    = dummy.Mode{ModeSkipCoinbase: true}
+		burning = dummy.NewEngine(&mockable.Clock{}, mode, dummy.NewBaseFeeBurner(50))
+		plain   = dummy.NewEngine(&mockable.Clock{}, mode, nil)
+	)
+	newGenesis := func(rewardDistributionTimestamp *uint64) *Genesis {
+		config := *params.TestChainConfig
+		config.RewardDistributionTimestamp = rewardDistributionTimestamp
+		return &Genesis{
+			Config: &config,
+			Alloc:  GenesisAlloc{addr1: {Balance: big.NewInt(params.Ether)}},
+		}
+	}
+	generate := func(gspec *Genesis, engine *dummy.DummyEngine) []*types.Block {
+		signer := types.LatestSigner(gspec.Config)
+		_, chain, _, err := GenerateChainWithGenesis(gspec, engine, 3, 10, func(i int, gen *BlockGen) {
+			// Pay a tip equal to the base fee
+			gasPrice := new(big.Int).Mul(gen.BaseFee(), big.NewInt(2))
+			tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(addr1), addr2, big.NewInt(10000), params.TxGas, gasPrice, nil), signer, key1)
+			gen.AddTx(tx)
+		})
+		require.NoError(err)
+		return chain
+	}
+
+	// Before the upgrade the distributor is not called
+	gspec := newGenesis(nil)
+	require.Equal(generate(gspec, plain)[2].Hash(), generate(gspec, burning)[2].Hash())
+
+	// After the upgrade half of the base fees are burned
+	gspec = newGenesis(utils.NewUint64(0))
+	chain := generate(gspec, burning)
+	blockchain, err := NewBlockChain(rawdb.NewMemoryDatabase(), DefaultCacheConfig, gspec, burning, vm.Config{}, common.Hash{}, false)
+	require.NoError(err)
+	defer blockchain.Stop()
+	_, err = blockchain.InsertChain(chain)
+	require.NoError(err)
+
+	expected := new(big.Int)
+	for _, block := range chain {
+		gasUsed := new(big.Int).SetUint64(block.GasUsed())
+		baseFees := new(big.Int).Mul(block.BaseFee(), gasUsed)
+		// the coinbase is paid twice the base fee, and half the base fee is burned
+		expected.Add(expected, new(big.Int).Mul(baseFees, big.NewInt(2)))
+		expected.Sub(expected, new(big.Int).Rsh(baseFees, 1))
+	}
+	statedb, err := blockchain.StateAt(chain[len(chain)-1].Root())
+	require.NoError(err)
+	require.Equal(expected, statedb.GetBalance(chain[0].Coinbase()))
+
+	// Distributing rewards is consensus critical, so blocks built without
+	// burning the base fee are rejected
+	blockchain, err = NewBlockChain(rawdb.NewMemoryDatabase(), DefaultCacheConfig, gspec, burning, vm.Config{}, common.Hash{}, false)
+	require.NoError(err)
+	defer blockchain.Stop()
+	_, err = blockchain.InsertChain(generate(gspec, plain))
+	require.Error(err)
+}
+
+func TestHistoricalBlockHash(t *testing.T) {
+	require := require.New(t)
+
+	config := *params.TestChainConfig
+	config.GenesisPrecompiles = params.Precompiles{
+		blockhashes.ConfigKey: blockhashes.NewConfig(utils.NewUint64(0)),
+	}
+	gspec := &Genesis{Config: &config}
+	_, chain, _, err := GenerateChainWithGenesis(gspec, dummy.NewCoinbaseFaker(), 300, 10, func(int, *BlockGen) {})
+	require.NoError(err)
+
+	blockchain, err := NewBlockChain(rawdb.NewMemoryDatabase(), DefaultCacheConfig, gspec, dummy.NewCoinbaseFaker(), vm.Config{}, common.Hash{}, false)
+	require.NoError(err)
+	defer blockchain.Stop()
+	_, err = blockchain.InsertChain(chain)
+	require.NoError(err)
+	// Accept all but the last 10 blocks, so hashes are read both from accepted
+	// blocks and by walking back from processing ones
+	for _, block := range chain[:len(chain)-10] {
+		require.NoError(blockchain.Accept(block))
+	}
+	blockchain.DrainAcceptorQueue()
+
+	head := chain[len(chain)-1].Header()
+	statedb, err := blockchain.StateAt(head.Root)
+	require.NoError(err)
+	evm := vm.NewEVM(NewEVMBlockContext(head, blockchain, nil), vm.TxContext{}, statedb, &config, vm.Config{})
+
+	getBlockHash := func(number uint64) common.Hash {
+		input, err := blockhashes.PackGetBlockHash(new(big.Int).SetUint64(number))
+		require.NoError(err)
+		ret, leftOverGas, err := evm.StaticCall(vm.AccountRef(common.Address{}), blockhashes.ContractAddress, input, blockhashes.GetBlockHashGasCost)
+		require.NoError(err)
+		require.Zero(leftOverGas)
+		return common.BytesToHash(ret)
+	}
+
+	// More than 256 blocks back: out of reach of BLOCKHASH
+	require.Equal(chain[0].Hash(), getBlockHash(1))
+	require.Equal(blockchain.Genesis().Hash(), getBlockHash(0))
+	require.Equal(common.Hash{}, evm.Context.GetHash(1))
+	// Processing ancestors
+	require.Equal(chain[len(chain)-5].Hash(), getBlockHash(head.Number.Uint64()-4))
+	// The current and future blocks are not available
+	require.Equal(common.Hash{}, getBlockHash(head.Number.Uint64()))
+	require.Equal(common.Hash{}, getBlockHash(head.Number.Uint64()+1))
+}
+
+func TestVerificationPipeline(t *testing.T) {
+	require := require.New(t)
+
+	var (
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr1   = crypto.PubkeyToAddress(key1.PublicKey)
+		gspec   = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  GenesisAlloc{addr1: {Balance: big.NewInt(params.Ether)}},
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	_, chain, _, err := GenerateChainWithGenesis(gspec, dummy.NewCoinbaseFaker(), 20, 10, func(i int, gen *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(addr1), common.Address{byte(i)}, big.NewInt(10000), params.TxGas, gen.BaseFee(), nil), signer, key1)
+		gen.AddTx(tx)
+	})
+	require.NoError(err)
+
+	newBlockChain := func(window int) *BlockChain {
+		cacheConfig := *DefaultCacheConfig
+		cacheConfig.VerificationPipelineWindow = window
+		blockchain, err := NewBlockChain(rawdb.NewMemoryDatabase(), &cacheConfig, gspec, dummy.NewCoinbaseFaker(), vm.Config{}, common.Hash{}, false)
+		require.NoError(err)
+		t.Cleanup(blockchain.Stop)
+		return blockchain
+	}
+
+	// Blocks inserted through the pipeline are written exactly as the blocks
+	// inserted one at a time, and the head events are sent in order
+	sequential := newBlockChain(0)
+	_, err = sequential.InsertChain(chain)
+	require.NoError(err)
+
+	pipelined := newBlockChain(4)
+	heads := make(chan ChainHeadEvent, len(chain))
+	sub := pipelined.SubscribeChainHeadEvent(heads)
+	defer sub.Unsubscribe()
+	_, err = pipelined.InsertChain(chain)
+	require.NoError(err)
+
+	require.Equal(sequential.CurrentBlock().Hash(), pipelined.CurrentBlock().Hash())
+	for _, block := range chain {
+		require.Equal(block.Hash(), pipelined.GetCanonicalHash(block.NumberU64()))
+		require.Equal(block.Hash(), (<-heads).Block.Hash())
+
+		expected := sequential.GetReceiptsByHash(block.Hash())
+		receipts := pipelined.GetReceiptsByHash(block.Hash())
+		require.Len(receipts, len(block.Transactions()))
+		require.Equal(types.DeriveSha(expected, trie.NewStackTrie(nil)), types.DeriveSha(receipts, trie.NewStackTrie(nil)))
+		require.True(pipelined.HasState(block.Root()))
+	}
+
+	// Blocks verified one at a time, as the VM does, are executed on the
+	// uncommitted state of their parent and committed before being accepted
+	pipelined = newBlockChain(4)
+	for _, block := range chain {
+		require.NoError(pipelined.InsertBlock(block))
+	}
+	for _, block := range chain {
+		require.NoError(pipelined.Accept(block))
+	}
+	pipelined.DrainAcceptorQueue()
+	require.Equal(chain[len(chain)-1].Hash(), pipelined.LastAcceptedBlock().Hash())
+	for _, block := range chain {
+		require.True(pipelined.HasState(block.Root()))
+		require.Len(pipelined.GetReceiptsByHash(block.Hash()), len(block.Transactions()))
+	}
+
+	// A block whose parent is still waiting for the commit stage is executed
+	// on the uncommitted state of its parent
+	pipelined = newBlockChain(4)
+	var (
+		committing = make(chan struct{}, len(chain))
+		release    = make(chan struct{})
+	)
+	pipelined.pipeline.onCommit = func(*types.Block) {
+		committing <- struct{}{}
+		<-release
+	}
+	require.NoError(pipelined.InsertBlock(chain[0]))
+	<-committing
+	require.False(pipelined.HasState(chain[0].Root()))
+	require.NoError(pipelined.InsertBlock(chain[1]))
+	close(release)
+	require.NoError(pipelined.Accept(chain[0]))
+	require.NoError(pipelined.Accept(chain[1]))
+	pipelined.DrainAcceptorQueue()
+	require.True(pipelined.HasState(chain[1].Root()))
+
+	// The blocks before a block failing verification are committed
+	header := types.CopyHeader(chain[10].Header())
+	header.Root = common.Hash{0x01}
+	bad := append(types.Blocks{}, chain[:10]...)
+	bad = append(bad, chain[10].WithSeal(header))
+
+	pipelined = newBlockChain(4)
+	n, err := pipelined.InsertChain(bad)
+	require.Error(err)
+	require.Equal(10, n)
+	require.Equal(chain[9].Hash(), pipelined.CurrentBlock().Hash())
+	require.Len(pipelined.GetReceiptsByHash(chain[9].Hash()), len(chain[9].Transactions()))
+}
@@ -38,6 +38,18 @@ var (
 
 	// ErrNoGenesis is returned when there is no Genesis Block.
 	ErrNoGenesis = errors.New("genesis not found in chain")
+
+	// ErrReorgTooDeep is returned when switching to a new preferred block would
+	// drop more blocks from the canonical chain than the configured maximum.
+	ErrReorgTooDeep = errors.New("reorg exceeds maximum depth")
+
+	// ErrStateIntegrity is returned by the startup state integrity check when
+	// the state of a block does not match the trie or the snapshot.
+	ErrStateIntegrity = errors.New("state integrity check failed")
+
+	// ErrTxNotIndexed is returned when a transaction is looked up by hash but
+	// may be older than the blocks covered by the transaction index.
+	ErrTxNotIndexed = errors.New("transaction not indexed")
 )
 
 // List of evm-call-message pre-checking errors. All state transition messages will
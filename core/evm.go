@@ -92,16 +92,17 @@ func newEVMBlockContext(header *types.Header, chain ChainContext, author *common
 		baseFee = new(big.Int).Set(header.BaseFee)
 	}
 	return vm.BlockContext{
-		CanTransfer:      CanTransfer,
-		Transfer:         Transfer,
-		GetHash:          GetHashFn(header, chain),
-		PredicateResults: predicateResults,
-		Coinbase:         beneficiary,
-		BlockNumber:      new(big.Int).Set(header.Number),
-		Time:             header.Time,
-		Difficulty:       new(big.Int).Set(header.Difficulty),
-		BaseFee:          baseFee,
-		GasLimit:         header.GasLimit,
+		CanTransfer:       CanTransfer,
+		Transfer:          Transfer,
+		GetHash:           GetHashFn(header, chain),
+		GetHistoricalHash: GetHistoricalHashFn(header, chain),
+		PredicateResults:  predicateResults,
+		Coinbase:          beneficiary,
+		BlockNumber:       new(big.Int).Set(header.Number),
+		Time:              header.Time,
+		Difficulty:        new(big.Int).Set(header.Difficulty),
+		BaseFee:           baseFee,
+		GasLimit:          header.GasLimit,
 	}
 }
 
@@ -152,6 +153,36 @@ func GetHashFn(ref *types.Header, chain ChainContext) func(n uint64) common.Hash
 	}
 }
 
+// acceptedHashReader is implemented by chain contexts that can look up the
+// hashes of accepted blocks by number.
+type acceptedHashReader interface {
+	// GetAcceptedHash returns the hash of the accepted block at [number] and
+	// whether the block at [number] has been accepted.
+	GetAcceptedHash(number uint64) (common.Hash, bool)
+}
+
+// GetHistoricalHashFn returns a GetHashFunc which retrieves the hashes of any
+// ancestor of [ref] by number. Since every block being processed descends from
+// the last accepted block, accepted ancestors are looked up by number if
+// [chain] supports it, and only the ancestors that are still processing are
+// found by walking back from [ref].
+func GetHistoricalHashFn(ref *types.Header, chain ChainContext) func(n uint64) common.Hash {
+	getAncestorHash := GetHashFn(ref, chain)
+	reader, ok := chain.(acceptedHashReader)
+
+	return func(n uint64) common.Hash {
+		if ref.Number.Uint64() <= n {
+			return common.Hash{}
+		}
+		if ok {
+			if hash, accepted := reader.GetAcceptedHash(n); accepted {
+				return hash
+			}
+		}
+		return getAncestorHash(n)
+	}
+}
+
 // CanTransfer checks whether there are enough funds in the address' account to make a transfer.
 // This does not take the necessary gas in to account to make the transfer valid.
 func CanTransfer(db vm.StateDB, addr common.Address, amount *big.Int) bool {
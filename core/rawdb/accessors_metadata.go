@@ -296,3 +296,18 @@ func ReadAcceptorTip(db ethdb.KeyValueReader) (common.Hash, error) {
 	}
 	return common.BytesToHash(h), nil
 }
+
+// ReadGasPriceHistory retrieves the serialized fee history persisted by the
+// gas price oracle.
+func ReadGasPriceHistory(db ethdb.KeyValueReader) []byte {
+	data, _ := db.Get(gasPriceHistoryKey)
+	return data
+}
+
+// WriteGasPriceHistory stores the serialized fee history of the gas price
+// oracle, so that it can be reloaded on startup.
+func WriteGasPriceHistory(db ethdb.KeyValueWriter, history []byte) {
+	if err := db.Put(gasPriceHistoryKey, history); err != nil {
+		log.Crit("Failed to store gas price history", "err", err)
+	}
+}
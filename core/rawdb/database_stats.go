@@ -0,0 +1,160 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package rawdb
+
+import (
+	"bytes"
+	"math"
+
+	"github.com/luxdefi/evm/ethdb"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DatabaseStat is the size of the entries of a category of database keys.
+type DatabaseStat struct {
+	Bytes uint64 `json:"bytes"`
+	Items uint64 `json:"items"`
+}
+
+func (s *DatabaseStat) add(key, value []byte) {
+	s.Bytes += uint64(len(key) + len(value))
+	s.Items++
+}
+
+func (s *DatabaseStat) scale(factor float64) {
+	s.Bytes = uint64(float64(s.Bytes) * factor)
+	s.Items = uint64(float64(s.Items) * factor)
+}
+
+// DatabaseStats is the size of a chain database broken down by category.
+type DatabaseStats struct {
+	State     DatabaseStat `json:"state"`     // Trie nodes and contract code
+	Blocks    DatabaseStat `json:"blocks"`    // Headers, bodies and number <-> hash mappings
+	Receipts  DatabaseStat `json:"receipts"`  // Receipt lists
+	Snapshots DatabaseStat `json:"snapshots"` // Account and storage snapshots
+	TxIndex   DatabaseStat `json:"txIndex"`   // Transaction lookup entries
+	Other     DatabaseStat `json:"other"`     // Everything else, only counted if not sampled
+	Sampled   bool         `json:"sampled"`
+}
+
+// hashedKeyRange describes a category of keys made of a prefix followed by a
+// hash. Since hashes are uniformly distributed, the size of the category can
+// be estimated from a sub-range of the hashes.
+type hashedKeyRange struct {
+	prefix []byte
+	keyLen int
+	stat   func(*DatabaseStats) *DatabaseStat
+}
+
+var hashedKeyRanges = []hashedKeyRange{
+	{nil, common.HashLength, func(s *DatabaseStats) *DatabaseStat { return &s.State }},
+	{CodePrefix, len(CodePrefix) + common.HashLength, func(s *DatabaseStats) *DatabaseStat { return &s.State }},
+	{headerNumberPrefix, len(headerNumberPrefix) + common.HashLength, func(s *DatabaseStats) *DatabaseStat { return &s.Blocks }},
+	{txLookupPrefix, len(txLookupPrefix) + common.HashLength, func(s *DatabaseStats) *DatabaseStat { return &s.TxIndex }},
+	{SnapshotAccountPrefix, len(SnapshotAccountPrefix) + common.HashLength, func(s *DatabaseStats) *DatabaseStat { return &s.Snapshots }},
+	{SnapshotStoragePrefix, len(SnapshotStoragePrefix) + 2*common.HashLength, func(s *DatabaseStats) *DatabaseStat { return &s.Snapshots }},
+}
+
+// CollectDatabaseStats returns the size of [db] broken down by category.
+//
+// If [sampleRate] is in (0, 1), only that fraction of the hash-keyed
+// categories (state, snapshots, the transaction index and hash -> number
+// mappings) is read and their sizes are extrapolated. Block data is keyed by
+// number and is always read in full. Entries that belong to no category are
+// not counted when sampling.
+//
+// The database is only read through iterators, so this is safe to call while
+// the chain is running.
+func CollectDatabaseStats(db ethdb.Iteratee, sampleRate float64) (*DatabaseStats, error) {
+	stats := new(DatabaseStats)
+	if sampleRate <= 0 || sampleRate >= 1 {
+		it := db.NewIterator(nil, nil)
+		defer it.Release()
+
+		for it.Next() {
+			stats.category(it.Key()).add(it.Key(), it.Value())
+		}
+		return stats, it.Error()
+	}
+	stats.Sampled = true
+
+	// Block data is keyed by number, so it cannot be sampled by key range.
+	for _, prefix := range [][]byte{headerPrefix, blockBodyPrefix, blockReceiptsPrefix} {
+		if err := collectPrefix(db, prefix, stats); err != nil {
+			return nil, err
+		}
+	}
+
+	// Only the keys whose hash starts with a byte below [limit] are read.
+	limit := int(math.Ceil(sampleRate * 256))
+	factor := 256 / float64(limit)
+	for _, r := range hashedKeyRanges {
+		var sampled DatabaseStat
+		if err := collectHashedRange(db, r, limit, &sampled); err != nil {
+			return nil, err
+		}
+		sampled.scale(factor)
+		stat := r.stat(stats)
+		stat.Bytes += sampled.Bytes
+		stat.Items += sampled.Items
+	}
+	return stats, nil
+}
+
+func collectPrefix(db ethdb.Iteratee, prefix []byte, stats *DatabaseStats) error {
+	it := db.NewIterator(prefix, nil)
+	defer it.Release()
+
+	for it.Next() {
+		stats.category(it.Key()).add(it.Key(), it.Value())
+	}
+	return it.Error()
+}
+
+func collectHashedRange(db ethdb.Iteratee, r hashedKeyRange, limit int, stat *DatabaseStat) error {
+	it := db.NewIterator(r.prefix, nil)
+	defer it.Release()
+
+	for it.Next() {
+		key := it.Key()
+		if len(key) <= len(r.prefix) {
+			continue
+		}
+		if int(key[len(r.prefix)]) >= limit {
+			break
+		}
+		if len(key) == r.keyLen {
+			stat.add(key, it.Value())
+		}
+	}
+	return it.Error()
+}
+
+// category returns the stat that [key] is accounted to.
+func (s *DatabaseStats) category(key []byte) *DatabaseStat {
+	switch {
+	case bytes.HasPrefix(key, headerPrefix) && len(key) == (len(headerPrefix)+8+common.HashLength):
+		return &s.Blocks
+	case bytes.HasPrefix(key, blockBodyPrefix) && len(key) == (len(blockBodyPrefix)+8+common.HashLength):
+		return &s.Blocks
+	case bytes.HasPrefix(key, blockReceiptsPrefix) && len(key) == (len(blockReceiptsPrefix)+8+common.HashLength):
+		return &s.Receipts
+	case bytes.HasPrefix(key, headerPrefix) && bytes.HasSuffix(key, headerHashSuffix):
+		return &s.Blocks
+	case bytes.HasPrefix(key, headerNumberPrefix) && len(key) == (len(headerNumberPrefix)+common.HashLength):
+		return &s.Blocks
+	case len(key) == common.HashLength:
+		return &s.State
+	case bytes.HasPrefix(key, CodePrefix) && len(key) == len(CodePrefix)+common.HashLength:
+		return &s.State
+	case bytes.HasPrefix(key, txLookupPrefix) && len(key) == (len(txLookupPrefix)+common.HashLength):
+		return &s.TxIndex
+	case bytes.HasPrefix(key, SnapshotAccountPrefix) && len(key) == (len(SnapshotAccountPrefix)+common.HashLength):
+		return &s.Snapshots
+	case bytes.HasPrefix(key, SnapshotStoragePrefix) && len(key) == (len(SnapshotStoragePrefix)+2*common.HashLength):
+		return &s.Snapshots
+	default:
+		return &s.Other
+	}
+}
@@ -0,0 +1,57 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package rawdb
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectDatabaseStats(t *testing.T) {
+	var (
+		db    = NewMemoryDatabase()
+		value = make([]byte, 10)
+		low   = common.Hash{0x00, 0x01}
+		high  = common.Hash{0xff, 0x01}
+	)
+	for _, key := range [][]byte{
+		headerKey(1, low),        // 41 bytes
+		blockBodyKey(1, low),     // 41 bytes
+		blockReceiptsKey(1, low), // 41 bytes
+		low[:],                   // 32 bytes
+		high[:],                  // 32 bytes
+		codeKey(low),             // 33 bytes
+		accountSnapshotKey(low),  // 33 bytes
+		txLookupKey(low),         // 33 bytes
+		headBlockKey,             // 9 bytes
+	} {
+		require.NoError(t, db.Put(key, value))
+	}
+
+	stats, err := CollectDatabaseStats(db, 1)
+	require.NoError(t, err)
+	require.Equal(t, &DatabaseStats{
+		State:     DatabaseStat{Bytes: 42 + 42 + 43, Items: 3},
+		Blocks:    DatabaseStat{Bytes: 51 + 51, Items: 2},
+		Receipts:  DatabaseStat{Bytes: 51, Items: 1},
+		Snapshots: DatabaseStat{Bytes: 43, Items: 1},
+		TxIndex:   DatabaseStat{Bytes: 43, Items: 1},
+		Other:     DatabaseStat{Bytes: 19, Items: 1},
+	}, stats)
+
+	// Sampling half of the hash space counts the keys starting with 0x00
+	// twice and skips the ones starting with 0xff. Block data is not sampled.
+	stats, err = CollectDatabaseStats(db, 0.5)
+	require.NoError(t, err)
+	require.Equal(t, &DatabaseStats{
+		State:     DatabaseStat{Bytes: 2 * (42 + 43), Items: 4},
+		Blocks:    DatabaseStat{Bytes: 51 + 51, Items: 2},
+		Receipts:  DatabaseStat{Bytes: 51, Items: 1},
+		Snapshots: DatabaseStat{Bytes: 2 * 43, Items: 2},
+		TxIndex:   DatabaseStat{Bytes: 2 * 43, Items: 2},
+		Sampled:   true,
+	}, stats)
+}
@@ -153,6 +153,12 @@ type (
 		account       *common.Address
 		key, prevalue common.Hash
 	}
+
+	// Changes to the storage writes counted against a precompile
+	precompileStorageWritesChange struct {
+		address *common.Address
+		prev    uint64
+	}
 )
 
 func (ch createObjectChange) revert(s *StateDB) {
@@ -236,6 +242,18 @@ func (ch transientStorageChange) dirtied() *common.Address {
 	return nil
 }
 
+func (ch precompileStorageWritesChange) revert(s *StateDB) {
+	if ch.prev == 0 {
+		delete(s.precompileStorageWrites, *ch.address)
+	} else {
+		s.precompileStorageWrites[*ch.address] = ch.prev
+	}
+}
+
+func (ch precompileStorageWritesChange) dirtied() *common.Address {
+	return nil
+}
+
 func (ch refundChange) revert(s *StateDB) {
 	s.refund = ch.prev
 }
@@ -37,4 +37,8 @@ var (
 	storageTriesUpdatedMeter = metrics.NewRegisteredMeter("state/update/storagenodes", nil)
 	accountTrieDeletedMeter  = metrics.NewRegisteredMeter("state/delete/accountnodes", nil)
 	storageTriesDeletedMeter = metrics.NewRegisteredMeter("state/delete/storagenodes", nil)
+
+	// Reads that fell back to the trie because the snapshot could not serve them
+	snapshotAccountFallbackMeter = metrics.NewRegisteredMeter("state/snapshot/fallback/account", nil)
+	snapshotStorageFallbackMeter = metrics.NewRegisteredMeter("state/snapshot/fallback/storage", nil)
 )
@@ -87,6 +87,75 @@ func FullAccount(data []byte) (Account, error) {
 	return account, nil
 }
 
+// AccountFields is a set of the fields of an Account, used to select the fields
+// decoded by DecodeAccount.
+type AccountFields uint8
+
+const (
+	AccountNonce AccountFields = 1 << iota
+	AccountBalance
+	AccountRoot
+	AccountCodeHash
+
+	AllAccountFields = AccountNonce | AccountBalance | AccountRoot | AccountCodeHash
+)
+
+// DecodeAccount decodes [fields] of the account [data], in the 'slim RLP' or the
+// consensus format.
+// If all fields are requested, the account is decoded eagerly as in FullAccount.
+// Otherwise only the requested fields are parsed: fields that are not requested
+// are skipped without being decoded and left unset, and the fields after the
+// last requested one are not read at all, so that for example reading only the
+// balance of an account does not pay for decoding its root and code hash.
+func DecodeAccount(data []byte, fields AccountFields) (Account, error) {
+	if fields&AllAccountFields == AllAccountFields {
+		return FullAccount(data)
+	}
+	content, _, err := rlp.SplitList(data)
+	if err != nil {
+		return Account{}, err
+	}
+	var account Account
+	for field := AccountNonce; field <= AccountCodeHash && field <= fields; field <<= 1 {
+		if fields&field == 0 {
+			if _, _, content, err = rlp.Split(content); err != nil {
+				return Account{}, err
+			}
+			continue
+		}
+		var value []byte
+		switch field {
+		case AccountNonce:
+			account.Nonce, content, err = rlp.SplitUint64(content)
+		case AccountBalance:
+			if value, content, err = rlp.SplitString(content); err == nil {
+				if len(value) > 0 && value[0] == 0 {
+					err = rlp.ErrCanonInt
+				}
+				account.Balance = new(big.Int).SetBytes(value)
+			}
+		case AccountRoot:
+			if value, content, err = rlp.SplitString(content); err == nil {
+				account.Root = types.EmptyRootHash[:]
+				if len(value) > 0 {
+					account.Root = common.CopyBytes(value)
+				}
+			}
+		case AccountCodeHash:
+			if value, content, err = rlp.SplitString(content); err == nil {
+				account.CodeHash = types.EmptyCodeHash[:]
+				if len(value) > 0 {
+					account.CodeHash = common.CopyBytes(value)
+				}
+			}
+		}
+		if err != nil {
+			return Account{}, err
+		}
+	}
+	return account, nil
+}
+
 // FullAccountRLP converts data on the 'slim RLP' format into the full RLP-format.
 func FullAccountRLP(data []byte) ([]byte, error) {
 	account, err := FullAccount(data)
@@ -0,0 +1,78 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package snapshot
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/luxdefi/evm/core/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+var testSlimAccounts = map[string][]byte{
+	"empty":    SlimAccountRLP(0, new(big.Int), types.EmptyRootHash, types.EmptyCodeHash[:]),
+	"eoa":      SlimAccountRLP(7, big.NewInt(1_000_000), types.EmptyRootHash, types.EmptyCodeHash[:]),
+	"contract": SlimAccountRLP(1, new(big.Int).Lsh(big.NewInt(1), 100), common.Hash{0x01}, common.Hash{0x02}.Bytes()),
+}
+
+func TestDecodeAccount(t *testing.T) {
+	for name, data := range testSlimAccounts {
+		t.Run(name, func(t *testing.T) {
+			require := require.New(t)
+			full, err := FullAccount(data)
+			require.NoError(err)
+
+			// decoding every field is equivalent to FullAccount
+			account, err := DecodeAccount(data, AllAccountFields)
+			require.NoError(err)
+			require.Equal(full, account)
+
+			// decoding some of the fields sets only those
+			account, err = DecodeAccount(data, AccountBalance)
+			require.NoError(err)
+			require.Equal(Account{Balance: full.Balance}, account)
+
+			account, err = DecodeAccount(data, AccountNonce|AccountCodeHash)
+			require.NoError(err)
+			require.Equal(Account{Nonce: full.Nonce, CodeHash: full.CodeHash}, account)
+
+			account, err = DecodeAccount(data, AccountBalance|AccountRoot|AccountCodeHash)
+			require.NoError(err)
+			require.Equal(Account{Balance: full.Balance, Root: full.Root, CodeHash: full.CodeHash}, account)
+		})
+	}
+}
+
+func TestDecodeAccountInvalid(t *testing.T) {
+	// non-canonical balance with a leading zero byte
+	_, err := DecodeAccount([]byte{0xc4, 0x80, 0x82, 0x00, 0x01}, AccountBalance)
+	require.Error(t, err)
+
+	// truncated before the requested field
+	data := testSlimAccounts["contract"]
+	_, err = DecodeAccount(data[:len(data)-10], AccountCodeHash)
+	require.Error(t, err)
+}
+
+func BenchmarkDecodeAccount(b *testing.B) {
+	data := testSlimAccounts["contract"]
+	b.Run("full", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := DecodeAccount(data, AllAccountFields); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("balance", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := DecodeAccount(data, AccountBalance); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -55,6 +55,14 @@ type diskLayer struct {
 
 	genStats *generatorStats // Stats for snapshot generation (generation aborted/finished if non-nil)
 
+	genCheckpoint   generatorCheckpoint // Cadence at which generation progress is persisted
+	genKeys         int                 // Number of keys generated since progress was last persisted
+	genCheckpointed time.Time           // Time at which generation progress was last persisted
+
+	genThrottle      *GeneratorThrottle // Rate limit of generation, nil if unlimited
+	genWindowStart   time.Time          // Start of the window the generation rate is measured over
+	genWindowStorage common.StorageSize // Generated snapshot size at the start of the window
+
 	created      time.Time // Time at which disk layer was created
 	logged       time.Time // Time at which last logged generation progress
 	abortStarted time.Time // Time as which disk layer started to be aborted
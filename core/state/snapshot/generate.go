@@ -126,10 +126,18 @@ func (gs *generatorStats) log(level log.Lvl, msg string, root common.Hash, marke
 	}
 }
 
+// generatorCheckpoint configures how often the generator persists its
+// progress in addition to whenever its write batch fills up. Zero values
+// disable the respective trigger.
+type generatorCheckpoint struct {
+	keys     int           // Number of generated keys between checkpoints
+	interval time.Duration // Time between checkpoints
+}
+
 // generateSnapshot regenerates a brand new snapshot based on an existing state
 // database and head block asynchronously. The snapshot is returned immediately
 // and generation is continued in the background until done.
-func generateSnapshot(diskdb ethdb.KeyValueStore, triedb *trie.Database, cache int, blockHash, root common.Hash, wiper chan struct{}) *diskLayer {
+func generateSnapshot(diskdb ethdb.KeyValueStore, triedb *trie.Database, cache int, checkpoint generatorCheckpoint, throttle *GeneratorThrottle, blockHash, root common.Hash, wiper chan struct{}) *diskLayer {
 	// Wipe any previously existing snapshot from the database if no wiper is
 	// currently in progress.
 	if wiper == nil {
@@ -148,15 +156,17 @@ func generateSnapshot(diskdb ethdb.KeyValueStore, triedb *trie.Database, cache i
 		log.Crit("Failed to write initialized state marker", "err", err)
 	}
 	base := &diskLayer{
-		diskdb:     diskdb,
-		triedb:     triedb,
-		blockHash:  blockHash,
-		root:       root,
-		cache:      newMeteredSnapshotCache(cache * 1024 * 1024),
-		genMarker:  genMarker,
-		genPending: make(chan struct{}),
-		genAbort:   make(chan chan struct{}),
-		created:    time.Now(),
+		diskdb:        diskdb,
+		triedb:        triedb,
+		blockHash:     blockHash,
+		root:          root,
+		cache:         newMeteredSnapshotCache(cache * 1024 * 1024),
+		genMarker:     genMarker,
+		genPending:    make(chan struct{}),
+		genAbort:      make(chan chan struct{}),
+		genCheckpoint: checkpoint,
+		genThrottle:   throttle,
+		created:       time.Now(),
 	}
 	go base.generate(stats)
 	log.Debug("Start snapshot generation", "root", root)
@@ -197,18 +207,34 @@ func journalProgress(db ethdb.KeyValueWriter, marker []byte, stats *generatorSta
 	rawdb.WriteSnapshotGenerator(db, blob)
 }
 
-// checkAndFlush checks to see if snapshot generation has been aborted or if
-// the current batch size is greater than ethdb.IdealBatchSize. If so, it saves
-// the current progress to disk and returns true. Else, it could log current
-// progress and returns true.
+// checkpointDue reports whether enough keys were generated or enough time
+// passed since the generation progress was last persisted.
+func (dl *diskLayer) checkpointDue() bool {
+	if keys := dl.genCheckpoint.keys; keys > 0 && dl.genKeys >= keys {
+		return true
+	}
+	if interval := dl.genCheckpoint.interval; interval > 0 && time.Since(dl.genCheckpointed) >= interval {
+		return true
+	}
+	return false
+}
+
+// checkAndFlush checks to see if snapshot generation has been aborted, if
+// the current batch size is greater than ethdb.IdealBatchSize or if a
+// checkpoint is due. If so, it saves the current progress to disk and returns
+// true only if generation was aborted. Else, it could log current progress
+// and returns false.
 func (dl *diskLayer) checkAndFlush(batch ethdb.Batch, stats *generatorStats, currentLocation []byte) bool {
 	// If we've exceeded our batch allowance or termination was requested, flush to disk
-	var abort chan struct{}
-	select {
-	case abort = <-dl.genAbort:
-	default:
+	abort := dl.throttle(stats)
+	if abort == nil {
+		select {
+		case abort = <-dl.genAbort:
+		default:
+		}
 	}
-	if batch.ValueSize() > ethdb.IdealBatchSize || abort != nil {
+	dl.genKeys++
+	if batch.ValueSize() > ethdb.IdealBatchSize || abort != nil || dl.checkpointDue() {
 		if bytes.Compare(currentLocation, dl.genMarker) < 0 {
 			log.Error("Snapshot generator went backwards",
 				"currentLocation", fmt.Sprintf("%x", currentLocation),
@@ -229,6 +255,7 @@ func (dl *diskLayer) checkAndFlush(batch ethdb.Batch, stats *generatorStats, cur
 			return true
 		}
 		batch.Reset()
+		dl.genKeys, dl.genCheckpointed = 0, time.Now()
 
 		dl.lock.Lock()
 		dl.genMarker = currentLocation
@@ -292,6 +319,7 @@ func (dl *diskLayer) generate(stats *generatorStats) {
 
 	// Iterate from the previous marker and continue generating the state snapshot
 	dl.logged = time.Now()
+	dl.genCheckpointed = dl.logged
 	for accIt.Next() {
 		// Retrieve the current account and flatten it into the internal format
 		accountHash := common.BytesToHash(accIt.Key)
@@ -27,9 +27,13 @@
 package snapshot
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"math/big"
 	"os"
+	"reflect"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -221,7 +225,7 @@ func (t *testHelper) Commit() common.Hash {
 
 func (t *testHelper) CommitAndGenerate() (common.Hash, *diskLayer) {
 	root := t.Commit()
-	snap := generateSnapshot(t.diskdb, t.triedb, 16, testBlockHash, root, nil)
+	snap := generateSnapshot(t.diskdb, t.triedb, 16, generatorCheckpoint{}, nil, testBlockHash, root, nil)
 	return root, snap
 }
 
@@ -406,7 +410,7 @@ func TestGenerateCorruptAccountTrie(t *testing.T) {
 	helper.triedb.Commit(root, false)
 	helper.diskdb.Delete(common.HexToHash("0x65145f923027566669a1ae5ccac66f945b55ff6eaeb17d2ea8e048b7d381f2d7").Bytes())
 
-	snap := generateSnapshot(helper.diskdb, helper.triedb, 16, testBlockHash, root, nil)
+	snap := generateSnapshot(helper.diskdb, helper.triedb, 16, generatorCheckpoint{}, nil, testBlockHash, root, nil)
 	select {
 	case <-snap.genPending:
 		// Snapshot generation succeeded
@@ -440,7 +444,7 @@ func TestGenerateMissingStorageTrie(t *testing.T) {
 	// Delete a storage trie root and ensure the generator chokes
 	helper.diskdb.Delete(stRoot) // We can only corrupt the disk database, so flush the tries out
 
-	snap := generateSnapshot(helper.diskdb, helper.triedb, 16, testBlockHash, root, nil)
+	snap := generateSnapshot(helper.diskdb, helper.triedb, 16, generatorCheckpoint{}, nil, testBlockHash, root, nil)
 	select {
 	case <-snap.genPending:
 		// Snapshot generation succeeded
@@ -473,7 +477,7 @@ func TestGenerateCorruptStorageTrie(t *testing.T) {
 	// Delete a storage trie leaf and ensure the generator chokes
 	helper.diskdb.Delete(common.HexToHash("0x18a0f4d79cff4459642dd7604f303886ad9d77c30cf3d7d7cedb3a693ab6d371").Bytes())
 
-	snap := generateSnapshot(helper.diskdb, helper.triedb, 16, testBlockHash, root, nil)
+	snap := generateSnapshot(helper.diskdb, helper.triedb, 16, generatorCheckpoint{}, nil, testBlockHash, root, nil)
 	select {
 	case <-snap.genPending:
 		// Snapshot generation succeeded
@@ -532,7 +536,7 @@ func TestGenerateWithExtraAccounts(t *testing.T) {
 	if data := rawdb.ReadStorageSnapshot(helper.diskdb, hashData([]byte("acc-2")), hashData([]byte("b-key-1"))); data == nil {
 		t.Fatalf("expected snap storage to exist")
 	}
-	snap := generateSnapshot(helper.diskdb, helper.triedb, 16, testBlockHash, root, nil)
+	snap := generateSnapshot(helper.diskdb, helper.triedb, 16, generatorCheckpoint{}, nil, testBlockHash, root, nil)
 	select {
 	case <-snap.genPending:
 		// Snapshot generation succeeded
@@ -878,3 +882,192 @@ func TestGenerateBrokenSnapshotWithDanglingStorage(t *testing.T) {
 	snap.genAbort <- stop
 	<-stop
 }
+
+// crashingDB fails every write of a batch with insertions once [limit] such
+// writes succeeded, as if the process had crashed at that point.
+type crashingDB struct {
+	ethdb.KeyValueStore
+	writes atomic.Int32
+	limit  int32
+}
+
+func (db *crashingDB) NewBatch() ethdb.Batch {
+	return &crashingBatch{Batch: db.KeyValueStore.NewBatch(), db: db}
+}
+
+func (db *crashingDB) NewBatchWithSize(size int) ethdb.Batch {
+	return &crashingBatch{Batch: db.KeyValueStore.NewBatchWithSize(size), db: db}
+}
+
+type crashingBatch struct {
+	ethdb.Batch
+	db   *crashingDB
+	puts int
+}
+
+func (b *crashingBatch) Put(key []byte, value []byte) error {
+	b.puts++
+	return b.Batch.Put(key, value)
+}
+
+func (b *crashingBatch) Write() error {
+	if b.puts > 0 && b.db.writes.Add(1) > b.db.limit {
+		return errors.New("crashed")
+	}
+	return b.Batch.Write()
+}
+
+func (b *crashingBatch) Reset() {
+	b.puts = 0
+	b.Batch.Reset()
+}
+
+// snapshotData returns all account and storage snapshot entries of [db].
+func snapshotData(db ethdb.KeyValueStore) map[string][]byte {
+	data := make(map[string][]byte)
+	it := db.NewIterator(nil, nil)
+	defer it.Release()
+	for it.Next() {
+		key := it.Key()
+		isAccount := bytes.HasPrefix(key, rawdb.SnapshotAccountPrefix) && len(key) == len(rawdb.SnapshotAccountPrefix)+common.HashLength
+		isStorage := bytes.HasPrefix(key, rawdb.SnapshotStoragePrefix) && len(key) == len(rawdb.SnapshotStoragePrefix)+2*common.HashLength
+		if isAccount || isStorage {
+			data[string(key)] = common.CopyBytes(it.Value())
+		}
+	}
+	return data
+}
+
+// Tests that generation interrupted by a crash resumes from the last checkpoint
+// and produces the same snapshot as an uninterrupted run.
+func TestGenerateResumeFromCheckpoint(t *testing.T) {
+	newTestHelper := func() *testHelper {
+		helper := newHelper()
+		for i := 0; i < 100; i++ {
+			stRoot := helper.makeStorageTrie(hashData([]byte(fmt.Sprintf("acc-%d", i))), []string{"key-1", "key-2", "key-3"}, []string{"val-1", "val-2", "val-3"}, true)
+			helper.addTrieAccount(fmt.Sprintf("acc-%d", i),
+				&Account{Balance: big.NewInt(1), Root: stRoot, CodeHash: types.EmptyCodeHash.Bytes()})
+		}
+		return helper
+	}
+	waitAndStop := func(snap *diskLayer) {
+		select {
+		case <-snap.genPending:
+		case <-time.After(3 * time.Second):
+			t.Fatalf("Snapshot generation failed")
+		}
+		stop := make(chan struct{})
+		snap.genAbort <- stop
+		<-stop
+	}
+
+	// Uninterrupted run
+	helper := newTestHelper()
+	root, snap := helper.CommitAndGenerate()
+	waitAndStop(snap)
+	want := snapshotData(helper.diskdb)
+
+	// Crash after a few checkpoints of 10 keys each
+	helper = newTestHelper()
+	if have := helper.Commit(); have != root {
+		t.Fatalf("root mismatch: have %#x want %#x", have, root)
+	}
+	crashing := &crashingDB{KeyValueStore: helper.diskdb, limit: 5}
+	snap = generateSnapshot(crashing, helper.triedb, 16, generatorCheckpoint{keys: 10}, nil, testBlockHash, root, nil)
+	for deadline := time.Now().Add(3 * time.Second); crashing.writes.Load() <= crashing.limit; {
+		if time.Now().After(deadline) {
+			t.Fatalf("Snapshot generation did not crash")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	stop := make(chan struct{})
+	snap.genAbort <- stop
+	<-stop
+
+	var generator journalGenerator
+	if err := rlp.DecodeBytes(rawdb.ReadSnapshotGenerator(helper.diskdb), &generator); err != nil {
+		t.Fatalf("failed to decode generator: %v", err)
+	}
+	if generator.Done || len(generator.Marker) == 0 {
+		t.Fatalf("generator progress not checkpointed: done %v, marker %x", generator.Done, generator.Marker)
+	}
+	if have := len(snapshotData(helper.diskdb)); have == 0 || have >= len(want) {
+		t.Fatalf("unexpected number of snapshot entries after crash: have %d, total %d", have, len(want))
+	}
+
+	// Resume from the checkpoint after restarting
+	layer, done, err := loadSnapshot(helper.diskdb, helper.triedb, 16, generatorCheckpoint{}, nil, testBlockHash, root, false)
+	if err != nil {
+		t.Fatalf("failed to load snapshot: %v", err)
+	}
+	if done {
+		t.Fatalf("snapshot reported as generated after crash")
+	}
+	snap = layer.(*diskLayer)
+	waitAndStop(snap)
+	checkSnapRoot(t, snap, root)
+	if have := snapshotData(helper.diskdb); !reflect.DeepEqual(have, want) {
+		t.Fatalf("resumed snapshot differs: have %d entries, want %d", len(have), len(want))
+	}
+}
+
+// Tests that throttled generation does not exceed the configured rate, and
+// that the rate can be changed while generating.
+func TestGenerateThrottled(t *testing.T) {
+	newTestHelper := func() *testHelper {
+		helper := newHelper()
+		for i := 0; i < 100; i++ {
+			stRoot := helper.makeStorageTrie(hashData([]byte(fmt.Sprintf("acc-%d", i))), []string{"key-1", "key-2", "key-3"}, []string{"val-1", "val-2", "val-3"}, true)
+			helper.addTrieAccount(fmt.Sprintf("acc-%d", i),
+				&Account{Balance: big.NewInt(1), Root: stRoot, CodeHash: types.EmptyCodeHash.Bytes()})
+		}
+		return helper
+	}
+	stopGenerator := func(snap *diskLayer) {
+		stop := make(chan struct{})
+		snap.genAbort <- stop
+		<-stop
+	}
+
+	// Generating the snapshot at a third of its size per second takes more
+	// than two throttling windows
+	helper := newTestHelper()
+	root := helper.Commit()
+	probe := generateSnapshot(rawdb.NewMemoryDatabase(), helper.triedb, 16, generatorCheckpoint{}, nil, testBlockHash, root, nil)
+	<-probe.genPending
+	size := uint64(probe.genStats.storage)
+	stopGenerator(probe)
+
+	start := time.Now()
+	snap := generateSnapshot(helper.diskdb, helper.triedb, 16, generatorCheckpoint{}, NewGeneratorThrottle(size/3), testBlockHash, root, nil)
+	select {
+	case <-snap.genPending:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("Snapshot generation failed")
+	}
+	if elapsed := time.Since(start); elapsed < 2*throttleWindow {
+		t.Fatalf("throttled generation of %d bytes at %d bytes/s took %v", size, size/3, elapsed)
+	}
+	checkSnapRoot(t, snap, root)
+	stopGenerator(snap)
+
+	// Generation stalls at a rate far below the size of the snapshot, until
+	// the limit is lifted
+	helper = newTestHelper()
+	root = helper.Commit()
+	throttle := NewGeneratorThrottle(1)
+	snap = generateSnapshot(helper.diskdb, helper.triedb, 16, generatorCheckpoint{}, throttle, testBlockHash, root, nil)
+	select {
+	case <-snap.genPending:
+		t.Fatalf("Snapshot generated despite the rate limit")
+	case <-time.After(250 * time.Millisecond):
+	}
+	throttle.SetLimit(0)
+	select {
+	case <-snap.genPending:
+	case <-time.After(3 * time.Second):
+		t.Fatalf("Snapshot generation did not resume after lifting the rate limit")
+	}
+	checkSnapRoot(t, snap, root)
+	stopGenerator(snap)
+}
@@ -56,7 +56,7 @@ type journalGenerator struct {
 // loadSnapshot loads a pre-existing state snapshot backed by a key-value
 // store. If loading the snapshot from disk is successful, this function also
 // returns a boolean indicating whether or not the snapshot is fully generated.
-func loadSnapshot(diskdb ethdb.KeyValueStore, triedb *trie.Database, cache int, blockHash, root common.Hash, noBuild bool) (snapshot, bool, error) {
+func loadSnapshot(diskdb ethdb.KeyValueStore, triedb *trie.Database, cache int, checkpoint generatorCheckpoint, throttle *GeneratorThrottle, blockHash, root common.Hash, noBuild bool) (snapshot, bool, error) {
 	// Retrieve the block number and hash of the snapshot, failing if no snapshot
 	// is present in the database (or crashed mid-update).
 	baseBlockHash := rawdb.ReadSnapshotBlockHash(diskdb)
@@ -88,12 +88,14 @@ func loadSnapshot(diskdb ethdb.KeyValueStore, triedb *trie.Database, cache int,
 
 	// Instantiate snapshot as disk layer with last recorded block hash and root
 	snapshot := &diskLayer{
-		diskdb:    diskdb,
-		triedb:    triedb,
-		cache:     newMeteredSnapshotCache(cache * 1024 * 1024),
-		root:      baseRoot,
-		blockHash: baseBlockHash,
-		created:   time.Now(),
+		diskdb:        diskdb,
+		triedb:        triedb,
+		cache:         newMeteredSnapshotCache(cache * 1024 * 1024),
+		root:          baseRoot,
+		blockHash:     baseBlockHash,
+		genCheckpoint: checkpoint,
+		genThrottle:   throttle,
+		created:       time.Now(),
 	}
 
 	var wiper chan struct{}
@@ -0,0 +1,224 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/luxdefi/evm/core/rawdb"
+	"github.com/luxdefi/evm/core/types"
+	"github.com/luxdefi/evm/ethdb"
+	"github.com/luxdefi/evm/trie"
+	"github.com/luxdefi/evm/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// repairStats tracks and logs the progress of a snapshot gap repair.
+type repairStats struct {
+	start    time.Time
+	logged   time.Time
+	accounts uint64
+	slots    uint64
+}
+
+// add counts [accounts] and [slots] more diffed. A nil *repairStats tracks
+// nothing.
+func (s *repairStats) add(accounts, slots uint64) {
+	if s != nil {
+		s.accounts += accounts
+		s.slots += slots
+	}
+}
+
+// log logs the progress of the repair if long enough time elapsed since the
+// last log, or unconditionally if [force] is set.
+func (s *repairStats) log(msg string, root common.Hash, force bool) {
+	if s == nil || !force && time.Since(s.logged) < 8*time.Second {
+		return
+	}
+	log.Info(msg, "root", root, "accounts", s.accounts, "slots", s.slots, "elapsed", common.PrettyDuration(time.Since(s.start)))
+	s.logged = time.Now()
+}
+
+// repairGap brings the disk layer persisted in [diskdb] up to the state [root]
+// of block [blockHash], if it is a complete snapshot of a different state. The
+// missing diff layers are regenerated as a single layer, computed by diffing
+// the trie of the disk layer against the trie of [root], and flattened into
+// the disk layer. Both tries must be available in [triedb].
+//
+// This is much cheaper than regenerating the whole snapshot when the disk
+// layer is only a few blocks away from [root]. If an error is returned, the
+// disk layer is left as is, unless it is returned while writing the repaired
+// layer, in which case the snapshot root is left missing.
+func repairGap(diskdb ethdb.KeyValueStore, triedb *trie.Database, blockHash, root common.Hash) error {
+	baseBlockHash := rawdb.ReadSnapshotBlockHash(diskdb)
+	baseRoot := rawdb.ReadSnapshotRoot(diskdb)
+	if baseBlockHash == (common.Hash{}) || baseRoot == (common.Hash{}) {
+		return nil // no snapshot to repair, it will be rebuilt
+	}
+	if baseBlockHash == blockHash && baseRoot == root {
+		return nil // no gap
+	}
+	generatorBlob := rawdb.ReadSnapshotGenerator(diskdb)
+	if len(generatorBlob) == 0 {
+		return errors.New("missing snapshot generator")
+	}
+	var generator journalGenerator
+	if err := rlp.DecodeBytes(generatorBlob, &generator); err != nil {
+		return fmt.Errorf("failed to decode snapshot generator: %v", err)
+	}
+	if !generator.Done {
+		return errors.New("cannot repair an unfinished snapshot")
+	}
+	baseTrie, err := trie.NewStateTrie(trie.StateTrieID(baseRoot), triedb)
+	if err != nil {
+		return fmt.Errorf("trie of snapshot root %s unavailable: %w", baseRoot, err)
+	}
+	headTrie, err := trie.NewStateTrie(trie.StateTrieID(root), triedb)
+	if err != nil {
+		return fmt.Errorf("trie of root %s unavailable: %w", root, err)
+	}
+
+	log.Info("Repairing snapshot gap", "fromHash", baseBlockHash, "fromRoot", baseRoot, "toHash", blockHash, "toRoot", root)
+	stats := &repairStats{start: time.Now(), logged: time.Now()}
+	destructs, accounts, storage, err := diffTries(triedb, baseRoot, root, baseTrie, headTrie, stats)
+	if err != nil {
+		return err
+	}
+
+	base := &diskLayer{
+		diskdb:    diskdb,
+		triedb:    triedb,
+		root:      baseRoot,
+		blockHash: baseBlockHash,
+		// the repaired layer is only used to write the diff, so this cache is
+		// discarded right away.
+		cache:   utils.NewMeteredCache(32*1024, "", "", 0),
+		created: time.Now(),
+	}
+	if _, _, err := diffToDisk(base.Update(blockHash, root, destructs, accounts, storage)); err != nil {
+		return err
+	}
+	stats.log("Repaired snapshot gap", root, true)
+	return nil
+}
+
+// DiffStates returns the changes turning the state [baseRoot] into [headRoot]
+// in the format of a diff layer: the deleted accounts, the accounts created or
+// modified in slim RLP encoding, and their changed storage slots, with nil
+// values for the slots deleted. Both tries must be available in [triedb].
+func DiffStates(triedb *trie.Database, baseRoot, headRoot common.Hash) (map[common.Hash]struct{}, map[common.Hash][]byte, map[common.Hash]map[common.Hash][]byte, error) {
+	baseTrie, err := trie.NewStateTrie(trie.StateTrieID(baseRoot), triedb)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("trie of root %s unavailable: %w", baseRoot, err)
+	}
+	headTrie, err := trie.NewStateTrie(trie.StateTrieID(headRoot), triedb)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("trie of root %s unavailable: %w", headRoot, err)
+	}
+	return diffTries(triedb, baseRoot, headRoot, baseTrie, headTrie, nil)
+}
+
+// diffTries returns the changes turning the state [baseTrie] with root
+// [baseRoot] into [headTrie] with root [headRoot], in the format of a diff
+// layer. The progress is tracked in [stats] if not nil.
+func diffTries(triedb *trie.Database, baseRoot, headRoot common.Hash, baseTrie, headTrie *trie.StateTrie, stats *repairStats) (map[common.Hash]struct{}, map[common.Hash][]byte, map[common.Hash]map[common.Hash][]byte, error) {
+	added, err := diffLeaves(baseTrie, headTrie)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	removed, err := diffLeaves(headTrie, baseTrie)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var (
+		destructs = make(map[common.Hash]struct{})
+		accounts  = make(map[common.Hash][]byte, len(added))
+		storage   = make(map[common.Hash]map[common.Hash][]byte)
+	)
+	for accountHash := range removed {
+		if _, ok := added[accountHash]; !ok {
+			destructs[accountHash] = struct{}{}
+			stats.add(1, 0)
+		}
+	}
+	for accountHash, data := range added {
+		var account types.StateAccount
+		if err := rlp.DecodeBytes(data, &account); err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid account %s: %w", accountHash, err)
+		}
+		accounts[accountHash] = SlimAccountRLP(account.Nonce, account.Balance, account.Root, account.CodeHash)
+		stats.add(1, 0)
+
+		baseStorageRoot := types.EmptyRootHash
+		if data, ok := removed[accountHash]; ok {
+			var baseAccount types.StateAccount
+			if err := rlp.DecodeBytes(data, &baseAccount); err != nil {
+				return nil, nil, nil, fmt.Errorf("invalid account %s: %w", accountHash, err)
+			}
+			baseStorageRoot = baseAccount.Root
+		}
+		if baseStorageRoot == account.Root {
+			continue
+		}
+		slots, err := diffStorage(triedb, baseRoot, headRoot, accountHash, baseStorageRoot, account.Root)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		storage[accountHash] = slots
+		stats.add(0, uint64(len(slots)))
+		stats.log("Repairing snapshot gap", headRoot, false)
+	}
+	return destructs, accounts, storage, nil
+}
+
+// diffStorage returns the slots of [accountHash] changed between its storage
+// tries [baseStorageRoot] and [headStorageRoot], with nil values for the slots
+// deleted.
+func diffStorage(triedb *trie.Database, baseRoot, headRoot, accountHash, baseStorageRoot, headStorageRoot common.Hash) (map[common.Hash][]byte, error) {
+	baseTrie, err := trie.NewStateTrie(trie.StorageTrieID(baseRoot, accountHash, baseStorageRoot), triedb)
+	if err != nil {
+		return nil, err
+	}
+	headTrie, err := trie.NewStateTrie(trie.StorageTrieID(headRoot, accountHash, headStorageRoot), triedb)
+	if err != nil {
+		return nil, err
+	}
+	slots, err := diffLeaves(baseTrie, headTrie)
+	if err != nil {
+		return nil, err
+	}
+	removed, err := diffLeaves(headTrie, baseTrie)
+	if err != nil {
+		return nil, err
+	}
+	for slotHash := range removed {
+		if _, ok := slots[slotHash]; !ok {
+			slots[slotHash] = nil
+		}
+	}
+	return slots, nil
+}
+
+// diffLeaves returns the leaves of [newTrie] that are not in [oldTrie], that
+// is the leaves added or modified from [oldTrie] to [newTrie].
+func diffLeaves(oldTrie, newTrie *trie.StateTrie) (map[common.Hash][]byte, error) {
+	var (
+		leaves  = make(map[common.Hash][]byte)
+		diff, _ = trie.NewDifferenceIterator(oldTrie.NodeIterator(nil), newTrie.NodeIterator(nil))
+		iter    = trie.NewIterator(diff)
+	)
+	for iter.Next() {
+		leaves[common.BytesToHash(iter.Key)] = common.CopyBytes(iter.Value)
+	}
+	if iter.Err != nil {
+		return nil, iter.Err
+	}
+	return leaves, nil
+}
@@ -0,0 +1,79 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package snapshot
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/luxdefi/evm/core/rawdb"
+	"github.com/luxdefi/evm/core/types"
+	"github.com/luxdefi/evm/trie"
+	"github.com/luxdefi/evm/trie/trienode"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Tests that a snapshot whose disk layer was persisted for the parent of the
+// requested block, with the diff layer of the requested block missing, is
+// repaired from the trie if allowed.
+func TestRepairGap(t *testing.T) {
+	helper := newHelper()
+	stRoot := helper.makeStorageTrie(hashData([]byte("acc-1")), []string{"key-1", "key-2", "key-3"}, []string{"val-1", "val-2", "val-3"}, true)
+	helper.addTrieAccount("acc-1", &Account{Balance: big.NewInt(1), Root: stRoot, CodeHash: types.EmptyCodeHash.Bytes()})
+	helper.addTrieAccount("acc-2", &Account{Balance: big.NewInt(2), Root: types.EmptyRootHash.Bytes(), CodeHash: types.EmptyCodeHash.Bytes()})
+	stRoot = helper.makeStorageTrie(hashData([]byte("acc-3")), []string{"key-1", "key-2"}, []string{"val-1", "val-2"}, true)
+	helper.addTrieAccount("acc-3", &Account{Balance: big.NewInt(3), Root: stRoot, CodeHash: types.EmptyCodeHash.Bytes()})
+
+	parentRoot, snap := helper.CommitAndGenerate()
+	select {
+	case <-snap.genPending:
+	case <-time.After(250 * time.Millisecond):
+		t.Fatal("Snapshot generation failed")
+	}
+	stop := make(chan struct{})
+	snap.genAbort <- stop
+	<-stop
+
+	// Build the state of the next block on top of the snapshotted one: modify
+	// a slot, delete a slot and add one in acc-1, delete acc-2 and add acc-4.
+	helper.accTrie, _ = trie.NewStateTrie(trie.StateTrieID(parentRoot), helper.triedb)
+	helper.nodes = trienode.NewMergedNodeSet()
+	stRoot = helper.makeStorageTrie(hashData([]byte("acc-1")), []string{"key-1", "key-2", "key-4"}, []string{"val-1", "val-5", "val-4"}, true)
+	helper.addTrieAccount("acc-1", &Account{Balance: big.NewInt(10), Root: stRoot, CodeHash: types.EmptyCodeHash.Bytes()})
+	helper.accTrie.MustDelete([]byte("acc-2"))
+	helper.addTrieAccount("acc-4", &Account{Nonce: 1, Balance: big.NewInt(4), Root: types.EmptyRootHash.Bytes(), CodeHash: types.EmptyCodeHash.Bytes()})
+	root := helper.Commit()
+	blockHash := common.HexToHash("0xbeefdead")
+
+	// Without repair, the missing layer cannot be recovered if rebuilding is
+	// not allowed.
+	config := Config{CacheSize: 16, NoBuild: true}
+	if _, err := New(config, helper.diskdb, helper.triedb, blockHash, root); err == nil {
+		t.Fatal("expected loading the snapshot with a missing layer to fail")
+	}
+	if have := rawdb.ReadSnapshotRoot(helper.diskdb); have != parentRoot {
+		t.Fatalf("snapshot root: have %#x, want %#x", have, parentRoot)
+	}
+
+	config.RepairGaps = true
+	tree, err := New(config, helper.diskdb, helper.triedb, blockHash, root)
+	if err != nil {
+		t.Fatalf("failed to repair snapshot: %v", err)
+	}
+	if have := rawdb.ReadSnapshotBlockHash(helper.diskdb); have != blockHash {
+		t.Fatalf("snapshot block hash: have %#x, want %#x", have, blockHash)
+	}
+	if have := rawdb.ReadSnapshotRoot(helper.diskdb); have != root {
+		t.Fatalf("snapshot root: have %#x, want %#x", have, root)
+	}
+	checkSnapRoot(t, tree.disklayer(), root)
+
+	if account, err := tree.Snapshot(root).Account(hashData([]byte("acc-2"))); err != nil || account != nil {
+		t.Fatalf("deleted account: have %v (err %v), want nil", account, err)
+	}
+	if data, err := tree.Snapshot(root).Storage(hashData([]byte("acc-1")), hashData([]byte("key-3"))); err != nil || len(data) != 0 {
+		t.Fatalf("deleted slot: have %x (err %v), want empty", data, err)
+	}
+}
@@ -31,6 +31,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"math/rand"
 	"sync"
 	"time"
 
@@ -172,6 +173,33 @@ type Config struct {
 	NoBuild    bool // Indicator that the snapshots generation is disallowed
 	AsyncBuild bool // The snapshot generation is allowed to be constructed asynchronously
 	SkipVerify bool // Indicator that all verification should be bypassed
+
+	// VerifySampleRate is the fraction of accounts checked against the trie
+	// when verifying the snapshot. If it is not in (0, 1), the whole snapshot
+	// is verified against the state root.
+	VerifySampleRate float64
+
+	// CheckpointKeys and CheckpointInterval make the generator persist its
+	// progress after the given number of generated keys or the given time, in
+	// addition to whenever its write batch fills up, so that generation
+	// resumes close to where it stopped after a crash. Zero disables either.
+	CheckpointKeys     int
+	CheckpointInterval time.Duration
+
+	// Throttle limits the rate of snapshot generation, and may be adjusted
+	// while the generator is running. Nil disables the limit.
+	Throttle *GeneratorThrottle
+
+	// RepairGaps makes New bring a complete disk layer persisted for another
+	// block up to date by diffing its trie against the requested root, instead
+	// of regenerating the whole snapshot. If either trie is missing, the
+	// snapshot is regenerated as usual.
+	RepairGaps bool
+}
+
+// checkpoint returns the generator checkpoint cadence of the config.
+func (c Config) checkpoint() generatorCheckpoint {
+	return generatorCheckpoint{keys: c.CheckpointKeys, interval: c.CheckpointInterval}
 }
 
 // Tree is an Ethereum state snapshot tree. It consists of one persistent base
@@ -208,6 +236,9 @@ type Tree struct {
 // If the snapshot is missing or the disk layer is broken, the snapshot will be
 // reconstructed using both the existing data and the state trie.
 // The repair happens on a background thread.
+//
+// If config.RepairGaps is set and the disk layer is a complete snapshot of
+// another block, it is first brought up to [root] from the trie.
 func New(config Config, diskdb ethdb.KeyValueStore, triedb *trie.Database, blockHash, root common.Hash) (*Tree, error) {
 	// Create a new, empty snapshot tree
 	snap := &Tree{
@@ -219,8 +250,15 @@ func New(config Config, diskdb ethdb.KeyValueStore, triedb *trie.Database, block
 		verified:    config.SkipVerify, // if SkipVerify is true, all verification will be bypassed
 	}
 
+	// Attempt to repair a disk layer left behind the requested block
+	if config.RepairGaps {
+		if err := repairGap(diskdb, triedb, blockHash, root); err != nil {
+			log.Warn("Failed to repair snapshot gap", "err", err)
+		}
+	}
+
 	// Attempt to load a previously persisted snapshot and rebuild one if failed
-	head, generated, err := loadSnapshot(diskdb, triedb, config.CacheSize, blockHash, root, config.NoBuild)
+	head, generated, err := loadSnapshot(diskdb, triedb, config.CacheSize, config.checkpoint(), config.Throttle, blockHash, root, config.NoBuild)
 	if err != nil {
 		log.Warn("Failed to load snapshot, regenerating", "err", err)
 		if !config.NoBuild {
@@ -371,8 +409,14 @@ func (t *Tree) verifyIntegrity(base *diskLayer, waitBuild bool) error {
 	}
 
 	start := time.Now()
-	log.Info("Verifying snapshot integrity", "root", base.root)
-	if err := t.verify(base.root, true); err != nil {
+	log.Info("Verifying snapshot integrity", "root", base.root, "sampleRate", t.config.VerifySampleRate)
+	verify := t.verify
+	if rate := t.config.VerifySampleRate; rate > 0 && rate < 1 {
+		verify = func(root common.Hash, force bool) error {
+			return t.verifySample(root, rate, force)
+		}
+	}
+	if err := verify(base.root, true); err != nil {
 		return fmt.Errorf("unable to verify snapshot integrity: %w", err)
 	}
 
@@ -781,7 +825,7 @@ func (t *Tree) Rebuild(blockHash, root common.Hash) {
 	// Start generating a new snapshot from scratch on a background thread. The
 	// generator will run a wiper first if there's not one running right now.
 	log.Info("Rebuilding state snapshot")
-	base := generateSnapshot(t.diskdb, t.triedb, t.config.CacheSize, blockHash, root, wiper)
+	base := generateSnapshot(t.diskdb, t.triedb, t.config.CacheSize, t.config.checkpoint(), t.config.Throttle, blockHash, root, wiper)
 	t.blockLayers = map[common.Hash]snapshot{
 		blockHash: base,
 	}
@@ -866,6 +910,70 @@ func (t *Tree) verify(root common.Hash, force bool) error {
 	return nil
 }
 
+// verifySample checks a random [rate] fraction of the accounts in the snapshot
+// at [root] against the state trie. For each sampled account, the account data
+// is compared with the trie and its storage root is re-computed from the
+// snapshot. Every mismatch is logged, and an error is returned if any are found.
+// [force] is passed to the iterators, as in verify.
+func (t *Tree) verifySample(root common.Hash, rate float64, force bool) error {
+	accTrie, err := trie.NewStateTrie(trie.StateTrieID(root), t.triedb)
+	if err != nil {
+		return err
+	}
+	acctIt, err := t.AccountIterator(root, common.Hash{}, force)
+	if err != nil {
+		return err
+	}
+	defer acctIt.Release()
+
+	var checked, mismatches int
+	for acctIt.Next() {
+		if rand.Float64() >= rate {
+			continue
+		}
+		checked++
+		accountHash := acctIt.Hash()
+		snapAccount, err := FullAccount(acctIt.Account())
+		if err != nil {
+			return err
+		}
+		trieAccount, err := accTrie.GetAccountByHash(accountHash)
+		if err != nil {
+			return err
+		}
+		if trieAccount == nil ||
+			snapAccount.Nonce != trieAccount.Nonce ||
+			snapAccount.Balance.Cmp(trieAccount.Balance) != 0 ||
+			!bytes.Equal(snapAccount.Root, trieAccount.Root[:]) ||
+			!bytes.Equal(snapAccount.CodeHash, trieAccount.CodeHash) {
+			log.Error("Snapshot account does not match trie", "root", root, "account", accountHash)
+			mismatches++
+			continue
+		}
+		storageIt, err := t.StorageIterator(root, accountHash, common.Hash{}, force)
+		if err != nil {
+			return err
+		}
+		storageRoot, err := generateTrieRoot(nil, "", storageIt, accountHash, stackTrieGenerate, nil, newGenerateStats(), false)
+		storageIt.Release()
+		if err != nil {
+			return err
+		}
+		if storageRoot != trieAccount.Root {
+			log.Error("Snapshot storage does not match trie", "root", root, "account", accountHash, "got", storageRoot, "want", trieAccount.Root)
+			mismatches++
+		}
+	}
+	if err := acctIt.Error(); err != nil {
+		return err
+	}
+	log.Info("Verified snapshot sample", "root", root, "checked", checked, "mismatches", mismatches)
+	if mismatches > 0 {
+		return fmt.Errorf("%d of %d sampled snapshot accounts do not match state root %x", mismatches, checked, root)
+	}
+	return nil
+}
+
 // disklayer is an internal helper function to return the disk layer.
 // The lock of snapTree is assumed to be held already.
 func (t *Tree) disklayer() *diskLayer {
@@ -721,3 +721,50 @@ func TestReadStateDuringFlattening(t *testing.T) {
 		t.Fatal("Unexpected blocker")
 	}
 }
+
+func TestVerifyIntegritySampled(t *testing.T) {
+	helper := newHelper()
+	for i := 0; i < 16; i++ {
+		acc := fmt.Sprintf("acc-%d", i)
+		stRoot := helper.makeStorageTrie(hashData([]byte(acc)), []string{"key-1", "key-2", "key-3"}, []string{"val-1", "val-2", "val-3"}, true)
+		helper.addAccount(acc, &Account{Balance: big.NewInt(int64(i)), Root: stRoot, CodeHash: types.EmptyCodeHash.Bytes()})
+		helper.addSnapStorage(acc, []string{"key-1", "key-2", "key-3"}, []string{"val-1", "val-2", "val-3"})
+	}
+	root := helper.Commit()
+
+	newTree := func(rate float64) *Tree {
+		tree := NewTestTree(helper.diskdb, testBlockHash, root)
+		tree.triedb = helper.triedb
+		tree.config = Config{VerifySampleRate: rate}
+		return tree
+	}
+	verify := func(tree *Tree) error {
+		return tree.verifyIntegrity(tree.disklayer(), false)
+	}
+
+	// An intact snapshot passes in both modes.
+	if err := verify(newTree(0)); err != nil {
+		t.Fatalf("full verification of intact snapshot failed: %v", err)
+	}
+	if err := verify(newTree(0.5)); err != nil {
+		t.Fatalf("sampled verification of intact snapshot failed: %v", err)
+	}
+
+	// Corrupt the balance of a single account.
+	stRoot := helper.makeStorageTrie(hashData([]byte("acc-3")), []string{"key-1", "key-2", "key-3"}, []string{"val-1", "val-2", "val-3"}, false)
+	helper.addSnapAccount("acc-3", &Account{Balance: big.NewInt(1000), Root: stRoot, CodeHash: types.EmptyCodeHash.Bytes()})
+
+	if err := verify(newTree(0)); err == nil {
+		t.Fatal("full verification did not catch corrupted account")
+	}
+	// Each attempt samples the corrupted account with probability 1/2, so
+	// the chance of it never being caught is negligible.
+	tree := newTree(0.5)
+	for i := 0; i < 32; i++ {
+		if err := verify(tree); err != nil {
+			return
+		}
+		tree.verified = false
+	}
+	t.Fatal("sampled verification did not catch corrupted account")
+}
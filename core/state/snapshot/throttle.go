@@ -0,0 +1,90 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package snapshot
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/luxdefi/evm/metrics"
+)
+
+const (
+	// throttleWindow is the period over which the generation rate is
+	// measured. Bursts are bounded by the limit of a single window.
+	throttleWindow = time.Second
+
+	// throttleRecheck is the longest the generator waits before checking
+	// whether the limit was changed.
+	throttleRecheck = 100 * time.Millisecond
+)
+
+var snapshotGeneratorThrottledTimer = metrics.NewRegisteredCounter("state/snapshot/generation/throttled", nil)
+
+// GeneratorThrottle limits the rate at which the snapshot generator writes
+// snapshot data, so that generation yields disk I/O to block processing. The
+// limit may be changed while generation is running.
+type GeneratorThrottle struct {
+	limit atomic.Uint64 // Bytes per second, 0 means unlimited
+}
+
+// NewGeneratorThrottle returns a throttle limiting generation to
+// [bytesPerSecond], or not at all if it is 0.
+func NewGeneratorThrottle(bytesPerSecond uint64) *GeneratorThrottle {
+	t := &GeneratorThrottle{}
+	t.limit.Store(bytesPerSecond)
+	return t
+}
+
+// Limit returns the rate limit in bytes per second, 0 if generation is not
+// limited.
+func (t *GeneratorThrottle) Limit() uint64 {
+	return t.limit.Load()
+}
+
+// SetLimit changes the rate limit to [bytesPerSecond]. 0 removes the limit.
+func (t *GeneratorThrottle) SetLimit(bytesPerSecond uint64) {
+	t.limit.Store(bytesPerSecond)
+}
+
+// delay returns how long to wait for [written] bytes generated [elapsed]
+// into the current window to stay within the limit.
+func (t *GeneratorThrottle) delay(written uint64, elapsed time.Duration) time.Duration {
+	limit := t.Limit()
+	if limit == 0 {
+		return 0
+	}
+	return time.Duration(float64(written)/float64(limit)*float64(time.Second)) - elapsed
+}
+
+// throttle delays the generator while it is ahead of the rate limit of
+// [genThrottle], checking for changes of the limit every [throttleRecheck].
+// It returns the abort request received while waiting, if any, which the
+// caller must handle.
+func (dl *diskLayer) throttle(stats *generatorStats) chan struct{} {
+	if dl.genThrottle == nil {
+		return nil
+	}
+	for {
+		now := time.Now()
+		if now.Sub(dl.genWindowStart) >= throttleWindow {
+			dl.genWindowStart, dl.genWindowStorage = now, stats.storage
+			return nil
+		}
+		wait := dl.genThrottle.delay(uint64(stats.storage-dl.genWindowStorage), now.Sub(dl.genWindowStart))
+		if wait <= 0 {
+			return nil
+		}
+		wait = min(wait, throttleRecheck)
+		snapshotGeneratorThrottledTimer.Inc(wait.Milliseconds())
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case abort := <-dl.genAbort:
+			timer.Stop()
+			return abort
+		}
+	}
+}
@@ -210,6 +210,10 @@ func (s *stateObject) GetCommittedState(db Database, key common.Hash) common.Has
 	}
 	// If the snapshot is unavailable or reading from it fails, load from the database.
 	if s.db.snap == nil || err != nil {
+		if s.db.snap != nil {
+			s.db.SnapshotStorageFallbacks++
+			snapshotStorageFallbackMeter.Mark(1)
+		}
 		start := time.Now()
 		tr, err := s.getTrie(db)
 		if err != nil {
@@ -113,6 +113,9 @@ type StateDB struct {
 	// Ordered storage slots to be used in predicate verification as set in the tx access list.
 	// Only set in PrepareAccessList, and un-modified through execution.
 	predicateStorageSlots map[common.Address][][]byte
+	// Storage writes performed by each stateful precompile since the StateDB
+	// was created, which is once per block.
+	precompileStorageWrites map[common.Address]uint64
 
 	// Transient storage
 	transientStorage transientStorage
@@ -141,6 +144,11 @@ type StateDB struct {
 	StorageUpdated int
 	AccountDeleted int
 	StorageDeleted int
+
+	// Reads served by the trie because the snapshot could not serve them,
+	// for example while it is being generated
+	SnapshotAccountFallbacks int
+	SnapshotStorageFallbacks int
 }
 
 // New creates a new state from a given trie.
@@ -289,6 +297,25 @@ func (s *StateDB) Preimages() map[common.Hash][]byte {
 	return s.preimages
 }
 
+// AccessedState returns the accounts loaded by the state so far and, for each
+// of them, the storage slots read or written.
+func (s *StateDB) AccessedState() map[common.Address][]common.Hash {
+	accessed := make(map[common.Address][]common.Hash, len(s.stateObjects))
+	for addr, obj := range s.stateObjects {
+		slots := make([]common.Hash, 0, len(obj.originStorage)+len(obj.pendingStorage))
+		for key := range obj.originStorage {
+			slots = append(slots, key)
+		}
+		for key := range obj.pendingStorage {
+			if _, ok := obj.originStorage[key]; !ok {
+				slots = append(slots, key)
+			}
+		}
+		accessed[addr] = slots
+	}
+	return accessed
+}
+
 // AddRefund adds gas to the refund counter
 func (s *StateDB) AddRefund(gas uint64) {
 	s.journal.append(refundChange{prev: s.refund})
@@ -638,6 +665,10 @@ func (s *StateDB) getDeletedStateObject(addr common.Address) *stateObject {
 	}
 	// If snapshot unavailable or reading from it failed, load from the database
 	if data == nil {
+		if s.snap != nil {
+			s.SnapshotAccountFallbacks++
+			snapshotAccountFallbackMeter.Mark(1)
+		}
 		start := time.Now()
 		var err error
 		data, err = s.trie.GetAccount(addr)
@@ -834,6 +865,12 @@ func (s *StateDB) Copy() *StateDB {
 	state.accessList = s.accessList.Copy()
 	state.transientStorage = s.transientStorage.Copy()
 	state.predicateStorageSlots = copyPredicateStorageSlots(s.predicateStorageSlots)
+	if len(s.precompileStorageWrites) > 0 {
+		state.precompileStorageWrites = make(map[common.Address]uint64, len(s.precompileStorageWrites))
+		for addr, writes := range s.precompileStorageWrites {
+			state.precompileStorageWrites[addr] = writes
+		}
+	}
 
 	// If there's a prefetcher running, make an inactive copy of it that can
 	// only access data but does not actively preload (since the user will not
@@ -865,6 +902,22 @@ func (s *StateDB) Copy() *StateDB {
 	return state
 }
 
+// CopyForChild returns a copy of the state, whose changes must be finalised
+// into its tries, on which a child block can be executed before the state is
+// committed. Unlike Copy, the logs, preimages and storage writes of stateful
+// precompiles of the block are reset, and the pre-state root of the copy is
+// the root of the state. Committing the copy also commits the changes that
+// were not committed when it was created.
+func (s *StateDB) CopyForChild() *StateDB {
+	state := s.Copy()
+	state.originalRoot = state.trie.Hash()
+	state.logs = make(map[common.Hash][]*types.Log)
+	state.logSize = 0
+	state.preimages = make(map[common.Hash][]byte)
+	state.precompileStorageWrites = nil
+	return state
+}
+
 // Snapshot returns an identifier for the current revision of the state.
 func (s *StateDB) Snapshot() int {
 	id := s.nextRevisionId
@@ -1276,6 +1329,27 @@ func (s *StateDB) convertAccountSet(set map[common.Address]struct{}) map[common.
 	return ret
 }
 
+// GetPrecompileStorageWrites returns the number of storage writes performed by
+// the stateful precompile at [address] in this StateDB.
+func (s *StateDB) GetPrecompileStorageWrites(address common.Address) uint64 {
+	return s.precompileStorageWrites[address]
+}
+
+// AddPrecompileStorageWrite counts a storage write performed by the stateful
+// precompile at [address]. The count is reverted along with the write if the
+// call performing it is reverted.
+func (s *StateDB) AddPrecompileStorageWrite(address common.Address) {
+	if s.precompileStorageWrites == nil {
+		s.precompileStorageWrites = make(map[common.Address]uint64)
+	}
+	prev := s.precompileStorageWrites[address]
+	s.journal.append(precompileStorageWritesChange{
+		address: &address,
+		prev:    prev,
+	})
+	s.precompileStorageWrites[address] = prev + 1
+}
+
 // SetPredicateStorageSlots sets the predicate storage slots for the given address
 func (s *StateDB) SetPredicateStorageSlots(address common.Address, predicates [][]byte) {
 	s.predicateStorageSlots[address] = predicates
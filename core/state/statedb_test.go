@@ -40,6 +40,7 @@ import (
 	"testing/quick"
 
 	"github.com/luxdefi/evm/core/rawdb"
+	"github.com/luxdefi/evm/core/state/snapshot"
 	"github.com/luxdefi/evm/core/types"
 	"github.com/ethereum/go-ethereum/common"
 )
@@ -1008,3 +1009,81 @@ func TestStateDBTransientStorage(t *testing.T) {
 		t.Fatalf("transient storage mismatch: have %x, want %x", got, value)
 	}
 }
+
+// uncoveredSnapshot is a snapshot that cannot serve any account or storage
+// slot, as is the case for a snapshot whose generation has not reached them.
+type uncoveredSnapshot struct {
+	snapshot.Snapshot
+}
+
+func (uncoveredSnapshot) Account(common.Hash) (*snapshot.Account, error) {
+	return nil, snapshot.ErrNotCoveredYet
+}
+
+func (uncoveredSnapshot) Storage(common.Hash, common.Hash) ([]byte, error) {
+	return nil, snapshot.ErrNotCoveredYet
+}
+
+// Tests that reads the snapshot cannot serve are served by the trie, while the
+// reads the snapshot serves, including those of missing accounts, are not.
+func TestSnapshotFallback(t *testing.T) {
+	var (
+		diskdb  = rawdb.NewMemoryDatabase()
+		db      = NewDatabase(diskdb)
+		addr    = common.Address{0x01}
+		missing = common.Address{0x02}
+		slot    = common.Hash{0x01}
+		value   = common.Hash{0x02}
+	)
+	state, _ := New(types.EmptyRootHash, db, nil)
+	state.SetBalance(addr, big.NewInt(42))
+	state.SetState(addr, slot, value)
+	root, err := state.Commit(false, false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if err := db.TrieDB().Commit(root, false); err != nil {
+		t.Fatalf("failed to commit trie: %v", err)
+	}
+	snaps, err := snapshot.New(snapshot.Config{CacheSize: 16, SkipVerify: true}, diskdb, db.TrieDB(), common.Hash{0xbb}, root)
+	if err != nil {
+		t.Fatalf("failed to create snapshot: %v", err)
+	}
+	snap := snaps.Snapshot(root)
+	if snap == nil {
+		t.Fatalf("snapshot missing for root %x", root)
+	}
+
+	for name, test := range map[string]struct {
+		snap                     snapshot.Snapshot
+		expectedAccountFallbacks int
+		expectedStorageFallbacks int
+	}{
+		"authoritative snapshot": {snap: snap},
+		// Both the existing and the missing account are read from the trie
+		"uncovered snapshot": {snap: uncoveredSnapshot{snap}, expectedAccountFallbacks: 2, expectedStorageFallbacks: 1},
+	} {
+		state, err := NewWithSnapshot(root, db, test.snap)
+		if err != nil {
+			t.Fatalf("%s: failed to create state: %v", name, err)
+		}
+		if balance := state.GetBalance(addr); balance.Cmp(big.NewInt(42)) != 0 {
+			t.Errorf("%s: balance mismatch: have %v, want 42", name, balance)
+		}
+		if have := state.GetState(addr, slot); have != value {
+			t.Errorf("%s: storage mismatch: have %x, want %x", name, have, value)
+		}
+		if state.Exist(missing) {
+			t.Errorf("%s: missing account exists", name)
+		}
+		if err := state.Error(); err != nil {
+			t.Fatalf("%s: state error: %v", name, err)
+		}
+		if state.SnapshotAccountFallbacks != test.expectedAccountFallbacks {
+			t.Errorf("%s: account fallbacks mismatch: have %d, want %d", name, state.SnapshotAccountFallbacks, test.expectedAccountFallbacks)
+		}
+		if state.SnapshotStorageFallbacks != test.expectedStorageFallbacks {
+			t.Errorf("%s: storage fallbacks mismatch: have %d, want %d", name, state.SnapshotStorageFallbacks, test.expectedStorageFallbacks)
+		}
+	}
+}
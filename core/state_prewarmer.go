@@ -0,0 +1,203 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package core
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/luxdefi/evm/core/state"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	// prewarmWindow is the number of recently accepted blocks whose state
+	// accesses are counted to select the state to prewarm.
+	prewarmWindow = 16
+
+	// prewarmPendingBlocks is the number of processed blocks whose state
+	// accesses are held until they are accepted.
+	prewarmPendingBlocks = 64
+)
+
+// prewarmKey is an account, or one of its storage slots if [isSlot] is set.
+type prewarmKey struct {
+	addr   common.Address
+	slot   common.Hash
+	isSlot bool
+}
+
+// statePrewarmer tracks the state accessed by the recently accepted blocks, to
+// select the accounts and storage slots most frequently accessed.
+// A nil *statePrewarmer tracks nothing.
+type statePrewarmer struct {
+	limit int // Maximum number of keys selected for prewarming
+
+	// [requests] holds the state root to prewarm next, if the worker is
+	// still busy with the previous one.
+	requests chan common.Hash
+
+	// [pending] holds the accesses of processed blocks by block hash, until
+	// they are accepted. [window] holds the accesses of the last accepted
+	// blocks, and [counts] the number of blocks in [window] accessing each
+	// key. All are protected by [lock].
+	pending *lru.Cache[common.Hash, []prewarmKey]
+	window  [][]prewarmKey
+	next    int
+	counts  map[prewarmKey]int
+	lock    sync.Mutex
+}
+
+// newStatePrewarmer returns a prewarmer selecting at most [limit] keys, or nil
+// if [limit] is not positive.
+func newStatePrewarmer(limit int) *statePrewarmer {
+	if limit <= 0 {
+		return nil
+	}
+	return &statePrewarmer{
+		limit:    limit,
+		requests: make(chan common.Hash, 1),
+		pending:  lru.NewCache[common.Hash, []prewarmKey](prewarmPendingBlocks),
+		window:   make([][]prewarmKey, prewarmWindow),
+		counts:   make(map[prewarmKey]int),
+	}
+}
+
+// record holds the state accessed by [statedb] while processing the block
+// [blockHash], until the block is accepted.
+func (p *statePrewarmer) record(blockHash common.Hash, statedb *state.StateDB) {
+	if p == nil {
+		return
+	}
+	var keys []prewarmKey
+	for addr, slots := range statedb.AccessedState() {
+		keys = append(keys, prewarmKey{addr: addr})
+		for _, slot := range slots {
+			keys = append(keys, prewarmKey{addr: addr, slot: slot, isSlot: true})
+		}
+	}
+	p.pending.Add(blockHash, keys)
+}
+
+// accept counts the state accessed by the accepted block [blockHash].
+func (p *statePrewarmer) accept(blockHash common.Hash) {
+	if p == nil {
+		return
+	}
+	keys, ok := p.pending.Get(blockHash)
+	if !ok {
+		return
+	}
+	p.pending.Remove(blockHash)
+
+	p.lock.Lock()
+	for _, key := range p.window[p.next] {
+		if p.counts[key]--; p.counts[key] == 0 {
+			delete(p.counts, key)
+		}
+	}
+	p.window[p.next] = keys
+	p.next = (p.next + 1) % len(p.window)
+	for _, key := range keys {
+		p.counts[key]++
+	}
+	p.lock.Unlock()
+}
+
+// schedule requests the state [root] to be prewarmed by the worker, and
+// returns false if a request is already waiting for it.
+func (p *statePrewarmer) schedule(root common.Hash) bool {
+	if p == nil {
+		return false
+	}
+	select {
+	case p.requests <- root:
+		return true
+	default:
+		return false
+	}
+}
+
+// top returns the keys most frequently accessed by the last accepted blocks,
+// at most [limit] of them. Ties are broken by address, accounts first, then
+// by slot.
+func (p *statePrewarmer) top() []prewarmKey {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	top := make([]prewarmKey, 0, len(p.counts))
+	for key := range p.counts {
+		top = append(top, key)
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if ci, cj := p.counts[top[i]], p.counts[top[j]]; ci != cj {
+			return ci > cj
+		}
+		if c := bytes.Compare(top[i].addr[:], top[j].addr[:]); c != 0 {
+			return c < 0
+		}
+		if top[i].isSlot != top[j].isSlot {
+			return !top[i].isSlot
+		}
+		return bytes.Compare(top[i].slot[:], top[j].slot[:]) < 0
+	})
+	if len(top) > p.limit {
+		top = top[:p.limit]
+	}
+	return top
+}
+
+// prewarmState requests the accounts and storage slots most frequently
+// accessed by the recently accepted blocks to be preloaded at the state
+// [root], in the background, while a block built on [root] is verified. A
+// request is dropped if the worker is already behind.
+func (bc *BlockChain) prewarmState(root common.Hash) {
+	if bc.prewarmer != nil && !bc.prewarmer.schedule(root) {
+		statePrewarmDroppedCounter.Inc(1)
+	}
+}
+
+// prewarmLoop preloads the states requested by prewarmState, one at a time,
+// until the chain is stopped.
+func (bc *BlockChain) prewarmLoop() {
+	defer bc.wg.Done()
+
+	for {
+		select {
+		case root := <-bc.prewarmer.requests:
+			if keys := bc.prewarmer.top(); len(keys) > 0 {
+				bc.prewarm(root, keys)
+			}
+		case <-bc.quit:
+			return
+		}
+	}
+}
+
+// prewarm reads [keys] at the state [root], loading them into the snapshot
+// and trie caches.
+func (bc *BlockChain) prewarm(root common.Hash, keys []prewarmKey) {
+	start := time.Now()
+	statedb, err := state.New(root, bc.stateCache, bc.snaps)
+	if err != nil {
+		log.Debug("Failed to open state to prewarm", "root", root, "err", err)
+		return
+	}
+	for _, key := range keys {
+		if key.isSlot {
+			statedb.GetState(key.addr, key.slot)
+		} else {
+			statedb.Exist(key.addr)
+		}
+	}
+	if err := statedb.Error(); err != nil {
+		log.Debug("Failed to prewarm state", "root", root, "err", err)
+	}
+	statePrewarmTimer.Inc(time.Since(start).Milliseconds())
+	statePrewarmKeysCounter.Inc(int64(len(keys)))
+}
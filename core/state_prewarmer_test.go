@@ -0,0 +1,59 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package core
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatePrewarmer(t *testing.T) {
+	require := require.New(t)
+	require.Nil(newStatePrewarmer(0))
+
+	var (
+		p       = newStatePrewarmer(2)
+		a       = prewarmKey{addr: common.Address{1}}
+		aSlot   = prewarmKey{addr: common.Address{1}, slot: common.Hash{1}, isSlot: true}
+		b       = prewarmKey{addr: common.Address{2}}
+		blockA  = common.Hash{0xa}
+		blockAB = common.Hash{0xab}
+	)
+	p.pending.Add(blockA, []prewarmKey{aSlot, a})
+	p.pending.Add(blockAB, []prewarmKey{b, a})
+
+	// Blocks not processed are ignored.
+	p.accept(common.Hash{0xff})
+	require.Empty(p.top())
+
+	p.accept(blockA)
+	require.Equal([]prewarmKey{a, aSlot}, p.top())
+	// [a] is accessed by both blocks, and [aSlot] sorts before [b]. The
+	// selection is bounded by the limit.
+	p.accept(blockAB)
+	require.Equal([]prewarmKey{a, aSlot}, p.top())
+	// A block is only counted once.
+	p.accept(blockAB)
+	require.Equal(2, p.counts[a])
+
+	// The accesses of the blocks leaving the window are no longer counted.
+	for i := 0; i < prewarmWindow; i++ {
+		blockHash := common.Hash{byte(i)}
+		p.pending.Add(blockHash, []prewarmKey{b})
+		p.accept(blockHash)
+	}
+	require.Equal([]prewarmKey{b}, p.top())
+
+	// Requests are dropped while one is already waiting for the worker.
+	require.True(p.schedule(common.Hash{1}))
+	require.False(p.schedule(common.Hash{2}))
+	require.Equal(common.Hash{1}, <-p.requests)
+	require.True(p.schedule(common.Hash{2}))
+
+	var nilPrewarmer *statePrewarmer
+	nilPrewarmer.accept(blockA)
+	require.False(nilPrewarmer.schedule(common.Hash{1}))
+}
@@ -51,6 +51,7 @@ type StateProcessor struct {
 	config *params.ChainConfig // Chain configuration options
 	bc     *BlockChain         // Canonical block chain
 	engine consensus.Engine    // Consensus engine used for block rewards
+	sink   TxResultSink        // Sink notified of each transaction result
 }
 
 // NewStateProcessor initialises a new StateProcessor.
@@ -59,9 +60,19 @@ func NewStateProcessor(config *params.ChainConfig, bc *BlockChain, engine consen
 		config: config,
 		bc:     bc,
 		engine: engine,
+		sink:   noopTxResultSink{},
 	}
 }
 
+// SetTxResultSink sets the sink notified after each transaction is executed.
+// Passing nil restores the default, which discards all results.
+func (p *StateProcessor) SetTxResultSink(sink TxResultSink) {
+	if sink == nil {
+		sink = noopTxResultSink{}
+	}
+	p.sink = sink
+}
+
 // Process processes the state changes according to the Ethereum rules by running
 // the transaction messages using the statedb and applying any rewards to both
 // the processor (coinbase) and any included uncles.
@@ -105,6 +116,13 @@ func (p *StateProcessor) Process(block *types.Block, parent *types.Header, state
 		}
 		receipts = append(receipts, receipt)
 		allLogs = append(allLogs, receipt.Logs...)
+		p.sink.OnTxResult(TxResult{
+			TxHash:          receipt.TxHash,
+			Status:          receipt.Status,
+			GasUsed:         receipt.GasUsed,
+			Logs:            len(receipt.Logs),
+			ContractAddress: receipt.ContractAddress,
+		})
 	}
 	// Finalize the block, applying any consensus engine specific extras (e.g. block rewards)
 	if err := p.engine.Finalize(p.bc, block, parent, statedb, receipts); err != nil {
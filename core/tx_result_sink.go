@@ -0,0 +1,38 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package core
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TxResult summarizes the execution of a single transaction.
+type TxResult struct {
+	TxHash          common.Hash
+	Status          uint64
+	GasUsed         uint64
+	Logs            int
+	ContractAddress common.Address // Zero unless the transaction created a contract
+}
+
+// TxResultSink receives the result of every transaction executed by the
+// state processor. Implementations are called synchronously during block
+// processing and must not block.
+type TxResultSink interface {
+	OnTxResult(result TxResult)
+}
+
+// noopTxResultSink discards all results.
+type noopTxResultSink struct{}
+
+func (noopTxResultSink) OnTxResult(TxResult) {}
+
+// SetTxResultSink sets the sink notified after each transaction is executed
+// during block processing. Passing nil restores the default, which discards
+// all results. It must be called before blocks are processed.
+func (bc *BlockChain) SetTxResultSink(sink TxResultSink) {
+	if p, ok := bc.processor.(*StateProcessor); ok {
+		p.SetTxResultSink(sink)
+	}
+}
@@ -0,0 +1,88 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/luxdefi/evm/core/rawdb"
+	"github.com/luxdefi/evm/core/types"
+	"github.com/luxdefi/evm/params"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+type capturingTxResultSink struct {
+	results []TxResult
+}
+
+func (s *capturingTxResultSink) OnTxResult(result TxResult) {
+	s.results = append(s.results, result)
+}
+
+func TestTxResultSink(t *testing.T) {
+	var (
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr1   = crypto.PubkeyToAddress(key1.PublicKey)
+		addr2   = common.Address{0x02}
+		logger  = common.Address{0xc0}
+		signer  = types.HomesteadSigner{}
+	)
+	gspec := &Genesis{
+		Config: &params.ChainConfig{HomesteadBlock: new(big.Int)},
+		Alloc: GenesisAlloc{
+			addr1: {Balance: big.NewInt(params.Ether)},
+			// PUSH1 0 PUSH1 0 LOG0 STOP: emits a single empty log
+			logger: {Code: common.Hex2Bytes("60006000a000"), Balance: common.Big0},
+		},
+	}
+	blockchain, err := createBlockChain(rawdb.NewMemoryDatabase(), DefaultCacheConfig, gspec, common.Hash{})
+	require.NoError(t, err)
+	defer blockchain.Stop()
+
+	sink := &capturingTxResultSink{}
+	blockchain.SetTxResultSink(sink)
+
+	var txs []*types.Transaction
+	genDB, chain, _, err := GenerateChainWithGenesis(gspec, blockchain.engine, 2, 10, func(i int, gen *BlockGen) {
+		switch i {
+		case 0:
+			tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(addr1), addr2, big.NewInt(1), params.TxGas, nil, nil), signer, key1)
+			gen.AddTx(tx)
+			txs = append(txs, tx)
+			tx, _ = types.SignTx(types.NewTransaction(gen.TxNonce(addr1), logger, common.Big0, 100000, nil, nil), signer, key1)
+			gen.AddTx(tx)
+			txs = append(txs, tx)
+		case 1:
+			// Deploys an empty contract
+			tx, _ := types.SignTx(types.NewContractCreation(gen.TxNonce(addr1), common.Big0, 100000, nil, nil), signer, key1)
+			gen.AddTx(tx)
+			txs = append(txs, tx)
+		}
+	})
+	require.NoError(t, err)
+	_, err = blockchain.InsertChain(chain)
+	require.NoError(t, err)
+
+	require.Equal(t, []TxResult{
+		{TxHash: txs[0].Hash(), Status: types.ReceiptStatusSuccessful, GasUsed: params.TxGas},
+		{TxHash: txs[1].Hash(), Status: types.ReceiptStatusSuccessful, GasUsed: sink.results[1].GasUsed, Logs: 1},
+		{TxHash: txs[2].Hash(), Status: types.ReceiptStatusSuccessful, GasUsed: sink.results[2].GasUsed, ContractAddress: crypto.CreateAddress(addr1, 2)},
+	}, sink.results)
+	require.Greater(t, sink.results[1].GasUsed, params.TxGas)
+	require.Greater(t, sink.results[2].GasUsed, params.TxGas)
+
+	// Restoring the default sink stops reporting.
+	blockchain.SetTxResultSink(nil)
+	chain, _, err = GenerateChain(gspec.Config, chain[1], blockchain.engine, genDB, 1, 10, func(i int, gen *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(addr1), addr2, big.NewInt(1), params.TxGas, nil, nil), signer, key1)
+		gen.AddTx(tx)
+	})
+	require.NoError(t, err)
+	_, err = blockchain.InsertChain(chain)
+	require.NoError(t, err)
+	require.Len(t, sink.results, 3)
+}
@@ -0,0 +1,202 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package txpool
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/luxdefi/evm/core/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DefaultTxEventBuffer is the number of events buffered for a subscriber of
+// SubscribeTxEvents if no buffer size is given.
+const DefaultTxEventBuffer = 1024
+
+// TxEventKind is the kind of change to the pool reported by a TxEvent.
+type TxEventKind uint8
+
+const (
+	// TxAdded reports a transaction admitted to the pool.
+	TxAdded TxEventKind = iota
+	// TxRemoved reports a transaction removed from the pool.
+	TxRemoved
+)
+
+func (k TxEventKind) String() string {
+	switch k {
+	case TxAdded:
+		return "added"
+	case TxRemoved:
+		return "removed"
+	default:
+		return "unknown"
+	}
+}
+
+// TxRemovalReason describes why a transaction was removed from the pool.
+type TxRemovalReason uint8
+
+const (
+	// RemovedNone is the reason of events that are not removals.
+	RemovedNone TxRemovalReason = iota
+	// RemovedIncluded marks a transaction whose nonce was used by a block.
+	RemovedIncluded
+	// RemovedReplaced marks a transaction replaced by one with the same
+	// nonce paying a sufficiently higher price.
+	RemovedReplaced
+	// RemovedUnderpriced marks a transaction dropped for paying less than
+	// the pool requires, or less than the transactions it competed with.
+	RemovedUnderpriced
+	// RemovedUnpayable marks a transaction whose sender can no longer pay for
+	// it, or whose gas exceeds the block gas limit.
+	RemovedUnpayable
+	// RemovedEvicted marks a transaction dropped to keep the pool within its
+	// capacity limits.
+	RemovedEvicted
+	// RemovedExpired marks a queued transaction dropped after the sender was
+	// inactive for longer than the configured lifetime.
+	RemovedExpired
+	// RemovedDropped marks a transaction removed on request of the node.
+	RemovedDropped
+)
+
+func (r TxRemovalReason) String() string {
+	switch r {
+	case RemovedNone:
+		return ""
+	case RemovedIncluded:
+		return "included"
+	case RemovedReplaced:
+		return "replaced"
+	case RemovedUnderpriced:
+		return "underpriced"
+	case RemovedUnpayable:
+		return "unpayable"
+	case RemovedEvicted:
+		return "evicted"
+	case RemovedExpired:
+		return "expired"
+	case RemovedDropped:
+		return "dropped"
+	default:
+		return "unknown"
+	}
+}
+
+// TxEvent is a change to the set of transactions held by the pool.
+type TxEvent struct {
+	Kind   TxEventKind
+	Hash   common.Hash
+	From   common.Address
+	Nonce  uint64
+	Reason TxRemovalReason // Set if Kind is TxRemoved
+}
+
+// TxEventSubscription delivers the events of the pool to a single subscriber.
+// If the subscriber falls behind, the oldest buffered events are dropped so
+// that the pool never blocks on it.
+type TxEventSubscription struct {
+	feed    *txEventFeed
+	events  chan TxEvent
+	dropped atomic.Uint64
+	once    sync.Once
+}
+
+// Events returns the channel delivering the events. It is closed once the
+// subscription is unsubscribed.
+func (s *TxEventSubscription) Events() <-chan TxEvent {
+	return s.events
+}
+
+// Dropped returns the number of events dropped because the subscriber fell
+// behind.
+func (s *TxEventSubscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Unsubscribe stops the delivery of events and closes the events channel.
+func (s *TxEventSubscription) Unsubscribe() {
+	s.once.Do(func() {
+		s.feed.unsubscribe(s)
+		close(s.events)
+	})
+}
+
+// deliver buffers [ev], dropping the oldest buffered event if the buffer is
+// full.
+func (s *TxEventSubscription) deliver(ev TxEvent) {
+	for {
+		select {
+		case s.events <- ev:
+			return
+		default:
+		}
+		select {
+		case <-s.events:
+			s.dropped.Add(1)
+		default:
+		}
+	}
+}
+
+// txEventFeed fans out the events of the pool to its subscribers.
+type txEventFeed struct {
+	lock sync.Mutex
+	subs map[*TxEventSubscription]struct{}
+}
+
+func (f *txEventFeed) subscribe(buffer int) *TxEventSubscription {
+	if buffer <= 0 {
+		buffer = DefaultTxEventBuffer
+	}
+	sub := &TxEventSubscription{
+		feed:   f,
+		events: make(chan TxEvent, buffer),
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.subs == nil {
+		f.subs = make(map[*TxEventSubscription]struct{})
+	}
+	f.subs[sub] = struct{}{}
+	return sub
+}
+
+func (f *txEventFeed) unsubscribe(sub *TxEventSubscription) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	delete(f.subs, sub)
+}
+
+// send delivers an event of [kind] for [tx] to all subscribers.
+func (f *txEventFeed) send(signer types.Signer, kind TxEventKind, tx *types.Transaction, reason TxRemovalReason) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if len(f.subs) == 0 {
+		return
+	}
+	from, _ := types.Sender(signer, tx) // already validated
+	ev := TxEvent{
+		Kind:   kind,
+		Hash:   tx.Hash(),
+		From:   from,
+		Nonce:  tx.Nonce(),
+		Reason: reason,
+	}
+	for sub := range f.subs {
+		sub.deliver(ev)
+	}
+}
+
+// sendRemoved delivers a removal event with [reason] for each of [txs].
+func (f *txEventFeed) sendRemoved(signer types.Signer, txs types.Transactions, reason TxRemovalReason) {
+	for _, tx := range txs {
+		f.send(signer, TxRemoved, tx, reason)
+	}
+}
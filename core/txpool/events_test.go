@@ -0,0 +1,102 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package txpool
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/luxdefi/evm/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// drainTxEvents returns the events buffered by [sub].
+func drainTxEvents(sub *TxEventSubscription) []TxEvent {
+	var events []TxEvent
+	for {
+		select {
+		case ev := <-sub.Events():
+			events = append(events, ev)
+		default:
+			return events
+		}
+	}
+}
+
+// Tests that admitting and replacing a transaction is reported as an addition,
+// followed by the removal of the replaced transaction and the addition of its
+// replacement.
+func TestTxEventsReplacement(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupPool()
+	defer pool.Stop()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	testAddBalance(pool, from, big.NewInt(1000000000))
+
+	sub := pool.SubscribeTxEvents(0)
+	defer sub.Unsubscribe()
+
+	// Nonce 0 is pending, nonce 2 is queued
+	for _, nonce := range []uint64{0, 2} {
+		original := pricedTransaction(nonce, 100000, big.NewInt(1000), key)
+		replacement := pricedTransaction(nonce, 100000, big.NewInt(2000), key)
+		if err := pool.addRemoteSync(original); err != nil {
+			t.Fatalf("nonce %d: failed to add original transaction: %v", nonce, err)
+		}
+		if err := pool.addRemoteSync(replacement); err != nil {
+			t.Fatalf("nonce %d: failed to replace transaction: %v", nonce, err)
+		}
+		want := []TxEvent{
+			{Kind: TxAdded, Hash: original.Hash(), From: from, Nonce: nonce},
+			{Kind: TxRemoved, Hash: original.Hash(), From: from, Nonce: nonce, Reason: RemovedReplaced},
+			{Kind: TxAdded, Hash: replacement.Hash(), From: from, Nonce: nonce},
+		}
+		have := drainTxEvents(sub)
+		if len(have) != len(want) {
+			t.Fatalf("nonce %d: event count mismatch: have %d, want %d: %v", nonce, len(have), len(want), have)
+		}
+		for i := range want {
+			if have[i] != want[i] {
+				t.Fatalf("nonce %d: event %d mismatch: have %+v, want %+v", nonce, i, have[i], want[i])
+			}
+		}
+	}
+	if dropped := sub.Dropped(); dropped != 0 {
+		t.Fatalf("dropped events mismatch: have %d, want 0", dropped)
+	}
+}
+
+// Tests that a subscriber falling behind loses the oldest events.
+func TestTxEventsDropOldest(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupPool()
+	defer pool.Stop()
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+
+	sub := pool.SubscribeTxEvents(2)
+	txs := []*types.Transaction{transaction(0, 100000, key), transaction(1, 100000, key), transaction(2, 100000, key)}
+	for _, tx := range txs {
+		if err := pool.addRemoteSync(tx); err != nil {
+			t.Fatalf("failed to add transaction: %v", err)
+		}
+	}
+	have := drainTxEvents(sub)
+	if len(have) != 2 || have[0].Hash != txs[1].Hash() || have[1].Hash != txs[2].Hash() {
+		t.Fatalf("buffered events mismatch: have %v, want the additions of the last two transactions", have)
+	}
+	if dropped := sub.Dropped(); dropped != 1 {
+		t.Fatalf("dropped events mismatch: have %d, want 1", dropped)
+	}
+
+	// No events are delivered once unsubscribed
+	sub.Unsubscribe()
+	if err := pool.addRemoteSync(transaction(3, 100000, key)); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	if _, ok := <-sub.Events(); ok {
+		t.Fatalf("event delivered after unsubscribing")
+	}
+}
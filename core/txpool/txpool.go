@@ -117,6 +117,24 @@ var (
 	// ErrOverdraft is returned if a transaction would cause the senders balance to go negative
 	// thus invalidating a potential large number of transactions.
 	ErrOverdraft = errors.New("transaction would cause overdraft")
+
+	// ErrDustTransaction is returned if the dust filter is enabled and the sum of a
+	// transaction's value and tip is below the configured threshold.
+	ErrDustTransaction = errors.New("dust transaction")
+
+	// ErrInvalidChainID is returned if a replay protected transaction is signed
+	// for a chain ID other than the one of the chain, as it could never be
+	// included in a block.
+	ErrInvalidChainID = errors.New("transaction signed for another chain ID")
+
+	// ErrAccountLimitExceeded is returned if a remote account already has the
+	// maximum number of transactions permitted per account in the pool.
+	ErrAccountLimitExceeded = errors.New("account limit exceeded")
+
+	// ErrTxPoolBusy is returned by the RPC submission of remote transactions
+	// once the pool is filled above its back-pressure threshold, so that
+	// clients back off and retry later.
+	ErrTxPoolBusy = errors.New("txpool is full, retry later")
 )
 
 var (
@@ -145,6 +163,9 @@ var (
 	invalidTxMeter     = metrics.NewRegisteredMeter("txpool/invalid", nil)
 	underpricedTxMeter = metrics.NewRegisteredMeter("txpool/underpriced", nil)
 	overflowedTxMeter  = metrics.NewRegisteredMeter("txpool/overflowed", nil)
+	dustTxMeter        = metrics.NewRegisteredMeter("txpool/dust", nil)
+	accountLimitMeter  = metrics.NewRegisteredMeter("txpool/accountlimit", nil)
+	backPressureMeter  = metrics.NewRegisteredMeter("txpool/backpressure", nil)
 
 	// throttleTxMeter counts how many transactions are rejected due to too-many-changes between
 	// txpool reorgs.
@@ -200,6 +221,33 @@ type Config struct {
 	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
 
 	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
+
+	// QueueGracePeriod is the time since the last heartbeat of an account
+	// during which its non-executable transactions are only evicted to make
+	// room in the global queue once accounts out of their grace period have
+	// been evicted, so that gaps in a batch submitted out of order can still
+	// be filled in. Lifetime based eviction is not affected.
+	QueueGracePeriod time.Duration
+
+	DustThreshold       uint64 // Minimum value plus tip (in wei) for remote transactions, 0 disables the filter
+	DustExemptContracts bool   // Whether contract creations and calls bypass the dust filter
+
+	// AccountPendingLimit is the maximum number of pending and queued
+	// transactions a remote account may have in the pool, 0 disables the
+	// limit. Replacements of pooled transactions are always admitted.
+	AccountPendingLimit uint64
+
+	// BackPressureThreshold is the percentage of the pool's slots in use from
+	// which transactions submitted over RPC are rejected with ErrTxPoolBusy,
+	// 0 disables back-pressure. Local transactions are exempt.
+	BackPressureThreshold uint64
+
+	// StrictIntrinsicGas rejects transactions whose gas limit is below their
+	// intrinsic gas under the forks active for a block built now, before
+	// recovering their sender. Transactions reinjected after a reorg are
+	// checked too. Otherwise, intrinsic gas is checked under the rules of the
+	// current head.
+	StrictIntrinsicGas bool
 }
 
 // DefaultConfig contains the default configurations for the transaction
@@ -217,6 +265,8 @@ var DefaultConfig = Config{
 	GlobalQueue:  1024,
 
 	Lifetime: 3 * time.Hour,
+
+	DustExemptContracts: true,
 }
 
 // sanitize checks the provided user configurations and changes anything that's
@@ -255,6 +305,14 @@ func (config *Config) sanitize() Config {
 		log.Warn("Sanitizing invalid txpool lifetime", "provided", conf.Lifetime, "updated", DefaultConfig.Lifetime)
 		conf.Lifetime = DefaultConfig.Lifetime
 	}
+	if conf.QueueGracePeriod < 0 {
+		log.Warn("Sanitizing invalid txpool queue grace period", "provided", conf.QueueGracePeriod, "updated", 0)
+		conf.QueueGracePeriod = 0
+	}
+	if conf.BackPressureThreshold > 100 {
+		log.Warn("Sanitizing invalid txpool back-pressure threshold", "provided", conf.BackPressureThreshold, "updated", 100)
+		conf.BackPressureThreshold = 100
+	}
 	return conf
 }
 
@@ -274,6 +332,7 @@ type TxPool struct {
 	txFeed      event.Feed
 	headFeed    event.Feed
 	reorgFeed   event.Feed
+	txEvents    txEventFeed
 	scope       event.SubscriptionScope
 	signer      types.Signer
 	mu          sync.RWMutex
@@ -283,6 +342,9 @@ type TxPool struct {
 	eip1559 atomic.Bool                  // Fork indicator whether we are using EIP-1559 type transactions.
 	eip3860 atomic.Bool                  // Fork indicator whether EIP-3860 is activated. (activated in Shanghai Upgrade in Ethereum)
 
+	nextNumber atomic.Pointer[big.Int] // Number of the block following the currentHead
+	headTime   atomic.Uint64           // Timestamp of the currentHead
+
 	currentHead *types.Header
 	// [currentState] is the state of the blockchain head. It is reset whenever
 	// head changes.
@@ -444,6 +506,7 @@ func (pool *TxPool) loop() {
 					for _, tx := range list {
 						pool.removeTx(tx.Hash(), true)
 					}
+					pool.txEvents.sendRemoved(pool.signer, list, RemovedExpired)
 					queuedEvictionMeter.Mark(int64(len(list)))
 				}
 			}
@@ -496,6 +559,14 @@ func (pool *TxPool) SubscribeNewReorgEvent(ch chan<- core.NewTxPoolReorgEvent) e
 	return pool.scope.Track(pool.reorgFeed.Subscribe(ch))
 }
 
+// SubscribeTxEvents registers a subscription delivering the transactions
+// added to and removed from the pool. Up to [buffer] events are buffered for
+// the subscriber, after which the oldest events are dropped. A non-positive
+// [buffer] selects DefaultTxEventBuffer.
+func (pool *TxPool) SubscribeTxEvents(buffer int) *TxEventSubscription {
+	return pool.txEvents.subscribe(buffer)
+}
+
 // GasPrice returns the current gas price enforced by the transaction pool.
 func (pool *TxPool) GasPrice() *big.Int {
 	pool.mu.RLock()
@@ -519,6 +590,7 @@ func (pool *TxPool) SetGasPrice(price *big.Int) {
 		for _, tx := range drop {
 			pool.removeTx(tx.Hash(), false)
 		}
+		pool.txEvents.sendRemoved(pool.signer, drop, RemovedUnderpriced)
 		pool.priced.Removed(len(drop))
 	}
 
@@ -768,6 +840,10 @@ func (pool *TxPool) validateTxBasics(tx *types.Transaction, local bool) error {
 	if tx.Type() == types.BlobTxType {
 		return core.ErrTxTypeNotSupported
 	}
+	// Reject transactions signed for another chain before verifying the signature
+	if tx.Protected() && tx.ChainId().Cmp(pool.chainconfig.ChainID) != 0 {
+		return fmt.Errorf("%w: tx chain ID %d, chain ID %d", ErrInvalidChainID, tx.ChainId(), pool.chainconfig.ChainID)
+	}
 	// Reject transactions over defined size to prevent DOS attacks
 	if tx.Size() > txMaxSize {
 		return fmt.Errorf("%w tx size %d > max size %d", ErrOversizedData, tx.Size(), txMaxSize)
@@ -801,6 +877,11 @@ func (pool *TxPool) validateTxBasics(tx *types.Transaction, local bool) error {
 	if tx.GasFeeCapIntCmp(tx.GasTipCap()) < 0 {
 		return core.ErrTipAboveFeeCap
 	}
+	if pool.config.StrictIntrinsicGas {
+		if err := pool.validateStrictIntrinsicGas(tx); err != nil {
+			return err
+		}
+	}
 	// Make sure the transaction is signed properly.
 	from, err := types.Sender(pool.signer, tx)
 	if err != nil {
@@ -821,11 +902,38 @@ func (pool *TxPool) validateTxBasics(tx *types.Transaction, local bool) error {
 	return nil
 }
 
+// validateStrictIntrinsicGas returns ErrIntrinsicGas if the gas limit of [tx]
+// is below its intrinsic gas, including its calldata and access list, under
+// the rules of the next block if it was built now. A fork activating after
+// the current head may raise the intrinsic gas from the rules of the head.
+func (pool *TxPool) validateStrictIntrinsicGas(tx *types.Transaction) error {
+	timestamp := uint64(time.Now().Unix())
+	if headTime := pool.headTime.Load(); timestamp < headTime {
+		timestamp = headTime
+	}
+	rules := pool.chainconfig.LuxRules(pool.nextNumber.Load(), timestamp)
+	intrGas, err := core.IntrinsicGas(tx.Data(), tx.AccessList(), tx.To() == nil, rules)
+	if err != nil {
+		return err
+	}
+	if tx.Gas() < intrGas {
+		return fmt.Errorf("%w: tx %s gas (%d) < intrinsic gas (%d)", core.ErrIntrinsicGas, tx.Hash(), tx.Gas(), intrGas)
+	}
+	return nil
+}
+
 // validateTx checks whether a transaction is valid according to the consensus
 // rules and adheres to some heuristic limits of the local node (price and size).
 func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	// Signature has been checked already, this cannot error.
 	from, _ := types.Sender(pool.signer, tx)
+	// Transactions reinjected after a reorg skip the basic validation, so
+	// check them against the rules of the new head.
+	if pool.config.StrictIntrinsicGas {
+		if err := pool.validateStrictIntrinsicGas(tx); err != nil {
+			return err
+		}
+	}
 	// Drop the transaction if the gas fee cap is below the pool's minimum fee
 	if pool.minimumFee != nil && tx.GasFeeCapIntCmp(pool.minimumFee) < 0 {
 		return fmt.Errorf("%w: address %s have gas fee cap (%d) < pool minimum fee cap (%d)", ErrUnderpriced, from.Hex(), tx.GasFeeCap(), pool.minimumFee)
@@ -836,9 +944,71 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	if err := pool.checkTxState(from, tx); err != nil {
 		return err
 	}
+	// Drop non-local transactions moving too little value to be worth including
+	if !local && pool.config.DustThreshold > 0 {
+		if err := pool.checkDust(from, tx); err != nil {
+			return err
+		}
+	}
+	// Stop non-local accounts from filling the pool with their transactions
+	if !local && pool.config.AccountPendingLimit > 0 {
+		if err := pool.checkAccountLimit(from, tx); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// checkAccountLimit returns ErrAccountLimitExceeded if [from] already has
+// AccountPendingLimit transactions in the pool and [tx] does not replace one
+// of them.
+func (pool *TxPool) checkAccountLimit(from common.Address, tx *types.Transaction) error {
+	var count int
+	if list := pool.pending[from]; list != nil {
+		if list.Contains(tx.Nonce()) {
+			return nil
+		}
+		count += list.Len()
+	}
+	if list := pool.queue[from]; list != nil {
+		if list.Contains(tx.Nonce()) {
+			return nil
+		}
+		count += list.Len()
+	}
+	if uint64(count) >= pool.config.AccountPendingLimit {
+		return fmt.Errorf("%w: address %s has %d pooled transactions, limit %d", ErrAccountLimitExceeded, from.Hex(), count, pool.config.AccountPendingLimit)
+	}
+	return nil
+}
+
+// checkDust returns ErrDustTransaction if the transaction's value plus the
+// maximum tip it pays (GasTipCap * Gas) is below the configured dust threshold.
+// Contract interactions are exempt if DustExemptContracts is set.
+func (pool *TxPool) checkDust(from common.Address, tx *types.Transaction) error {
+	if pool.config.DustExemptContracts && pool.isContractInteraction(tx) {
+		return nil
+	}
+	total := new(big.Int).Mul(tx.GasTipCap(), new(big.Int).SetUint64(tx.Gas()))
+	total.Add(total, tx.Value())
+	if total.Cmp(new(big.Int).SetUint64(pool.config.DustThreshold)) < 0 {
+		return fmt.Errorf("%w: address %s value plus tip (%d) < dust threshold (%d)", ErrDustTransaction, from.Hex(), total, pool.config.DustThreshold)
+	}
+	return nil
+}
+
+// isContractInteraction returns true if the transaction creates a contract or
+// targets an address with code in the current state.
+func (pool *TxPool) isContractInteraction(tx *types.Transaction) bool {
+	to := tx.To()
+	if to == nil {
+		return true
+	}
+	pool.currentStateLock.Lock()
+	defer pool.currentStateLock.Unlock()
+	return pool.currentState.GetCodeSize(*to) > 0
+}
+
 // add validates a transaction and inserts it into the non-executable queue for later
 // pending promotion and execution. If the transaction is a replacement for an already
 // pending or queued one, it overwrites the previous transaction if its price is higher.
@@ -862,6 +1032,12 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 	if err := pool.validateTx(tx, isLocal); err != nil {
 		log.Trace("Discarding invalid transaction", "hash", hash, "err", err)
 		invalidTxMeter.Mark(1)
+		switch {
+		case errors.Is(err, ErrDustTransaction):
+			dustTxMeter.Mark(1)
+		case errors.Is(err, ErrAccountLimitExceeded):
+			accountLimitMeter.Mark(1)
+		}
 		return false, err
 	}
 
@@ -924,6 +1100,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 			underpricedTxMeter.Mark(1)
 			dropped := pool.removeTx(tx.Hash(), false)
 			pool.changesSinceReorg += dropped
+			pool.txEvents.send(pool.signer, TxRemoved, tx, RemovedUnderpriced)
 		}
 	}
 
@@ -940,9 +1117,11 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 			pool.all.Remove(old.Hash())
 			pool.priced.Removed(1)
 			pendingReplaceMeter.Mark(1)
+			pool.txEvents.send(pool.signer, TxRemoved, old, RemovedReplaced)
 		}
 		pool.all.Add(tx, isLocal)
 		pool.priced.Put(tx, isLocal)
+		pool.txEvents.send(pool.signer, TxAdded, tx, RemovedNone)
 		pool.journalTx(from, tx)
 		pool.queueTxEvent(tx)
 		log.Trace("Pooled new executable transaction", "hash", hash, "from", from, "to", tx.To())
@@ -956,6 +1135,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 	if err != nil {
 		return false, err
 	}
+	pool.txEvents.send(pool.signer, TxAdded, tx, RemovedNone)
 	// Mark local addresses and journal local transactions
 	if local && !pool.locals.contains(from) {
 		log.Info("Setting new local account", "address", from)
@@ -1013,6 +1193,7 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction, local boo
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed(1)
 		queuedReplaceMeter.Mark(1)
+		pool.txEvents.send(pool.signer, TxRemoved, old, RemovedReplaced)
 	} else {
 		// Nothing was replaced, bump the queued counter
 		queuedGauge.Inc(1)
@@ -1062,6 +1243,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 		pool.all.Remove(hash)
 		pool.priced.Removed(1)
 		pendingDiscardMeter.Mark(1)
+		pool.txEvents.send(pool.signer, TxRemoved, tx, RemovedUnderpriced)
 		return false
 	}
 	// Otherwise discard any previous transaction and mark this
@@ -1069,6 +1251,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed(1)
 		pendingReplaceMeter.Mark(1)
+		pool.txEvents.send(pool.signer, TxRemoved, old, RemovedReplaced)
 	} else {
 		// Nothing was replaced, bump the pending counter
 		pendingGauge.Inc(1)
@@ -1087,7 +1270,41 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 // This method is used to add transactions from the RPC API and performs synchronous pool
 // reorganization and event propagation.
 func (pool *TxPool) AddLocals(txs []*types.Transaction) []error {
-	return pool.addTxs(txs, !pool.config.NoLocals, true)
+	local := !pool.config.NoLocals
+	if local || pool.config.BackPressureThreshold == 0 {
+		return pool.addTxs(txs, local, true)
+	}
+	// Signal back-pressure to the submitters of remote transactions once the
+	// pool is nearly full, instead of underpricing them on admission.
+	var (
+		errs    = make([]error, len(txs))
+		admit   = make([]*types.Transaction, 0, len(txs))
+		indices = make([]int, 0, len(txs))
+	)
+	pool.mu.RLock()
+	busy := pool.busy()
+	for i, tx := range txs {
+		if busy && !pool.locals.containsTx(tx) {
+			errs[i] = ErrTxPoolBusy
+			backPressureMeter.Mark(1)
+			continue
+		}
+		admit = append(admit, tx)
+		indices = append(indices, i)
+	}
+	pool.mu.RUnlock()
+
+	for i, err := range pool.addTxs(admit, false, true) {
+		errs[indices[i]] = err
+	}
+	return errs
+}
+
+// busy returns whether the slots in use reached the back-pressure threshold
+// of the pool's capacity.
+func (pool *TxPool) busy() bool {
+	capacity := pool.config.GlobalSlots + pool.config.GlobalQueue
+	return uint64(pool.all.Slots())*100 >= pool.config.BackPressureThreshold*capacity
 }
 
 // AddLocal enqueues a single local transaction into the pool if it is valid. This is
@@ -1239,7 +1456,10 @@ func (pool *TxPool) RemoveTx(hash common.Hash) {
 	pool.mu.Lock()
 	defer pool.mu.Unlock()
 
-	pool.removeTx(hash, true)
+	if tx := pool.all.Get(hash); tx != nil {
+		pool.removeTx(hash, true)
+		pool.txEvents.send(pool.signer, TxRemoved, tx, RemovedDropped)
+	}
 }
 
 // removeTx removes a single transaction from the queue, moving all subsequent
@@ -1582,6 +1802,8 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	rules := pool.chainconfig.LuxRules(next, newHead.Time)
 
 	pool.rules.Store(&rules)
+	pool.nextNumber.Store(next)
+	pool.headTime.Store(newHead.Time)
 	pool.eip2718.Store(rules.IsEVM)
 	pool.eip1559.Store(rules.IsEVM)
 	pool.eip3860.Store(rules.IsDUpgrade)
@@ -1609,6 +1831,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) []*types.Trans
 			hash := tx.Hash()
 			pool.all.Remove(hash)
 		}
+		pool.txEvents.sendRemoved(pool.signer, forwards, RemovedIncluded)
 		log.Trace("Removed old queued transactions", "count", len(forwards))
 		// Drop all transactions that are too costly (low balance or out of gas)
 		drops, _ := list.Filter(pool.currentState.GetBalance(addr), pool.currentMaxGas.Load())
@@ -1616,6 +1839,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) []*types.Trans
 			hash := tx.Hash()
 			pool.all.Remove(hash)
 		}
+		pool.txEvents.sendRemoved(pool.signer, drops, RemovedUnpayable)
 		log.Trace("Removed unpayable queued transactions", "count", len(drops))
 		queuedNofundsMeter.Mark(int64(len(drops)))
 
@@ -1639,6 +1863,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) []*types.Trans
 				pool.all.Remove(hash)
 				log.Trace("Removed cap-exceeding queued transaction", "hash", hash)
 			}
+			pool.txEvents.sendRemoved(pool.signer, caps, RemovedEvicted)
 			queuedRateLimitMeter.Mark(int64(len(caps)))
 		}
 		// Mark all the items dropped as removed
@@ -1704,6 +1929,7 @@ func (pool *TxPool) truncatePending() {
 						pool.pendingNonces.setIfLower(offenders[i], tx.Nonce())
 						log.Trace("Removed fairness-exceeding pending transaction", "hash", hash)
 					}
+					pool.txEvents.sendRemoved(pool.signer, caps, RemovedEvicted)
 					pool.priced.Removed(len(caps))
 					pendingGauge.Dec(int64(len(caps)))
 					if pool.locals.contains(offenders[i]) {
@@ -1731,6 +1957,7 @@ func (pool *TxPool) truncatePending() {
 					pool.pendingNonces.setIfLower(addr, tx.Nonce())
 					log.Trace("Removed fairness-exceeding pending transaction", "hash", hash)
 				}
+				pool.txEvents.sendRemoved(pool.signer, caps, RemovedEvicted)
 				pool.priced.Removed(len(caps))
 				pendingGauge.Dec(int64(len(caps)))
 				if pool.locals.contains(addr) {
@@ -1753,14 +1980,24 @@ func (pool *TxPool) truncateQueue() {
 		return
 	}
 
-	// Sort all accounts with queued transactions by heartbeat
+	// Sort all accounts with queued transactions by heartbeat, accounts within
+	// their grace period last so that they are only dropped if the others do
+	// not make enough room
 	addresses := make(addressesByHeartbeat, 0, len(pool.queue))
+	graced := make(addressesByHeartbeat, 0)
 	for addr := range pool.queue {
-		if !pool.locals.contains(addr) { // don't drop locals
-			addresses = append(addresses, addressByHeartbeat{addr, pool.beats[addr]})
+		if pool.locals.contains(addr) { // don't drop locals
+			continue
+		}
+		if time.Since(pool.beats[addr]) < pool.config.QueueGracePeriod {
+			graced = append(graced, addressByHeartbeat{addr, pool.beats[addr]})
+			continue
 		}
+		addresses = append(addresses, addressByHeartbeat{addr, pool.beats[addr]})
 	}
 	sort.Sort(sort.Reverse(addresses))
+	sort.Sort(sort.Reverse(graced))
+	addresses = append(graced, addresses...)
 
 	// Drop transactions until the total is below the limit or only locals remain
 	for drop := queued - pool.config.GlobalQueue; drop > 0 && len(addresses) > 0; {
@@ -1771,9 +2008,11 @@ func (pool *TxPool) truncateQueue() {
 
 		// Drop all transactions if they are less than the overflow
 		if size := uint64(list.Len()); size <= drop {
-			for _, tx := range list.Flatten() {
+			txs := list.Flatten()
+			for _, tx := range txs {
 				pool.removeTx(tx.Hash(), true)
 			}
+			pool.txEvents.sendRemoved(pool.signer, txs, RemovedEvicted)
 			drop -= size
 			queuedRateLimitMeter.Mark(int64(size))
 			continue
@@ -1782,6 +2021,7 @@ func (pool *TxPool) truncateQueue() {
 		txs := list.Flatten()
 		for i := len(txs) - 1; i >= 0 && drop > 0; i-- {
 			pool.removeTx(txs[i].Hash(), true)
+			pool.txEvents.send(pool.signer, TxRemoved, txs[i], RemovedEvicted)
 			drop--
 			queuedRateLimitMeter.Mark(1)
 		}
@@ -1810,6 +2050,7 @@ func (pool *TxPool) demoteUnexecutables() {
 			pool.all.Remove(hash)
 			log.Trace("Removed old pending transaction", "hash", hash)
 		}
+		pool.txEvents.sendRemoved(pool.signer, olds, RemovedIncluded)
 		// Drop all transactions that are too costly (low balance or out of gas), and queue any invalids back for later
 		drops, invalids := list.Filter(pool.currentState.GetBalance(addr), pool.currentMaxGas.Load())
 		for _, tx := range drops {
@@ -1817,6 +2058,7 @@ func (pool *TxPool) demoteUnexecutables() {
 			log.Trace("Removed unpayable pending transaction", "hash", hash)
 			pool.all.Remove(hash)
 		}
+		pool.txEvents.sendRemoved(pool.signer, drops, RemovedUnpayable)
 		pendingNofundsMeter.Mark(int64(len(drops)))
 
 		for _, tx := range invalids {
@@ -484,6 +484,250 @@ func TestVeryHighValues(t *testing.T) {
 	}
 }
 
+// Tests that the dust filter rejects remote transfers moving less than the
+// configured threshold, while contract interactions can be exempted.
+func TestDustFilter(t *testing.T) {
+	t.Parallel()
+
+	for _, exempt := range []bool{true, false} {
+		statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		blockchain := newTestBlockChain(1000000, statedb, new(event.Feed))
+
+		config := testTxPoolConfig
+		config.DustThreshold = 1000000
+		config.DustExemptContracts = exempt
+		pool := NewTxPool(config, params.TestChainConfig, blockchain)
+		<-pool.initDoneCh
+
+		key, _ := crypto.GenerateKey()
+		account := crypto.PubkeyToAddress(key.PublicKey)
+		testAddBalance(pool, account, big.NewInt(1000000000))
+
+		contract := common.HexToAddress("0xc0ffee")
+		pool.currentStateLock.Lock()
+		pool.currentState.SetCode(contract, []byte{0x00})
+		pool.currentStateLock.Unlock()
+
+		// A simple transfer paying 100 wei plus 21000 wei in tips is dust
+		transfer := transaction(0, 21000, key)
+		if err := pool.AddRemote(transfer); !errors.Is(err, ErrDustTransaction) {
+			t.Errorf("exempt=%v: expected %v, got %v", exempt, ErrDustTransaction, err)
+		}
+		// The same amount sent to a contract is only accepted if contracts are exempt
+		call, _ := types.SignTx(types.NewTransaction(0, contract, big.NewInt(100), 21000, big.NewInt(1), nil), types.HomesteadSigner{}, key)
+		err := pool.AddRemote(call)
+		switch {
+		case exempt && err != nil:
+			t.Errorf("exempt=%v: failed to add contract call: %v", exempt, err)
+		case !exempt && !errors.Is(err, ErrDustTransaction):
+			t.Errorf("exempt=%v: expected %v, got %v", exempt, ErrDustTransaction, err)
+		}
+		// Transfers above the threshold are accepted
+		if err := pool.AddRemote(pricedTransaction(1, 21000, big.NewInt(100), key)); err != nil {
+			t.Errorf("exempt=%v: failed to add transaction above dust threshold: %v", exempt, err)
+		}
+		pool.Stop()
+	}
+}
+
+// Tests that a remote account with the maximum number of transactions in the
+// pool is throttled until earlier ones clear, without affecting replacements
+// or other accounts.
+func TestAccountPendingLimit(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := newTestBlockChain(1000000, statedb, new(event.Feed))
+
+	config := testTxPoolConfig
+	config.AccountPendingLimit = 4
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	<-pool.initDoneCh
+	defer pool.Stop()
+
+	key, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	testAddBalance(pool, addr, big.NewInt(1000000000))
+	testAddBalance(pool, crypto.PubkeyToAddress(other.PublicKey), big.NewInt(1000000000))
+
+	// Fill the allowance of the account with pending and queued transactions
+	for _, nonce := range []uint64{0, 1, 2, 5} {
+		if err := pool.AddRemote(transaction(nonce, 100000, key)); err != nil {
+			t.Fatalf("nonce %d: failed to add transaction: %v", nonce, err)
+		}
+	}
+	if err := pool.AddRemote(transaction(3, 100000, key)); !errors.Is(err, ErrAccountLimitExceeded) {
+		t.Fatalf("expected %v, got %v", ErrAccountLimitExceeded, err)
+	}
+	// Replacing pooled transactions is still possible
+	if err := pool.AddRemote(pricedTransaction(1, 100000, big.NewInt(2), key)); err != nil {
+		t.Fatalf("failed to replace pending transaction: %v", err)
+	}
+	if err := pool.AddRemote(pricedTransaction(5, 100000, big.NewInt(2), key)); err != nil {
+		t.Fatalf("failed to replace queued transaction: %v", err)
+	}
+	// Other accounts are not affected
+	for nonce := uint64(0); nonce < 4; nonce++ {
+		if err := pool.AddRemote(transaction(nonce, 100000, other)); err != nil {
+			t.Fatalf("nonce %d: failed to add transaction of other account: %v", nonce, err)
+		}
+	}
+	// Once earlier transactions are included, the account may submit again
+	testSetNonce(pool, addr, 4)
+	<-pool.requestReset(nil, nil)
+
+	if err := pool.AddRemote(transaction(4, 100000, key)); err != nil {
+		t.Fatalf("failed to add transaction after earlier ones cleared: %v", err)
+	}
+	if err := validatePoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that once the pool is filled above the back-pressure threshold,
+// transactions submitted over RPC are rejected with ErrTxPoolBusy unless they
+// are local.
+func TestBackPressure(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := newTestBlockChain(1000000, statedb, new(event.Feed))
+
+	config := testTxPoolConfig
+	config.NoLocals = true
+	config.GlobalSlots = 4
+	config.GlobalQueue = 4
+	config.BackPressureThreshold = 50
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	<-pool.initDoneCh
+	defer pool.Stop()
+
+	keys := make([]*ecdsa.PrivateKey, 3)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		testAddBalance(pool, crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000000))
+	}
+	// Submissions are accepted until half of the slots are used
+	for nonce := uint64(0); nonce < 4; nonce++ {
+		if err := pool.AddLocal(transaction(nonce, 100000, keys[0])); err != nil {
+			t.Fatalf("nonce %d: failed to add transaction: %v", nonce, err)
+		}
+	}
+	if err := pool.AddLocal(transaction(0, 100000, keys[1])); !errors.Is(err, ErrTxPoolBusy) {
+		t.Fatalf("expected %v, got %v", ErrTxPoolBusy, err)
+	}
+	// Transactions of tracked local accounts are exempt
+	pool.mu.Lock()
+	pool.locals.add(crypto.PubkeyToAddress(keys[2].PublicKey))
+	pool.mu.Unlock()
+	if err := pool.AddLocal(transaction(0, 100000, keys[2])); err != nil {
+		t.Fatalf("failed to add transaction of local account: %v", err)
+	}
+	// Gossiped transactions are not subject to back-pressure
+	if err := pool.addRemoteSync(transaction(0, 100000, keys[1])); err != nil {
+		t.Fatalf("failed to add remote transaction: %v", err)
+	}
+}
+
+// Tests that transactions signed for a chain ID other than the one of the chain
+// are rejected before their signature is verified.
+func TestRejectWrongChainID(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupPool()
+	defer pool.Stop()
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+
+	wrongChainID := new(big.Int).Add(params.TestChainConfig.ChainID, common.Big1)
+	tx, _ := types.SignTx(types.NewTransaction(0, common.Address{}, big.NewInt(100), 21000, big.NewInt(1), nil), types.NewEIP155Signer(wrongChainID), key)
+	if err := pool.AddRemote(tx); !errors.Is(err, ErrInvalidChainID) {
+		t.Fatalf("expected %v, got %v", ErrInvalidChainID, err)
+	}
+	// The same transaction signed for the chain ID of the node is accepted
+	tx, _ = types.SignTx(types.NewTransaction(0, common.Address{}, big.NewInt(100), 21000, big.NewInt(1), nil), types.NewEIP155Signer(params.TestChainConfig.ChainID), key)
+	if err := pool.AddRemote(tx); err != nil {
+		t.Fatalf("failed to add transaction signed for the chain ID of the node: %v", err)
+	}
+}
+
+// Tests that strict intrinsic gas validation rejects transactions whose gas
+// limit does not cover their calldata, under the forks active for a block built
+// now rather than at the head.
+func TestStrictIntrinsicGas(t *testing.T) {
+	t.Parallel()
+
+	// DUpgrade activates after the head (at timestamp 0), pricing init code.
+	chainConfig := *params.TestChainConfig
+	chainConfig.MandatoryNetworkUpgrades.DUpgradeTimestamp = utils.NewUint64(1)
+	headRules := chainConfig.LuxRules(common.Big1, 0)
+	nextRules := chainConfig.LuxRules(common.Big1, uint64(time.Now().Unix()))
+
+	newPool := func(strict bool) (*TxPool, *ecdsa.PrivateKey) {
+		statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		config := testTxPoolConfig
+		config.StrictIntrinsicGas = strict
+		pool := NewTxPool(config, &chainConfig, newTestBlockChain(10000000, statedb, new(event.Feed)))
+		<-pool.initDoneCh
+
+		key, _ := crypto.GenerateKey()
+		testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000000))
+		return pool, key
+	}
+	// Calldata mixing zero and non-zero bytes
+	data := make([]byte, 4096)
+	for i := range data {
+		if i%3 != 0 {
+			data[i] = byte(i)
+		}
+	}
+
+	pool, key := newPool(true)
+	defer pool.Stop()
+	intrGas, err := core.IntrinsicGas(data, nil, false, nextRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, _ := types.SignTx(types.NewTransaction(0, common.Address{}, big.NewInt(0), intrGas-1, big.NewInt(1), data), types.HomesteadSigner{}, key)
+	if err := pool.AddRemote(tx); !errors.Is(err, core.ErrIntrinsicGas) {
+		t.Fatalf("expected %v, got %v", core.ErrIntrinsicGas, err)
+	}
+	tx, _ = types.SignTx(types.NewTransaction(0, common.Address{}, big.NewInt(0), intrGas, big.NewInt(1), data), types.HomesteadSigner{}, key)
+	if err := pool.AddRemote(tx); err != nil {
+		t.Fatalf("failed to add transaction covering its intrinsic gas: %v", err)
+	}
+
+	// A contract creation covering its intrinsic gas at the head only is
+	// rejected in strict mode.
+	headGas, err := core.IntrinsicGas(data, nil, true, headRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nextGas, err := core.IntrinsicGas(data, nil, true, nextRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if headGas >= nextGas {
+		t.Fatalf("intrinsic gas at head (%d) should be below the next block (%d)", headGas, nextGas)
+	}
+	tx, _ = types.SignTx(types.NewContractCreation(1, big.NewInt(0), headGas, big.NewInt(1), data), types.HomesteadSigner{}, key)
+	if err := pool.AddRemote(tx); !errors.Is(err, core.ErrIntrinsicGas) {
+		t.Fatalf("expected %v, got %v", core.ErrIntrinsicGas, err)
+	}
+	tx, _ = types.SignTx(types.NewContractCreation(1, big.NewInt(0), nextGas, big.NewInt(1), data), types.HomesteadSigner{}, key)
+	if err := pool.AddRemote(tx); err != nil {
+		t.Fatalf("failed to add contract creation covering its intrinsic gas: %v", err)
+	}
+
+	// Without strict validation, only the rules of the head apply.
+	lenient, key := newPool(false)
+	defer lenient.Stop()
+	tx, _ = types.SignTx(types.NewContractCreation(0, big.NewInt(0), headGas, big.NewInt(1), data), types.HomesteadSigner{}, key)
+	if err := lenient.AddRemote(tx); err != nil {
+		t.Fatalf("failed to add contract creation without strict validation: %v", err)
+	}
+}
+
 func TestChainFork(t *testing.T) {
 	t.Parallel()
 
@@ -1007,6 +1251,83 @@ func testQueueGlobalLimiting(t *testing.T, nolocals bool) {
 	}
 }
 
+// Tests that gapped transactions of an account within its grace period are
+// evicted after those of accounts out of their grace period when the global
+// queue overflows, so that they can still be promoted once the gap is filled,
+// and that the global limit is enforced nonetheless.
+func TestQueueGracePeriod(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := newTestBlockChain(1000000, statedb, new(event.Feed))
+
+	config := testTxPoolConfig
+	config.NoLocals = true
+	config.GlobalQueue = 3
+	config.QueueGracePeriod = time.Hour
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	first, _ := crypto.GenerateKey()
+	second, _ := crypto.GenerateKey()
+	firstAddr, secondAddr := crypto.PubkeyToAddress(first.PublicKey), crypto.PubkeyToAddress(second.PublicKey)
+	testAddBalance(pool, firstAddr, big.NewInt(1000000))
+	testAddBalance(pool, secondAddr, big.NewInt(1000000))
+
+	// The second account queues gapped transactions and leaves its grace period.
+	pool.AddRemotesSync([]*types.Transaction{transaction(1, 100000, second), transaction(2, 100000, second), transaction(3, 100000, second)})
+	pool.mu.Lock()
+	pool.beats[secondAddr] = time.Now().Add(-2 * config.QueueGracePeriod)
+	pool.mu.Unlock()
+
+	// The first account overflows the global queue, evicting the second's.
+	pool.AddRemotesSync([]*types.Transaction{transaction(1, 100000, first), transaction(2, 100000, first)})
+	pending, queued := pool.Stats()
+	if pending != 0 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 0)
+	}
+	if queued != int(config.GlobalQueue) {
+		t.Fatalf("queued transactions mismatched: have %d, want %d", queued, int(config.GlobalQueue))
+	}
+	if pool.queue[firstAddr].Len() != 2 {
+		t.Fatalf("queued transactions of the graced account mismatched: have %d, want %d", pool.queue[firstAddr].Len(), 2)
+	}
+	if err := validatePoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+
+	// Filling the gap promotes the retained transactions.
+	pool.AddRemotesSync([]*types.Transaction{transaction(0, 100000, first)})
+	pending, queued = pool.Stats()
+	if pending != 3 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 3)
+	}
+	if queued != 1 {
+		t.Fatalf("queued transactions mismatched: have %d, want %d", queued, 1)
+	}
+	if err := validatePoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+
+	// Accounts within their grace period are evicted once no other is left,
+	// so that the global queue stays bounded.
+	pool.AddRemotesSync([]*types.Transaction{transaction(4, 100000, first), transaction(5, 100000, first), transaction(6, 100000, first), transaction(7, 100000, first)})
+	pending, queued = pool.Stats()
+	if pending != 3 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 3)
+	}
+	if queued != int(config.GlobalQueue) {
+		t.Fatalf("queued transactions mismatched: have %d, want %d", queued, int(config.GlobalQueue))
+	}
+	if _, ok := pool.queue[secondAddr]; ok {
+		t.Fatalf("account out of its grace period was not evicted first")
+	}
+	if err := validatePoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
 // Tests that if an account remains idle for a prolonged amount of time, any
 // non-executable transactions queued up are dropped to prevent wasting resources
 // on shuffling them around.
@@ -2204,6 +2525,42 @@ func TestReplacement(t *testing.T) {
 	}
 }
 
+// Tests that the pool enforces a configured price bump other than the default
+// when replacing pending and queued transactions.
+func TestReplacementConfiguredPriceBump(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := newTestBlockChain(1000000, statedb, new(event.Feed))
+
+	config := testTxPoolConfig
+	config.PriceBump = 25
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	key, _ := crypto.GenerateKey()
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+
+	price := int64(1000)
+	threshold := price * (100 + int64(config.PriceBump)) / 100 // 1250
+
+	// Nonce 0 is pending, nonce 2 is queued
+	for _, nonce := range []uint64{0, 2} {
+		if err := pool.addRemoteSync(pricedTransaction(nonce, 100000, big.NewInt(price), key)); err != nil {
+			t.Fatalf("nonce %d: failed to add original transaction: %v", nonce, err)
+		}
+		if err := pool.addRemoteSync(pricedTransaction(nonce, 100001, big.NewInt(threshold-1), key)); err != ErrReplaceUnderpriced {
+			t.Fatalf("nonce %d: replacement just below the price bump error mismatch: have %v, want %v", nonce, err, ErrReplaceUnderpriced)
+		}
+		if err := pool.addRemoteSync(pricedTransaction(nonce, 100000, big.NewInt(threshold+1), key)); err != nil {
+			t.Fatalf("nonce %d: failed to replace transaction just above the price bump: %v", nonce, err)
+		}
+	}
+	if err := validatePoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
 // Tests that the pool rejects replacement dynamic fee transactions that don't
 // meet the minimum price bump required.
 func TestReplacementDynamicFee(t *testing.T) {
@@ -5,6 +5,7 @@ package vm
 
 import (
 	"github.com/luxdefi/evm/precompile/contract"
+	"github.com/luxdefi/evm/vmerrs"
 	"github.com/ethereum/go-ethereum/common"
 )
 
@@ -29,3 +30,52 @@ func (w *wrappedPrecompiledContract) Run(accessibleState contract.AccessibleStat
 func RunStatefulPrecompiledContract(precompile contract.StatefulPrecompiledContract, accessibleState contract.AccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
 	return precompile.Run(accessibleState, caller, addr, input, suppliedGas, readOnly)
 }
+
+// runStatefulPrecompile runs [precompile] at [addr], enforcing the maximum
+// number of storage writes a precompile may perform per block set in the chain
+// config after the PrecompileStorageWriteLimit upgrade. A call exceeding the
+// limit fails and consumes all of its gas.
+func (evm *EVM) runStatefulPrecompile(precompile contract.StatefulPrecompiledContract, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+	limit := evm.chainConfig.PrecompileStorageWriteLimit
+	if limit == 0 || !evm.chainConfig.IsPrecompileStorageWriteLimit(evm.Context.Time) {
+		return RunStatefulPrecompiledContract(precompile, evm, caller, addr, input, suppliedGas, readOnly)
+	}
+	state := &writeLimitedState{EVM: evm, addr: addr, limit: limit}
+	ret, remainingGas, err = RunStatefulPrecompiledContract(precompile, state, caller, addr, input, suppliedGas, readOnly)
+	if state.exceeded {
+		return nil, 0, vmerrs.ErrPrecompileWriteLimit
+	}
+	return ret, remainingGas, err
+}
+
+// writeLimitedState is the contract.AccessibleState of a stateful precompile
+// at [addr] whose storage writes count towards [limit].
+type writeLimitedState struct {
+	*EVM
+	addr     common.Address
+	limit    uint64
+	exceeded bool
+}
+
+func (s *writeLimitedState) GetStateDB() contract.StateDB {
+	return &writeLimitedStateDB{StateDB: s.EVM.StateDB, state: s}
+}
+
+// writeLimitedStateDB drops the storage writes of a precompile once it has
+// reached the limit of its writeLimitedState.
+type writeLimitedStateDB struct {
+	StateDB
+	state *writeLimitedState
+}
+
+func (s *writeLimitedStateDB) SetState(addr common.Address, key common.Hash, value common.Hash) {
+	if s.state.exceeded {
+		return
+	}
+	if s.StateDB.GetPrecompileStorageWrites(s.state.addr) >= s.state.limit {
+		s.state.exceeded = true
+		return
+	}
+	s.StateDB.AddPrecompileStorageWrite(s.state.addr)
+	s.StateDB.SetState(addr, key, value)
+}
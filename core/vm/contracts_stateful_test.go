@@ -0,0 +1,89 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/luxdefi/evm/core/rawdb"
+	"github.com/luxdefi/evm/core/state"
+	"github.com/luxdefi/evm/core/types"
+	"github.com/luxdefi/evm/params"
+	"github.com/luxdefi/evm/precompile/contract"
+	"github.com/luxdefi/evm/utils"
+	"github.com/luxdefi/evm/vmerrs"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+// storageWriter is a stateful precompile writing to as many storage slots as
+// the first byte of its input, starting at the slot given by the second byte.
+type storageWriter struct{}
+
+func (storageWriter) Run(accessibleState contract.AccessibleState, _ common.Address, addr common.Address, input []byte, suppliedGas uint64, _ bool) ([]byte, uint64, error) {
+	stateDB := accessibleState.GetStateDB()
+	for i := 0; i < int(input[0]); i++ {
+		stateDB.SetState(addr, common.BigToHash(big.NewInt(int64(input[1])+int64(i))), common.Hash{1})
+	}
+	return nil, suppliedGas, nil
+}
+
+func TestPrecompileStorageWriteLimit(t *testing.T) {
+	require := require.New(t)
+
+	addr := common.Address{0x03, 0x01}
+	config := *params.TestChainConfig
+	config.PrecompileStorageWriteLimit = 3
+	config.PrecompileStorageWriteLimitTimestamp = utils.NewUint64(10)
+	newEVMAt := func(time uint64) (*EVM, *state.StateDB) {
+		statedb, err := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		require.NoError(err)
+		return NewEVM(BlockContext{BlockNumber: big.NewInt(0), Time: time}, TxContext{}, statedb, &config, Config{}), statedb
+	}
+	newEVM := func() (*EVM, *state.StateDB) {
+		return newEVMAt(10)
+	}
+
+	// no limit is enforced before the upgrade
+	evm, statedb := newEVMAt(9)
+	_, _, err := evm.runStatefulPrecompile(storageWriter{}, common.Address{}, addr, []byte{10, 0}, 100, false)
+	require.NoError(err)
+	require.Zero(statedb.GetPrecompileStorageWrites(addr))
+
+	// writes under the limit succeed and are counted for the block
+	evm, statedb = newEVM()
+	_, remainingGas, err := evm.runStatefulPrecompile(storageWriter{}, common.Address{}, addr, []byte{2, 0}, 100, false)
+	require.NoError(err)
+	require.EqualValues(100, remainingGas)
+	require.EqualValues(2, statedb.GetPrecompileStorageWrites(addr))
+
+	// a call exceeding the limit fails, consumes all gas and has its
+	// counted writes reverted along with the call
+	snapshot := statedb.Snapshot()
+	_, remainingGas, err = evm.runStatefulPrecompile(storageWriter{}, common.Address{}, addr, []byte{2, 2}, 100, false)
+	require.ErrorIs(err, vmerrs.ErrPrecompileWriteLimit)
+	require.Zero(remainingGas)
+	statedb.RevertToSnapshot(snapshot)
+	require.EqualValues(2, statedb.GetPrecompileStorageWrites(addr))
+	require.Equal(common.Hash{}, statedb.GetState(addr, common.BigToHash(big.NewInt(2))))
+
+	// the remaining write of the block is still allowed
+	_, _, err = evm.runStatefulPrecompile(storageWriter{}, common.Address{}, addr, []byte{1, 2}, 100, false)
+	require.NoError(err)
+	require.Equal(common.Hash{1}, statedb.GetState(addr, common.BigToHash(big.NewInt(2))))
+
+	// the limit applies per block
+	evm, statedb = newEVM()
+	_, _, err = evm.runStatefulPrecompile(storageWriter{}, common.Address{}, addr, []byte{3, 0}, 100, false)
+	require.NoError(err)
+	require.EqualValues(3, statedb.GetPrecompileStorageWrites(addr))
+
+	// no limit is enforced if it is not configured
+	config.PrecompileStorageWriteLimit = 0
+	evm, statedb = newEVM()
+	_, _, err = evm.runStatefulPrecompile(storageWriter{}, common.Address{}, addr, []byte{10, 0}, 100, false)
+	require.NoError(err)
+	require.Zero(statedb.GetPrecompileStorageWrites(addr))
+}
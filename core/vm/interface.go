@@ -90,6 +90,9 @@ type StateDB interface {
 	GetPredicateStorageSlots(address common.Address, index int) ([]byte, bool)
 	SetPredicateStorageSlots(address common.Address, predicates [][]byte)
 
+	GetPrecompileStorageWrites(address common.Address) uint64
+	AddPrecompileStorageWrite(address common.Address)
+
 	GetTxHash() common.Hash
 
 	AddPreimage(common.Hash, []byte)
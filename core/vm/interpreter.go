@@ -155,6 +155,8 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 		logged  bool   // deferred EVMLogger should ignore already logged steps
 		res     []byte // result of the opcode execution function
 		debug   = in.evm.Config.Tracer != nil
+
+		countOpcodes = opcodeMetricsEnabled.Load() // count executed opcodes for profiling
 	)
 
 	// Don't move this deferred function, it's placed before the capturestate-deferred method,
@@ -188,6 +190,9 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 		// Get the operation from the jump table and validate the stack to ensure there are
 		// enough stack items available to perform the operation.
 		op = contract.GetOp(pc)
+		if countOpcodes {
+			opcodeCounts[op].Add(1)
+		}
 		operation := in.table[op]
 		cost = operation.constantGas // For tracing
 		// Validate stack
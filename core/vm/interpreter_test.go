@@ -85,3 +85,48 @@ func TestLoopInterrupt(t *testing.T) {
 		}
 	}
 }
+
+func TestOpcodeMetrics(t *testing.T) {
+	address := common.BytesToAddress([]byte("contract"))
+	vmctx := BlockContext{
+		Transfer: func(StateDB, common.Address, common.Address, *big.Int) {},
+	}
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.CreateAccount(address)
+	// push(1) push(2) add push(0) mstore stop
+	statedb.SetCode(address, common.Hex2Bytes("600160020160005200"))
+	statedb.Finalise(true)
+	evm := NewEVM(vmctx, TxContext{}, statedb, params.TestChainConfig, Config{})
+
+	ops := []OpCode{PUSH1, ADD, MSTORE, STOP, MUL}
+	run := func() map[OpCode]uint64 {
+		before := make(map[OpCode]uint64, len(ops))
+		for _, op := range ops {
+			before[op] = OpcodeCount(op)
+		}
+		if _, _, err := evm.Call(AccountRef(common.Address{}), address, nil, math.MaxUint64, new(big.Int)); err != nil {
+			t.Fatal(err)
+		}
+		executed := make(map[OpCode]uint64, len(ops))
+		for _, op := range ops {
+			executed[op] = OpcodeCount(op) - before[op]
+		}
+		return executed
+	}
+
+	// Nothing is counted while opcode metrics are disabled.
+	for op, count := range run() {
+		if count != 0 {
+			t.Errorf("expected %v not to be counted while disabled, got %d", op, count)
+		}
+	}
+
+	EnableOpcodeMetrics()
+	defer DisableOpcodeMetrics()
+	expected := map[OpCode]uint64{PUSH1: 3, ADD: 1, MSTORE: 1, STOP: 1, MUL: 0}
+	for op, count := range run() {
+		if count != expected[op] {
+			t.Errorf("expected %v to be executed %d times, got %d", op, expected[op], count)
+		}
+	}
+}
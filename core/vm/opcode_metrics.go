@@ -0,0 +1,53 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/luxdefi/evm/metrics"
+)
+
+var (
+	// opcodeMetricsEnabled is checked once per interpreter run, so counting
+	// opcodes costs a single predictable branch per opcode when disabled.
+	opcodeMetricsEnabled  atomic.Bool
+	registerOpcodeMetrics sync.Once
+
+	// opcodeCounts holds the number of times each opcode was executed since
+	// opcode metrics were enabled.
+	opcodeCounts [256]atomic.Uint64
+)
+
+// EnableOpcodeMetrics starts counting the opcodes executed by all interpreters,
+// exported as the vm/opcodes/<name> metrics. Intended for profiling, counting
+// adds an atomic increment to the execution of every opcode.
+func EnableOpcodeMetrics() {
+	registerOpcodeMetrics.Do(func() {
+		for op := range opcodeCounts {
+			name, ok := opCodeToString[OpCode(op)]
+			if !ok {
+				continue
+			}
+			count := &opcodeCounts[op]
+			metrics.NewRegisteredFunctionalGauge("vm/opcodes/"+name, nil, func() int64 {
+				return int64(count.Load())
+			})
+		}
+	})
+	opcodeMetricsEnabled.Store(true)
+}
+
+// DisableOpcodeMetrics stops counting executed opcodes. Counts recorded so far
+// are kept.
+func DisableOpcodeMetrics() {
+	opcodeMetricsEnabled.Store(false)
+}
+
+// OpcodeCount returns the number of times [op] was executed while opcode
+// metrics were enabled.
+func OpcodeCount(op OpCode) uint64 {
+	return opcodeCounts[op].Load()
+}
@@ -178,6 +178,21 @@ func (api *AdminAPI) ImportChain(file string) (bool, error) {
 	return true, nil
 }
 
+// PinContract preloads the account and storage trie nodes of a contract and
+// keeps them in memory, exempt from cache eviction, until it is unpinned.
+func (api *AdminAPI) PinContract(address common.Address) (bool, error) {
+	if err := api.eth.BlockChain().PinContract(address); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// UnpinContract releases the trie nodes pinned for a contract by PinContract.
+// It returns false if the contract was not pinned.
+func (api *AdminAPI) UnpinContract(address common.Address) (bool, error) {
+	return api.eth.BlockChain().UnpinContract(address)
+}
+
 // DebugAPI is the collection of Ethereum full node APIs for debugging the
 // protocol.
 type DebugAPI struct {
@@ -29,6 +29,7 @@ package eth
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math/big"
 	"time"
 
@@ -38,8 +39,8 @@ import (
 	"github.com/luxdefi/evm/consensus/dummy"
 	"github.com/luxdefi/evm/core"
 	"github.com/luxdefi/evm/core/bloombits"
-	"github.com/luxdefi/evm/core/rawdb"
 	"github.com/luxdefi/evm/core/state"
+	"github.com/luxdefi/evm/core/txpool"
 	"github.com/luxdefi/evm/core/types"
 	"github.com/luxdefi/evm/core/vm"
 	"github.com/luxdefi/evm/eth/gasprice"
@@ -60,6 +61,10 @@ type EthAPIBackend struct {
 	allowUnprotectedTxHashes map[common.Hash]struct{} // Invariant: read-only after creation.
 	eth                      *Ethereum
 	gpo                      *gasprice.Oracle
+
+	// historicalStateWindow bounds how far behind the last accepted block
+	// HistoricalBalance may regenerate pruned state.
+	historicalStateWindow uint64
 }
 
 // ChainConfig returns the active chain configuration.
@@ -256,6 +261,17 @@ func (b *EthAPIBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockN
 	return nil, nil, errors.New("invalid arguments; neither block nor hash specified")
 }
 
+func (b *EthAPIBackend) HistoricalBalance(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*big.Int, error) {
+	block, err := b.BlockByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, errors.New("block not found")
+	}
+	return b.eth.BalanceAt(ctx, address, block, b.historicalStateWindow)
+}
+
 func (b *EthAPIBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
 	if err := ctx.Err(); err != nil {
 		return nil, err
@@ -340,10 +356,36 @@ func (b *EthAPIBackend) GetPoolTransaction(hash common.Hash) *types.Transaction
 	return b.eth.txPool.Get(hash)
 }
 
+// readTransaction looks up [txHash] through the blockchain, which also knows
+// about accepted transactions whose indices have not been written to disk yet.
+func (b *EthAPIBackend) readTransaction(txHash common.Hash) (*types.Transaction, common.Hash, uint64, uint64) {
+	lookup := b.eth.blockchain.GetTransactionLookup(txHash)
+	if lookup == nil {
+		return nil, common.Hash{}, 0, 0
+	}
+	block := b.eth.blockchain.GetBlock(lookup.BlockHash, lookup.BlockIndex)
+	if block == nil {
+		return nil, common.Hash{}, 0, 0
+	}
+	txs := block.Transactions()
+	if lookup.Index >= uint64(len(txs)) || txs[lookup.Index].Hash() != txHash {
+		return nil, common.Hash{}, 0, 0
+	}
+	return txs[lookup.Index], lookup.BlockHash, lookup.BlockIndex, lookup.Index
+}
+
 func (b *EthAPIBackend) GetTransaction(ctx context.Context, txHash common.Hash) (*types.Transaction, common.Hash, uint64, uint64, error) {
 	// Note: we only index transactions during Accept, so the below check against unfinalized queries is technically redundant, but
 	// we keep it for defense in depth.
-	tx, blockHash, blockNumber, index := rawdb.ReadTransaction(b.eth.ChainDb(), txHash)
+	tx, blockHash, blockNumber, index := b.readTransaction(txHash)
+
+	// While the index is filled back in, an unknown transaction may have
+	// been included in a block of the lookup window that is not indexed yet.
+	// Report this instead of responding as if the transaction did not exist,
+	// unless it is pending.
+	if tx == nil && b.eth.blockchain.TxIndexPending() && b.GetPoolTransaction(txHash) == nil {
+		return nil, common.Hash{}, 0, 0, fmt.Errorf("%w: %s, transactions are only indexed from block %d", core.ErrTxNotIndexed, txHash, b.eth.blockchain.TxIndexTail())
+	}
 
 	// Respond as if the transaction does not exist if it is not yet in an
 	// accepted block. We explicitly choose not to error here to avoid breaking
@@ -379,6 +421,10 @@ func (b *EthAPIBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.S
 	return b.eth.txPool.SubscribeNewTxsEvent(ch)
 }
 
+func (b *EthAPIBackend) SubscribeTxPoolEvents(buffer int) *txpool.TxEventSubscription {
+	return b.eth.txPool.SubscribeTxEvents(buffer)
+}
+
 func (b *EthAPIBackend) EstimateBaseFee(ctx context.Context) (*big.Int, error) {
 	return b.gpo.EstimateBaseFee(ctx)
 }
@@ -443,6 +489,14 @@ func (b *EthAPIBackend) RPCTxFeeCap() float64 {
 	return b.eth.config.RPCTxFeeCap
 }
 
+func (b *EthAPIBackend) RPCGasStatsBlockCap() uint64 {
+	return b.eth.config.RPCGasStatsBlockCap
+}
+
+func (b *EthAPIBackend) RPCEstimateGasMaxIterations() int {
+	return b.eth.config.RPCEstimateGasMaxIterations
+}
+
 func (b *EthAPIBackend) BloomStatus() (uint64, uint64) {
 	sections, _, _ := b.eth.bloomIndexer.Sections()
 	return params.BloomBitsBlocks, sections
@@ -67,7 +67,9 @@ type Config = ethconfig.Config
 var DefaultSettings Settings = Settings{MaxBlocksPerRequest: 2000}
 
 type Settings struct {
-	MaxBlocksPerRequest int64 // Maximum number of blocks to serve per getLogs request
+	MaxBlocksPerRequest   int64 // Maximum number of blocks to serve per getLogs request
+	MaxAddressesPerFilter int   // Maximum number of addresses per log filter (0 means no limit)
+	MaxTopicsPerFilter    int   // Maximum number of topic alternatives per log filter (0 means no limit)
 }
 
 // Ethereum implements the Ethereum full node service.
@@ -153,7 +155,7 @@ func New(
 		chainDb:           chainDb,
 		eventMux:          new(event.TypeMux),
 		accountManager:    stack.AccountManager(),
-		engine:            dummy.NewFakerWithClock(clock),
+		engine:            dummy.NewEngine(clock, dummy.Mode{}, config.RewardDistributor),
 		closeBloomHandler: make(chan struct{}),
 		networkID:         config.NetworkId,
 		etherbase:         config.Miner.Etherbase,
@@ -178,6 +180,9 @@ func New(
 			rawdb.WriteDatabaseVersion(chainDb, core.BlockChainVersion)
 		}
 	}
+	if config.OpcodeMetrics {
+		vm.EnableOpcodeMetrics()
+	}
 	var (
 		vmConfig = vm.Config{
 			EnablePreimageRecording: config.EnablePreimageRecording,
@@ -189,6 +194,7 @@ func New(
 			TrieCleanRejournal:              config.TrieCleanRejournal,
 			TrieDirtyLimit:                  config.TrieDirtyCache,
 			TrieDirtyCommitTarget:           config.TrieDirtyCommitTarget,
+			TriePinnedLimit:                 config.TriePinnedCache,
 			Pruning:                         config.Pruning,
 			AcceptorQueueLimit:              config.AcceptorQueueLimit,
 			CommitInterval:                  config.CommitInterval,
@@ -199,10 +205,25 @@ func New(
 			SnapshotLimit:                   config.SnapshotCache,
 			SnapshotWait:                    config.SnapshotWait,
 			SnapshotVerify:                  config.SnapshotVerify,
+			SnapshotVerifySampleRate:        config.SnapshotVerifySampleRate,
 			SnapshotNoBuild:                 config.SkipSnapshotRebuild,
 			Preimages:                       config.Preimages,
 			AcceptedCacheSize:               config.AcceptedCacheSize,
 			TxLookupLimit:                   config.TxLookupLimit,
+			MaxReorgDepth:                   config.MaxReorgDepth,
+			StateIntegrityCheckDepth:        config.StateIntegrityCheckDepth,
+			StateIntegrityCheckHalt:         config.StateIntegrityCheckHalt,
+			AcceptedIndexBatchBlocks:        config.AcceptedIndexBatchBlocks,
+			AcceptedIndexFlushInterval:      config.AcceptedIndexFlushInterval,
+			SpeculativeExecutionLimit:       config.SpeculativeExecutionLimit,
+			SnapshotCheckpointKeys:          config.SnapshotCheckpointKeys,
+			SnapshotCheckpointInterval:      config.SnapshotCheckpointInterval,
+			SnapshotRecoveryLimit:           config.SnapshotRecoveryLimit,
+			SnapshotRepairGaps:              config.SnapshotRepairGaps,
+			SnapshotGenerationRateLimit:     config.SnapshotGenerationRateLimit,
+			BlockExecutionBudget:            config.BlockExecutionBudget,
+			VerificationPipelineWindow:      config.VerificationPipelineWindow,
+			StatePrewarmLimit:               config.StatePrewarmLimit,
 		}
 	)
 
@@ -242,6 +263,7 @@ func New(
 		allowUnprotectedTxs:      config.AllowUnprotectedTxs,
 		allowUnprotectedTxHashes: allowUnprotectedTxHashes,
 		eth:                      eth,
+		historicalStateWindow:    config.HistoricalStateWindow,
 	}
 	if config.AllowUnprotectedTxs {
 		log.Info("Unprotected transactions allowed")
@@ -269,14 +291,17 @@ func (s *Ethereum) APIs() []rpc.API {
 	apis := ethapi.GetAPIs(s.APIBackend)
 
 	// Append tracing APIs
-	apis = append(apis, tracers.APIs(s.APIBackend)...)
+	traceLimiter := tracers.NewTraceLimiter(s.config.TraceConcurrencyLimit, s.config.TraceQueueTimeout)
+	apis = append(apis, tracers.APIs(s.APIBackend, traceLimiter, s.config.TraceMemoryLimit)...)
 
 	// Add the APIs from the node
 	apis = append(apis, s.stackRPCs...)
 
 	// Create [filterSystem] with the log cache size set in the config.
 	filterSystem := filters.NewFilterSystem(s.APIBackend, filters.Config{
-		Timeout: 5 * time.Minute,
+		Timeout:      5 * time.Minute,
+		MaxAddresses: s.settings.MaxAddressesPerFilter,
+		MaxTopics:    s.settings.MaxTopicsPerFilter,
 	})
 
 	// Append all the local APIs and return
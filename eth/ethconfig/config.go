@@ -29,6 +29,7 @@ package ethconfig
 import (
 	"time"
 
+	"github.com/luxdefi/evm/consensus/dummy"
 	"github.com/luxdefi/evm/core"
 	"github.com/luxdefi/evm/core/txpool"
 	"github.com/luxdefi/evm/eth/gasprice"
@@ -46,6 +47,7 @@ var DefaultFullGPOConfig = gasprice.Config{
 	MinPrice:            gasprice.DefaultMinPrice,
 	MaxPrice:            gasprice.DefaultMaxPrice,
 	MinGasUsed:          gasprice.DefaultMinGasUsed,
+	MaxHistoryAge:       gasprice.DefaultMaxHistoryAge,
 }
 
 // DefaultConfig contains default settings for use on the Lux main net.
@@ -65,6 +67,7 @@ func NewDefaultConfig() Config {
 		RPCEVMTimeout:         5 * time.Second,
 		GPO:                   DefaultFullGPOConfig,
 		RPCTxFeeCap:           1,
+		HistoricalStateWindow: 128,
 	}
 }
 
@@ -88,6 +91,7 @@ type Config struct {
 	SnapshotDelayInit               bool    // Whether snapshot tree should be initialized on startup or delayed until explicit call
 	SnapshotWait                    bool    // Whether to wait for the initial snapshot generation
 	SnapshotVerify                  bool    // Whether to verify generated snapshots
+	SnapshotVerifySampleRate        float64 // Fraction of accounts to check when verifying snapshots (0 = all)
 	SkipSnapshotRebuild             bool    // Whether to skip rebuilding the snapshot in favor of returning an error (only set to true for tests)
 
 	// Database options
@@ -99,6 +103,7 @@ type Config struct {
 	TrieCleanRejournal    time.Duration
 	TrieDirtyCache        int
 	TrieDirtyCommitTarget int
+	TriePinnedCache       int
 	SnapshotCache         int
 	Preimages             bool
 
@@ -109,6 +114,10 @@ type Config struct {
 	// Mining options
 	Miner miner.Config
 
+	// RewardDistributor controls the issuance and fee burning of blocks after
+	// the RewardDistribution upgrade (nil means fees are left with the coinbase).
+	RewardDistributor dummy.RewardDistributor `toml:"-"`
+
 	// Transaction pool options
 	TxPool txpool.Config
 
@@ -118,12 +127,36 @@ type Config struct {
 	// Enables tracking of SHA3 preimages in the VM
 	EnablePreimageRecording bool
 
+	// Enables per-opcode execution counters in the VM, for profiling
+	OpcodeMetrics bool
+
 	// RPCGasCap is the global gas cap for eth-call variants.
 	RPCGasCap uint64 `toml:",omitempty"`
 
 	// RPCEVMTimeout is the global timeout for eth-call.
 	RPCEVMTimeout time.Duration
 
+	// RPCGasStatsBlockCap is the maximum number of blocks covered by an
+	// eth_gasStats request (0 means no limit).
+	RPCGasStatsBlockCap uint64
+
+	// RPCEstimateGasMaxIterations is the maximum number of binary search
+	// iterations of eth_estimateGas (0 means no limit).
+	RPCEstimateGasMaxIterations int
+
+	// TraceConcurrencyLimit is the maximum number of block traces executing
+	// concurrently across all RPC clients (0 means no limit).
+	TraceConcurrencyLimit int64
+
+	// TraceQueueTimeout is the maximum time a block trace waits for a free slot
+	// before failing as busy (0 means wait until the request is cancelled).
+	TraceQueueTimeout time.Duration
+
+	// TraceMemoryLimit is the maximum number of bytes of stack, memory, storage
+	// and return data a struct log trace may capture before it is aborted
+	// (0 means no limit).
+	TraceMemoryLimit int
+
 	// RPCTxFeeCap is the global transaction fee(price * gaslimit) cap for
 	// send-transaction variants. The unit is ether.
 	RPCTxFeeCap float64 `toml:",omitempty"`
@@ -156,4 +189,74 @@ type Config struct {
 	//  * 0:   means no limit
 	//  * N:   means N block limit [HEAD-N+1, HEAD] and delete extra indexes
 	TxLookupLimit uint64
+
+	// HistoricalStateWindow is the maximum number of blocks behind the last
+	// accepted block for which historical balance queries may regenerate
+	// pruned state by re-executing blocks.
+	HistoricalStateWindow uint64
+
+	// MaxReorgDepth is the maximum number of canonical blocks a change of
+	// preference may drop. Deeper reorgs are refused and reported as a fatal
+	// error. 0 means no limit.
+	MaxReorgDepth uint64
+
+	// StateIntegrityCheckDepth is the number of blocks, starting at the head,
+	// whose state is checked against the trie and the snapshot on startup.
+	// 0 disables the check.
+	StateIntegrityCheckDepth uint64
+
+	// StateIntegrityCheckHalt makes a failed startup state integrity check
+	// fatal instead of only logging it.
+	StateIntegrityCheckHalt bool
+
+	// AcceptedIndexBatchBlocks is the number of accepted blocks whose
+	// transaction indices are written to disk in a single batch.
+	AcceptedIndexBatchBlocks int
+
+	// AcceptedIndexFlushInterval is the maximum time batched accepted block
+	// indices are held in memory before they are written.
+	AcceptedIndexFlushInterval time.Duration
+
+	// SpeculativeExecutionLimit is the maximum number of received blocks
+	// whose transactions are pre-executed concurrently before verification.
+	// 0 disables speculative execution.
+	SpeculativeExecutionLimit int
+
+	// SnapshotCheckpointKeys and SnapshotCheckpointInterval are the number of
+	// generated keys and the time after which snapshot generation progress is
+	// persisted, so that generation resumes from there after a crash.
+	// 0 disables the respective checkpoint.
+	SnapshotCheckpointKeys     int
+	SnapshotCheckpointInterval time.Duration
+
+	// SnapshotRecoveryLimit is the maximum number of blocks replayed onto the
+	// snapshot when recovering it after an unclean shutdown. If more blocks
+	// would be replayed, the snapshot is rebuilt from the trie instead.
+	// 0 means no limit.
+	SnapshotRecoveryLimit uint64
+
+	// SnapshotRepairGaps enables repairing a complete snapshot persisted for
+	// another block than the one it is loaded for from the trie, instead of
+	// rebuilding it.
+	SnapshotRepairGaps bool
+
+	// SnapshotGenerationRateLimit is the number of bytes of snapshot data
+	// that may be generated per second, so that generation leaves disk I/O to
+	// block processing. 0 means no limit.
+	SnapshotGenerationRateLimit uint64
+
+	// BlockExecutionBudget is the execution time above which a block is
+	// reported as slow. 0 disables the reporting.
+	BlockExecutionBudget time.Duration
+
+	// VerificationPipelineWindow is the number of blocks that may be verified
+	// ahead of committing their state, receipts and head updates. 0 verifies
+	// and commits the blocks one at a time.
+	VerificationPipelineWindow int
+
+	// StatePrewarmLimit is the number of accounts and storage slots most
+	// frequently accessed by the recently accepted blocks that are preloaded
+	// into the caches in the background while each block is verified. 0
+	// disables prewarming.
+	StatePrewarmLimit int
 }
@@ -44,9 +44,15 @@ import (
 	"github.com/ethereum/go-ethereum/event"
 )
 
+// defaultStreamLogsBlocksPerChunk is the number of blocks scanned for each
+// chunk delivered by StreamLogs if the request does not specify it.
+const defaultStreamLogsBlocksPerChunk = 1024
+
 var (
-	errInvalidTopic   = errors.New("invalid topic(s)")
-	errFilterNotFound = errors.New("filter not found")
+	errInvalidTopic       = errors.New("invalid topic(s)")
+	errFilterNotFound     = errors.New("filter not found")
+	errExceedMaxAddresses = errors.New("exceed max addresses")
+	errExceedMaxTopics    = errors.New("exceed max topics")
 )
 
 // filter is a helper struct that holds meta information over the filter type
@@ -320,6 +326,9 @@ func (api *FilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subsc
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
+	if err := api.checkLimits(crit); err != nil {
+		return nil, err
+	}
 
 	var (
 		rpcSub      = notifier.CreateSubscription()
@@ -361,6 +370,84 @@ func (api *FilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subsc
 	return rpcSub, nil
 }
 
+// StreamLogs creates a subscription that delivers the logs matching the given
+// filter criteria that are stored within the state, like GetLogs. The logs are
+// delivered in chunks of [blocksPerChunk] blocks as the blocks are scanned, so
+// that large ranges can be searched without buffering all of their logs. The
+// last chunk has done set, and error set if the search failed.
+func (api *FilterAPI) StreamLogs(ctx context.Context, crit FilterCriteria, blocksPerChunk *hexutil.Uint64) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	if err := api.checkLimits(crit); err != nil {
+		return nil, err
+	}
+	if crit.BlockHash != nil {
+		return nil, errors.New("cannot stream logs of a single block, use eth_getLogs")
+	}
+	begin := rpc.LatestBlockNumber.Int64()
+	if crit.FromBlock != nil {
+		begin = crit.FromBlock.Int64()
+	}
+	end := rpc.LatestBlockNumber.Int64()
+	if crit.ToBlock != nil {
+		end = crit.ToBlock.Int64()
+	}
+	filter, err := api.sys.NewRangeFilter(begin, end, crit.Addresses, crit.Topics)
+	if err != nil {
+		return nil, err
+	}
+	chunkSize := uint64(defaultStreamLogsBlocksPerChunk)
+	if blocksPerChunk != nil {
+		chunkSize = uint64(*blocksPerChunk)
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		// The search runs after the subscription call returned, so it is
+		// cancelled when the client unsubscribes or the connection drops.
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			select {
+			case <-rpcSub.Err():
+			case <-notifier.Closed():
+			}
+			cancel()
+		}()
+
+		err := filter.Stream(ctx, chunkSize, func(chunk LogsChunk) error {
+			chunk.Logs = returnLogs(chunk.Logs)
+			return notifier.Notify(rpcSub.ID, chunk)
+		})
+		if err != nil && ctx.Err() == nil {
+			notifier.Notify(rpcSub.ID, LogsChunk{Logs: []*types.Log{}, Done: true, Error: err.Error()})
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// checkLimits rejects criteria that specify more addresses or, summed over
+// all positions, more topic alternatives than the filter system allows.
+func (api *FilterAPI) checkLimits(crit FilterCriteria) error {
+	if limit := api.sys.cfg.MaxAddresses; limit > 0 && len(crit.Addresses) > limit {
+		return fmt.Errorf("%w: %d addresses, limit is %d", errExceedMaxAddresses, len(crit.Addresses), limit)
+	}
+	if limit := api.sys.cfg.MaxTopics; limit > 0 {
+		var topics int
+		for _, sub := range crit.Topics {
+			topics += len(sub)
+		}
+		if topics > limit {
+			return fmt.Errorf("%w: %d topics, limit is %d", errExceedMaxTopics, topics, limit)
+		}
+	}
+	return nil
+}
+
 // FilterCriteria represents a request to create a new filter.
 // Same as interfaces.FilterQuery but with UnmarshalJSON() method.
 type FilterCriteria interfaces.FilterQuery
@@ -377,6 +464,9 @@ type FilterCriteria interfaces.FilterQuery
 //
 // In case "fromBlock" > "toBlock" an error is returned.
 func (api *FilterAPI) NewFilter(crit FilterCriteria) (rpc.ID, error) {
+	if err := api.checkLimits(crit); err != nil {
+		return rpc.ID(""), err
+	}
 	var (
 		logs    = make(chan []*types.Log)
 		logsSub *Subscription
@@ -422,6 +512,9 @@ func (api *FilterAPI) NewFilter(crit FilterCriteria) (rpc.ID, error) {
 
 // GetLogs returns logs matching the given argument that are stored within the state.
 func (api *FilterAPI) GetLogs(ctx context.Context, crit FilterCriteria) ([]*types.Log, error) {
+	if err := api.checkLimits(crit); err != nil {
+		return nil, err
+	}
 	var filter *Filter
 	if crit.BlockHash != nil {
 		// Block filter requested, construct a single-shot filter
@@ -36,6 +36,7 @@ import (
 	"github.com/luxdefi/evm/core/types"
 	"github.com/luxdefi/evm/rpc"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 )
 
 // Filter can be used to retrieve and filter logs.
@@ -138,15 +139,92 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 		// There is no pending block, if the request specifies only the pending block, then return nil.
 		return nil, nil
 	}
+	end, ok, err := f.resolveRange(ctx)
+	if !ok || err != nil {
+		return nil, err
+	}
+
+	// If the requested range of blocks exceeds the maximum number of blocks allowed by the backend
+	// return an error instead of searching for the logs.
+	if maxBlocks := f.sys.backend.GetMaxBlocksPerRequest(); int64(end)-f.begin >= maxBlocks && maxBlocks > 0 {
+		return nil, fmt.Errorf("requested too many blocks from %d to %d, maximum is set to %d", f.begin, int64(end), maxBlocks)
+	}
+	return f.rangeLogs(ctx, end)
+}
+
+// LogsChunk is a batch of the logs matching a filter that were found in the
+// blocks from FromBlock to ToBlock. Done is set on the last chunk of a stream.
+type LogsChunk struct {
+	Logs      []*types.Log   `json:"logs"`
+	FromBlock hexutil.Uint64 `json:"fromBlock"`
+	ToBlock   hexutil.Uint64 `json:"toBlock"`
+	Done      bool           `json:"done"`
+	Error     string         `json:"error,omitempty"` // Set on the last chunk if the search failed
+}
+
+// Stream searches the blockchain for matching log entries like Logs, but
+// delivers them to [send] in chunks of at most [blocksPerChunk] blocks as the
+// blocks are scanned, so that only the logs of a single chunk are held in
+// memory. The range is not limited by GetMaxBlocksPerRequest, which instead
+// bounds the size of the chunks. Only range filters can be streamed.
+func (f *Filter) Stream(ctx context.Context, blocksPerChunk uint64, send func(LogsChunk) error) error {
+	if f.block != nil {
+		return errors.New("cannot stream logs of a single block")
+	}
+	if f.begin == rpc.PendingBlockNumber.Int64() {
+		return errors.New("cannot stream pending logs")
+	}
+	end, ok, err := f.resolveRange(ctx)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return send(LogsChunk{Done: true})
+	}
+	if maxBlocks := f.sys.backend.GetMaxBlocksPerRequest(); maxBlocks > 0 && blocksPerChunk > uint64(maxBlocks) {
+		blocksPerChunk = uint64(maxBlocks)
+	}
+	if blocksPerChunk == 0 {
+		blocksPerChunk = 1
+	}
+	for uint64(f.begin) <= end {
+		from := uint64(f.begin)
+		to := end
+		if end-from >= blocksPerChunk {
+			to = from + blocksPerChunk - 1
+		}
+		logs, err := f.rangeLogs(ctx, to)
+		if err != nil {
+			return err
+		}
+		// Like Logs, the search ends early at a block that is not available
+		done := to == end || uint64(f.begin) <= to
+		chunk := LogsChunk{
+			Logs:      logs,
+			FromBlock: hexutil.Uint64(from),
+			ToBlock:   hexutil.Uint64(to),
+			Done:      done,
+		}
+		if err := send(chunk); err != nil || done {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveRange replaces the symbolic begin of the filter range with the
+// current head and returns the resolved end of the range. Returns false if
+// there is no head to search from.
+func (f *Filter) resolveRange(ctx context.Context) (uint64, bool, error) {
 	// Figure out the limits of the filter range
 	// LatestBlockNumber is transformed into the last accepted block in HeaderByNumber
 	// so it is left in place here.
 	header, err := f.sys.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
 	if err != nil {
-		return nil, err
+		return 0, false, err
 	}
 	if header == nil {
-		return nil, nil
+		return 0, false, nil
 	}
 	var (
 		head = header.Number.Uint64()
@@ -166,17 +244,18 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 	// are no logs from the specified beginning to end (when in reality there may
 	// be some).
 	if end < uint64(f.begin) {
-		return nil, fmt.Errorf("begin block %d is greater than end block %d", f.begin, end)
+		return 0, false, fmt.Errorf("begin block %d is greater than end block %d", f.begin, end)
 	}
+	return end, true, nil
+}
 
-	// If the requested range of blocks exceeds the maximum number of blocks allowed by the backend
-	// return an error instead of searching for the logs.
-	if maxBlocks := f.sys.backend.GetMaxBlocksPerRequest(); int64(end)-f.begin >= maxBlocks && maxBlocks > 0 {
-		return nil, fmt.Errorf("requested too many blocks from %d to %d, maximum is set to %d", f.begin, int64(end), maxBlocks)
-	}
+// rangeLogs returns the logs matching the filter criteria from the begin of
+// the filter up to and including block [end], advancing the begin past [end].
+func (f *Filter) rangeLogs(ctx context.Context, end uint64) ([]*types.Log, error) {
 	// Gather all indexed logs, and finish with non indexed ones
 	var (
 		logs           []*types.Log
+		err            error
 		size, sections = f.sys.backend.BloomStatus()
 	)
 	if indexed := sections * size; indexed > uint64(f.begin) {
@@ -50,7 +50,9 @@ import (
 
 // Config represents the configuration of the filter system.
 type Config struct {
-	Timeout time.Duration // how long filters stay active (default: 5min)
+	Timeout      time.Duration // how long filters stay active (default: 5min)
+	MaxAddresses int           // maximum number of addresses per filter (0 means no limit)
+	MaxTopics    int           // maximum number of topic alternatives per filter (0 means no limit)
 }
 
 func (cfg Config) withDefaults() Config {
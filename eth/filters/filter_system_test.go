@@ -474,6 +474,59 @@ func TestInvalidGetLogsRequest(t *testing.T) {
 	}
 }
 
+// TestLogFilterLimits tests that filters with more addresses or topic
+// alternatives than configured are rejected.
+func TestLogFilterLimits(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db     = rawdb.NewMemoryDatabase()
+		_, sys = newTestFilterSystem(t, db, Config{MaxAddresses: 2, MaxTopics: 3})
+		api    = NewFilterAPI(sys)
+
+		addrs  = []common.Address{{0x01}, {0x02}, {0x03}}
+		topics = []common.Hash{{0x01}, {0x02}, {0x03}, {0x04}}
+	)
+
+	// At the cap
+	id, err := api.NewFilter(FilterCriteria{Addresses: addrs[:2], Topics: [][]common.Hash{topics[:1], topics[1:3]}})
+	require.NoError(t, err)
+	require.True(t, api.UninstallFilter(id))
+
+	// Exceeding the cap
+	_, err = api.NewFilter(FilterCriteria{Addresses: addrs})
+	require.ErrorIs(t, err, errExceedMaxAddresses)
+	_, err = api.GetLogs(context.Background(), FilterCriteria{Addresses: addrs})
+	require.ErrorIs(t, err, errExceedMaxAddresses)
+	_, err = api.NewFilter(FilterCriteria{Topics: [][]common.Hash{topics[:2], nil, topics[2:]}})
+	require.ErrorIs(t, err, errExceedMaxTopics)
+	_, err = api.GetLogs(context.Background(), FilterCriteria{Topics: [][]common.Hash{topics}})
+	require.ErrorIs(t, err, errExceedMaxTopics)
+}
+
+// TestFilterLogsTopicAlternatives tests that topic sets match any of their
+// alternatives at each position, while all positions must match.
+func TestFilterLogsTopicAlternatives(t *testing.T) {
+	t.Parallel()
+
+	var (
+		a, b, c, d = common.Hash{0x0a}, common.Hash{0x0b}, common.Hash{0x0c}, common.Hash{0x0d}
+		logs       = []*types.Log{
+			0: {Topics: []common.Hash{a, c}},
+			1: {Topics: []common.Hash{b, d}},
+			2: {Topics: []common.Hash{a, a}},
+			3: {Topics: []common.Hash{d, c}},
+			4: {Topics: []common.Hash{b}},
+		}
+	)
+	matched := filterLogs(logs, nil, nil, nil, [][]common.Hash{{a, b}, {c, d}})
+	require.Equal(t, []*types.Log{logs[0], logs[1]}, matched)
+
+	// A wildcard position matches any topic, but the log must have one.
+	matched = filterLogs(logs, nil, nil, nil, [][]common.Hash{{b, d}, nil})
+	require.Equal(t, []*types.Log{logs[1], logs[3]}, matched)
+}
+
 // TestLogFilter tests whether log filters match the correct logs that are posted to the event feed.
 func TestLogFilter(t *testing.T) {
 	t.Parallel()
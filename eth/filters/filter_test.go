@@ -261,6 +261,73 @@ func TestFilters(t *testing.T) {
 	}
 }
 
+func TestFilterStream(t *testing.T) {
+	var (
+		db, _   = rawdb.NewLevelDBDatabase(t.TempDir(), 0, 0, "", false)
+		_, sys  = newTestFilterSystem(t, db, Config{})
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key1.PublicKey)
+
+		gspec = &core.Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   core.GenesisAlloc{addr: {Balance: big.NewInt(1000000)}},
+			BaseFee: big.NewInt(1),
+		}
+	)
+	defer db.Close()
+
+	_, chain, receipts, err := core.GenerateChainWithGenesis(gspec, dummy.NewFaker(), 100, 10, func(i int, gen *core.BlockGen) {
+		if i%3 != 0 {
+			return
+		}
+		receipt := types.NewReceipt(nil, false, 0)
+		receipt.Logs = []*types.Log{
+			{
+				Address: addr,
+				Topics:  []common.Hash{common.BigToHash(big.NewInt(int64(i)))},
+			},
+		}
+		gen.AddUncheckedReceipt(receipt)
+		gen.AddUncheckedTx(types.NewTransaction(uint64(i), common.HexToAddress("0x1"), big.NewInt(1), 1, gen.BaseFee(), nil))
+	})
+	require.NoError(t, err)
+	gspec.MustCommit(db)
+	for i, block := range chain {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+
+	logs, err := mustNewRangeFilter(t, sys, 0, int64(rpc.LatestBlockNumber), []common.Address{addr}, nil).Logs(context.Background())
+	require.NoError(t, err)
+	require.Len(t, logs, 34)
+
+	var (
+		streamed []*types.Log
+		chunks   []LogsChunk
+	)
+	err = mustNewRangeFilter(t, sys, 0, int64(rpc.LatestBlockNumber), []common.Address{addr}, nil).Stream(context.Background(), 7, func(chunk LogsChunk) error {
+		chunks = append(chunks, chunk)
+		streamed = append(streamed, chunk.Logs...)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, logs, streamed)
+
+	// The chunks cover the range in order and only the last one is done
+	require.Len(t, chunks, 15)
+	for i, chunk := range chunks {
+		require.EqualValues(t, i*7, chunk.FromBlock)
+		require.Equal(t, i == len(chunks)-1, chunk.Done)
+		for _, log := range chunk.Logs {
+			require.GreaterOrEqual(t, log.BlockNumber, uint64(chunk.FromBlock))
+			require.LessOrEqual(t, log.BlockNumber, uint64(chunk.ToBlock))
+		}
+	}
+	require.EqualValues(t, 100, chunks[len(chunks)-1].ToBlock)
+}
+
 func mustNewRangeFilter(t *testing.T, sys *FilterSystem, begin, end int64, addresses []common.Address, topics [][]common.Hash) *Filter {
 	t.Helper()
 	f, err := sys.NewRangeFilter(begin, end, addresses, topics)
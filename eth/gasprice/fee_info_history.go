@@ -0,0 +1,149 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package gasprice
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/luxdefi/evm/core/rawdb"
+	"github.com/luxdefi/evm/core/types"
+	"github.com/luxdefi/evm/ethdb"
+	"github.com/luxdefi/evm/rpc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// DefaultMaxHistoryAge is the age past which the fee history persisted by the
+// oracle is discarded on startup.
+const DefaultMaxHistoryAge = 10 * time.Minute
+
+// feeHistoryFlushInterval is the minimum time between two writes of the fee
+// history as blocks are accepted. The fee infos of the blocks accepted since
+// the last write are read again from the chain on startup.
+const feeHistoryFlushInterval = 30 * time.Second
+
+// feeHistoryStore persists the fee infos of the most recently accepted blocks,
+// so that they need not be read again from the chain on startup.
+type feeHistoryStore struct {
+	db     ethdb.KeyValueStore
+	maxAge time.Duration // persisted history older than this is discarded
+
+	lock      sync.Mutex
+	lastWrite time.Time // time the history was last written
+}
+
+// storedFeeHistory is the encoding of the fee history in the database.
+type storedFeeHistory struct {
+	Written uint64      // unix time at which the history was written
+	Head    common.Hash // hash of the block of the last fee info
+	Infos   []storedFeeInfo
+}
+
+type storedFeeInfo struct {
+	Number    uint64
+	BaseFee   *big.Int `rlp:"nil"`
+	Tip       *big.Int `rlp:"nil"`
+	Timestamp uint64
+}
+
+// persist writes the fee infos of up to [f.size] blocks ending at [head] to the
+// history store of [f], if any. Unless [force] is set, nothing is written if
+// the history was written less than [feeHistoryFlushInterval] ago.
+func (f *feeInfoProvider) persist(head *types.Header, force bool) {
+	if f.history == nil {
+		return
+	}
+	f.history.lock.Lock()
+	defer f.history.lock.Unlock()
+
+	now := time.Now()
+	if !force && now.Sub(f.history.lastWrite) < feeHistoryFlushInterval {
+		return
+	}
+	history := storedFeeHistory{
+		Written: uint64(now.Unix()),
+		Head:    head.Hash(),
+	}
+	number := head.Number.Uint64()
+	lower := uint64(0)
+	if uint64(f.size-1) <= number {
+		lower = number - uint64(f.size-1)
+	}
+	for i := lower; i <= number; i++ {
+		feeInfo, ok := f.get(i)
+		if !ok {
+			continue
+		}
+		history.Infos = append(history.Infos, storedFeeInfo{
+			Number:    i,
+			BaseFee:   feeInfo.baseFee,
+			Tip:       feeInfo.tip,
+			Timestamp: feeInfo.timestamp,
+		})
+	}
+	blob, err := rlp.EncodeToBytes(history)
+	if err != nil {
+		log.Warn("Failed to encode gas price history", "err", err)
+		return
+	}
+	rawdb.WriteGasPriceHistory(f.history.db, blob)
+	f.history.lastWrite = now
+}
+
+// loadHistory adds the fee infos persisted in the history store of [f], if
+// any, to its cache and returns how many were loaded. The history is ignored
+// if it is older than the maximum age of the store or does not belong to the
+// accepted chain.
+func (f *feeInfoProvider) loadHistory() int {
+	if f.history == nil {
+		return 0
+	}
+	blob := rawdb.ReadGasPriceHistory(f.history.db)
+	if len(blob) == 0 {
+		return 0
+	}
+	var history storedFeeHistory
+	if err := rlp.DecodeBytes(blob, &history); err != nil {
+		log.Warn("Failed to decode gas price history", "err", err)
+		return 0
+	}
+	if len(history.Infos) == 0 {
+		return 0
+	}
+	if age := time.Since(time.Unix(int64(history.Written), 0)); age > f.history.maxAge {
+		log.Debug("Discarding stale gas price history", "age", age, "maxAge", f.history.maxAge)
+		return 0
+	}
+	head := history.Infos[len(history.Infos)-1].Number
+	lastAccepted := f.backend.LastAcceptedBlock()
+	if head > lastAccepted.NumberU64() {
+		log.Debug("Discarding gas price history ahead of the last accepted block", "head", head, "lastAccepted", lastAccepted.NumberU64())
+		return 0
+	}
+	headHash := lastAccepted.Hash()
+	if head != lastAccepted.NumberU64() {
+		header, err := f.backend.HeaderByNumber(context.Background(), rpc.BlockNumber(head))
+		if err != nil || header == nil {
+			return 0
+		}
+		headHash = header.Hash()
+	}
+	if headHash != history.Head {
+		log.Debug("Discarding gas price history of a different chain", "number", head, "hash", history.Head)
+		return 0
+	}
+	for _, info := range history.Infos {
+		f.cache.Add(info.Number, &feeInfo{
+			baseFee:   info.BaseFee,
+			tip:       info.Tip,
+			timestamp: info.Timestamp,
+		})
+	}
+	log.Debug("Loaded gas price history", "blocks", len(history.Infos), "head", head)
+	return len(history.Infos)
+}
@@ -47,7 +47,9 @@ type feeInfoProvider struct {
 	// [minGasUsed] ensures we don't recommend users pay non-zero tips when other
 	// users are paying a tip to unnecessarily expedite block production.
 	minGasUsed     uint64
-	newHeaderAdded func() // callback used in tests
+	size           int
+	history        *feeHistoryStore // persists the cached fee infos, nil if disabled
+	newHeaderAdded func()           // callback used in tests
 }
 
 // feeInfo is the type of data stored in feeInfoProvider's cache.
@@ -57,11 +59,15 @@ type feeInfo struct {
 }
 
 // newFeeInfoProvider returns a bounded buffer with [size] slots to
-// store [*feeInfo] for the most recently accepted blocks.
-func newFeeInfoProvider(backend OracleBackend, minGasUsed uint64, size int) (*feeInfoProvider, error) {
+// store [*feeInfo] for the most recently accepted blocks. If [history] is
+// not nil, the buffer is persisted to it periodically and reloaded from it on
+// creation.
+func newFeeInfoProvider(backend OracleBackend, minGasUsed uint64, size int, history *feeHistoryStore) (*feeInfoProvider, error) {
 	fc := &feeInfoProvider{
 		backend:    backend,
 		minGasUsed: minGasUsed,
+		size:       size,
+		history:    history,
 	}
 	if size == 0 {
 		// if size is zero, we return early as there is no
@@ -72,12 +78,15 @@ func newFeeInfoProvider(backend OracleBackend, minGasUsed uint64, size int) (*fe
 	}
 
 	fc.cache, _ = lru.New(size + feeCacheExtraSlots)
+	fc.loadHistory()
 	// subscribe to the chain accepted event
 	acceptedEvent := make(chan core.ChainEvent, 1)
 	backend.SubscribeChainAcceptedEvent(acceptedEvent)
 	go func() {
 		for ev := range acceptedEvent {
-			fc.addHeader(context.Background(), ev.Block.Header())
+			header := ev.Block.Header()
+			fc.addHeader(context.Background(), header)
+			fc.persist(header, false)
 			if fc.newHeaderAdded != nil {
 				fc.newHeaderAdded()
 			}
@@ -122,16 +131,21 @@ func (f *feeInfoProvider) get(number uint64) (*feeInfo, bool) {
 	return nil, ok
 }
 
-// populateCache populates [f] with [size] blocks up to last accepted.
+// populateCache populates [f] with [size] blocks up to last accepted, skipping
+// the blocks already loaded from the history store.
 // Note: assumes [size] is greater than zero.
 func (f *feeInfoProvider) populateCache(size int) error {
-	lastAccepted := f.backend.LastAcceptedBlock().NumberU64()
+	lastAcceptedBlock := f.backend.LastAcceptedBlock()
+	lastAccepted := lastAcceptedBlock.NumberU64()
 	lowerBlockNumber := uint64(0)
 	if uint64(size-1) <= lastAccepted { // Note: "size-1" because we need a total of size blocks.
 		lowerBlockNumber = lastAccepted - uint64(size-1)
 	}
 
 	for i := lowerBlockNumber; i <= lastAccepted; i++ {
+		if _, ok := f.get(i); ok {
+			continue
+		}
 		header, err := f.backend.HeaderByNumber(context.Background(), rpc.BlockNumber(i))
 		if err != nil {
 			return err
@@ -141,5 +155,6 @@ func (f *feeInfoProvider) populateCache(size int) error {
 			return err
 		}
 	}
+	f.persist(lastAcceptedBlock.Header(), true)
 	return nil
 }
@@ -8,16 +8,20 @@ import (
 	"math/big"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/luxdefi/evm/core"
+	"github.com/luxdefi/evm/core/rawdb"
 	"github.com/luxdefi/evm/core/types"
 	"github.com/luxdefi/evm/params"
+	"github.com/luxdefi/evm/rpc"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/stretchr/testify/require"
 )
 
 func TestFeeInfoProvider(t *testing.T) {
 	backend := newTestBackend(t, params.TestChainConfig, 2, testGenBlock(t, 55, 370))
-	f, err := newFeeInfoProvider(backend, 1, 2)
+	f, err := newFeeInfoProvider(backend, 1, 2, nil)
 	require.NoError(t, err)
 
 	// check that accepted event was subscribed
@@ -45,7 +49,7 @@ func TestFeeInfoProviderCacheSize(t *testing.T) {
 	size := 5
 	overflow := 3
 	backend := newTestBackend(t, params.TestChainConfig, 0, testGenBlock(t, 55, 370))
-	f, err := newFeeInfoProvider(backend, 1, size)
+	f, err := newFeeInfoProvider(backend, 1, size, nil)
 	require.NoError(t, err)
 
 	// add [overflow] more elements than what will fit in the cache
@@ -70,3 +74,67 @@ func TestFeeInfoProviderCacheSize(t *testing.T) {
 		require.NotNil(t, feeInfo)
 	}
 }
+
+// countingBackend counts the headers read by the fee info provider.
+type countingBackend struct {
+	*testBackend
+	headers int
+}
+
+func (b *countingBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	b.headers++
+	return b.testBackend.HeaderByNumber(ctx, number)
+}
+
+func TestFeeInfoProviderHistory(t *testing.T) {
+	require := require.New(t)
+
+	size := 3
+	backend := newTestBackend(t, params.TestChainConfig, 5, testGenBlock(t, 55, 370))
+	history := &feeHistoryStore{db: backend.db, maxAge: time.Minute}
+	f, err := newFeeInfoProvider(backend, 1, size, history)
+	require.NoError(err)
+
+	// A restarted provider loads the persisted history without reading headers
+	restarted := &countingBackend{testBackend: backend}
+	reloaded, err := newFeeInfoProvider(restarted, 1, size, history)
+	require.NoError(err)
+	require.Zero(restarted.headers)
+	for i := uint64(3); i <= 5; i++ {
+		want, ok := f.get(i)
+		require.True(ok)
+		have, ok := reloaded.get(i)
+		require.True(ok, "block %d", i)
+		require.Equal(want.baseFee.String(), have.baseFee.String())
+		require.Equal(want.tip.String(), have.tip.String())
+		require.Equal(want.timestamp, have.timestamp)
+	}
+
+	// Accepted blocks only write the history once the flush interval elapsed
+	var stored storedFeeHistory
+	genesis, err := backend.HeaderByNumber(context.Background(), 0)
+	require.NoError(err)
+	f.persist(genesis, false)
+	require.NoError(rlp.DecodeBytes(rawdb.ReadGasPriceHistory(backend.db), &stored))
+	require.NotEqual(genesis.Hash(), stored.Head)
+	history.lastWrite = time.Now().Add(-feeHistoryFlushInterval)
+	f.persist(genesis, false)
+	require.NoError(rlp.DecodeBytes(rawdb.ReadGasPriceHistory(backend.db), &stored))
+	require.Equal(genesis.Hash(), stored.Head)
+	lastAccepted, err := backend.HeaderByNumber(context.Background(), 5)
+	require.NoError(err)
+	f.persist(lastAccepted, true)
+
+	// Stale history is discarded and the cache is populated from the chain
+
+	require.NoError(rlp.DecodeBytes(rawdb.ReadGasPriceHistory(backend.db), &stored))
+	stored.Written = uint64(time.Now().Add(-2 * time.Minute).Unix())
+	blob, err := rlp.EncodeToBytes(stored)
+	require.NoError(err)
+	rawdb.WriteGasPriceHistory(backend.db, blob)
+
+	restarted = &countingBackend{testBackend: backend}
+	_, err = newFeeInfoProvider(restarted, 1, size, history)
+	require.NoError(err)
+	require.Equal(size, restarted.headers)
+}
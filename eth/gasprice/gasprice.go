@@ -32,12 +32,14 @@ import (
 	"math/big"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/luxdefi/node/utils/timer/mockable"
 	"github.com/luxdefi/evm/commontype"
 	"github.com/luxdefi/evm/consensus/dummy"
 	"github.com/luxdefi/evm/core"
 	"github.com/luxdefi/evm/core/types"
+	"github.com/luxdefi/evm/ethdb"
 	"github.com/luxdefi/evm/params"
 	"github.com/luxdefi/evm/precompile/contracts/feemanager"
 	"github.com/luxdefi/evm/rpc"
@@ -90,6 +92,15 @@ type Config struct {
 	MaxPrice        *big.Int `toml:",omitempty"`
 	MinPrice        *big.Int `toml:",omitempty"`
 	MinGasUsed      *big.Int `toml:",omitempty"`
+	// WarmupBlocks specifies the number of recent blocks sampled on startup to seed
+	// the price estimate, so that suggestions reflect recent history even when no
+	// block falls within MaxLookbackSeconds. Bounded by the chain height, 0 disables it.
+	WarmupBlocks int
+	// PersistHistory enables storing the fee info of the sampled blocks in the database,
+	// so that it is not read again from the chain on startup.
+	PersistHistory bool
+	// MaxHistoryAge is the age past which the persisted fee info is discarded on startup.
+	MaxHistoryAge time.Duration
 }
 
 // OracleBackend includes all necessary background APIs for oracle.
@@ -98,6 +109,7 @@ type OracleBackend interface {
 	BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error)
 	GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error)
 	ChainConfig() *params.ChainConfig
+	ChainDb() ethdb.Database
 	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
 	SubscribeChainAcceptedEvent(ch chan<- core.ChainEvent) event.Subscription
 	MinRequiredTip(ctx context.Context, header *types.Header) (*big.Int, error)
@@ -199,11 +211,20 @@ func NewOracle(backend OracleBackend, config Config) (*Oracle, error) {
 	} else {
 		minBaseFee = feeConfig.MinBaseFee
 	}
-	feeInfoProvider, err := newFeeInfoProvider(backend, minGasUsed.Uint64(), config.Blocks)
+	var history *feeHistoryStore
+	if config.PersistHistory {
+		maxAge := config.MaxHistoryAge
+		if maxAge <= 0 {
+			maxAge = DefaultMaxHistoryAge
+			log.Warn("Sanitizing invalid gasprice oracle max history age", "provided", config.MaxHistoryAge, "updated", maxAge)
+		}
+		history = &feeHistoryStore{db: backend.ChainDb(), maxAge: maxAge}
+	}
+	feeInfoProvider, err := newFeeInfoProvider(backend, minGasUsed.Uint64(), config.Blocks, history)
 	if err != nil {
 		return nil, err
 	}
-	return &Oracle{
+	oracle := &Oracle{
 		backend:             backend,
 		lastPrice:           minPrice,
 		lastBaseFee:         new(big.Int).Set(minBaseFee),
@@ -216,7 +237,76 @@ func NewOracle(backend OracleBackend, config Config) (*Oracle, error) {
 		maxBlockHistory:     maxBlockHistory,
 		historyCache:        cache,
 		feeInfoProvider:     feeInfoProvider,
-	}, nil
+	}
+	if config.WarmupBlocks > 0 {
+		if err := oracle.warmUp(context.Background(), config.WarmupBlocks); err != nil {
+			log.Warn("Failed to warm up gasprice oracle, using default prices", "blocks", config.WarmupBlocks, "err", err)
+		}
+	}
+	return oracle, nil
+}
+
+// warmUp seeds the last price and base fee (used as fallback when no recent enough blocks
+// are found) from the fees of up to [blocks] blocks ending at the last accepted block,
+// regardless of their timestamps.
+func (oracle *Oracle) warmUp(ctx context.Context, blocks int) error {
+	var (
+		lastAccepted          = oracle.backend.LastAcceptedBlock().Header()
+		latestBlockNumber     = lastAccepted.Number.Uint64()
+		lowerBlockNumberLimit = uint64(0)
+		tipResults            []*big.Int
+		baseFeeResults        []*big.Int
+	)
+	if uint64(blocks) <= latestBlockNumber {
+		lowerBlockNumberLimit = latestBlockNumber - uint64(blocks)
+	}
+	// fees before the most recent fee config change are not representative
+	if oracle.backend.ChainConfig().IsPrecompileEnabled(feemanager.ContractAddress, lastAccepted.Time) {
+		_, feeLastChangedAt, err := oracle.backend.GetFeeConfigAt(lastAccepted)
+		if err != nil {
+			return err
+		}
+		if feeLastChangedAt != nil && lowerBlockNumberLimit < feeLastChangedAt.Uint64() {
+			lowerBlockNumberLimit = feeLastChangedAt.Uint64()
+		}
+	}
+
+	for i := latestBlockNumber; i > lowerBlockNumberLimit; i-- {
+		feeInfo, err := oracle.getFeeInfo(ctx, i)
+		if err != nil {
+			return err
+		}
+		if feeInfo.tip != nil {
+			tipResults = append(tipResults, feeInfo.tip)
+		} else {
+			tipResults = append(tipResults, new(big.Int).Set(common.Big0))
+		}
+		if feeInfo.baseFee != nil {
+			baseFeeResults = append(baseFeeResults, feeInfo.baseFee)
+		}
+	}
+	if len(tipResults) == 0 {
+		return nil
+	}
+
+	sort.Sort(bigIntArray(tipResults))
+	price := tipResults[(len(tipResults)-1)*oracle.percentile/100]
+	if price.Cmp(oracle.maxPrice) > 0 {
+		price = new(big.Int).Set(oracle.maxPrice)
+	}
+	if price.Cmp(oracle.minPrice) < 0 {
+		price = new(big.Int).Set(oracle.minPrice)
+	}
+
+	oracle.cacheLock.Lock()
+	defer oracle.cacheLock.Unlock()
+	oracle.lastPrice = price
+	if len(baseFeeResults) > 0 {
+		sort.Sort(bigIntArray(baseFeeResults))
+		oracle.lastBaseFee = baseFeeResults[(len(baseFeeResults)-1)*oracle.percentile/100]
+	}
+	log.Debug("Warmed up gasprice oracle", "blocks", len(tipResults), "price", oracle.lastPrice, "baseFee", oracle.lastBaseFee)
+	return nil
 }
 
 // EstimateBaseFee returns an estimate of what the base fee will be on a block
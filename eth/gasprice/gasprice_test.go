@@ -38,6 +38,7 @@ import (
 	"github.com/luxdefi/evm/core/rawdb"
 	"github.com/luxdefi/evm/core/types"
 	"github.com/luxdefi/evm/core/vm"
+	"github.com/luxdefi/evm/ethdb"
 	"github.com/luxdefi/evm/params"
 	"github.com/luxdefi/evm/precompile/contracts/feemanager"
 	"github.com/luxdefi/evm/rpc"
@@ -55,6 +56,7 @@ var (
 )
 
 type testBackend struct {
+	db            ethdb.Database
 	chain         *core.BlockChain
 	acceptedEvent chan<- core.ChainEvent
 }
@@ -77,6 +79,10 @@ func (b *testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.
 	return b.chain.GetReceiptsByHash(hash), nil
 }
 
+func (b *testBackend) ChainDb() ethdb.Database {
+	return b.db
+}
+
 func (b *testBackend) ChainConfig() *params.ChainConfig {
 	return b.chain.Config()
 }
@@ -120,7 +126,7 @@ func newTestBackendFakerEngine(t *testing.T, config *params.ChainConfig, numBloc
 	if _, err := chain.InsertChain(blocks); err != nil {
 		t.Fatalf("Failed to insert chain, %v", err)
 	}
-	return &testBackend{chain: chain}
+	return &testBackend{db: diskdb, chain: chain}
 }
 
 // newTestBackend creates a test backend. OBS: don't forget to invoke tearDown
@@ -139,14 +145,15 @@ func newTestBackend(t *testing.T, config *params.ChainConfig, numBlocks int, gen
 		t.Fatal(err)
 	}
 	// Construct testing chain
-	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), core.DefaultCacheConfig, gspec, engine, vm.Config{}, common.Hash{}, false)
+	diskdb := rawdb.NewMemoryDatabase()
+	chain, err := core.NewBlockChain(diskdb, core.DefaultCacheConfig, gspec, engine, vm.Config{}, common.Hash{}, false)
 	if err != nil {
 		t.Fatalf("Failed to create local chain, %v", err)
 	}
 	if _, err := chain.InsertChain(blocks); err != nil {
 		t.Fatalf("Failed to insert chain, %v", err)
 	}
-	return &testBackend{chain: chain}
+	return &testBackend{db: diskdb, chain: chain}
 }
 
 func (b *testBackend) MinRequiredTip(ctx context.Context, header *types.Header) (*big.Int, error) {
@@ -382,6 +389,38 @@ func TestSuggestTipCapMaxBlocksSecondsLookback(t *testing.T) {
 	}, timeCrunchOracleConfig())
 }
 
+func TestSuggestTipCapWarmup(t *testing.T) {
+	require := require.New(t)
+	backend := newTestBackend(t, params.TestChainConfig, 3, testGenBlock(t, 55, 370))
+	defer backend.teardown()
+
+	newOracle := func(warmupBlocks int, now time.Time) *Oracle {
+		config := defaultOracleConfig()
+		config.WarmupBlocks = warmupBlocks
+		oracle, err := NewOracle(backend, config)
+		require.NoError(err)
+		oracle.clock.Set(now)
+		return oracle
+	}
+
+	// the tip suggested while the blocks are recent
+	expectedTip, err := newOracle(0, time.Unix(20, 0)).SuggestTipCap(context.Background())
+	require.NoError(err)
+	require.Positive(expectedTip.Sign())
+
+	// after a restart, all blocks are beyond the lookback window, so a cold oracle
+	// falls back to the minimum price
+	restartTime := time.Unix(10_000, 0)
+	got, err := newOracle(0, restartTime).SuggestTipCap(context.Background())
+	require.NoError(err)
+	require.Zero(DefaultMinPrice.Cmp(got), "expected tip (%d), got tip (%d)", DefaultMinPrice, got)
+
+	// a warmed up oracle suggests the tip based on the recent block history
+	got, err = newOracle(20, restartTime).SuggestTipCap(context.Background())
+	require.NoError(err)
+	require.Zero(expectedTip.Cmp(got), "expected tip (%d), got tip (%d)", expectedTip, got)
+}
+
 // Regression test to ensure the last estimation of base fee is not used
 // for the block immediately following a fee configuration update.
 func TestSuggestGasPriceAfterFeeConfigUpdate(t *testing.T) {
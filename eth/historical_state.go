@@ -0,0 +1,56 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package eth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/luxdefi/evm/core/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ErrStateUnavailable is returned by historical state queries when the state of
+// the requested block is not on disk and cannot be regenerated within the
+// configured window.
+var ErrStateUnavailable = errors.New("state unavailable")
+
+// BalanceAt returns the balance of [address] at the state of [block].
+// The balance is read from the snapshot layer for the block's root if present,
+// then from the state trie. If the state has been pruned, it is regenerated by
+// re-executing up to [window] blocks from the nearest available state, as long
+// as [block] is at most [window] blocks behind the last accepted block.
+func (eth *Ethereum) BalanceAt(ctx context.Context, address common.Address, block *types.Block, window uint64) (*big.Int, error) {
+	if snaps := eth.blockchain.Snapshots(); snaps != nil {
+		if snap := snaps.Snapshot(block.Root()); snap != nil {
+			// Errors (stale layer, generation in progress) fall back to the trie.
+			if acc, err := snap.Account(crypto.Keccak256Hash(address.Bytes())); err == nil {
+				if acc == nil {
+					return new(big.Int), nil
+				}
+				return acc.Balance, nil
+			}
+		}
+	}
+	if statedb, err := eth.blockchain.StateAt(block.Root()); err == nil {
+		return statedb.GetBalance(address), statedb.Error()
+	}
+
+	lastAccepted := eth.LastAcceptedBlock().NumberU64()
+	if number := block.NumberU64(); number+window < lastAccepted {
+		return nil, fmt.Errorf("%w: block %d is more than %d blocks behind last accepted block %d", ErrStateUnavailable, number, window, lastAccepted)
+	}
+	statedb, release, err := eth.StateAtBlock(ctx, block, window, nil, true, false)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, fmt.Errorf("%w: %v", ErrStateUnavailable, err)
+	}
+	defer release()
+	return statedb.GetBalance(address), statedb.Error()
+}
@@ -0,0 +1,78 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package eth
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/luxdefi/evm/consensus/dummy"
+	"github.com/luxdefi/evm/core"
+	"github.com/luxdefi/evm/core/rawdb"
+	"github.com/luxdefi/evm/core/types"
+	"github.com/luxdefi/evm/core/vm"
+	"github.com/luxdefi/evm/params"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBalanceAtPrunedState(t *testing.T) {
+	const (
+		numBlocks = 64
+		amount    = 1000
+		target    = 10
+	)
+	var (
+		require   = require.New(t)
+		key, _    = crypto.GenerateKey()
+		addr      = crypto.PubkeyToAddress(key.PublicKey)
+		recipient = common.Address{1}
+		gspec     = &core.Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   core.GenesisAlloc{addr: {Balance: new(big.Int).Mul(big.NewInt(100), big.NewInt(params.Ether))}},
+			BaseFee: big.NewInt(params.TestInitialBaseFee),
+		}
+		signer = types.LatestSigner(gspec.Config)
+		engine = dummy.NewETHFaker()
+	)
+	_, blocks, _, err := core.GenerateChainWithGenesis(gspec, engine, numBlocks, 10, func(i int, b *core.BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(b.TxNonce(addr), recipient, big.NewInt(amount), params.TxGas, b.BaseFee(), nil), signer, key)
+		require.NoError(err)
+		b.AddTx(tx)
+	})
+	require.NoError(err)
+
+	// Use a pruning node without snapshots, so only the genesis state is on
+	// disk and old accepted tries are dereferenced from memory.
+	cacheConfig := *core.DefaultCacheConfig
+	cacheConfig.SnapshotLimit = 0
+	db := rawdb.NewMemoryDatabase()
+	chain, err := core.NewBlockChain(db, &cacheConfig, gspec, engine, vm.Config{}, common.Hash{}, false)
+	require.NoError(err)
+	defer chain.Stop()
+
+	_, err = chain.InsertChain(blocks)
+	require.NoError(err)
+	for _, block := range blocks {
+		require.NoError(chain.Accept(block))
+	}
+	chain.DrainAcceptorQueue()
+
+	block := blocks[target-1]
+	_, err = chain.StateAt(block.Root())
+	require.Error(err, "state of block %d should be pruned", target)
+
+	eth := &Ethereum{blockchain: chain, chainDb: db}
+
+	// The block is within the window, so its state is regenerated from genesis.
+	balance, err := eth.BalanceAt(context.Background(), recipient, block, numBlocks)
+	require.NoError(err)
+	require.Equal(big.NewInt(target*amount), balance)
+
+	// The block is too far behind the last accepted block.
+	_, err = eth.BalanceAt(context.Background(), recipient, block, numBlocks/2)
+	require.ErrorIs(err, ErrStateUnavailable)
+}
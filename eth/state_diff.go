@@ -0,0 +1,154 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package eth
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/luxdefi/evm/core/types"
+	"github.com/luxdefi/evm/trie"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// StateDiffResult lists the accounts and storage slots that differ between the
+// states of two blocks.
+type StateDiffResult struct {
+	StartRoot common.Hash        `json:"startRoot"`
+	EndRoot   common.Hash        `json:"endRoot"`
+	Accounts  []StateDiffAccount `json:"accounts"`
+}
+
+// StateDiffAccount is an account created, deleted or modified between the
+// states of two blocks. The address is only set if its preimage is known.
+type StateDiffAccount struct {
+	Address     *common.Address `json:"address,omitempty"`
+	AddressHash common.Hash     `json:"addressHash"`
+	Storage     []common.Hash   `json:"storage"` // Hashes of the slots created, deleted or modified
+}
+
+// GetStateDiffByNumber returns the accounts and storage slots that differ
+// between the states of blocks [startNum] and [endNum], by comparing their
+// state tries. Returns ErrStateUnavailable if the state of either block has
+// been pruned.
+//
+// With one parameter, returns the changes made by the specified block.
+func (api *DebugAPI) GetStateDiffByNumber(startNum uint64, endNum *uint64) (*StateDiffResult, error) {
+	var startBlock, endBlock *types.Block
+
+	startBlock = api.eth.blockchain.GetBlockByNumber(startNum)
+	if startBlock == nil {
+		return nil, fmt.Errorf("start block %d not found", startNum)
+	}
+
+	if endNum == nil {
+		endBlock = startBlock
+		startBlock = api.eth.blockchain.GetBlockByHash(startBlock.ParentHash())
+		if startBlock == nil {
+			return nil, fmt.Errorf("block %d has no parent", endBlock.Number())
+		}
+	} else {
+		endBlock = api.eth.blockchain.GetBlockByNumber(*endNum)
+		if endBlock == nil {
+			return nil, fmt.Errorf("end block %d not found", *endNum)
+		}
+	}
+	return api.stateDiff(startBlock, endBlock)
+}
+
+func (api *DebugAPI) stateDiff(startBlock, endBlock *types.Block) (*StateDiffResult, error) {
+	for _, block := range []*types.Block{startBlock, endBlock} {
+		if !api.eth.blockchain.HasState(block.Root()) {
+			return nil, fmt.Errorf("%w: state of block %d (%s) is not on disk, it may have been pruned", ErrStateUnavailable, block.NumberU64(), block.Hash())
+		}
+	}
+	triedb := api.eth.BlockChain().StateCache().TrieDB()
+
+	oldTrie, err := trie.NewStateTrie(trie.StateTrieID(startBlock.Root()), triedb)
+	if err != nil {
+		return nil, err
+	}
+	newTrie, err := trie.NewStateTrie(trie.StateTrieID(endBlock.Root()), triedb)
+	if err != nil {
+		return nil, err
+	}
+	changed, err := diffKeys(oldTrie, newTrie)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &StateDiffResult{
+		StartRoot: startBlock.Root(),
+		EndRoot:   endBlock.Root(),
+		Accounts:  make([]StateDiffAccount, 0, len(changed)),
+	}
+	for _, addrHash := range changed {
+		account := StateDiffAccount{AddressHash: addrHash}
+		if key := newTrie.GetKey(addrHash[:]); key != nil {
+			address := common.BytesToAddress(key)
+			account.Address = &address
+		}
+		oldRoot, err := storageRoot(oldTrie, addrHash)
+		if err != nil {
+			return nil, err
+		}
+		newRoot, err := storageRoot(newTrie, addrHash)
+		if err != nil {
+			return nil, err
+		}
+		if oldRoot != newRoot {
+			oldStorage, err := trie.NewStateTrie(trie.StorageTrieID(startBlock.Root(), addrHash, oldRoot), triedb)
+			if err != nil {
+				return nil, err
+			}
+			newStorage, err := trie.NewStateTrie(trie.StorageTrieID(endBlock.Root(), addrHash, newRoot), triedb)
+			if err != nil {
+				return nil, err
+			}
+			if account.Storage, err = diffKeys(oldStorage, newStorage); err != nil {
+				return nil, err
+			}
+		}
+		result.Accounts = append(result.Accounts, account)
+	}
+	return result, nil
+}
+
+// storageRoot returns the storage root of the account with [addrHash] in
+// [stateTrie], the empty root if there is no such account.
+func storageRoot(stateTrie *trie.StateTrie, addrHash common.Hash) (common.Hash, error) {
+	account, err := stateTrie.GetAccountByHash(addrHash)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if account == nil {
+		return types.EmptyRootHash, nil
+	}
+	return account.Root, nil
+}
+
+// diffKeys returns the sorted hashed keys of the leaves added, removed or
+// modified between [oldTrie] and [newTrie].
+func diffKeys(oldTrie, newTrie *trie.StateTrie) ([]common.Hash, error) {
+	changed := make(map[common.Hash]struct{})
+	for _, tries := range [][2]*trie.StateTrie{{oldTrie, newTrie}, {newTrie, oldTrie}} {
+		// The difference iterator yields the leaves of the second trie not in
+		// the first one, so both directions are needed to include removals.
+		diff, _ := trie.NewDifferenceIterator(tries[0].NodeIterator(nil), tries[1].NodeIterator(nil))
+		iter := trie.NewIterator(diff)
+		for iter.Next() {
+			changed[common.BytesToHash(iter.Key)] = struct{}{}
+		}
+		if iter.Err != nil {
+			return nil, iter.Err
+		}
+	}
+	keys := make([]common.Hash, 0, len(changed))
+	for key := range changed {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i][:], keys[j][:]) < 0 })
+	return keys, nil
+}
@@ -0,0 +1,89 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package eth
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/luxdefi/evm/consensus/dummy"
+	"github.com/luxdefi/evm/core"
+	"github.com/luxdefi/evm/core/rawdb"
+	"github.com/luxdefi/evm/core/types"
+	"github.com/luxdefi/evm/core/vm"
+	"github.com/luxdefi/evm/params"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetStateDiffByNumber(t *testing.T) {
+	const numBlocks = 64
+	var (
+		require   = require.New(t)
+		key, _    = crypto.GenerateKey()
+		addr      = crypto.PubkeyToAddress(key.PublicKey)
+		recipient = common.Address{1}
+		gspec     = &core.Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   core.GenesisAlloc{addr: {Balance: new(big.Int).Mul(big.NewInt(100), big.NewInt(params.Ether))}},
+			BaseFee: big.NewInt(params.TestInitialBaseFee),
+		}
+		signer = types.LatestSigner(gspec.Config)
+		engine = dummy.NewETHFaker()
+	)
+	_, blocks, _, err := core.GenerateChainWithGenesis(gspec, engine, numBlocks, 10, func(i int, b *core.BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(b.TxNonce(addr), recipient, big.NewInt(1000), params.TxGas, b.BaseFee(), nil), signer, key)
+		require.NoError(err)
+		b.AddTx(tx)
+	})
+	require.NoError(err)
+
+	// Use a pruning node without snapshots, so the states of old accepted
+	// blocks are not available.
+	cacheConfig := *core.DefaultCacheConfig
+	cacheConfig.SnapshotLimit = 0
+	cacheConfig.Preimages = true
+	db := rawdb.NewMemoryDatabase()
+	chain, err := core.NewBlockChain(db, &cacheConfig, gspec, engine, vm.Config{}, common.Hash{}, false)
+	require.NoError(err)
+	defer chain.Stop()
+
+	_, err = chain.InsertChain(blocks)
+	require.NoError(err)
+	for _, block := range blocks {
+		require.NoError(chain.Accept(block))
+	}
+	chain.DrainAcceptorQueue()
+
+	api := NewDebugAPI(&Ethereum{blockchain: chain, chainDb: db})
+
+	// The last block transfers from [addr] to [recipient], and changes no storage.
+	last := uint64(numBlocks)
+	diff, err := api.GetStateDiffByNumber(last, nil)
+	require.NoError(err)
+	require.Equal(blocks[numBlocks-2].Root(), diff.StartRoot)
+	require.Equal(blocks[numBlocks-1].Root(), diff.EndRoot)
+	changed := make(map[common.Hash]StateDiffAccount)
+	for _, account := range diff.Accounts {
+		changed[account.AddressHash] = account
+		require.Empty(account.Storage)
+	}
+	for _, address := range []common.Address{addr, recipient} {
+		account, ok := changed[crypto.Keccak256Hash(address.Bytes())]
+		require.True(ok, "account %s should be changed", address)
+		require.NotNil(account.Address)
+		require.Equal(address, *account.Address)
+	}
+
+	// The diff between consecutive blocks is the same either way.
+	prev := last - 1
+	explicit, err := api.GetStateDiffByNumber(prev, &last)
+	require.NoError(err)
+	require.Equal(diff, explicit)
+
+	// The state of an old block has been pruned.
+	_, err = api.GetStateDiffByNumber(10, nil)
+	require.ErrorIs(err, ErrStateUnavailable)
+}
@@ -103,7 +103,10 @@ type Backend interface {
 
 // baseAPI holds the collection of common methods for API and FileTracerAPI.
 type baseAPI struct {
-	backend Backend
+	backend     Backend
+	limiter     *TraceLimiter  // bounds concurrent block traces, nil means no limit
+	memoryLimit int            // caps the bytes captured by struct log traces, zero means no limit
+	requests    *traceRequests // running traces that may be cancelled by request ID
 }
 
 // API is the collection of tracing APIs exposed over the private debugging endpoint.
@@ -113,7 +116,7 @@ type API struct {
 
 // NewAPI creates a new API definition for the tracing methods of the Ethereum service.
 func NewAPI(backend Backend) *API {
-	return &API{baseAPI{backend: backend}}
+	return &API{baseAPI{backend: backend, requests: newTraceRequests()}}
 }
 
 // FileTracerAPI is the collection of additional tracing APIs exposed over the private
@@ -125,7 +128,7 @@ type FileTracerAPI struct {
 // NewFileTracerAPI creates a new API definition for the tracing methods of the Ethererum
 // service that log their output to a file.
 func NewFileTracerAPI(backend Backend) *FileTracerAPI {
-	return &FileTracerAPI{baseAPI{backend: backend}}
+	return &FileTracerAPI{baseAPI{backend: backend, requests: newTraceRequests()}}
 }
 
 // chainContext constructs the context reader which is used by the evm for reading
@@ -185,6 +188,9 @@ type TraceConfig struct {
 	// Config specific to given tracer. Note struct logger
 	// config are historically embedded in main object.
 	TracerConfig json.RawMessage
+	// RequestID, if set, allows the trace to be cancelled with CancelTrace
+	// while it is running. See NewTraceRequestID.
+	RequestID *string
 }
 
 // TraceCallConfig is the config for traceCall API. It holds one more
@@ -619,6 +625,18 @@ func (api *baseAPI) traceBlock(ctx context.Context, block *types.Block, config *
 	if block.NumberU64() == 0 {
 		return nil, errors.New("genesis is not traceable")
 	}
+	ctx, untrack, err := api.requests.track(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	defer untrack()
+
+	done, err := api.limiter.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
 	// Prepare base state
 	parent, err := api.blockByNumberAndHash(ctx, rpc.BlockNumber(block.NumberU64()-1), block.ParentHash())
 	if err != nil {
@@ -721,7 +739,7 @@ txloop:
 		task := &txTraceTask{statedb: statedb.Copy(), index: i}
 		select {
 		case <-ctx.Done():
-			failed = ctx.Err()
+			failed = context.Cause(ctx)
 			break txloop
 		case jobs <- task:
 		}
@@ -762,6 +780,12 @@ func (api *FileTracerAPI) standardTraceBlockToFile(ctx context.Context, block *t
 	if block.NumberU64() == 0 {
 		return nil, errors.New("genesis is not traceable")
 	}
+	done, err := api.limiter.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
 	parent, err := api.blockByNumberAndHash(ctx, rpc.BlockNumber(block.NumberU64()-1), block.ParentHash())
 	if err != nil {
 		return nil, err
@@ -874,6 +898,12 @@ func containsTx(block *types.Block, hash common.Hash) bool {
 // TraceTransaction returns the structured logs created during the execution of EVM
 // and returns them as a JSON object.
 func (api *API) TraceTransaction(ctx context.Context, hash common.Hash, config *TraceConfig) (interface{}, error) {
+	ctx, done, err := api.requests.track(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
 	tx, blockHash, blockNumber, index, err := api.backend.GetTransaction(ctx, hash)
 	if err != nil {
 		return nil, err
@@ -913,11 +943,18 @@ func (api *API) TraceTransaction(ctx context.Context, hash common.Hash, config *
 // created during the execution of EVM if the given transaction was added on
 // top of the provided block and returns them as a JSON object.
 func (api *API) TraceCall(ctx context.Context, args ethapi.TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, config *TraceCallConfig) (interface{}, error) {
+	var traceConfig *TraceConfig
+	if config != nil {
+		traceConfig = &config.TraceConfig
+	}
+	ctx, done, err := api.requests.track(ctx, traceConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
 	// Try to retrieve the specified block
-	var (
-		err   error
-		block *types.Block
-	)
+	var block *types.Block
 	if hash, ok := blockNrOrHash.Hash(); ok {
 		block, err = api.blockByHash(ctx, hash)
 	} else if number, ok := blockNrOrHash.Number(); ok {
@@ -968,11 +1005,6 @@ func (api *API) TraceCall(ctx context.Context, args ethapi.TransactionArgs, bloc
 	if err != nil {
 		return nil, err
 	}
-
-	var traceConfig *TraceConfig
-	if config != nil {
-		traceConfig = &config.TraceConfig
-	}
 	return api.traceTx(ctx, msg, new(Context), vmctx, statedb, traceConfig)
 }
 
@@ -990,7 +1022,7 @@ func (api *baseAPI) traceTx(ctx context.Context, message *core.Message, txctx *C
 		config = &TraceConfig{}
 	}
 	// Default tracer is the struct logger
-	tracer = logger.NewStructLogger(config.Config)
+	tracer = logger.NewStructLogger(api.structLoggerConfig(config.Config))
 	if config.Tracer != nil {
 		tracer, err = DefaultDirectory.New(*config.Tracer, txctx, config.TracerConfig)
 		if err != nil {
@@ -1008,10 +1040,14 @@ func (api *baseAPI) traceTx(ctx context.Context, message *core.Message, txctx *C
 	deadlineCtx, cancel := context.WithTimeout(ctx, timeout)
 	go func() {
 		<-deadlineCtx.Done()
-		if errors.Is(deadlineCtx.Err(), context.DeadlineExceeded) {
+		switch {
+		case errors.Is(deadlineCtx.Err(), context.DeadlineExceeded):
 			tracer.Stop(errors.New("execution timeout"))
 			// Stop evm execution. Note cancellation is not necessarily immediate.
 			vmenv.Cancel()
+		case errors.Is(context.Cause(deadlineCtx), ErrTraceCancelled):
+			tracer.Stop(ErrTraceCancelled)
+			vmenv.Cancel()
 		}
 	}()
 	defer cancel()
@@ -1024,18 +1060,48 @@ func (api *baseAPI) traceTx(ctx context.Context, message *core.Message, txctx *C
 	return tracer.GetResult()
 }
 
+// structLoggerConfig returns a copy of [cfg] whose memory limit does not
+// exceed the memory limit of the API.
+func (api *baseAPI) structLoggerConfig(cfg *logger.Config) *logger.Config {
+	var limited logger.Config
+	if cfg != nil {
+		limited = *cfg
+	}
+	if api.memoryLimit != 0 && (limited.MemoryLimit == 0 || limited.MemoryLimit > api.memoryLimit) {
+		limited.MemoryLimit = api.memoryLimit
+	}
+	return &limited
+}
+
+// NewTraceRequestID returns a new request ID. Passed as the RequestID of the
+// config of a trace, it allows the trace to be cancelled with CancelTrace.
+func (api *API) NewTraceRequestID() string {
+	return string(rpc.NewID())
+}
+
+// CancelTrace stops the running trace started with request ID [id], which
+// then fails with ErrTraceCancelled. It returns an error if no trace with
+// that ID is running.
+func (api *API) CancelTrace(id string) error {
+	return api.requests.cancel(id)
+}
+
 // APIs return the collection of RPC services the tracer package offers.
-func APIs(backend Backend) []rpc.API {
+// Block traces of both services share [limiter], which may be nil, and struct
+// log traces capture at most [memoryLimit] bytes, zero meaning no limit. Both
+// services share the traces that may be cancelled by request ID.
+func APIs(backend Backend, limiter *TraceLimiter, memoryLimit int) []rpc.API {
+	base := baseAPI{backend: backend, limiter: limiter, memoryLimit: memoryLimit, requests: newTraceRequests()}
 	// Append all the local APIs and return
 	return []rpc.API{
 		{
 			Namespace: "debug",
-			Service:   NewAPI(backend),
+			Service:   &API{base},
 			Name:      "debug-tracer",
 		},
 		{
 			Namespace: "debug",
-			Service:   NewFileTracerAPI(backend),
+			Service:   &FileTracerAPI{base},
 			Name:      "debug-file-tracer",
 		},
 	}
@@ -27,7 +27,9 @@
 package tracetest
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"math/big"
 	"os"
 	"path/filepath"
@@ -76,6 +78,7 @@ type callTrace struct {
 	Error        string          `json:"error,omitempty"`
 	RevertReason string          `json:"revertReason,omitempty"`
 	Calls        []callTrace     `json:"calls,omitempty"`
+	ElidedCalls  hexutil.Uint64  `json:"elidedCalls,omitempty"`
 	Logs         []callLog       `json:"logs,omitempty"`
 	Value        *hexutil.Big    `json:"value,omitempty"`
 	// Gencodec adds overridden fields at the end
@@ -389,3 +392,196 @@ func TestInternals(t *testing.T) {
 		}
 	}
 }
+
+// TestCallTracerNestedRevertReason checks that the revert reason of a reverted
+// call deep in the call tree is decoded into its own frame, while the calls
+// that ignore the failure succeed.
+func TestCallTracerNestedRevertReason(t *testing.T) {
+	var (
+		to     = common.HexToAddress("0x00000000000000000000000000000000deadbeef")
+		middle = common.HexToAddress("0x00000000000000000000000000000000000c0de1")
+		inner  = common.HexToAddress("0x00000000000000000000000000000000000c0de2")
+		origin = common.HexToAddress("0x00000000000000000000000000000000feed")
+		reason = "inner call failed"
+	)
+	// Error(string) encoding of [reason]
+	revertData := append(common.FromHex("0x08c379a0"), common.LeftPadBytes([]byte{0x20}, 32)...)
+	revertData = append(revertData, common.LeftPadBytes([]byte{byte(len(reason))}, 32)...)
+	revertData = append(revertData, common.RightPadBytes([]byte(reason), 32)...)
+
+	var revertCode []byte
+	for i := 0; i < len(revertData); i += 32 {
+		revertCode = append(revertCode, byte(vm.PUSH32))
+		revertCode = append(revertCode, common.RightPadBytes(revertData[i:], 32)[:32]...)
+		revertCode = append(revertCode, byte(vm.PUSH1), byte(i), byte(vm.MSTORE))
+	}
+	revertCode = append(revertCode, byte(vm.PUSH1), byte(len(revertData)), byte(vm.PUSH1), 0, byte(vm.REVERT))
+
+	// callCode calls [addr] without arguments and ignores the result.
+	callCode := func(addr common.Address) []byte {
+		code := []byte{
+			byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, // out size and offset
+			byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, // in size and offset
+			byte(vm.PUSH1), 0, // value
+			byte(vm.PUSH20),
+		}
+		code = append(code, addr.Bytes()...)
+		return append(code, byte(vm.GAS), byte(vm.CALL), byte(vm.POP), byte(vm.STOP))
+	}
+
+	_, statedb := tests.MakePreState(rawdb.NewMemoryDatabase(),
+		core.GenesisAlloc{
+			to:     core.GenesisAccount{Code: callCode(middle)},
+			middle: core.GenesisAccount{Code: callCode(inner)},
+			inner:  core.GenesisAccount{Code: revertCode},
+			origin: core.GenesisAccount{Balance: big.NewInt(500000000000000)},
+		}, false)
+	tracer, err := tracers.DefaultDirectory.New("callTracer", nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create call tracer: %v", err)
+	}
+	context := vm.BlockContext{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		BlockNumber: new(big.Int).SetUint64(8000000),
+		Time:        5,
+		Difficulty:  big.NewInt(0x30000),
+		GasLimit:    uint64(6000000),
+	}
+	evm := vm.NewEVM(context, vm.TxContext{Origin: origin, GasPrice: big.NewInt(1)}, statedb, params.TestPreEVMConfig, vm.Config{Tracer: tracer})
+	msg := &core.Message{
+		To:        &to,
+		From:      origin,
+		Value:     big.NewInt(0),
+		GasLimit:  100000,
+		GasPrice:  big.NewInt(0),
+		GasFeeCap: big.NewInt(0),
+		GasTipCap: big.NewInt(0),
+	}
+	st := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(msg.GasLimit))
+	if _, err := st.TransitionDb(); err != nil {
+		t.Fatalf("failed to execute transaction: %v", err)
+	}
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("failed to retrieve trace result: %v", err)
+	}
+	var trace callTrace
+	if err := json.Unmarshal(res, &trace); err != nil {
+		t.Fatalf("failed to unmarshal trace result: %v", err)
+	}
+
+	// The outer frames succeed and nest the reverted call.
+	if trace.Error != "" || len(trace.Calls) != 1 {
+		t.Fatalf("unexpected outer frame: %s", res)
+	}
+	middleFrame := trace.Calls[0]
+	if middleFrame.Error != "" || middleFrame.To == nil || *middleFrame.To != middle || len(middleFrame.Calls) != 1 {
+		t.Fatalf("unexpected middle frame: %s", res)
+	}
+	innerFrame := middleFrame.Calls[0]
+	if innerFrame.To == nil || *innerFrame.To != inner {
+		t.Fatalf("unexpected inner frame: %s", res)
+	}
+	if innerFrame.Error != "execution reverted" {
+		t.Fatalf("inner frame error mismatch: have %q, want %q", innerFrame.Error, "execution reverted")
+	}
+	if innerFrame.RevertReason != reason {
+		t.Fatalf("inner frame revert reason mismatch: have %q, want %q", innerFrame.RevertReason, reason)
+	}
+	if !bytes.Equal(innerFrame.Output, revertData) {
+		t.Fatalf("inner frame output mismatch: have %x, want %x", innerFrame.Output, revertData)
+	}
+}
+
+// TestCallTracerMaxDepth checks that the calls of a recursive contract nested
+// deeper than the max depth are counted in their deepest traced ancestor
+// instead of being expanded.
+func TestCallTracerMaxDepth(t *testing.T) {
+	const maxDepth = 3
+	var (
+		to     = common.HexToAddress("0x00000000000000000000000000000000deadbeef")
+		origin = common.HexToAddress("0x00000000000000000000000000000000feed")
+		// Calls itself with all available gas until it runs out
+		code = []byte{
+			byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, // out size and offset
+			byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, // in size and offset
+			byte(vm.PUSH1), 0, // value
+			byte(vm.ADDRESS), byte(vm.GAS), byte(vm.CALL), byte(vm.POP), byte(vm.STOP),
+		}
+		context = vm.BlockContext{
+			CanTransfer: core.CanTransfer,
+			Transfer:    core.Transfer,
+			BlockNumber: new(big.Int).SetUint64(8000000),
+			Time:        5,
+			Difficulty:  big.NewInt(0x30000),
+			GasLimit:    uint64(6000000),
+		}
+	)
+	trace := func(cfg json.RawMessage) callTrace {
+		t.Helper()
+		_, statedb := tests.MakePreState(rawdb.NewMemoryDatabase(),
+			core.GenesisAlloc{
+				to:     core.GenesisAccount{Code: code},
+				origin: core.GenesisAccount{Balance: big.NewInt(500000000000000)},
+			}, false)
+		tracer, err := tracers.DefaultDirectory.New("callTracer", nil, cfg)
+		if err != nil {
+			t.Fatalf("failed to create call tracer: %v", err)
+		}
+		evm := vm.NewEVM(context, vm.TxContext{Origin: origin, GasPrice: big.NewInt(1)}, statedb, params.TestPreEVMConfig, vm.Config{Tracer: tracer})
+		msg := &core.Message{
+			To:        &to,
+			From:      origin,
+			Value:     big.NewInt(0),
+			GasLimit:  200000,
+			GasPrice:  big.NewInt(0),
+			GasFeeCap: big.NewInt(0),
+			GasTipCap: big.NewInt(0),
+		}
+		st := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(msg.GasLimit))
+		if _, err := st.TransitionDb(); err != nil {
+			t.Fatalf("failed to execute transaction: %v", err)
+		}
+		res, err := tracer.GetResult()
+		if err != nil {
+			t.Fatalf("failed to retrieve trace result: %v", err)
+		}
+		var result callTrace
+		if err := json.Unmarshal(res, &result); err != nil {
+			t.Fatalf("failed to unmarshal trace result: %v", err)
+		}
+		return result
+	}
+	full := trace(nil)
+	elided := trace(json.RawMessage(fmt.Sprintf(`{"maxDepth": %d}`, maxDepth)))
+
+	// Both traces match down to the max depth, where the nested calls of the
+	// full trace are counted instead.
+	fullFrame, elidedFrame := full, elided
+	for depth := 0; depth < maxDepth; depth++ {
+		if len(fullFrame.Calls) != 1 || len(elidedFrame.Calls) != 1 {
+			t.Fatalf("depth %d: have %d calls, want 1 (full trace has %d)", depth, len(elidedFrame.Calls), len(fullFrame.Calls))
+		}
+		if elidedFrame.ElidedCalls != 0 {
+			t.Fatalf("depth %d: have %d elided calls, want 0", depth, elidedFrame.ElidedCalls)
+		}
+		fullFrame, elidedFrame = fullFrame.Calls[0], elidedFrame.Calls[0]
+		if *elidedFrame.GasUsed != *fullFrame.GasUsed {
+			t.Fatalf("depth %d: have gas used %d, want %d", depth+1, *elidedFrame.GasUsed, *fullFrame.GasUsed)
+		}
+	}
+	if len(elidedFrame.Calls) != 0 {
+		t.Fatalf("have %d calls beyond the max depth, want 0", len(elidedFrame.Calls))
+	}
+	var nested uint64
+	for frame := fullFrame; len(frame.Calls) > 0; frame = frame.Calls[0] {
+		nested++
+	}
+	if nested <= maxDepth {
+		t.Fatalf("have %d calls beyond the max depth in the full trace, want more than %d", nested, maxDepth)
+	}
+	if uint64(elidedFrame.ElidedCalls) != nested {
+		t.Fatalf("have %d elided calls, want %d", elidedFrame.ElidedCalls, nested)
+	}
+}
@@ -0,0 +1,121 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package tracetest
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/luxdefi/evm/core"
+	"github.com/luxdefi/evm/core/rawdb"
+	"github.com/luxdefi/evm/core/vm"
+	"github.com/luxdefi/evm/eth/tracers"
+	"github.com/luxdefi/evm/params"
+	"github.com/luxdefi/evm/tests"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// TestEcrecoverTracer checks that the ecrecover tracer reports the signer
+// recovered by a contract verifying a signature, and nothing for transactions
+// that do not call ecrecover.
+func TestEcrecoverTracer(t *testing.T) {
+	var (
+		to     = common.HexToAddress("0x00000000000000000000000000000000deadbeef")
+		origin = common.HexToAddress("0x00000000000000000000000000000000feed")
+		// verifier forwards its calldata to ecrecover and returns the result
+		verifier = []byte{
+			byte(vm.CALLDATASIZE), byte(vm.PUSH1), 0x0, byte(vm.PUSH1), 0x0, byte(vm.CALLDATACOPY),
+			byte(vm.PUSH1), 0x20, byte(vm.PUSH1), 0x0, byte(vm.PUSH1), 0x80, byte(vm.PUSH1), 0x0, // out and in
+			byte(vm.PUSH1), 0x1, byte(vm.GAS), byte(vm.STATICCALL), byte(vm.POP), // address=ecrecover, gas=GAS
+			byte(vm.PUSH1), 0x20, byte(vm.PUSH1), 0x0, byte(vm.RETURN),
+		}
+		context = vm.BlockContext{
+			CanTransfer: core.CanTransfer,
+			Transfer:    core.Transfer,
+			BlockNumber: new(big.Int).SetUint64(8000000),
+			Time:        5,
+			Difficulty:  big.NewInt(0x30000),
+			GasLimit:    uint64(6000000),
+		}
+	)
+	key, err := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	if err != nil {
+		t.Fatalf("failed to parse key: %v", err)
+	}
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+	hash := crypto.Keccak256Hash([]byte("typed data"))
+	sig, err := crypto.Sign(hash[:], key)
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	// ecrecover input is hash, v, r, s
+	input := append(hash.Bytes(), math.PaddedBigBytes(big.NewInt(int64(sig[64])+27), 32)...)
+	input = append(input, sig[:64]...)
+
+	for _, tc := range []struct {
+		name string
+		data []byte
+		want []map[string]interface{}
+	}{
+		{
+			name: "recovers signer",
+			data: input,
+			want: []map[string]interface{}{{"caller": to, "hash": hash, "signer": signer}},
+		},
+		{
+			name: "invalid signature",
+			data: append(hash.Bytes(), make([]byte, 96)...),
+			want: []map[string]interface{}{{"caller": to, "hash": hash, "signer": nil}},
+		},
+		{
+			name: "no ecrecover call",
+			want: []map[string]interface{}{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			code := verifier
+			if tc.data == nil {
+				code = []byte{byte(vm.STOP)}
+			}
+			_, statedb := tests.MakePreState(rawdb.NewMemoryDatabase(),
+				core.GenesisAlloc{
+					to:     core.GenesisAccount{Code: code},
+					origin: core.GenesisAccount{Balance: big.NewInt(500000000000000)},
+				}, false)
+			tracer, err := tracers.DefaultDirectory.New("ecrecoverTracer", nil, nil)
+			if err != nil {
+				t.Fatalf("failed to create ecrecover tracer: %v", err)
+			}
+			evm := vm.NewEVM(context, vm.TxContext{Origin: origin, GasPrice: big.NewInt(1)}, statedb, params.TestPreEVMConfig, vm.Config{Tracer: tracer})
+			msg := &core.Message{
+				To:        &to,
+				From:      origin,
+				Value:     big.NewInt(0),
+				GasLimit:  100000,
+				GasPrice:  big.NewInt(0),
+				GasFeeCap: big.NewInt(0),
+				GasTipCap: big.NewInt(0),
+				Data:      tc.data,
+			}
+			st := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(msg.GasLimit))
+			if _, err := st.TransitionDb(); err != nil {
+				t.Fatalf("failed to execute transaction: %v", err)
+			}
+			res, err := tracer.GetResult()
+			if err != nil {
+				t.Fatalf("failed to retrieve trace result: %v", err)
+			}
+			want, err := json.Marshal(tc.want)
+			if err != nil {
+				t.Fatalf("failed to marshal expected result: %v", err)
+			}
+			if string(res) != string(want) {
+				t.Fatalf("trace mismatch\n have: %v\n want: %v\n", string(res), string(want))
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package tracers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// ErrTracerBusy is returned when a trace could not start before the limiter's
+// queue timeout elapsed.
+var ErrTracerBusy = errors.New("too many concurrent traces, try again later")
+
+// TraceLimiter bounds the number of block traces executing concurrently
+// across all APIs sharing it. Traces beyond the limit are queued until a slot
+// frees up or the queue timeout elapses.
+type TraceLimiter struct {
+	sem     *semaphore.Weighted
+	timeout time.Duration // 0 means wait until the request's context is done
+}
+
+// NewTraceLimiter returns a TraceLimiter allowing up to [maxConcurrent]
+// concurrent traces, or nil (no limit) if [maxConcurrent] is 0.
+func NewTraceLimiter(maxConcurrent int64, timeout time.Duration) *TraceLimiter {
+	if maxConcurrent <= 0 {
+		return nil
+	}
+	return &TraceLimiter{
+		sem:     semaphore.NewWeighted(maxConcurrent),
+		timeout: timeout,
+	}
+}
+
+// acquire blocks until a trace may start and returns a function to be called
+// when it finishes. Returns ErrTracerBusy if the queue timeout elapses first.
+// A nil TraceLimiter never blocks.
+func (l *TraceLimiter) acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	waitCtx := ctx
+	if l.timeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, l.timeout)
+		defer cancel()
+	}
+	if err := l.sem.Acquire(waitCtx, 1); err != nil {
+		// Report the caller's own cancellation as is.
+		if ctx.Err() != nil {
+			return nil, context.Cause(ctx)
+		}
+		return nil, ErrTracerBusy
+	}
+	return func() { l.sem.Release(1) }, nil
+}
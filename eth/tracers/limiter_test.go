@@ -0,0 +1,79 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package tracers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTraceLimiterQueuesExcessTraces(t *testing.T) {
+	const limit = 3
+	limiter := NewTraceLimiter(limit, 0)
+
+	releases := make([]func(), limit)
+	for i := range releases {
+		release, err := limiter.acquire(context.Background())
+		require.NoError(t, err)
+		releases[i] = release
+	}
+
+	// The N+1th trace must wait for one of the running traces to finish.
+	started := make(chan error, 1)
+	go func() {
+		release, err := limiter.acquire(context.Background())
+		if err == nil {
+			defer release()
+		}
+		started <- err
+	}()
+
+	select {
+	case <-started:
+		t.Fatal("trace started while the limit was reached")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	releases[0]()
+	select {
+	case err := <-started:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("trace did not start after a slot was released")
+	}
+
+	for _, release := range releases[1:] {
+		release()
+	}
+}
+
+func TestTraceLimiterBusy(t *testing.T) {
+	limiter := NewTraceLimiter(1, 10*time.Millisecond)
+
+	release, err := limiter.acquire(context.Background())
+	require.NoError(t, err)
+	defer release()
+
+	_, err = limiter.acquire(context.Background())
+	require.ErrorIs(t, err, ErrTracerBusy)
+
+	// Cancellation by the caller is not reported as busy.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = limiter.acquire(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestTraceLimiterDisabled(t *testing.T) {
+	limiter := NewTraceLimiter(0, time.Second)
+	require.Nil(t, limiter)
+
+	for i := 0; i < 10; i++ {
+		_, err := limiter.acquire(context.Background())
+		require.NoError(t, err)
+	}
+}
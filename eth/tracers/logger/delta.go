@@ -0,0 +1,132 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package logger
+
+import (
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/holiman/uint256"
+)
+
+// memoryWordSize is the granularity at which memory changes are reported.
+const memoryWordSize = 32
+
+// MemoryChange is a region of memory that changed since the previous step of
+// the same call frame.
+type MemoryChange struct {
+	Offset uint64        `json:"offset"`
+	Data   hexutil.Bytes `json:"data"`
+}
+
+// deltaFrame holds the stack and memory last reported for a call frame.
+type deltaFrame struct {
+	stack  []uint256.Int
+	memory []byte
+}
+
+// frameAt returns the frame at [depth], discarding the frames of calls that
+// have returned and starting a fresh frame for a call that was entered.
+func frameAt(frames []deltaFrame, depth int) ([]deltaFrame, *deltaFrame) {
+	if len(frames) > depth {
+		frames = frames[:depth]
+	}
+	for len(frames) < depth {
+		frames = append(frames, deltaFrame{})
+	}
+	return frames, &frames[depth-1]
+}
+
+// updateStack records [stack] as the current stack of the frame and returns
+// the number of entries popped from and the entries pushed onto the previous
+// stack to obtain it.
+func (f *deltaFrame) updateStack(stack []uint256.Int) (int, []uint256.Int) {
+	n := 0
+	for n < len(f.stack) && n < len(stack) && f.stack[n] == stack[n] {
+		n++
+	}
+	pop := len(f.stack) - n
+	push := make([]uint256.Int, len(stack)-n)
+	copy(push, stack[n:])
+	f.stack = append(f.stack[:n], push...)
+	return pop, push
+}
+
+// updateMemory records [memory] as the current memory of the frame and returns
+// the regions that differ from the previous memory, which is zero beyond its
+// previous size.
+func (f *deltaFrame) updateMemory(memory []byte) []MemoryChange {
+	changes := make([]MemoryChange, 0)
+	for offset := 0; offset < len(memory); {
+		if f.wordEqual(memory, offset) {
+			offset += memoryWordSize
+			continue
+		}
+		start := offset
+		for offset < len(memory) && !f.wordEqual(memory, offset) {
+			offset += memoryWordSize
+		}
+		if offset > len(memory) {
+			offset = len(memory)
+		}
+		data := make([]byte, offset-start)
+		copy(data, memory[start:offset])
+		changes = append(changes, MemoryChange{Offset: uint64(start), Data: data})
+	}
+	f.memory = append(f.memory[:0], memory...)
+	return changes
+}
+
+// wordEqual reports whether the word of [memory] at [offset] is unchanged.
+func (f *deltaFrame) wordEqual(memory []byte, offset int) bool {
+	end := offset + memoryWordSize
+	if end > len(memory) {
+		end = len(memory)
+	}
+	for i := offset; i < end; i++ {
+		var prev byte
+		if i < len(f.memory) {
+			prev = f.memory[i]
+		}
+		if memory[i] != prev {
+			return false
+		}
+	}
+	return true
+}
+
+// ExpandDeltas reconstructs the full stack and memory of every step of a
+// trace captured with EnableDeltas, as they would have been captured without
+// it. Steps without deltas are returned unchanged.
+func ExpandDeltas(logs []StructLog) []StructLog {
+	var (
+		frames   []deltaFrame
+		frame    *deltaFrame
+		expanded = make([]StructLog, len(logs))
+	)
+	for i, log := range logs {
+		if log.StackPush == nil && log.MemoryChanges == nil {
+			expanded[i] = log
+			continue
+		}
+		frames, frame = frameAt(frames, log.Depth)
+		if log.StackPush != nil {
+			frame.stack = append(frame.stack[:len(frame.stack)-log.StackPop], log.StackPush...)
+			log.Stack = make([]uint256.Int, len(frame.stack))
+			copy(log.Stack, frame.stack)
+			log.StackPop, log.StackPush = 0, nil
+		}
+		if log.MemoryChanges != nil {
+			if grow := log.MemorySize - len(frame.memory); grow > 0 {
+				frame.memory = append(frame.memory, make([]byte, grow)...)
+			}
+			for _, change := range log.MemoryChanges {
+				copy(frame.memory[change.Offset:], change.Data)
+			}
+			log.Memory = make([]byte, len(frame.memory))
+			copy(log.Memory, frame.memory)
+			log.MemoryChanges = nil
+		}
+		expanded[i] = log
+	}
+	return expanded
+}
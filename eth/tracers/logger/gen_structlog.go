@@ -29,6 +29,9 @@ func (s StructLog) MarshalJSON() ([]byte, error) {
 		Depth         int                         `json:"depth"`
 		RefundCounter uint64                      `json:"refund"`
 		Err           error                       `json:"-"`
+		StackPop      int                         `json:"stackPop,omitempty"`
+		StackPush     []uint256.Int               `json:"stackPush"`
+		MemoryChanges []MemoryChange              `json:"memoryChanges"`
 		OpName        string                      `json:"opName"`
 		ErrorString   string                      `json:"error,omitempty"`
 	}
@@ -45,6 +48,9 @@ func (s StructLog) MarshalJSON() ([]byte, error) {
 	enc.Depth = s.Depth
 	enc.RefundCounter = s.RefundCounter
 	enc.Err = s.Err
+	enc.StackPop = s.StackPop
+	enc.StackPush = s.StackPush
+	enc.MemoryChanges = s.MemoryChanges
 	enc.OpName = s.OpName()
 	enc.ErrorString = s.ErrorString()
 	return json.Marshal(&enc)
@@ -65,6 +71,9 @@ func (s *StructLog) UnmarshalJSON(input []byte) error {
 		Depth         *int                        `json:"depth"`
 		RefundCounter *uint64                     `json:"refund"`
 		Err           error                       `json:"-"`
+		StackPop      *int                        `json:"stackPop,omitempty"`
+		StackPush     []uint256.Int               `json:"stackPush"`
+		MemoryChanges []MemoryChange              `json:"memoryChanges"`
 	}
 	var dec StructLog
 	if err := json.Unmarshal(input, &dec); err != nil {
@@ -106,5 +115,14 @@ func (s *StructLog) UnmarshalJSON(input []byte) error {
 	if dec.Err != nil {
 		s.Err = dec.Err
 	}
+	if dec.StackPop != nil {
+		s.StackPop = *dec.StackPop
+	}
+	if dec.StackPush != nil {
+		s.StackPush = dec.StackPush
+	}
+	if dec.MemoryChanges != nil {
+		s.MemoryChanges = dec.MemoryChanges
+	}
 	return nil
 }
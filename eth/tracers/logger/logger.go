@@ -19,6 +19,7 @@ package logger
 import (
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
@@ -35,6 +36,10 @@ import (
 	"github.com/holiman/uint256"
 )
 
+// ErrMemoryLimitExceeded is returned by a struct logger whose captured data
+// exceeded the configured memory limit.
+var ErrMemoryLimitExceeded = errors.New("trace memory limit exceeded")
+
 // Storage represents a contract's storage.
 type Storage map[common.Hash]common.Hash
 
@@ -53,8 +58,10 @@ type Config struct {
 	DisableStack     bool // disable stack capture
 	DisableStorage   bool // disable storage capture
 	EnableReturnData bool // enable return data capture
+	EnableDeltas     bool // capture stack and memory changes instead of full snapshots
 	Debug            bool // print output during capture end
 	Limit            int  // maximum length of output, but zero means unlimited
+	MemoryLimit      int  // maximum bytes of stack, memory, storage and return data captured, but zero means unlimited
 	// Chain overrides, can be used to execute a trace using future fork rules
 	Overrides *params.ChainConfig `json:"overrides,omitempty"`
 }
@@ -76,6 +83,13 @@ type StructLog struct {
 	Depth         int                         `json:"depth"`
 	RefundCounter uint64                      `json:"refund"`
 	Err           error                       `json:"-"`
+
+	// Set instead of Stack and Memory if deltas are enabled. Deltas are relative
+	// to the previous step in the same call frame, or to an empty stack and
+	// memory for the first step of a call frame.
+	StackPop      int            `json:"stackPop,omitempty"` // Number of entries popped from the previous stack
+	StackPush     []uint256.Int  `json:"stackPush"`          // Entries pushed after popping, empty rather than nil for steps that only pop
+	MemoryChanges []MemoryChange `json:"memoryChanges"`      // Regions of memory that changed
 }
 
 // overrides for gencodec
@@ -88,6 +102,16 @@ type structLogMarshaling struct {
 	ErrorString string `json:"error,omitempty"` // adds call to ErrorString() in MarshalJSON
 }
 
+// capturedSize returns the number of bytes of stack, memory, storage and
+// return data captured by the log.
+func (s *StructLog) capturedSize() int {
+	size := len(s.Memory) + len(s.ReturnData) + (len(s.Stack)+len(s.StackPush))*32 + len(s.Storage)*2*common.HashLength
+	for _, change := range s.MemoryChanges {
+		size += len(change.Data)
+	}
+	return size
+}
+
 // OpName formats the operand name in a human-readable format.
 func (s *StructLog) OpName() string {
 	return s.Op.String()
@@ -111,11 +135,13 @@ type StructLogger struct {
 	env *vm.EVM
 
 	storage  map[common.Address]Storage
+	frames   []deltaFrame // Stack and memory last reported per call frame, if deltas are enabled
 	logs     []StructLog
 	output   []byte
 	err      error
 	gasLimit uint64
 	usedGas  uint64
+	captured int // Bytes of stack, memory, storage and return data captured, tracked if limited
 
 	interrupt atomic.Bool // Atomic flag to signal execution interruption
 	reason    error       // Textual reason for the interruption
@@ -135,8 +161,10 @@ func NewStructLogger(cfg *Config) *StructLogger {
 // Reset clears the data held by the logger.
 func (l *StructLogger) Reset() {
 	l.storage = make(map[common.Address]Storage)
+	l.frames = nil
 	l.output = make([]byte, 0)
 	l.logs = l.logs[:0]
+	l.captured = 0
 	l.err = nil
 }
 
@@ -162,18 +190,34 @@ func (l *StructLogger) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, s
 	contract := scope.Contract
 	// Copy a snapshot of the current memory state to a new buffer
 	var mem []byte
-	if l.cfg.EnableMemory {
+	if l.cfg.EnableMemory && !l.cfg.EnableDeltas {
 		mem = make([]byte, len(memory.Data()))
 		copy(mem, memory.Data())
 	}
 	// Copy a snapshot of the current stack state to a new buffer
 	var stck []uint256.Int
-	if !l.cfg.DisableStack {
+	if !l.cfg.DisableStack && !l.cfg.EnableDeltas {
 		stck = make([]uint256.Int, len(stack.Data()))
 		for i, item := range stack.Data() {
 			stck[i] = item
 		}
 	}
+	// Record the changes to the stack and memory since the last step of the call frame
+	var (
+		stackPop      int
+		stackPush     []uint256.Int
+		memoryChanges []MemoryChange
+	)
+	if l.cfg.EnableDeltas {
+		var frame *deltaFrame
+		l.frames, frame = frameAt(l.frames, depth)
+		if !l.cfg.DisableStack {
+			stackPop, stackPush = frame.updateStack(stack.Data())
+		}
+		if l.cfg.EnableMemory {
+			memoryChanges = frame.updateMemory(memory.Data())
+		}
+	}
 	stackData := stack.Data()
 	stackLen := len(stackData)
 	// Copy a snapshot of the current storage to a new container
@@ -208,7 +252,15 @@ func (l *StructLogger) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, s
 		copy(rdata, rData)
 	}
 	// create a new snapshot of the EVM.
-	log := StructLog{pc, op, gas, cost, mem, memory.Len(), stck, rdata, storage, depth, l.env.StateDB.GetRefund(), err}
+	log := StructLog{pc, op, gas, cost, mem, memory.Len(), stck, rdata, storage, depth, l.env.StateDB.GetRefund(), err, stackPop, stackPush, memoryChanges}
+	if l.cfg.MemoryLimit != 0 {
+		l.captured += log.capturedSize()
+		if l.captured > l.cfg.MemoryLimit {
+			l.Stop(fmt.Errorf("%w: captured more than %d bytes", ErrMemoryLimitExceeded, l.cfg.MemoryLimit))
+			l.env.Cancel()
+			return
+		}
+	}
 	l.logs = append(l.logs, log)
 }
 
@@ -421,6 +473,12 @@ type StructLogRes struct {
 	Memory        *[]string          `json:"memory,omitempty"`
 	Storage       *map[string]string `json:"storage,omitempty"`
 	RefundCounter uint64             `json:"refund,omitempty"`
+
+	// Set instead of Stack and Memory if deltas are enabled.
+	StackPop      *int            `json:"stackPop,omitempty"`
+	StackPush     *[]string       `json:"stackPush"`
+	MemorySize    *int            `json:"memSize,omitempty"`
+	MemoryChanges *[]MemoryChange `json:"memoryChanges"`
 }
 
 // formatLogs formats EVM returned structured logs for json output
@@ -457,6 +515,21 @@ func formatLogs(logs []StructLog) []StructLogRes {
 			}
 			formatted[index].Storage = &storage
 		}
+		if trace.StackPush != nil {
+			pop := trace.StackPop
+			push := make([]string, len(trace.StackPush))
+			for i, stackValue := range trace.StackPush {
+				push[i] = stackValue.Hex()
+			}
+			formatted[index].StackPop = &pop
+			formatted[index].StackPush = &push
+		}
+		if trace.MemoryChanges != nil {
+			size := trace.MemorySize
+			changes := trace.MemoryChanges
+			formatted[index].MemorySize = &size
+			formatted[index].MemoryChanges = &changes
+		}
 	}
 	return formatted
 }
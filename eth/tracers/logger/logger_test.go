@@ -17,9 +17,12 @@
 package logger
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"math/big"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/luxdefi/evm/core/rawdb"
@@ -27,6 +30,7 @@ import (
 	"github.com/luxdefi/evm/core/vm"
 	"github.com/luxdefi/evm/params"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
 )
 
 type dummyContractRef struct {
@@ -79,13 +83,13 @@ func TestStructLogMarshalingOmitEmpty(t *testing.T) {
 		want string
 	}{
 		{"empty err and no fields", &StructLog{},
-			`{"pc":0,"op":0,"gas":"0x0","gasCost":"0x0","memSize":0,"stack":null,"depth":0,"refund":0,"opName":"STOP"}`},
+			`{"pc":0,"op":0,"gas":"0x0","gasCost":"0x0","memSize":0,"stack":null,"depth":0,"refund":0,"stackPush":null,"memoryChanges":null,"opName":"STOP"}`},
 		{"with err", &StructLog{Err: errors.New("this failed")},
-			`{"pc":0,"op":0,"gas":"0x0","gasCost":"0x0","memSize":0,"stack":null,"depth":0,"refund":0,"opName":"STOP","error":"this failed"}`},
+			`{"pc":0,"op":0,"gas":"0x0","gasCost":"0x0","memSize":0,"stack":null,"depth":0,"refund":0,"stackPush":null,"memoryChanges":null,"opName":"STOP","error":"this failed"}`},
 		{"with mem", &StructLog{Memory: make([]byte, 2), MemorySize: 2},
-			`{"pc":0,"op":0,"gas":"0x0","gasCost":"0x0","memory":"0x0000","memSize":2,"stack":null,"depth":0,"refund":0,"opName":"STOP"}`},
+			`{"pc":0,"op":0,"gas":"0x0","gasCost":"0x0","memory":"0x0000","memSize":2,"stack":null,"depth":0,"refund":0,"stackPush":null,"memoryChanges":null,"opName":"STOP"}`},
 		{"with 0-size mem", &StructLog{Memory: make([]byte, 0)},
-			`{"pc":0,"op":0,"gas":"0x0","gasCost":"0x0","memSize":0,"stack":null,"depth":0,"refund":0,"opName":"STOP"}`},
+			`{"pc":0,"op":0,"gas":"0x0","gasCost":"0x0","memSize":0,"stack":null,"depth":0,"refund":0,"stackPush":null,"memoryChanges":null,"opName":"STOP"}`},
 	}
 
 	for _, tt := range tests {
@@ -100,3 +104,150 @@ func TestStructLogMarshalingOmitEmpty(t *testing.T) {
 		})
 	}
 }
+
+func TestStructLoggerDeltas(t *testing.T) {
+	var (
+		caller = common.Address{0x01}
+		outer  = common.Address{0x0a}
+		inner  = common.Address{0x0b}
+		// PUSH1 0x2a PUSH1 0 MSTORE PUSH1 0x20 PUSH1 0 RETURN
+		innerCode = common.Hex2Bytes("602a60005260206000f3")
+		// CALL(GAS, inner, 0, 0, 0, 0x80, 0x20)
+		callInner = "6020608060006000600073" + common.Bytes2Hex(inner[:]) + "5af1"
+		// PUSH1 1 PUSH1 2 PUSH1 3 SWAP2 DUP2 POP PUSH1 0xff PUSH1 0x40 MSTORE8,
+		// then call the inner contract twice and STOP.
+		outerCode = common.Hex2Bytes("60016002600391815060ff604053" + callInner + callInner + "00")
+	)
+	trace := func(cfg *Config) []StructLog {
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		statedb.SetCode(outer, outerCode)
+		statedb.SetCode(inner, innerCode)
+		logger := NewStructLogger(cfg)
+		blockCtx := vm.BlockContext{
+			CanTransfer: func(vm.StateDB, common.Address, *big.Int) bool { return true },
+			Transfer:    func(vm.StateDB, common.Address, common.Address, *big.Int) {},
+			BlockNumber: common.Big0,
+		}
+		env := vm.NewEVM(blockCtx, vm.TxContext{}, statedb, params.TestChainConfig, vm.Config{Tracer: logger})
+		if _, _, err := env.Call(vm.AccountRef(caller), outer, nil, 100000, common.Big0); err != nil {
+			t.Fatal(err)
+		}
+		return logger.StructLogs()
+	}
+	full := trace(&Config{EnableMemory: true})
+	deltas := trace(&Config{EnableMemory: true, EnableDeltas: true})
+
+	var fullEntries, deltaEntries int
+	for i := range full {
+		fullEntries += len(full[i].Stack) + len(full[i].Memory)/memoryWordSize
+		deltaEntries += len(deltas[i].StackPush)
+		for _, change := range deltas[i].MemoryChanges {
+			deltaEntries += len(change.Data) / memoryWordSize
+		}
+		if deltas[i].Stack != nil || deltas[i].Memory != nil {
+			t.Fatalf("step %d: full snapshot captured with deltas enabled", i)
+		}
+	}
+	if deltaEntries >= fullEntries {
+		t.Fatalf("deltas are not smaller than full snapshots: %d >= %d", deltaEntries, fullEntries)
+	}
+	if expanded := ExpandDeltas(deltas); !reflect.DeepEqual(expanded, full) {
+		t.Fatalf("reconstructed trace mismatch\n\thave: %v\n\twant: %v", expanded, full)
+	}
+}
+
+// Tests that a delta trace survives a JSON round trip, including the steps
+// that only pop from the stack or leave it unchanged.
+func TestStructLoggerDeltasJSON(t *testing.T) {
+	var (
+		caller   = common.Address{0x01}
+		contract = common.Address{0x0a}
+		// PUSH1 1 PUSH1 2 POP JUMPDEST STOP
+		code = common.Hex2Bytes("60016002505b00")
+	)
+	trace := func(cfg *Config) []StructLog {
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		statedb.SetCode(contract, code)
+		logger := NewStructLogger(cfg)
+		blockCtx := vm.BlockContext{
+			CanTransfer: func(vm.StateDB, common.Address, *big.Int) bool { return true },
+			Transfer:    func(vm.StateDB, common.Address, common.Address, *big.Int) {},
+			BlockNumber: common.Big0,
+		}
+		env := vm.NewEVM(blockCtx, vm.TxContext{}, statedb, params.TestChainConfig, vm.Config{Tracer: logger})
+		if _, _, err := env.Call(vm.AccountRef(caller), contract, nil, 100000, common.Big0); err != nil {
+			t.Fatal(err)
+		}
+		return logger.StructLogs()
+	}
+	full := trace(&Config{EnableMemory: true})
+	deltas := trace(&Config{EnableMemory: true, EnableDeltas: true})
+
+	// The step after the POP only pops from the stack.
+	if pop := deltas[3]; pop.StackPop != 1 || pop.StackPush == nil || len(pop.StackPush) != 0 {
+		t.Fatalf("unexpected delta after POP: pop %d, push %v", pop.StackPop, pop.StackPush)
+	}
+	blob, err := json.Marshal(deltas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded []StructLog
+	if err := json.Unmarshal(blob, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	expanded := ExpandDeltas(decoded)
+	if len(expanded) != len(full) {
+		t.Fatalf("reconstructed %d steps, want %d", len(expanded), len(full))
+	}
+	for i := range full {
+		if have, want := append([]uint256.Int{}, expanded[i].Stack...), append([]uint256.Int{}, full[i].Stack...); !reflect.DeepEqual(have, want) {
+			t.Fatalf("step %d (%v): reconstructed stack mismatch\n\thave: %v\n\twant: %v", i, full[i].Op, have, want)
+		}
+		if have, want := expanded[i].Memory, full[i].Memory; !bytes.Equal(have, want) {
+			t.Fatalf("step %d (%v): reconstructed memory mismatch\n\thave: %x\n\twant: %x", i, full[i].Op, have, want)
+		}
+	}
+}
+
+func TestStructLoggerMemoryLimit(t *testing.T) {
+	var (
+		caller   = common.Address{0x01}
+		contract = common.Address{0x0a}
+		// PUSH1 0xff PUSH3 0x010000 MSTORE8 expands memory to 64KiB, which is
+		// then captured by each of the following JUMPDESTs before the STOP.
+		code = common.Hex2Bytes("60ff6201000053" + strings.Repeat("5b", 32) + "00")
+	)
+	trace := func(cfg *Config) (*StructLogger, error) {
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		statedb.SetCode(contract, code)
+		logger := NewStructLogger(cfg)
+		blockCtx := vm.BlockContext{
+			CanTransfer: func(vm.StateDB, common.Address, *big.Int) bool { return true },
+			Transfer:    func(vm.StateDB, common.Address, common.Address, *big.Int) {},
+			BlockNumber: common.Big0,
+		}
+		env := vm.NewEVM(blockCtx, vm.TxContext{}, statedb, params.TestChainConfig, vm.Config{Tracer: logger})
+		logger.CaptureTxStart(100000)
+		_, leftOverGas, err := env.Call(vm.AccountRef(caller), contract, nil, 100000, common.Big0)
+		logger.CaptureTxEnd(leftOverGas)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = logger.GetResult()
+		return logger, err
+	}
+	unlimited, err := trace(&Config{EnableMemory: true})
+	if err != nil {
+		t.Fatalf("unlimited trace failed: %v", err)
+	}
+	limited, err := trace(&Config{EnableMemory: true, MemoryLimit: 1 << 20})
+	if !errors.Is(err, ErrMemoryLimitExceeded) {
+		t.Fatalf("unexpected error: have %v, want %v", err, ErrMemoryLimitExceeded)
+	}
+	if have, max := len(limited.StructLogs()), len(unlimited.StructLogs()); have >= max {
+		t.Fatalf("limit did not stop capturing: %d >= %d logs", have, max)
+	}
+	if _, err := trace(&Config{EnableMemory: true, MemoryLimit: 1 << 22}); err != nil {
+		t.Fatalf("trace within the limit failed: %v", err)
+	}
+}
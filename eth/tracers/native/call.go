@@ -63,6 +63,7 @@ type callFrame struct {
 	Error        string          `json:"error,omitempty" rlp:"optional"`
 	RevertReason string          `json:"revertReason,omitempty"`
 	Calls        []callFrame     `json:"calls,omitempty" rlp:"optional"`
+	ElidedCalls  uint64          `json:"elidedCalls,omitempty" rlp:"optional"` // Number of nested calls beyond the max depth, not in Calls
 	Logs         []callLog       `json:"logs,omitempty" rlp:"optional"`
 	// Placed at end on purpose. The RLP will be decoded to 0 instead of
 	// nil if there are non-empty elements after in the struct.
@@ -100,12 +101,13 @@ func (f *callFrame) processOutput(output []byte, err error) {
 }
 
 type callFrameMarshaling struct {
-	TypeString string `json:"type"`
-	Gas        hexutil.Uint64
-	GasUsed    hexutil.Uint64
-	Value      *hexutil.Big
-	Input      hexutil.Bytes
-	Output     hexutil.Bytes
+	TypeString  string `json:"type"`
+	Gas         hexutil.Uint64
+	GasUsed     hexutil.Uint64
+	ElidedCalls hexutil.Uint64
+	Value       *hexutil.Big
+	Input       hexutil.Bytes
+	Output      hexutil.Bytes
 }
 
 type callTracer struct {
@@ -115,11 +117,13 @@ type callTracer struct {
 	gasLimit  uint64
 	interrupt atomic.Bool // Atomic flag to signal execution interruption
 	reason    error       // Textual reason for the interruption
+	elided    int         // Number of nested calls entered beyond the max depth and not exited yet
 }
 
 type callTracerConfig struct {
 	OnlyTopCall bool `json:"onlyTopCall"` // If true, call tracer won't collect any subcalls
 	WithLog     bool `json:"withLog"`     // If true, call tracer will collect event logs
+	MaxDepth    int  `json:"maxDepth"`    // If positive, calls nested deeper are only counted in their deepest traced ancestor
 }
 
 // newCallTracer returns a native go tracer which tracks
@@ -171,6 +175,10 @@ func (t *callTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, sco
 	if t.config.OnlyTopCall && depth > 0 {
 		return
 	}
+	// Logs of calls beyond the max depth are elided with their call
+	if t.elided > 0 {
+		return
+	}
 	// Skip if tracing was interrupted
 	if t.interrupt.Load() {
 		return
@@ -207,6 +215,14 @@ func (t *callTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.
 	if t.config.OnlyTopCall {
 		return
 	}
+	// Summarize the calls nested beyond the max depth in their deepest traced
+	// ancestor. They are counted even if tracing was interrupted, so that
+	// their exits are matched.
+	if t.elided > 0 || (t.config.MaxDepth > 0 && len(t.callstack) > t.config.MaxDepth) {
+		t.elided++
+		t.callstack[len(t.callstack)-1].ElidedCalls++
+		return
+	}
 	// Skip if tracing was interrupted
 	if t.interrupt.Load() {
 		return
@@ -230,6 +246,10 @@ func (t *callTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
 	if t.config.OnlyTopCall {
 		return
 	}
+	if t.elided > 0 {
+		t.elided--
+		return
+	}
 	size := len(t.callstack)
 	if size <= 1 {
 		return
@@ -0,0 +1,136 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/luxdefi/evm/core/vm"
+	"github.com/luxdefi/evm/eth/tracers"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("ecrecoverTracer", newEcrecoverTracer, false)
+}
+
+// ecrecoverAddress is the address of the ecrecover precompile.
+var ecrecoverAddress = common.BytesToAddress([]byte{1})
+
+// ecrecoverCall is a call of the ecrecover precompile. Signer is nil if no
+// address could be recovered from the signature.
+type ecrecoverCall struct {
+	Caller common.Address  `json:"caller"`
+	Hash   common.Hash     `json:"hash"`
+	Signer *common.Address `json:"signer"`
+}
+
+// ecrecoverTracer collects the calls of the ecrecover precompile along with
+// the hash each signature was recovered from and the recovered signer, eg. to
+// debug the verification of EIP-712 signatures.
+//
+// Example:
+//
+//	> debug.traceTransaction("0x214e597e35da083692f5386141e69f47e973b2c56e7a8073b1ea08fd7571e9de", {tracer: "ecrecoverTracer"})
+//	[
+//	  {
+//	    "caller": "0x00000000000000000000000000000000deadbeef",
+//	    "hash": "0x4e03657aea45a94fc7d47ba826c8d667c0d1e6e33a64a036ec44f58fa12d6c45",
+//	    "signer": "0x71562b71999873db5b286df957af199ec94617f7"
+//	  }
+//	]
+type ecrecoverTracer struct {
+	noopTracer
+	calls     []ecrecoverCall
+	frames    []int       // index in calls of the ecrecover call of each entered frame, -1 for other calls
+	interrupt atomic.Bool // Atomic flag to signal execution interruption
+	reason    error       // Textual reason for the interruption
+}
+
+// newEcrecoverTracer returns a native go tracer which collects the calls of
+// the ecrecover precompile of a tx, and implements vm.EVMLogger.
+func newEcrecoverTracer(ctx *tracers.Context, _ json.RawMessage) (tracers.Tracer, error) {
+	return &ecrecoverTracer{
+		calls: make([]ecrecoverCall, 0),
+	}, nil
+}
+
+// enter records the call of [to] by [from] if it is a call of ecrecover.
+func (t *ecrecoverTracer) enter(op vm.OpCode, from common.Address, to common.Address, input []byte) {
+	if to != ecrecoverAddress || (op != vm.CALL && op != vm.STATICCALL && op != vm.CALLCODE && op != vm.DELEGATECALL) {
+		t.frames = append(t.frames, -1)
+		return
+	}
+	// ecrecover right pads its input, the hash is the first word
+	t.calls = append(t.calls, ecrecoverCall{
+		Caller: from,
+		Hash:   common.BytesToHash(common.RightPadBytes(input, common.HashLength)[:common.HashLength]),
+	})
+	t.frames = append(t.frames, len(t.calls)-1)
+}
+
+// exit records the signer recovered by the exited frame if it is a call of
+// ecrecover.
+func (t *ecrecoverTracer) exit(output []byte, err error) {
+	if len(t.frames) == 0 {
+		return
+	}
+	index := t.frames[len(t.frames)-1]
+	t.frames = t.frames[:len(t.frames)-1]
+	if index < 0 || err != nil || len(output) != common.HashLength {
+		return
+	}
+	signer := common.BytesToAddress(output)
+	t.calls[index].Signer = &signer
+}
+
+// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *ecrecoverTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	op := vm.CALL
+	if create {
+		op = vm.CREATE
+	}
+	t.enter(op, from, to, input)
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *ecrecoverTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
+	t.exit(output, err)
+}
+
+// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *ecrecoverTracer) CaptureEnter(op vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	// Skip if tracing was interrupted
+	if t.interrupt.Load() {
+		return
+	}
+	t.enter(op, from, to, input)
+}
+
+// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *ecrecoverTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	if t.interrupt.Load() {
+		return
+	}
+	t.exit(output, err)
+}
+
+// GetResult returns the json-encoded list of ecrecover calls, and any
+// error arising from the encoding or forceful termination (via `Stop`).
+func (t *ecrecoverTracer) GetResult() (json.RawMessage, error) {
+	res, err := json.Marshal(t.calls)
+	if err != nil {
+		return nil, err
+	}
+	return res, t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *ecrecoverTracer) Stop(err error) {
+	t.reason = err
+	t.interrupt.Store(true)
+}
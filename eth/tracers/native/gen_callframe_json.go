@@ -26,6 +26,7 @@ func (c callFrame) MarshalJSON() ([]byte, error) {
 		Error        string          `json:"error,omitempty" rlp:"optional"`
 		RevertReason string          `json:"revertReason,omitempty"`
 		Calls        []callFrame     `json:"calls,omitempty" rlp:"optional"`
+		ElidedCalls  hexutil.Uint64  `json:"elidedCalls,omitempty"`
 		Logs         []callLog       `json:"logs,omitempty" rlp:"optional"`
 		Value        *hexutil.Big    `json:"value,omitempty" rlp:"optional"`
 		TypeString   string          `json:"type"`
@@ -41,6 +42,7 @@ func (c callFrame) MarshalJSON() ([]byte, error) {
 	enc.Error = c.Error
 	enc.RevertReason = c.RevertReason
 	enc.Calls = c.Calls
+	enc.ElidedCalls = hexutil.Uint64(c.ElidedCalls)
 	enc.Logs = c.Logs
 	enc.Value = (*hexutil.Big)(c.Value)
 	enc.TypeString = c.TypeString()
@@ -60,6 +62,7 @@ func (c *callFrame) UnmarshalJSON(input []byte) error {
 		Error        *string         `json:"error,omitempty" rlp:"optional"`
 		RevertReason *string         `json:"revertReason,omitempty"`
 		Calls        []callFrame     `json:"calls,omitempty" rlp:"optional"`
+		ElidedCalls  *hexutil.Uint64 `json:"elidedCalls,omitempty"`
 		Logs         []callLog       `json:"logs,omitempty" rlp:"optional"`
 		Value        *hexutil.Big    `json:"value,omitempty" rlp:"optional"`
 	}
@@ -97,6 +100,9 @@ func (c *callFrame) UnmarshalJSON(input []byte) error {
 	if dec.Calls != nil {
 		c.Calls = dec.Calls
 	}
+	if dec.ElidedCalls != nil {
+		c.ElidedCalls = uint64(*dec.ElidedCalls)
+	}
 	if dec.Logs != nil {
 		c.Logs = dec.Logs
 	}
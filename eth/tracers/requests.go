@@ -0,0 +1,67 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package tracers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	// ErrTraceCancelled is returned by traces cancelled by their client.
+	ErrTraceCancelled = errors.New("trace cancelled")
+
+	errUnknownTraceRequest = errors.New("unknown trace request")
+)
+
+// traceRequests tracks the running traces started with a request ID, so that
+// their clients may cancel them.
+type traceRequests struct {
+	lock    sync.Mutex
+	running map[string]context.CancelCauseFunc
+}
+
+func newTraceRequests() *traceRequests {
+	return &traceRequests{running: make(map[string]context.CancelCauseFunc)}
+}
+
+// track registers the trace configured by [config] under its request ID, if
+// any. It returns the context to run the trace with and a function to call
+// once the trace is done.
+func (r *traceRequests) track(ctx context.Context, config *TraceConfig) (context.Context, func(), error) {
+	if r == nil || config == nil || config.RequestID == nil {
+		return ctx, func() {}, nil
+	}
+	id := *config.RequestID
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, ok := r.running[id]; ok {
+		return nil, nil, fmt.Errorf("trace request %s is already running", id)
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	r.running[id] = cancel
+	return ctx, func() {
+		r.lock.Lock()
+		delete(r.running, id)
+		r.lock.Unlock()
+		cancel(nil)
+	}, nil
+}
+
+// cancel stops the trace running under [id], which returns ErrTraceCancelled.
+func (r *traceRequests) cancel(id string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	cancel, ok := r.running[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", errUnknownTraceRequest, id)
+	}
+	cancel(ErrTraceCancelled)
+	return nil
+}
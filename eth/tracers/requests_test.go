@@ -0,0 +1,72 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package tracers
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/luxdefi/evm/core"
+	"github.com/luxdefi/evm/eth/tracers/logger"
+	"github.com/luxdefi/evm/internal/ethapi"
+	"github.com/luxdefi/evm/params"
+	"github.com/luxdefi/evm/rpc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCancelTrace(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	accounts := newAccounts(1)
+	genesis := &core.Genesis{
+		Config: params.TestEVMConfig,
+		Alloc: core.GenesisAlloc{
+			accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+		},
+	}
+	backend := newTestBackend(t, 1, genesis, func(i int, b *core.BlockGen) {})
+	defer backend.teardown()
+	api := NewAPI(backend)
+
+	// A contract looping until it runs out of gas, which takes millions of
+	// steps with the gas cap of the backend.
+	loop := common.Address{0x10, 0x0b}
+	gas := hexutil.Uint64(backend.RPCGasCap())
+	id := api.NewTraceRequestID()
+	timeout := "1m"
+	config := &TraceCallConfig{
+		TraceConfig: TraceConfig{
+			Config:    &logger.Config{Limit: 1},
+			Timeout:   &timeout,
+			RequestID: &id,
+		},
+		StateOverrides: &ethapi.StateOverride{
+			loop: ethapi.OverrideAccount{Code: newRPCBytes(common.Hex2Bytes("5b600056"))}, // JUMPDEST PUSH1 0 JUMP
+		},
+	}
+	errs := make(chan error, 1)
+	go func() {
+		_, err := api.TraceCall(context.Background(), ethapi.TransactionArgs{From: &accounts[0].addr, To: &loop, Gas: &gas}, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), config)
+		errs <- err
+	}()
+
+	// The trace can be cancelled once it is running
+	require.Eventually(func() bool {
+		return api.CancelTrace(id) == nil
+	}, 5*time.Second, time.Millisecond)
+
+	select {
+	case err := <-errs:
+		require.ErrorIs(err, ErrTraceCancelled)
+	case <-time.After(5 * time.Second):
+		t.Fatal("trace did not stop after being cancelled")
+	}
+	// The request ID is released once the trace is done
+	require.ErrorIs(api.CancelTrace(id), errUnknownTraceRequest)
+}
@@ -27,6 +27,8 @@
 package ethapi
 
 import (
+	"bytes"
+	"compress/zlib"
 	"context"
 	"encoding/hex"
 	"errors"
@@ -41,13 +43,19 @@ import (
 	"github.com/luxdefi/evm/accounts/scwallet"
 	"github.com/luxdefi/evm/commontype"
 	"github.com/luxdefi/evm/consensus"
+	"github.com/luxdefi/evm/consensus/dummy"
 	"github.com/luxdefi/evm/core"
+	"github.com/luxdefi/evm/core/rawdb"
 	"github.com/luxdefi/evm/core/state"
+	"github.com/luxdefi/evm/core/txpool"
 	"github.com/luxdefi/evm/core/types"
 	"github.com/luxdefi/evm/core/vm"
 	"github.com/luxdefi/evm/eth/tracers/logger"
 	"github.com/luxdefi/evm/params"
+	"github.com/luxdefi/evm/precompile/modules"
+	"github.com/luxdefi/evm/precompile/precompileconfig"
 	"github.com/luxdefi/evm/rpc"
+	"github.com/luxdefi/evm/trie"
 	"github.com/luxdefi/evm/vmerrs"
 	"github.com/davecgh/go-spew/spew"
 	"github.com/ethereum/go-ethereum/common"
@@ -216,6 +224,69 @@ func (s *TxPoolAPI) Status() map[string]hexutil.Uint {
 	}
 }
 
+// RPCTxPoolEvent is a transaction added to or removed from the pool, as
+// delivered by the txpool events subscription.
+type RPCTxPoolEvent struct {
+	Type   string         `json:"type"`
+	Hash   common.Hash    `json:"hash"`
+	From   common.Address `json:"from"`
+	Nonce  hexutil.Uint64 `json:"nonce"`
+	Reason string         `json:"reason,omitempty"`
+}
+
+// Events creates a subscription delivering the transactions added to and
+// removed from the pool, with the reason of each removal. The transactions
+// already in the pool are first delivered as added; a transaction added while
+// the subscription starts may be reported as added twice. If the subscriber
+// falls behind, the oldest undelivered events are dropped.
+func (s *TxPoolAPI) Events(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	// Subscribe before reading the pool contents, so that no change made in
+	// between is missed.
+	sub := s.b.SubscribeTxPoolEvents(txpool.DefaultTxEventBuffer)
+	pending, queue := s.b.TxPoolContent()
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		defer sub.Unsubscribe()
+
+		for _, content := range []map[common.Address]types.Transactions{pending, queue} {
+			for from, txs := range content {
+				for _, tx := range txs {
+					notifier.Notify(rpcSub.ID, &RPCTxPoolEvent{
+						Type:  txpool.TxAdded.String(),
+						Hash:  tx.Hash(),
+						From:  from,
+						Nonce: hexutil.Uint64(tx.Nonce()),
+					})
+				}
+			}
+		}
+		for {
+			select {
+			case ev := <-sub.Events():
+				notifier.Notify(rpcSub.ID, &RPCTxPoolEvent{
+					Type:   ev.Kind.String(),
+					Hash:   ev.Hash,
+					From:   ev.From,
+					Nonce:  hexutil.Uint64(ev.Nonce),
+					Reason: ev.Reason.String(),
+				})
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
 // Inspect retrieves the content of the transaction pool and flattens it into an
 // easily inspectable list.
 func (s *TxPoolAPI) Inspect() map[string]map[string]map[string]string {
@@ -631,6 +702,38 @@ func (s *BlockChainAPI) GetActivePrecompilesAt(ctx context.Context, blockTimesta
 	return s.b.ChainConfig().EnabledStatefulPrecompiles(timestamp)
 }
 
+// ActivePrecompileResult describes a stateful precompile enabled at the
+// current block.
+type ActivePrecompileResult struct {
+	Address             common.Address          `json:"address"`
+	Name                string                  `json:"name"`
+	ActivationTimestamp *hexutil.Uint64         `json:"activationTimestamp"`
+	Config              precompileconfig.Config `json:"config"`
+}
+
+// ActivePrecompiles returns the address, name, activation timestamp and config
+// of every stateful precompile enabled at the current block, ordered by
+// address.
+func (s *BlockChainAPI) ActivePrecompiles(ctx context.Context) []ActivePrecompileResult {
+	var (
+		enabled = s.b.ChainConfig().EnabledStatefulPrecompiles(s.b.CurrentHeader().Time)
+		results = make([]ActivePrecompileResult, 0, len(enabled))
+	)
+	for _, module := range modules.RegisteredModules() {
+		config, ok := enabled[module.ConfigKey]
+		if !ok {
+			continue
+		}
+		results = append(results, ActivePrecompileResult{
+			Address:             module.Address,
+			Name:                module.ConfigKey,
+			ActivationTimestamp: (*hexutil.Uint64)(config.Timestamp()),
+			Config:              config,
+		})
+	}
+	return results
+}
+
 type FeeConfigResult struct {
 	FeeConfig     commontype.FeeConfig `json:"feeConfig"`
 	LastChangedAt *big.Int             `json:"lastChangedAt,omitempty"`
@@ -657,6 +760,162 @@ func (s *BlockChainAPI) FeeConfig(ctx context.Context, blockNrOrHash *rpc.BlockN
 	return &FeeConfigResult{FeeConfig: feeConfig, LastChangedAt: lastChangedAt}, nil
 }
 
+// DynamicFeeParamsResult are the parameters of the dynamic fee algorithm that
+// apply to the next block.
+type DynamicFeeParamsResult struct {
+	// BaseFee is the base fee of the next block if it were produced now. It
+	// is nil if dynamic fees are not enabled.
+	BaseFee              *hexutil.Big   `json:"baseFee"`
+	GasTarget            *hexutil.Big   `json:"gasTarget"`
+	TargetBlockRate      hexutil.Uint64 `json:"targetBlockRate"`
+	MaxChangeDenominator *hexutil.Big   `json:"maxChangeDenominator"`
+	// ElasticityMultiplier is the factor by which the gas consumed in the
+	// rollup window may exceed [GasTarget], when blocks filled up to the
+	// gas limit are produced at the target rate.
+	ElasticityMultiplier float64 `json:"elasticityMultiplier"`
+}
+
+// DynamicFeeParams returns the parameters of the dynamic fee algorithm for
+// the next block, from the fee config in effect after the current block.
+func (s *BlockChainAPI) DynamicFeeParams(ctx context.Context) (*DynamicFeeParamsResult, error) {
+	header := s.b.CurrentHeader()
+	feeConfig, _, err := s.b.GetFeeConfigAt(header)
+	if err != nil {
+		return nil, err
+	}
+
+	blocksPerWindow := float64(params.RollupWindow) / float64(feeConfig.TargetBlockRate)
+	gasPerWindow := new(big.Float).Mul(new(big.Float).SetInt(feeConfig.GasLimit), big.NewFloat(blocksPerWindow))
+	elasticity, _ := new(big.Float).Quo(gasPerWindow, new(big.Float).SetInt(feeConfig.TargetGas)).Float64()
+	result := &DynamicFeeParamsResult{
+		GasTarget:            (*hexutil.Big)(feeConfig.TargetGas),
+		TargetBlockRate:      hexutil.Uint64(feeConfig.TargetBlockRate),
+		MaxChangeDenominator: (*hexutil.Big)(feeConfig.BaseFeeChangeDenominator),
+		ElasticityMultiplier: elasticity,
+	}
+	if header.BaseFee == nil {
+		return result, nil
+	}
+	_, baseFee, err := dummy.EstimateNextBaseFee(s.b.ChainConfig(), feeConfig, header, uint64(time.Now().Unix()))
+	if err != nil {
+		return nil, err
+	}
+	result.BaseFee = (*hexutil.Big)(baseFee)
+	return result, nil
+}
+
+// EstimateSettlementCost returns the estimated fee (in wei) charged by the
+// parent chain for posting the signed transaction [input], based on its
+// compressed size and the settlement fee parameters of the chain config.
+// Returns zero if no settlement layer is configured.
+func (s *BlockChainAPI) EstimateSettlementCost(ctx context.Context, input hexutil.Bytes) (*hexutil.Big, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(input); err != nil {
+		return nil, err
+	}
+	settlement := s.b.ChainConfig().SettlementConfig
+	if settlement == nil {
+		return (*hexutil.Big)(new(big.Int)), nil
+	}
+	size, err := compressedSize(input)
+	if err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(settlement.DataCost(size)), nil
+}
+
+// compressedSize returns the size of [data] after zlib compression, which
+// approximates the size of the transaction data posted to the parent chain.
+func compressedSize(data []byte) (uint64, error) {
+	var buf bytes.Buffer
+	w, err := zlib.NewWriterLevel(&buf, zlib.BestCompression)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return 0, err
+	}
+	if err := w.Close(); err != nil {
+		return 0, err
+	}
+	return uint64(buf.Len()), nil
+}
+
+// BlockGasStats is the gas usage of a single block in a GasStatsResult.
+type BlockGasStats struct {
+	Number  hexutil.Uint64 `json:"number"`
+	GasUsed hexutil.Uint64 `json:"gasUsed"`
+	TxCount hexutil.Uint64 `json:"transactionCount"`
+	BaseFee *hexutil.Big   `json:"baseFee,omitempty"`
+}
+
+// GasStatsResult is the result of a GasStats API call.
+type GasStatsResult struct {
+	Blocks         []BlockGasStats `json:"blocks"`
+	GasUsed        hexutil.Uint64  `json:"gasUsed"`
+	TxCount        hexutil.Uint64  `json:"transactionCount"`
+	AverageBaseFee *hexutil.Big    `json:"averageBaseFee,omitempty"` // Averaged over the blocks with a base fee
+}
+
+// GasStats returns the gas used, transaction count and base fee of each block
+// in the inclusive range [fromBlock, toBlock], along with the totals of the
+// range and its average base fee. The range is limited to RPCGasStatsBlockCap
+// blocks.
+func (s *BlockChainAPI) GasStats(ctx context.Context, fromBlock, toBlock rpc.BlockNumber) (*GasStatsResult, error) {
+	fromHeader, err := s.b.HeaderByNumber(ctx, fromBlock)
+	if err != nil {
+		return nil, err
+	}
+	toHeader, err := s.b.HeaderByNumber(ctx, toBlock)
+	if err != nil {
+		return nil, err
+	}
+	if fromHeader == nil || toHeader == nil {
+		return nil, errors.New("block range not found")
+	}
+	from, to := fromHeader.Number.Uint64(), toHeader.Number.Uint64()
+	if from > to {
+		return nil, fmt.Errorf("invalid block range, from (%d) is greater than to (%d)", from, to)
+	}
+	if limit := s.b.RPCGasStatsBlockCap(); limit > 0 && to-from+1 > limit {
+		return nil, fmt.Errorf("block range of %d blocks exceeds maximum of %d", to-from+1, limit)
+	}
+
+	var (
+		result = &GasStatsResult{
+			Blocks: make([]BlockGasStats, 0, to-from+1),
+		}
+		totalBaseFee = new(big.Int)
+		baseFees     int64
+	)
+	for number := from; number <= to; number++ {
+		block, err := s.b.BlockByNumber(ctx, rpc.BlockNumber(number))
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			return nil, fmt.Errorf("block %d not found", number)
+		}
+		stats := BlockGasStats{
+			Number:  hexutil.Uint64(number),
+			GasUsed: hexutil.Uint64(block.GasUsed()),
+			TxCount: hexutil.Uint64(len(block.Transactions())),
+		}
+		if baseFee := block.BaseFee(); baseFee != nil {
+			stats.BaseFee = (*hexutil.Big)(baseFee)
+			totalBaseFee.Add(totalBaseFee, baseFee)
+			baseFees++
+		}
+		result.Blocks = append(result.Blocks, stats)
+		result.GasUsed += stats.GasUsed
+		result.TxCount += stats.TxCount
+	}
+	if baseFees > 0 {
+		result.AverageBaseFee = (*hexutil.Big)(totalBaseFee.Div(totalBaseFee, big.NewInt(baseFees)))
+	}
+	return result, nil
+}
+
 // BlockNumber returns the block number of the chain head.
 func (s *BlockChainAPI) BlockNumber() hexutil.Uint64 {
 	header, _ := s.b.HeaderByNumber(context.Background(), rpc.LatestBlockNumber) // latest header should always be available
@@ -674,6 +933,18 @@ func (s *BlockChainAPI) GetBalance(ctx context.Context, address common.Address,
 	return (*hexutil.Big)(state.GetBalance(address)), state.Error()
 }
 
+// GetHistoricalBalance returns the amount of wei for the given address in the state of the
+// given block number, like GetBalance. If the state of the block has been pruned, it is
+// regenerated from the nearest available state, provided the block is within the node's
+// configured historical state window.
+func (s *BlockChainAPI) GetHistoricalBalance(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Big, error) {
+	balance, err := s.b.HistoricalBalance(ctx, address, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(balance), nil
+}
+
 // Result structs for GetProof
 type AccountResult struct {
 	Address      common.Address  `json:"address"`
@@ -747,6 +1018,68 @@ func (s *BlockChainAPI) GetProof(ctx context.Context, address common.Address, st
 	}, state.Error()
 }
 
+const (
+	// maxAccountProofs is the maximum number of addresses a single
+	// GetAccountProofs call may request.
+	maxAccountProofs = 1024
+	// maxAccountProofsSize bounds the total size of the proof nodes returned
+	// by a single GetAccountProofs call.
+	maxAccountProofsSize = 4 * 1024 * 1024
+)
+
+// AccountProofsResult is the result of a GetAccountProofs API call.
+type AccountProofsResult struct {
+	StateRoot common.Hash          `json:"stateRoot"`
+	Accounts  []AccountProofResult `json:"accounts"`
+}
+
+// AccountProofResult is the proof of the existence or absence of an account.
+type AccountProofResult struct {
+	Address common.Address `json:"address"`
+	Exists  bool           `json:"exists"`
+	Proof   []string       `json:"proof"`
+}
+
+// GetAccountProofs returns, for each of the given addresses, the Merkle proof
+// of the inclusion or exclusion of its account in the state trie at the given
+// block. The proofs are returned in the requested order. Once their total size
+// exceeds [maxAccountProofsSize], the remaining addresses are left out of the
+// result and should be requested again.
+func (s *BlockChainAPI) GetAccountProofs(ctx context.Context, addresses []common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*AccountProofsResult, error) {
+	if len(addresses) > maxAccountProofs {
+		return nil, fmt.Errorf("too many addresses requested (%d), limit %d", len(addresses), maxAccountProofs)
+	}
+	state, header, err := s.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	var (
+		result = &AccountProofsResult{
+			StateRoot: header.Root,
+			Accounts:  make([]AccountProofResult, 0, len(addresses)),
+		}
+		size int
+	)
+	for _, address := range addresses {
+		if size >= maxAccountProofsSize {
+			break
+		}
+		proof, err := state.GetProof(address)
+		if err != nil {
+			return nil, err
+		}
+		for _, node := range proof {
+			size += len(node)
+		}
+		result.Accounts = append(result.Accounts, AccountProofResult{
+			Address: address,
+			Exists:  state.Exist(address),
+			Proof:   toHexSlice(proof),
+		})
+	}
+	return result, state.Error()
+}
+
 // decodeHash parses a hex-encoded 32-byte hash. The input may optionally
 // be prefixed by 0x and can have a byte length up to 32.
 func decodeHash(s string) (common.Hash, error) {
@@ -899,6 +1232,38 @@ func (s *BlockChainAPI) GetStorageAt(ctx context.Context, address common.Address
 	return res[:], state.Error()
 }
 
+// PreviewStateRoot returns the state root resulting from applying [changes] to
+// the state at the given block, without persisting anything. Storage can only
+// be changed for accounts with code. Replacing the entire storage of an account
+// is not supported, storage changes must be given as 'stateDiff'.
+func (s *BlockChainAPI) PreviewStateRoot(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, changes StateOverride) (common.Hash, error) {
+	state, header, err := s.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if state == nil || err != nil {
+		return common.Hash{}, err
+	}
+	for addr, account := range changes {
+		if account.State != nil {
+			return common.Hash{}, fmt.Errorf("account %s: replacing the entire storage is not supported, use 'stateDiff'", addr.Hex())
+		}
+		if account.StateDiff == nil || len(*account.StateDiff) == 0 {
+			continue
+		}
+		code := state.GetCode(addr)
+		if account.Code != nil {
+			code = *account.Code
+		}
+		if len(code) == 0 {
+			return common.Hash{}, fmt.Errorf("account %s: cannot change the storage of an account without code", addr.Hex())
+		}
+	}
+	// The state is private to this call, so the changes are discarded with it.
+	if err := changes.Apply(state); err != nil {
+		return common.Hash{}, err
+	}
+	root := state.IntermediateRoot(s.b.ChainConfig().IsEIP158(header.Number))
+	return root, state.Error()
+}
+
 // OverrideAccount indicates the overriding fields of account during the execution
 // of a message call.
 // Note, state and stateDiff can't be specified at the same time. If state is
@@ -963,6 +1328,9 @@ type BlockOverrides struct {
 	GasLimit   *hexutil.Uint64
 	Coinbase   *common.Address
 	BaseFee    *hexutil.Big
+	// Random is the value returned by the DIFFICULTY (PREVRANDAO) opcode. It
+	// takes precedence over Difficulty.
+	Random *common.Hash
 }
 
 // Apply overrides the given header fields into the given block context.
@@ -988,6 +1356,9 @@ func (diff *BlockOverrides) Apply(blockCtx *vm.BlockContext) {
 	if diff.BaseFee != nil {
 		blockCtx.BaseFee = diff.BaseFee.ToInt()
 	}
+	if diff.Random != nil {
+		blockCtx.Difficulty = diff.Random.Big()
+	}
 }
 
 // ChainContextBackend provides methods required to implement ChainContext.
@@ -1022,6 +1393,20 @@ func (context *ChainContext) GetHeader(hash common.Hash, number uint64) *types.H
 	return header
 }
 
+// GetAcceptedHash returns the hash of the accepted block at [number] and
+// whether the block at [number] has been accepted.
+func (context *ChainContext) GetAcceptedHash(number uint64) (common.Hash, bool) {
+	accepted, err := context.b.HeaderByNumber(context.ctx, rpc.AcceptedBlockNumber)
+	if err != nil || accepted == nil || number > accepted.Number.Uint64() {
+		return common.Hash{}, false
+	}
+	header, err := context.b.HeaderByNumber(context.ctx, rpc.BlockNumber(number))
+	if err != nil || header == nil {
+		return common.Hash{}, true
+	}
+	return header.Hash(), true
+}
+
 func DoCall(ctx context.Context, b Backend, args TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *StateOverride, blockOverrides *BlockOverrides, timeout time.Duration, globalGasCap uint64) (*core.ExecutionResult, error) {
 	defer func(start time.Time) { log.Debug("Executing EVM call finished", "runtime", time.Since(start)) }(time.Now())
 
@@ -1179,6 +1564,15 @@ func (s *BlockChainAPI) Call(ctx context.Context, args TransactionArgs, blockNrO
 }
 
 func DoEstimateGas(ctx context.Context, b Backend, args TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, gasCap uint64) (hexutil.Uint64, error) {
+	gas, _, err := doEstimateGas(ctx, b, args, blockNrOrHash, gasCap)
+	return gas, err
+}
+
+// doEstimateGas binary searches the gas requirement of the given transaction.
+// If the search is stopped by RPCEstimateGasMaxIterations before converging,
+// the lowest executable gas limit found so far is returned and loose is set,
+// as the estimate may be higher than needed.
+func doEstimateGas(ctx context.Context, b Backend, args TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, gasCap uint64) (gas hexutil.Uint64, loose bool, err error) {
 	// Binary search the gas requirement, as it may be higher than the amount used
 	var (
 		lo  uint64 = params.TxGas - 1
@@ -1196,17 +1590,17 @@ func DoEstimateGas(ctx context.Context, b Backend, args TransactionArgs, blockNr
 		// Retrieve the block to act as the gas ceiling
 		block, err := b.BlockByNumberOrHash(ctx, blockNrOrHash)
 		if err != nil {
-			return 0, err
+			return 0, false, err
 		}
 		if block == nil {
-			return 0, errors.New("block not found")
+			return 0, false, errors.New("block not found")
 		}
 		hi = block.GasLimit()
 	}
 	// Normalize the max fee per gas the call is willing to spend.
 	var feeCap *big.Int
 	if args.GasPrice != nil && (args.MaxFeePerGas != nil || args.MaxPriorityFeePerGas != nil) {
-		return 0, errors.New("both gasPrice and (maxFeePerGas or maxPriorityFeePerGas) specified")
+		return 0, false, errors.New("both gasPrice and (maxFeePerGas or maxPriorityFeePerGas) specified")
 	} else if args.GasPrice != nil {
 		feeCap = args.GasPrice.ToInt()
 	} else if args.MaxFeePerGas != nil {
@@ -1218,13 +1612,13 @@ func DoEstimateGas(ctx context.Context, b Backend, args TransactionArgs, blockNr
 	if feeCap.BitLen() != 0 {
 		state, _, err := b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
 		if err != nil {
-			return 0, err
+			return 0, false, err
 		}
 		balance := state.GetBalance(*args.From) // from can't be nil
 		available := new(big.Int).Set(balance)
 		if args.Value != nil {
 			if args.Value.ToInt().Cmp(available) >= 0 {
-				return 0, core.ErrInsufficientFundsForTransfer
+				return 0, false, core.ErrInsufficientFundsForTransfer
 			}
 			available.Sub(available, args.Value.ToInt())
 		}
@@ -1262,7 +1656,13 @@ func DoEstimateGas(ctx context.Context, b Backend, args TransactionArgs, blockNr
 		return result.Failed(), result, nil
 	}
 	// Execute the binary search and hone in on an executable gas limit
-	for lo+1 < hi {
+	maxIterations := b.RPCEstimateGasMaxIterations()
+	for iterations := 0; lo+1 < hi; iterations++ {
+		if maxIterations > 0 && iterations >= maxIterations {
+			log.Debug("Gas estimation stopped at iteration limit", "lo", lo, "hi", hi, "iterations", iterations)
+			loose = true
+			break
+		}
 		mid := (hi + lo) / 2
 		failed, _, err := executable(mid)
 
@@ -1270,7 +1670,7 @@ func DoEstimateGas(ctx context.Context, b Backend, args TransactionArgs, blockNr
 		// call or transaction will never be accepted no matter how much gas it is
 		// assigned. Return the error directly, don't struggle any more.
 		if err != nil {
-			return 0, err
+			return 0, false, err
 		}
 		if failed {
 			lo = mid
@@ -1282,24 +1682,26 @@ func DoEstimateGas(ctx context.Context, b Backend, args TransactionArgs, blockNr
 	if hi == cap {
 		failed, result, err := executable(hi)
 		if err != nil {
-			return 0, err
+			return 0, false, err
 		}
 		if failed {
 			if result != nil && result.Err != vmerrs.ErrOutOfGas {
 				if len(result.Revert()) > 0 {
-					return 0, newRevertError(result)
+					return 0, false, newRevertError(result)
 				}
-				return 0, result.Err
+				return 0, false, result.Err
 			}
 			// Otherwise, the specified gas cap is too low
-			return 0, fmt.Errorf("gas required exceeds allowance (%d)", cap)
+			return 0, false, fmt.Errorf("gas required exceeds allowance (%d)", cap)
 		}
 	}
-	return hexutil.Uint64(hi), nil
+	return hexutil.Uint64(hi), loose, nil
 }
 
 // EstimateGas returns an estimate of the amount of gas needed to execute the
-// given transaction against the current pending block.
+// given transaction against the current pending block. If the transaction
+// specifies an access list, it is applied during estimation so the estimate
+// reflects the cost of the list and the discount on warm accesses.
 func (s *BlockChainAPI) EstimateGas(ctx context.Context, args TransactionArgs, blockNrOrHash *rpc.BlockNumberOrHash) (hexutil.Uint64, error) {
 	bNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
 	if blockNrOrHash != nil {
@@ -1308,6 +1710,28 @@ func (s *BlockChainAPI) EstimateGas(ctx context.Context, args TransactionArgs, b
 	return DoEstimateGas(ctx, s.b, args, bNrOrHash, s.b.RPCGasCap())
 }
 
+// EstimateGasResult is the result of eth_estimateGasDetailed.
+type EstimateGasResult struct {
+	Gas hexutil.Uint64 `json:"gas"`
+	// Loose is set if the search was stopped by the iteration limit before
+	// converging, in which case Gas is sufficient but may be higher than needed.
+	Loose bool `json:"loose"`
+}
+
+// EstimateGasDetailed is like EstimateGas, but also reports whether the
+// estimate was stopped early by the configured iteration limit.
+func (s *BlockChainAPI) EstimateGasDetailed(ctx context.Context, args TransactionArgs, blockNrOrHash *rpc.BlockNumberOrHash) (*EstimateGasResult, error) {
+	bNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	if blockNrOrHash != nil {
+		bNrOrHash = *blockNrOrHash
+	}
+	gas, loose, err := doEstimateGas(ctx, s.b, args, bNrOrHash, s.b.RPCGasCap())
+	if err != nil {
+		return nil, err
+	}
+	return &EstimateGasResult{Gas: gas, Loose: loose}, nil
+}
+
 // RPCMarshalHeader converts the given header to the RPC output .
 func RPCMarshalHeader(head *types.Header) map[string]interface{} {
 	result := map[string]interface{}{
@@ -1509,7 +1933,7 @@ func newRPCRawTransactionFromBlockIndex(b *types.Block, index uint64) hexutil.By
 }
 
 // accessListResult returns an optional accesslist
-// It's the result of the `debug_createAccessList` RPC call.
+// It's the result of the `eth_createAccessList` RPC call.
 // It contains an error if the transaction itself failed.
 type accessListResult struct {
 	Accesslist *types.AccessList `json:"accessList"`
@@ -1824,6 +2248,148 @@ func (s *TransactionAPI) GetTransactionReceipt(ctx context.Context, hash common.
 	return fields, nil
 }
 
+// TransactionRulesResult is the result of a GetTransactionRules API call.
+type TransactionRulesResult struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	Fork        string         `json:"fork"`
+	EIPs        []int          `json:"eips"`
+}
+
+// GetTransactionRules returns the fork and the EIPs that governed the execution
+// of the transaction with the given hash, as determined by the chain config
+// at the number and timestamp of its block.
+func (s *TransactionAPI) GetTransactionRules(ctx context.Context, hash common.Hash) (*TransactionRulesResult, error) {
+	tx, blockHash, blockNumber, _, err := s.b.GetTransaction(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil {
+		// Pending transactions have not been executed yet
+		return nil, nil
+	}
+	header, err := s.b.HeaderByHash(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, fmt.Errorf("header %s not found", blockHash)
+	}
+	rules := s.b.ChainConfig().LuxRules(header.Number, header.Time)
+	return &TransactionRulesResult{
+		BlockNumber: hexutil.Uint64(blockNumber),
+		Fork:        rules.ForkName(),
+		EIPs:        rules.ActiveEIPs(),
+	}, nil
+}
+
+// EffectiveGasPriceResult is the result of a GetEffectiveGasPrice API call.
+type EffectiveGasPriceResult struct {
+	BlockNumber       hexutil.Uint64 `json:"blockNumber"`
+	BaseFee           *hexutil.Big   `json:"baseFee,omitempty"`
+	EffectiveGasPrice *hexutil.Big   `json:"effectiveGasPrice"`
+	EffectiveGasTip   *hexutil.Big   `json:"effectiveGasTip"`
+}
+
+// GetEffectiveGasPrice returns the gas price paid by the transaction with the
+// given hash, and the tip per unit of gas received by the coinbase of its
+// block. Dynamic fee transactions pay min(maxFeePerGas, baseFee +
+// maxPriorityFeePerGas) and legacy transactions their gas price.
+func (s *TransactionAPI) GetEffectiveGasPrice(ctx context.Context, hash common.Hash) (*EffectiveGasPriceResult, error) {
+	tx, blockHash, blockNumber, _, err := s.b.GetTransaction(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil {
+		// Pending transactions have not paid for gas yet
+		return nil, nil
+	}
+	header, err := s.b.HeaderByHash(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, fmt.Errorf("header %s not found", blockHash)
+	}
+	result := &EffectiveGasPriceResult{
+		BlockNumber: hexutil.Uint64(blockNumber),
+	}
+	if header.BaseFee != nil {
+		result.BaseFee = (*hexutil.Big)(header.BaseFee)
+	}
+	tip, err := tx.EffectiveGasTip(header.BaseFee)
+	if err != nil {
+		// Included transactions cover the base fee of their block
+		return nil, fmt.Errorf("transaction %s does not cover the base fee of block %s: %w", hash, blockHash, err)
+	}
+	result.EffectiveGasTip = (*hexutil.Big)(tip)
+	switch {
+	case tx.Type() == types.LegacyTxType || tx.Type() == types.AccessListTxType || header.BaseFee == nil:
+		result.EffectiveGasPrice = (*hexutil.Big)(tx.GasPrice())
+	default:
+		result.EffectiveGasPrice = (*hexutil.Big)(new(big.Int).Add(header.BaseFee, tip))
+	}
+	return result, nil
+}
+
+// TransactionProofResult is the result of a GetTransactionProof API call.
+type TransactionProofResult struct {
+	TxHash           common.Hash    `json:"transactionHash"`
+	TransactionIndex hexutil.Uint64 `json:"transactionIndex"`
+	BlockHash        common.Hash    `json:"blockHash"`
+	BlockNumber      hexutil.Uint64 `json:"blockNumber"`
+	TransactionsRoot common.Hash    `json:"transactionsRoot"`
+	Proof            []string       `json:"proof"`
+}
+
+// GetTransactionProof returns the Merkle proof of the transaction with the
+// given hash in the transaction trie of its block. The proof is keyed by the
+// RLP encoding of the transaction index and verifies against the
+// transactionsRoot of the block header.
+func (s *TransactionAPI) GetTransactionProof(ctx context.Context, hash common.Hash) (*TransactionProofResult, error) {
+	tx, blockHash, blockNumber, index, err := s.b.GetTransaction(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil {
+		return nil, fmt.Errorf("transaction %s not found", hash)
+	}
+	block, err := s.b.BlockByHash(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block %s not found", blockHash)
+	}
+	tr := trie.NewEmpty(trie.NewDatabase(rawdb.NewMemoryDatabase()))
+	if root := types.DeriveSha(block.Transactions(), tr); root != block.TxHash() {
+		return nil, fmt.Errorf("transaction root mismatch for block %s: have %s, want %s", blockHash, root, block.TxHash())
+	}
+	var proof proofList
+	if err := tr.Prove(rlp.AppendUint64(nil, index), 0, &proof); err != nil {
+		return nil, err
+	}
+	return &TransactionProofResult{
+		TxHash:           hash,
+		TransactionIndex: hexutil.Uint64(index),
+		BlockHash:        blockHash,
+		BlockNumber:      hexutil.Uint64(blockNumber),
+		TransactionsRoot: block.TxHash(),
+		Proof:            toHexSlice(proof),
+	}, nil
+}
+
+// proofList collects the nodes of a Merkle proof, in order from the root.
+type proofList [][]byte
+
+func (n *proofList) Put(key []byte, value []byte) error {
+	*n = append(*n, value)
+	return nil
+}
+
+func (n *proofList) Delete(key []byte) error {
+	panic("not supported")
+}
+
 // sign is a helper function that signs a transaction with the private key of the given address.
 func (s *TransactionAPI) sign(addr common.Address, tx *types.Transaction) (*types.Transaction, error) {
 	// Look up the wallet containing the requested signer
@@ -1927,6 +2493,50 @@ func (s *TransactionAPI) SendRawTransaction(ctx context.Context, input hexutil.B
 	return SubmitTransaction(ctx, s.b, tx)
 }
 
+// SendReplacementTransaction submits the signed transaction [input] as a replacement
+// of the pending transaction with [hash], for example to speed it up with a higher tip.
+// Since the node cannot sign on behalf of the sender, the replacement must be signed
+// by the caller. It must be sent from the same account with the same nonce and payload
+// as the original, and pay both a higher fee cap and a higher tip. The transaction pool
+// evicts the original once it accepts the replacement.
+func (s *TransactionAPI) SendReplacementTransaction(ctx context.Context, hash common.Hash, input hexutil.Bytes) (common.Hash, error) {
+	original := s.b.GetPoolTransaction(hash)
+	if original == nil {
+		return common.Hash{}, fmt.Errorf("transaction %#x not found in pool", hash)
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(input); err != nil {
+		return common.Hash{}, err
+	}
+	from, err := types.Sender(s.signer, original)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	replacementFrom, err := types.Sender(s.signer, tx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	switch {
+	case replacementFrom != from:
+		return common.Hash{}, fmt.Errorf("replacement sender %s does not match original sender %s", replacementFrom, from)
+	case tx.Nonce() != original.Nonce():
+		return common.Hash{}, fmt.Errorf("replacement nonce %d does not match original nonce %d", tx.Nonce(), original.Nonce())
+	case !sameTxPayload(tx, original):
+		return common.Hash{}, errors.New("replacement recipient, value and data must match the original")
+	case tx.GasFeeCapCmp(original) <= 0 || tx.GasTipCapCmp(original) <= 0:
+		return common.Hash{}, fmt.Errorf("replacement must pay a higher fee cap and tip than the original (feeCap %v, tip %v)", original.GasFeeCap(), original.GasTipCap())
+	}
+	return SubmitTransaction(ctx, s.b, tx)
+}
+
+// sameTxPayload returns whether [a] and [b] have the same recipient, value and data.
+func sameTxPayload(a, b *types.Transaction) bool {
+	if (a.To() == nil) != (b.To() == nil) || (a.To() != nil && *a.To() != *b.To()) {
+		return false
+	}
+	return a.Value().Cmp(b.Value()) == 0 && bytes.Equal(a.Data(), b.Data())
+}
+
 // Sign calculates an ECDSA signature for:
 // keccak256("\x19Ethereum Signed Message:\n" + len(message) + message).
 //
@@ -2078,55 +2688,53 @@ func NewDebugAPI(b Backend) *DebugAPI {
 	return &DebugAPI{b: b}
 }
 
-// GetRawHeader retrieves the RLP encoding for a single header.
+// GetRawHeader retrieves the RLP encoding for a single header. The encoding is
+// the one hashed for consensus, so its keccak256 hash is the block hash.
 func (api *DebugAPI) GetRawHeader(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
-	var hash common.Hash
-	if h, ok := blockNrOrHash.Hash(); ok {
-		hash = h
-	} else {
-		block, err := api.b.BlockByNumberOrHash(ctx, blockNrOrHash)
-		if err != nil {
-			return nil, err
-		}
-		hash = block.Hash()
+	hash, err := api.blockHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
 	}
 	header, _ := api.b.HeaderByHash(ctx, hash)
 	if header == nil {
-		return nil, fmt.Errorf("header #%d not found", hash)
+		return nil, fmt.Errorf("header %s not found", hash)
 	}
 	return rlp.EncodeToBytes(header)
 }
 
 // GetRawBlock retrieves the RLP encoded for a single block.
 func (api *DebugAPI) GetRawBlock(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
-	var hash common.Hash
-	if h, ok := blockNrOrHash.Hash(); ok {
-		hash = h
-	} else {
-		block, err := api.b.BlockByNumberOrHash(ctx, blockNrOrHash)
-		if err != nil {
-			return nil, err
-		}
-		hash = block.Hash()
+	hash, err := api.blockHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
 	}
 	block, _ := api.b.BlockByHash(ctx, hash)
 	if block == nil {
-		return nil, fmt.Errorf("block #%d not found", hash)
+		return nil, fmt.Errorf("block %s not found", hash)
 	}
 	return rlp.EncodeToBytes(block)
 }
 
+// blockHash returns the hash of the block specified by [blockNrOrHash].
+func (api *DebugAPI) blockHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (common.Hash, error) {
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		return hash, nil
+	}
+	block, err := api.b.BlockByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if block == nil {
+		return common.Hash{}, fmt.Errorf("block %s not found", blockNrOrHash.String())
+	}
+	return block.Hash(), nil
+}
+
 // GetRawReceipts retrieves the binary-encoded receipts of a single block.
 func (api *DebugAPI) GetRawReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]hexutil.Bytes, error) {
-	var hash common.Hash
-	if h, ok := blockNrOrHash.Hash(); ok {
-		hash = h
-	} else {
-		block, err := api.b.BlockByNumberOrHash(ctx, blockNrOrHash)
-		if err != nil {
-			return nil, err
-		}
-		hash = block.Hash()
+	hash, err := api.blockHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
 	}
 	receipts, err := api.b.GetReceipts(ctx, hash)
 	if err != nil {
@@ -47,11 +47,16 @@ import (
 	"github.com/luxdefi/evm/core/bloombits"
 	"github.com/luxdefi/evm/core/rawdb"
 	"github.com/luxdefi/evm/core/state"
+	"github.com/luxdefi/evm/core/txpool"
 	"github.com/luxdefi/evm/core/types"
 	"github.com/luxdefi/evm/core/vm"
 	"github.com/luxdefi/evm/ethdb"
+	"github.com/luxdefi/evm/ethdb/memorydb"
 	"github.com/luxdefi/evm/params"
+	"github.com/luxdefi/evm/precompile/contracts/blockhashes"
 	"github.com/luxdefi/evm/rpc"
+	"github.com/luxdefi/evm/trie"
+	"github.com/luxdefi/evm/utils"
 <<<<<<< HEAD
 
 =======
@@ -61,6 +66,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/rlp"
 	"golang.org/x/crypto/sha3"
 )
 
@@ -198,6 +204,9 @@ func allTransactionTypes(addr common.Address, config *params.ChainConfig) []type
 type testBackend struct {
 	db    ethdb.Database
 	chain *core.BlockChain
+	pool  *txpool.TxPool // optional, set by tests submitting transactions
+
+	estimateGasMaxIterations int
 }
 
 func newTestBackend(t *testing.T, n int, gspec *core.Genesis, generator func(i int, b *core.BlockGen)) *testBackend {
@@ -239,6 +248,8 @@ func (b testBackend) ExtRPCEnabled() bool                        { return false
 func (b testBackend) RPCGasCap() uint64                          { return 10000000 }
 func (b testBackend) RPCEVMTimeout() time.Duration               { return time.Second }
 func (b testBackend) RPCTxFeeCap() float64                       { return 0 }
+func (b testBackend) RPCGasStatsBlockCap() uint64                { return 3 }
+func (b testBackend) RPCEstimateGasMaxIterations() int           { return b.estimateGasMaxIterations }
 func (b testBackend) UnprotectedAllowed(*types.Transaction) bool { return false }
 func (b testBackend) SetHead(number uint64)                      {}
 func (b testBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
@@ -248,13 +259,13 @@ func (b testBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber)
 	return b.chain.GetHeaderByNumber(uint64(number)), nil
 }
 func (b testBackend) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
-	panic("implement me")
+	return b.chain.GetHeaderByHash(hash), nil
 }
 func (b testBackend) HeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Header, error) {
 	panic("implement me")
 }
-func (b testBackend) CurrentHeader() *types.Header { panic("implement me") }
-func (b testBackend) CurrentBlock() *types.Header  { panic("implement me") }
+func (b testBackend) CurrentHeader() *types.Header { return b.chain.CurrentBlock() }
+func (b testBackend) CurrentBlock() *types.Header  { return b.chain.CurrentBlock() }
 func (b testBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
 	if number == rpc.LatestBlockNumber {
 		head := b.chain.CurrentBlock()
@@ -263,7 +274,7 @@ func (b testBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber)
 	return b.chain.GetBlockByNumber(uint64(number)), nil
 }
 func (b testBackend) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
-	panic("implement me")
+	return b.chain.GetBlockByHash(hash), nil
 }
 func (b testBackend) BlockByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Block, error) {
 	if blockNr, ok := blockNrOrHash.Number(); ok {
@@ -294,6 +305,9 @@ func (b testBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOr
 	}
 	panic("only implemented for number")
 }
+func (b testBackend) HistoricalBalance(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*big.Int, error) {
+	panic("implement me")
+}
 func (b testBackend) PendingBlockAndReceipts() (*types.Block, types.Receipts) { panic("implement me") }
 func (b testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
 	panic("implement me")
@@ -321,16 +335,19 @@ func (b testBackend) SubscribeChainSideEvent(ch chan<- core.ChainSideEvent) even
 	panic("implement me")
 }
 func (b testBackend) GetFeeConfigAt(parent *types.Header) (commontype.FeeConfig, *big.Int, error) {
-	panic("implement me")
+	return b.chain.GetFeeConfigAt(parent)
 }
 func (b testBackend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
-	panic("implement me")
+	return b.pool.AddLocal(signedTx)
 }
 func (b testBackend) GetTransaction(ctx context.Context, txHash common.Hash) (*types.Transaction, common.Hash, uint64, uint64, error) {
-	panic("implement me")
+	tx, blockHash, blockNumber, index := rawdb.ReadTransaction(b.db, txHash)
+	return tx, blockHash, blockNumber, index, nil
+}
+func (b testBackend) GetPoolTransactions() (types.Transactions, error) { panic("implement me") }
+func (b testBackend) GetPoolTransaction(txHash common.Hash) *types.Transaction {
+	return b.pool.Get(txHash)
 }
-func (b testBackend) GetPoolTransactions() (types.Transactions, error)         { panic("implement me") }
-func (b testBackend) GetPoolTransaction(txHash common.Hash) *types.Transaction { panic("implement me") }
 func (b testBackend) GetPoolNonce(ctx context.Context, addr common.Address) (uint64, error) {
 	panic("implement me")
 }
@@ -344,6 +361,9 @@ func (b testBackend) TxPoolContentFrom(addr common.Address) (types.Transactions,
 func (b testBackend) SubscribeNewTxsEvent(events chan<- core.NewTxsEvent) event.Subscription {
 	panic("implement me")
 }
+func (b testBackend) SubscribeTxPoolEvents(buffer int) *txpool.TxEventSubscription {
+	panic("implement me")
+}
 func (b testBackend) ChainConfig() *params.ChainConfig { return b.chain.Config() }
 func (b testBackend) Engine() consensus.Engine         { return b.chain.Engine() }
 func (b testBackend) GetLogs(ctx context.Context, blockHash common.Hash, number uint64) ([][]*types.Log, error) {
@@ -452,6 +472,95 @@ func TestEstimateGas(t *testing.T) {
 	}
 }
 
+func TestEstimateGasWithAccessList(t *testing.T) {
+	t.Parallel()
+	var (
+		accounts = newAccounts(1)
+		contract = common.HexToAddress("0xc0ffee0000000000000000000000000000000000")
+		touched1 = common.HexToAddress("0x1111111111111111111111111111111111111111")
+		touched2 = common.HexToAddress("0x2222222222222222222222222222222222222222")
+		genesis  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: core.GenesisAlloc{
+				accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+				// BALANCE(touched1) POP BALANCE(touched2) POP STOP
+				contract: {Code: common.Hex2Bytes("73" + touched1.Hex()[2:] + "3150" + "73" + touched2.Hex()[2:] + "315000")},
+			},
+		}
+		ctx    = context.Background()
+		latest = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		args   = TransactionArgs{From: &accounts[0].addr, To: &contract}
+	)
+	api := NewBlockChainAPI(newTestBackend(t, 1, genesis, func(i int, b *core.BlockGen) {}))
+
+	withoutList, err := api.EstimateGas(ctx, args, &latest)
+	if err != nil {
+		t.Fatalf("failed to estimate gas without access list: %v", err)
+	}
+	args.AccessList = &types.AccessList{{Address: touched1}, {Address: touched2}}
+	withList, err := api.EstimateGas(ctx, args, &latest)
+	if err != nil {
+		t.Fatalf("failed to estimate gas with access list: %v", err)
+	}
+	// Each listed account costs 2400 gas up front, but saves the 2500 gas
+	// difference between a cold and a warm access.
+	if want := withoutList - 200; withList != want {
+		t.Fatalf("estimate mismatch: have %d, want %d (without access list %d)", withList, want, withoutList)
+	}
+}
+
+func TestEstimateGasMaxIterations(t *testing.T) {
+	t.Parallel()
+	var (
+		accounts = newAccounts(1)
+		contract = common.HexToAddress("0xc0ffee0000000000000000000000000000000000")
+		genesis  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: core.GenesisAlloc{
+				accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+				// Counts down from 1024 in a loop, so the gas used is far from
+				// both ends of the search range: PUSH2 0x0400 JUMPDEST PUSH1 1
+				// SWAP1 SUB DUP1 PUSH1 3 JUMPI STOP
+				contract: {Code: common.Hex2Bytes("6104005b600190038060035700")},
+			},
+		}
+		ctx    = context.Background()
+		latest = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		args   = TransactionArgs{From: &accounts[0].addr, To: &contract}
+	)
+	backend := newTestBackend(t, 1, genesis, func(i int, b *core.BlockGen) {})
+	api := NewBlockChainAPI(backend)
+
+	// Without a limit the search converges on the exact requirement
+	precise, err := api.EstimateGasDetailed(ctx, args, &latest)
+	if err != nil {
+		t.Fatalf("failed to estimate gas: %v", err)
+	}
+	if precise.Loose {
+		t.Fatalf("unlimited estimate reported as loose")
+	}
+	if gas, err := api.EstimateGas(ctx, args, &latest); err != nil || gas != precise.Gas {
+		t.Fatalf("estimate mismatch: have %d (err %v), want %d", gas, err, precise.Gas)
+	}
+
+	// With a limit the search stops early with a sufficient upper bound
+	backend.estimateGasMaxIterations = 4
+	bounded, err := api.EstimateGasDetailed(ctx, args, &latest)
+	if err != nil {
+		t.Fatalf("failed to estimate gas with iteration limit: %v", err)
+	}
+	if !bounded.Loose {
+		t.Fatalf("bounded estimate not reported as loose")
+	}
+	if bounded.Gas <= precise.Gas {
+		t.Fatalf("bounded estimate %d not above precise estimate %d", bounded.Gas, precise.Gas)
+	}
+	args.Gas = &bounded.Gas
+	if _, err := api.Call(ctx, args, latest, nil, nil); err != nil {
+		t.Fatalf("call with bounded estimate failed: %v", err)
+	}
+}
+
 func TestCall(t *testing.T) {
 	t.Parallel()
 	// Initialize test accounts
@@ -595,6 +704,33 @@ func TestCall(t *testing.T) {
 			blockOverrides: BlockOverrides{Number: (*hexutil.Big)(big.NewInt(11))},
 			want:           "0x000000000000000000000000000000000000000000000000000000000000000b",
 		},
+		// Without an override, the random value is the block's difficulty
+		{
+			blockNumber: rpc.LatestBlockNumber,
+			call: TransactionArgs{
+				From: &accounts[1].addr,
+				Input: &hexutil.Bytes{
+					0x44,             // DIFFICULTY (PREVRANDAO)
+					0x60, 0x00, 0x52, // MSTORE offset 0
+					0x60, 0x20, 0x60, 0x00, 0xf3,
+				},
+			},
+			want: "0x0000000000000000000000000000000000000000000000000000000000000001",
+		},
+		// The random value can be overridden
+		{
+			blockNumber: rpc.LatestBlockNumber,
+			call: TransactionArgs{
+				From: &accounts[1].addr,
+				Input: &hexutil.Bytes{
+					0x44,             // DIFFICULTY (PREVRANDAO)
+					0x60, 0x00, 0x52, // MSTORE offset 0
+					0x60, 0x20, 0x60, 0x00, 0xf3,
+				},
+			},
+			blockOverrides: BlockOverrides{Random: &common.Hash{0xde, 0xad}},
+			want:           "0xdead000000000000000000000000000000000000000000000000000000000000",
+		},
 	}
 	for i, tc := range testSuite {
 		result, err := api.Call(context.Background(), tc.call, rpc.BlockNumberOrHash{BlockNumber: &tc.blockNumber}, &tc.overrides, &tc.blockOverrides)
@@ -621,6 +757,165 @@ func TestCall(t *testing.T) {
 	}
 }
 
+func TestCreateAccessList(t *testing.T) {
+	t.Parallel()
+	var (
+		accounts = newAccounts(1)
+		contract = common.HexToAddress("0xc0ffee0000000000000000000000000000000000")
+		touched1 = common.HexToAddress("0x1111111111111111111111111111111111111111")
+		touched2 = common.HexToAddress("0x2222222222222222222222222222222222222222")
+		genesis  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: core.GenesisAlloc{
+				accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+				// BALANCE(touched1) POP BALANCE(touched2) POP STOP
+				contract: {Code: common.Hex2Bytes("73" + touched1.Hex()[2:] + "3150" + "73" + touched2.Hex()[2:] + "315000")},
+			},
+		}
+		ctx    = context.Background()
+		latest = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		args   = TransactionArgs{From: &accounts[0].addr, To: &contract}
+	)
+	api := NewBlockChainAPI(newTestBackend(t, 1, genesis, func(i int, b *core.BlockGen) {}))
+
+	result, err := api.CreateAccessList(ctx, args, &latest)
+	if err != nil {
+		t.Fatalf("failed to create access list: %v", err)
+	}
+	if result.Error != "" {
+		t.Fatalf("unexpected execution error: %s", result.Error)
+	}
+	// The sender, the recipient and precompiles are warm already, so only the
+	// accounts touched by the contract are listed.
+	if have := len(*result.Accesslist); have != 2 {
+		t.Fatalf("access list length mismatch: have %d, want 2", have)
+	}
+	for _, tuple := range *result.Accesslist {
+		if tuple.Address != touched1 && tuple.Address != touched2 {
+			t.Fatalf("unexpected address in access list: %s", tuple.Address)
+		}
+	}
+
+	// Re-executing with the returned access list uses the reported amount of
+	// gas, which is less than without it.
+	withoutList, err := DoCall(ctx, api.b, args, latest, nil, nil, time.Second, api.b.RPCGasCap())
+	if err != nil {
+		t.Fatalf("failed to execute call without access list: %v", err)
+	}
+	args.AccessList = result.Accesslist
+	withList, err := DoCall(ctx, api.b, args, latest, nil, nil, time.Second, api.b.RPCGasCap())
+	if err != nil {
+		t.Fatalf("failed to execute call with access list: %v", err)
+	}
+	if withList.UsedGas != uint64(result.GasUsed) {
+		t.Fatalf("gas used mismatch: have %d, reported %d", withList.UsedGas, result.GasUsed)
+	}
+	if withList.UsedGas >= withoutList.UsedGas {
+		t.Fatalf("access list did not reduce gas: with %d, without %d", withList.UsedGas, withoutList.UsedGas)
+	}
+}
+
+func TestEstimateSettlementCost(t *testing.T) {
+	t.Parallel()
+	var (
+		accounts = newAccounts(1)
+		ctx      = context.Background()
+		signer   = types.LatestSigner(params.TestChainConfig)
+	)
+	tx, err := types.SignTx(types.NewTransaction(0, accounts[0].addr, big.NewInt(1), params.TxGas, big.NewInt(params.GWei), make([]byte, 1000)), signer, accounts[0].key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a settlement layer, posting data is free.
+	api := NewBlockChainAPI(newTestBackend(t, 1, &core.Genesis{Config: params.TestChainConfig}, func(i int, b *core.BlockGen) {}))
+	cost, err := api.EstimateSettlementCost(ctx, raw)
+	if err != nil {
+		t.Fatalf("failed to estimate settlement cost: %v", err)
+	}
+	if cost.ToInt().Sign() != 0 {
+		t.Fatalf("settlement cost mismatch: have %d, want 0", cost.ToInt())
+	}
+
+	config := *params.TestChainConfig
+	config.SettlementConfig = &params.SettlementConfig{
+		BaseFee:        big.NewInt(3),
+		DataGasPerByte: 16,
+		FixedOverhead:  188,
+	}
+	// 100 compressed bytes cost (100*16 + 188) * 3 wei.
+	if have, want := config.SettlementConfig.DataCost(100), big.NewInt(5364); have.Cmp(want) != 0 {
+		t.Fatalf("data cost mismatch: have %d, want %d", have, want)
+	}
+
+	size, err := compressedSize(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The zeroed calldata compresses well.
+	if size >= uint64(len(raw)) {
+		t.Fatalf("compressed size %d not below raw size %d", size, len(raw))
+	}
+	api = NewBlockChainAPI(newTestBackend(t, 1, &core.Genesis{Config: &config}, func(i int, b *core.BlockGen) {}))
+	cost, err = api.EstimateSettlementCost(ctx, raw)
+	if err != nil {
+		t.Fatalf("failed to estimate settlement cost: %v", err)
+	}
+	if want := new(big.Int).SetUint64(3 * (16*size + 188)); cost.ToInt().Cmp(want) != 0 {
+		t.Fatalf("settlement cost mismatch: have %d, want %d", cost.ToInt(), want)
+	}
+
+	if _, err := api.EstimateSettlementCost(ctx, hexutil.Bytes{0x01, 0x02}); err == nil {
+		t.Fatal("expected error for malformed transaction")
+	}
+}
+
+func TestDynamicFeeParams(t *testing.T) {
+	t.Parallel()
+	config := *params.TestChainConfig
+	config.FeeConfig = commontype.FeeConfig{
+		GasLimit:                 big.NewInt(10_000_000),
+		TargetBlockRate:          2,
+		MinBaseFee:               big.NewInt(params.GWei),
+		TargetGas:                big.NewInt(20_000_000),
+		BaseFeeChangeDenominator: big.NewInt(48),
+		MinBlockGasCost:          big.NewInt(0),
+		MaxBlockGasCost:          big.NewInt(1_000_000),
+		BlockGasCostStep:         big.NewInt(200_000),
+	}
+	api := NewBlockChainAPI(newTestBackend(t, 2, &core.Genesis{Config: &config}, func(i int, b *core.BlockGen) {}))
+
+	result, err := api.DynamicFeeParams(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get dynamic fee params: %v", err)
+	}
+	if have, want := result.GasTarget.ToInt(), config.FeeConfig.TargetGas; have.Cmp(want) != 0 {
+		t.Fatalf("gas target mismatch: have %d, want %d", have, want)
+	}
+	if have, want := result.MaxChangeDenominator.ToInt(), config.FeeConfig.BaseFeeChangeDenominator; have.Cmp(want) != 0 {
+		t.Fatalf("max change denominator mismatch: have %d, want %d", have, want)
+	}
+	if have, want := uint64(result.TargetBlockRate), config.FeeConfig.TargetBlockRate; have != want {
+		t.Fatalf("target block rate mismatch: have %d, want %d", have, want)
+	}
+	// 5 blocks of 10M gas in the 10s rollup window against a target of 20M gas
+	if have, want := result.ElasticityMultiplier, 2.5; have != want {
+		t.Fatalf("elasticity multiplier mismatch: have %f, want %f", have, want)
+	}
+	// No blocks were produced since long before now, so the base fee of the
+	// next block falls to the minimum
+	if result.BaseFee == nil {
+		t.Fatal("expected base fee for the next block")
+	}
+	if have, want := result.BaseFee.ToInt(), config.FeeConfig.MinBaseFee; have.Cmp(want) != 0 {
+		t.Fatalf("base fee mismatch: have %d, want %d", have, want)
+	}
+}
+
 type Account struct {
 	key  *ecdsa.PrivateKey
 	addr common.Address
@@ -632,6 +927,656 @@ func (a Accounts) Len() int           { return len(a) }
 func (a Accounts) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a Accounts) Less(i, j int) bool { return bytes.Compare(a[i].addr.Bytes(), a[j].addr.Bytes()) < 0 }
 
+func TestActivePrecompiles(t *testing.T) {
+	t.Parallel()
+	config := *params.TestChainConfig
+	config.GenesisPrecompiles = params.Precompiles{
+		blockhashes.ConfigKey: blockhashes.NewConfig(utils.NewUint64(15)),
+	}
+	genesis := &core.Genesis{Config: &config}
+
+	// Blocks are 10 seconds apart, so the precompile activates at the second block
+	for _, tt := range []struct {
+		blocks int
+		active bool
+	}{
+		{blocks: 1, active: false},
+		{blocks: 2, active: true},
+	} {
+		api := NewBlockChainAPI(newTestBackend(t, tt.blocks, genesis, func(i int, b *core.BlockGen) {}))
+		results := api.ActivePrecompiles(context.Background())
+		if !tt.active {
+			if len(results) != 0 {
+				t.Errorf("blocks %d: expected no active precompiles, have %v", tt.blocks, results)
+			}
+			continue
+		}
+		if len(results) != 1 {
+			t.Fatalf("blocks %d: active precompiles mismatch, have %d, want 1", tt.blocks, len(results))
+		}
+		result := results[0]
+		if result.Address != blockhashes.ContractAddress {
+			t.Errorf("blocks %d: address mismatch, have %s, want %s", tt.blocks, result.Address, blockhashes.ContractAddress)
+		}
+		if result.Name != blockhashes.ConfigKey {
+			t.Errorf("blocks %d: name mismatch, have %s, want %s", tt.blocks, result.Name, blockhashes.ConfigKey)
+		}
+		if result.ActivationTimestamp == nil || uint64(*result.ActivationTimestamp) != 15 {
+			t.Errorf("blocks %d: activation timestamp mismatch, have %v, want 15", tt.blocks, result.ActivationTimestamp)
+		}
+	}
+}
+
+func TestGetAccountProofs(t *testing.T) {
+	t.Parallel()
+	var (
+		accounts = newAccounts(3)
+		genesis  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: core.GenesisAlloc{
+				accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+				accounts[1].addr: {Balance: big.NewInt(1), Nonce: 1},
+			},
+		}
+		missing = []common.Address{accounts[2].addr, {0xde, 0xad}}
+	)
+	api := NewBlockChainAPI(newTestBackend(t, 1, genesis, func(i int, b *core.BlockGen) {}))
+
+	addresses := append([]common.Address{accounts[0].addr, accounts[1].addr}, missing...)
+	result, err := api.GetAccountProofs(context.Background(), addresses, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber))
+	if err != nil {
+		t.Fatalf("failed to get proofs: %v", err)
+	}
+	if len(result.Accounts) != len(addresses) {
+		t.Fatalf("proof count mismatch, have %d, want %d", len(result.Accounts), len(addresses))
+	}
+	for i, account := range result.Accounts {
+		if account.Address != addresses[i] {
+			t.Errorf("proof %d: address mismatch, have %s, want %s", i, account.Address, addresses[i])
+		}
+		if want := i < 2; account.Exists != want {
+			t.Errorf("proof %d: existence mismatch, have %v, want %v", i, account.Exists, want)
+		}
+		proofDb := memorydb.New()
+		for _, node := range account.Proof {
+			blob := hexutil.MustDecode(node)
+			if err := proofDb.Put(crypto.Keccak256(blob), blob); err != nil {
+				t.Fatal(err)
+			}
+		}
+		value, err := trie.VerifyProof(result.StateRoot, crypto.Keccak256(account.Address.Bytes()), proofDb)
+		if err != nil {
+			t.Fatalf("proof %d: invalid proof: %v", i, err)
+		}
+		if exists := value != nil; exists != account.Exists {
+			t.Errorf("proof %d: proven existence mismatch, have %v, want %v", i, exists, account.Exists)
+		}
+	}
+
+	// Requests are bounded
+	if _, err := api.GetAccountProofs(context.Background(), make([]common.Address, maxAccountProofs+1), rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)); err == nil {
+		t.Fatal("expected error for too many addresses")
+	}
+}
+
+func TestPreviewStateRoot(t *testing.T) {
+	t.Parallel()
+	var (
+		accounts = newAccounts(2)
+		contract = common.HexToAddress("0xc0ffee")
+		genesis  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: core.GenesisAlloc{
+				accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+				contract: {
+					Code:    []byte{byte(vm.PUSH1), 0x00, byte(vm.SLOAD)},
+					Storage: map[common.Hash]common.Hash{{0x01}: {0x01}},
+				},
+			},
+		}
+		ctx     = context.Background()
+		latest  = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		backend = newTestBackend(t, 1, genesis, func(i int, b *core.BlockGen) {})
+		api     = NewBlockChainAPI(backend)
+	)
+	nonce := hexutil.Uint64(5)
+	changes := StateOverride{
+		accounts[0].addr: {Balance: newRPCBalance(big.NewInt(1000)), Nonce: &nonce},
+		accounts[1].addr: {Balance: newRPCBalance(big.NewInt(2000))},
+		contract:         {StateDiff: &map[common.Hash]common.Hash{{0x01}: {0x02}, {0x02}: {0x03}}},
+	}
+	root, err := api.PreviewStateRoot(ctx, latest, changes)
+	if err != nil {
+		t.Fatalf("failed to preview state root: %v", err)
+	}
+
+	// Commit the same changes and compare the resulting root
+	head := backend.chain.CurrentBlock()
+	statedb, err := backend.chain.StateAt(head.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statedb.SetBalance(accounts[0].addr, big.NewInt(1000))
+	statedb.SetNonce(accounts[0].addr, 5)
+	statedb.SetBalance(accounts[1].addr, big.NewInt(2000))
+	statedb.SetState(contract, common.Hash{0x01}, common.Hash{0x02})
+	statedb.SetState(contract, common.Hash{0x02}, common.Hash{0x03})
+	want, err := statedb.Commit(true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != want {
+		t.Fatalf("previewed root mismatch: have %x, want %x", root, want)
+	}
+	if root == head.Root {
+		t.Fatal("previewed root should differ from the head root")
+	}
+	// Previewing does not change the state of the chain
+	if balance, _ := api.GetBalance(ctx, accounts[0].addr, latest); balance.ToInt().Cmp(big.NewInt(params.Ether)) != 0 {
+		t.Fatalf("balance changed by preview: have %d, want %d", balance.ToInt(), big.NewInt(params.Ether))
+	}
+
+	// Storage of an account without code is rejected
+	_, err = api.PreviewStateRoot(ctx, latest, StateOverride{
+		accounts[0].addr: {StateDiff: &map[common.Hash]common.Hash{{0x01}: {0x01}}},
+	})
+	if err == nil {
+		t.Fatal("expected error changing the storage of an account without code")
+	}
+}
+
+func TestGetTransactionRules(t *testing.T) {
+	t.Parallel()
+	var (
+		accounts = newAccounts(2)
+		config   = *params.TestEVMConfig
+		genesis  = &core.Genesis{
+			Config: &config,
+			Alloc:  core.GenesisAlloc{accounts[0].addr: {Balance: big.NewInt(params.Ether)}},
+		}
+		signer = types.LatestSigner(&config)
+		txs    []common.Hash
+	)
+	// Blocks are 10 seconds apart, so DUpgrade activates at the third block
+	config.DUpgradeTimestamp = utils.NewUint64(25)
+	backend := newTestBackend(t, 4, genesis, func(i int, b *core.BlockGen) {
+		tx, err := types.SignTx(types.NewTx(&types.LegacyTx{
+			Nonce:    uint64(i),
+			To:       &accounts[1].addr,
+			Value:    big.NewInt(1000),
+			Gas:      params.TxGas,
+			GasPrice: b.BaseFee(),
+		}), signer, accounts[0].key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.AddTx(tx)
+		txs = append(txs, tx.Hash())
+	})
+	// Transactions are indexed on accept
+	for i := uint64(1); i <= 4; i++ {
+		if err := backend.chain.Accept(backend.chain.GetBlockByNumber(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	backend.chain.DrainAcceptorQueue()
+	api := NewTransactionAPI(backend, new(AddrLocker))
+
+	for i, tt := range []struct {
+		fork    string
+		enabled []int
+		missing []int
+	}{
+		{fork: "EVM", enabled: []int{155, 2200, 1559, 2929}, missing: []int{1283, 3651, 3855, 3860}},
+		{fork: "EVM", enabled: []int{155, 2200, 1559, 2929}, missing: []int{1283, 3651, 3855, 3860}},
+		{fork: "DUpgrade", enabled: []int{155, 2200, 1559, 2929, 3651, 3855, 3860}, missing: []int{1283, 4844}},
+		{fork: "DUpgrade", enabled: []int{155, 2200, 1559, 2929, 3651, 3855, 3860}, missing: []int{1283, 4844}},
+	} {
+		result, err := api.GetTransactionRules(context.Background(), txs[i])
+		if err != nil {
+			t.Fatalf("tx %d: failed to get rules: %v", i, err)
+		}
+		if result == nil {
+			t.Fatalf("tx %d: missing rules", i)
+		}
+		if uint64(result.BlockNumber) != uint64(i+1) {
+			t.Errorf("tx %d: block number mismatch, have %d, want %d", i, result.BlockNumber, i+1)
+		}
+		if result.Fork != tt.fork {
+			t.Errorf("tx %d: fork mismatch, have %s, want %s", i, result.Fork, tt.fork)
+		}
+		active := make(map[int]bool)
+		for _, eip := range result.EIPs {
+			active[eip] = true
+		}
+		for _, eip := range tt.enabled {
+			if !active[eip] {
+				t.Errorf("tx %d: expected EIP-%d to be active", i, eip)
+			}
+		}
+		for _, eip := range tt.missing {
+			if active[eip] {
+				t.Errorf("tx %d: expected EIP-%d to be inactive", i, eip)
+			}
+		}
+	}
+
+	// Unknown transactions have no rules
+	result, err := api.GetTransactionRules(context.Background(), common.Hash{0x01})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != nil {
+		t.Fatalf("expected no rules for unknown transaction, have %v", result)
+	}
+}
+
+func TestGetEffectiveGasPrice(t *testing.T) {
+	t.Parallel()
+	var (
+		accounts = newAccounts(2)
+		genesis  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  core.GenesisAlloc{accounts[0].addr: {Balance: big.NewInt(params.Ether)}},
+		}
+		signer = types.LatestSigner(params.TestChainConfig)
+		txs    []*types.Transaction
+	)
+	backend := newTestBackend(t, 1, genesis, func(i int, b *core.BlockGen) {
+		baseFee := b.BaseFee()
+		for nonce, txdata := range []types.TxData{
+			// Legacy transactions pay their gas price, above the base fee
+			&types.LegacyTx{
+				Nonce:    0,
+				To:       &accounts[1].addr,
+				Gas:      params.TxGas,
+				GasPrice: new(big.Int).Mul(baseFee, common.Big2),
+			},
+			// The tip is bounded by the max priority fee
+			&types.DynamicFeeTx{
+				ChainID:   params.TestChainConfig.ChainID,
+				Nonce:     1,
+				To:        &accounts[1].addr,
+				Gas:       params.TxGas,
+				GasFeeCap: new(big.Int).Mul(baseFee, common.Big2),
+				GasTipCap: big.NewInt(1),
+			},
+			// The tip is bounded by the max fee
+			&types.DynamicFeeTx{
+				ChainID:   params.TestChainConfig.ChainID,
+				Nonce:     2,
+				To:        &accounts[1].addr,
+				Gas:       params.TxGas,
+				GasFeeCap: new(big.Int).Add(baseFee, common.Big3),
+				GasTipCap: baseFee,
+			},
+		} {
+			tx, err := types.SignNewTx(accounts[0].key, signer, txdata)
+			if err != nil {
+				t.Fatalf("tx %d: failed to sign: %v", nonce, err)
+			}
+			b.AddTx(tx)
+			txs = append(txs, tx)
+		}
+	})
+	// Transactions are indexed on accept
+	if err := backend.chain.Accept(backend.chain.GetBlockByNumber(1)); err != nil {
+		t.Fatal(err)
+	}
+	backend.chain.DrainAcceptorQueue()
+	api := NewTransactionAPI(backend, new(AddrLocker))
+	baseFee := backend.chain.GetHeaderByNumber(1).BaseFee
+
+	for i, want := range []struct {
+		price *big.Int
+		tip   *big.Int
+	}{
+		{price: new(big.Int).Mul(baseFee, common.Big2), tip: baseFee},
+		{price: new(big.Int).Add(baseFee, common.Big1), tip: common.Big1},
+		{price: new(big.Int).Add(baseFee, common.Big3), tip: common.Big3},
+	} {
+		result, err := api.GetEffectiveGasPrice(context.Background(), txs[i].Hash())
+		if err != nil {
+			t.Fatalf("tx %d: failed to get effective gas price: %v", i, err)
+		}
+		if result == nil {
+			t.Fatalf("tx %d: missing effective gas price", i)
+		}
+		if uint64(result.BlockNumber) != 1 {
+			t.Errorf("tx %d: block number mismatch, have %d, want 1", i, result.BlockNumber)
+		}
+		if result.BaseFee.ToInt().Cmp(baseFee) != 0 {
+			t.Errorf("tx %d: base fee mismatch, have %v, want %v", i, result.BaseFee, baseFee)
+		}
+		if result.EffectiveGasPrice.ToInt().Cmp(want.price) != 0 {
+			t.Errorf("tx %d: effective gas price mismatch, have %v, want %v", i, result.EffectiveGasPrice.ToInt(), want.price)
+		}
+		if result.EffectiveGasTip.ToInt().Cmp(want.tip) != 0 {
+			t.Errorf("tx %d: effective gas tip mismatch, have %v, want %v", i, result.EffectiveGasTip.ToInt(), want.tip)
+		}
+	}
+
+	// Unknown transactions have no effective gas price
+	result, err := api.GetEffectiveGasPrice(context.Background(), common.Hash{0x01})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != nil {
+		t.Fatalf("expected no effective gas price for unknown transaction, have %v", result)
+	}
+}
+
+func TestGetTransactionProof(t *testing.T) {
+	t.Parallel()
+	var (
+		accounts = newAccounts(2)
+		genesis  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  core.GenesisAlloc{accounts[0].addr: {Balance: big.NewInt(params.Ether)}},
+		}
+		signer = types.LatestSigner(params.TestChainConfig)
+		txs    []*types.Transaction
+	)
+	// A single block with several transactions, so the proof spans more than
+	// the root node
+	backend := newTestBackend(t, 1, genesis, func(i int, b *core.BlockGen) {
+		for nonce := uint64(0); nonce < 20; nonce++ {
+			tx, err := types.SignTx(types.NewTx(&types.LegacyTx{
+				Nonce:    nonce,
+				To:       &accounts[1].addr,
+				Value:    big.NewInt(1000),
+				Gas:      params.TxGas,
+				GasPrice: b.BaseFee(),
+			}), signer, accounts[0].key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b.AddTx(tx)
+			txs = append(txs, tx)
+		}
+	})
+	// Transactions are indexed on accept
+	if err := backend.chain.Accept(backend.chain.GetBlockByNumber(1)); err != nil {
+		t.Fatal(err)
+	}
+	backend.chain.DrainAcceptorQueue()
+	api := NewTransactionAPI(backend, new(AddrLocker))
+	header := backend.chain.GetHeaderByNumber(1)
+
+	for i, tx := range txs {
+		result, err := api.GetTransactionProof(context.Background(), tx.Hash())
+		if err != nil {
+			t.Fatalf("tx %d: failed to get proof: %v", i, err)
+		}
+		if result.BlockHash != header.Hash() {
+			t.Errorf("tx %d: block hash mismatch, have %s, want %s", i, result.BlockHash, header.Hash())
+		}
+		if result.TransactionsRoot != header.TxHash {
+			t.Errorf("tx %d: transactions root mismatch, have %s, want %s", i, result.TransactionsRoot, header.TxHash)
+		}
+		if uint64(result.TransactionIndex) != uint64(i) {
+			t.Errorf("tx %d: index mismatch, have %d, want %d", i, result.TransactionIndex, i)
+		}
+		proofDb := memorydb.New()
+		for _, node := range result.Proof {
+			blob := hexutil.MustDecode(node)
+			if err := proofDb.Put(crypto.Keccak256(blob), blob); err != nil {
+				t.Fatal(err)
+			}
+		}
+		value, err := trie.VerifyProof(header.TxHash, rlp.AppendUint64(nil, uint64(result.TransactionIndex)), proofDb)
+		if err != nil {
+			t.Fatalf("tx %d: invalid proof: %v", i, err)
+		}
+		want, err := tx.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(value, want) {
+			t.Errorf("tx %d: proven value mismatch, have %x, want %x", i, value, want)
+		}
+	}
+
+	// Unknown transactions have no proof
+	if _, err := api.GetTransactionProof(context.Background(), common.Hash{0x01}); err == nil {
+		t.Fatal("expected error for unknown transaction")
+	}
+}
+
+func TestGetRawBlockAndHeader(t *testing.T) {
+	t.Parallel()
+	var (
+		accounts = newAccounts(2)
+		genesis  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  core.GenesisAlloc{accounts[0].addr: {Balance: big.NewInt(params.Ether)}},
+		}
+		signer = types.LatestSigner(params.TestChainConfig)
+	)
+	backend := newTestBackend(t, 2, genesis, func(i int, b *core.BlockGen) {
+		tx, err := types.SignTx(types.NewTx(&types.LegacyTx{
+			Nonce:    uint64(i),
+			To:       &accounts[1].addr,
+			Value:    big.NewInt(1000),
+			Gas:      params.TxGas,
+			GasPrice: b.BaseFee(),
+		}), signer, accounts[0].key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.AddTx(tx)
+	})
+	api := NewDebugAPI(backend)
+
+	for number := uint64(0); number <= 2; number++ {
+		want := backend.chain.GetBlockByNumber(number)
+		for _, blockNrOrHash := range []rpc.BlockNumberOrHash{
+			rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(number)),
+			rpc.BlockNumberOrHashWithHash(want.Hash(), false),
+		} {
+			rawHeader, err := api.GetRawHeader(context.Background(), blockNrOrHash)
+			if err != nil {
+				t.Fatalf("block %d: failed to get raw header: %v", number, err)
+			}
+			if hash := crypto.Keccak256Hash(rawHeader); hash != want.Hash() {
+				t.Errorf("block %d: raw header hash mismatch, have %s, want %s", number, hash, want.Hash())
+			}
+			header := new(types.Header)
+			if err := rlp.DecodeBytes(rawHeader, header); err != nil {
+				t.Fatalf("block %d: failed to decode raw header: %v", number, err)
+			}
+			if header.Hash() != want.Hash() {
+				t.Errorf("block %d: decoded header hash mismatch, have %s, want %s", number, header.Hash(), want.Hash())
+			}
+
+			rawBlock, err := api.GetRawBlock(context.Background(), blockNrOrHash)
+			if err != nil {
+				t.Fatalf("block %d: failed to get raw block: %v", number, err)
+			}
+			block := new(types.Block)
+			if err := rlp.DecodeBytes(rawBlock, block); err != nil {
+				t.Fatalf("block %d: failed to decode raw block: %v", number, err)
+			}
+			if block.Hash() != want.Hash() {
+				t.Errorf("block %d: decoded block hash mismatch, have %s, want %s", number, block.Hash(), want.Hash())
+			}
+			if root := types.DeriveSha(block.Transactions(), trie.NewStackTrie(nil)); root != want.TxHash() {
+				t.Errorf("block %d: decoded transactions root mismatch, have %s, want %s", number, root, want.TxHash())
+			}
+			if len(block.Transactions()) != len(want.Transactions()) {
+				t.Fatalf("block %d: have %d transactions, want %d", number, len(block.Transactions()), len(want.Transactions()))
+			}
+			for i, tx := range block.Transactions() {
+				if tx.Hash() != want.Transactions()[i].Hash() {
+					t.Errorf("block %d: transaction %d mismatch, have %s, want %s", number, i, tx.Hash(), want.Transactions()[i].Hash())
+				}
+			}
+		}
+	}
+
+	// Unknown blocks are reported as errors
+	unknown := rpc.BlockNumberOrHashWithNumber(3)
+	if _, err := api.GetRawHeader(context.Background(), unknown); err == nil {
+		t.Error("expected error for unknown header")
+	}
+	if _, err := api.GetRawBlock(context.Background(), unknown); err == nil {
+		t.Error("expected error for unknown block")
+	}
+}
+
+func TestSendReplacementTransaction(t *testing.T) {
+	t.Parallel()
+	var (
+		accounts = newAccounts(2)
+		genesis  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  core.GenesisAlloc{accounts[0].addr: {Balance: big.NewInt(params.Ether)}},
+		}
+		signer = types.LatestSigner(params.TestChainConfig)
+	)
+	backend := newTestBackend(t, 1, genesis, func(i int, b *core.BlockGen) {})
+	backend.pool = txpool.NewTxPool(txpool.DefaultConfig, backend.chain.Config(), backend.chain)
+	defer backend.pool.Stop()
+	api := NewTransactionAPI(backend, new(AddrLocker))
+
+	sign := func(key *ecdsa.PrivateKey, nonce uint64, to common.Address, feeCap, tip int64) *types.Transaction {
+		tx, err := types.SignTx(types.NewTx(&types.DynamicFeeTx{
+			ChainID:   params.TestChainConfig.ChainID,
+			Nonce:     nonce,
+			To:        &to,
+			Value:     big.NewInt(1000),
+			Gas:       params.TxGas,
+			GasFeeCap: big.NewInt(feeCap * params.GWei),
+			GasTipCap: big.NewInt(tip * params.GWei),
+		}), signer, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tx
+	}
+	marshal := func(tx *types.Transaction) hexutil.Bytes {
+		input, err := tx.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return input
+	}
+	original := sign(accounts[0].key, 0, accounts[1].addr, 1000, 1)
+	if _, err := api.SendRawTransaction(context.Background(), marshal(original)); err != nil {
+		t.Fatalf("failed to send original: %v", err)
+	}
+
+	// Replacements that do not supersede the original are rejected
+	for name, tx := range map[string]*types.Transaction{
+		"different sender":  sign(accounts[1].key, 0, accounts[1].addr, 2000, 2),
+		"different nonce":   sign(accounts[0].key, 1, accounts[1].addr, 2000, 2),
+		"different payload": sign(accounts[0].key, 0, accounts[0].addr, 2000, 2),
+		"same tip":          sign(accounts[0].key, 0, accounts[1].addr, 2000, 1),
+	} {
+		if _, err := api.SendReplacementTransaction(context.Background(), original.Hash(), marshal(tx)); err == nil {
+			t.Errorf("%s: expected replacement to be rejected", name)
+		}
+	}
+	if backend.pool.Get(original.Hash()) == nil {
+		t.Fatal("original evicted by a rejected replacement")
+	}
+
+	// A replacement with a higher fee evicts the original
+	replacement := sign(accounts[0].key, 0, accounts[1].addr, 2000, 2)
+	hash, err := api.SendReplacementTransaction(context.Background(), original.Hash(), marshal(replacement))
+	if err != nil {
+		t.Fatalf("failed to send replacement: %v", err)
+	}
+	if hash != replacement.Hash() {
+		t.Fatalf("replacement hash mismatch, have %s, want %s", hash, replacement.Hash())
+	}
+	if backend.pool.Get(original.Hash()) != nil {
+		t.Fatal("original not evicted by the replacement")
+	}
+	if backend.pool.Get(replacement.Hash()) == nil {
+		t.Fatal("replacement not in pool")
+	}
+
+	// The original can no longer be replaced
+	if _, err := api.SendReplacementTransaction(context.Background(), original.Hash(), marshal(replacement)); err == nil {
+		t.Fatal("expected replacement of an evicted transaction to be rejected")
+	}
+}
+
+func TestGasStats(t *testing.T) {
+	t.Parallel()
+	var (
+		accounts = newAccounts(2)
+		genesis  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  core.GenesisAlloc{accounts[0].addr: {Balance: big.NewInt(params.Ether)}},
+		}
+		signer = types.LatestSigner(params.TestChainConfig)
+		nonce  uint64
+		ctx    = context.Background()
+	)
+	// Block i includes i transactions
+	backend := newTestBackend(t, 4, genesis, func(i int, b *core.BlockGen) {
+		for j := 0; j < i; j++ {
+			tx, err := types.SignTx(types.NewTx(&types.LegacyTx{
+				Nonce:    nonce,
+				To:       &accounts[1].addr,
+				Value:    big.NewInt(1000),
+				Gas:      params.TxGas,
+				GasPrice: b.BaseFee(),
+			}), signer, accounts[0].key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b.AddTx(tx)
+			nonce++
+		}
+	})
+	api := NewBlockChainAPI(backend)
+
+	result, err := api.GasStats(ctx, 2, rpc.LatestBlockNumber)
+	if err != nil {
+		t.Fatalf("failed to get gas stats: %v", err)
+	}
+	if len(result.Blocks) != 3 {
+		t.Fatalf("block count mismatch: have %d, want 3", len(result.Blocks))
+	}
+	totalBaseFee := new(big.Int)
+	for i, stats := range result.Blocks {
+		block := backend.chain.GetBlockByNumber(uint64(i + 2))
+		if uint64(stats.Number) != block.NumberU64() {
+			t.Errorf("block %d: number mismatch: have %d, want %d", i, stats.Number, block.NumberU64())
+		}
+		if uint64(stats.TxCount) != uint64(i+1) {
+			t.Errorf("block %d: transaction count mismatch: have %d, want %d", i, stats.TxCount, i+1)
+		}
+		if uint64(stats.GasUsed) != uint64(i+1)*params.TxGas {
+			t.Errorf("block %d: gas used mismatch: have %d, want %d", i, stats.GasUsed, uint64(i+1)*params.TxGas)
+		}
+		if stats.BaseFee.ToInt().Cmp(block.BaseFee()) != 0 {
+			t.Errorf("block %d: base fee mismatch: have %d, want %d", i, stats.BaseFee.ToInt(), block.BaseFee())
+		}
+		totalBaseFee.Add(totalBaseFee, block.BaseFee())
+	}
+	if result.TxCount != 6 {
+		t.Errorf("total transaction count mismatch: have %d, want 6", result.TxCount)
+	}
+	if uint64(result.GasUsed) != 6*params.TxGas {
+		t.Errorf("total gas used mismatch: have %d, want %d", result.GasUsed, 6*params.TxGas)
+	}
+	if want := totalBaseFee.Div(totalBaseFee, big.NewInt(3)); result.AverageBaseFee.ToInt().Cmp(want) != 0 {
+		t.Errorf("average base fee mismatch: have %d, want %d", result.AverageBaseFee.ToInt(), want)
+	}
+
+	// Ranges above the cap and inverted ranges are rejected
+	if _, err := api.GasStats(ctx, 0, 3); err == nil {
+		t.Fatal("expected error for range exceeding the cap")
+	}
+	if _, err := api.GasStats(ctx, 3, 2); err == nil {
+		t.Fatal("expected error for inverted range")
+	}
+}
+
 func newAccounts(n int) (accounts Accounts) {
 	for i := 0; i < n; i++ {
 		key, _ := crypto.GenerateKey()
@@ -38,6 +38,7 @@ import (
 	"github.com/luxdefi/evm/core"
 	"github.com/luxdefi/evm/core/bloombits"
 	"github.com/luxdefi/evm/core/state"
+	"github.com/luxdefi/evm/core/txpool"
 	"github.com/luxdefi/evm/core/types"
 	"github.com/luxdefi/evm/core/vm"
 	"github.com/luxdefi/evm/ethdb"
@@ -61,6 +62,8 @@ type Backend interface {
 	RPCGasCap() uint64                             // global gas cap for eth_call over rpc: DoS protection
 	RPCEVMTimeout() time.Duration                  // global timeout for eth_call over rpc: DoS protection
 	RPCTxFeeCap() float64                          // global tx fee cap for all transaction related APIs
+	RPCGasStatsBlockCap() uint64                   // maximum number of blocks per eth_gasStats request (0 means no limit)
+	RPCEstimateGasMaxIterations() int              // maximum number of eth_estimateGas search iterations (0 means no limit)
 	UnprotectedAllowed(tx *types.Transaction) bool // allows only for EIP155 transactions.
 
 	// Blockchain API
@@ -74,6 +77,7 @@ type Backend interface {
 	BlockByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Block, error)
 	StateAndHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*state.StateDB, *types.Header, error)
 	StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error)
+	HistoricalBalance(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*big.Int, error)
 	GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error)
 	GetEVM(ctx context.Context, msg *core.Message, state *state.StateDB, header *types.Header, vmConfig *vm.Config, blockCtx *vm.BlockContext) (*vm.EVM, func() error)
 	SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription
@@ -92,6 +96,7 @@ type Backend interface {
 	TxPoolContent() (map[common.Address]types.Transactions, map[common.Address]types.Transactions)
 	TxPoolContentFrom(addr common.Address) (types.Transactions, types.Transactions)
 	SubscribeNewTxsEvent(chan<- core.NewTxsEvent) event.Subscription
+	SubscribeTxPoolEvents(buffer int) *txpool.TxEventSubscription
 
 	ChainConfig() *params.ChainConfig
 	Engine() consensus.Engine
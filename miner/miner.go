@@ -28,6 +28,8 @@
 package miner
 
 import (
+	"time"
+
 	"github.com/luxdefi/node/utils/timer/mockable"
 	"github.com/luxdefi/evm/consensus"
 	"github.com/luxdefi/evm/core"
@@ -48,6 +50,32 @@ type Backend interface {
 // Config is the configuration parameters of mining.
 type Config struct {
 	Etherbase common.Address `toml:",omitempty"` // Public address for block mining rewards
+
+	// BuildTimeBudget bounds the time spent adding transactions to a block.
+	// Once it elapses the block is finalized even if gas remains. 0 disables the limit.
+	BuildTimeBudget time.Duration `toml:",omitempty"`
+
+	// SystemTxSenders lists the senders of system transactions, which are
+	// included in a block before any user transaction.
+	SystemTxSenders []common.Address `toml:",omitempty"`
+
+	// SystemTxGasReserve is the gas of each block reserved for system
+	// transactions. System transactions may use at most this much gas, and user
+	// transactions the remainder of the block gas limit. The reserve is only
+	// withheld from user transactions in blocks with system transactions to
+	// include. 0 places system transactions in the same budget as user
+	// transactions.
+	SystemTxGasReserve uint64 `toml:",omitempty"`
+}
+
+// FeeRecipientResolver selects the address receiving the fees of each block
+// built by the miner.
+type FeeRecipientResolver interface {
+	// FeeRecipient returns the coinbase of [header], which is being built on
+	// top of [parent]. [etherbase] is the configured coinbase of the miner.
+	// If fee recipients are not allowed, the returned address is replaced by
+	// the required coinbase.
+	FeeRecipient(parent *types.Header, header *types.Header, etherbase common.Address) (common.Address, error)
 }
 
 type Miner struct {
@@ -64,6 +92,12 @@ func (miner *Miner) SetEtherbase(addr common.Address) {
 	miner.worker.setEtherbase(addr)
 }
 
+// SetFeeRecipientResolver sets the policy selecting the coinbase of each
+// block. If [resolver] is nil, the etherbase is used.
+func (miner *Miner) SetFeeRecipientResolver(resolver FeeRecipientResolver) {
+	miner.worker.setFeeRecipientResolver(resolver)
+}
+
 func (miner *Miner) GenerateBlock(predicateContext *precompileconfig.PredicateContext) (*types.Block, error) {
 	return miner.worker.commitNewWork(predicateContext)
 }
@@ -30,6 +30,7 @@
 package miner
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math/big"
@@ -44,6 +45,7 @@ import (
 	"github.com/luxdefi/evm/core/state"
 	"github.com/luxdefi/evm/core/types"
 	"github.com/luxdefi/evm/core/vm"
+	"github.com/luxdefi/evm/metrics"
 	"github.com/luxdefi/evm/params"
 	"github.com/luxdefi/evm/precompile/precompileconfig"
 	"github.com/luxdefi/evm/predicate"
@@ -56,6 +58,10 @@ const (
 	targetTxsSize = 1800 * units.KiB
 )
 
+// buildDeadlineExceededCounter counts the blocks finalized before the pending
+// transactions or the block gas were exhausted because BuildTimeBudget elapsed.
+var buildDeadlineExceededCounter = metrics.NewRegisteredCounter("miner/build/deadline_exceeded", nil)
+
 // environment is the worker's current environment and holds all of the current state information.
 type environment struct {
 	signer types.Signer
@@ -95,10 +101,11 @@ type worker struct {
 	pendingLogsFeed event.Feed
 
 	// Subscriptions
-	mux      *event.TypeMux // TODO replace
-	mu       sync.RWMutex   // The lock used to protect the coinbase and extra fields
-	coinbase common.Address
-	clock    *mockable.Clock // Allows us mock the clock for testing
+	mux          *event.TypeMux // TODO replace
+	mu           sync.RWMutex   // The lock used to protect the coinbase and extra fields
+	coinbase     common.Address
+	feeRecipient FeeRecipientResolver // Selects the coinbase of each block, nil uses [coinbase]
+	clock        *mockable.Clock      // Allows us mock the clock for testing
 }
 
 func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus.Engine, eth Backend, mux *event.TypeMux, clock *mockable.Clock) *worker {
@@ -123,6 +130,13 @@ func (w *worker) setEtherbase(addr common.Address) {
 	w.coinbase = addr
 }
 
+// setFeeRecipientResolver sets the policy selecting the coinbase of each block.
+func (w *worker) setFeeRecipientResolver(resolver FeeRecipientResolver) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.feeRecipient = resolver
+}
+
 // commitNewWork generates several new sealing tasks based on the parent block.
 func (w *worker) commitNewWork(predicateContext *precompileconfig.PredicateContext) (*types.Block, error) {
 	w.mu.RLock()
@@ -169,22 +183,9 @@ func (w *worker) commitNewWork(predicateContext *precompileconfig.PredicateConte
 		}
 	}
 
-	if w.coinbase == (common.Address{}) {
-		return nil, errors.New("cannot mine without etherbase")
-	}
-	header.Coinbase = w.coinbase
-
-	configuredCoinbase, isAllowFeeRecipient, err := w.chain.GetCoinbaseAt(parent)
+	header.Coinbase, err = w.selectCoinbase(parent, header)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get configured coinbase: %w", err)
-	}
-
-	// if fee recipients are not allowed, then the coinbase is the configured coinbase
-	// don't set w.coinbase directly to the configured coinbase because that would override the
-	// coinbase set by the user
-	if !isAllowFeeRecipient && w.coinbase != configuredCoinbase {
-		log.Info("fee recipients are not allowed, using required coinbase for the mining", "currentminer", w.coinbase, "required", configuredCoinbase)
-		header.Coinbase = configuredCoinbase
+		return nil, err
 	}
 
 	if err := w.engine.Prepare(w.chain, header); err != nil {
@@ -202,28 +203,104 @@ func (w *worker) commitNewWork(predicateContext *precompileconfig.PredicateConte
 		return nil, err
 	}
 
+	ctx := context.Background()
+	if w.config.BuildTimeBudget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, w.config.BuildTimeBudget)
+		defer cancel()
+	}
+
 	// Get the pending txs from TxPool
 	pending := w.eth.TxPool().Pending(true)
+	w.fillTransactions(ctx, env, pending, w.eth.TxPool().Locals())
 
-	// Split the pending transactions into locals and remotes
+	return w.commit(env)
+}
+
+// fillTransactions applies the [pending] transactions to [env], starting with
+// the system transactions, followed by the transactions of [locals] and then
+// the remaining transactions. If there are system transactions, they draw
+// from the configured gas reserve and user transactions from the rest of the
+// block gas limit. Otherwise, user transactions may use the whole block gas
+// limit.
+func (w *worker) fillTransactions(ctx context.Context, env *environment, pending map[common.Address]types.Transactions, locals []common.Address) {
+	// Split the pending transactions into system, local and remote transactions
+	systemTxs := make(map[common.Address]types.Transactions)
 	localTxs := make(map[common.Address]types.Transactions)
 	remoteTxs := pending
-	for _, account := range w.eth.TxPool().Locals() {
+	for _, account := range w.config.SystemTxSenders {
+		if txs := remoteTxs[account]; len(txs) > 0 {
+			delete(remoteTxs, account)
+			systemTxs[account] = txs
+		}
+	}
+	for _, account := range locals {
 		if txs := remoteTxs[account]; len(txs) > 0 {
 			delete(remoteTxs, account)
 			localTxs[account] = txs
 		}
 	}
-	if len(localTxs) > 0 {
-		txs := types.NewTransactionsByPriceAndNonce(env.signer, localTxs, header.BaseFee)
-		w.commitTransactions(env, txs, header.Coinbase)
+
+	var deadlineExceeded bool
+	if len(systemTxs) > 0 {
+		// The reserve only applies to blocks including system transactions,
+		// so that it is not withheld from user transactions otherwise.
+		reserve := w.config.SystemTxGasReserve
+		userGas := env.gasPool.Gas()
+		if reserve > 0 {
+			systemGas := reserve
+			if systemGas > userGas {
+				systemGas = userGas
+			}
+			userGas -= systemGas
+			env.gasPool.SetGas(systemGas)
+		}
+		txs := types.NewTransactionsByPriceAndNonce(env.signer, systemTxs, env.header.BaseFee)
+		deadlineExceeded = w.commitTransactions(ctx, env, txs, env.header.Coinbase)
+		// User transactions are limited to the gas outside of the reserve,
+		// regardless of the gas used by system transactions.
+		if reserve > 0 {
+			env.gasPool.SetGas(userGas)
+		}
+	}
+	if len(localTxs) > 0 && !deadlineExceeded {
+		txs := types.NewTransactionsByPriceAndNonce(env.signer, localTxs, env.header.BaseFee)
+		deadlineExceeded = w.commitTransactions(ctx, env, txs, env.header.Coinbase)
 	}
-	if len(remoteTxs) > 0 {
-		txs := types.NewTransactionsByPriceAndNonce(env.signer, remoteTxs, header.BaseFee)
-		w.commitTransactions(env, txs, header.Coinbase)
+	if len(remoteTxs) > 0 && !deadlineExceeded {
+		txs := types.NewTransactionsByPriceAndNonce(env.signer, remoteTxs, env.header.BaseFee)
+		w.commitTransactions(ctx, env, txs, env.header.Coinbase)
 	}
+}
 
-	return w.commit(env)
+// selectCoinbase returns the coinbase of [header], built on top of [parent].
+// Assumes [w.mu] is held.
+func (w *worker) selectCoinbase(parent *types.Header, header *types.Header) (common.Address, error) {
+	coinbase := w.coinbase
+	if w.feeRecipient != nil {
+		var err error
+		coinbase, err = w.feeRecipient.FeeRecipient(parent, header, w.coinbase)
+		if err != nil {
+			return common.Address{}, fmt.Errorf("failed to resolve fee recipient: %w", err)
+		}
+	}
+	if coinbase == (common.Address{}) {
+		return common.Address{}, errors.New("cannot mine without etherbase")
+	}
+
+	configuredCoinbase, isAllowFeeRecipient, err := w.chain.GetCoinbaseAt(parent)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to get configured coinbase: %w", err)
+	}
+
+	// if fee recipients are not allowed, then the coinbase is the configured coinbase
+	// don't set w.coinbase directly to the configured coinbase because that would override the
+	// coinbase set by the user
+	if !isAllowFeeRecipient && coinbase != configuredCoinbase {
+		log.Info("fee recipients are not allowed, using required coinbase for the mining", "currentminer", coinbase, "required", configuredCoinbase)
+		return configuredCoinbase, nil
+	}
+	return coinbase, nil
 }
 
 func (w *worker) createCurrentEnvironment(predicateContext *precompileconfig.PredicateContext, parent *types.Header, header *types.Header, tstart time.Time) (*environment, error) {
@@ -279,17 +356,27 @@ func (w *worker) commitTransaction(env *environment, tx *types.Transaction, coin
 	return receipt.Logs, nil
 }
 
-func (w *worker) commitTransactions(env *environment, txs *types.TransactionsByPriceAndNonce, coinbase common.Address) {
+// commitTransactions applies [txs] to [env] until the block is full, the
+// transactions are exhausted or [ctx] is done. Returns true if the block was
+// cut short because [ctx] expired.
+func (w *worker) commitTransactions(ctx context.Context, env *environment, txs *types.TransactionsByPriceAndNonce, coinbase common.Address) bool {
 	for {
 		// If we don't have enough gas for any further transactions then we're done.
 		if env.gasPool.Gas() < params.TxGas {
 			log.Trace("Not enough gas for further transactions", "have", env.gasPool, "want", params.TxGas)
-			break
+			return false
 		}
 		// Retrieve the next transaction and abort if all done.
 		tx := txs.Peek()
 		if tx == nil {
-			break
+			return false
+		}
+		// Finalize the block with the transactions included so far if we are
+		// running out of time to produce it.
+		if err := ctx.Err(); err != nil {
+			log.Debug("Block building time budget exceeded, finalizing block", "txs", env.tcount, "gasRemaining", env.gasPool.Gas(), "elapsed", common.PrettyDuration(time.Since(env.start)))
+			buildDeadlineExceededCounter.Inc(1)
+			return true
 		}
 		// Abort transaction if it won't fit in the block and continue to search for a smaller
 		// transction that will fit.
@@ -0,0 +1,253 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package miner
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/luxdefi/node/utils/timer/mockable"
+	"github.com/luxdefi/evm/consensus/dummy"
+	"github.com/luxdefi/evm/core"
+	"github.com/luxdefi/evm/core/rawdb"
+	"github.com/luxdefi/evm/core/types"
+	"github.com/luxdefi/evm/core/vm"
+	"github.com/luxdefi/evm/params"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitTransactionsDeadline(t *testing.T) {
+	const (
+		numTxs = 20
+		txGas  = 1_000_000
+	)
+	var (
+		key, _ = crypto.GenerateKey()
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		// slowContract loops forever (JUMPDEST, PUSH1 0, JUMP), so each call
+		// simulates a slow execution by burning all the gas it is given.
+		slowContract = common.HexToAddress("0x0100000000000000000000000000000000000001")
+		gspec        = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: core.GenesisAlloc{
+				addr:         {Balance: new(big.Int).Mul(big.NewInt(100), big.NewInt(params.Ether))},
+				slowContract: {Code: common.FromHex("0x5b600056"), Balance: common.Big0},
+			},
+			BaseFee: big.NewInt(params.TestInitialBaseFee),
+		}
+		signer = types.LatestSigner(gspec.Config)
+		engine = dummy.NewETHFaker()
+	)
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), core.DefaultCacheConfig, gspec, engine, vm.Config{}, common.Hash{}, false)
+	require.NoError(t, err)
+	defer chain.Stop()
+
+	w := &worker{
+		config:      &Config{},
+		chainConfig: gspec.Config,
+		engine:      engine,
+		chain:       chain,
+		clock:       &mockable.Clock{},
+	}
+
+	newTxs := func() *types.TransactionsByPriceAndNonce {
+		txs := make(types.Transactions, numTxs)
+		for i := range txs {
+			tx := types.NewTransaction(uint64(i), slowContract, common.Big0, txGas, big.NewInt(params.TestInitialBaseFee), nil)
+			txs[i], err = types.SignTx(tx, signer, key)
+			require.NoError(t, err)
+		}
+		return types.NewTransactionsByPriceAndNonce(signer, map[common.Address]types.Transactions{addr: txs}, gspec.BaseFee)
+	}
+	newEnv := func() *environment {
+		parent := chain.CurrentBlock()
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     new(big.Int).Add(parent.Number, common.Big1),
+			GasLimit:   numTxs * txGas,
+			Time:       parent.Time,
+			BaseFee:    gspec.BaseFee,
+			Coinbase:   addr,
+		}
+		env, err := w.createCurrentEnvironment(nil, parent, header, time.Now())
+		require.NoError(t, err)
+		return env
+	}
+
+	// Without a deadline every transaction is included.
+	env := newEnv()
+	require.False(t, w.commitTransactions(context.Background(), env, newTxs(), addr))
+	require.Equal(t, numTxs, env.tcount)
+
+	// Once the deadline is reached the block is finalized while gas remains.
+	env = newEnv()
+	ctx := &deadlineAfterChecks{Context: context.Background(), checks: 3}
+	require.True(t, w.commitTransactions(ctx, env, newTxs(), addr))
+	require.Equal(t, 3, env.tcount)
+	require.GreaterOrEqual(t, env.gasPool.Gas(), uint64(txGas))
+}
+
+// deadlineAfterChecks is a context whose deadline is reached once Err has
+// been called [checks] times, so that block building stops after a known
+// number of transactions regardless of how long they take to execute.
+type deadlineAfterChecks struct {
+	context.Context
+	checks int
+}
+
+func (c *deadlineAfterChecks) Err() error {
+	if c.checks <= 0 {
+		return context.DeadlineExceeded
+	}
+	c.checks--
+	return nil
+}
+
+// alternatingRecipients sends the fees of even blocks to the etherbase and
+// the fees of odd blocks to [odd].
+type alternatingRecipients struct {
+	odd common.Address
+}
+
+func (a alternatingRecipients) FeeRecipient(_ *types.Header, header *types.Header, etherbase common.Address) (common.Address, error) {
+	if header.Number.Uint64()%2 == 1 {
+		return a.odd, nil
+	}
+	return etherbase, nil
+}
+
+func TestFeeRecipientResolver(t *testing.T) {
+	var (
+		etherbase = common.Address{0x01}
+		odd       = common.Address{0x02}
+		config    = *params.TestChainConfig
+	)
+	config.AllowFeeRecipients = true
+	gspec := &core.Genesis{
+		Config:  &config,
+		BaseFee: big.NewInt(params.TestInitialBaseFee),
+	}
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), core.DefaultCacheConfig, gspec, dummy.NewETHFaker(), vm.Config{}, common.Hash{}, false)
+	require.NoError(t, err)
+	defer chain.Stop()
+
+	w := &worker{
+		config:      &Config{Etherbase: etherbase},
+		chainConfig: gspec.Config,
+		chain:       chain,
+		coinbase:    etherbase,
+		clock:       &mockable.Clock{},
+	}
+	parent := chain.CurrentBlock()
+	header := func(number int64) *types.Header {
+		return &types.Header{ParentHash: parent.Hash(), Number: big.NewInt(number)}
+	}
+
+	// Without a resolver the etherbase is used.
+	coinbase, err := w.selectCoinbase(parent, header(1))
+	require.NoError(t, err)
+	require.Equal(t, etherbase, coinbase)
+
+	w.setFeeRecipientResolver(alternatingRecipients{odd: odd})
+	for number, expected := range map[int64]common.Address{1: odd, 2: etherbase, 3: odd, 4: etherbase} {
+		coinbase, err := w.selectCoinbase(parent, header(number))
+		require.NoError(t, err)
+		require.Equal(t, expected, coinbase, "block %d", number)
+	}
+}
+
+func TestSystemTxGasReserve(t *testing.T) {
+	const numUserTxs = 10
+	var (
+		userKey, _   = crypto.GenerateKey()
+		userAddr     = crypto.PubkeyToAddress(userKey.PublicKey)
+		systemKey, _ = crypto.GenerateKey()
+		systemAddr   = crypto.PubkeyToAddress(systemKey.PublicKey)
+		gspec        = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: core.GenesisAlloc{
+				userAddr:   {Balance: new(big.Int).Mul(big.NewInt(100), big.NewInt(params.Ether))},
+				systemAddr: {Balance: new(big.Int).Mul(big.NewInt(100), big.NewInt(params.Ether))},
+			},
+			BaseFee: big.NewInt(params.TestInitialBaseFee),
+		}
+		signer = types.LatestSigner(gspec.Config)
+		engine = dummy.NewETHFaker()
+	)
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), core.DefaultCacheConfig, gspec, engine, vm.Config{}, common.Hash{}, false)
+	require.NoError(t, err)
+	defer chain.Stop()
+
+	w := &worker{
+		config: &Config{
+			SystemTxSenders:    []common.Address{systemAddr},
+			SystemTxGasReserve: params.TxGas,
+		},
+		chainConfig: gspec.Config,
+		engine:      engine,
+		chain:       chain,
+		clock:       &mockable.Clock{},
+	}
+
+	signTxs := func(key *ecdsa.PrivateKey, n int, gasPrice *big.Int) types.Transactions {
+		txs := make(types.Transactions, n)
+		for i := range txs {
+			tx := types.NewTransaction(uint64(i), common.Address{0x01}, common.Big1, params.TxGas, gasPrice, nil)
+			txs[i], err = types.SignTx(tx, signer, key)
+			require.NoError(t, err)
+		}
+		return txs
+	}
+	// The user transactions pay a higher price than the system transactions
+	// and alone would fill the block.
+	pending := map[common.Address]types.Transactions{
+		userAddr:   signTxs(userKey, numUserTxs, new(big.Int).Mul(gspec.BaseFee, big.NewInt(10))),
+		systemAddr: signTxs(systemKey, 2, gspec.BaseFee),
+	}
+	parent := chain.CurrentBlock()
+	newHeader := func() *types.Header {
+		return &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     new(big.Int).Add(parent.Number, common.Big1),
+			GasLimit:   5 * params.TxGas,
+			Time:       parent.Time,
+			BaseFee:    gspec.BaseFee,
+			Coinbase:   common.Address{0xcb},
+		}
+	}
+	header := newHeader()
+	env, err := w.createCurrentEnvironment(nil, parent, header, time.Now())
+	require.NoError(t, err)
+	w.fillTransactions(context.Background(), env, pending, nil)
+
+	// The system transaction is included first, the second one does not fit
+	// in the reserve and the user transactions fill the rest of the block.
+	require.Len(t, env.txs, 5)
+	sender, err := types.Sender(signer, env.txs[0])
+	require.NoError(t, err)
+	require.Equal(t, systemAddr, sender)
+	for _, tx := range env.txs[1:] {
+		sender, err := types.Sender(signer, tx)
+		require.NoError(t, err)
+		require.Equal(t, userAddr, sender)
+	}
+	require.Equal(t, header.GasLimit, header.GasUsed)
+
+	// Without system transactions to include, the reserve is not withheld
+	// from the user transactions.
+	pending = map[common.Address]types.Transactions{
+		userAddr: signTxs(userKey, numUserTxs, new(big.Int).Mul(gspec.BaseFee, big.NewInt(10))),
+	}
+	header = newHeader()
+	env, err = w.createCurrentEnvironment(nil, parent, header, time.Now())
+	require.NoError(t, err)
+	w.fillTransactions(context.Background(), env, pending, nil)
+	require.Len(t, env.txs, 5)
+	require.Equal(t, header.GasLimit, header.GasUsed)
+}
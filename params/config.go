@@ -226,6 +226,10 @@ type ChainConfig struct {
 	FeeConfig          commontype.FeeConfig `json:"feeConfig"`                    // Set the configuration for the dynamic fee algorithm
 	AllowFeeRecipients bool                 `json:"allowFeeRecipients,omitempty"` // Allows fees to be collected by block builders.
 
+	SettlementConfig *SettlementConfig `json:"settlementConfig,omitempty"` // Fees of the parent chain transaction data is posted to (nil = no settlement layer)
+
+	PrecompileStorageWriteLimit uint64 `json:"precompileStorageWriteLimit,omitempty"` // Maximum storage writes a stateful precompile may perform per block after the PrecompileStorageWriteLimit upgrade (0 = no limit)
+
 	HomesteadBlock *big.Int `json:"homesteadBlock,omitempty"` // Homestead switch block (nil = no fork, 0 = already homestead)
 
 	// EIP150 implements the Gas price changes (https://github.com/ethereum/EIPs/issues/150)
@@ -420,6 +424,24 @@ func (c *ChainConfig) IsCancun(time uint64) bool {
 	return utils.IsTimestampForked(c.CancunTime, time)
 }
 
+// IsRewardDistribution returns whether [time] represents a block
+// with a timestamp after the RewardDistribution upgrade time.
+func (c *ChainConfig) IsRewardDistribution(time uint64) bool {
+	return utils.IsTimestampForked(c.getOptionalNetworkUpgrades().RewardDistributionTimestamp, time)
+}
+
+// BaseFeeBurnPercentage returns the percentage of the base fees of every
+// block burned after the RewardDistribution upgrade.
+func (c *ChainConfig) BaseFeeBurnPercentage() uint64 {
+	return c.getOptionalNetworkUpgrades().RewardDistributionBaseFeeBurnPercentage
+}
+
+// IsPrecompileStorageWriteLimit returns whether [time] represents a block
+// with a timestamp after the PrecompileStorageWriteLimit upgrade time.
+func (c *ChainConfig) IsPrecompileStorageWriteLimit(time uint64) bool {
+	return utils.IsTimestampForked(c.getOptionalNetworkUpgrades().PrecompileStorageWriteLimitTimestamp, time)
+}
+
 func (r *Rules) PredicatersExist() bool {
 	return len(r.Predicaters) > 0
 }
@@ -466,6 +488,16 @@ func (c *ChainConfig) Verify() error {
 		return err
 	}
 
+	if c.SettlementConfig != nil {
+		if err := c.SettlementConfig.Verify(); err != nil {
+			return fmt.Errorf("invalid settlement config: %w", err)
+		}
+	}
+
+	if percentage := c.getOptionalNetworkUpgrades().RewardDistributionBaseFeeBurnPercentage; percentage > 100 {
+		return fmt.Errorf("reward distribution base fee burn percentage must be a percentage (%d)", percentage)
+	}
+
 	// Verify the precompile upgrades are internally consistent given the existing chainConfig.
 	if err := c.verifyPrecompileUpgrades(); err != nil {
 		return fmt.Errorf("invalid precompile upgrades: %w", err)
@@ -615,6 +647,10 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, height *big.Int, time
 	if err := c.getOptionalNetworkUpgrades().CheckOptionalCompatible(newOptionalNetworkUpgrades, time); err != nil {
 		return err
 	}
+	// The limit is consensus critical once the upgrade enabling it is active.
+	if c.IsPrecompileStorageWriteLimit(time) && c.PrecompileStorageWriteLimit != newcfg.PrecompileStorageWriteLimit {
+		return newTimestampCompatError("PrecompileStorageWriteLimit", c.getOptionalNetworkUpgrades().PrecompileStorageWriteLimitTimestamp, newOptionalNetworkUpgrades.PrecompileStorageWriteLimitTimestamp)
+	}
 
 	// Check that the precompiles on the new config are compatible with the existing precompile config.
 	if err := c.CheckPrecompilesCompatible(newcfg.PrecompileUpgrades, time); err != nil {
@@ -781,6 +817,72 @@ func (r *Rules) IsPrecompileEnabled(addr common.Address) bool {
 	return ok
 }
 
+// ForkName returns the name of the latest fork enabled for this rule set.
+func (r *Rules) ForkName() string {
+	switch {
+	case r.IsCancun:
+		return "Cancun"
+	case r.IsDUpgrade:
+		return "DUpgrade"
+	case r.IsEVM:
+		return "EVM"
+	case r.IsIstanbul:
+		return "Istanbul"
+	case r.IsPetersburg:
+		return "Petersburg"
+	case r.IsConstantinople:
+		return "Constantinople"
+	case r.IsByzantium:
+		return "Byzantium"
+	case r.IsEIP158:
+		return "SpuriousDragon"
+	case r.IsEIP150:
+		return "TangerineWhistle"
+	case r.IsHomestead:
+		return "Homestead"
+	default:
+		return "Frontier"
+	}
+}
+
+// ActiveEIPs returns the EIPs enabled for this rule set, in the order of the
+// forks activating them.
+func (r *Rules) ActiveEIPs() []int {
+	eips := make([]int, 0)
+	if r.IsHomestead {
+		eips = append(eips, 2, 7, 8)
+	}
+	if r.IsEIP150 {
+		eips = append(eips, 150)
+	}
+	if r.IsEIP155 {
+		eips = append(eips, 155)
+	}
+	if r.IsEIP158 {
+		eips = append(eips, 160, 161, 170)
+	}
+	if r.IsByzantium {
+		eips = append(eips, 140, 196, 197, 198, 211, 214, 658)
+	}
+	if r.IsConstantinople {
+		eips = append(eips, 145, 1014, 1052)
+		// Petersburg removes the net gas metering of Constantinople
+		if !r.IsPetersburg {
+			eips = append(eips, 1283)
+		}
+	}
+	if r.IsIstanbul {
+		eips = append(eips, 152, 1108, 1344, 1884, 2028, 2200)
+	}
+	if r.IsEVM {
+		eips = append(eips, 1559, 2565, 2718, 2929, 2930, 3198, 3529, 3541)
+	}
+	if r.IsDUpgrade {
+		eips = append(eips, 3651, 3855, 3860)
+	}
+	return eips
+}
+
 // Rules ensures c's ChainID is not nil.
 func (c *ChainConfig) rules(num *big.Int, timestamp uint64) Rules {
 	chainID := c.ChainID
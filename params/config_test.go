@@ -146,6 +146,64 @@ func TestCheckCompatible(t *testing.T) {
 				RewindToTime: 0,
 			},
 		},
+		{
+			stored: &ChainConfig{
+				PrecompileStorageWriteLimit: 10,
+				OptionalNetworkUpgrades:     OptionalNetworkUpgrades{PrecompileStorageWriteLimitTimestamp: utils.NewUint64(100)},
+			},
+			new: &ChainConfig{
+				PrecompileStorageWriteLimit: 20,
+				OptionalNetworkUpgrades:     OptionalNetworkUpgrades{PrecompileStorageWriteLimitTimestamp: utils.NewUint64(100)},
+			},
+			headBlock:     10,
+			headTimestamp: 90,
+			wantErr:       nil,
+		},
+		{
+			stored: &ChainConfig{
+				PrecompileStorageWriteLimit: 10,
+				OptionalNetworkUpgrades:     OptionalNetworkUpgrades{PrecompileStorageWriteLimitTimestamp: utils.NewUint64(100)},
+			},
+			new: &ChainConfig{
+				PrecompileStorageWriteLimit: 20,
+				OptionalNetworkUpgrades:     OptionalNetworkUpgrades{PrecompileStorageWriteLimitTimestamp: utils.NewUint64(100)},
+			},
+			headBlock:     10,
+			headTimestamp: 100,
+			wantErr: &ConfigCompatError{
+				What:         "PrecompileStorageWriteLimit",
+				StoredTime:   utils.NewUint64(100),
+				NewTime:      utils.NewUint64(100),
+				RewindToTime: 99,
+			},
+		},
+		{
+			stored: &ChainConfig{
+				OptionalNetworkUpgrades: OptionalNetworkUpgrades{RewardDistributionTimestamp: utils.NewUint64(100), RewardDistributionBaseFeeBurnPercentage: 50},
+			},
+			new: &ChainConfig{
+				OptionalNetworkUpgrades: OptionalNetworkUpgrades{RewardDistributionTimestamp: utils.NewUint64(100), RewardDistributionBaseFeeBurnPercentage: 20},
+			},
+			headBlock:     10,
+			headTimestamp: 90,
+			wantErr:       nil,
+		},
+		{
+			stored: &ChainConfig{
+				OptionalNetworkUpgrades: OptionalNetworkUpgrades{RewardDistributionTimestamp: utils.NewUint64(100), RewardDistributionBaseFeeBurnPercentage: 50},
+			},
+			new: &ChainConfig{
+				OptionalNetworkUpgrades: OptionalNetworkUpgrades{RewardDistributionTimestamp: utils.NewUint64(100), RewardDistributionBaseFeeBurnPercentage: 20},
+			},
+			headBlock:     10,
+			headTimestamp: 100,
+			wantErr: &ConfigCompatError{
+				What:         "RewardDistributionBaseFeeBurnPercentage",
+				StoredTime:   utils.NewUint64(100),
+				NewTime:      utils.NewUint64(100),
+				RewindToTime: 99,
+			},
+		},
 	}
 
 	for _, test := range tests {
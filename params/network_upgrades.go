@@ -67,13 +67,37 @@ func (m *MandatoryNetworkUpgrades) mandatoryForkOrder() []fork {
 // OptionalNetworkUpgrades includes overridable and optional EVM network upgrades.
 // These can be specified in genesis and upgrade configs.
 // Timestamps can be different for each subnet network.
-// TODO: once we add the first optional upgrade here, we should uncomment TestVMUpgradeBytesOptionalNetworkUpgrades
-type OptionalNetworkUpgrades struct{}
+type OptionalNetworkUpgrades struct {
+	// RewardDistribution activates the reward distributor of the consensus
+	// engine, which controls the issuance and fee burning of chains with custom
+	// economics. (nil = no fork, 0 = already activated)
+	RewardDistributionTimestamp *uint64 `json:"rewardDistributionTimestamp,omitempty"`
+	// RewardDistributionBaseFeeBurnPercentage is the percentage of the base
+	// fees of every block burned from its coinbase after the
+	// RewardDistribution upgrade. (0 = fees are left with the coinbase)
+	RewardDistributionBaseFeeBurnPercentage uint64 `json:"rewardDistributionBaseFeeBurnPercentage,omitempty"`
+	// PrecompileStorageWriteLimit activates the PrecompileStorageWriteLimit
+	// of the chain config. (nil = no fork, 0 = already activated)
+	PrecompileStorageWriteLimitTimestamp *uint64 `json:"precompileStorageWriteLimitTimestamp,omitempty"`
+}
 
 func (n *OptionalNetworkUpgrades) CheckOptionalCompatible(newcfg *OptionalNetworkUpgrades, time uint64) *ConfigCompatError {
+	if isForkTimestampIncompatible(n.RewardDistributionTimestamp, newcfg.RewardDistributionTimestamp, time) {
+		return newTimestampCompatError("RewardDistribution fork block timestamp", n.RewardDistributionTimestamp, newcfg.RewardDistributionTimestamp)
+	}
+	// The burn percentage is consensus critical once the upgrade is active.
+	if utils.IsTimestampForked(n.RewardDistributionTimestamp, time) && n.RewardDistributionBaseFeeBurnPercentage != newcfg.RewardDistributionBaseFeeBurnPercentage {
+		return newTimestampCompatError("RewardDistributionBaseFeeBurnPercentage", n.RewardDistributionTimestamp, newcfg.RewardDistributionTimestamp)
+	}
+	if isForkTimestampIncompatible(n.PrecompileStorageWriteLimitTimestamp, newcfg.PrecompileStorageWriteLimitTimestamp, time) {
+		return newTimestampCompatError("PrecompileStorageWriteLimit fork block timestamp", n.PrecompileStorageWriteLimitTimestamp, newcfg.PrecompileStorageWriteLimitTimestamp)
+	}
 	return nil
 }
 
 func (n *OptionalNetworkUpgrades) optionalForkOrder() []fork {
-	return []fork{}
+	return []fork{
+		{name: "rewardDistributionTimestamp", timestamp: n.RewardDistributionTimestamp, optional: true},
+		{name: "precompileStorageWriteLimitTimestamp", timestamp: n.PrecompileStorageWriteLimitTimestamp, optional: true},
+	}
 }
@@ -0,0 +1,45 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package params
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var errNilSettlementBaseFee = errors.New("settlement base fee cannot be nil")
+
+// SettlementConfig describes the fees charged by the parent chain to which
+// this chain posts its transaction data.
+type SettlementConfig struct {
+	// BaseFee is the parent chain price (in wei) of a unit of data gas.
+	BaseFee *big.Int `json:"baseFee"`
+	// DataGasPerByte is the data gas charged per byte of compressed
+	// transaction data.
+	DataGasPerByte uint64 `json:"dataGasPerByte"`
+	// FixedOverhead is the data gas charged for every transaction, regardless
+	// of its size.
+	FixedOverhead uint64 `json:"fixedOverhead"`
+}
+
+// Verify returns an error if the settlement config is invalid.
+func (s *SettlementConfig) Verify() error {
+	if s.BaseFee == nil {
+		return errNilSettlementBaseFee
+	}
+	if s.BaseFee.Sign() < 0 {
+		return fmt.Errorf("settlement base fee cannot be negative (%d)", s.BaseFee)
+	}
+	return nil
+}
+
+// DataCost returns the parent chain cost (in wei) of posting a transaction
+// whose compressed size is [compressedSize] bytes.
+func (s *SettlementConfig) DataCost(compressedSize uint64) *big.Int {
+	dataGas := new(big.Int).SetUint64(compressedSize)
+	dataGas.Mul(dataGas, new(big.Int).SetUint64(s.DataGasPerByte))
+	dataGas.Add(dataGas, new(big.Int).SetUint64(s.FixedOverhead))
+	return dataGas.Mul(dataGas, s.BaseFee)
+}
@@ -26,6 +26,13 @@ type NetworkClient interface {
 	// the request should be retried.
 	SendAppRequestAny(ctx context.Context, minVersion *version.Application, request []byte) ([]byte, ids.NodeID, error)
 
+	// SendAppRequestAnyArchive synchronously sends request to a peer with a
+	// node version greater than or equal to minVersion that advertised it
+	// serves historical data.
+	// Returns response bytes, the ID of the chosen peer, and ErrRequestFailed if
+	// the request should be retried.
+	SendAppRequestAnyArchive(ctx context.Context, minVersion *version.Application, request []byte) ([]byte, ids.NodeID, error)
+
 	// SendAppRequest synchronously sends request to the selected nodeID
 	// Returns response bytes, and ErrRequestFailed if the request should be retried.
 	SendAppRequest(ctx context.Context, nodeID ids.NodeID, request []byte) ([]byte, error)
@@ -70,6 +77,20 @@ func (c *client) SendAppRequestAny(ctx context.Context, minVersion *version.Appl
 	return response, nodeID, err
 }
 
+// SendAppRequestAnyArchive synchronously sends request to a peer with a node
+// version greater than or equal to minVersion that serves historical data.
+// Returns response bytes, the ID of the chosen peer, and ErrRequestFailed if
+// the request should be retried.
+func (c *client) SendAppRequestAnyArchive(ctx context.Context, minVersion *version.Application, request []byte) ([]byte, ids.NodeID, error) {
+	waitingHandler := newWaitingResponseHandler()
+	nodeID, err := c.network.SendAppRequestAnyArchive(ctx, minVersion, request, waitingHandler)
+	if err != nil {
+		return nil, nodeID, err
+	}
+	response, err := waitingHandler.WaitForResult(ctx)
+	return response, nodeID, err
+}
+
 // SendAppRequest synchronously sends request to the specified nodeID
 // Returns response bytes and ErrRequestFailed if the request should be retried.
 func (c *client) SendAppRequest(ctx context.Context, nodeID ids.NodeID, request []byte) ([]byte, error) {
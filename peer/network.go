@@ -48,6 +48,12 @@ type Network interface {
 	// be sent to a peer with the desired [minVersion].
 	SendAppRequestAny(ctx context.Context, minVersion *version.Application, message []byte, handler message.ResponseHandler) (ids.NodeID, error)
 
+	// SendAppRequestAnyArchive synchronously sends request to a peer with a
+	// node version greater than or equal to minVersion that advertised it
+	// serves historical data.
+	// Returns the ID of the chosen peer, and an error if no such peer is connected.
+	SendAppRequestAnyArchive(ctx context.Context, minVersion *version.Application, message []byte, handler message.ResponseHandler) (ids.NodeID, error)
+
 	// SendAppRequest sends message to given nodeID, notifying handler when there's a response or timeout
 	SendAppRequest(ctx context.Context, nodeID ids.NodeID, message []byte, handler message.ResponseHandler) error
 
@@ -74,6 +80,11 @@ type Network interface {
 	// Size returns the size of the network in number of connected peers
 	Size() uint32
 
+	// EnableArchivePeerDiscovery requests the capabilities of each peer that
+	// connects from then on, so that requests for historical data can be
+	// routed to peers serving it.
+	EnableArchivePeerDiscovery()
+
 	// TrackBandwidth should be called for each valid request with the bandwidth
 	// (length of response divided by request time), and with 0 if the response is invalid.
 	TrackBandwidth(nodeID ids.NodeID, bandwidth float64)
@@ -102,6 +113,7 @@ type network struct {
 	peers                      *peerTracker                     // tracking of peers & bandwidth
 	appStats                   stats.RequestHandlerStats        // Provide request handler metrics
 	crossChainStats            stats.RequestHandlerStats        // Provide cross chain request handler metrics
+	archivePeerDiscovery       bool                             // request the capabilities of connecting peers
 
 	// Set to true when Shutdown is called, after which all operations on this
 	// struct are no-ops.
@@ -154,6 +166,27 @@ func (n *network) SendAppRequestAny(ctx context.Context, minVersion *version.App
 	return ids.EmptyNodeID, fmt.Errorf("no peers found matching version %s out of %d peers", minVersion, n.peers.Size())
 }
 
+// SendAppRequestAnyArchive synchronously sends request to a peer with a node
+// version greater than or equal to minVersion that advertised it serves
+// historical data. Peers that did not advertise it are never selected.
+// Returns the ID of the chosen peer, and an error if the request could not
+// be sent to an archive peer with the desired [minVersion].
+func (n *network) SendAppRequestAnyArchive(ctx context.Context, minVersion *version.Application, request []byte, handler message.ResponseHandler) (ids.NodeID, error) {
+	// Take a slot from total [activeAppRequests] and block until a slot becomes available.
+	if err := n.activeAppRequests.Acquire(ctx, 1); err != nil {
+		return ids.EmptyNodeID, errAcquiringSemaphore
+	}
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if nodeID, ok := n.peers.GetArchivePeer(minVersion); ok {
+		return nodeID, n.sendAppRequest(ctx, nodeID, request, handler)
+	}
+
+	n.activeAppRequests.Release(1)
+	return ids.EmptyNodeID, fmt.Errorf("no archive peers found matching version %s out of %d peers", minVersion, n.peers.Size())
+}
+
 // SendAppRequest sends request message bytes to specified nodeID, notifying the responseHandler on response or failure
 func (n *network) SendAppRequest(ctx context.Context, nodeID ids.NodeID, request []byte, responseHandler message.ResponseHandler) error {
 	if nodeID == ids.EmptyNodeID {
@@ -473,9 +506,27 @@ func (n *network) Connected(ctx context.Context, nodeID ids.NodeID, nodeVersion
 	}
 
 	n.peers.Connected(nodeID, nodeVersion)
+	if n.archivePeerDiscovery {
+		go n.requestCapabilities(nodeID)
+	}
 	return n.network.Connected(ctx, nodeID, nodeVersion)
 }
 
+// requestCapabilities asks [nodeID] for the data it is able to serve and
+// records whether it serves historical data.
+// Assumes the lock is not held.
+func (n *network) requestCapabilities(nodeID ids.NodeID) {
+	requestBytes, err := message.RequestToBytes(n.codec, message.CapabilitiesRequest{})
+	if err != nil {
+		log.Warn("failed to marshal CapabilitiesRequest", "err", err)
+		return
+	}
+	handler := &capabilitiesResponseHandler{network: n, nodeID: nodeID}
+	if err := n.SendAppRequest(context.Background(), nodeID, requestBytes, handler); err != nil {
+		log.Debug("failed to request peer capabilities", "nodeID", nodeID, "err", err)
+	}
+}
+
 // Disconnected removes given [nodeID] from the peer list
 func (n *network) Disconnected(ctx context.Context, nodeID ids.NodeID) error {
 	n.lock.Lock()
@@ -539,6 +590,13 @@ func (n *network) TrackBandwidth(nodeID ids.NodeID, bandwidth float64) {
 	n.peers.TrackBandwidth(nodeID, bandwidth)
 }
 
+func (n *network) EnableArchivePeerDiscovery() {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.archivePeerDiscovery = true
+}
+
 func (n *network) NewAppProtocol(protocol uint64, handler p2p.Handler, options ...p2p.ClientOption) (*p2p.Client, error) {
 	return n.network.NewAppProtocol(protocol, handler, options...)
 }
@@ -554,3 +612,28 @@ func (n *network) nextRequestID() uint32 {
 
 	return next
 }
+
+// capabilitiesResponseHandler records the archive status advertised by
+// [nodeID] in response to a CapabilitiesRequest
+type capabilitiesResponseHandler struct {
+	network *network
+	nodeID  ids.NodeID
+}
+
+func (c *capabilitiesResponseHandler) OnResponse(response []byte) error {
+	var capabilities message.CapabilitiesResponse
+	if _, err := c.network.codec.Unmarshal(response, &capabilities); err != nil {
+		log.Debug("could not parse CapabilitiesResponse", "nodeID", c.nodeID, "err", err)
+		return nil
+	}
+
+	c.network.lock.Lock()
+	defer c.network.lock.Unlock()
+
+	c.network.peers.SetArchive(c.nodeID, capabilities.Archive)
+	return nil
+}
+
+func (c *capabilitiesResponseHandler) OnFailure() error {
+	return nil
+}
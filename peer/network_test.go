@@ -132,6 +132,78 @@ func TestRequestAnyRequestsRoutingAndResponse(t *testing.T) {
 	assert.Equal(t, totalCalls, int(atomic.LoadUint32(&callNum)))
 }
 
+func TestRequestAnyArchiveRoutesToArchivePeers(t *testing.T) {
+	require := require.New(t)
+
+	var (
+		net               Network
+		archivePeer       = ids.GenerateTestNodeID()
+		prunedPeers       = []ids.NodeID{ids.GenerateTestNodeID(), ids.GenerateTestNodeID()}
+		capabilitiesCount uint32
+		requestedLock     sync.Mutex
+		requested         []ids.NodeID
+	)
+	sender := testAppSender{
+		sendAppRequestFn: func(_ context.Context, nodes set.Set[ids.NodeID], requestID uint32, requestBytes []byte) error {
+			nodeID, _ := nodes.Pop()
+			var request message.Request
+			if _, err := message.Codec.Unmarshal(requestBytes, &request); err != nil {
+				return err
+			}
+			responseBytes := []byte("receipts")
+			if _, ok := request.(message.CapabilitiesRequest); ok {
+				var err error
+				responseBytes, err = message.Codec.Marshal(message.Version, message.CapabilitiesResponse{Archive: nodeID == archivePeer})
+				if err != nil {
+					return err
+				}
+			} else {
+				requestedLock.Lock()
+				requested = append(requested, nodeID)
+				requestedLock.Unlock()
+			}
+			go func() {
+				if err := net.AppResponse(context.Background(), nodeID, requestID, responseBytes); err != nil {
+					panic(err)
+				}
+				if _, ok := request.(message.CapabilitiesRequest); ok {
+					atomic.AddUint32(&capabilitiesCount, 1)
+				}
+			}()
+			return nil
+		},
+	}
+
+	net = NewNetwork(p2p.NewNetwork(logging.NoLog{}, nil, prometheus.NewRegistry(), ""), sender, message.Codec, message.CrossChainCodec, ids.EmptyNodeID, 16, 16)
+	defer net.Shutdown()
+	net.EnableArchivePeerDiscovery()
+	client := NewNetworkClient(net)
+
+	for _, nodeID := range append(prunedPeers, archivePeer) {
+		require.NoError(net.Connected(context.Background(), nodeID, defaultPeerVersion))
+	}
+	require.Eventually(func() bool { return atomic.LoadUint32(&capabilitiesCount) == 3 }, 5*time.Second, 10*time.Millisecond)
+
+	requestBytes, err := message.RequestToBytes(message.Codec, message.ReceiptsRequest{Hash: ethcommon.Hash{1}, Height: 1, Parents: 1})
+	require.NoError(err)
+	for i := 0; i < 10; i++ {
+		_, nodeID, err := client.SendAppRequestAnyArchive(context.Background(), defaultPeerVersion, requestBytes)
+		require.NoError(err)
+		require.Equal(archivePeer, nodeID)
+	}
+	requestedLock.Lock()
+	require.Len(requested, 10)
+	for _, nodeID := range requested {
+		require.Equal(archivePeer, nodeID)
+	}
+	requestedLock.Unlock()
+
+	// historical requests fail rather than being sent to peers that cannot serve them
+	require.NoError(net.Disconnected(context.Background(), archivePeer))
+	_, _, err = client.SendAppRequestAnyArchive(context.Background(), defaultPeerVersion, requestBytes)
+	require.ErrorContains(err, "no archive peers found")
+}
+
 func TestAppRequestOnCtxCancellation(t *testing.T) {
 	codecManager := buildCodec(t, HelloRequest{}, HelloResponse{})
 	crossChainCodecManager := buildCodec(t, ExampleCrossChainRequest{}, ExampleCrossChainResponse{})
@@ -35,6 +35,7 @@ const (
 type peerInfo struct {
 	version   *version.Application
 	bandwidth utils_math.Averager
+	archive   bool // set if the peer advertised it serves historical data
 }
 
 // peerTracker tracks the bandwidth of responses coming from peers,
@@ -130,6 +131,50 @@ func (p *peerTracker) GetAnyPeer(minVersion *version.Application) (ids.NodeID, b
 	return p.trackedPeers.Peek()
 }
 
+// GetArchivePeer returns a peer with a node version greater than or equal to
+// [minVersion] that advertised it serves historical data, so that requests for
+// deep history are never sent to peers that cannot fulfill them.
+// Archive peers that were not sent a request yet are preferred so that their
+// bandwidth becomes known, then the archive peer with the best bandwidth.
+func (p *peerTracker) GetArchivePeer(minVersion *version.Application) (ids.NodeID, bool) {
+	var (
+		best          ids.NodeID
+		bestBandwidth float64
+		found         bool
+	)
+	for nodeID, peer := range p.peers {
+		if !peer.archive {
+			continue
+		}
+		if minVersion != nil && peer.version.Compare(minVersion) < 0 {
+			continue
+		}
+		if !p.trackedPeers.Contains(nodeID) {
+			log.Debug("peer tracking: connecting to new archive peer", "nodeID", nodeID)
+			return nodeID, true
+		}
+		var bandwidth float64
+		if peer.bandwidth != nil {
+			bandwidth = peer.bandwidth.Read()
+		}
+		if !found || bandwidth > bestBandwidth {
+			best, bestBandwidth, found = nodeID, bandwidth, true
+		}
+	}
+	return best, found
+}
+
+// SetArchive records whether [nodeID] advertised it serves historical data
+func (p *peerTracker) SetArchive(nodeID ids.NodeID, archive bool) {
+	peer := p.peers[nodeID]
+	if peer == nil {
+		// we're not connected to this peer, nothing to do here
+		log.Debug("setting archive status of untracked peer", "nodeID", nodeID)
+		return
+	}
+	peer.archive = archive
+}
+
 func (p *peerTracker) TrackPeer(nodeID ids.NodeID) {
 	p.trackedPeers.Add(nodeID)
 	p.numTrackedPeers.Update(int64(p.trackedPeers.Len()))
@@ -171,6 +216,7 @@ func (p *peerTracker) Connected(nodeID ids.NodeID, nodeVersion *version.Applicat
 			p.peers[nodeID] = &peerInfo{
 				version:   nodeVersion,
 				bandwidth: peer.bandwidth,
+				archive:   peer.archive,
 			}
 			log.Warn("updating node version of already connected peer", "nodeID", nodeID, "storedVersion", peer.version, "nodeVersion", nodeVersion)
 		} else {
@@ -0,0 +1,128 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package evm
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var errAcceptGapTimeout = errors.New("gap in accepted blocks was not filled in time")
+
+// acceptReorderBuffer holds the blocks accepted ahead of their parent, by
+// height, until the blocks below them are accepted. Block.Accept then accepts
+// them in height order, so that their precompile accept logs, the write of
+// [lastAcceptedKey] and the commit of the versiondb happen as if the blocks
+// were accepted in order.
+type acceptReorderBuffer struct {
+	window  uint64        // number of heights above the next one that may be buffered, 0 to disable
+	timeout time.Duration // maximum time the gap below the buffered blocks may stay open, 0 for no limit
+
+	lock   sync.Mutex
+	blocks map[uint64]*Block
+	gap    uint64      // next height to be accepted while [blocks] is not empty
+	timer  *time.Timer // fires if [gap] is not filled within [timeout]
+	err    error       // set by [timer] until [gap] is filled
+}
+
+func newAcceptReorderBuffer(window uint64, timeout time.Duration) *acceptReorderBuffer {
+	return &acceptReorderBuffer{
+		window:  window,
+		timeout: timeout,
+		blocks:  make(map[uint64]*Block),
+	}
+}
+
+// add buffers [b] and returns true if it is at most [window] heights above
+// [next], the next height to be accepted. It returns an error instead if the
+// gap below the buffered blocks was not filled within [timeout].
+func (r *acceptReorderBuffer) add(b *Block, next uint64) (bool, error) {
+	height := b.Height()
+	if r.window == 0 || height <= next || height-next > r.window {
+		return false, nil
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.err != nil {
+		return false, r.err
+	}
+	if len(r.blocks) == 0 {
+		r.openGap(next)
+	}
+	log.Debug("Buffering block accepted ahead of its parent", "height", height, "id", b.ID(), "next", next)
+	r.blocks[height] = b
+	return true, nil
+}
+
+// take removes and returns the buffered block at [next], the next height to
+// be accepted, or returns nil if there is none. In that case, the blocks left
+// in the buffer wait on a gap opened at [next], whose timeout starts now if
+// the gap below them moved.
+func (r *acceptReorderBuffer) take(next uint64) *Block {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if b, ok := r.blocks[next]; ok {
+		delete(r.blocks, next)
+		return b
+	}
+	switch {
+	case len(r.blocks) == 0:
+		r.closeGap()
+	case r.gap != next:
+		r.openGap(next)
+	}
+	return nil
+}
+
+// gapErr returns an error if the gap below the buffered blocks was not filled
+// within [timeout].
+func (r *acceptReorderBuffer) gapErr() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.err
+}
+
+// openGap starts the timeout of a gap at [height], replacing the timeout of
+// the previous gap if any.
+//
+// Assumes [r.lock] is held by the caller.
+func (r *acceptReorderBuffer) openGap(height uint64) {
+	r.closeGap()
+	r.gap = height
+	if r.timeout == 0 {
+		return
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(r.timeout, func() {
+		r.lock.Lock()
+		defer r.lock.Unlock()
+
+		// The gap was filled or replaced after the timer fired.
+		if r.timer != timer {
+			return
+		}
+		r.err = fmt.Errorf("%w: block %d missing for %s", errAcceptGapTimeout, height, r.timeout)
+		log.Error("Gap in accepted blocks was not filled in time", "height", height, "timeout", r.timeout, "buffered", len(r.blocks))
+	})
+	r.timer = timer
+}
+
+// closeGap stops the timeout of the current gap, if any.
+//
+// Assumes [r.lock] is held by the caller.
+func (r *acceptReorderBuffer) closeGap() {
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+	r.err = nil
+}
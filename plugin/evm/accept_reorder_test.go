@@ -0,0 +1,104 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package evm
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/luxdefi/node/ids"
+	"github.com/luxdefi/node/snow/consensus/snowman"
+	"github.com/luxdefi/evm/core/types"
+	"github.com/luxdefi/evm/params"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcceptReorderWindow(t *testing.T) {
+	require := require.New(t)
+
+	// [source] builds the blocks the VM under test accepts out of order
+	issuer, source, _, _ := GenesisVM(t, true, genesisJSONEVM, "", "")
+	defer func() {
+		require.NoError(source.Shutdown(context.Background()))
+	}()
+	var blocks []snowman.Block
+	for i := uint64(0); i < 6; i++ {
+		tx := types.NewTransaction(i, common.Address{0x01}, big.NewInt(1), params.TxGas, big.NewInt(testMinGasPrice), nil)
+		signedTx, err := types.SignTx(tx, types.NewEIP155Signer(source.chainConfig.ChainID), testKeys[0])
+		require.NoError(err)
+		for _, err := range source.txPool.AddRemotesSync([]*types.Transaction{signedTx}) {
+			require.NoError(err)
+		}
+		source.clock.Set(source.clock.Time().Add(2 * time.Second))
+		blocks = append(blocks, issueAndAccept(t, issuer, source))
+	}
+
+	const timeout = 200 * time.Millisecond
+	_, vm, _, _ := GenesisVM(t, true, genesisJSONEVM, `{"accept-reorder-window": 2, "accept-reorder-timeout": "200ms"}`, "")
+	defer func() {
+		require.NoError(vm.Shutdown(context.Background()))
+	}()
+	vm.clock.Set(source.clock.Time())
+	parsed := make([]snowman.Block, len(blocks))
+	for i, blk := range blocks {
+		var err error
+		parsed[i], err = vm.ParseBlock(context.Background(), blk.Bytes())
+		require.NoError(err)
+		require.NoError(parsed[i].Verify(context.Background()))
+	}
+	requireLastAccepted := func(blk snowman.Block) {
+		lastAccepted, err := vm.LastAccepted(context.Background())
+		require.NoError(err)
+		require.Equal(blk.ID(), lastAccepted)
+		lastAcceptedBytes, err := vm.acceptedBlockDB.Get(lastAcceptedKey)
+		require.NoError(err)
+		require.Equal(blk.ID(), ids.ID(lastAcceptedBytes))
+	}
+
+	// A block accepted ahead of its parent within the window is buffered, and
+	// accepted once its parent is.
+	require.NoError(parsed[1].Accept(context.Background()))
+	lastAccepted, err := vm.LastAccepted(context.Background())
+	require.NoError(err)
+	require.Equal(ids.ID(vm.genesisHash), lastAccepted)
+	hasLastAccepted, err := vm.acceptedBlockDB.Has(lastAcceptedKey)
+	require.NoError(err)
+	require.False(hasLastAccepted)
+	require.NoError(parsed[0].Accept(context.Background()))
+	requireLastAccepted(parsed[1])
+
+	// Blocks beyond the window are rejected.
+	require.ErrorContains(parsed[5].Accept(context.Background()), "expected accepted block to have parent")
+
+	// Accepting the block below a gap restarts its timeout, even though the
+	// buffered blocks are still waiting on another gap.
+	require.NoError(parsed[4].Accept(context.Background()))
+	time.Sleep(timeout * 3 / 5)
+	require.NoError(parsed[2].Accept(context.Background()))
+	requireLastAccepted(parsed[2])
+	time.Sleep(timeout * 3 / 5)
+	_, err = vm.HealthCheck(context.Background())
+	require.NoError(err)
+
+	// A gap that persists beyond the timeout fails the health check and the
+	// acceptance of further blocks ahead of it.
+	require.Eventually(func() bool {
+		_, err := vm.HealthCheck(context.Background())
+		return err != nil
+	}, 2*timeout, 10*time.Millisecond)
+	_, err = vm.HealthCheck(context.Background())
+	require.ErrorIs(err, errAcceptGapTimeout)
+	require.ErrorIs(parsed[5].Accept(context.Background()), errAcceptGapTimeout)
+
+	// Filling the gap still accepts the buffered blocks in order.
+	require.NoError(parsed[3].Accept(context.Background()))
+	requireLastAccepted(parsed[4])
+	_, err = vm.HealthCheck(context.Background())
+	require.NoError(err)
+	require.NoError(parsed[5].Accept(context.Background()))
+	requireLastAccepted(parsed[5])
+}
@@ -0,0 +1,27 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package evm
+
+import (
+	"context"
+
+	"github.com/luxdefi/evm/core/types"
+)
+
+// AcceptanceObserver is notified of each block just before the VM marks it
+// accepted, for example to audit it or anchor it in an external system.
+//
+// Consensus has already decided the block by the time it is observed, so an
+// error returned from BlockAccepting does not reject the block. Instead it is
+// returned from Accept, which halts the node.
+type AcceptanceObserver interface {
+	BlockAccepting(ctx context.Context, block *types.Block) error
+}
+
+// SetAcceptanceObserver sets the observer notified of each accepted block.
+// No observer is set by default. It must be set before the VM is handed to
+// the consensus engine, as it is not safe to change while blocks are accepted.
+func (vm *VM) SetAcceptanceObserver(observer AcceptanceObserver) {
+	vm.acceptanceObserver = observer
+}
@@ -0,0 +1,70 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package evm
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/luxdefi/node/vms/components/chain"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxdefi/evm/core/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type testAcceptanceObserver struct {
+	hashes []common.Hash
+	err    error
+}
+
+func (o *testAcceptanceObserver) BlockAccepting(_ context.Context, block *types.Block) error {
+	if o.err != nil {
+		return o.err
+	}
+	o.hashes = append(o.hashes, block.Hash())
+	return nil
+}
+
+func TestAcceptanceObserver(t *testing.T) {
+	require := require.New(t)
+	issuer, vm, _, _ := GenesisVM(t, true, genesisJSONEVM, "", "")
+	defer func() {
+		require.NoError(vm.Shutdown(context.Background()))
+	}()
+
+	observer := &testAcceptanceObserver{}
+	vm.SetAcceptanceObserver(observer)
+
+	addTx := func(nonce uint64) {
+		tx := types.NewTransaction(nonce, testEthAddrs[1], big.NewInt(1), 21000, big.NewInt(testMinGasPrice), nil)
+		signedTx, err := types.SignTx(tx, types.NewEIP155Signer(vm.chainConfig.ChainID), testKeys[0])
+		require.NoError(err)
+		for _, err := range vm.txPool.AddRemotesSync([]*types.Transaction{signedTx}) {
+			require.NoError(err)
+		}
+	}
+
+	var expected []common.Hash
+	for nonce := uint64(0); nonce < 3; nonce++ {
+		addTx(nonce)
+		blk := issueAndAccept(t, issuer, vm)
+		expected = append(expected, blk.(*chain.BlockWrapper).Block.(*Block).ethBlock.Hash())
+	}
+	require.Equal(expected, observer.hashes)
+
+	// An observer error halts acceptance instead of rejecting the block
+	errHalt := errors.New("halt")
+	observer.err = errHalt
+	addTx(3)
+	<-issuer
+	blk, err := vm.BuildBlock(context.Background())
+	require.NoError(err)
+	require.NoError(blk.Verify(context.Background()))
+	require.NoError(vm.SetPreference(context.Background(), blk.ID()))
+	require.ErrorIs(blk.Accept(context.Background()), errHalt)
+	require.Equal(expected[len(expected)-1], vm.blockChain.LastAcceptedBlock().Hash())
+}
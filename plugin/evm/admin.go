@@ -4,14 +4,23 @@
 package evm
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"os"
 
 	"github.com/luxdefi/node/api"
+	"github.com/luxdefi/node/utils/crypto/bls"
+	"github.com/luxdefi/node/utils/json"
 	"github.com/luxdefi/node/utils/profiler"
+	statesyncclient "github.com/luxdefi/evm/sync/client"
+	"github.com/luxdefi/evm/warp"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/log"
 )
 
+var errNoSyncClient = errors.New("state sync client is not initialized")
+
 // Admin is the API service for admin API calls
 type Admin struct {
 	vm       *VM
@@ -65,6 +74,21 @@ func (p *Admin) LockProfile(_ *http.Request, _ *struct{}, _ *api.EmptyReply) err
 	return p.profiler.LockProfile()
 }
 
+type ProfileBlockArgs struct {
+	Number json.Uint64 `json:"number"`
+}
+
+// ProfileBlock captures a cpu profile of the next execution of the block with
+// the given number, writing it to the block profiler directory
+func (p *Admin) ProfileBlock(_ *http.Request, args *ProfileBlockArgs, _ *api.EmptyReply) error {
+	log.Info("Admin: ProfileBlock called", "number", args.Number)
+
+	if lastAccepted := p.vm.blockChain.LastAcceptedBlock().NumberU64(); uint64(args.Number) <= lastAccepted {
+		return fmt.Errorf("block %d is already accepted, last accepted block is %d", args.Number, lastAccepted)
+	}
+	return p.vm.blockProfiler.request(uint64(args.Number))
+}
+
 type SetLogLevelArgs struct {
 	Level string `json:"level"`
 }
@@ -81,6 +105,25 @@ func (p *Admin) SetLogLevel(_ *http.Request, args *SetLogLevelArgs, reply *api.E
 	return nil
 }
 
+type SetSnapshotGenerationRateLimitArgs struct {
+	BytesPerSecond json.Uint64 `json:"bytesPerSecond"`
+}
+
+type SetSnapshotGenerationRateLimitReply struct {
+	PreviousBytesPerSecond json.Uint64 `json:"previousBytesPerSecond"`
+}
+
+// SetSnapshotGenerationRateLimit changes the bytes of snapshot data that may be
+// generated per second, taking effect immediately if the snapshot is being
+// generated. 0 removes the limit
+func (p *Admin) SetSnapshotGenerationRateLimit(_ *http.Request, args *SetSnapshotGenerationRateLimitArgs, reply *SetSnapshotGenerationRateLimitReply) error {
+	log.Info("EVM: SetSnapshotGenerationRateLimit called", "bytesPerSecond", args.BytesPerSecond)
+
+	reply.PreviousBytesPerSecond = json.Uint64(p.vm.blockChain.SnapshotGenerationRateLimit())
+	p.vm.blockChain.SetSnapshotGenerationRateLimit(uint64(args.BytesPerSecond))
+	return nil
+}
+
 type ConfigReply struct {
 	Config *Config `json:"config"`
 }
@@ -89,3 +132,73 @@ func (p *Admin) GetVMConfig(_ *http.Request, _ *struct{}, reply *ConfigReply) er
 	reply.Config = &p.vm.config
 	return nil
 }
+
+// SyncStatus returns the phase of syncing the chain the VM is in, along with
+// the last accepted height and the height being synced to
+func (p *Admin) SyncStatus(_ *http.Request, _ *struct{}, reply *SyncStatusReply) error {
+	p.vm.ctx.Lock.Lock()
+	defer p.vm.ctx.Lock.Unlock()
+
+	*reply = p.vm.syncStatus()
+	return nil
+}
+
+type SyncRequestsReply struct {
+	Requests []statesyncclient.ActiveRequest `json:"requests"`
+}
+
+// GetSyncRequests returns the state sync requests awaiting a response
+func (p *Admin) GetSyncRequests(_ *http.Request, _ *struct{}, reply *SyncRequestsReply) error {
+	if p.vm.syncClient == nil {
+		return errNoSyncClient
+	}
+	reply.Requests = p.vm.syncClient.ActiveRequests()
+	return nil
+}
+
+type CancelSyncRequestArgs struct {
+	ID json.Uint64 `json:"id"`
+}
+
+type CancelSyncRequestReply struct {
+	Cancelled bool `json:"cancelled"`
+}
+
+// CancelSyncRequest cancels an active state sync request, which is then
+// retried, possibly with another peer
+func (p *Admin) CancelSyncRequest(_ *http.Request, args *CancelSyncRequestArgs, reply *CancelSyncRequestReply) error {
+	log.Info("EVM: CancelSyncRequest called", "id", args.ID)
+
+	if p.vm.syncClient == nil {
+		return errNoSyncClient
+	}
+	reply.Cancelled = p.vm.syncClient.CancelRequest(uint64(args.ID))
+	return nil
+}
+
+type UpdateWarpSignerArgs struct {
+	KeyFile string `json:"keyFile"`
+}
+
+type UpdateWarpSignerReply struct {
+	PublicKey hexutil.Bytes `json:"publicKey"`
+}
+
+// UpdateWarpSigner signs warp messages with the BLS secret key read from
+// [KeyFile] from now on, after the key of the node was rotated. Signatures made
+// with the previous key are no longer served.
+func (p *Admin) UpdateWarpSigner(_ *http.Request, args *UpdateWarpSignerArgs, reply *UpdateWarpSignerReply) error {
+	log.Info("EVM: UpdateWarpSigner called", "keyFile", args.KeyFile)
+
+	keyBytes, err := os.ReadFile(args.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to read BLS key file: %w", err)
+	}
+	sk, err := bls.SecretKeyFromBytes(keyBytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse BLS key: %w", err)
+	}
+	p.vm.warpBackend.UpdateSigner(warp.NewPublicKeySigner(sk, p.vm.ctx.NetworkID, p.vm.ctx.ChainID))
+	reply.PublicKey = bls.PublicKeyToBytes(bls.PublicFromSecretKey(sk))
+	return nil
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/luxdefi/evm/core/rawdb"
 	"github.com/luxdefi/evm/core/types"
 	"github.com/luxdefi/evm/params"
+	"github.com/luxdefi/evm/precompile/contracts/blockhashes"
 	"github.com/luxdefi/evm/precompile/precompileconfig"
 	"github.com/luxdefi/evm/predicate"
 
@@ -52,15 +53,48 @@ func (vm *VM) newBlock(ethBlock *types.Block) *Block {
 func (b *Block) ID() ids.ID { return b.id }
 
 // Accept implements the snowman.Block interface
-func (b *Block) Accept(context.Context) error {
+//
+// If the accept reorder window is set, a block accepted ahead of its parent
+// is buffered, and accepted once the blocks below it are.
+func (b *Block) Accept(ctx context.Context) error {
+	vm := b.vm
+
+	next := vm.blockChain.LastConsensusAcceptedBlock().NumberU64() + 1
+	if buffered, err := vm.acceptReorder.add(b, next); buffered || err != nil {
+		return err
+	}
+	if err := b.accept(ctx); err != nil {
+		return err
+	}
+	// Accept the buffered blocks no longer waiting for their parent.
+	for height := b.Height() + 1; ; height++ {
+		child := vm.acceptReorder.take(height)
+		if child == nil {
+			return nil
+		}
+		if err := child.accept(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// accept accepts [b], whose parent must be the last accepted block.
+func (b *Block) accept(ctx context.Context) error {
 	vm := b.vm
 
 	// Although returning an error from Accept is considered fatal, it is good
 	// practice to cleanup the batch we were modifying in the case of an error.
 	defer vm.db.Abort()
 
+	if vm.acceptanceObserver != nil {
+		if err := vm.acceptanceObserver.BlockAccepting(ctx, b.ethBlock); err != nil {
+			return fmt.Errorf("acceptance observer failed on block %s: %w", b.ID(), err)
+		}
+	}
+
 	b.status = choices.Accepted
 	log.Debug(fmt.Sprintf("Accepting block %s (%s) at height %d", b.ID().Hex(), b.ID(), b.Height()))
+	vm.updateHighestSeenHeight(b.Height())
 
 	// Call Accept for relevant precompile logs. Note we do this prior to
 	// calling Accept on the blockChain so any side effects (eg warp signatures)
@@ -224,6 +258,15 @@ func (b *Block) verify(predicateContext *precompileconfig.PredicateContext, writ
 		return fmt.Errorf("syntactic block verification failed: %w", err)
 	}
 
+	// Refuse to execute blocks that may read block hashes the node does not
+	// have yet, rather than reading zero hashes from the precompile.
+	if !b.vm.blockHashHistoryComplete.Load() {
+		rules := b.vm.chainConfig.LuxRules(b.ethBlock.Number(), b.ethBlock.Timestamp())
+		if rules.IsPrecompileEnabled(blockhashes.ContractAddress) {
+			return fmt.Errorf("%w: backfill from peers in progress", errBlockHashHistoryIncomplete)
+		}
+	}
+
 	// Only enforce predicates if the chain has already bootstrapped.
 	// If the chain is still bootstrapping, we can assume that all blocks we are verifying have
 	// been accepted by the network (so the predicate was validated by the network when the
@@ -243,7 +286,13 @@ func (b *Block) verify(predicateContext *precompileconfig.PredicateContext, writ
 		return nil
 	}
 
-	return b.vm.blockChain.InsertBlockManual(b.ethBlock, writes)
+	if err := b.vm.blockProfiler.profile(b.Height(), func() error {
+		return b.vm.blockChain.InsertBlockManual(b.ethBlock, writes)
+	}); err != nil {
+		return err
+	}
+	b.vm.updateHighestSeenHeight(b.Height())
+	return nil
 }
 
 // verifyPredicates verifies the predicates in the block are valid according to predicateContext.
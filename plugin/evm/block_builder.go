@@ -10,6 +10,7 @@ import (
 	"github.com/luxdefi/node/utils/timer"
 	"github.com/luxdefi/evm/core"
 	"github.com/luxdefi/evm/core/txpool"
+	"github.com/luxdefi/evm/metrics"
 	"github.com/luxdefi/evm/params"
 
 	"github.com/ethereum/go-ethereum/log"
@@ -21,8 +22,14 @@ const (
 	// Minimum amount of time to wait after building a block before attempting to build a block
 	// a second time without changing the contents of the mempool.
 	minBlockBuildingRetryDelay = 500 * time.Millisecond
+
+	// Interval at which the connected peer count is checked while waiting for
+	// the minimum number of peers before starting block production.
+	peerCountPollInterval = 100 * time.Millisecond
 )
 
+var peerWaitTimer = metrics.NewRegisteredTimer("block_builder/peer_wait", nil)
+
 type blockBuilder struct {
 	ctx         *snow.Context
 	chainConfig *params.ChainConfig
@@ -30,6 +37,12 @@ type blockBuilder struct {
 	txPool   *txpool.TxPool
 	gossiper Gossiper
 
+	// Block production does not start until [minPeers] peers are connected
+	// (as reported by [peerCount]) or [minPeersTimeout] elapses.
+	minPeers        uint32
+	minPeersTimeout time.Duration
+	peerCount       func() uint32
+
 	shutdownChan <-chan struct{}
 	shutdownWg   *sync.WaitGroup
 
@@ -37,9 +50,13 @@ type blockBuilder struct {
 	// is ready to be build. This notifies the consensus engine.
 	notifyBuildBlockChan chan<- commonEng.Message
 
-	// [buildBlockLock] must be held when accessing [buildSent]
+	// [buildBlockLock] must be held when accessing [buildSent] and [awaitingPeers]
 	buildBlockLock sync.Mutex
 
+	// awaitingPeers is true while block production is held back until enough
+	// peers are connected.
+	awaitingPeers bool
+
 	// buildSent is true iff we have sent a PendingTxs message to the consensus message and
 	// are still waiting for buildBlock to be called.
 	buildSent bool
@@ -57,14 +74,62 @@ func (vm *VM) NewBlockBuilder(notifyBuildBlockChan chan<- commonEng.Message) *bl
 		chainConfig:          vm.chainConfig,
 		txPool:               vm.txPool,
 		gossiper:             vm.gossiper,
+		minPeers:             vm.config.BlockProductionMinPeers,
+		minPeersTimeout:      vm.config.BlockProductionMinPeersTimeout.Duration,
+		peerCount:            vm.Network.Size,
 		shutdownChan:         vm.shutdownChan,
 		shutdownWg:           &vm.shutdownWg,
 		notifyBuildBlockChan: notifyBuildBlockChan,
 	}
 	b.handleBlockBuilding()
+	b.awaitPeers()
 	return b
 }
 
+// awaitPeers holds back block production until [minPeers] peers are connected
+// or [minPeersTimeout] elapses, then notifies the engine if there are transactions
+// waiting to be built.
+func (b *blockBuilder) awaitPeers() {
+	if b.minPeers == 0 {
+		return
+	}
+	b.buildBlockLock.Lock()
+	b.awaitingPeers = true
+	b.buildBlockLock.Unlock()
+
+	b.shutdownWg.Add(1)
+	go b.ctx.Log.RecoverAndPanic(func() {
+		defer b.shutdownWg.Done()
+
+		startTime := time.Now()
+		ticker := time.NewTicker(peerCountPollInterval)
+		defer ticker.Stop()
+		timeout := time.NewTimer(b.minPeersTimeout)
+		defer timeout.Stop()
+
+	waitLoop:
+		for b.peerCount() < b.minPeers {
+			select {
+			case <-ticker.C:
+			case <-timeout.C:
+				log.Warn("Timed out waiting for peers, starting block production", "minPeers", b.minPeers, "peers", b.peerCount(), "timeout", b.minPeersTimeout)
+				break waitLoop
+			case <-b.shutdownChan:
+				return
+			}
+		}
+		peerWaitTimer.UpdateSince(startTime)
+		log.Info("Starting block production", "peers", b.peerCount(), "waited", time.Since(startTime))
+
+		b.buildBlockLock.Lock()
+		defer b.buildBlockLock.Unlock()
+		b.awaitingPeers = false
+		if b.needToBuild() {
+			b.markBuilding()
+		}
+	})
+}
+
 // handleBlockBuilding dispatches a timer used to delay block building retry attempts when the contents
 // of the mempool has not been changed since the last attempt.
 func (b *blockBuilder) handleBlockBuilding() {
@@ -111,6 +176,11 @@ func (b *blockBuilder) markBuilding() {
 	if b.buildSent {
 		return
 	}
+	// Blocks are not built until enough peers are connected, [awaitPeers] will
+	// notify the engine once they are.
+	if b.awaitingPeers {
+		return
+	}
 	b.buildBlockTimer.Cancel() // Cancel any future attempt from the timer to send a PendingTxs message
 
 	select {
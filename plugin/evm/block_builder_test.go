@@ -4,12 +4,18 @@
 package evm
 
 import (
+	"context"
+	"math/big"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/luxdefi/node/ids"
+	commonEng "github.com/luxdefi/node/snow/engine/common"
+	"github.com/luxdefi/evm/core/types"
 	"github.com/luxdefi/evm/params"
 	"github.com/luxdefi/evm/utils"
+	"github.com/stretchr/testify/require"
 <<<<<<< HEAD
 =======
 
@@ -76,3 +82,58 @@ func TestBlockBuilderSkipsTimerInitialization(t *testing.T) {
 	// should be created when all prices should be set from the start
 	attemptAwait(t, wg, time.Millisecond)
 }
+
+func TestBlockBuilderAwaitsMinPeers(t *testing.T) {
+	require := require.New(t)
+	issuer, vm, _, _ := GenesisVM(t, true, genesisJSONLatest, `{"block-production-min-peers": 2, "block-production-min-peers-timeout": "1m"}`, "")
+	defer func() {
+		require.NoError(vm.Shutdown(context.Background()))
+	}()
+
+	tx := types.NewTransaction(uint64(0), testEthAddrs[1], big.NewInt(1), 21000, big.NewInt(testMinGasPrice), nil)
+	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(vm.chainConfig.ChainID), testKeys[0])
+	require.NoError(err)
+	for _, err := range vm.txPool.AddRemotesSync([]*types.Transaction{signedTx}) {
+		require.NoError(err)
+	}
+
+	// With a single connected peer, the engine is not notified of the pending tx.
+	require.NoError(vm.Network.Connected(context.Background(), ids.GenerateTestNodeID(), nil))
+	select {
+	case <-issuer:
+		t.Fatal("block production started before the minimum number of peers connected")
+	case <-time.After(5 * peerCountPollInterval):
+	}
+
+	// Once the peer count crosses the threshold, block production starts.
+	require.NoError(vm.Network.Connected(context.Background(), ids.GenerateTestNodeID(), nil))
+	select {
+	case msg := <-issuer:
+		require.Equal(commonEng.PendingTxs, msg)
+	case <-time.After(5 * time.Second):
+		t.Fatal("block production did not start after the minimum number of peers connected")
+	}
+}
+
+func TestBlockBuilderMinPeersTimeout(t *testing.T) {
+	require := require.New(t)
+	issuer, vm, _, _ := GenesisVM(t, true, genesisJSONLatest, `{"block-production-min-peers": 2, "block-production-min-peers-timeout": "500ms"}`, "")
+	defer func() {
+		require.NoError(vm.Shutdown(context.Background()))
+	}()
+
+	tx := types.NewTransaction(uint64(0), testEthAddrs[1], big.NewInt(1), 21000, big.NewInt(testMinGasPrice), nil)
+	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(vm.chainConfig.ChainID), testKeys[0])
+	require.NoError(err)
+	for _, err := range vm.txPool.AddRemotesSync([]*types.Transaction{signedTx}) {
+		require.NoError(err)
+	}
+
+	// No peers connect, so block production starts once the timeout fires.
+	select {
+	case msg := <-issuer:
+		require.Equal(commonEng.PendingTxs, msg)
+	case <-time.After(5 * time.Second):
+		t.Fatal("block production did not start after the min peers timeout")
+	}
+}
@@ -0,0 +1,68 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package evm
+
+import (
+	"context"
+	"errors"
+
+	"github.com/luxdefi/evm/core/rawdb"
+	"github.com/luxdefi/evm/core/types"
+	"github.com/luxdefi/evm/ethdb"
+	"github.com/luxdefi/evm/precompile/contracts/blockhashes"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var errBlockHashHistoryIncomplete = errors.New("block hash history window is incomplete")
+
+// hasBlockHashHistory returns true if the canonical hashes of the
+// [blockhashes.HistoryWindow] blocks up to [lastAccepted] are on disk, so the
+// block hashes precompile can serve them to the blocks built on top of it.
+// Nodes that state synced before the window was extended only have the last
+// 256 of them.
+func hasBlockHashHistory(db ethdb.Reader, lastAccepted *types.Block) bool {
+	height := lastAccepted.NumberU64()
+	for i := uint64(0); i < blockhashes.HistoryWindow && i <= height; i++ {
+		if rawdb.ReadCanonicalHash(db, height-i) == (common.Hash{}) {
+			return false
+		}
+	}
+	return true
+}
+
+// startBlockHashBackfill fetches the blocks of the block hash history window
+// missing on disk from peers. Until it completes, blocks that activate the
+// block hashes precompile fail verification rather than reading zero hashes.
+func (vm *VM) startBlockHashBackfill() {
+	lastAccepted := vm.blockChain.LastAcceptedBlock()
+	if hasBlockHashHistory(vm.chaindb, lastAccepted) {
+		vm.blockHashHistoryComplete.Store(true)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	vm.shutdownWg.Add(1)
+	go func() {
+		defer vm.shutdownWg.Done()
+		defer cancel()
+
+		log.Info("backfilling block hash history", "from", lastAccepted.NumberU64(), "window", blockhashes.HistoryWindow)
+		if err := fetchBlocks(ctx, vm.syncClient, vm.chaindb, lastAccepted.Hash(), lastAccepted.NumberU64(), int(blockhashes.HistoryWindow)); err != nil {
+			if ctx.Err() == nil {
+				log.Error("block hash history backfill failed", "err", err)
+			}
+			return
+		}
+		vm.blockHashHistoryComplete.Store(true)
+		log.Info("backfilled block hash history")
+	}()
+	go func() {
+		select {
+		case <-vm.shutdownChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+}
@@ -0,0 +1,107 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package evm
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/luxdefi/evm/consensus/dummy"
+	"github.com/luxdefi/evm/core"
+	"github.com/luxdefi/evm/core/rawdb"
+	"github.com/luxdefi/evm/core/types"
+	"github.com/luxdefi/evm/params"
+	"github.com/luxdefi/evm/plugin/evm/message"
+	"github.com/luxdefi/evm/precompile/contracts/blockhashes"
+	statesyncclient "github.com/luxdefi/evm/sync/client"
+	"github.com/luxdefi/evm/sync/handlers"
+	handlerstats "github.com/luxdefi/evm/sync/handlers/stats"
+	"github.com/luxdefi/evm/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockHashBackfill(t *testing.T) {
+	require := require.New(t)
+
+	gspec := &core.Genesis{Config: params.TestChainConfig}
+	_, blocks, _, err := core.GenerateChainWithGenesis(gspec, dummy.NewETHFaker(), 300, 0, func(int, *core.BlockGen) {})
+	require.NoError(err)
+	genesis := gspec.ToBlock()
+	blocksByHash := map[common.Hash]*types.Block{genesis.Hash(): genesis}
+	for _, blk := range blocks {
+		blocksByHash[blk.Hash()] = blk
+	}
+	blockProvider := &handlers.TestBlockProvider{
+		GetBlockFn: func(hash common.Hash, height uint64) *types.Block {
+			blk, ok := blocksByHash[hash]
+			if !ok || blk.NumberU64() != height {
+				return nil
+			}
+			return blk
+		},
+	}
+	client := statesyncclient.NewMockClient(
+		message.Codec,
+		nil,
+		nil,
+		handlers.NewBlockRequestHandler(blockProvider, message.Codec, handlerstats.NewNoopHandlerStats()),
+		nil,
+		nil,
+	)
+
+	// the node state synced when only the last 256 parents were fetched
+	chaindb := rawdb.NewMemoryDatabase()
+	rawdb.WriteBlock(chaindb, genesis)
+	rawdb.WriteCanonicalHash(chaindb, genesis.Hash(), 0)
+	for _, blk := range blocks[len(blocks)-257:] {
+		rawdb.WriteBlock(chaindb, blk)
+		rawdb.WriteCanonicalHash(chaindb, blk.Hash(), blk.NumberU64())
+	}
+	lastAccepted := blocks[len(blocks)-1]
+	require.False(hasBlockHashHistory(chaindb, lastAccepted))
+
+	require.NoError(fetchBlocks(context.Background(), client, chaindb, lastAccepted.Hash(), lastAccepted.NumberU64(), int(blockhashes.HistoryWindow)))
+	require.True(hasBlockHashHistory(chaindb, lastAccepted))
+	for _, blk := range blocks {
+		require.Equal(blk.Hash(), rawdb.ReadCanonicalHash(chaindb, blk.NumberU64()))
+	}
+}
+
+func TestBlockHashHistoryIncompleteFailsVerification(t *testing.T) {
+	require := require.New(t)
+
+	genesis := &core.Genesis{}
+	require.NoError(genesis.UnmarshalJSON([]byte(genesisJSONEVM)))
+	genesis.Config.GenesisPrecompiles = params.Precompiles{
+		blockhashes.ConfigKey: blockhashes.NewConfig(utils.NewUint64(0)),
+	}
+	genesisJSON, err := genesis.MarshalJSON()
+	require.NoError(err)
+
+	issuer, vm, _, _ := GenesisVM(t, true, string(genesisJSON), "", "")
+	defer func() {
+		require.NoError(vm.Shutdown(context.Background()))
+	}()
+	require.True(vm.blockHashHistoryComplete.Load())
+
+	tx := types.NewTransaction(0, testEthAddrs[1], big.NewInt(1), params.TxGas, big.NewInt(testMinGasPrice), nil)
+	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(vm.chainConfig.ChainID), testKeys[0])
+	require.NoError(err)
+	for _, err := range vm.txPool.AddRemotesSync([]*types.Transaction{signedTx}) {
+		require.NoError(err)
+	}
+	<-issuer
+
+	// blocks are not executed while block hashes may be missing
+	vm.blockHashHistoryComplete.Store(false)
+	_, err = vm.BuildBlock(context.Background())
+	require.ErrorIs(err, errBlockHashHistoryIncomplete)
+
+	vm.blockHashHistoryComplete.Store(true)
+	blk, err := vm.BuildBlock(context.Background())
+	require.NoError(err)
+	require.NoError(blk.Verify(context.Background()))
+}
@@ -0,0 +1,120 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package evm
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var errBlockProfilerDisabled = errors.New("block profiler is not enabled")
+
+// blockProfiler captures CPU profiles of the execution of single blocks,
+// requested by number. Blocks that were not requested execute unprofiled.
+type blockProfiler struct {
+	dir string
+
+	// numRequested is the number of entries in [requested], so that blocks
+	// are executed without locking when no profile was requested.
+	numRequested atomic.Int32
+	lock         sync.Mutex
+	requested    map[uint64]struct{}
+}
+
+// newBlockProfiler returns a block profiler writing profiles to [dir].
+func newBlockProfiler(dir string) *blockProfiler {
+	return &blockProfiler{
+		dir:       dir,
+		requested: make(map[uint64]struct{}),
+	}
+}
+
+// profilePath returns the path of the profile of block [number].
+func (p *blockProfiler) profilePath(number uint64) string {
+	return filepath.Join(p.dir, fmt.Sprintf("block_%d.profile", number))
+}
+
+// request schedules a CPU profile of the next execution of block [number].
+func (p *blockProfiler) request(number uint64) error {
+	if p.dir == "" {
+		return errBlockProfilerDisabled
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if _, ok := p.requested[number]; !ok {
+		p.requested[number] = struct{}{}
+		p.numRequested.Add(1)
+	}
+	return nil
+}
+
+// take returns true and removes the request for block [number] if it was
+// requested.
+func (p *blockProfiler) take(number uint64) bool {
+	if p.numRequested.Load() == 0 {
+		return false
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if _, ok := p.requested[number]; !ok {
+		return false
+	}
+	delete(p.requested, number)
+	p.numRequested.Add(-1)
+	return true
+}
+
+// profile calls [execute], capturing a CPU profile of the call if block
+// [number] was requested. Failing to capture the profile is logged and does
+// not fail the execution.
+func (p *blockProfiler) profile(number uint64, execute func() error) error {
+	if !p.take(number) {
+		return execute()
+	}
+
+	path := p.profilePath(number)
+	stop, err := startCPUProfile(path)
+	if err != nil {
+		log.Warn("Failed to start block profile", "number", number, "path", path, "err", err)
+		return execute()
+	}
+	defer func() {
+		if err := stop(); err != nil {
+			log.Warn("Failed to write block profile", "number", number, "path", path, "err", err)
+			return
+		}
+		log.Info("Wrote block profile", "number", number, "path", path)
+	}()
+	return execute()
+}
+
+// startCPUProfile starts a CPU profile written to [path] and returns the
+// function stopping it.
+func startCPUProfile(path string) (func() error, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := pprof.StartCPUProfile(f); err != nil {
+		f.Close()
+		os.Remove(path)
+		return nil, err
+	}
+	return func() error {
+		pprof.StopCPUProfile()
+		return f.Close()
+	}, nil
+}
@@ -0,0 +1,68 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package evm
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/luxdefi/node/api"
+	"github.com/luxdefi/evm/core/types"
+	"github.com/luxdefi/evm/params"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminProfileBlock(t *testing.T) {
+	require := require.New(t)
+
+	dir := t.TempDir()
+	issuer, vm, _, _ := GenesisVM(t, true, genesisJSONEVM, fmt.Sprintf(`{"block-profiler-dir":%q}`, dir), "")
+	defer func() {
+		require.NoError(vm.Shutdown(context.Background()))
+	}()
+	admin := NewAdminService(vm, t.TempDir())
+
+	// Accepted blocks are not executed again
+	err := admin.ProfileBlock(nil, &ProfileBlockArgs{Number: 0}, &api.EmptyReply{})
+	require.ErrorContains(err, "already accepted")
+	require.NoError(admin.ProfileBlock(nil, &ProfileBlockArgs{Number: 1}, &api.EmptyReply{}))
+
+	for i := uint64(0); i < 2; i++ {
+		tx := types.NewTransaction(i, common.Address{0x01}, big.NewInt(1), params.TxGas, big.NewInt(testMinGasPrice), nil)
+		signedTx, err := types.SignTx(tx, types.NewEIP155Signer(vm.chainConfig.ChainID), testKeys[0])
+		require.NoError(err)
+		for _, err := range vm.txPool.AddRemotesSync([]*types.Transaction{signedTx}) {
+			require.NoError(err)
+		}
+		vm.clock.Set(vm.clock.Time().Add(2 * time.Second))
+		issueAndAccept(t, issuer, vm)
+	}
+
+	// Only the requested block is profiled
+	entries, err := os.ReadDir(dir)
+	require.NoError(err)
+	require.Len(entries, 1)
+	info, err := os.Stat(vm.blockProfiler.profilePath(1))
+	require.NoError(err)
+	require.NotZero(info.Size())
+}
+
+func TestBlockProfilerDisabled(t *testing.T) {
+	require := require.New(t)
+
+	profiler := newBlockProfiler("")
+	require.ErrorIs(profiler.request(1), errBlockProfilerDisabled)
+
+	executed := false
+	require.NoError(profiler.profile(1, func() error {
+		executed = true
+		return nil
+	}))
+	require.True(executed)
+}
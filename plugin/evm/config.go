@@ -17,16 +17,19 @@ import (
 
 const (
 	defaultAcceptorQueueLimit                         = 64 // Provides 2 minutes of buffer (2s block target) for a commit delay
+	defaultAcceptReorderTimeout                       = 10 * time.Second
 	defaultPruningEnabled                             = true
 	defaultCommitInterval                             = 4096
 	defaultTrieCleanCache                             = 512
 	defaultTrieDirtyCache                             = 512
 	defaultTrieDirtyCommitTarget                      = 20
+	defaultTriePinnedCache                            = 64
 	defaultSnapshotCache                              = 256
 	defaultSyncableCommitInterval                     = defaultCommitInterval * 4
 	defaultSnapshotWait                               = false
 	defaultRpcGasCap                                  = 50_000_000 // Default to 50M Gas Limit
 	defaultRpcTxFeeCap                                = 100        // 100 LUX
+	defaultRpcGasStatsBlockCap                        = 1024       // blocks
 	defaultMetricsExpensiveEnabled                    = true
 	defaultApiMaxDuration                             = 0 // Default to no maximum API call duration
 	defaultWsCpuRefillRate                            = 0 // Default to no maximum WS CPU usage
@@ -46,8 +49,11 @@ const (
 	defaultMaxOutboundActiveRequests                  = 16
 	defaultMaxOutboundActiveCrossChainRequests        = 64
 	defaultPopulateMissingTriesParallelism            = 1024
-	defaultStateSyncServerTrieCache                   = 64 // MB
-	defaultAcceptedCacheSize                          = 32 // blocks
+	defaultStateSyncServerTrieCache                   = 64  // MB
+	defaultAcceptedCacheSize                          = 32  // blocks
+	defaultHistoricalStateWindow                      = 128 // blocks
+	defaultSnapshotVerificationSampleRate             = 0.01
+	defaultGasPriceHistoryMaxAge                      = 10 * time.Minute
 
 	// defaultStateSyncMinBlocks is the minimum number of blocks the blockchain
 	// should be ahead of local last accepted to perform state sync.
@@ -58,6 +64,25 @@ const (
 	// - state sync time: ~6 hrs.
 	defaultStateSyncMinBlocks   = 300_000
 	defaultStateSyncRequestSize = 1024 // the number of key/values to ask peers for per request
+
+	defaultStateSyncReceiptBackfillRequestRate = 10 // requests per second
+
+	// maxWarpSignatureCoalesceWindow bounds the delay added to warp message
+	// signature requests by coalescing.
+	maxWarpSignatureCoalesceWindow = 100 * time.Millisecond
+)
+
+// Snapshot verification modes
+const (
+	snapshotVerificationNone    = "none"
+	snapshotVerificationSampled = "sampled"
+	snapshotVerificationFull    = "full"
+)
+
+// Tx pool intrinsic gas validation modes
+const (
+	txPoolIntrinsicGasHead   = "head"
+	txPoolIntrinsicGasStrict = "strict"
 )
 
 var (
@@ -101,9 +126,19 @@ type Config struct {
 	ContinuousProfilerFrequency Duration `json:"continuous-profiler-frequency"` // Frequency to run continuous profiler if enabled
 	ContinuousProfilerMaxFiles  int      `json:"continuous-profiler-max-files"` // Maximum number of files to maintain
 
+	// BlockProfilerDir is the directory CPU profiles of the execution of single
+	// blocks, requested with the admin API, are written to. Block profiles are
+	// disabled if empty.
+	BlockProfilerDir string `json:"block-profiler-dir"`
+
 	// Gas/Price Caps
-	RPCGasCap   uint64  `json:"rpc-gas-cap"`
-	RPCTxFeeCap float64 `json:"rpc-tx-fee-cap"`
+	RPCGasCap           uint64  `json:"rpc-gas-cap"`
+	RPCTxFeeCap         float64 `json:"rpc-tx-fee-cap"`
+	RPCGasStatsBlockCap uint64  `json:"rpc-gas-stats-block-cap"` // Maximum number of blocks per eth_gasStats request (0 means no limit)
+	// RPCEstimateGasMaxIterations is the maximum number of binary search
+	// iterations of eth_estimateGas. If the limit is hit, the lowest
+	// sufficient gas limit found so far is returned (0 means no limit).
+	RPCEstimateGasMaxIterations int `json:"rpc-estimate-gas-max-iterations"`
 
 	// Cache settings
 	TrieCleanCache        int      `json:"trie-clean-cache"`         // Size of the trie clean cache (MB)
@@ -111,6 +146,7 @@ type Config struct {
 	TrieCleanRejournal    Duration `json:"trie-clean-rejournal"`     // Frequency to re-journal the trie clean cache to disk (minimum 1 minute, must be populated to enable journaling the trie clean cache)
 	TrieDirtyCache        int      `json:"trie-dirty-cache"`         // Size of the trie dirty cache (MB)
 	TrieDirtyCommitTarget int      `json:"trie-dirty-commit-target"` // Memory limit to target in the dirty cache before performing a commit (MB)
+	TriePinnedCache       int      `json:"trie-pinned-cache"`        // Memory budget for trie nodes of contracts pinned with admin_pinContract (MB)
 	SnapshotCache         int      `json:"snapshot-cache"`           // Size of the snapshot disk layer clean cache (MB)
 
 	// Eth Settings
@@ -118,6 +154,60 @@ type Config struct {
 	SnapshotWait   bool `json:"snapshot-wait"`
 	SnapshotVerify bool `json:"snapshot-verification-enabled"`
 
+	// SnapshotVerificationMode is one of "none", "sampled" or "full". If it
+	// is empty, "full" is used if SnapshotVerify is set and "none" otherwise.
+	SnapshotVerificationMode       string  `json:"snapshot-verification-mode"`
+	SnapshotVerificationSampleRate float64 `json:"snapshot-verification-sample-rate"` // Fraction of accounts checked in "sampled" mode
+
+	// SnapshotCheckpointKeys and SnapshotCheckpointInterval make snapshot
+	// generation persist its progress after the given number of generated
+	// keys or the given time, so that a restart resumes where it left off.
+	// 0 disables the respective checkpoint.
+	SnapshotCheckpointKeys     int      `json:"snapshot-checkpoint-keys"`
+	SnapshotCheckpointInterval Duration `json:"snapshot-checkpoint-interval"`
+
+	// SnapshotRecoveryLimit caps the number of blocks replayed onto the
+	// snapshot on startup after an unclean shutdown. If more blocks would be
+	// replayed, the snapshot is rebuilt from the trie instead. 0 disables the cap.
+	SnapshotRecoveryLimit uint64 `json:"snapshot-recovery-limit"`
+
+	// SnapshotRepairGaps makes a snapshot left behind the block it is loaded
+	// for, for example by an unclean shutdown, catch up by diffing the state
+	// tries of both blocks when they are available, instead of rebuilding the
+	// whole snapshot.
+	SnapshotRepairGaps bool `json:"snapshot-repair-gaps-enabled"`
+
+	// SnapshotGenerationRateLimit is the number of bytes of snapshot data
+	// that may be generated per second, so that generation does not starve
+	// block processing of disk I/O. It can be changed at runtime with
+	// admin.setSnapshotGenerationRateLimit. 0 disables the limit.
+	SnapshotGenerationRateLimit uint64 `json:"snapshot-generation-rate-limit"`
+
+	// AcceptReorderWindow is the number of heights ahead of the next block to
+	// be accepted at which accepted blocks are buffered instead of rejected,
+	// until the blocks below them are accepted. A gap that is not filled
+	// within AcceptReorderTimeout fails the health check and the acceptance
+	// of further blocks ahead of it. 0 disables the buffering.
+	AcceptReorderWindow  uint64   `json:"accept-reorder-window"`
+	AcceptReorderTimeout Duration `json:"accept-reorder-timeout"`
+
+	// BlockExecutionBudget is the time a block may take to execute before it
+	// is logged and counted in the chain/block/slow/count metric. 0 disables
+	// the reporting.
+	BlockExecutionBudget Duration `json:"block-execution-budget"`
+
+	// VerificationPipelineWindow is the number of verified blocks whose state
+	// may be committed in the background while the blocks after them are
+	// executed, such as during catch-up or a batch import with
+	// admin.importChain. 0 verifies and commits the blocks one at a time.
+	VerificationPipelineWindow int `json:"verification-pipeline-window"`
+
+	// StatePrewarmLimit is the number of accounts and storage slots, among
+	// the most frequently accessed by the recently accepted blocks, preloaded
+	// into the state caches in the background while each block is verified,
+	// so that its execution reads them warm. 0 disables prewarming.
+	StatePrewarmLimit int `json:"state-prewarm-limit"`
+
 	// Pruning Settings
 	Pruning                         bool    `json:"pruning-enabled"`                    // If enabled, trie roots are only persisted every 4096 blocks
 	AcceptorQueueLimit              int     `json:"accepted-queue-limit"`               // Maximum blocks to queue before blocking during acceptance
@@ -129,6 +219,7 @@ type Config struct {
 
 	// Metric Settings
 	MetricsExpensiveEnabled bool `json:"metrics-expensive-enabled"` // Debug-level metrics that might impact runtime performance
+	OpcodeMetricsEnabled    bool `json:"metrics-opcodes-enabled"`   // Per-opcode execution counters for profiling, adds overhead to every executed opcode
 
 	// API Settings
 	LocalTxsEnabled bool `json:"local-txs-enabled"`
@@ -142,13 +233,68 @@ type Config struct {
 	TxPoolAccountQueue uint64   `json:"tx-pool-account-queue"`
 	TxPoolGlobalQueue  uint64   `json:"tx-pool-global-queue"`
 
+	// TxPoolQueueGracePeriod is how long after its last activity the gapped
+	// transactions of an account are evicted last when the global queue
+	// overflows, for example "30s". After that they are evicted as usual.
+	TxPoolQueueGracePeriod Duration `json:"tx-pool-queue-grace-period"`
+
+	TxPoolDustThreshold       uint64 `json:"tx-pool-dust-threshold"`        // Minimum value plus tip (in wei) of remote transactions, 0 disables the dust filter
+	TxPoolDustExemptContracts bool   `json:"tx-pool-dust-exempt-contracts"` // Whether contract creations and calls bypass the dust filter
+
+	// TxPoolAccountPendingLimit is the maximum number of transactions a remote
+	// account may have in the pool. Further transactions from the account are
+	// rejected until earlier ones are included, 0 disables the limit.
+	TxPoolAccountPendingLimit uint64 `json:"tx-pool-account-pending-limit"`
+
+	// TxPoolBackPressureThreshold is the percentage of the txpool capacity in
+	// use from which eth_sendRawTransaction rejects remote transactions with a
+	// "retry later" error, 0 disables back-pressure.
+	TxPoolBackPressureThreshold uint64 `json:"tx-pool-back-pressure-threshold"`
+
+	// TxPoolIntrinsicGasValidation is one of "head" or "strict". In "head"
+	// mode, the default, the intrinsic gas of transactions is checked under
+	// the forks active at the head. In "strict" mode, transactions with a gas
+	// limit below their intrinsic gas under the forks active for the next
+	// block are rejected before recovering their sender, and reinjected
+	// transactions are checked again.
+	TxPoolIntrinsicGasValidation string `json:"tx-pool-intrinsic-gas-validation"`
+
+	// WSMaxSubscriptions caps the subscriptions open at once over all websocket
+	// connections, and WSMaxSubscriptionsPerConnection those of a single
+	// connection. Subscriptions past a cap are rejected. 0 means no cap.
+	WSMaxSubscriptions              int `json:"ws-max-subscriptions"`
+	WSMaxSubscriptionsPerConnection int `json:"ws-max-subscriptions-per-connection"`
+
 	APIMaxDuration           Duration      `json:"api-max-duration"`
 	WSCPURefillRate          Duration      `json:"ws-cpu-refill-rate"`
 	WSCPUMaxStored           Duration      `json:"ws-cpu-max-stored"`
 	MaxBlocksPerRequest      int64         `json:"api-max-blocks-per-request"`
+	TraceConcurrencyLimit    int64         `json:"trace-concurrency-limit"` // Maximum number of concurrent block traces (0 means no limit)
+	TraceQueueTimeout        Duration      `json:"trace-queue-timeout"`     // Maximum time a block trace waits for a slot before failing as busy (0 means no timeout)
+	TraceMemoryLimit         int           `json:"trace-memory-limit"`      // Maximum bytes of stack, memory, storage and return data captured by a struct log trace (0 means no limit)
 	AllowUnfinalizedQueries  bool          `json:"allow-unfinalized-queries"`
 	AllowUnprotectedTxs      bool          `json:"allow-unprotected-txs"`
 	AllowUnprotectedTxHashes []common.Hash `json:"allow-unprotected-tx-hashes"`
+	HistoricalStateWindow    uint64        `json:"historical-state-window"` // Number of blocks behind the last accepted block for which pruned state may be regenerated by historical balance queries
+	GasPriceWarmupBlocks     int           `json:"gas-price-warmup-blocks"` // Number of recent blocks sampled on startup to seed gas price suggestions (0 disables the warm-up)
+
+	// GasPricePersistHistory enables storing the fee history sampled by the gas
+	// price oracle in the database, so that suggestions are warm on restart
+	// without reading the sampled blocks again.
+	GasPricePersistHistory bool `json:"gas-price-persist-history"`
+	// GasPriceHistoryMaxAge is the age past which the persisted fee history is
+	// discarded on startup.
+	GasPriceHistoryMaxAge Duration `json:"gas-price-history-max-age"`
+
+	// APISlowCallThreshold is the duration from which API calls are logged as
+	// slow, with the sizes of their parameters. 0 disables logging.
+	APISlowCallThreshold Duration `json:"api-slow-call-threshold"`
+	// APISlowCallMethodThresholds overrides APISlowCallThreshold for the
+	// methods it contains, by method name (e.g. "eth_call").
+	APISlowCallMethodThresholds map[string]Duration `json:"api-slow-call-method-thresholds"`
+
+	MaxAddressesPerFilter int `json:"api-max-addresses-per-filter"` // Maximum number of addresses a log filter may specify (0 means no limit)
+	MaxTopicsPerFilter    int `json:"api-max-topics-per-filter"`    // Maximum number of topic alternatives a log filter may specify (0 means no limit)
 
 	// Keystore Settings
 	KeystoreDirectory             string `json:"keystore-directory"` // both absolute and relative supported
@@ -165,6 +311,12 @@ type Config struct {
 	PriorityRegossipTxsPerAddress int              `json:"priority-regossip-txs-per-address"`
 	PriorityRegossipAddresses     []common.Address `json:"priority-regossip-addresses"`
 
+	// TxGossipMinTip is the minimum tip in wei at the current base fee of the
+	// remote transactions gossiped to peers. Transactions paying less are
+	// still added to the mempool, but not propagated. 0 gossips all
+	// transactions.
+	TxGossipMinTip uint64 `json:"tx-gossip-min-tip"`
+
 	// Log
 	LogLevel      string `json:"log-level"`
 	LogJSONFormat bool   `json:"log-json-format"`
@@ -172,6 +324,16 @@ type Config struct {
 	// Address for Tx Fees (must be empty if not supported by blockchain)
 	FeeRecipient string `json:"feeRecipient"`
 
+	// Block Building Settings
+	BuildBlockTimeBudget           Duration `json:"build-block-time-budget"`            // Maximum time spent adding transactions to a block (0 disables the limit)
+	BlockProductionMinPeers        uint32   `json:"block-production-min-peers"`         // Number of connected peers required before starting block production (0 disables the wait)
+	BlockProductionMinPeersTimeout Duration `json:"block-production-min-peers-timeout"` // Maximum time to wait for the minimum number of peers before starting block production anyway (required with block-production-min-peers)
+
+	// SystemTxSenders are the senders of system transactions, such as Warp fee adjustments, which are
+	// included in a block before user transactions and draw from SystemTxGasReserve.
+	SystemTxSenders    []common.Address `json:"system-tx-senders"`
+	SystemTxGasReserve uint64           `json:"system-tx-gas-reserve"` // Gas of each block reserved for system transactions and unavailable to user transactions while system transactions are pending
+
 	// Offline Pruning Settings
 	OfflinePruning                bool   `json:"offline-pruning-enabled"`
 	OfflinePruningBloomFilterSize uint64 `json:"offline-pruning-bloom-filter-size"`
@@ -190,6 +352,41 @@ type Config struct {
 	StateSyncMinBlocks       uint64 `json:"state-sync-min-blocks"`
 	StateSyncRequestSize     uint16 `json:"state-sync-request-size"`
 
+	// StateSyncReceiptBackfill enables fetching the receipts of blocks prior to the
+	// state sync summary from peers once state sync completes, so that receipts
+	// of pre-sync transactions can be served. The backfill resumes after restarts.
+	StateSyncReceiptBackfill            bool    `json:"state-sync-receipt-backfill-enabled"`
+	StateSyncReceiptBackfillRequestRate float64 `json:"state-sync-receipt-backfill-request-rate"` // Maximum number of backfill requests sent per second
+
+	// StateSyncArchivePeers requests the capabilities of connecting peers and
+	// sends backfill requests for historical data only to peers advertising
+	// that they are archival, instead of to any peer.
+	StateSyncArchivePeers bool `json:"state-sync-archive-peers-enabled"`
+
+	// StateSyncMaxResponseSize is the maximum size in bytes of the data asked
+	// for in a single response to the requests that peers may truncate, such as
+	// receipt backfill, leafs, block and code requests. Peers serve at most
+	// their own limit regardless. 0 uses the limit of the peer. Leafs, block and
+	// code requests that set it can only be decoded by peers that support
+	// version 1 of the network codec.
+	StateSyncMaxResponseSize uint32 `json:"state-sync-max-response-size"`
+
+	// StateSyncServerMaxLeavesPerResponse caps the number of trie leaves served
+	// in response to a single state sync request, bounding the work spent on
+	// each range proof. Requesters continue from the last leaf served. 0 uses
+	// the protocol maximum of 1024.
+	StateSyncServerMaxLeavesPerResponse uint16 `json:"state-sync-server-max-leaves-per-response"`
+
+	// StateSyncServerProofWorkers is the number of proofs generated
+	// concurrently when serving state sync and state proof requests, shared
+	// by all requests. 0 generates the proofs of each request sequentially.
+	StateSyncServerProofWorkers int `json:"state-sync-server-proof-workers"`
+
+	// StateSyncServerLazyAccountDecoding decodes only the account fields needed
+	// to serve a state sync request, such as the storage root for the empty
+	// storage flags of account leafs, instead of the full account.
+	StateSyncServerLazyAccountDecoding bool `json:"state-sync-server-lazy-account-decoding"`
+
 	// Database Settings
 	InspectDatabase bool `json:"inspect-database"` // Inspects the database on startup if enabled.
 
@@ -210,13 +407,90 @@ type Config struct {
 	// are reserved:
 	//  * 0:   means no limit
 	//  * N:   means N block limit [HEAD-N+1, HEAD] and delete extra indexes
+	// Raising the limit after indexes were deleted only reindexes the blocks
+	// that are missing from the new window.
 	TxLookupLimit uint64 `json:"tx-lookup-limit"`
 
+	// MaxReorgDepth is the maximum number of blocks a change of preference may
+	// remove from the canonical chain. If a deeper reorg is requested, the node
+	// refuses to switch heads and halts with a fatal error so that an operator
+	// can investigate. 0 means no limit.
+	MaxReorgDepth uint64 `json:"max-reorg-depth"`
+
+	// StateIntegrityCheckDepth is the number of blocks, starting at the head,
+	// whose state root is checked against the trie on startup. The snapshot
+	// root of the head block is checked as well. 0 disables the check.
+	StateIntegrityCheckDepth uint64 `json:"state-integrity-check-depth"`
+	// StateIntegrityCheckHalt prevents the node from starting if the state
+	// integrity check fails. Otherwise, failures are only logged.
+	StateIntegrityCheckHalt bool `json:"state-integrity-check-halt"`
+
+	// AcceptedIndexBatchBlocks is the number of accepted blocks whose
+	// transaction indices are grouped into a single database write. Values
+	// of 0 and 1 write the indices of every block separately.
+	AcceptedIndexBatchBlocks int `json:"accepted-index-batch-blocks"`
+	// AcceptedIndexFlushInterval is the maximum time batched indices are held
+	// before being written. 0 means no limit.
+	AcceptedIndexFlushInterval Duration `json:"accepted-index-flush-interval"`
+
+	// SpeculativeExecutionLimit is the maximum number of received blocks whose
+	// transactions are executed in the background against the state of their
+	// parent before being verified, to warm the state caches. 0 disables
+	// speculative execution.
+	SpeculativeExecutionLimit int `json:"speculative-execution-limit"`
+
 	// WarpOffChainMessages encodes off-chain messages (unrelated to any on-chain event ie. block or AddressedCall)
 	// that the node should be willing to sign.
 	// Note: only supports AddressedCall payloads as defined here:
 	// https://github.com/luxdefi/node/tree/7623ffd4be915a5185c9ed5e11fa9be15a6e1f00/vms/platformvm/warp/payload#addressedcall
 	WarpOffChainMessages []hexutil.Bytes `json:"warp-off-chain-messages"`
+
+	// WarpMessageDedupEnabled skips storing and signing a warp message again if
+	// a message with the same contents was already added. Messages with the
+	// same payload sent from different chains are never deduplicated. Disabled
+	// by default.
+	WarpMessageDedupEnabled bool `json:"warp-message-dedup-enabled"`
+
+	// WarpSignatureRequestMaxConcurrency is the maximum number of warp signature
+	// requests from a single peer that are served at the same time. Requests above
+	// the limit are dropped. 0, the default, means no limit.
+	WarpSignatureRequestMaxConcurrency int `json:"warp-signature-request-max-concurrency"`
+
+	// WarpSignatureCoalesceWindow is how long a warp message signature request
+	// waits for identical requests, so that they are served by a single signing.
+	// Must not exceed maxWarpSignatureCoalesceWindow. 0 disables the coalescing.
+	WarpSignatureCoalesceWindow Duration `json:"warp-signature-coalesce-window"`
+
+	// WarpSignatureWarmupBlocks is the number of most recently accepted blocks
+	// whose warp signatures are produced on startup, so that requests for them
+	// are served from the cache. It is capped by the size of the signature
+	// cache. 0 disables the warm-up.
+	WarpSignatureWarmupBlocks int `json:"warp-signature-warmup-blocks"`
+
+	// WarpAggregationMaxSignatures is the number of validator signatures the
+	// warp API collects when aggregating a signature, so that the quorum is
+	// exceeded by a margin. Collection never stops before the quorum is
+	// reached. 0 stops as soon as the quorum is reached.
+	WarpAggregationMaxSignatures int `json:"warp-aggregation-max-signatures"`
+
+	// WarpAggregationMaxConcurrency is the maximum number of signature requests
+	// the warp API sends to validators at once when aggregating a signature.
+	// 0 queries all validators at once.
+	WarpAggregationMaxConcurrency int `json:"warp-aggregation-max-concurrency"`
+
+	// WarpAggregationRequestTimeout bounds each signature request sent to a
+	// validator when aggregating a signature. 0 means no timeout.
+	WarpAggregationRequestTimeout Duration `json:"warp-aggregation-request-timeout"`
+
+	// WarpPruneCompactionThreshold is the number of entries removed from the
+	// warpDB by prune-warp-db-enabled above which the warpDB is compacted in
+	// the background to reclaim disk space. 0 disables the compaction.
+	WarpPruneCompactionThreshold int `json:"warp-prune-compaction-threshold"`
+
+	// WarpValidatorSetRefreshInterval is how long the validator set of a subnet
+	// fetched from the P-Chain to verify warp messages is cached before it is
+	// fetched again. 0 disables the cache.
+	WarpValidatorSetRefreshInterval Duration `json:"warp-validator-set-refresh-interval"`
 }
 
 // EthAPIs returns an array of strings representing the Eth APIs that should be enabled
@@ -225,14 +499,21 @@ func (c Config) EthAPIs() []string {
 }
 
 func (c Config) EthBackendSettings() eth.Settings {
-	return eth.Settings{MaxBlocksPerRequest: c.MaxBlocksPerRequest}
+	return eth.Settings{
+		MaxBlocksPerRequest:   c.MaxBlocksPerRequest,
+		MaxAddressesPerFilter: c.MaxAddressesPerFilter,
+		MaxTopicsPerFilter:    c.MaxTopicsPerFilter,
+	}
 }
 
 func (c *Config) SetDefaults() {
 	c.EnabledEthAPIs = defaultEnabledAPIs
 	c.RPCGasCap = defaultRpcGasCap
 	c.RPCTxFeeCap = defaultRpcTxFeeCap
+	c.RPCGasStatsBlockCap = defaultRpcGasStatsBlockCap
 	c.MetricsExpensiveEnabled = defaultMetricsExpensiveEnabled
+	c.HistoricalStateWindow = defaultHistoricalStateWindow
+	c.GasPriceHistoryMaxAge.Duration = defaultGasPriceHistoryMaxAge
 
 	c.TxPoolJournal = txpool.DefaultConfig.Journal
 	c.TxPoolRejournal = Duration{txpool.DefaultConfig.Rejournal}
@@ -242,6 +523,10 @@ func (c *Config) SetDefaults() {
 	c.TxPoolGlobalSlots = txpool.DefaultConfig.GlobalSlots
 	c.TxPoolAccountQueue = txpool.DefaultConfig.AccountQueue
 	c.TxPoolGlobalQueue = txpool.DefaultConfig.GlobalQueue
+	c.TxPoolQueueGracePeriod = Duration{txpool.DefaultConfig.QueueGracePeriod}
+	c.TxPoolDustThreshold = txpool.DefaultConfig.DustThreshold
+	c.TxPoolDustExemptContracts = txpool.DefaultConfig.DustExemptContracts
+	c.TxPoolAccountPendingLimit = txpool.DefaultConfig.AccountPendingLimit
 
 	c.APIMaxDuration.Duration = defaultApiMaxDuration
 	c.WSCPURefillRate.Duration = defaultWsCpuRefillRate
@@ -253,8 +538,10 @@ func (c *Config) SetDefaults() {
 	c.TrieCleanCache = defaultTrieCleanCache
 	c.TrieDirtyCache = defaultTrieDirtyCache
 	c.TrieDirtyCommitTarget = defaultTrieDirtyCommitTarget
+	c.TriePinnedCache = defaultTriePinnedCache
 	c.SnapshotCache = defaultSnapshotCache
 	c.AcceptorQueueLimit = defaultAcceptorQueueLimit
+	c.AcceptReorderTimeout.Duration = defaultAcceptReorderTimeout
 	c.CommitInterval = defaultCommitInterval
 	c.SnapshotWait = defaultSnapshotWait
 	c.RegossipFrequency.Duration = defaultRegossipFrequency
@@ -273,8 +560,10 @@ func (c *Config) SetDefaults() {
 	c.StateSyncCommitInterval = defaultSyncableCommitInterval
 	c.StateSyncMinBlocks = defaultStateSyncMinBlocks
 	c.StateSyncRequestSize = defaultStateSyncRequestSize
+	c.StateSyncReceiptBackfillRequestRate = defaultStateSyncReceiptBackfillRequestRate
 	c.AllowUnprotectedTxHashes = defaultAllowUnprotectedTxHashes
 	c.AcceptedCacheSize = defaultAcceptedCacheSize
+	c.SnapshotVerificationSampleRate = defaultSnapshotVerificationSampleRate
 }
 
 func (d *Duration) UnmarshalJSON(data []byte) (err error) {
@@ -296,6 +585,22 @@ func (d Duration) MarshalJSON() ([]byte, error) {
 	return json.Marshal(d.Duration.String())
 }
 
+// snapshotVerification returns whether the snapshot should be verified on
+// startup and the fraction of accounts to check. A rate of 0 verifies the
+// whole snapshot.
+func (c *Config) snapshotVerification() (bool, float64) {
+	switch c.SnapshotVerificationMode {
+	case snapshotVerificationNone:
+		return false, 0
+	case snapshotVerificationSampled:
+		return true, c.SnapshotVerificationSampleRate
+	case snapshotVerificationFull:
+		return true, 0
+	default:
+		return c.SnapshotVerify, 0
+	}
+}
+
 // Validate returns an error if this is an invalid config.
 func (c *Config) Validate() error {
 	if c.PopulateMissingTries != nil && (c.OfflinePruning || c.Pruning) {
@@ -313,5 +618,149 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("cannot use commit interval of 0 with pruning enabled")
 	}
 
+	if c.StateSyncReceiptBackfill && c.StateSyncReceiptBackfillRequestRate <= 0 {
+		return fmt.Errorf("cannot enable receipt backfill with a non-positive request rate (%f)", c.StateSyncReceiptBackfillRequestRate)
+	}
+
+	if c.AcceptedIndexBatchBlocks < 0 {
+		return fmt.Errorf("accepted index batch blocks cannot be negative (%d)", c.AcceptedIndexBatchBlocks)
+	}
+	if c.AcceptedIndexFlushInterval.Duration < 0 {
+		return fmt.Errorf("accepted index flush interval cannot be negative (%s)", c.AcceptedIndexFlushInterval)
+	}
+
+	if c.SnapshotCheckpointKeys < 0 {
+		return fmt.Errorf("snapshot checkpoint keys cannot be negative (%d)", c.SnapshotCheckpointKeys)
+	}
+	if c.SnapshotCheckpointInterval.Duration < 0 {
+		return fmt.Errorf("snapshot checkpoint interval cannot be negative (%s)", c.SnapshotCheckpointInterval)
+	}
+
+	if c.BuildBlockTimeBudget.Duration < 0 {
+		return fmt.Errorf("build block time budget cannot be negative (%s)", c.BuildBlockTimeBudget)
+	}
+
+	if c.BlockProductionMinPeersTimeout.Duration < 0 {
+		return fmt.Errorf("block production min peers timeout cannot be negative (%s)", c.BlockProductionMinPeersTimeout)
+	}
+	// Without a timeout, a node that never reaches the minimum number of peers
+	// would never produce blocks.
+	if c.BlockProductionMinPeers > 0 && c.BlockProductionMinPeersTimeout.Duration == 0 {
+		return fmt.Errorf("block production min peers (%d) requires a non-zero block production min peers timeout", c.BlockProductionMinPeers)
+	}
+
+	if c.GasPriceWarmupBlocks < 0 {
+		return fmt.Errorf("gas price warmup blocks cannot be negative (%d)", c.GasPriceWarmupBlocks)
+	}
+
+	if c.GasPriceHistoryMaxAge.Duration < 0 {
+		return fmt.Errorf("gas price history max age cannot be negative (%s)", c.GasPriceHistoryMaxAge)
+	}
+
+	if c.TxPoolBackPressureThreshold > 100 {
+		return fmt.Errorf("tx pool back-pressure threshold must be a percentage (%d)", c.TxPoolBackPressureThreshold)
+	}
+
+	if c.MaxAddressesPerFilter < 0 {
+		return fmt.Errorf("max addresses per filter cannot be negative (%d)", c.MaxAddressesPerFilter)
+	}
+	if c.MaxTopicsPerFilter < 0 {
+		return fmt.Errorf("max topics per filter cannot be negative (%d)", c.MaxTopicsPerFilter)
+	}
+	if c.TraceConcurrencyLimit < 0 {
+		return fmt.Errorf("trace concurrency limit cannot be negative (%d)", c.TraceConcurrencyLimit)
+	}
+	if c.TraceMemoryLimit < 0 {
+		return fmt.Errorf("trace memory limit cannot be negative (%d)", c.TraceMemoryLimit)
+	}
+	if c.WSMaxSubscriptions < 0 {
+		return fmt.Errorf("ws max subscriptions cannot be negative (%d)", c.WSMaxSubscriptions)
+	}
+	if c.WSMaxSubscriptionsPerConnection < 0 {
+		return fmt.Errorf("ws max subscriptions per connection cannot be negative (%d)", c.WSMaxSubscriptionsPerConnection)
+	}
+	if c.APISlowCallThreshold.Duration < 0 {
+		return fmt.Errorf("api slow call threshold cannot be negative (%s)", c.APISlowCallThreshold)
+	}
+	for method, threshold := range c.APISlowCallMethodThresholds {
+		if threshold.Duration < 0 {
+			return fmt.Errorf("api slow call threshold of %s cannot be negative (%s)", method, threshold)
+		}
+	}
+	if c.StateSyncServerProofWorkers < 0 {
+		return fmt.Errorf("state sync server proof workers cannot be negative (%d)", c.StateSyncServerProofWorkers)
+	}
+	if c.RPCEstimateGasMaxIterations < 0 {
+		return fmt.Errorf("rpc estimate gas max iterations cannot be negative (%d)", c.RPCEstimateGasMaxIterations)
+	}
+
+	if c.AcceptReorderTimeout.Duration < 0 {
+		return fmt.Errorf("accept reorder timeout cannot be negative (%s)", c.AcceptReorderTimeout)
+	}
+	if c.BlockExecutionBudget.Duration < 0 {
+		return fmt.Errorf("block execution budget cannot be negative (%s)", c.BlockExecutionBudget)
+	}
+	if c.VerificationPipelineWindow < 0 {
+		return fmt.Errorf("verification pipeline window cannot be negative (%d)", c.VerificationPipelineWindow)
+	}
+	if c.SpeculativeExecutionLimit < 0 {
+		return fmt.Errorf("speculative execution limit cannot be negative (%d)", c.SpeculativeExecutionLimit)
+	}
+	if c.StatePrewarmLimit < 0 {
+		return fmt.Errorf("state prewarm limit cannot be negative (%d)", c.StatePrewarmLimit)
+	}
+
+	if c.WarpSignatureRequestMaxConcurrency < 0 {
+		return fmt.Errorf("warp signature request max concurrency cannot be negative (%d)", c.WarpSignatureRequestMaxConcurrency)
+	}
+
+	if c.WarpSignatureCoalesceWindow.Duration < 0 || c.WarpSignatureCoalesceWindow.Duration > maxWarpSignatureCoalesceWindow {
+		return fmt.Errorf("warp signature coalesce window must be between 0 and %s (%s)", maxWarpSignatureCoalesceWindow, c.WarpSignatureCoalesceWindow)
+	}
+
+	if c.WarpSignatureWarmupBlocks < 0 {
+		return fmt.Errorf("warp signature warmup blocks cannot be negative (%d)", c.WarpSignatureWarmupBlocks)
+	}
+
+	if c.WarpAggregationMaxSignatures < 0 {
+		return fmt.Errorf("warp aggregation max signatures cannot be negative (%d)", c.WarpAggregationMaxSignatures)
+	}
+
+	if c.WarpAggregationMaxConcurrency < 0 {
+		return fmt.Errorf("warp aggregation max concurrency cannot be negative (%d)", c.WarpAggregationMaxConcurrency)
+	}
+
+	if c.WarpAggregationRequestTimeout.Duration < 0 {
+		return fmt.Errorf("warp aggregation request timeout cannot be negative (%s)", c.WarpAggregationRequestTimeout)
+	}
+
+	if c.WarpPruneCompactionThreshold < 0 {
+		return fmt.Errorf("warp prune compaction threshold cannot be negative (%d)", c.WarpPruneCompactionThreshold)
+	}
+
+	if c.WarpValidatorSetRefreshInterval.Duration < 0 {
+		return fmt.Errorf("warp validator set refresh interval cannot be negative (%s)", c.WarpValidatorSetRefreshInterval)
+	}
+
+	if c.TriePinnedCache < 0 {
+		return fmt.Errorf("trie pinned cache cannot be negative (%d)", c.TriePinnedCache)
+	}
+
+	switch c.SnapshotVerificationMode {
+	case "", snapshotVerificationNone, snapshotVerificationFull:
+	case snapshotVerificationSampled:
+		if c.SnapshotVerificationSampleRate <= 0 || c.SnapshotVerificationSampleRate > 1 {
+			return fmt.Errorf("snapshot verification sample rate must be in (0, 1] (%f)", c.SnapshotVerificationSampleRate)
+		}
+	default:
+		return fmt.Errorf("invalid snapshot verification mode %q", c.SnapshotVerificationMode)
+	}
+
+	switch c.TxPoolIntrinsicGasValidation {
+	case "", txPoolIntrinsicGasHead, txPoolIntrinsicGasStrict:
+	default:
+		return fmt.Errorf("invalid tx pool intrinsic gas validation %q", c.TxPoolIntrinsicGasValidation)
+	}
+
 	return nil
 }
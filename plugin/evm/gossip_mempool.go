@@ -6,6 +6,7 @@ package evm
 import (
 	"context"
 	"fmt"
+	"math/big"
 	"sync"
 
 	"github.com/luxdefi/node/ids"
@@ -23,7 +24,10 @@ var (
 	_ gossip.Set[*GossipTx] = (*GossipTxPool)(nil)
 )
 
-func NewGossipTxPool(mempool *txpool.TxPool) (*GossipTxPool, error) {
+// NewGossipTxPool returns the set of transactions of [mempool] pulled by peers.
+// Remote transactions paying less than [minTip] at the base fee of the current
+// block of [blockchain] are not served to peers.
+func NewGossipTxPool(mempool *txpool.TxPool, blockchain *core.BlockChain, minTip uint64) (*GossipTxPool, error) {
 	bloom, err := gossip.NewBloomFilter(txGossipBloomMaxItems, txGossipBloomFalsePositiveRate)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize bloom filter: %w", err)
@@ -31,6 +35,8 @@ func NewGossipTxPool(mempool *txpool.TxPool) (*GossipTxPool, error) {
 
 	return &GossipTxPool{
 		mempool:    mempool,
+		blockchain: blockchain,
+		minTip:     minTip,
 		pendingTxs: make(chan core.NewTxsEvent),
 		bloom:      bloom,
 	}, nil
@@ -38,6 +44,8 @@ func NewGossipTxPool(mempool *txpool.TxPool) (*GossipTxPool, error) {
 
 type GossipTxPool struct {
 	mempool    *txpool.TxPool
+	blockchain *core.BlockChain
+	minTip     uint64
 	pendingTxs chan core.NewTxsEvent
 
 	bloom *gossip.BloomFilter
@@ -84,7 +92,14 @@ func (g *GossipTxPool) Add(tx *GossipTx) error {
 }
 
 func (g *GossipTxPool) Iterate(f func(tx *GossipTx) bool) {
+	var baseFee *big.Int
+	if g.minTip != 0 {
+		baseFee = g.blockchain.CurrentBlock().BaseFee
+	}
 	g.mempool.IteratePending(func(tx *types.Transaction) bool {
+		if tipTooLowForGossip(tx, baseFee, g.minTip) && !g.mempool.HasLocal(tx.Hash()) {
+			return true
+		}
 		return f(&GossipTx{Tx: tx})
 	})
 }
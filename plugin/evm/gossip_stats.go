@@ -26,6 +26,9 @@ type GossipReceivedStats interface {
 type GossipSentStats interface {
 	IncEthTxsGossipSent()
 
+	// txs not gossiped for paying less than the minimum tip
+	IncEthTxsGossipTipTooLow()
+
 	// regossip
 	IncEthTxsRegossipQueued()
 	IncEthTxsRegossipQueuedLocal(count int)
@@ -38,6 +41,9 @@ type gossipStats struct {
 	ethTxsGossipSent     metrics.Counter
 	ethTxsGossipReceived metrics.Counter
 
+	// txs not gossiped for paying less than the minimum tip
+	ethTxsGossipTipTooLow metrics.Counter
+
 	// regossip
 	ethTxsRegossipQueued       metrics.Counter
 	ethTxsRegossipQueuedLocal  metrics.Counter
@@ -53,6 +59,8 @@ func NewGossipStats() GossipStats {
 		ethTxsGossipSent:     metrics.GetOrRegisterCounter("gossip_eth_txs_sent", nil),
 		ethTxsGossipReceived: metrics.GetOrRegisterCounter("gossip_eth_txs_received", nil),
 
+		ethTxsGossipTipTooLow: metrics.GetOrRegisterCounter("gossip_eth_txs_tip_too_low", nil),
+
 		ethTxsRegossipQueued:       metrics.GetOrRegisterCounter("regossip_eth_txs_queued_attempts", nil),
 		ethTxsRegossipQueuedLocal:  metrics.GetOrRegisterCounter("regossip_eth_txs_queued_local_tx_count", nil),
 		ethTxsRegossipQueuedRemote: metrics.GetOrRegisterCounter("regossip_eth_txs_queued_remote_tx_count", nil),
@@ -72,6 +80,9 @@ func (g *gossipStats) IncEthTxsGossipReceivedNew()   { g.ethTxsGossipReceivedNew
 // outgoing messages
 func (g *gossipStats) IncEthTxsGossipSent() { g.ethTxsGossipSent.Inc(1) }
 
+// txs not gossiped for paying less than the minimum tip
+func (g *gossipStats) IncEthTxsGossipTipTooLow() { g.ethTxsGossipTipTooLow.Inc(1) }
+
 // regossip
 func (g *gossipStats) IncEthTxsRegossipQueued() { g.ethTxsRegossipQueued.Inc(1) }
 func (g *gossipStats) IncEthTxsRegossipQueuedLocal(count int) {
@@ -334,6 +334,7 @@ func (n *pushGossiper) gossipTxs(force bool) (int, error) {
 		delete(n.txsToGossip, txHash)
 	}
 
+	baseFee := n.blockchain.CurrentBlock().BaseFee
 	selectedTxs := make([]*types.Transaction, 0)
 	for _, tx := range txs {
 		txHash := tx.Hash()
@@ -342,7 +343,12 @@ func (n *pushGossiper) gossipTxs(force bool) (int, error) {
 			continue
 		}
 
-		if n.config.RemoteGossipOnlyEnabled && n.txPool.HasLocal(txHash) {
+		isLocal := n.txPool.HasLocal(txHash)
+		if n.config.RemoteGossipOnlyEnabled && isLocal {
+			continue
+		}
+		if !isLocal && tipTooLowForGossip(tx, baseFee, n.config.TxGossipMinTip) {
+			n.stats.IncEthTxsGossipTipTooLow()
 			continue
 		}
 
@@ -382,6 +388,21 @@ func (n *pushGossiper) gossipTxs(force bool) (int, error) {
 	return len(selectedTxs), n.sendTxs(msgTxs)
 }
 
+// tipTooLowForGossip returns true if [tx] pays less than [minTip] on top of
+// [baseFee], and so should not be gossiped. A [minTip] of 0 gossips all
+// transactions.
+func tipTooLowForGossip(tx *types.Transaction, baseFee *big.Int, minTip uint64) bool {
+	if minTip == 0 {
+		return false
+	}
+	tip, err := tx.EffectiveGasTip(baseFee)
+	if err != nil {
+		// the fee cap is below the base fee
+		return true
+	}
+	return tip.Cmp(new(big.Int).SetUint64(minTip)) < 0
+}
+
 // GossipTxs enqueues the provided [txs] for gossiping. At some point, the
 // [pushGossiper] will attempt to gossip the provided txs to other nodes
 // (usually right away if not under load).
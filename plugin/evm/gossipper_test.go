@@ -424,3 +424,85 @@ func TestMempoolTxsPriorityRegossip(t *testing.T) {
 	assert.Len(queued, 10, "unexpected length of queued txs")
 	assert.ElementsMatch(txs, queued)
 }
+
+func TestMempoolTxsGossipMinTip(t *testing.T) {
+	assert := assert.New(t)
+
+	key, err := crypto.GenerateKey()
+	assert.NoError(err)
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	key2, err := crypto.GenerateKey()
+	assert.NoError(err)
+	addr2 := crypto.PubkeyToAddress(key2.PublicKey)
+
+	cfgJson, err := fundAddressByGenesis([]common.Address{addr, addr2})
+	assert.NoError(err)
+
+	minTip := uint64(5 * params.GWei)
+	cfg := fmt.Sprintf(`{"tx-gossip-min-tip":%d}`, minTip)
+	_, vm, _, sender := GenesisVM(t, true, cfgJson, cfg, "")
+	defer func() {
+		err := vm.Shutdown(context.Background())
+		assert.NoError(err)
+	}()
+	vm.txPool.SetGasPrice(common.Big1)
+	vm.txPool.SetMinFee(common.Big0)
+
+	baseFee := vm.blockChain.CurrentBlock().BaseFee
+	lowTip := getValidTxs(key, 1, new(big.Int).Add(baseFee, big.NewInt(params.GWei)))[0]
+	highTip := getValidTxs(key2, 1, new(big.Int).Add(baseFee, big.NewInt(10*params.GWei)))[0]
+
+	var (
+		lock     sync.Mutex
+		gossiped = make(map[common.Hash]struct{})
+		wg       sync.WaitGroup
+	)
+	sender.CantSendAppGossip = false
+	sender.SendAppGossipF = func(_ context.Context, gossipedBytes []byte) error {
+		notifyMsgIntf, err := message.ParseGossipMessage(vm.networkCodec, gossipedBytes)
+		if err != nil {
+			return nil // not a push gossip message
+		}
+		requestMsg, ok := notifyMsgIntf.(message.TxsGossip)
+		if !ok {
+			return nil
+		}
+		txs := make([]*types.Transaction, 0)
+		assert.NoError(rlp.DecodeBytes(requestMsg.Txs, &txs))
+
+		lock.Lock()
+		defer lock.Unlock()
+		for _, tx := range txs {
+			if _, ok := gossiped[tx.Hash()]; !ok && tx.Hash() == highTip.Hash() {
+				wg.Done()
+			}
+			gossiped[tx.Hash()] = struct{}{}
+		}
+		return nil
+	}
+
+	// Both transactions are accepted by the mempool, only the one paying the
+	// minimum tip is gossiped.
+	wg.Add(1)
+	for _, err := range vm.txPool.AddRemotesSync([]*types.Transaction{lowTip, highTip}) {
+		assert.NoError(err, "failed adding evm tx to remote mempool")
+	}
+	attemptAwait(t, &wg, 5*time.Second)
+
+	lock.Lock()
+	assert.Contains(gossiped, highTip.Hash())
+	assert.NotContains(gossiped, lowTip.Hash())
+	lock.Unlock()
+
+	// The transaction below the minimum tip is not served to peers pulling
+	// transactions either.
+	gossipTxPool, err := NewGossipTxPool(vm.txPool, vm.blockChain, minTip)
+	assert.NoError(err)
+	var served []common.Hash
+	gossipTxPool.Iterate(func(tx *GossipTx) bool {
+		served = append(served, tx.Tx.Hash())
+		return true
+	})
+	assert.Equal([]common.Hash{highTip.Hash()}, served)
+}
@@ -9,6 +9,10 @@ import "context"
 // Also returns details, which should be one of:
 // string, []byte, map[string]string
 func (vm *VM) HealthCheck(context.Context) (interface{}, error) {
-	// TODO perform actual health check
+	if vm.acceptReorder != nil {
+		if err := vm.acceptReorder.gapErr(); err != nil {
+			return nil, err
+		}
+	}
 	return nil, nil
 }
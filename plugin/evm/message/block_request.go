@@ -17,19 +17,33 @@ var (
 )
 
 // BlockRequest is a request to retrieve Parents number of blocks starting from Hash from newest-oldest manner
+// MaxResponseSize is the maximum total size in bytes of the blocks the requester
+// wants in the response, which the server honors up to its own limit (0 means
+// the server's limit). It is only serialized by codec version StatusVersion, and
+// requests that do not set it are encoded with Version so that peers that do not
+// support StatusVersion still decode them.
 type BlockRequest struct {
 	Hash    common.Hash `serialize:"true"`
 	Height  uint64      `serialize:"true"`
 	Parents uint16      `serialize:"true"`
+
+	MaxResponseSize uint32 `serializeV1:"true"`
 }
 
 func (b BlockRequest) String() string {
 	return fmt.Sprintf(
-		"BlockRequest(Hash=%s, Height=%d, Parents=%d)",
-		b.Hash, b.Height, b.Parents,
+		"BlockRequest(Hash=%s, Height=%d, Parents=%d, MaxResponseSize=%d)",
+		b.Hash, b.Height, b.Parents, b.MaxResponseSize,
 	)
 }
 
+func (b BlockRequest) codecVersion() uint16 {
+	if b.MaxResponseSize > 0 {
+		return StatusVersion
+	}
+	return Version
+}
+
 func (b BlockRequest) Handle(ctx context.Context, nodeID ids.NodeID, requestID uint32, handler RequestHandler) ([]byte, error) {
 	return handler.HandleBlockRequest(ctx, nodeID, requestID, b)
 }
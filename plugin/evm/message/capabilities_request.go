@@ -0,0 +1,30 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package message
+
+import (
+	"context"
+
+	"github.com/luxdefi/node/ids"
+)
+
+var _ Request = CapabilitiesRequest{}
+
+// CapabilitiesRequest is a request for the data a peer is able to serve
+type CapabilitiesRequest struct{}
+
+func (CapabilitiesRequest) String() string {
+	return "CapabilitiesRequest"
+}
+
+func (c CapabilitiesRequest) Handle(ctx context.Context, nodeID ids.NodeID, requestID uint32, handler RequestHandler) ([]byte, error) {
+	return handler.HandleCapabilitiesRequest(ctx, nodeID, requestID, c)
+}
+
+// CapabilitiesResponse is a response to a CapabilitiesRequest
+// Archive is set if the peer retains the state and receipts of all accepted
+// blocks, so that it can serve requests for historical ranges.
+type CapabilitiesResponse struct {
+	Archive bool `serialize:"true"`
+}
@@ -18,6 +18,13 @@ var _ Request = CodeRequest{}
 type CodeRequest struct {
 	// Hashes is a list of contract code hashes
 	Hashes []common.Hash `serialize:"true"`
+	// MaxResponseSize is the maximum total size in bytes of the code the
+	// requester wants in the response, which the server honors up to its own
+	// limit (0 means the server's limit) by serving only the code of the first
+	// hashes. It is only serialized by codec version StatusVersion, and requests
+	// that do not set it are encoded with Version so that peers that do not
+	// support StatusVersion still decode them.
+	MaxResponseSize uint32 `serializeV1:"true"`
 }
 
 func (c CodeRequest) String() string {
@@ -25,7 +32,14 @@ func (c CodeRequest) String() string {
 	for i, hash := range c.Hashes {
 		hashStrs[i] = hash.String()
 	}
-	return fmt.Sprintf("CodeRequest(Hashes=%s)", strings.Join(hashStrs, ", "))
+	return fmt.Sprintf("CodeRequest(Hashes=%s, MaxResponseSize=%d)", strings.Join(hashStrs, ", "), c.MaxResponseSize)
+}
+
+func (c CodeRequest) codecVersion() uint16 {
+	if c.MaxResponseSize > 0 {
+		return StatusVersion
+	}
+	return Version
 }
 
 func (c CodeRequest) Handle(ctx context.Context, nodeID ids.NodeID, requestID uint32, handler RequestHandler) ([]byte, error) {
@@ -41,6 +55,8 @@ func NewCodeRequest(hashes []common.Hash) CodeRequest {
 // CodeResponse is a response to a CodeRequest
 // crypto.Keccak256Hash of each element in Data is expected to equal
 // the corresponding element in CodeRequest.Hashes
+// Data holds the code of every hash, unless the request set MaxResponseSize, in
+// which case it may only hold the code of the first hashes
 // handler: handlers.CodeRequestHandler
 type CodeResponse struct {
 	Data [][]byte `serialize:"true"`
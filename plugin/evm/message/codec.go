@@ -6,13 +6,20 @@ package message
 import (
 	"github.com/luxdefi/node/codec"
 	"github.com/luxdefi/node/codec/linearcodec"
+	"github.com/luxdefi/node/codec/reflectcodec"
 	"github.com/luxdefi/node/utils/units"
 	"github.com/luxdefi/node/utils/wrappers"
 )
 
 const (
-	Version        = uint16(0)
+	Version = uint16(0)
+	// StatusVersion additionally serializes fields tagged with serializeV1,
+	// such as SignatureResponse.Status. It otherwise encodes types identically
+	// to Version.
+	StatusVersion  = uint16(1)
 	maxMessageSize = 1 * units.MiB
+
+	statusVersionTagName = "serializeV1"
 )
 
 var (
@@ -23,7 +30,41 @@ var (
 func init() {
 	Codec = codec.NewManager(maxMessageSize)
 	c := linearcodec.NewDefault()
+	statusCodec := linearcodec.New([]string{reflectcodec.DefaultTagName, statusVersionTagName}, linearcodec.DefaultMaxSliceLength)
+
+	errs := wrappers.Errs{}
+	errs.Add(
+		registerTypes(c),
+		registerTypes(statusCodec),
+
+		Codec.RegisterCodec(Version, c),
+		Codec.RegisterCodec(StatusVersion, statusCodec),
+	)
+
+	if errs.Errored() {
+		panic(errs.Err)
+	}
+
+	CrossChainCodec = codec.NewManager(maxMessageSize)
+	ccc := linearcodec.NewDefault()
+
+	errs = wrappers.Errs{}
+	errs.Add(
+		// CrossChainRequest Types
+		ccc.RegisterType(EthCallRequest{}),
+		ccc.RegisterType(EthCallResponse{}),
+
+		CrossChainCodec.RegisterCodec(Version, ccc),
+	)
 
+	if errs.Errored() {
+		panic(errs.Err)
+	}
+}
+
+// registerTypes registers the types of [Codec] in [c]. Every codec version must
+// register the same types in the same order so that type IDs match across versions.
+func registerTypes(c linearcodec.Codec) error {
 	errs := wrappers.Errs{}
 	errs.Add(
 		// Gossip types
@@ -45,26 +86,31 @@ func init() {
 		c.RegisterType(BlockSignatureRequest{}),
 		c.RegisterType(SignatureResponse{}),
 
-		Codec.RegisterCodec(Version, c),
-	)
+		// Receipts backfill types
+		// Note: new types must be registered after the existing ones so that
+		// previously assigned type IDs are preserved.
+		c.RegisterType(ReceiptsRequest{}),
+		c.RegisterType(ReceiptsResponse{}),
 
-	if errs.Errored() {
-		panic(errs.Err)
-	}
+		// Trie node by hash types
+		c.RegisterType(TrieNodeRequest{}),
+		c.RegisterType(TrieNodeResponse{}),
 
-	CrossChainCodec = codec.NewManager(maxMessageSize)
-	ccc := linearcodec.NewDefault()
+		// State proof types
+		c.RegisterType(StateProofRequest{}),
+		c.RegisterType(StateProofResponse{}),
 
-	errs = wrappers.Errs{}
-	errs.Add(
-		// CrossChainRequest Types
-		ccc.RegisterType(EthCallRequest{}),
-		ccc.RegisterType(EthCallResponse{}),
+		// Peer capabilities types
+		c.RegisterType(CapabilitiesRequest{}),
+		c.RegisterType(CapabilitiesResponse{}),
 
-		CrossChainCodec.RegisterCodec(Version, ccc),
-	)
+		// State multiproof types
+		c.RegisterType(StateMultiproofRequest{}),
+		c.RegisterType(StateMultiproofResponse{}),
 
-	if errs.Errored() {
-		panic(errs.Err)
-	}
+		// Snapshot diff types
+		c.RegisterType(SnapshotDiffRequest{}),
+		c.RegisterType(SnapshotDiffResponse{}),
+	)
+	return errs.Err
 }
@@ -40,6 +40,12 @@ type RequestHandler interface {
 	HandleCodeRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, codeRequest CodeRequest) ([]byte, error)
 	HandleMessageSignatureRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, signatureRequest MessageSignatureRequest) ([]byte, error)
 	HandleBlockSignatureRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, signatureRequest BlockSignatureRequest) ([]byte, error)
+	HandleReceiptsRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, receiptsRequest ReceiptsRequest) ([]byte, error)
+	HandleTrieNodeRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, trieNodeRequest TrieNodeRequest) ([]byte, error)
+	HandleStateProofRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, stateProofRequest StateProofRequest) ([]byte, error)
+	HandleStateMultiproofRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, multiproofRequest StateMultiproofRequest) ([]byte, error)
+	HandleCapabilitiesRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, capabilitiesRequest CapabilitiesRequest) ([]byte, error)
+	HandleSnapshotDiffRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, snapshotDiffRequest SnapshotDiffRequest) ([]byte, error)
 }
 
 // ResponseHandler handles response for a sent request
@@ -73,6 +79,30 @@ func (NoopRequestHandler) HandleBlockSignatureRequest(ctx context.Context, nodeI
 	return nil, nil
 }
 
+func (NoopRequestHandler) HandleReceiptsRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, receiptsRequest ReceiptsRequest) ([]byte, error) {
+	return nil, nil
+}
+
+func (NoopRequestHandler) HandleTrieNodeRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, trieNodeRequest TrieNodeRequest) ([]byte, error) {
+	return nil, nil
+}
+
+func (NoopRequestHandler) HandleStateProofRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, stateProofRequest StateProofRequest) ([]byte, error) {
+	return nil, nil
+}
+
+func (NoopRequestHandler) HandleStateMultiproofRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, multiproofRequest StateMultiproofRequest) ([]byte, error) {
+	return nil, nil
+}
+
+func (NoopRequestHandler) HandleCapabilitiesRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, capabilitiesRequest CapabilitiesRequest) ([]byte, error) {
+	return nil, nil
+}
+
+func (NoopRequestHandler) HandleSnapshotDiffRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, snapshotDiffRequest SnapshotDiffRequest) ([]byte, error) {
+	return nil, nil
+}
+
 // CrossChainRequestHandler interface handles incoming requests from another chain
 type CrossChainRequestHandler interface {
 	HandleEthCallRequest(ctx context.Context, requestingchainID ids.ID, requestID uint32, ethCallRequest EthCallRequest) ([]byte, error)
@@ -17,21 +17,38 @@ var _ Request = LeafsRequest{}
 
 // LeafsRequest is a request to receive trie leaves at specified Root within Start and End byte range
 // Limit outlines maximum number of leaves to returns starting at Start
+//
+// IncludeEmptyStorage requests LeafsResponse.EmptyStorage for the leaves of the
+// account trie. MaxResponseSize is the maximum total size in bytes of the keys
+// and values the requester wants in the response, which the server honors up to
+// its own limit (0 means the server's limit). Both are only serialized by codec
+// version StatusVersion, and requests that do not set them are encoded with
+// Version so that peers that do not support StatusVersion still decode them.
 type LeafsRequest struct {
 	Root    common.Hash `serialize:"true"`
 	Account common.Hash `serialize:"true"`
 	Start   []byte      `serialize:"true"`
 	End     []byte      `serialize:"true"`
 	Limit   uint16      `serialize:"true"`
+
+	IncludeEmptyStorage bool   `serializeV1:"true"`
+	MaxResponseSize     uint32 `serializeV1:"true"`
 }
 
 func (l LeafsRequest) String() string {
 	return fmt.Sprintf(
-		"LeafsRequest(Root=%s, Account=%s, Start=%s, End %s, Limit=%d)",
-		l.Root, l.Account, common.Bytes2Hex(l.Start), common.Bytes2Hex(l.End), l.Limit,
+		"LeafsRequest(Root=%s, Account=%s, Start=%s, End %s, Limit=%d, IncludeEmptyStorage=%t, MaxResponseSize=%d)",
+		l.Root, l.Account, common.Bytes2Hex(l.Start), common.Bytes2Hex(l.End), l.Limit, l.IncludeEmptyStorage, l.MaxResponseSize,
 	)
 }
 
+func (l LeafsRequest) codecVersion() uint16 {
+	if l.IncludeEmptyStorage || l.MaxResponseSize > 0 {
+		return StatusVersion
+	}
+	return Version
+}
+
 func (l LeafsRequest) Handle(ctx context.Context, nodeID ids.NodeID, requestID uint32, handler RequestHandler) ([]byte, error) {
 	return handler.HandleTrieLeafsRequest(ctx, nodeID, requestID, l)
 }
@@ -61,4 +78,11 @@ type LeafsResponse struct {
 	// ProofVals contain the edge merkle-proofs for the range of keys included in the response.
 	// The keys for the proof are simply the keccak256 hashes of the values, so they are not included in the response to save bandwidth.
 	ProofVals [][]byte `serialize:"true"`
+
+	// EmptyStorage is only set if requested by LeafsRequest.IncludeEmptyStorage
+	// for the account trie, in which case the response is encoded with
+	// StatusVersion. EmptyStorage[i] is true if the account in Vals[i] has no
+	// storage, either because it is an EOA or a contract with empty storage,
+	// so that the requester can skip the storage leafs requests of the account.
+	EmptyStorage []bool `serializeV1:"true"`
 }
@@ -102,3 +102,47 @@ func TestMarshalLeafsResponse(t *testing.T) {
 	assert.False(t, l.More) // make sure it is not serialized
 	assert.Equal(t, leafsResponse.ProofVals, l.ProofVals)
 }
+
+// TestMarshalLeafsEmptyStorage asserts that requests not asking for empty
+// storage flags are encoded with Version, so that they are unchanged for peers
+// that do not support StatusVersion, and that the flags are only serialized by
+// StatusVersion.
+func TestMarshalLeafsEmptyStorage(t *testing.T) {
+	leafsRequest := LeafsRequest{
+		Root:  common.BytesToHash([]byte("im ROOTing for ya")),
+		Limit: 1024,
+	}
+	requestBytes, err := RequestToBytes(Codec, leafsRequest)
+	assert.NoError(t, err)
+	var request Request
+	version, err := Codec.Unmarshal(requestBytes, &request)
+	assert.NoError(t, err)
+	assert.Equal(t, Version, version)
+	assert.Equal(t, leafsRequest, request)
+
+	leafsRequest.IncludeEmptyStorage = true
+	requestBytes, err = RequestToBytes(Codec, leafsRequest)
+	assert.NoError(t, err)
+	version, err = Codec.Unmarshal(requestBytes, &request)
+	assert.NoError(t, err)
+	assert.Equal(t, StatusVersion, version)
+	assert.Equal(t, leafsRequest, request)
+
+	leafsResponse := LeafsResponse{
+		Keys:         [][]byte{{0x01}, {0x02}},
+		Vals:         [][]byte{{0x03}, {0x04}},
+		EmptyStorage: []bool{true, false},
+	}
+	responseBytes, err := Codec.Marshal(Version, leafsResponse)
+	assert.NoError(t, err)
+	var l LeafsResponse
+	_, err = Codec.Unmarshal(responseBytes, &l)
+	assert.NoError(t, err)
+	assert.Empty(t, l.EmptyStorage)
+
+	responseBytes, err = Codec.Marshal(StatusVersion, leafsResponse)
+	assert.NoError(t, err)
+	_, err = Codec.Unmarshal(responseBytes, &l)
+	assert.NoError(t, err)
+	assert.Equal(t, leafsResponse.EmptyStorage, l.EmptyStorage)
+}
@@ -0,0 +1,49 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package message
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/luxdefi/node/ids"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var _ Request = ReceiptsRequest{}
+
+// ReceiptsRequest is a request to retrieve the receipts of Parents number of blocks
+// starting from Hash in a newest-oldest manner
+// MaxResponseSize is the maximum total size in bytes of the receipts the requester
+// wants in the response, which the server honors up to its own limit (0 means the
+// server's limit)
+type ReceiptsRequest struct {
+	Hash            common.Hash `serialize:"true"`
+	Height          uint64      `serialize:"true"`
+	Parents         uint16      `serialize:"true"`
+	MaxResponseSize uint32      `serialize:"true"`
+}
+
+func (r ReceiptsRequest) String() string {
+	return fmt.Sprintf(
+		"ReceiptsRequest(Hash=%s, Height=%d, Parents=%d, MaxResponseSize=%d)",
+		r.Hash, r.Height, r.Parents, r.MaxResponseSize,
+	)
+}
+
+func (r ReceiptsRequest) Handle(ctx context.Context, nodeID ids.NodeID, requestID uint32, handler RequestHandler) ([]byte, error) {
+	return handler.HandleReceiptsRequest(ctx, nodeID, requestID, r)
+}
+
+// ReceiptsResponse is a response to a ReceiptsRequest
+// Receipts is a slice of RLP encoded receipt lists (consensus encoding) starting
+// with the receipts of the block requested in ReceiptsRequest.Hash. The next
+// element holds the receipts of its parent, etc.
+// The derived SHA of each element is expected to equal the ReceiptHash of the
+// corresponding block header.
+// handler: handlers.ReceiptsRequestHandler
+type ReceiptsResponse struct {
+	Receipts [][]byte `serialize:"true"`
+}
@@ -0,0 +1,52 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package message
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMarshalReceiptsRequest asserts that the structure or serialization logic hasn't changed, primarily to
+// ensure compatibility with the network.
+func TestMarshalReceiptsRequest(t *testing.T) {
+	receiptsRequest := ReceiptsRequest{
+		Hash:            common.BytesToHash([]byte("some hash is here yo")),
+		Height:          1337,
+		Parents:         32,
+		MaxResponseSize: 64 * 1024,
+	}
+
+	base64ReceiptsRequest := "AAAAAAAAAAAAAAAAAABzb21lIGhhc2ggaXMgaGVyZSB5bwAAAAAAAAU5ACAAAQAA"
+
+	receiptsRequestBytes, err := Codec.Marshal(Version, receiptsRequest)
+	assert.NoError(t, err)
+	assert.Equal(t, base64ReceiptsRequest, base64.StdEncoding.EncodeToString(receiptsRequestBytes))
+
+	var r ReceiptsRequest
+	_, err = Codec.Unmarshal(receiptsRequestBytes, &r)
+	assert.NoError(t, err)
+	assert.Equal(t, receiptsRequest.Hash, r.Hash)
+	assert.Equal(t, receiptsRequest.Height, r.Height)
+	assert.Equal(t, receiptsRequest.Parents, r.Parents)
+	assert.Equal(t, receiptsRequest.MaxResponseSize, r.MaxResponseSize)
+}
+
+// TestMarshalReceiptsResponse asserts that a ReceiptsResponse round trips through the codec.
+func TestMarshalReceiptsResponse(t *testing.T) {
+	receiptsResponse := ReceiptsResponse{
+		Receipts: [][]byte{{0xc0}, {0xc2, 0x01, 0x02}},
+	}
+
+	receiptsResponseBytes, err := Codec.Marshal(Version, receiptsResponse)
+	assert.NoError(t, err)
+
+	var r ReceiptsResponse
+	_, err = Codec.Unmarshal(receiptsResponseBytes, &r)
+	assert.NoError(t, err)
+	assert.Equal(t, receiptsResponse.Receipts, r.Receipts)
+}
@@ -30,9 +30,22 @@ func BytesToRequest(codec codec.Manager, requestBytes []byte) (Request, error) {
 	return request, nil
 }
 
+// versionedRequest is implemented by requests with fields that are only
+// serialized by a later codec version, to pick the version they are encoded
+// with.
+type versionedRequest interface {
+	codecVersion() uint16
+}
+
 // RequestToBytes marshals the given request object into bytes
+// Requests are encoded with Version, unless they set fields that require a
+// later codec version.
 func RequestToBytes(codec codec.Manager, request Request) ([]byte, error) {
-	return codec.Marshal(Version, &request)
+	version := Version
+	if versioned, ok := request.(versionedRequest); ok {
+		version = versioned.codecVersion()
+	}
+	return codec.Marshal(version, &request)
 }
 
 // CrossChainRequest represents the interface a cross chain request should implement
@@ -42,8 +42,40 @@ func (s BlockSignatureRequest) Handle(ctx context.Context, nodeID ids.NodeID, re
 	return handler.HandleBlockSignatureRequest(ctx, nodeID, requestID, s)
 }
 
+// SignatureStatus describes the outcome of a signature request.
+type SignatureStatus uint8
+
+const (
+	// SignatureOK indicates the response contains a valid signature.
+	SignatureOK SignatureStatus = iota
+	// SignatureUnknown indicates the responding node does not know the requested
+	// message or block, so retrying the request is not expected to succeed.
+	SignatureUnknown
+	// SignatureSigningFailed indicates the responding node knows the requested
+	// message or block but failed to sign it. The request may be retried.
+	SignatureSigningFailed
+)
+
+func (s SignatureStatus) String() string {
+	switch s {
+	case SignatureOK:
+		return "ok"
+	case SignatureUnknown:
+		return "unknown"
+	case SignatureSigningFailed:
+		return "signing failed"
+	default:
+		return fmt.Sprintf("SignatureStatus(%d)", uint8(s))
+	}
+}
+
 // SignatureResponse is the response to a BlockSignatureRequest or MessageSignatureRequest.
 // The response contains a BLS signature of the requested message, signed by the responding node's BLS private key.
+//
+// Status is only serialized by codec version StatusVersion. Responses encoded with
+// Version are always decoded with SignatureOK, so peers that do not support
+// StatusVersion must be sent Version responses with an empty signature on failure.
 type SignatureResponse struct {
 	Signature [bls.SignatureLen]byte `serialize:"true"`
+	Status    SignatureStatus        `serializeV1:"true"`
 }
@@ -71,3 +71,35 @@ func TestMarshalSignatureResponse(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, signatureResponse.Signature, s.Signature)
 }
+
+// TestMarshalSignatureResponseStatus asserts that the status is only serialized by StatusVersion,
+// so that Version responses are unchanged for peers that do not support statuses.
+func TestMarshalSignatureResponseStatus(t *testing.T) {
+	for _, status := range []SignatureStatus{SignatureOK, SignatureUnknown, SignatureSigningFailed} {
+		t.Run(status.String(), func(t *testing.T) {
+			signatureResponse := SignatureResponse{Status: status}
+
+			// Version drops the status
+			signatureResponseBytes, err := Codec.Marshal(Version, signatureResponse)
+			require.NoError(t, err)
+			require.Len(t, signatureResponseBytes, 2+bls.SignatureLen)
+
+			var s SignatureResponse
+			version, err := Codec.Unmarshal(signatureResponseBytes, &s)
+			require.NoError(t, err)
+			require.Equal(t, Version, version)
+			require.Equal(t, SignatureOK, s.Status)
+
+			// StatusVersion appends the status
+			signatureResponseBytes, err = Codec.Marshal(StatusVersion, signatureResponse)
+			require.NoError(t, err)
+			require.Len(t, signatureResponseBytes, 2+bls.SignatureLen+1)
+			require.Equal(t, byte(status), signatureResponseBytes[len(signatureResponseBytes)-1])
+
+			version, err = Codec.Unmarshal(signatureResponseBytes, &s)
+			require.NoError(t, err)
+			require.Equal(t, StatusVersion, version)
+			require.Equal(t, status, s.Status)
+		})
+	}
+}
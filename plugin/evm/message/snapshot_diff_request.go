@@ -0,0 +1,56 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package message
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/luxdefi/node/ids"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var _ Request = SnapshotDiffRequest{}
+
+// SnapshotDiffRequest is a request to retrieve the changes to the state made
+// by the accepted block with the given Hash and Height
+type SnapshotDiffRequest struct {
+	Hash   common.Hash `serialize:"true"`
+	Height uint64      `serialize:"true"`
+}
+
+func (s SnapshotDiffRequest) String() string {
+	return fmt.Sprintf("SnapshotDiffRequest(Hash=%s, Height=%d)", s.Hash, s.Height)
+}
+
+func (s SnapshotDiffRequest) Handle(ctx context.Context, nodeID ids.NodeID, requestID uint32, handler RequestHandler) ([]byte, error) {
+	return handler.HandleSnapshotDiffRequest(ctx, nodeID, requestID, s)
+}
+
+// SnapshotDiffResponse is a response to a SnapshotDiffRequest
+// It holds the changes turning the state ParentRoot of the parent block into
+// the state Root of the requested block, in the format of a snapshot diff
+// layer: Destructs are the hashes of the deleted accounts, and Accounts the
+// accounts created or modified, sorted by hash.
+// Applying the diff to the state trie of ParentRoot is expected to result in
+// Root, which must equal the state root of the requested block header.
+// handler: handlers.SnapshotDiffRequestHandler
+type SnapshotDiffResponse struct {
+	ParentRoot common.Hash           `serialize:"true"`
+	Root       common.Hash           `serialize:"true"`
+	Destructs  []common.Hash         `serialize:"true"`
+	Accounts   []SnapshotDiffAccount `serialize:"true"`
+}
+
+// SnapshotDiffAccount is an account created or modified by a block
+// Account is the slim RLP encoding of the account, as stored in the snapshot.
+// Keys are the hashes of the storage slots changed by the block, sorted, and
+// Vals their RLP encoded values, which are empty for deleted slots.
+type SnapshotDiffAccount struct {
+	Hash    common.Hash   `serialize:"true"`
+	Account []byte        `serialize:"true"`
+	Keys    []common.Hash `serialize:"true"`
+	Vals    [][]byte      `serialize:"true"`
+}
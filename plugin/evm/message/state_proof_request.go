@@ -0,0 +1,121 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package message
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/luxdefi/node/ids"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// MaxStateProofSlotsPerRequest is the maximum number of storage slots a StateProofRequest may carry
+const MaxStateProofSlotsPerRequest = 256
+
+var _ Request = StateProofRequest{}
+
+// StateProofRequest is a request to retrieve an EIP-1186 style proof of
+// Account and its storage Slots in the state of the block with BlockHash at Height
+type StateProofRequest struct {
+	BlockHash common.Hash    `serialize:"true"`
+	Height    uint64         `serialize:"true"`
+	Account   common.Address `serialize:"true"`
+	Slots     []common.Hash  `serialize:"true"`
+}
+
+func (s StateProofRequest) String() string {
+	return fmt.Sprintf(
+		"StateProofRequest(BlockHash=%s, Height=%d, Account=%s, Slots=%d)",
+		s.BlockHash, s.Height, s.Account, len(s.Slots),
+	)
+}
+
+func (s StateProofRequest) Handle(ctx context.Context, nodeID ids.NodeID, requestID uint32, handler RequestHandler) ([]byte, error) {
+	return handler.HandleStateProofRequest(ctx, nodeID, requestID, s)
+}
+
+// StateProofStatus describes the outcome of a state proof request.
+type StateProofStatus uint8
+
+const (
+	// StateProofOK indicates the response contains the requested proofs.
+	StateProofOK StateProofStatus = iota
+	// StateProofUnknownBlock indicates the responding node does not know the
+	// requested block.
+	StateProofUnknownBlock
+	// StateProofStateUnavailable indicates the responding node knows the
+	// requested block but no longer has its state, as is the case for
+	// historical blocks on pruning nodes.
+	StateProofStateUnavailable
+)
+
+func (s StateProofStatus) String() string {
+	switch s {
+	case StateProofOK:
+		return "ok"
+	case StateProofUnknownBlock:
+		return "unknown block"
+	case StateProofStateUnavailable:
+		return "state unavailable"
+	default:
+		return fmt.Sprintf("StateProofStatus(%d)", uint8(s))
+	}
+}
+
+// StateProofResponse is a response to a StateProofRequest
+// AccountProof holds the RLP encoded trie nodes on the path from the state root
+// of the requested block to StateProofRequest.Account, starting with the root node.
+// StorageProofs holds the proof of each of StateProofRequest.Slots in the same
+// order, against the storage root of the account. It may hold fewer proofs than
+// requested slots to stay within the response size limit.
+// The account and slot values are obtained by verifying the proofs.
+// Proofs are only set if Status is StateProofOK.
+// handler: handlers.StateProofRequestHandler
+type StateProofResponse struct {
+	Status        StateProofStatus `serialize:"true"`
+	AccountProof  [][]byte         `serialize:"true"`
+	StorageProofs [][][]byte       `serialize:"true"`
+}
+
+var _ Request = StateMultiproofRequest{}
+
+// StateMultiproofRequest is a request to retrieve a single proof of Account and
+// all of its storage Slots in the state of the block with BlockHash at Height
+type StateMultiproofRequest struct {
+	BlockHash common.Hash    `serialize:"true"`
+	Height    uint64         `serialize:"true"`
+	Account   common.Address `serialize:"true"`
+	Slots     []common.Hash  `serialize:"true"`
+}
+
+func (s StateMultiproofRequest) String() string {
+	return fmt.Sprintf(
+		"StateMultiproofRequest(BlockHash=%s, Height=%d, Account=%s, Slots=%d)",
+		s.BlockHash, s.Height, s.Account, len(s.Slots),
+	)
+}
+
+func (s StateMultiproofRequest) Handle(ctx context.Context, nodeID ids.NodeID, requestID uint32, handler RequestHandler) ([]byte, error) {
+	return handler.HandleStateMultiproofRequest(ctx, nodeID, requestID, s)
+}
+
+// StateMultiproofResponse is a response to a StateMultiproofRequest
+// AccountProof holds the RLP encoded trie nodes on the path from the state root
+// of the requested block to StateMultiproofRequest.Account, starting with the root node.
+// StorageProof holds the trie nodes on the paths from the storage root of the
+// account to the first NumSlots of StateMultiproofRequest.Slots, with the nodes
+// shared by several paths included once. NumSlots may be lower than the number
+// of requested slots to stay within the response size limit.
+// Each slot is verified with trie.VerifyProof against the StorageProof nodes keyed
+// by their hash.
+// Proofs are only set if Status is StateProofOK.
+// handler: handlers.StateProofRequestHandler
+type StateMultiproofResponse struct {
+	Status       StateProofStatus `serialize:"true"`
+	AccountProof [][]byte         `serialize:"true"`
+	StorageProof [][]byte         `serialize:"true"`
+	NumSlots     uint16           `serialize:"true"`
+}
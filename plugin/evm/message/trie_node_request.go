@@ -0,0 +1,56 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package message
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/luxdefi/node/ids"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// MaxTrieNodeHashesPerRequest is the maximum number of hashes a TrieNodeRequest may carry
+const MaxTrieNodeHashesPerRequest = 512
+
+var _ Request = TrieNodeRequest{}
+
+// TrieNodeRequest is a request to retrieve trie nodes by their hashes
+type TrieNodeRequest struct {
+	// Hashes is a list of trie node hashes
+	Hashes []common.Hash `serialize:"true"`
+	// MaxResponseSize is the maximum total size in bytes of the nodes the
+	// requester wants in the response, which the server honors up to its own
+	// limit (0 means the server's limit)
+	MaxResponseSize uint32 `serialize:"true"`
+}
+
+func (t TrieNodeRequest) String() string {
+	hashStrs := make([]string, len(t.Hashes))
+	for i, hash := range t.Hashes {
+		hashStrs[i] = hash.String()
+	}
+	return fmt.Sprintf("TrieNodeRequest(Hashes=%s, MaxResponseSize=%d)", strings.Join(hashStrs, ", "), t.MaxResponseSize)
+}
+
+func (t TrieNodeRequest) Handle(ctx context.Context, nodeID ids.NodeID, requestID uint32, handler RequestHandler) ([]byte, error) {
+	return handler.HandleTrieNodeRequest(ctx, nodeID, requestID, t)
+}
+
+func NewTrieNodeRequest(hashes []common.Hash) TrieNodeRequest {
+	return TrieNodeRequest{
+		Hashes: hashes,
+	}
+}
+
+// TrieNodeResponse is a response to a TrieNodeRequest
+// Nodes holds the raw (RLP encoded) trie nodes that were found, in the order of
+// TrieNodeRequest.Hashes. Unknown hashes are skipped, so crypto.Keccak256Hash of
+// each element in Nodes is expected to equal one of the requested hashes, and
+// the response may be truncated to stay within the response size limit.
+// handler: handlers.TrieNodeRequestHandler
+type TrieNodeResponse struct {
+	Nodes [][]byte `serialize:"true"`
+}
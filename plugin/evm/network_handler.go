@@ -5,6 +5,7 @@ package evm
 
 import (
 	"context"
+	"time"
 
 	"github.com/luxdefi/node/codec"
 	"github.com/luxdefi/node/ids"
@@ -24,6 +25,11 @@ type networkHandler struct {
 	stateTrieLeafsRequestHandler *syncHandlers.LeafsRequestHandler
 	blockRequestHandler          *syncHandlers.BlockRequestHandler
 	codeRequestHandler           *syncHandlers.CodeRequestHandler
+	receiptsRequestHandler       *syncHandlers.ReceiptsRequestHandler
+	trieNodeRequestHandler       *syncHandlers.TrieNodeRequestHandler
+	stateProofRequestHandler     *syncHandlers.StateProofRequestHandler
+	capabilitiesRequestHandler   *syncHandlers.CapabilitiesRequestHandler
+	snapshotDiffRequestHandler   *syncHandlers.SnapshotDiffRequestHandler
 	signatureRequestHandler      *warpHandlers.SignatureRequestHandler
 }
 
@@ -34,13 +40,26 @@ func newNetworkHandler(
 	evmTrieDB *trie.Database,
 	warpBackend warp.Backend,
 	networkCodec codec.Manager,
+	warpMaxConcurrentRequests int,
+	warpCoalesceWindow time.Duration,
+	maxLeavesPerResponse uint16,
+	proofWorkers int,
+	lazyAccounts bool,
+	archive bool,
 ) message.RequestHandler {
 	syncStats := syncStats.NewHandlerStats(metrics.Enabled)
+	// the proof workers are shared, bounding proof generation across request types
+	workers := syncHandlers.NewProofWorkers(proofWorkers)
 	return &networkHandler{
-		stateTrieLeafsRequestHandler: syncHandlers.NewLeafsRequestHandler(evmTrieDB, provider, networkCodec, syncStats),
+		stateTrieLeafsRequestHandler: syncHandlers.NewLeafsRequestHandler(evmTrieDB, provider, networkCodec, syncStats, maxLeavesPerResponse, workers, lazyAccounts),
 		blockRequestHandler:          syncHandlers.NewBlockRequestHandler(provider, networkCodec, syncStats),
 		codeRequestHandler:           syncHandlers.NewCodeRequestHandler(diskDB, networkCodec, syncStats),
-		signatureRequestHandler:      warpHandlers.NewSignatureRequestHandler(warpBackend, networkCodec),
+		receiptsRequestHandler:       syncHandlers.NewReceiptsRequestHandler(provider, provider, networkCodec, syncStats),
+		trieNodeRequestHandler:       syncHandlers.NewTrieNodeRequestHandler(evmTrieDB, networkCodec, syncStats),
+		stateProofRequestHandler:     syncHandlers.NewStateProofRequestHandler(evmTrieDB, provider, provider, networkCodec, syncStats, workers),
+		capabilitiesRequestHandler:   syncHandlers.NewCapabilitiesRequestHandler(archive, networkCodec),
+		snapshotDiffRequestHandler:   syncHandlers.NewSnapshotDiffRequestHandler(evmTrieDB, provider, networkCodec, syncStats),
+		signatureRequestHandler:      warpHandlers.NewSignatureRequestHandler(warpBackend, networkCodec, warpMaxConcurrentRequests, warpCoalesceWindow),
 	}
 }
 
@@ -56,6 +75,30 @@ func (n networkHandler) HandleCodeRequest(ctx context.Context, nodeID ids.NodeID
 	return n.codeRequestHandler.OnCodeRequest(ctx, nodeID, requestID, codeRequest)
 }
 
+func (n networkHandler) HandleReceiptsRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, receiptsRequest message.ReceiptsRequest) ([]byte, error) {
+	return n.receiptsRequestHandler.OnReceiptsRequest(ctx, nodeID, requestID, receiptsRequest)
+}
+
+func (n networkHandler) HandleTrieNodeRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, trieNodeRequest message.TrieNodeRequest) ([]byte, error) {
+	return n.trieNodeRequestHandler.OnTrieNodeRequest(ctx, nodeID, requestID, trieNodeRequest)
+}
+
+func (n networkHandler) HandleStateProofRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, stateProofRequest message.StateProofRequest) ([]byte, error) {
+	return n.stateProofRequestHandler.OnStateProofRequest(ctx, nodeID, requestID, stateProofRequest)
+}
+
+func (n networkHandler) HandleStateMultiproofRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, multiproofRequest message.StateMultiproofRequest) ([]byte, error) {
+	return n.stateProofRequestHandler.OnStateMultiproofRequest(ctx, nodeID, requestID, multiproofRequest)
+}
+
+func (n networkHandler) HandleCapabilitiesRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, capabilitiesRequest message.CapabilitiesRequest) ([]byte, error) {
+	return n.capabilitiesRequestHandler.OnCapabilitiesRequest(ctx, nodeID, requestID, capabilitiesRequest)
+}
+
+func (n networkHandler) HandleSnapshotDiffRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, snapshotDiffRequest message.SnapshotDiffRequest) ([]byte, error) {
+	return n.snapshotDiffRequestHandler.OnSnapshotDiffRequest(ctx, nodeID, requestID, snapshotDiffRequest)
+}
+
 func (n networkHandler) HandleMessageSignatureRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, messageSignatureRequest message.MessageSignatureRequest) ([]byte, error) {
 	return n.signatureRequestHandler.OnMessageSignatureRequest(ctx, nodeID, requestID, messageSignatureRequest)
 }
@@ -0,0 +1,169 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package evm
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/luxdefi/evm/core/rawdb"
+	"github.com/luxdefi/evm/core/types"
+	"github.com/luxdefi/evm/ethdb"
+	syncclient "github.com/luxdefi/evm/sync/client"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"golang.org/x/time/rate"
+)
+
+const (
+	defaultReceiptBackfillBlocksPerRequest = uint16(32)
+
+	receiptBackfillMarkerLen = common.HashLength + 8
+)
+
+// receiptBackfillMarkerKey tracks the next block (newest first) whose receipts
+// must be fetched from peers. It is written when state sync completes and
+// removed once the backfill reaches a block whose receipts are already present.
+var receiptBackfillMarkerKey = []byte("receiptBackfillMarker")
+
+// receiptBackfillerConfig defines the options and dependencies needed to
+// construct a receiptBackfiller
+type receiptBackfillerConfig struct {
+	client  syncclient.Client
+	chaindb ethdb.Database
+
+	// requestRate bounds the number of network requests issued per second.
+	requestRate float64
+	// blocksPerRequest is the number of blocks whose receipts are requested at once.
+	blocksPerRequest uint16
+}
+
+// receiptBackfiller fetches the receipts of blocks accepted before the state
+// sync summary so that receipt lookups work for pre-sync blocks.
+// Progress is persisted with each written batch, so an interrupted backfill
+// resumes where it left off.
+type receiptBackfiller struct {
+	*receiptBackfillerConfig
+
+	limiter *rate.Limiter
+}
+
+func newReceiptBackfiller(config *receiptBackfillerConfig) *receiptBackfiller {
+	if config.blocksPerRequest == 0 {
+		config.blocksPerRequest = defaultReceiptBackfillBlocksPerRequest
+	}
+	return &receiptBackfiller{
+		receiptBackfillerConfig: config,
+		limiter:                 rate.NewLimiter(rate.Limit(config.requestRate), 1),
+	}
+}
+
+// writeReceiptBackfillMarker marks [hash] at [height] as the first block whose
+// receipts should be backfilled.
+func writeReceiptBackfillMarker(db ethdb.KeyValueWriter, hash common.Hash, height uint64) error {
+	marker := make([]byte, receiptBackfillMarkerLen)
+	copy(marker, hash[:])
+	binary.BigEndian.PutUint64(marker[common.HashLength:], height)
+	return db.Put(receiptBackfillMarkerKey, marker)
+}
+
+// readReceiptBackfillMarker returns the next block to backfill receipts for
+// and false if there is no backfill in progress.
+func readReceiptBackfillMarker(db ethdb.KeyValueReader) (common.Hash, uint64, bool, error) {
+	has, err := db.Has(receiptBackfillMarkerKey)
+	if err != nil || !has {
+		return common.Hash{}, 0, false, err
+	}
+	marker, err := db.Get(receiptBackfillMarkerKey)
+	if err != nil {
+		return common.Hash{}, 0, false, err
+	}
+	if len(marker) != receiptBackfillMarkerLen {
+		return common.Hash{}, 0, false, fmt.Errorf("receipt backfill marker should have length %d, but found %d", receiptBackfillMarkerLen, len(marker))
+	}
+	return common.BytesToHash(marker[:common.HashLength]), binary.BigEndian.Uint64(marker[common.HashLength:]), true, nil
+}
+
+// Run blockingly backfills receipts from the persisted marker down to the
+// first block with receipts already on disk (or genesis).
+// Returns nil immediately if there is no backfill in progress.
+func (r *receiptBackfiller) Run(ctx context.Context) error {
+	hash, height, found, err := readReceiptBackfillMarker(r.chaindb)
+	if err != nil || !found {
+		return err
+	}
+	log.Info("starting receipt backfill", "hash", hash, "height", height)
+
+	total := 0
+	for height > 0 && !rawdb.HasReceipts(r.chaindb, hash, height) {
+		blocks, fetched, err := r.getBlocks(ctx, hash, height)
+		if err != nil {
+			return err
+		}
+		if err := r.limiter.Wait(ctx); err != nil {
+			return err
+		}
+		receipts, err := r.client.GetReceipts(ctx, blocks)
+		if err != nil {
+			return err
+		}
+
+		batch := r.chaindb.NewBatch()
+		for i, blockReceipts := range receipts {
+			block := blocks[i]
+			if fetched {
+				rawdb.WriteBlock(batch, block)
+				rawdb.WriteCanonicalHash(batch, block.Hash(), block.NumberU64())
+			}
+			rawdb.WriteReceipts(batch, block.Hash(), block.NumberU64(), blockReceipts)
+			rawdb.WriteTxLookupEntriesByBlock(batch, block)
+
+			hash = block.ParentHash()
+			height = block.NumberU64() - 1
+		}
+		if err := writeReceiptBackfillMarker(batch, hash, height); err != nil {
+			return err
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		total += len(receipts)
+		log.Debug("backfilled receipts", "blocks", len(receipts), "total", total, "next", height)
+	}
+
+	log.Info("completed receipt backfill", "total", total, "last", height)
+	return r.chaindb.Delete(receiptBackfillMarkerKey)
+}
+
+// getBlocks returns up to [blocksPerRequest] consecutive blocks starting at [hash]
+// and going towards genesis. Blocks available on disk are preferred, otherwise
+// the blocks are fetched from peers, in which case fetched is true.
+func (r *receiptBackfiller) getBlocks(ctx context.Context, hash common.Hash, height uint64) (blocks []*types.Block, fetched bool, err error) {
+	for len(blocks) < int(r.blocksPerRequest) && height > 0 {
+		block := rawdb.ReadBlock(r.chaindb, hash, height)
+		if block == nil || rawdb.HasReceipts(r.chaindb, hash, height) {
+			break
+		}
+		blocks = append(blocks, block)
+		hash = block.ParentHash()
+		height--
+	}
+	if len(blocks) > 0 {
+		return blocks, false, nil
+	}
+
+	parents := r.blocksPerRequest
+	if uint64(parents) > height {
+		parents = uint16(height)
+	}
+	if err := r.limiter.Wait(ctx); err != nil {
+		return nil, false, err
+	}
+	blocks, err = r.client.GetBlocks(ctx, hash, height, parents)
+	if err != nil {
+		return nil, false, err
+	}
+	return blocks, true, nil
+}
@@ -0,0 +1,118 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package evm
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/luxdefi/evm/consensus/dummy"
+	"github.com/luxdefi/evm/core"
+	"github.com/luxdefi/evm/core/rawdb"
+	"github.com/luxdefi/evm/core/types"
+	"github.com/luxdefi/evm/params"
+	"github.com/luxdefi/evm/plugin/evm/message"
+	statesyncclient "github.com/luxdefi/evm/sync/client"
+	"github.com/luxdefi/evm/sync/handlers"
+	handlerstats "github.com/luxdefi/evm/sync/handlers/stats"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReceiptBackfill(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	gspec := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc:  core.GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+	}
+	signer := types.LatestSigner(params.TestChainConfig)
+	_, blocks, canonicalReceipts, err := core.GenerateChainWithGenesis(gspec, dummy.NewETHFaker(), 40, 0, func(i int, b *core.BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(b.TxNonce(addr), common.Address{1}, big.NewInt(1), params.TxGas, b.BaseFee(), nil), signer, key)
+		require.NoError(t, err)
+		b.AddTx(tx)
+	})
+	require.NoError(t, err)
+
+	// serve blocks and receipts from the generated chain
+	blocksByHash := make(map[common.Hash]*types.Block, len(blocks))
+	receiptsByHash := make(map[common.Hash]types.Receipts, len(blocks))
+	for i, blk := range blocks {
+		blocksByHash[blk.Hash()] = blk
+		receiptsByHash[blk.Hash()] = canonicalReceipts[i]
+	}
+	blockProvider := &handlers.TestBlockProvider{
+		GetBlockFn: func(hash common.Hash, height uint64) *types.Block {
+			blk, ok := blocksByHash[hash]
+			if !ok || blk.NumberU64() != height {
+				return nil
+			}
+			return blk
+		},
+	}
+	receiptProvider := &handlers.TestReceiptProvider{
+		GetReceiptsByHashFn: func(hash common.Hash) types.Receipts {
+			return receiptsByHash[hash]
+		},
+	}
+	handlerStats := handlerstats.NewNoopHandlerStats()
+	client := statesyncclient.NewMockClient(
+		message.Codec,
+		nil,
+		nil,
+		handlers.NewBlockRequestHandler(blockProvider, message.Codec, handlerStats),
+		handlers.NewReceiptsRequestHandler(blockProvider, receiptProvider, message.Codec, handlerStats),
+		nil,
+	)
+
+	// the syncing node only has the last few blocks (as fetched by state sync)
+	// and resumes a backfill that was interrupted below the synced tip.
+	chaindb := rawdb.NewMemoryDatabase()
+	for _, blk := range blocks[30:] {
+		rawdb.WriteBlock(chaindb, blk)
+		rawdb.WriteCanonicalHash(chaindb, blk.Hash(), blk.NumberU64())
+	}
+	resumeFrom := blocks[34]
+	require.NoError(t, writeReceiptBackfillMarker(chaindb, resumeFrom.Hash(), resumeFrom.NumberU64()))
+
+	backfiller := newReceiptBackfiller(&receiptBackfillerConfig{
+		client:           client,
+		chaindb:          chaindb,
+		requestRate:      1000,
+		blocksPerRequest: 8,
+	})
+	require.NoError(t, backfiller.Run(context.Background()))
+
+	// the marker is cleared once the backfill completes
+	_, _, found, err := readReceiptBackfillMarker(chaindb)
+	require.NoError(t, err)
+	require.False(t, found)
+
+	// blocks above the resume point were not backfilled
+	for _, blk := range blocks[35:] {
+		require.False(t, rawdb.HasReceipts(chaindb, blk.Hash(), blk.NumberU64()))
+	}
+	for i, blk := range blocks[:35] {
+		require.NotNil(t, rawdb.ReadBlock(chaindb, blk.Hash(), blk.NumberU64()))
+		for _, canonical := range canonicalReceipts[i] {
+			// lookup the receipt by transaction hash the same way eth_getTransactionReceipt does
+			receipt, blockHash, blockNumber, index := rawdb.ReadReceipt(chaindb, canonical.TxHash, params.TestChainConfig)
+			require.NotNil(t, receipt)
+			require.Equal(t, blk.Hash(), blockHash)
+			require.Equal(t, blk.NumberU64(), blockNumber)
+			require.Equal(t, uint64(canonical.TransactionIndex), index)
+
+			require.Equal(t, canonical.TxHash, receipt.TxHash)
+			require.Equal(t, canonical.Status, receipt.Status)
+			require.Equal(t, canonical.CumulativeGasUsed, receipt.CumulativeGasUsed)
+			require.Equal(t, canonical.GasUsed, receipt.GasUsed)
+			require.Equal(t, canonical.Bloom, receipt.Bloom)
+			require.Equal(t, canonical.ContractAddress, receipt.ContractAddress)
+			require.Len(t, receipt.Logs, len(canonical.Logs))
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"context"
 	"math/big"
 
+	"github.com/luxdefi/evm/core/rawdb"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/log"
 )
@@ -36,3 +37,39 @@ func (api *SnowmanAPI) IssueBlock(ctx context.Context) error {
 	api.vm.builder.signalTxsReady()
 	return nil
 }
+
+// defaultDBStatsSampleRate is the fraction of hash-keyed entries read by
+// DbStats if no sample rate is given.
+const defaultDBStatsSampleRate = 1.0 / 16
+
+// DebugAPI introduces VM specific debugging functionality to the evm
+type DebugAPI struct{ vm *VM }
+
+// DBStatsReply defines the reply that will be sent from the DbStats API call
+type DBStatsReply struct {
+	*rawdb.DatabaseStats
+	Warp rawdb.DatabaseStat `json:"warp"`
+}
+
+// DbStats returns the size of the database broken down by category. Only
+// [sampleRate] of the hash-keyed entries are read and their size is
+// extrapolated. A sample rate of 1 reads the whole database.
+func (api *DebugAPI) DbStats(ctx context.Context, sampleRate *float64) (*DBStatsReply, error) {
+	rate := defaultDBStatsSampleRate
+	if sampleRate != nil {
+		rate = *sampleRate
+	}
+	stats, err := rawdb.CollectDatabaseStats(api.vm.chaindb, rate)
+	if err != nil {
+		return nil, err
+	}
+	// Every entry of the warp database is a warp message or its signature.
+	reply := &DBStatsReply{DatabaseStats: stats}
+	it := api.vm.warpDB.NewIterator()
+	defer it.Release()
+	for it.Next() {
+		reply.Warp.Bytes += uint64(len(it.Key()) + len(it.Value()))
+		reply.Warp.Items++
+	}
+	return reply, it.Error()
+}
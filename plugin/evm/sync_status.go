@@ -0,0 +1,76 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package evm
+
+import (
+	"github.com/luxdefi/node/snow"
+	"github.com/luxdefi/node/utils/json"
+)
+
+// SyncPhase is the phase of syncing the chain the VM is in.
+type SyncPhase string
+
+const (
+	SyncPhaseBootstrapping SyncPhase = "bootstrapping"
+	SyncPhaseStateSyncing  SyncPhase = "state-syncing"
+	SyncPhaseCatchingUp    SyncPhase = "catching-up"
+	SyncPhaseSynced        SyncPhase = "synced"
+
+	// catchingUpBlocks is the number of blocks the last accepted block may be
+	// behind the highest block seen in normal operation before the VM is
+	// reported to be catching up.
+	catchingUpBlocks = 32
+)
+
+type SyncStatusReply struct {
+	Phase         SyncPhase   `json:"phase"`
+	CurrentHeight json.Uint64 `json:"currentHeight"`
+	TargetHeight  json.Uint64 `json:"targetHeight"`
+	// Progress is the percentage of the blocks up to TargetHeight that are
+	// accepted. It is not set while state syncing, since the blocks up to the
+	// target are not accepted one by one.
+	Progress *json.Float64 `json:"progress,omitempty"`
+}
+
+// updateHighestSeenHeight raises the highest block height seen to [height],
+// which must be the height of a verified or accepted block so that unverified
+// bytes cannot move the target reported by syncStatus.
+func (vm *VM) updateHighestSeenHeight(height uint64) {
+	for {
+		highest := vm.highestSeenHeight.Load()
+		if height <= highest || vm.highestSeenHeight.CompareAndSwap(highest, height) {
+			return
+		}
+	}
+}
+
+// syncStatus returns the sync phase of the VM along with its progress.
+// Assumes ctx.Lock is held.
+func (vm *VM) syncStatus() SyncStatusReply {
+	current := vm.blockChain.LastAcceptedBlock().NumberU64()
+	reply := SyncStatusReply{CurrentHeight: json.Uint64(current)}
+
+	target := vm.highestSeenHeight.Load()
+	switch vm.snowState {
+	case snow.StateSyncing:
+		reply.Phase = SyncPhaseStateSyncing
+		reply.TargetHeight = json.Uint64(max(current, vm.SyncTargetHeight()))
+		return reply
+	case snow.NormalOp:
+		reply.Phase = SyncPhaseSynced
+		if target > current+catchingUpBlocks {
+			reply.Phase = SyncPhaseCatchingUp
+		}
+	default:
+		reply.Phase = SyncPhaseBootstrapping
+	}
+	target = max(target, current)
+	progress := json.Float64(100)
+	if target > 0 {
+		progress = json.Float64(float64(current) / float64(target) * 100)
+	}
+	reply.TargetHeight = json.Uint64(target)
+	reply.Progress = &progress
+	return reply
+}
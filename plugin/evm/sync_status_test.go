@@ -0,0 +1,113 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package evm
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/luxdefi/node/snow"
+	"github.com/luxdefi/node/snow/consensus/snowman"
+	"github.com/luxdefi/evm/core/types"
+	"github.com/luxdefi/evm/params"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminSyncStatus(t *testing.T) {
+	require := require.New(t)
+
+	// [source] builds the blocks the VM under test syncs
+	issuer, source, _, _ := GenesisVM(t, true, genesisJSONEVM, "", "")
+	defer func() {
+		require.NoError(source.Shutdown(context.Background()))
+	}()
+	var blocks []snowman.Block
+	for i := uint64(0); i < 3; i++ {
+		tx := types.NewTransaction(i, common.Address{0x01}, big.NewInt(1), params.TxGas, big.NewInt(testMinGasPrice), nil)
+		signedTx, err := types.SignTx(tx, types.NewEIP155Signer(source.chainConfig.ChainID), testKeys[0])
+		require.NoError(err)
+		for _, err := range source.txPool.AddRemotesSync([]*types.Transaction{signedTx}) {
+			require.NoError(err)
+		}
+		source.clock.Set(source.clock.Time().Add(2 * time.Second))
+		blocks = append(blocks, issueAndAccept(t, issuer, source))
+	}
+
+	_, vm, _, _ := GenesisVM(t, false, genesisJSONEVM, "", "")
+	defer func() {
+		require.NoError(vm.Shutdown(context.Background()))
+	}()
+	vm.clock.Set(source.clock.Time())
+	admin := NewAdminService(vm, t.TempDir())
+	status := func() SyncStatusReply {
+		var reply SyncStatusReply
+		require.NoError(admin.SyncStatus(nil, nil, &reply))
+		return reply
+	}
+
+	reply := status()
+	require.Equal(SyncPhaseBootstrapping, reply.Phase)
+	require.Zero(reply.CurrentHeight)
+	require.Zero(reply.TargetHeight)
+
+	// No state summary is accepted, so there is no target beyond the last accepted block
+	require.NoError(vm.SetState(context.Background(), snow.StateSyncing))
+	reply = status()
+	require.Equal(SyncPhaseStateSyncing, reply.Phase)
+	require.Zero(reply.TargetHeight)
+	require.Nil(reply.Progress)
+
+	// Bootstrapping targets the highest block verified, and makes progress as
+	// blocks are accepted. Parsed blocks are not trusted to set the target.
+	require.NoError(vm.SetState(context.Background(), snow.Bootstrapping))
+	var parsed []snowman.Block
+	for i := len(blocks) - 1; i >= 0; i-- {
+		blk, err := vm.ParseBlock(context.Background(), blocks[i].Bytes())
+		require.NoError(err)
+		parsed = append([]snowman.Block{blk}, parsed...)
+	}
+	reply = status()
+	require.Equal(SyncPhaseBootstrapping, reply.Phase)
+	require.Zero(reply.TargetHeight)
+
+	for _, blk := range parsed {
+		require.NoError(blk.Verify(context.Background()))
+	}
+	reply = status()
+	require.Equal(SyncPhaseBootstrapping, reply.Phase)
+	require.EqualValues(0, reply.CurrentHeight)
+	require.EqualValues(3, reply.TargetHeight)
+	require.EqualValues(0, *reply.Progress)
+
+	for i, blk := range parsed[:2] {
+		require.NoError(blk.Accept(context.Background()))
+		reply = status()
+		require.EqualValues(i+1, reply.CurrentHeight)
+		require.InDelta(float64(i+1)/3*100, float64(*reply.Progress), 0.01)
+	}
+
+	// Normal operation is synced within [catchingUpBlocks] of the highest block seen
+	require.NoError(vm.SetState(context.Background(), snow.NormalOp))
+	reply = status()
+	require.Equal(SyncPhaseSynced, reply.Phase)
+	require.EqualValues(2, reply.CurrentHeight)
+	require.EqualValues(3, reply.TargetHeight)
+
+	// and catching up when further behind
+	vm.highestSeenHeight.Store(2 + catchingUpBlocks + 1)
+	reply = status()
+	require.Equal(SyncPhaseCatchingUp, reply.Phase)
+	require.EqualValues(2+catchingUpBlocks+1, reply.TargetHeight)
+
+	vm.highestSeenHeight.Store(3)
+	require.NoError(parsed[2].Verify(context.Background()))
+	require.NoError(parsed[2].Accept(context.Background()))
+	reply = status()
+	require.Equal(SyncPhaseSynced, reply.Phase)
+	require.EqualValues(3, reply.CurrentHeight)
+	require.EqualValues(100, *reply.Progress)
+}
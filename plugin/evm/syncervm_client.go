@@ -21,6 +21,7 @@ import (
 	"github.com/luxdefi/evm/ethdb"
 	"github.com/luxdefi/evm/params"
 	"github.com/luxdefi/evm/plugin/evm/message"
+	"github.com/luxdefi/evm/precompile/contracts/blockhashes"
 	syncclient "github.com/luxdefi/evm/sync/client"
 	"github.com/luxdefi/evm/sync/statesync"
 	"github.com/ethereum/go-ethereum/common"
@@ -29,8 +30,9 @@ import (
 
 const (
 	// State sync fetches [parentsToGet] parents of the block it syncs to.
-	// The last 256 block hashes are necessary to support the BLOCKHASH opcode.
-	parentsToGet = 256
+	// The hashes of the last [blockhashes.HistoryWindow] blocks are necessary
+	// to support the BLOCKHASH opcode and the block hashes precompile.
+	parentsToGet = int(blockhashes.HistoryWindow)
 )
 
 var stateSyncSummaryKey = []byte("stateSyncSummary")
@@ -44,6 +46,9 @@ type stateSyncClientConfig struct {
 	// algorithm.
 	stateSyncMinBlocks   uint64
 	stateSyncRequestSize uint16 // number of key/value pairs to ask peers for per request
+	// If set, marks the synced block as the starting point for backfilling
+	// receipts of pre-sync blocks once state sync completes.
+	receiptBackfillEnabled bool
 
 	lastAcceptedHeight uint64
 
@@ -88,6 +93,7 @@ type StateSyncClient interface {
 	StateSyncClearOngoingSummary() error
 	Shutdown() error
 	Error() error
+	SyncTargetHeight() uint64
 }
 
 // Syncer represents a step in state sync,
@@ -229,6 +235,12 @@ func (client *stateSyncerClient) acceptSyncSummary(proposedSummary message.SyncS
 // the process begins with [fromHash] and it fetches parents recursively.
 // fetching starts from the first ancestor not found on disk
 func (client *stateSyncerClient) syncBlocks(ctx context.Context, fromHash common.Hash, fromHeight uint64, parentsToGet int) error {
+	return fetchBlocks(ctx, client.client, client.chaindb, fromHash, fromHeight, parentsToGet)
+}
+
+// fetchBlocks fetches (up to) [parentsToGet] ancestors of [fromHash] missing
+// from [chaindb] using [syncClient] and writes them with their canonical hash.
+func fetchBlocks(ctx context.Context, syncClient syncclient.Client, chaindb ethdb.Database, fromHash common.Hash, fromHeight uint64, parentsToGet int) error {
 	nextHash := fromHash
 	nextHeight := fromHeight
 	parentsPerRequest := uint16(32)
@@ -236,7 +248,7 @@ func (client *stateSyncerClient) syncBlocks(ctx context.Context, fromHash common
 	// first, check for blocks already available on disk so we don't
 	// request them from peers.
 	for parentsToGet >= 0 {
-		blk := rawdb.ReadBlock(client.chaindb, nextHash, nextHeight)
+		blk := rawdb.ReadBlock(chaindb, nextHash, nextHeight)
 		if blk != nil {
 			// block exists
 			nextHash = blk.ParentHash()
@@ -251,12 +263,12 @@ func (client *stateSyncerClient) syncBlocks(ctx context.Context, fromHash common
 
 	// get any blocks we couldn't find on disk from peers and write
 	// them to disk.
-	batch := client.chaindb.NewBatch()
+	batch := chaindb.NewBatch()
 	for i := parentsToGet - 1; i >= 0 && (nextHash != common.Hash{}); {
 		if err := ctx.Err(); err != nil {
 			return err
 		}
-		blocks, err := client.client.GetBlocks(ctx, nextHash, nextHeight, parentsPerRequest)
+		blocks, err := syncClient.GetBlocks(ctx, nextHash, nextHeight, parentsPerRequest)
 		if err != nil {
 			log.Error("could not get blocks from peer", "err", err, "nextHash", nextHash, "remaining", i+1)
 			return err
@@ -348,6 +360,12 @@ func (client *stateSyncerClient) finishSync() error {
 		return err
 	}
 
+	if client.receiptBackfillEnabled {
+		if err := writeReceiptBackfillMarker(client.chaindb, block.Hash(), block.NumberU64()); err != nil {
+			return fmt.Errorf("failed to write receipt backfill marker: %w", err)
+		}
+	}
+
 	if err := client.updateVMMarkers(); err != nil {
 		return fmt.Errorf("error updating vm markers, height=%d, hash=%s, err=%w", block.NumberU64(), block.Hash(), err)
 	}
@@ -373,3 +391,7 @@ func (client *stateSyncerClient) updateVMMarkers() error {
 
 // Error returns a non-nil error if one occurred during the sync.
 func (client *stateSyncerClient) Error() error { return client.stateSyncErr }
+
+// SyncTargetHeight returns the height of the summary being synced to, or 0 if
+// state sync was not started.
+func (client *stateSyncerClient) SyncTargetHeight() uint64 { return client.syncSummary.BlockNumber }
@@ -14,6 +14,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	nodeMetrics "github.com/luxdefi/node/api/metrics"
@@ -25,6 +26,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/luxdefi/evm/commontype"
+	"github.com/luxdefi/evm/consensus/dummy"
 	"github.com/luxdefi/evm/constants"
 	"github.com/luxdefi/evm/core"
 	"github.com/luxdefi/evm/core/rawdb"
@@ -44,6 +46,7 @@ import (
 	"github.com/luxdefi/evm/sync/client/stats"
 	"github.com/luxdefi/evm/trie"
 	"github.com/luxdefi/evm/warp"
+	"github.com/luxdefi/evm/warp/aggregator"
 	warpValidators "github.com/luxdefi/evm/warp/validators"
 
 	// Force-load tracer engine to trigger registration
@@ -225,6 +228,9 @@ type VM struct {
 
 	// Continuous Profiler
 	profiler profiler.ContinuousProfiler
+	// blockProfiler captures the CPU profiles of blocks requested through
+	// the admin API
+	blockProfiler *blockProfiler
 
 	peer.Network
 	client       peer.NetworkClient
@@ -237,6 +243,11 @@ type VM struct {
 	sdkMetrics    *prometheus.Registry
 
 	bootstrapped bool
+	// snowState is the last state set by the engine, reported by admin.syncStatus
+	snowState snow.State
+	// highestSeenHeight is the height of the highest block verified or
+	// accepted, used as the target height of bootstrapping
+	highestSeenHeight atomic.Uint64
 
 	logger EVMLogger
 	// State sync server and client
@@ -246,6 +257,24 @@ type VM struct {
 	// Lux Warp Messaging backend
 	// Used to serve BLS signatures of warp messages over RPC
 	warpBackend warp.Backend
+
+	// receiptBackfiller fetches receipts of pre-state sync blocks from peers
+	receiptBackfiller *receiptBackfiller
+
+	// blockHashHistoryComplete is set once the blocks of the block hashes
+	// precompile history window are on disk.
+	blockHashHistoryComplete atomic.Bool
+
+	// syncClient fetches state sync data from peers. Its in-flight requests
+	// can be inspected and cancelled through the admin API.
+	syncClient statesyncclient.Client
+
+	// acceptReorder buffers the blocks accepted ahead of their parent
+	acceptReorder *acceptReorderBuffer
+
+	// acceptanceObserver, if set, is notified of each block before it is
+	// accepted. See SetAcceptanceObserver.
+	acceptanceObserver AcceptanceObserver
 }
 
 // Initialize implements the snowman.ChainVM interface
@@ -271,6 +300,12 @@ func (vm *VM) Initialize(
 	}
 	vm.ctx = chainCtx
 
+	// Cache the validator sets fetched from the P-Chain, such as those used to
+	// verify warp messages, if configured.
+	if interval := vm.config.WarpValidatorSetRefreshInterval.Duration; interval > 0 {
+		vm.ctx.ValidatorState = warpValidators.NewCachedState(vm.ctx.ValidatorState, interval)
+	}
+
 	// Create logger
 	alias, err := vm.ctx.BCLookup.PrimaryAlias(vm.ctx.ChainID)
 	if err != nil {
@@ -300,6 +335,7 @@ func (vm *VM) Initialize(
 	vm.chaindb = Database{prefixdb.NewNested(ethDBPrefix, db)}
 	vm.db = versiondb.New(db)
 	vm.acceptedBlockDB = prefixdb.New(acceptedPrefix, vm.db)
+	vm.acceptReorder = newAcceptReorderBuffer(vm.config.AcceptReorderWindow, vm.config.AcceptReorderTimeout.Duration)
 	vm.metadataDB = prefixdb.New(metadataPrefix, vm.db)
 	// Note warpDB is not part of versiondb because it is not necessary
 	// that warp signatures are committed to the database atomically with
@@ -383,6 +419,11 @@ func (vm *VM) Initialize(
 	// gas price to prevent so transactions and blocks all use the correct fees
 	vm.ethConfig.RPCGasCap = vm.config.RPCGasCap
 	vm.ethConfig.RPCEVMTimeout = vm.config.APIMaxDuration.Duration
+	vm.ethConfig.RPCGasStatsBlockCap = vm.config.RPCGasStatsBlockCap
+	vm.ethConfig.RPCEstimateGasMaxIterations = vm.config.RPCEstimateGasMaxIterations
+	vm.ethConfig.TraceConcurrencyLimit = vm.config.TraceConcurrencyLimit
+	vm.ethConfig.TraceQueueTimeout = vm.config.TraceQueueTimeout.Duration
+	vm.ethConfig.TraceMemoryLimit = vm.config.TraceMemoryLimit
 	vm.ethConfig.RPCTxFeeCap = vm.config.RPCTxFeeCap
 
 	vm.ethConfig.TxPool.Locals = vm.config.PriorityRegossipAddresses
@@ -395,6 +436,12 @@ func (vm *VM) Initialize(
 	vm.ethConfig.TxPool.GlobalSlots = vm.config.TxPoolGlobalSlots
 	vm.ethConfig.TxPool.AccountQueue = vm.config.TxPoolAccountQueue
 	vm.ethConfig.TxPool.GlobalQueue = vm.config.TxPoolGlobalQueue
+	vm.ethConfig.TxPool.QueueGracePeriod = vm.config.TxPoolQueueGracePeriod.Duration
+	vm.ethConfig.TxPool.DustThreshold = vm.config.TxPoolDustThreshold
+	vm.ethConfig.TxPool.DustExemptContracts = vm.config.TxPoolDustExemptContracts
+	vm.ethConfig.TxPool.AccountPendingLimit = vm.config.TxPoolAccountPendingLimit
+	vm.ethConfig.TxPool.BackPressureThreshold = vm.config.TxPoolBackPressureThreshold
+	vm.ethConfig.TxPool.StrictIntrinsicGas = vm.config.TxPoolIntrinsicGasValidation == txPoolIntrinsicGasStrict
 
 	vm.ethConfig.AllowUnfinalizedQueries = vm.config.AllowUnfinalizedQueries
 	vm.ethConfig.AllowUnprotectedTxs = vm.config.AllowUnprotectedTxs
@@ -406,6 +453,7 @@ func (vm *VM) Initialize(
 	vm.ethConfig.TrieCleanRejournal = vm.config.TrieCleanRejournal.Duration
 	vm.ethConfig.TrieDirtyCache = vm.config.TrieDirtyCache
 	vm.ethConfig.TrieDirtyCommitTarget = vm.config.TrieDirtyCommitTarget
+	vm.ethConfig.TriePinnedCache = vm.config.TriePinnedCache
 	vm.ethConfig.SnapshotCache = vm.config.SnapshotCache
 	vm.ethConfig.AcceptorQueueLimit = vm.config.AcceptorQueueLimit
 	vm.ethConfig.PopulateMissingTries = vm.config.PopulateMissingTries
@@ -413,7 +461,7 @@ func (vm *VM) Initialize(
 	vm.ethConfig.AllowMissingTries = vm.config.AllowMissingTries
 	vm.ethConfig.SnapshotDelayInit = vm.config.StateSyncEnabled
 	vm.ethConfig.SnapshotWait = vm.config.SnapshotWait
-	vm.ethConfig.SnapshotVerify = vm.config.SnapshotVerify
+	vm.ethConfig.SnapshotVerify, vm.ethConfig.SnapshotVerifySampleRate = vm.config.snapshotVerification()
 	vm.ethConfig.OfflinePruning = vm.config.OfflinePruning
 	vm.ethConfig.OfflinePruningBloomFilterSize = vm.config.OfflinePruningBloomFilterSize
 	vm.ethConfig.OfflinePruningDataDirectory = vm.config.OfflinePruningDataDirectory
@@ -421,6 +469,28 @@ func (vm *VM) Initialize(
 	vm.ethConfig.SkipUpgradeCheck = vm.config.SkipUpgradeCheck
 	vm.ethConfig.AcceptedCacheSize = vm.config.AcceptedCacheSize
 	vm.ethConfig.TxLookupLimit = vm.config.TxLookupLimit
+	vm.ethConfig.MaxReorgDepth = vm.config.MaxReorgDepth
+	vm.ethConfig.StateIntegrityCheckDepth = vm.config.StateIntegrityCheckDepth
+	vm.ethConfig.StateIntegrityCheckHalt = vm.config.StateIntegrityCheckHalt
+	vm.ethConfig.AcceptedIndexBatchBlocks = vm.config.AcceptedIndexBatchBlocks
+	vm.ethConfig.AcceptedIndexFlushInterval = vm.config.AcceptedIndexFlushInterval.Duration
+	vm.ethConfig.SpeculativeExecutionLimit = vm.config.SpeculativeExecutionLimit
+	vm.ethConfig.SnapshotCheckpointKeys = vm.config.SnapshotCheckpointKeys
+	vm.ethConfig.SnapshotCheckpointInterval = vm.config.SnapshotCheckpointInterval.Duration
+	vm.ethConfig.SnapshotRecoveryLimit = vm.config.SnapshotRecoveryLimit
+	vm.ethConfig.SnapshotRepairGaps = vm.config.SnapshotRepairGaps
+	vm.ethConfig.SnapshotGenerationRateLimit = vm.config.SnapshotGenerationRateLimit
+	vm.ethConfig.BlockExecutionBudget = vm.config.BlockExecutionBudget.Duration
+	vm.ethConfig.VerificationPipelineWindow = vm.config.VerificationPipelineWindow
+	vm.ethConfig.StatePrewarmLimit = vm.config.StatePrewarmLimit
+	if percentage := g.Config.BaseFeeBurnPercentage(); percentage > 0 {
+		vm.ethConfig.RewardDistributor = dummy.NewBaseFeeBurner(percentage)
+	}
+	vm.ethConfig.HistoricalStateWindow = vm.config.HistoricalStateWindow
+	vm.ethConfig.OpcodeMetrics = vm.config.OpcodeMetricsEnabled
+	vm.ethConfig.GPO.WarmupBlocks = vm.config.GasPriceWarmupBlocks
+	vm.ethConfig.GPO.PersistHistory = vm.config.GasPricePersistHistory
+	vm.ethConfig.GPO.MaxHistoryAge = vm.config.GasPriceHistoryMaxAge.Duration
 
 	// Create directory for offline pruning
 	if len(vm.ethConfig.OfflinePruningDataDirectory) != 0 {
@@ -439,6 +509,9 @@ func (vm *VM) Initialize(
 		log.Info("Config has not specified any coinbase address. Defaulting to the blackhole address.")
 		vm.ethConfig.Miner.Etherbase = constants.BlackholeAddr
 	}
+	vm.ethConfig.Miner.BuildTimeBudget = vm.config.BuildBlockTimeBudget.Duration
+	vm.ethConfig.Miner.SystemTxSenders = vm.config.SystemTxSenders
+	vm.ethConfig.Miner.SystemTxGasReserve = vm.config.SystemTxGasReserve
 
 	vm.chainConfig = g.Config
 	vm.networkID = vm.ethConfig.NetworkId
@@ -461,6 +534,9 @@ func (vm *VM) Initialize(
 	vm.validators = p2p.NewValidators(p2pNetwork.Peers, vm.ctx.Log, vm.ctx.SubnetID, vm.ctx.ValidatorState, maxValidatorSetStaleness)
 	vm.networkCodec = message.Codec
 	vm.Network = peer.NewNetwork(p2pNetwork, appSender, vm.networkCodec, message.CrossChainCodec, chainCtx.NodeID, vm.config.MaxOutboundActiveRequests, vm.config.MaxOutboundActiveCrossChainRequests)
+	if vm.config.StateSyncArchivePeers {
+		vm.Network.EnableArchivePeerDiscovery()
+	}
 	vm.client = peer.NewNetworkClient(vm.Network)
 
 	// Initialize warp backend
@@ -468,26 +544,37 @@ func (vm *VM) Initialize(
 	for i, hexMsg := range vm.config.WarpOffChainMessages {
 		offchainWarpMessages[i] = []byte(hexMsg)
 	}
-	vm.warpBackend, err = warp.NewBackend(vm.ctx.NetworkID, vm.ctx.ChainID, vm.ctx.WarpSigner, vm, vm.warpDB, warpSignatureCacheSize, offchainWarpMessages)
+	warpSigner := vm.ctx.WarpSigner
+	if vm.ctx.PublicKey != nil {
+		// expose the public key of the node, which the warp signer signs with
+		warpSigner = warp.WithPublicKey(warpSigner, vm.ctx.PublicKey)
+	}
+	vm.warpBackend, err = warp.NewBackend(vm.ctx.NetworkID, vm.ctx.ChainID, warpSigner, vm, vm.warpDB, warpSignatureCacheSize, vm.config.WarpMessageDedupEnabled, offchainWarpMessages)
 	if err != nil {
 		return err
 	}
 
 	// clear warpdb on initialization if config enabled
 	if vm.config.PruneWarpDB {
-		if err := vm.warpBackend.Clear(); err != nil {
+		if err := vm.warpBackend.Prune(vm.config.WarpPruneCompactionThreshold); err != nil {
 			return fmt.Errorf("failed to prune warpDB: %w", err)
 		}
 	}
 
+	vm.blockProfiler = newBlockProfiler(vm.config.BlockProfilerDir)
 	if err := vm.initializeChain(lastAcceptedHash, vm.ethConfig); err != nil {
 		return err
 	}
 
+	vm.warmWarpBlockSignatures(vm.config.WarpSignatureWarmupBlocks)
 	go vm.ctx.Log.RecoverAndPanic(vm.startContinuousProfiler)
 
 	vm.initializeStateSyncServer()
-	return vm.initializeStateSyncClient(lastAcceptedHeight)
+	if err := vm.initializeStateSyncClient(lastAcceptedHeight); err != nil {
+		return err
+	}
+	vm.startBlockHashBackfill()
+	return nil
 }
 
 func (vm *VM) initializeMetrics() error {
@@ -561,29 +648,41 @@ func (vm *VM) initializeStateSyncClient(lastAcceptedHeight uint64) error {
 		}
 	}
 
+	syncClient := statesyncclient.NewClient(
+		&statesyncclient.ClientConfig{
+			NetworkClient:    vm.client,
+			Codec:            vm.networkCodec,
+			Stats:            stats.NewClientSyncerStats(),
+			StateSyncNodeIDs: stateSyncIDs,
+			BlockParser:      vm,
+			ArchiveRouting:   vm.config.StateSyncArchivePeers,
+			MaxResponseSize:  vm.config.StateSyncMaxResponseSize,
+		},
+	)
+	vm.syncClient = syncClient
 	vm.StateSyncClient = NewStateSyncClient(&stateSyncClientConfig{
-		chain: vm.eth,
-		state: vm.State,
-		client: statesyncclient.NewClient(
-			&statesyncclient.ClientConfig{
-				NetworkClient:    vm.client,
-				Codec:            vm.networkCodec,
-				Stats:            stats.NewClientSyncerStats(),
-				StateSyncNodeIDs: stateSyncIDs,
-				BlockParser:      vm,
-			},
-		),
-		enabled:              vm.config.StateSyncEnabled,
-		skipResume:           vm.config.StateSyncSkipResume,
-		stateSyncMinBlocks:   vm.config.StateSyncMinBlocks,
-		stateSyncRequestSize: vm.config.StateSyncRequestSize,
-		lastAcceptedHeight:   lastAcceptedHeight, // TODO clean up how this is passed around
-		chaindb:              vm.chaindb,
-		metadataDB:           vm.metadataDB,
-		acceptedBlockDB:      vm.acceptedBlockDB,
-		db:                   vm.db,
-		toEngine:             vm.toEngine,
+		chain:                  vm.eth,
+		state:                  vm.State,
+		client:                 syncClient,
+		enabled:                vm.config.StateSyncEnabled,
+		skipResume:             vm.config.StateSyncSkipResume,
+		stateSyncMinBlocks:     vm.config.StateSyncMinBlocks,
+		stateSyncRequestSize:   vm.config.StateSyncRequestSize,
+		receiptBackfillEnabled: vm.config.StateSyncReceiptBackfill,
+		lastAcceptedHeight:     lastAcceptedHeight, // TODO clean up how this is passed around
+		chaindb:                vm.chaindb,
+		metadataDB:             vm.metadataDB,
+		acceptedBlockDB:        vm.acceptedBlockDB,
+		db:                     vm.db,
+		toEngine:               vm.toEngine,
 	})
+	if vm.config.StateSyncReceiptBackfill {
+		vm.receiptBackfiller = newReceiptBackfiller(&receiptBackfillerConfig{
+			client:      syncClient,
+			chaindb:     vm.chaindb,
+			requestRate: vm.config.StateSyncReceiptBackfillRequestRate,
+		})
+	}
 
 	// If StateSync is disabled, clear any ongoing summary so that we will not attempt to resume
 	// sync using a snapshot that has been modified by the node running normal operations.
@@ -637,9 +736,11 @@ func (vm *VM) SetState(_ context.Context, state snow.State) error {
 	switch state {
 	case snow.StateSyncing:
 		vm.bootstrapped = false
+		vm.snowState = state
 		return nil
 	case snow.Bootstrapping:
 		vm.bootstrapped = false
+		vm.snowState = state
 		if err := vm.StateSyncClient.Error(); err != nil {
 			return err
 		}
@@ -650,6 +751,7 @@ func (vm *VM) SetState(_ context.Context, state snow.State) error {
 			return fmt.Errorf("failed to initialize block building: %w", err)
 		}
 		vm.bootstrapped = true
+		vm.snowState = state
 		return nil
 	default:
 		return snow.ErrUnknownState
@@ -668,7 +770,7 @@ func (vm *VM) initBlockBuilding() error {
 	vm.builder.awaitSubmittedTxs()
 	vm.Network.SetGossipHandler(NewGossipHandler(vm, gossipStats))
 
-	txPool, err := NewGossipTxPool(vm.txPool)
+	txPool, err := NewGossipTxPool(vm.txPool, vm.blockChain, vm.config.TxGossipMinTip)
 	if err != nil {
 		return err
 	}
@@ -718,6 +820,16 @@ func (vm *VM) initBlockBuilding() error {
 		vm.shutdownWg.Done()
 	}()
 
+	if vm.receiptBackfiller != nil {
+		vm.shutdownWg.Add(1)
+		go func() {
+			defer vm.shutdownWg.Done()
+			if err := vm.receiptBackfiller.Run(ctx); err != nil && ctx.Err() == nil {
+				log.Error("receipt backfill failed", "err", err)
+			}
+		}()
+	}
+
 	return nil
 }
 
@@ -734,7 +846,7 @@ func (vm *VM) setAppRequestHandlers() {
 		},
 	)
 
-	networkHandler := newNetworkHandler(vm.blockChain, vm.chaindb, evmTrieDB, vm.warpBackend, vm.networkCodec)
+	networkHandler := newNetworkHandler(vm.blockChain, vm.chaindb, evmTrieDB, vm.warpBackend, vm.networkCodec, vm.config.WarpSignatureRequestMaxConcurrency, vm.config.WarpSignatureCoalesceWindow.Duration, vm.config.StateSyncServerMaxLeavesPerResponse, vm.config.StateSyncServerProofWorkers, vm.config.StateSyncServerLazyAccountDecoding, !vm.config.Pruning)
 	vm.Network.SetRequestHandler(networkHandler)
 }
 
@@ -825,6 +937,8 @@ func (vm *VM) parseBlock(_ context.Context, b []byte) (snowman.Block, error) {
 	if err := block.syntacticVerify(); err != nil {
 		return nil, fmt.Errorf("syntactic block verification failed: %w", err)
 	}
+	// Start executing the block ahead of its verification.
+	vm.blockChain.Speculate(ethBlock)
 	return block, nil
 }
 
@@ -860,7 +974,20 @@ func (vm *VM) SetPreference(ctx context.Context, blkID ids.ID) error {
 		return fmt.Errorf("failed to set preference to %s: %w", blkID, err)
 	}
 
-	return vm.blockChain.SetPreference(block.(*Block).ethBlock)
+	if err := vm.blockChain.SetPreference(block.(*Block).ethBlock); err != nil {
+		if errors.Is(err, core.ErrReorgTooDeep) {
+			log.Error("Halting: preference change exceeds max reorg depth, operator intervention required", "blkID", blkID, "err", err)
+		}
+		return err
+	}
+	return nil
+}
+
+// LastAccepted returns the ID of the last accepted block. Since [vm.State]
+// tracks the last block passed to Accept, which may be buffered ahead of its
+// parent, it is read from [vm.blockChain] instead.
+func (vm *VM) LastAccepted(context.Context) (ids.ID, error) {
+	return ids.ID(vm.blockChain.LastConsensusAcceptedBlock().Hash()), nil
 }
 
 // VerifyHeightIndex always returns a nil error since the index is maintained by
@@ -903,6 +1030,12 @@ func newHandler(name string, service interface{}) (http.Handler, error) {
 // CreateHandlers makes new http handlers that can handle API calls
 func (vm *VM) CreateHandlers(context.Context) (map[string]http.Handler, error) {
 	handler := rpc.NewServer(vm.config.APIMaxDuration.Duration)
+	slowCallThresholds := make(map[string]time.Duration, len(vm.config.APISlowCallMethodThresholds))
+	for method, threshold := range vm.config.APISlowCallMethodThresholds {
+		slowCallThresholds[method] = threshold.Duration
+	}
+	handler.SetSlowCallThresholds(vm.config.APISlowCallThreshold.Duration, slowCallThresholds)
+	handler.SetSubscriptionLimits(vm.config.WSMaxSubscriptions, vm.config.WSMaxSubscriptionsPerConnection)
 	enabledAPIs := vm.config.EthAPIs()
 	if err := attachEthService(handler, vm.eth.APIs(), enabledAPIs); err != nil {
 		return nil, err
@@ -929,9 +1062,24 @@ func (vm *VM) CreateHandlers(context.Context) (map[string]http.Handler, error) {
 		enabledAPIs = append(enabledAPIs, "snowman")
 	}
 
+	for _, name := range enabledAPIs {
+		if name == "debug" {
+			// Extends the debug namespace registered by the eth service.
+			if err := handler.RegisterName("debug", &DebugAPI{vm}); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
 	if vm.config.WarpAPIEnabled {
 		validatorsState := warpValidators.NewState(vm.ctx)
-		if err := handler.RegisterName("warp", warp.NewAPI(vm.ctx.NetworkID, vm.ctx.SubnetID, vm.ctx.ChainID, validatorsState, vm.warpBackend, vm.client)); err != nil {
+		aggregatorConfig := aggregator.Config{
+			MaxSignatures:  vm.config.WarpAggregationMaxSignatures,
+			MaxConcurrency: vm.config.WarpAggregationMaxConcurrency,
+			RequestTimeout: vm.config.WarpAggregationRequestTimeout.Duration,
+		}
+		if err := handler.RegisterName("warp", warp.NewAPI(vm.ctx.NetworkID, vm.ctx.SubnetID, vm.ctx.ChainID, validatorsState, vm.warpBackend, vm.client, aggregatorConfig)); err != nil {
 			return nil, err
 		}
 		enabledAPIs = append(enabledAPIs, "warp")
@@ -978,6 +1126,29 @@ func (vm *VM) GetCurrentNonce(address common.Address) (uint64, error) {
 	return state.GetNonce(address), nil
 }
 
+// warmWarpBlockSignatures signs up to [count] of the most recently accepted
+// blocks, so that relayers requesting their warp signatures right after
+// startup are served from the cache.
+func (vm *VM) warmWarpBlockSignatures(count int) {
+	if count > warpSignatureCacheSize {
+		count = warpSignatureCacheSize
+	}
+	if count <= 0 {
+		return
+	}
+	start := time.Now()
+	blockIDs := make([]ids.ID, 0, count)
+	for block := vm.blockChain.LastAcceptedBlock(); block != nil && len(blockIDs) < count; {
+		blockIDs = append(blockIDs, ids.ID(block.Hash()))
+		if block.NumberU64() == 0 {
+			break
+		}
+		block = vm.blockChain.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	}
+	warmed := vm.warpBackend.WarmBlockSignatures(blockIDs)
+	log.Info("Warmed warp block signatures", "blocks", warmed, "duration", time.Since(start))
+}
+
 func (vm *VM) startContinuousProfiler() {
 	// If the profiler directory is empty, return immediately
 	// without creating or starting a continuous profiler.
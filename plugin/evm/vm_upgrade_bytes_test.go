@@ -159,76 +159,76 @@ func TestVMUpgradeBytesPrecompile(t *testing.T) {
 	assert.Equal(t, signedTx1.Hash(), txs[0].Hash())
 }
 
-// func TestVMUpgradeBytesOptionalNetworkUpgrades(t *testing.T) {
-// 	tests := []struct {
-// 		name           string
-// 		setTimestampFn func(upgrade *params.UpgradeConfig, timestamp *big.Int)
-// 		checkUpgradeFn func(config *params.ChainConfig, blockTimestamp *big.Int) bool
-// 	}{
-// 		{
-// 			name: "Test",
-// 			setTimestampFn: func(upgrade *params.UpgradeConfig, timestamp *big.Int) {
-// 				upgrade.OptionalNetworkUpgrades.TestTimestamp = timestamp
-// 			},
-// 			checkUpgradeFn: func(config *params.ChainConfig, blockTimestamp *big.Int) bool {
-// 				return config.IsTest(blockTimestamp)
-// 			},
-// 		},
-// 	}
-// 	// Hack: registering metrics uses global variables, so we need to disable metrics here so that we can initialize the VM twice.
-// 	metrics.Enabled = false
-// 	defer func() {
-// 		metrics.Enabled = true
-// 	}()
-// 	for _, test := range tests {
-// 		t.Run(test.name, func(t *testing.T) {
-// 			// Get a json specifying a Network upgrade at genesis
-// 			// to apply as upgradeBytes.
-// 			testTimestamp := time.Unix(10, 0)
-// 			upgradeConfig := &params.UpgradeConfig{
-// 				OptionalNetworkUpgrades: &params.OptionalNetworkUpgrades{},
-// 			}
-// 			test.setTimestampFn(upgradeConfig, big.NewInt(testTimestamp.Unix()))
-// 			upgradeBytesJSON, err := json.Marshal(upgradeConfig)
-// 			require.NoError(t, err)
-
-// 			// initialize the VM with these upgrade bytes
-// 			issuer, vm, dbManager, appSender := GenesisVM(t, true, genesisJSONPreEVM, "", string(upgradeBytesJSON))
-// 			vm.clock.Set(testTimestamp)
-
-// 			// verify upgrade is applied
-// 			require.True(t, test.checkUpgradeFn(vm.chainConfig, big.NewInt(testTimestamp.Unix())))
-
-// 			// Submit a successful transaction and build a block to move the chain head past the EVMTimestamp network upgrade
-// 			tx0 := types.NewTransaction(uint64(0), testEthAddrs[0], big.NewInt(1), 21000, big.NewInt(testMinGasPrice), nil)
-// 			signedTx0, err := types.SignTx(tx0, types.NewEIP155Signer(vm.chainConfig.ChainID), testKeys[0])
-// 			require.NoError(t, err)
-// 			errs := vm.txPool.AddRemotesSync([]*types.Transaction{signedTx0})
-// 			require.NoError(t, errs[0])
-
-// 			issueAndAccept(t, issuer, vm) // make a block
-
-// 			require.NoError(t, vm.Shutdown(context.Background()))
-// 			// VM should not start again without proper upgrade bytes.
-// 			err = vm.Initialize(context.Background(), vm.ctx, dbManager, []byte(genesisJSONPreEVM), []byte{}, []byte{}, issuer, []*commonEng.Fx{}, appSender)
-// 			require.ErrorContains(t, err, fmt.Sprintf("mismatching %s fork block timestamp in database", test.name))
-
-// 			// VM should not start if fork is moved back
-// 			test.setTimestampFn(upgradeConfig, big.NewInt(2))
-// 			upgradeBytesJSON, err = json.Marshal(upgradeConfig)
-// 			require.NoError(t, err)
-// 			err = vm.Initialize(context.Background(), vm.ctx, dbManager, []byte(genesisJSONPreEVM), upgradeBytesJSON, []byte{}, issuer, []*commonEng.Fx{}, appSender)
-// 			require.ErrorContains(t, err, fmt.Sprintf("mismatching %s fork block timestamp in database", test.name))
-
-// 			// VM should not start if fork is moved forward
-// 			test.setTimestampFn(upgradeConfig, big.NewInt(30))
-// 			upgradeBytesJSON, err = json.Marshal(upgradeConfig)
-// 			require.NoError(t, err)
-// 			err = vm.Initialize(context.Background(), vm.ctx, dbManager, []byte(genesisJSONPreEVM), upgradeBytesJSON, []byte{}, issuer, []*commonEng.Fx{}, appSender)
-// 			require.ErrorContains(t, err, fmt.Sprintf("mismatching %s fork block timestamp in database", test.name))
-// 		})
-// 	}
-// }
+func TestVMUpgradeBytesOptionalNetworkUpgrades(t *testing.T) {
+	tests := []struct {
+		name           string
+		setTimestampFn func(upgrade *params.UpgradeConfig, timestamp *uint64)
+		checkUpgradeFn func(config *params.ChainConfig, blockTimestamp uint64) bool
+	}{
+		{
+			name: "RewardDistribution",
+			setTimestampFn: func(upgrade *params.UpgradeConfig, timestamp *uint64) {
+				upgrade.OptionalNetworkUpgrades.RewardDistributionTimestamp = timestamp
+			},
+			checkUpgradeFn: func(config *params.ChainConfig, blockTimestamp uint64) bool {
+				return config.IsRewardDistribution(blockTimestamp)
+			},
+		},
+	}
+	// Hack: registering metrics uses global variables, so we need to disable metrics here so that we can initialize the VM twice.
+	metrics.Enabled = false
+	defer func() {
+		metrics.Enabled = true
+	}()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Get a json specifying a Network upgrade at genesis
+			// to apply as upgradeBytes.
+			testTimestamp := time.Unix(10, 0)
+			upgradeConfig := &params.UpgradeConfig{
+				OptionalNetworkUpgrades: &params.OptionalNetworkUpgrades{},
+			}
+			test.setTimestampFn(upgradeConfig, utils.TimeToNewUint64(testTimestamp))
+			upgradeBytesJSON, err := json.Marshal(upgradeConfig)
+			require.NoError(t, err)
+
+			// initialize the VM with these upgrade bytes
+			issuer, vm, dbManager, appSender := GenesisVM(t, true, genesisJSONPreEVM, "", string(upgradeBytesJSON))
+			vm.clock.Set(testTimestamp)
+
+			// verify upgrade is applied
+			require.True(t, test.checkUpgradeFn(vm.chainConfig, uint64(testTimestamp.Unix())))
+
+			// Submit a successful transaction and build a block to move the chain head past the EVMTimestamp network upgrade
+			tx0 := types.NewTransaction(uint64(0), testEthAddrs[0], big.NewInt(1), 21000, big.NewInt(testMinGasPrice), nil)
+			signedTx0, err := types.SignTx(tx0, types.NewEIP155Signer(vm.chainConfig.ChainID), testKeys[0])
+			require.NoError(t, err)
+			errs := vm.txPool.AddRemotesSync([]*types.Transaction{signedTx0})
+			require.NoError(t, errs[0])
+
+			issueAndAccept(t, issuer, vm) // make a block
+
+			require.NoError(t, vm.Shutdown(context.Background()))
+			// VM should not start again without proper upgrade bytes.
+			err = vm.Initialize(context.Background(), vm.ctx, dbManager, []byte(genesisJSONPreEVM), []byte{}, []byte{}, issuer, []*commonEng.Fx{}, appSender)
+			require.ErrorContains(t, err, fmt.Sprintf("mismatching %s fork block timestamp in database", test.name))
+
+			// VM should not start if fork is moved back
+			test.setTimestampFn(upgradeConfig, utils.NewUint64(2))
+			upgradeBytesJSON, err = json.Marshal(upgradeConfig)
+			require.NoError(t, err)
+			err = vm.Initialize(context.Background(), vm.ctx, dbManager, []byte(genesisJSONPreEVM), upgradeBytesJSON, []byte{}, issuer, []*commonEng.Fx{}, appSender)
+			require.ErrorContains(t, err, fmt.Sprintf("mismatching %s fork block timestamp in database", test.name))
+
+			// VM should not start if fork is moved forward
+			test.setTimestampFn(upgradeConfig, utils.NewUint64(30))
+			upgradeBytesJSON, err = json.Marshal(upgradeConfig)
+			require.NoError(t, err)
+			err = vm.Initialize(context.Background(), vm.ctx, dbManager, []byte(genesisJSONPreEVM), upgradeBytesJSON, []byte{}, issuer, []*commonEng.Fx{}, appSender)
+			require.ErrorContains(t, err, fmt.Sprintf("mismatching %s fork block timestamp in database", test.name))
+		})
+	}
+}
 
 func TestMandatoryUpgradesEnforced(t *testing.T) {
 	// make genesis w/ fork at block 5
@@ -598,7 +598,7 @@ func TestMessageSignatureRequestsToVM(t *testing.T) {
 	require.NoError(t, err)
 
 	// Add the known message and get its signature to confirm.
-	err = vm.warpBackend.AddMessage(warpMessage)
+	err = vm.warpBackend.AddMessage(warpMessage, 1)
 	require.NoError(t, err)
 	signature, err := vm.warpBackend.GetMessageSignature(warpMessage.ID())
 	require.NoError(t, err)
@@ -606,6 +606,7 @@ func TestMessageSignatureRequestsToVM(t *testing.T) {
 	tests := map[string]struct {
 		messageID        ids.ID
 		expectedResponse [bls.SignatureLen]byte
+		expectedStatus   message.SignatureStatus
 	}{
 		"known": {
 			messageID:        warpMessage.ID(),
@@ -614,6 +615,7 @@ func TestMessageSignatureRequestsToVM(t *testing.T) {
 		"unknown": {
 			messageID:        ids.GenerateTestID(),
 			expectedResponse: [bls.SignatureLen]byte{},
+			expectedStatus:   message.SignatureUnknown,
 		},
 	}
 
@@ -625,6 +627,7 @@ func TestMessageSignatureRequestsToVM(t *testing.T) {
 			_, err := message.Codec.Unmarshal(responseBytes, &response)
 			require.NoError(t, err)
 			require.Equal(t, test.expectedResponse, response.Signature)
+			require.Equal(t, test.expectedStatus, response.Status)
 
 			return nil
 		}
@@ -662,6 +665,7 @@ func TestBlockSignatureRequestsToVM(t *testing.T) {
 	tests := map[string]struct {
 		blockID          ids.ID
 		expectedResponse [bls.SignatureLen]byte
+		expectedStatus   message.SignatureStatus
 	}{
 		"known": {
 			blockID:          lastAcceptedID,
@@ -670,6 +674,7 @@ func TestBlockSignatureRequestsToVM(t *testing.T) {
 		"unknown": {
 			blockID:          ids.GenerateTestID(),
 			expectedResponse: [bls.SignatureLen]byte{},
+			expectedStatus:   message.SignatureUnknown,
 		},
 	}
 
@@ -681,6 +686,7 @@ func TestBlockSignatureRequestsToVM(t *testing.T) {
 			_, err := message.Codec.Unmarshal(responseBytes, &response)
 			require.NoError(t, err)
 			require.Equal(t, test.expectedResponse, response.Signature)
+			require.Equal(t, test.expectedStatus, response.Status)
 
 			return nil
 		}
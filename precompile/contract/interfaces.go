@@ -62,6 +62,10 @@ type ConfigurationBlockContext interface {
 
 type BlockContext interface {
 	ConfigurationBlockContext
+	// GetBlockHash returns the hash of the ancestor block at [number], or the
+	// empty hash if [number] is not below the current block or the block is
+	// not available.
+	GetBlockHash(number uint64) common.Hash
 	// GetResults returns an arbitrary byte array result of verifying the predicates
 	// of the given transaction, precompile address pair.
 	GetPredicateResults(txHash common.Hash, precompileAddress common.Address) []byte
@@ -37,6 +37,20 @@ func (m *MockBlockContext) EXPECT() *MockBlockContextMockRecorder {
 	return m.recorder
 }
 
+// GetBlockHash mocks base method.
+func (m *MockBlockContext) GetBlockHash(arg0 uint64) common.Hash {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBlockHash", arg0)
+	ret0, _ := ret[0].(common.Hash)
+	return ret0
+}
+
+// GetBlockHash indicates an expected call of GetBlockHash.
+func (mr *MockBlockContextMockRecorder) GetBlockHash(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlockHash", reflect.TypeOf((*MockBlockContext)(nil).GetBlockHash), arg0)
+}
+
 // GetPredicateResults mocks base method.
 func (m *MockBlockContext) GetPredicateResults(arg0 common.Hash, arg1 common.Address) []byte {
 	m.ctrl.T.Helper()
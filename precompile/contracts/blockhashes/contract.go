@@ -0,0 +1,110 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package blockhashes
+
+import (
+	_ "embed"
+	"fmt"
+	"math/big"
+
+	"github.com/luxdefi/evm/accounts/abi"
+	"github.com/luxdefi/evm/precompile/contract"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	// GetBlockHashGasCost is the cost of looking up a block hash in the block
+	// store, based on the cost of a cold account access since both read the
+	// database.
+	GetBlockHashGasCost uint64 = 2_600
+
+	// HistoryWindow is the number of most recent blocks whose hashes are
+	// returned by getBlockHash. State sync fetches as many parents of the
+	// block it syncs to, so that every node has the hashes of the window and
+	// the result of getBlockHash does not depend on how a node was synced.
+	HistoryWindow uint64 = 8192
+)
+
+var (
+	// BlockHashesRawABI contains the raw ABI of the BlockHashes contract.
+	//go:embed contract.abi
+	BlockHashesRawABI string
+
+	BlockHashesABI = contract.ParseABI(BlockHashesRawABI)
+
+	BlockHashesPrecompile = createBlockHashesPrecompile()
+)
+
+// PackGetBlockHash packs [blockNumber] into the appropriate arguments for getBlockHash.
+// the packed bytes include selector (first 4 func signature bytes).
+func PackGetBlockHash(blockNumber *big.Int) ([]byte, error) {
+	return BlockHashesABI.Pack("getBlockHash", blockNumber)
+}
+
+// UnpackGetBlockHashInput attempts to unpack [input] into the block number argument
+// assumes that [input] does not include selector (omits first 4 func signature bytes)
+func UnpackGetBlockHashInput(input []byte) (*big.Int, error) {
+	res, err := BlockHashesABI.UnpackInput("getBlockHash", input, false)
+	if err != nil {
+		return nil, err
+	}
+	return *abi.ConvertType(res[0], new(*big.Int)).(**big.Int), nil
+}
+
+// PackGetBlockHashOutput attempts to pack given [blockHash] of type common.Hash
+// to conform the ABI outputs.
+func PackGetBlockHashOutput(blockHash common.Hash) ([]byte, error) {
+	return BlockHashesABI.PackOutput("getBlockHash", blockHash)
+}
+
+// getBlockHash returns the hash of the block with the given number, which may
+// be older than the 256 blocks available to BLOCKHASH. Returns the empty hash
+// for the current block, future blocks and blocks older than [HistoryWindow].
+func getBlockHash(accessibleState contract.AccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+	if remainingGas, err = contract.DeductGas(suppliedGas, GetBlockHashGasCost); err != nil {
+		return nil, 0, err
+	}
+	blockNumber, err := UnpackGetBlockHashInput(input)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+
+	var hash common.Hash
+	if blockNumber.IsUint64() {
+		blockContext := accessibleState.GetBlockContext()
+		number, current := blockNumber.Uint64(), blockContext.Number().Uint64()
+		if number < current && current-number <= HistoryWindow {
+			hash = blockContext.GetBlockHash(number)
+		}
+	}
+	packedOutput, err := PackGetBlockHashOutput(hash)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	return packedOutput, remainingGas, nil
+}
+
+// createBlockHashesPrecompile returns a StatefulPrecompiledContract with the
+// read only getBlockHash function.
+func createBlockHashesPrecompile() contract.StatefulPrecompiledContract {
+	var functions []*contract.StatefulPrecompileFunction
+
+	abiFunctionMap := map[string]contract.RunStatefulPrecompileFunc{
+		"getBlockHash": getBlockHash,
+	}
+
+	for name, function := range abiFunctionMap {
+		method, ok := BlockHashesABI.Methods[name]
+		if !ok {
+			panic(fmt.Errorf("given method (%s) does not exist in the ABI", name))
+		}
+		functions = append(functions, contract.NewStatefulPrecompileFunction(method.ID, function))
+	}
+	// Construct the contract with no fallback function.
+	statefulContract, err := contract.NewStatefulPrecompileContract(nil, functions)
+	if err != nil {
+		panic(err)
+	}
+	return statefulContract
+}
@@ -0,0 +1,174 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package blockhashes
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/luxdefi/evm/core/state"
+	"github.com/luxdefi/evm/precompile/contract"
+	"github.com/luxdefi/evm/precompile/testutils"
+	"github.com/luxdefi/evm/vmerrs"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testBlockHash = common.HexToHash("0x01")
+
+	tests = map[string]testutils.PrecompileTest{
+		"get block hash": {
+			InputFn: func(t testing.TB) []byte {
+				input, err := PackGetBlockHash(big.NewInt(1))
+				require.NoError(t, err)
+				return input
+			},
+			SuppliedGas: GetBlockHashGasCost,
+			ReadOnly:    false,
+			SetupBlockContext: func(mbc *contract.MockBlockContext) {
+				mbc.EXPECT().Number().Return(big.NewInt(2))
+				mbc.EXPECT().GetBlockHash(uint64(1)).Return(testBlockHash)
+			},
+			ExpectedRes: func() []byte {
+				res, err := PackGetBlockHashOutput(testBlockHash)
+				if err != nil {
+					panic(err)
+				}
+				return res
+			}(),
+		},
+		"get block hash readOnly": {
+			InputFn: func(t testing.TB) []byte {
+				input, err := PackGetBlockHash(big.NewInt(1))
+				require.NoError(t, err)
+				return input
+			},
+			SuppliedGas: GetBlockHashGasCost,
+			ReadOnly:    true,
+			SetupBlockContext: func(mbc *contract.MockBlockContext) {
+				mbc.EXPECT().Number().Return(big.NewInt(2))
+				mbc.EXPECT().GetBlockHash(uint64(1)).Return(testBlockHash)
+			},
+			ExpectedRes: func() []byte {
+				res, err := PackGetBlockHashOutput(testBlockHash)
+				if err != nil {
+					panic(err)
+				}
+				return res
+			}(),
+		},
+		"get unavailable block hash": {
+			InputFn: func(t testing.TB) []byte {
+				input, err := PackGetBlockHash(big.NewInt(100))
+				require.NoError(t, err)
+				return input
+			},
+			SuppliedGas: GetBlockHashGasCost,
+			ReadOnly:    true,
+			SetupBlockContext: func(mbc *contract.MockBlockContext) {
+				mbc.EXPECT().Number().Return(big.NewInt(200))
+				mbc.EXPECT().GetBlockHash(uint64(100)).Return(common.Hash{})
+			},
+			ExpectedRes: func() []byte {
+				res, err := PackGetBlockHashOutput(common.Hash{})
+				if err != nil {
+					panic(err)
+				}
+				return res
+			}(),
+		},
+		"get block hash of current block": {
+			InputFn: func(t testing.TB) []byte {
+				input, err := PackGetBlockHash(big.NewInt(2))
+				require.NoError(t, err)
+				return input
+			},
+			SuppliedGas: GetBlockHashGasCost,
+			ReadOnly:    true,
+			SetupBlockContext: func(mbc *contract.MockBlockContext) {
+				mbc.EXPECT().Number().Return(big.NewInt(2))
+			},
+			ExpectedRes: func() []byte {
+				res, err := PackGetBlockHashOutput(common.Hash{})
+				if err != nil {
+					panic(err)
+				}
+				return res
+			}(),
+		},
+		"get block hash at history window": {
+			InputFn: func(t testing.TB) []byte {
+				input, err := PackGetBlockHash(big.NewInt(1))
+				require.NoError(t, err)
+				return input
+			},
+			SuppliedGas: GetBlockHashGasCost,
+			ReadOnly:    true,
+			SetupBlockContext: func(mbc *contract.MockBlockContext) {
+				mbc.EXPECT().Number().Return(new(big.Int).SetUint64(HistoryWindow + 1))
+				mbc.EXPECT().GetBlockHash(uint64(1)).Return(testBlockHash)
+			},
+			ExpectedRes: func() []byte {
+				res, err := PackGetBlockHashOutput(testBlockHash)
+				if err != nil {
+					panic(err)
+				}
+				return res
+			}(),
+		},
+		"get block hash before history window": {
+			InputFn: func(t testing.TB) []byte {
+				input, err := PackGetBlockHash(big.NewInt(1))
+				require.NoError(t, err)
+				return input
+			},
+			SuppliedGas: GetBlockHashGasCost,
+			ReadOnly:    true,
+			SetupBlockContext: func(mbc *contract.MockBlockContext) {
+				mbc.EXPECT().Number().Return(new(big.Int).SetUint64(HistoryWindow + 2))
+			},
+			ExpectedRes: func() []byte {
+				res, err := PackGetBlockHashOutput(common.Hash{})
+				if err != nil {
+					panic(err)
+				}
+				return res
+			}(),
+		},
+		"get block hash above uint64": {
+			InputFn: func(t testing.TB) []byte {
+				blockNumber := new(big.Int).Lsh(common.Big1, 64)
+				input, err := PackGetBlockHash(blockNumber)
+				require.NoError(t, err)
+				return input
+			},
+			SuppliedGas:       GetBlockHashGasCost,
+			ReadOnly:          true,
+			SetupBlockContext: func(*contract.MockBlockContext) {},
+			ExpectedRes: func() []byte {
+				res, err := PackGetBlockHashOutput(common.Hash{})
+				if err != nil {
+					panic(err)
+				}
+				return res
+			}(),
+		},
+		"get block hash insufficient gas": {
+			InputFn: func(t testing.TB) []byte {
+				input, err := PackGetBlockHash(big.NewInt(1))
+				require.NoError(t, err)
+				return input
+			},
+			SuppliedGas:       GetBlockHashGasCost - 1,
+			ReadOnly:          true,
+			SetupBlockContext: func(*contract.MockBlockContext) {},
+			ExpectedErr:       vmerrs.ErrOutOfGas.Error(),
+		},
+	}
+)
+
+func TestBlockHashes(t *testing.T) {
+	testutils.RunPrecompileTests(t, Module, state.NewTestStateDB, tests)
+}
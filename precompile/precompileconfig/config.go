@@ -59,7 +59,8 @@ type SharedMemoryWriter interface {
 }
 
 type WarpMessageWriter interface {
-	AddMessage(unsignedMessage *warp.UnsignedMessage) error
+	// AddMessage adds [unsignedMessage] sent by the block at [height].
+	AddMessage(unsignedMessage *warp.UnsignedMessage, height uint64) error
 }
 
 // AcceptContext defines the context passed in to a precompileconfig's Accepter
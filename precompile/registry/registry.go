@@ -18,6 +18,8 @@ import (
 	_ "github.com/luxdefi/evm/precompile/contracts/rewardmanager"
 
 	_ "github.com/luxdefi/evm/x/warp"
+
+	_ "github.com/luxdefi/evm/precompile/contracts/blockhashes"
 	// ADD YOUR PRECOMPILE HERE
 	// _ "github.com/luxdefi/evm/precompile/contracts/yourprecompile"
 )
@@ -40,5 +42,6 @@ import (
 // FeeManagerAddress                = common.HexToAddress("0x0200000000000000000000000000000000000003")
 // RewardManagerAddress             = common.HexToAddress("0x0200000000000000000000000000000000000004")
 // WarpAddress                      = common.HexToAddress("0x0200000000000000000000000000000000000005")
+// BlockHashesAddress               = common.HexToAddress("0x0200000000000000000000000000000000000006")
 // ADD YOUR PRECOMPILE HERE
 // {YourPrecompile}Address          = common.HexToAddress("0x03000000000000000000000000000000000000??")
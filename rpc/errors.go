@@ -71,6 +71,7 @@ const (
 	errcodeDefault                  = -32000
 	errcodeNotificationsUnsupported = -32001
 	errcodeTimeout                  = -32002
+	errcodeSubscriptionLimit        = -32005
 	errcodePanic                    = -32603
 	errcodeMarshalError             = -32603
 )
@@ -75,6 +75,7 @@ type handler struct {
 
 	subLock    sync.Mutex
 	serverSubs map[ID]*Subscription
+	subSlots   int // subscriptions open or being created, if limited
 
 	deadlineContext time.Duration // limits execution after some time.Duration
 	limiter         *rate.Limiter
@@ -350,8 +351,12 @@ func (h *handler) addSubscriptions(nn []*Notifier) {
 
 	for _, n := range nn {
 		if sub := n.takeSubscription(); sub != nil {
+			sub.limits = n.limits
 			h.serverSubs[sub.ID] = sub
+		} else {
+			h.releaseSubscription(n.limits)
 		}
+		n.limits = nil
 	}
 }
 
@@ -364,6 +369,7 @@ func (h *handler) cancelServerSubscriptions(err error) {
 		s.err <- err
 		close(s.err)
 		delete(h.serverSubs, id)
+		h.releaseSubscription(s.limits)
 	}
 }
 
@@ -564,10 +570,12 @@ func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage
 		} else {
 			successfulRequestGauge.Inc(1)
 		}
-		rpcServingTimer.UpdateSince(start)
+		elapsed := time.Since(start)
+		rpcServingTimer.Update(elapsed)
 		if metrics.EnabledExpensive {
-			updateServeTimeHistogram(msg.Method, answer.Error == nil, time.Since(start))
+			updateServeTimeHistogram(msg.Method, answer.Error == nil, elapsed)
 		}
+		h.logSlowCall(msg, elapsed)
 	}
 	return answer
 }
@@ -602,6 +610,9 @@ func (h *handler) handleSubscribe(cp *callProc, msg *jsonrpcMessage) *jsonrpcMes
 
 	// Install notifier in context so the subscription handler can find it.
 	n := &Notifier{h: h, namespace: namespace}
+	if err := h.reserveSubscription(n); err != nil {
+		return msg.errorResponse(err)
+	}
 	cp.notifiers = append(cp.notifiers, n)
 	ctx := context.WithValue(cp.ctx, notifierKey{}, n)
 
@@ -628,6 +639,7 @@ func (h *handler) unsubscribe(ctx context.Context, id ID) (bool, error) {
 	}
 	close(s.err)
 	delete(h.serverSubs, id)
+	h.releaseSubscription(s.limits)
 	return true, nil
 }
 
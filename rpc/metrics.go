@@ -42,6 +42,14 @@ var (
 	serveTimeHistName = "rpc/duration"
 
 	rpcServingTimer = metrics.NewRegisteredTimer("rpc/duration/all", nil)
+
+	// rpcSlowCallCounter counts the calls taking longer than their slow call
+	// threshold.
+	rpcSlowCallCounter = metrics.NewRegisteredCounter("rpc/slow", nil)
+
+	// rpcSubscriptionRejectedCounter counts the subscriptions rejected by a
+	// subscription limit.
+	rpcSubscriptionRejectedCounter = metrics.NewRegisteredCounter("rpc/subscriptions/rejected", nil)
 )
 
 // updateServeTimeHistogram tracks the serving time of a remote RPC call.
@@ -46,8 +46,10 @@ var (
 )
 
 type serviceRegistry struct {
-	mu       sync.Mutex
-	services map[string]service
+	mu        sync.Mutex
+	services  map[string]service
+	slowCalls *slowCallThresholds
+	subLimits *subscriptionLimits
 }
 
 // service represents a registered object.
@@ -0,0 +1,68 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package rpc
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// slowCallThresholds configures which calls are logged as slow.
+type slowCallThresholds struct {
+	threshold time.Duration            // default threshold, 0 disables logging
+	overrides map[string]time.Duration // per-method thresholds, 0 disables logging
+}
+
+// SetSlowCallThresholds enables logging of the calls served by [s] taking at
+// least [threshold], or the threshold of their method in [overrides] if any.
+// A threshold of 0 disables logging of the calls it applies to.
+func (s *Server) SetSlowCallThresholds(threshold time.Duration, overrides map[string]time.Duration) {
+	thresholds := &slowCallThresholds{
+		threshold: threshold,
+		overrides: make(map[string]time.Duration, len(overrides)),
+	}
+	for method, t := range overrides {
+		thresholds.overrides[method] = t
+	}
+	s.services.mu.Lock()
+	s.services.slowCalls = thresholds
+	s.services.mu.Unlock()
+}
+
+// slowCallThreshold returns the duration from which calls to [method] are
+// logged as slow, 0 if they are not.
+func (r *serviceRegistry) slowCallThreshold(method string) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.slowCalls == nil {
+		return 0
+	}
+	if t, ok := r.slowCalls.overrides[method]; ok {
+		return t
+	}
+	return r.slowCalls.threshold
+}
+
+// logSlowCall logs the call [msg] if it took [elapsed], at least the slow call
+// threshold of its method. Only the sizes of the parameters are logged, since
+// their values may be large or sensitive.
+func (h *handler) logSlowCall(msg *jsonrpcMessage, elapsed time.Duration) {
+	threshold := h.reg.slowCallThreshold(msg.Method)
+	if threshold <= 0 || elapsed < threshold {
+		return
+	}
+	rpcSlowCallCounter.Inc(1)
+
+	var params []json.RawMessage
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		h.log.Warn("Slow RPC call", "method", msg.Method, "elapsed", elapsed, "threshold", threshold, "paramsSize", len(msg.Params))
+		return
+	}
+	sizes := make([]int, len(params))
+	for i, param := range params {
+		sizes[i] = len(param)
+	}
+	h.log.Warn("Slow RPC call", "method", msg.Method, "elapsed", elapsed, "threshold", threshold, "paramSizes", sizes)
+}
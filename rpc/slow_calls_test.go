@@ -0,0 +1,86 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package rpc
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"golang.org/x/exp/slog"
+)
+
+// slowCallRecorder is a slog.Handler recording the slow calls logged.
+type slowCallRecorder struct {
+	lock    sync.Mutex
+	records []slog.Record
+}
+
+func (r *slowCallRecorder) Enabled(context.Context, slog.Level) bool { return true }
+
+func (r *slowCallRecorder) Handle(_ context.Context, record slog.Record) error {
+	if record.Message == "Slow RPC call" {
+		r.lock.Lock()
+		r.records = append(r.records, record.Clone())
+		r.lock.Unlock()
+	}
+	return nil
+}
+
+func (r *slowCallRecorder) WithAttrs([]slog.Attr) slog.Handler { return r }
+
+func (r *slowCallRecorder) WithGroup(string) slog.Handler { return r }
+
+func TestServerSlowCalls(t *testing.T) {
+	recorder := &slowCallRecorder{}
+	root := log.Root()
+	log.SetDefault(log.NewLogger(recorder))
+	defer log.SetDefault(root)
+
+	server := newTestServer()
+	defer server.Stop()
+	server.SetSlowCallThresholds(50*time.Millisecond, map[string]time.Duration{
+		"test_echo": 0,
+	})
+	client := DialInProc(server)
+	defer client.Close()
+
+	// Fast and exempted calls are not logged
+	if err := client.Call(nil, "test_sleep", time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	var result echoResult
+	if err := client.Call(&result, "test_echo", "hello", 1, &echoArgs{"world"}); err != nil {
+		t.Fatal(err)
+	}
+	before := rpcSlowCallCounter.Snapshot().Count()
+	if err := client.Call(nil, "test_sleep", 100*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+	if len(recorder.records) != 1 {
+		t.Fatalf("have %d slow calls logged, want 1", len(recorder.records))
+	}
+	ctx := make(map[string]interface{})
+	recorder.records[0].Attrs(func(attr slog.Attr) bool {
+		ctx[attr.Key] = attr.Value.Any()
+		return true
+	})
+	if method := ctx["method"]; method != "test_sleep" {
+		t.Errorf("have method %v, want test_sleep", method)
+	}
+	if elapsed, _ := ctx["elapsed"].(time.Duration); elapsed < 100*time.Millisecond {
+		t.Errorf("have elapsed %v, want at least 100ms", elapsed)
+	}
+	if sizes, _ := ctx["paramSizes"].([]int); len(sizes) != 1 || sizes[0] != len("100000000") {
+		t.Errorf("have param sizes %v, want [%d]", ctx["paramSizes"], len("100000000"))
+	}
+	if count := rpcSlowCallCounter.Snapshot().Count() - before; count != 1 {
+		t.Errorf("have %d slow calls counted, want 1", count)
+	}
+}
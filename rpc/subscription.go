@@ -46,6 +46,9 @@ var (
 	ErrNotificationsUnsupported = errors.New("notifications not supported")
 	// ErrSubscriptionNotFound is returned when the notification for the given id is not found
 	ErrSubscriptionNotFound = errors.New("subscription not found")
+	// ErrSubscriptionLimitReached is returned when a subscription is rejected
+	// because too many subscriptions are open
+	ErrSubscriptionLimitReached = errors.New("subscription limit reached")
 )
 
 var globalGen = randomIDGenerator()
@@ -103,6 +106,7 @@ func NotifierFromContext(ctx context.Context) (*Notifier, bool) {
 type Notifier struct {
 	h         *handler
 	namespace string
+	limits    *subscriptionLimits // slot reserved for the subscription, if limited
 
 	mu           sync.Mutex
 	sub          *Subscription
@@ -199,7 +203,8 @@ func (n *Notifier) send(sub *Subscription, data json.RawMessage) error {
 type Subscription struct {
 	ID        ID
 	namespace string
-	err       chan error // closed on unsubscribe
+	err       chan error          // closed on unsubscribe
+	limits    *subscriptionLimits // slot reserved for the subscription, if limited
 }
 
 // Err returns a channel that is closed when the client send an unsubscribe request.
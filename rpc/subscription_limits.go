@@ -0,0 +1,88 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package rpc
+
+import (
+	"fmt"
+	"sync"
+)
+
+// subscriptionLimits caps the number of subscriptions open at once.
+type subscriptionLimits struct {
+	maxTotal   int // maximum subscriptions over all connections, 0 for no limit
+	maxPerConn int // maximum subscriptions per connection, 0 for no limit
+
+	mu    sync.Mutex
+	total int // subscriptions open or being created over all connections
+}
+
+// SetSubscriptionLimits caps the number of subscriptions open at once on the
+// connections served by [s] to [maxTotal], and to [maxPerConn] per connection.
+// Subscriptions past a limit are rejected until others are closed. A limit of
+// 0 disables it.
+func (s *Server) SetSubscriptionLimits(maxTotal, maxPerConn int) {
+	s.services.mu.Lock()
+	s.services.subLimits = &subscriptionLimits{
+		maxTotal:   maxTotal,
+		maxPerConn: maxPerConn,
+	}
+	s.services.mu.Unlock()
+}
+
+func (r *serviceRegistry) subscriptionLimits() *subscriptionLimits {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.subLimits
+}
+
+// reserveSubscription reserves a slot for the subscription created by [n], or
+// returns an error if a subscription limit is reached. The slot is freed when
+// the subscription is closed, or when the subscribe call returns if it did not
+// create a subscription.
+func (h *handler) reserveSubscription(n *Notifier) error {
+	h.subLock.Lock()
+	defer h.subLock.Unlock()
+
+	limits := h.reg.subscriptionLimits()
+	if limits == nil {
+		return nil
+	}
+	if limits.maxPerConn > 0 && h.subSlots >= limits.maxPerConn {
+		rpcSubscriptionRejectedCounter.Inc(1)
+		return subscriptionLimitError(fmt.Sprintf("at most %d subscriptions per connection", limits.maxPerConn))
+	}
+
+	limits.mu.Lock()
+	defer limits.mu.Unlock()
+
+	if limits.maxTotal > 0 && limits.total >= limits.maxTotal {
+		rpcSubscriptionRejectedCounter.Inc(1)
+		return subscriptionLimitError(fmt.Sprintf("at most %d subscriptions over all connections", limits.maxTotal))
+	}
+	limits.total++
+	h.subSlots++
+	n.limits = limits
+	return nil
+}
+
+// releaseSubscription frees a slot reserved in [limits], if not nil. Assumes
+// h.subLock is held.
+func (h *handler) releaseSubscription(limits *subscriptionLimits) {
+	if limits == nil {
+		return
+	}
+	h.subSlots--
+
+	limits.mu.Lock()
+	limits.total--
+	limits.mu.Unlock()
+}
+
+func subscriptionLimitError(limit string) error {
+	return &internalServerError{
+		code:    errcodeSubscriptionLimit,
+		message: fmt.Sprintf("%s: %s", ErrSubscriptionLimitReached, limit),
+	}
+}
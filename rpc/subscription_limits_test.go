@@ -0,0 +1,84 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServerSubscriptionLimits(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	server.SetSubscriptionLimits(3, 2)
+
+	subscribe := func(client *Client) (*ClientSubscription, error) {
+		return client.Subscribe(context.Background(), "nftest", make(chan int), "someSubscription", 0, 0)
+	}
+	expectLimitError := func(err error) {
+		t.Helper()
+		var rpcErr Error
+		if !errors.As(err, &rpcErr) || rpcErr.ErrorCode() != errcodeSubscriptionLimit || !strings.Contains(err.Error(), ErrSubscriptionLimitReached.Error()) {
+			t.Fatalf("expected subscription limit error, got %v", err)
+		}
+	}
+
+	client1 := DialInProc(server)
+	defer client1.Close()
+	client2 := DialInProc(server)
+	defer client2.Close()
+
+	// Subscriptions past the limit per connection are rejected.
+	var subs []*ClientSubscription
+	for i := 0; i < 2; i++ {
+		sub, err := subscribe(client1)
+		if err != nil {
+			t.Fatalf("can't subscribe: %v", err)
+		}
+		subs = append(subs, sub)
+	}
+	_, err := subscribe(client1)
+	expectLimitError(err)
+
+	// Subscriptions past the total limit are rejected.
+	if _, err := subscribe(client2); err != nil {
+		t.Fatalf("can't subscribe: %v", err)
+	}
+	_, err = subscribe(client2)
+	expectLimitError(err)
+
+	// Closing a subscription frees a slot.
+	subs[0].Unsubscribe()
+	if _, err := subscribe(client2); err != nil {
+		t.Fatalf("can't subscribe after unsubscribe: %v", err)
+	}
+	_, err = subscribe(client1)
+	expectLimitError(err)
+
+	// Closing a connection frees its slots.
+	client2.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_, err := subscribe(client1)
+		if err == nil {
+			break
+		}
+		expectLimitError(err)
+		if time.Now().After(deadline) {
+			t.Fatal("slots not freed after closing the connection")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	_, err = subscribe(client1)
+	expectLimitError(err)
+
+	// Calls on a connection at its limit are still served.
+	var result int
+	if err := client1.Call(&result, "nftest_echo", 1); err != nil {
+		t.Fatal(err)
+	}
+}
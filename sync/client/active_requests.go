@@ -0,0 +1,112 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package statesyncclient
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/luxdefi/node/ids"
+
+	"github.com/luxdefi/evm/plugin/evm/message"
+)
+
+// ActiveRequest describes a request sent by the client that is awaiting a
+// response.
+type ActiveRequest struct {
+	ID      uint64        `json:"id"`
+	Type    string        `json:"type"`
+	Request string        `json:"request"` // Includes the requested range
+	NodeID  ids.NodeID    `json:"nodeID"`  // Empty if the request was sent to any peer
+	Attempt int           `json:"attempt"`
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+type activeRequest struct {
+	info      ActiveRequest
+	start     time.Time
+	cancel    context.CancelFunc
+	cancelled bool
+}
+
+// activeRequests tracks the requests of the client that are awaiting a
+// response, so that they can be listed and cancelled.
+type activeRequests struct {
+	lock     sync.Mutex
+	nextID   uint64
+	requests map[uint64]*activeRequest
+}
+
+func newActiveRequests() *activeRequests {
+	return &activeRequests{requests: make(map[uint64]*activeRequest)}
+}
+
+// add tracks [request] until remove is called with the returned ID. [cancel]
+// is called if the request is cancelled.
+func (a *activeRequests) add(request message.Request, nodeID ids.NodeID, attempt int, cancel context.CancelFunc) uint64 {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.nextID++
+	a.requests[a.nextID] = &activeRequest{
+		info: ActiveRequest{
+			ID:      a.nextID,
+			Type:    strings.TrimPrefix(fmt.Sprintf("%T", request), "message."),
+			Request: request.String(),
+			NodeID:  nodeID,
+			Attempt: attempt,
+		},
+		start:  time.Now(),
+		cancel: cancel,
+	}
+	return a.nextID
+}
+
+// remove stops tracking the request [id] and returns whether it was
+// cancelled.
+func (a *activeRequests) remove(id uint64) bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	r, ok := a.requests[id]
+	if !ok {
+		return false
+	}
+	delete(a.requests, id)
+	return r.cancelled
+}
+
+// cancelRequest cancels the request [id] and returns whether it was active.
+func (a *activeRequests) cancelRequest(id uint64) bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	r, ok := a.requests[id]
+	if !ok {
+		return false
+	}
+	r.cancelled = true
+	r.cancel()
+	return true
+}
+
+// list returns the active requests ordered by ID.
+func (a *activeRequests) list() []ActiveRequest {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	now := time.Now()
+	requests := make([]ActiveRequest, 0, len(a.requests))
+	for _, r := range a.requests {
+		info := r.info
+		info.Elapsed = now.Sub(r.start)
+		requests = append(requests, info)
+	}
+	sort.Slice(requests, func(i, j int) bool { return requests[i].ID < requests[j].ID })
+	return requests
+}
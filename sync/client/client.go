@@ -23,6 +23,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
 
 	"github.com/luxdefi/evm/core/types"
 	"github.com/luxdefi/evm/ethdb"
@@ -51,6 +52,10 @@ var (
 	errUnmarshalResponse      = errors.New("failed to unmarshal response")
 	errInvalidCodeResponseLen = errors.New("number of code bytes in response does not match requested hashes")
 	errMaxCodeSizeExceeded    = errors.New("max code size exceeded")
+	errTooManyReceipts        = errors.New("response contains receipts of more blocks than requested")
+	errReceiptsRootMismatch   = errors.New("receipts root does not match block header")
+	errStateRootMismatch      = errors.New("state root does not match block header")
+	errInvalidEmptyStorage    = errors.New("empty storage flags do not match the accounts")
 )
 var _ Client = &client{}
 
@@ -67,6 +72,24 @@ type Client interface {
 
 	// GetCode synchronously retrieves code associated with the given hashes
 	GetCode(ctx context.Context, hashes []common.Hash) ([][]byte, error)
+
+	// GetReceipts synchronously retrieves the receipts of the given blocks, which must be
+	// ordered from newest to oldest with each block being the parent of the previous one.
+	// The returned receipts are verified against the receipt root of each block header
+	// and may cover only a prefix of [blocks].
+	GetReceipts(ctx context.Context, blocks []*types.Block) ([]types.Receipts, error)
+
+	// GetSnapshotDiff synchronously retrieves the changes made to the state by [block].
+	// Note: only the state root the diff results in is checked against [block], the diff
+	// itself must be verified with statesync.ApplySnapshotDiff.
+	GetSnapshotDiff(ctx context.Context, block *types.Block) (message.SnapshotDiffResponse, error)
+
+	// ActiveRequests returns the requests that are awaiting a response.
+	ActiveRequests() []ActiveRequest
+
+	// CancelRequest cancels the active request [id], which is then retried,
+	// and returns whether the request was active.
+	CancelRequest(id uint64) bool
 }
 
 // parseResponseFn parses given response bytes in context of specified request
@@ -82,6 +105,9 @@ type client struct {
 	stateSyncNodeIdx uint32
 	stats            stats.ClientSyncerStats
 	blockParser      EthBlockParser
+	activeRequests   *activeRequests
+	archiveRouting   bool
+	maxResponseSize  uint32
 }
 
 type ClientConfig struct {
@@ -90,6 +116,16 @@ type ClientConfig struct {
 	Stats            stats.ClientSyncerStats
 	StateSyncNodeIDs []ids.NodeID
 	BlockParser      EthBlockParser
+
+	// ArchiveRouting sends requests for historical data, such as the receipts
+	// of blocks accepted before the state sync summary, only to peers that
+	// advertised they serve it.
+	ArchiveRouting bool
+
+	// MaxResponseSize is the maximum size in bytes of the data requested in a
+	// single response, for requests whose responses the server can truncate.
+	// Servers clamp it to their own limit (0 means the server's limit).
+	MaxResponseSize uint32
 }
 
 type EthBlockParser interface {
@@ -98,20 +134,34 @@ type EthBlockParser interface {
 
 func NewClient(config *ClientConfig) *client {
 	return &client{
-		networkClient:  config.NetworkClient,
-		codec:          config.Codec,
-		stats:          config.Stats,
-		stateSyncNodes: config.StateSyncNodeIDs,
-		blockParser:    config.BlockParser,
+		networkClient:   config.NetworkClient,
+		codec:           config.Codec,
+		stats:           config.Stats,
+		stateSyncNodes:  config.StateSyncNodeIDs,
+		blockParser:     config.BlockParser,
+		activeRequests:  newActiveRequests(),
+		archiveRouting:  config.ArchiveRouting,
+		maxResponseSize: config.MaxResponseSize,
 	}
 }
 
+func (c *client) ActiveRequests() []ActiveRequest {
+	return c.activeRequests.list()
+}
+
+func (c *client) CancelRequest(id uint64) bool {
+	return c.activeRequests.cancelRequest(id)
+}
+
 // GetLeafs synchronously retrieves leafs as per given [message.LeafsRequest]
 // Retries when:
 // - response bytes could not be unmarshalled to [message.LeafsResponse]
 // - response keys do not correspond to the requested range.
 // - response does not contain a valid merkle proof.
 func (c *client) GetLeafs(ctx context.Context, req message.LeafsRequest) (message.LeafsResponse, error) {
+	if req.MaxResponseSize == 0 {
+		req.MaxResponseSize = c.maxResponseSize
+	}
 	data, err := c.get(ctx, req, parseLeafsResponse)
 	if err != nil {
 		return message.LeafsResponse{}, err
@@ -182,6 +232,22 @@ func parseLeafsResponse(codec codec.Manager, reqIntf message.Request, data []byt
 		return nil, 0, fmt.Errorf("%s due to %w", errInvalidRangeProof, err)
 	}
 
+	// The empty storage flags must match the storage roots of the proven accounts.
+	if leafsRequest.IncludeEmptyStorage && leafsRequest.Account == (common.Hash{}) {
+		if len(leafsResponse.EmptyStorage) != len(leafsResponse.Vals) {
+			return nil, 0, fmt.Errorf("%w: (got %d flags) (expected %d)", errInvalidEmptyStorage, len(leafsResponse.EmptyStorage), len(leafsResponse.Vals))
+		}
+		for i, val := range leafsResponse.Vals {
+			var account types.StateAccount
+			if err := rlp.DecodeBytes(val, &account); err != nil {
+				return nil, 0, fmt.Errorf("%w: invalid account at index %d: %v", errInvalidEmptyStorage, i, err)
+			}
+			if empty := account.Root == types.EmptyRootHash; leafsResponse.EmptyStorage[i] != empty {
+				return nil, 0, fmt.Errorf("%w: flag at index %d is %t for storage root %s", errInvalidEmptyStorage, i, leafsResponse.EmptyStorage[i], account.Root)
+			}
+		}
+	}
+
 	// Set the [More] flag to indicate if there are more leaves to the right of the last key in the response
 	// that needs to be fetched.
 	leafsResponse.More = more
@@ -191,9 +257,10 @@ func parseLeafsResponse(codec codec.Manager, reqIntf message.Request, data []byt
 
 func (c *client) GetBlocks(ctx context.Context, hash common.Hash, height uint64, parents uint16) ([]*types.Block, error) {
 	req := message.BlockRequest{
-		Hash:    hash,
-		Height:  height,
-		Parents: parents,
+		Hash:            hash,
+		Height:          height,
+		Parents:         parents,
+		MaxResponseSize: c.maxResponseSize,
 	}
 
 	data, err := c.get(ctx, req, c.parseBlocks)
@@ -244,15 +311,22 @@ func (c *client) parseBlocks(codec codec.Manager, req message.Request, data []by
 	return blocks, len(blocks), nil
 }
 
+// GetCode requests the code of the remaining hashes again if a response holds
+// only the code of the first ones to stay within the response size.
 func (c *client) GetCode(ctx context.Context, hashes []common.Hash) ([][]byte, error) {
-	req := message.NewCodeRequest(hashes)
+	code := make([][]byte, 0, len(hashes))
+	for len(code) < len(hashes) {
+		req := message.NewCodeRequest(hashes[len(code):])
+		req.MaxResponseSize = c.maxResponseSize
 
-	data, err := c.get(ctx, req, parseCode)
-	if err != nil {
-		return nil, fmt.Errorf("could not get code (%s): %w", req, err)
+		data, err := c.get(ctx, req, parseCode)
+		if err != nil {
+			return nil, fmt.Errorf("could not get code (%s): %w", req, err)
+		}
+		code = append(code, data.([][]byte)...)
 	}
 
-	return data.([][]byte), nil
+	return code, nil
 }
 
 // parseCode validates given object as a code object
@@ -265,7 +339,9 @@ func parseCode(codec codec.Manager, req message.Request, data []byte) (interface
 	}
 
 	codeRequest := req.(message.CodeRequest)
-	if len(response.Data) != len(codeRequest.Hashes) {
+	// Only requests with a response size may be served the code of some of the hashes.
+	if len(response.Data) != len(codeRequest.Hashes) &&
+		(codeRequest.MaxResponseSize == 0 || len(response.Data) == 0 || len(response.Data) > len(codeRequest.Hashes)) {
 		return nil, 0, fmt.Errorf("%w (got %d) (requested %d)", errInvalidCodeResponseLen, len(response.Data), len(codeRequest.Hashes))
 	}
 
@@ -285,6 +361,105 @@ func parseCode(codec codec.Manager, req message.Request, data []byte) (interface
 	return response.Data, totalBytes, nil
 }
 
+func (c *client) GetReceipts(ctx context.Context, blocks []*types.Block) ([]types.Receipts, error) {
+	if len(blocks) == 0 {
+		return nil, nil
+	}
+	req := message.ReceiptsRequest{
+		Hash:            blocks[0].Hash(),
+		Height:          blocks[0].NumberU64(),
+		Parents:         uint16(len(blocks)),
+		MaxResponseSize: c.maxResponseSize,
+	}
+
+	data, err := c.get(ctx, req, parseReceiptsFn(blocks))
+	if err != nil {
+		return nil, fmt.Errorf("could not get receipts (%s) due to %w", req.Hash, err)
+	}
+
+	return data.([]types.Receipts), nil
+}
+
+// parseReceiptsFn returns a parseResponseFn that validates given object as message.ReceiptsResponse
+// assumes req is of type message.ReceiptsRequest for [blocks]
+// returns []types.Receipts as interface{}
+// returns a non-nil error if the request should be retried
+func parseReceiptsFn(blocks []*types.Block) parseResponseFn {
+	return func(codec codec.Manager, req message.Request, data []byte) (interface{}, int, error) {
+		var response message.ReceiptsResponse
+		if _, err := codec.Unmarshal(data, &response); err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", errUnmarshalResponse, err)
+		}
+		if len(response.Receipts) == 0 {
+			return nil, 0, errEmptyResponse
+		}
+		receiptsRequest := req.(message.ReceiptsRequest)
+		if len(response.Receipts) > int(receiptsRequest.Parents) || len(response.Receipts) > len(blocks) {
+			return nil, 0, errTooManyReceipts
+		}
+
+		receipts := make([]types.Receipts, len(response.Receipts))
+		numReceipts := 0
+		for i, receiptsBytes := range response.Receipts {
+			var blockReceipts types.Receipts
+			if err := rlp.DecodeBytes(receiptsBytes, &blockReceipts); err != nil {
+				return nil, 0, fmt.Errorf("%s: %w", errUnmarshalResponse, err)
+			}
+
+			block := blocks[i]
+			if root := types.DeriveSha(blockReceipts, trie.NewStackTrie(nil)); root != block.ReceiptHash() {
+				return nil, 0, fmt.Errorf("%w for block %s: (got %v) (expected %v)", errReceiptsRootMismatch, block.Hash(), root, block.ReceiptHash())
+			}
+			receipts[i] = blockReceipts
+			numReceipts += len(blockReceipts)
+		}
+
+		return receipts, numReceipts, nil
+	}
+}
+
+func (c *client) GetSnapshotDiff(ctx context.Context, block *types.Block) (message.SnapshotDiffResponse, error) {
+	req := message.SnapshotDiffRequest{
+		Hash:   block.Hash(),
+		Height: block.NumberU64(),
+	}
+
+	data, err := c.get(ctx, req, parseSnapshotDiffFn(block))
+	if err != nil {
+		return message.SnapshotDiffResponse{}, fmt.Errorf("could not get snapshot diff (%s) due to %w", req.Hash, err)
+	}
+
+	return data.(message.SnapshotDiffResponse), nil
+}
+
+// parseSnapshotDiffFn returns a parseResponseFn that validates given object as message.SnapshotDiffResponse
+// assumes req is of type message.SnapshotDiffRequest for [block]
+// returns message.SnapshotDiffResponse as interface{}
+// returns a non-nil error if the request should be retried
+func parseSnapshotDiffFn(block *types.Block) parseResponseFn {
+	return func(codec codec.Manager, req message.Request, data []byte) (interface{}, int, error) {
+		if len(data) == 0 {
+			return nil, 0, errEmptyResponse
+		}
+		var response message.SnapshotDiffResponse
+		if _, err := codec.Unmarshal(data, &response); err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", errUnmarshalResponse, err)
+		}
+		if response.Root != block.Root() {
+			return nil, 0, fmt.Errorf("%w for block %s: (got %v) (expected %v)", errStateRootMismatch, block.Hash(), response.Root, block.Root())
+		}
+
+		return response, len(response.Destructs) + len(response.Accounts), nil
+	}
+}
+
+// isHistorical returns whether [request] is for data that pruning nodes may no
+// longer have, so that it should only be served by archive peers.
+func isHistorical(request message.Request) bool {
+	_, ok := request.(message.ReceiptsRequest)
+	return ok
+}
+
 // get submits given request and blockingly returns with either a parsed response object or an error
 // if [ctx] expires before the client can successfully retrieve a valid response.
 // Retries if there is a network error or if the [parseResponseFn] returns an error indicating an invalid response.
@@ -324,19 +499,32 @@ func (c *client) get(ctx context.Context, request message.Request, parseFn parse
 			nodeID   ids.NodeID
 			start    time.Time = time.Now()
 		)
-		if len(c.stateSyncNodes) == 0 {
-			response, nodeID, err = c.networkClient.SendAppRequestAny(ctx, StateSyncVersion, requestBytes)
-		} else {
+		if len(c.stateSyncNodes) > 0 {
 			// get the next nodeID using the nodeIdx offset. If we're out of nodes, loop back to 0
 			// we do this every attempt to ensure we get a different node each time if possible.
 			nodeIdx := atomic.AddUint32(&c.stateSyncNodeIdx, 1)
 			nodeID = c.stateSyncNodes[nodeIdx%uint32(len(c.stateSyncNodes))]
-
-			response, err = c.networkClient.SendAppRequest(ctx, nodeID, requestBytes)
 		}
+		// Each attempt can be cancelled through CancelRequest.
+		attemptCtx, cancel := context.WithCancel(ctx)
+		requestID := c.activeRequests.add(request, nodeID, attempt, cancel)
+		switch {
+		case len(c.stateSyncNodes) == 0 && c.archiveRouting && isHistorical(request):
+			response, nodeID, err = c.networkClient.SendAppRequestAnyArchive(attemptCtx, StateSyncVersion, requestBytes)
+		case len(c.stateSyncNodes) == 0:
+			response, nodeID, err = c.networkClient.SendAppRequestAny(attemptCtx, StateSyncVersion, requestBytes)
+		default:
+			response, err = c.networkClient.SendAppRequest(attemptCtx, nodeID, requestBytes)
+		}
+		cancelled := c.activeRequests.remove(requestID)
+		cancel()
 		metric.UpdateRequestLatency(time.Since(start))
 
-		if err != nil {
+		if cancelled && ctx.Err() == nil {
+			log.Info("request cancelled, retrying", "nodeID", nodeID, "attempt", attempt, "request", request)
+			metric.IncFailed()
+			continue
+		} else if err != nil {
 			ctx := make([]interface{}, 0, 8)
 			if nodeID != ids.EmptyNodeID {
 				ctx = append(ctx, "nodeID", nodeID)
@@ -14,6 +14,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/luxdefi/node/ids"
+	"github.com/luxdefi/node/version"
 
 	"github.com/luxdefi/evm/consensus/dummy"
 	"github.com/luxdefi/evm/core"
@@ -134,6 +135,43 @@ func TestGetCode(t *testing.T) {
 	}
 }
 
+func TestGetCodePartialResponses(t *testing.T) {
+	mockNetClient := &mockNetwork{}
+	stateSyncClient := NewClient(&ClientConfig{
+		NetworkClient:   mockNetClient,
+		Codec:           message.Codec,
+		Stats:           clientstats.NewNoOpStats(),
+		BlockParser:     mockBlockParser,
+		MaxResponseSize: 1,
+	})
+
+	// Each response holds the code of the first hash requested only, so the
+	// client requests the code of the remaining hashes again.
+	codes := [][]byte{[]byte("code 1"), []byte("code 2"), []byte("code 3")}
+	hashes := make([]common.Hash, len(codes))
+	responses := make([][]byte, len(codes))
+	for i, code := range codes {
+		hashes[i] = crypto.Keccak256Hash(code)
+		responseBytes, err := message.Codec.Marshal(message.Version, message.CodeResponse{Data: codes[i : i+1]})
+		if err != nil {
+			t.Fatal(err)
+		}
+		responses[i] = responseBytes
+	}
+	mockNetClient.mockResponses(nil, responses...)
+
+	code, err := stateSyncClient.GetCode(context.Background(), hashes)
+	assert.NoError(t, err)
+	assert.Equal(t, codes, code)
+	assert.EqualValues(t, len(codes), mockNetClient.numCalls)
+
+	// The last request asks for the code of the last hash only, with the
+	// response size.
+	request, err := message.BytesToRequest(message.Codec, mockNetClient.request)
+	assert.NoError(t, err)
+	assert.Equal(t, message.CodeRequest{Hashes: hashes[2:], MaxResponseSize: 1}, request)
+}
+
 func TestGetBlocks(t *testing.T) {
 	// set random seed for deterministic tests
 	rand.Seed(1)
@@ -413,7 +451,7 @@ func TestGetLeafs(t *testing.T) {
 	largeTrieRoot, largeTrieKeys, _ := trie.GenerateTrie(t, trieDB, 100_000, common.HashLength)
 	smallTrieRoot, _, _ := trie.GenerateTrie(t, trieDB, leafsLimit, common.HashLength)
 
-	handler := handlers.NewLeafsRequestHandler(trieDB, nil, message.Codec, handlerstats.NewNoopHandlerStats())
+	handler := handlers.NewLeafsRequestHandler(trieDB, nil, message.Codec, handlerstats.NewNoopHandlerStats(), 0, nil, false)
 	client := NewClient(&ClientConfig{
 		NetworkClient:    &mockNetwork{},
 		Codec:            message.Codec,
@@ -783,7 +821,7 @@ func TestGetLeafsRetries(t *testing.T) {
 	trieDB := trie.NewDatabase(memorydb.New())
 	root, _, _ := trie.GenerateTrie(t, trieDB, 100_000, common.HashLength)
 
-	handler := handlers.NewLeafsRequestHandler(trieDB, nil, message.Codec, handlerstats.NewNoopHandlerStats())
+	handler := handlers.NewLeafsRequestHandler(trieDB, nil, message.Codec, handlerstats.NewNoopHandlerStats(), 0, nil, false)
 	mockNetClient := &mockNetwork{}
 
 	const maxAttempts = 8
@@ -877,3 +915,70 @@ func TestStateSyncNodes(t *testing.T) {
 	assert.Contains(t, mockNetClient.nodesRequested, stateSyncNodes[2])
 	assert.Contains(t, mockNetClient.nodesRequested, stateSyncNodes[3])
 }
+
+// blockingNetwork blocks the first request until its context is cancelled
+// and serves [response] to later requests.
+type blockingNetwork struct {
+	*mockNetwork
+	response []byte
+	started  chan struct{}
+	calls    int
+}
+
+func (b *blockingNetwork) SendAppRequestAny(ctx context.Context, _ *version.Application, _ []byte) ([]byte, ids.NodeID, error) {
+	b.calls++
+	if b.calls == 1 {
+		close(b.started)
+		<-ctx.Done()
+		return nil, ids.EmptyNodeID, ctx.Err()
+	}
+	return b.response, ids.EmptyNodeID, nil
+}
+
+func TestCancelRequest(t *testing.T) {
+	code := []byte("this is the code")
+	codeHash := crypto.Keccak256Hash(code)
+	response, err := message.Codec.Marshal(message.Version, message.CodeResponse{Data: [][]byte{code}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	network := &blockingNetwork{
+		mockNetwork: &mockNetwork{},
+		response:    response,
+		started:     make(chan struct{}),
+	}
+	client := NewClient(&ClientConfig{
+		NetworkClient: network,
+		Codec:         message.Codec,
+		Stats:         clientstats.NewNoOpStats(),
+		BlockParser:   mockBlockParser,
+	})
+
+	type result struct {
+		code [][]byte
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		code, err := client.GetCode(context.Background(), []common.Hash{codeHash})
+		done <- result{code, err}
+	}()
+	<-network.started
+
+	requests := client.ActiveRequests()
+	if !assert.Len(t, requests, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, "CodeRequest", requests[0].Type)
+	assert.Equal(t, ids.EmptyNodeID, requests[0].NodeID)
+	assert.Zero(t, requests[0].Attempt)
+	assert.False(t, client.CancelRequest(requests[0].ID+1))
+
+	// The cancelled request is sent again and succeeds.
+	assert.True(t, client.CancelRequest(requests[0].ID))
+	res := <-done
+	assert.NoError(t, res.err)
+	assert.Equal(t, [][]byte{code}, res.code)
+	assert.Equal(t, 2, network.calls)
+	assert.Empty(t, client.ActiveRequests())
+}
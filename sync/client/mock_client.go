@@ -31,6 +31,11 @@ type MockClient struct {
 	codeReceived   int32
 	blocksHandler  *handlers.BlockRequestHandler
 	blocksReceived int32
+	// receiptsHandler is optional, GetReceipts panics if it is not set.
+	receiptsHandler  *handlers.ReceiptsRequestHandler
+	receiptsReceived int32
+	// snapshotDiffHandler is optional, GetSnapshotDiff panics if it is not set.
+	snapshotDiffHandler *handlers.SnapshotDiffRequestHandler
 	// GetLeafsIntercept is called on every GetLeafs request if set to a non-nil callback.
 	// The returned response will be returned by MockClient to the caller.
 	GetLeafsIntercept func(req message.LeafsRequest, res message.LeafsResponse) (message.LeafsResponse, error)
@@ -47,12 +52,16 @@ func NewMockClient(
 	leafHandler *handlers.LeafsRequestHandler,
 	codesHandler *handlers.CodeRequestHandler,
 	blocksHandler *handlers.BlockRequestHandler,
+	receiptsHandler *handlers.ReceiptsRequestHandler,
+	snapshotDiffHandler *handlers.SnapshotDiffRequestHandler,
 ) *MockClient {
 	return &MockClient{
-		codec:         codec,
-		leafsHandler:  leafHandler,
-		codesHandler:  codesHandler,
-		blocksHandler: blocksHandler,
+		codec:               codec,
+		leafsHandler:        leafHandler,
+		codesHandler:        codesHandler,
+		blocksHandler:       blocksHandler,
+		receiptsHandler:     receiptsHandler,
+		snapshotDiffHandler: snapshotDiffHandler,
 	}
 }
 
@@ -138,6 +147,64 @@ func (ml *MockClient) BlocksReceived() int32 {
 	return atomic.LoadInt32(&ml.blocksReceived)
 }
 
+func (ml *MockClient) GetReceipts(ctx context.Context, blocks []*types.Block) ([]types.Receipts, error) {
+	if ml.receiptsHandler == nil {
+		panic("no receipts handler for mock client")
+	}
+	if len(blocks) == 0 {
+		return nil, nil
+	}
+	request := message.ReceiptsRequest{
+		Hash:    blocks[0].Hash(),
+		Height:  blocks[0].NumberU64(),
+		Parents: uint16(len(blocks)),
+	}
+	response, err := ml.receiptsHandler.OnReceiptsRequest(ctx, ids.GenerateTestNodeID(), 1, request)
+	if err != nil {
+		return nil, err
+	}
+
+	receiptsRes, numReceipts, err := parseReceiptsFn(blocks)(ml.codec, request, response)
+	if err != nil {
+		return nil, err
+	}
+	atomic.AddInt32(&ml.receiptsReceived, int32(numReceipts))
+	return receiptsRes.([]types.Receipts), nil
+}
+
+func (ml *MockClient) ReceiptsReceived() int32 {
+	return atomic.LoadInt32(&ml.receiptsReceived)
+}
+
+func (ml *MockClient) GetSnapshotDiff(ctx context.Context, block *types.Block) (message.SnapshotDiffResponse, error) {
+	if ml.snapshotDiffHandler == nil {
+		panic("no snapshot diff handler for mock client")
+	}
+	request := message.SnapshotDiffRequest{
+		Hash:   block.Hash(),
+		Height: block.NumberU64(),
+	}
+	response, err := ml.snapshotDiffHandler.OnSnapshotDiffRequest(ctx, ids.GenerateTestNodeID(), 1, request)
+	if err != nil {
+		return message.SnapshotDiffResponse{}, err
+	}
+
+	diff, _, err := parseSnapshotDiffFn(block)(ml.codec, request, response)
+	if err != nil {
+		return message.SnapshotDiffResponse{}, err
+	}
+	return diff.(message.SnapshotDiffResponse), nil
+}
+
+// ActiveRequests returns nil as MockClient requests are served synchronously.
+func (ml *MockClient) ActiveRequests() []ActiveRequest {
+	return nil
+}
+
+func (ml *MockClient) CancelRequest(uint64) bool {
+	return false
+}
+
 type testBlockParser struct{}
 
 func (t *testBlockParser) ParseEthBlock(b []byte) (*types.Block, error) {
@@ -40,6 +40,17 @@ func (t *mockNetwork) SendAppRequestAny(ctx context.Context, minVersion *version
 	return response, ids.EmptyNodeID, err
 }
 
+func (t *mockNetwork) SendAppRequestAnyArchive(ctx context.Context, minVersion *version.Application, request []byte) ([]byte, ids.NodeID, error) {
+	if len(t.response) == 0 {
+		return nil, ids.EmptyNodeID, errors.New("no mocked response to return in mockNetwork")
+	}
+
+	t.requestedVersion = minVersion
+
+	response, err := t.processMock(request)
+	return response, ids.EmptyNodeID, err
+}
+
 func (t *mockNetwork) SendAppRequest(ctx context.Context, nodeID ids.NodeID, request []byte) ([]byte, error) {
 	if len(t.response) == 0 {
 		return nil, errors.New("no mocked response to return in mockNetwork")
@@ -78,16 +78,20 @@ type clientSyncerStats struct {
 	atomicTrieLeavesMetric,
 	stateTrieLeavesMetric,
 	codeRequestMetric,
-	blockRequestMetric MessageMetric
+	blockRequestMetric,
+	receiptsRequestMetric,
+	snapshotDiffRequestMetric MessageMetric
 }
 
 // NewClientSyncerStats returns stats for the client syncer
 func NewClientSyncerStats() ClientSyncerStats {
 	return &clientSyncerStats{
-		atomicTrieLeavesMetric: NewMessageMetric("sync_atomic_trie_leaves"),
-		stateTrieLeavesMetric:  NewMessageMetric("sync_state_trie_leaves"),
-		codeRequestMetric:      NewMessageMetric("sync_code"),
-		blockRequestMetric:     NewMessageMetric("sync_blocks"),
+		atomicTrieLeavesMetric:    NewMessageMetric("sync_atomic_trie_leaves"),
+		stateTrieLeavesMetric:     NewMessageMetric("sync_state_trie_leaves"),
+		codeRequestMetric:         NewMessageMetric("sync_code"),
+		blockRequestMetric:        NewMessageMetric("sync_blocks"),
+		receiptsRequestMetric:     NewMessageMetric("sync_receipts"),
+		snapshotDiffRequestMetric: NewMessageMetric("sync_snapshot_diffs"),
 	}
 }
 
@@ -100,6 +104,10 @@ func (c *clientSyncerStats) GetMetric(msgIntf message.Request) (MessageMetric, e
 		return c.codeRequestMetric, nil
 	case message.LeafsRequest:
 		return c.stateTrieLeavesMetric, nil
+	case message.ReceiptsRequest:
+		return c.receiptsRequestMetric, nil
+	case message.SnapshotDiffRequest:
+		return c.snapshotDiffRequestMetric, nil
 	default:
 		return nil, fmt.Errorf("attempted to get metric for invalid request with type %T", msg)
 	}
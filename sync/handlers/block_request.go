@@ -10,6 +10,7 @@ import (
 
 	"github.com/luxdefi/node/codec"
 	"github.com/luxdefi/node/ids"
+	"github.com/luxdefi/node/utils/units"
 
 	"github.com/luxdefi/evm/plugin/evm/message"
 	"github.com/luxdefi/evm/sync/handlers/stats"
@@ -17,9 +18,15 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 )
 
-// parentLimit specifies how many parents to retrieve and send given a starting hash
-// This value overrides any specified limit in blockRequest.Parents if it is greater than this value
-const parentLimit = uint16(64)
+const (
+	// parentLimit specifies how many parents to retrieve and send given a starting hash
+	// This value overrides any specified limit in blockRequest.Parents if it is greater than this value
+	parentLimit = uint16(64)
+
+	// blocksResponseSizeLimit caps the total size of encoded blocks returned in a single response
+	// so that the response stays well within the network message size limit.
+	blocksResponseSizeLimit = 512 * units.KiB
+)
 
 // BlockRequestHandler is a peer.RequestHandler for message.BlockRequest
 // serving requested blocks starting at specified hash
@@ -41,6 +48,8 @@ func NewBlockRequestHandler(blockProvider BlockProvider, codec codec.Manager, ha
 // Never returns error
 // Expects returned errors to be treated as FATAL
 // Returns empty response or subset of requested blocks if ctx expires during fetch
+// Stops once the requested response size, capped at blocksResponseSizeLimit, is reached, but
+// always returns at least one block if available
 // Assumes ctx is active
 func (b *BlockRequestHandler) OnBlockRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, blockRequest message.BlockRequest) ([]byte, error) {
 	startTime := time.Now()
@@ -52,6 +61,8 @@ func (b *BlockRequestHandler) OnBlockRequest(ctx context.Context, nodeID ids.Nod
 		parents = parentLimit
 	}
 	blocks := make([][]byte, 0, parents)
+	sizeLimit := responseSizeLimit(blockRequest.MaxResponseSize, blocksResponseSizeLimit)
+	totalBytes := 0
 
 	// ensure metrics are captured properly on all return paths
 	defer func() {
@@ -84,6 +95,11 @@ func (b *BlockRequestHandler) OnBlockRequest(ctx context.Context, nodeID ids.Nod
 			return nil, nil
 		}
 
+		if len(blocks) > 0 && totalBytes+buf.Len() > sizeLimit {
+			break
+		}
+
+		totalBytes += buf.Len()
 		blocks = append(blocks, buf.Bytes())
 		hash = block.ParentHash()
 		height--
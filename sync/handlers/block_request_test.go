@@ -5,6 +5,7 @@ package handlers
 
 import (
 	"context"
+	"math"
 	"testing"
 
 	"github.com/luxdefi/node/ids"
@@ -207,3 +208,62 @@ func TestBlockRequestHandlerCtxExpires(t *testing.T) {
 		assert.Equal(t, blocks[len(blocks)-i-1].Hash(), block.Hash())
 	}
 }
+
+func TestBlockRequestHandlerResponseSize(t *testing.T) {
+	_, blocks, _, err := core.GenerateChainWithGenesis(&core.Genesis{Config: params.TestChainConfig}, dummy.NewETHFaker(), 32, 0, func(i int, b *core.BlockGen) {})
+	if err != nil {
+		t.Fatal("unexpected error when generating test blockchain", err)
+	}
+	blocksDB := make(map[common.Hash]*types.Block, len(blocks))
+	for _, blk := range blocks {
+		blocksDB[blk.Hash()] = blk
+	}
+	blockProvider := &TestBlockProvider{
+		GetBlockFn: func(hash common.Hash, height uint64) *types.Block {
+			return blocksDB[hash]
+		},
+	}
+	blockBytes, err := rlp.EncodeToBytes(blocks[len(blocks)-1])
+	assert.NoError(t, err)
+	size := len(blockBytes)
+	blockRequestHandler := NewBlockRequestHandler(blockProvider, message.Codec, &stats.MockHandlerStats{})
+
+	tests := map[string]struct {
+		maxResponseSize uint32
+		expectedBlocks  int
+	}{
+		"server_limit_by_default": {
+			expectedBlocks: len(blocks),
+		},
+		"handler_honors_requested_size": {
+			// leave room for the small differences in the size of the blocks
+			maxResponseSize: uint32(3*size + size/2),
+			expectedBlocks:  3,
+		},
+		"handler_returns_first_block_below_requested_size": {
+			maxResponseSize: 1,
+			expectedBlocks:  1,
+		},
+		"handler_clamps_requested_size": {
+			maxResponseSize: math.MaxUint32,
+			expectedBlocks:  len(blocks),
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			request := message.BlockRequest{
+				Hash:            blocks[len(blocks)-1].Hash(),
+				Height:          blocks[len(blocks)-1].NumberU64(),
+				Parents:         uint16(len(blocks)),
+				MaxResponseSize: test.maxResponseSize,
+			}
+			responseBytes, err := blockRequestHandler.OnBlockRequest(context.Background(), ids.GenerateTestNodeID(), 1, request)
+			assert.NoError(t, err)
+
+			var response message.BlockResponse
+			_, err = message.Codec.Unmarshal(responseBytes, &response)
+			assert.NoError(t, err)
+			assert.Len(t, response.Blocks, test.expectedBlocks)
+		})
+	}
+}
@@ -0,0 +1,40 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package handlers
+
+import (
+	"context"
+
+	"github.com/luxdefi/node/codec"
+	"github.com/luxdefi/node/ids"
+
+	"github.com/luxdefi/evm/plugin/evm/message"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// CapabilitiesRequestHandler is a peer.RequestHandler for message.CapabilitiesRequest
+// advertising the data this node is able to serve
+type CapabilitiesRequestHandler struct {
+	archive bool
+	codec   codec.Manager
+}
+
+func NewCapabilitiesRequestHandler(archive bool, codec codec.Manager) *CapabilitiesRequestHandler {
+	return &CapabilitiesRequestHandler{
+		archive: archive,
+		codec:   codec,
+	}
+}
+
+// OnCapabilitiesRequest handles request for the capabilities of this node
+// Never returns error
+// Expects returned errors to be treated as FATAL
+func (c *CapabilitiesRequestHandler) OnCapabilitiesRequest(_ context.Context, nodeID ids.NodeID, requestID uint32, _ message.CapabilitiesRequest) ([]byte, error) {
+	responseBytes, err := c.codec.Marshal(message.Version, message.CapabilitiesResponse{Archive: c.archive})
+	if err != nil {
+		log.Error("failed to marshal CapabilitiesResponse, dropping request", "nodeID", nodeID, "requestID", requestID, "err", err)
+		return nil, nil
+	}
+	return responseBytes, nil
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/luxdefi/node/codec"
 	"github.com/luxdefi/node/ids"
+	"github.com/luxdefi/node/utils/units"
 
 	"github.com/luxdefi/evm/core/rawdb"
 	"github.com/luxdefi/evm/ethdb"
@@ -18,6 +19,10 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 )
 
+// codeResponseSizeLimit caps the total size of code returned in a single response
+// so that the response stays well within the network message size limit.
+const codeResponseSizeLimit = 512 * units.KiB
+
 // CodeRequestHandler is a peer.RequestHandler for message.CodeRequest
 // serving requested contract code bytes
 type CodeRequestHandler struct {
@@ -38,6 +43,8 @@ func NewCodeRequestHandler(codeReader ethdb.KeyValueReader, codec codec.Manager,
 // OnCodeRequest handles request to retrieve contract code by its hash in message.CodeRequest
 // Never returns error
 // Returns nothing if code hash is not found
+// Returns the code of the first hashes only if the code of all of them exceeds the
+// requested response size, capped at codeResponseSizeLimit
 // Expects returned errors to be treated as FATAL
 // Assumes ctx is active
 func (n *CodeRequestHandler) OnCodeRequest(_ context.Context, nodeID ids.NodeID, requestID uint32, codeRequest message.CodeRequest) ([]byte, error) {
@@ -60,16 +67,21 @@ func (n *CodeRequestHandler) OnCodeRequest(_ context.Context, nodeID ids.NodeID,
 		return nil, nil
 	}
 
-	codeBytes := make([][]byte, len(codeRequest.Hashes))
+	codeBytes := make([][]byte, 0, len(codeRequest.Hashes))
+	sizeLimit := responseSizeLimit(codeRequest.MaxResponseSize, codeResponseSizeLimit)
 	totalBytes := 0
-	for i, hash := range codeRequest.Hashes {
-		codeBytes[i] = rawdb.ReadCode(n.codeReader, hash)
-		if len(codeBytes[i]) == 0 {
+	for _, hash := range codeRequest.Hashes {
+		code := rawdb.ReadCode(n.codeReader, hash)
+		if len(code) == 0 {
 			n.stats.IncMissingCodeHash()
 			log.Debug("requested code not found, dropping request", "nodeID", nodeID, "requestID", requestID, "hash", hash)
 			return nil, nil
 		}
-		totalBytes += len(codeBytes[i])
+		if len(codeBytes) > 0 && totalBytes+len(code) > sizeLimit {
+			break
+		}
+		totalBytes += len(code)
+		codeBytes = append(codeBytes, code)
 	}
 
 	codeResponse := message.CodeResponse{Data: codeBytes}
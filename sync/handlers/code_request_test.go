@@ -114,3 +114,53 @@ func TestCodeRequestHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestCodeRequestHandlerResponseSize(t *testing.T) {
+	database := memorydb.New()
+	const codeSize = 1000
+	hashes := make([]common.Hash, 3)
+	for i := range hashes {
+		code := make([]byte, codeSize)
+		_, err := rand.Read(code)
+		assert.NoError(t, err)
+		hashes[i] = crypto.Keccak256Hash(code)
+		rawdb.WriteCode(database, hashes[i], code)
+	}
+	codeRequestHandler := NewCodeRequestHandler(database, message.Codec, &stats.MockHandlerStats{})
+
+	tests := map[string]struct {
+		maxResponseSize uint32
+		expectedCode    int
+	}{
+		"server_limit_by_default": {
+			expectedCode: len(hashes),
+		},
+		"handler_honors_requested_size": {
+			maxResponseSize: 2*codeSize + codeSize/2,
+			expectedCode:    2,
+		},
+		"handler_returns_first_code_below_requested_size": {
+			maxResponseSize: 1,
+			expectedCode:    1,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			request := message.CodeRequest{
+				Hashes:          hashes,
+				MaxResponseSize: test.maxResponseSize,
+			}
+			responseBytes, err := codeRequestHandler.OnCodeRequest(context.Background(), ids.GenerateTestNodeID(), 1, request)
+			assert.NoError(t, err)
+
+			var response message.CodeResponse
+			_, err = message.Codec.Unmarshal(responseBytes, &response)
+			assert.NoError(t, err)
+			if assert.Len(t, response.Data, test.expectedCode) {
+				for i, code := range response.Data {
+					assert.Equal(t, hashes[i], crypto.Keccak256Hash(code))
+				}
+			}
+		})
+	}
+}
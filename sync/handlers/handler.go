@@ -17,7 +17,22 @@ type SnapshotProvider interface {
 	Snapshots() *snapshot.Tree
 }
 
+type ReceiptProvider interface {
+	GetReceiptsByHash(common.Hash) types.Receipts
+}
+
 type SyncDataProvider interface {
 	BlockProvider
 	SnapshotProvider
+	ReceiptProvider
+}
+
+// responseSizeLimit returns the maximum size of a response to a request whose
+// requester asked for at most [requested] bytes (0 means no preference),
+// clamped to the [ceiling] of the handler.
+func responseSizeLimit(requested uint32, ceiling int) int {
+	if requested == 0 || int64(requested) > int64(ceiling) {
+		return ceiling
+	}
+	return int(requested)
 }
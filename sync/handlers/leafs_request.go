@@ -12,6 +12,7 @@ import (
 	"github.com/luxdefi/node/codec"
 	"github.com/luxdefi/node/ids"
 	"github.com/luxdefi/node/utils/math"
+	"github.com/luxdefi/node/utils/units"
 	"github.com/luxdefi/evm/core/state/snapshot"
 	"github.com/luxdefi/evm/core/types"
 	"github.com/luxdefi/evm/ethdb"
@@ -31,6 +32,11 @@ const (
 	// in message.LeafsRequest if it is greater than this value
 	maxLeavesLimit = uint16(1024)
 
+	// leafsResponseSizeLimit caps the total size of the keys and values returned in
+	// a single message.LeafsResponse so that the response stays well within the
+	// network message size limit.
+	leafsResponseSizeLimit = 512 * units.KiB
+
 	// Maximum percent of the time left to deadline to spend on optimistically
 	// reading the snapshot to find the response
 	maxSnapshotReadTimePercent = 75
@@ -47,14 +53,29 @@ type LeafsRequestHandler struct {
 	codec            codec.Manager
 	stats            stats.LeafsRequestHandlerStats
 	pool             sync.Pool
+	maxLeaves        uint16        // Maximum number of leaves returned in a single response
+	workers          *ProofWorkers // Generates the range proofs, may be nil
+	lazyAccounts     bool          // Decode only the account fields needed to serve a response
 }
 
-func NewLeafsRequestHandler(trieDB *trie.Database, snapshotProvider SnapshotProvider, codec codec.Manager, syncerStats stats.LeafsRequestHandlerStats) *LeafsRequestHandler {
+// NewLeafsRequestHandler returns a handler serving at most [maxLeavesPerResponse]
+// leaves per response, regardless of the limit in the request. If
+// [maxLeavesPerResponse] is 0 or greater than maxLeavesLimit, maxLeavesLimit is
+// used instead. Range proofs are generated on [workers], if not nil.
+// If [lazyAccounts] is true, accounts are decoded with snapshot.DecodeAccount
+// only for the fields a response needs, instead of in full.
+func NewLeafsRequestHandler(trieDB *trie.Database, snapshotProvider SnapshotProvider, codec codec.Manager, syncerStats stats.LeafsRequestHandlerStats, maxLeavesPerResponse uint16, workers *ProofWorkers, lazyAccounts bool) *LeafsRequestHandler {
+	if maxLeavesPerResponse == 0 || maxLeavesPerResponse > maxLeavesLimit {
+		maxLeavesPerResponse = maxLeavesLimit
+	}
 	return &LeafsRequestHandler{
 		trieDB:           trieDB,
 		snapshotProvider: snapshotProvider,
 		codec:            codec,
 		stats:            syncerStats,
+		maxLeaves:        maxLeavesPerResponse,
+		workers:          workers,
+		lazyAccounts:     lazyAccounts,
 		pool: sync.Pool{
 			New: func() interface{} { return make([][]byte, 0, maxLeavesLimit) },
 		},
@@ -66,7 +87,9 @@ func NewLeafsRequestHandler(trieDB *trie.Database, snapshotProvider SnapshotProv
 // Returned message.LeafsResponse may contain partial leaves within requested Start and End range if:
 // - ctx expired while fetching leafs
 // - number of leaves read is greater than Limit (message.LeafsRequest)
-// Specified Limit in message.LeafsRequest is overridden to maxLeavesLimit if it is greater than maxLeavesLimit
+// Specified Limit in message.LeafsRequest is overridden to the handler's maximum number of leaves per response if it is greater
+// In that case, the response is a valid partial range and the requester continues from its last key
+// The same applies if the leaves exceed the requested response size, capped at leafsResponseSizeLimit
 // Expects returned errors to be treated as FATAL
 // Never returns errors
 // Returns nothing if the requested trie root is not found
@@ -100,10 +123,10 @@ func (lrh *LeafsRequestHandler) OnLeafsRequest(ctx context.Context, nodeID ids.N
 		lrh.stats.IncMissingRoot()
 		return nil, nil
 	}
-	// override limit if it is greater than the configured maxLeavesLimit
+	// override limit if it is greater than the configured maximum
 	limit := leafsRequest.Limit
-	if limit > maxLeavesLimit {
-		limit = maxLeavesLimit
+	if limit > lrh.maxLeaves {
+		limit = lrh.maxLeaves
 	}
 
 	var leafsResponse message.LeafsResponse
@@ -128,12 +151,16 @@ func (lrh *LeafsRequestHandler) OnLeafsRequest(ctx context.Context, nodeID ids.N
 		keyLength: keyLength,
 		limit:     limit,
 		stats:     lrh.stats,
+		workers:   lrh.workers,
 	}
 	// pass snapshot to responseBuilder if non-nil snapshot getter provided
 	if lrh.snapshotProvider != nil {
 		responseBuilder.snap = lrh.snapshotProvider.Snapshots()
 	}
 	err = responseBuilder.handleRequest(ctx)
+	if err == nil {
+		err = responseBuilder.trimToSize(ctx, responseSizeLimit(leafsRequest.MaxResponseSize, leafsResponseSizeLimit))
+	}
 
 	// ensure metrics are captured properly on all return paths
 	defer func() {
@@ -152,7 +179,21 @@ func (lrh *LeafsRequestHandler) OnLeafsRequest(ctx context.Context, nodeID ids.N
 		return nil, nil
 	}
 
-	responseBytes, err := lrh.codec.Marshal(message.Version, leafsResponse)
+	// The empty storage flags are encoded with message.StatusVersion, which
+	// the requester supports since it asked for them.
+	version := message.Version
+	if leafsRequest.IncludeEmptyStorage {
+		version = message.StatusVersion
+		if leafsRequest.Account == (common.Hash{}) {
+			leafsResponse.EmptyStorage, err = lrh.emptyStorage(leafsResponse.Vals)
+			if err != nil {
+				log.Debug("failed to decode accounts for empty storage, dropping request", "nodeID", nodeID, "requestID", requestID, "request", leafsRequest, "err", err)
+				return nil, nil
+			}
+		}
+	}
+
+	responseBytes, err := lrh.codec.Marshal(version, leafsResponse)
 	if err != nil {
 		log.Debug("failed to marshal LeafsResponse, dropping request", "nodeID", nodeID, "requestID", requestID, "request", leafsRequest, "err", err)
 		return nil, nil
@@ -162,6 +203,24 @@ func (lrh *LeafsRequestHandler) OnLeafsRequest(ctx context.Context, nodeID ids.N
 	return responseBytes, nil
 }
 
+// emptyStorage returns whether each of the accounts in [vals], the values of
+// account trie leaves, has empty storage.
+func (lrh *LeafsRequestHandler) emptyStorage(vals [][]byte) ([]bool, error) {
+	fields := snapshot.AllAccountFields
+	if lrh.lazyAccounts {
+		fields = snapshot.AccountRoot
+	}
+	empty := make([]bool, len(vals))
+	for i, val := range vals {
+		account, err := snapshot.DecodeAccount(val, fields)
+		if err != nil {
+			return nil, err
+		}
+		empty[i] = common.BytesToHash(account.Root) == types.EmptyRootHash
+	}
+	return empty, nil
+}
+
 type responseBuilder struct {
 	request   *message.LeafsRequest
 	response  *message.LeafsResponse
@@ -169,6 +228,7 @@ type responseBuilder struct {
 	snap      *snapshot.Tree
 	keyLength int
 	limit     uint16
+	workers   *ProofWorkers
 
 	// stats
 	trieReadTime time.Duration
@@ -202,7 +262,7 @@ func (rb *responseBuilder) handleRequest(ctx context.Context) error {
 	}
 
 	// Generate the proof and add it to the response.
-	proof, err := rb.generateRangeProof(rb.request.Start, rb.response.Keys)
+	proof, err := rb.generateRangeProof(ctx, rb.request.Start, rb.response.Keys)
 	if err != nil {
 		rb.stats.IncProofError()
 		return err
@@ -249,7 +309,7 @@ func (rb *responseBuilder) fillFromSnapshot(ctx context.Context) (bool, error) {
 	}
 
 	// Check if the entire range read from the snapshot is valid according to the trie.
-	proof, ok, more, err := rb.isRangeValid(snapKeys, snapVals, false)
+	proof, ok, more, err := rb.isRangeValid(ctx, snapKeys, snapVals, false)
 	if err != nil {
 		rb.stats.IncProofError()
 		return false, err
@@ -276,7 +336,7 @@ func (rb *responseBuilder) fillFromSnapshot(ctx context.Context) (bool, error) {
 	hasGap := false
 	for i := 0; i < len(snapKeys); i += segmentLen {
 		segmentEnd := math.Min(i+segmentLen, len(snapKeys))
-		proof, ok, _, err := rb.isRangeValid(snapKeys[i:segmentEnd], snapVals[i:segmentEnd], hasGap)
+		proof, ok, _, err := rb.isRangeValid(ctx, snapKeys[i:segmentEnd], snapVals[i:segmentEnd], hasGap)
 		if err != nil {
 			rb.stats.IncProofError()
 			return false, err
@@ -322,8 +382,43 @@ func (rb *responseBuilder) fillFromSnapshot(ctx context.Context) (bool, error) {
 	return false, nil
 }
 
+// trimToSize drops the leaves of the response after the first ones whose keys and
+// values fit in [sizeLimit] bytes, keeping at least one, and replaces the proof with
+// the range proof of the remaining leaves.
+func (rb *responseBuilder) trimToSize(ctx context.Context, sizeLimit int) error {
+	totalBytes := 0
+	for i := range rb.response.Keys {
+		totalBytes += len(rb.response.Keys[i]) + len(rb.response.Vals[i])
+		if i == 0 || totalBytes <= sizeLimit {
+			continue
+		}
+
+		// clear out the dropped leaves since the slices are pooled
+		for j := i; j < len(rb.response.Keys); j++ {
+			rb.response.Keys[j] = nil
+			rb.response.Vals[j] = nil
+		}
+		rb.response.Keys = rb.response.Keys[:i]
+		rb.response.Vals = rb.response.Vals[:i]
+
+		proof, err := rb.generateRangeProof(ctx, rb.request.Start, rb.response.Keys)
+		if err != nil {
+			rb.stats.IncProofError()
+			return err
+		}
+		defer proof.Close() // closing memdb does not error
+		rb.response.ProofVals, err = iterateVals(proof)
+		if err != nil {
+			rb.stats.IncProofError()
+		}
+		return err
+	}
+	return nil
+}
+
 // generateRangeProof returns a range proof for the range specified by [start] and [keys] using [t].
-func (rb *responseBuilder) generateRangeProof(start []byte, keys [][]byte) (*memorydb.Database, error) {
+// The proofs of both ends of the range are generated concurrently on the proof workers, if any.
+func (rb *responseBuilder) generateRangeProof(ctx context.Context, start []byte, keys [][]byte) (*memorydb.Database, error) {
 	proof := memorydb.New()
 	startTime := time.Now()
 	defer func() { rb.proofTime += time.Since(startTime) }()
@@ -333,17 +428,19 @@ func (rb *responseBuilder) generateRangeProof(start []byte, keys [][]byte) (*mem
 		start = bytes.Repeat([]byte{0x00}, rb.keyLength)
 	}
 
-	if err := rb.t.Prove(start, 0, proof); err != nil {
-		_ = proof.Close() // closing memdb does not error
-		return nil, err
+	// Proving does not modify the trie and memorydb is safe for concurrent
+	// use, so both ends can be proven at once.
+	tasks := []func() error{
+		func() error { return rb.t.Prove(start, 0, proof) },
 	}
 	if len(keys) > 0 {
 		// If there is a non-zero number of keys, set [end] for the range proof to the last key.
 		end := keys[len(keys)-1]
-		if err := rb.t.Prove(end, 0, proof); err != nil {
-			_ = proof.Close() // closing memdb does not error
-			return nil, err
-		}
+		tasks = append(tasks, func() error { return rb.t.Prove(end, 0, proof) })
+	}
+	if err := rb.workers.run(ctx, tasks...); err != nil {
+		_ = proof.Close() // closing memdb does not error
+		return nil, err
 	}
 	return proof, nil
 }
@@ -387,7 +484,7 @@ func iterateVals(db *memorydb.Database) ([][]byte, error) {
 // existing response. If [hasGap] is false, the range proof begins at a key which
 // guarantees the range can be appended to the response.
 // Additionally returns a boolean indicating if there are more leaves in the trie.
-func (rb *responseBuilder) isRangeValid(keys, vals [][]byte, hasGap bool) (*memorydb.Database, bool, bool, error) {
+func (rb *responseBuilder) isRangeValid(ctx context.Context, keys, vals [][]byte, hasGap bool) (*memorydb.Database, bool, bool, error) {
 	var startKey []byte
 	if hasGap {
 		startKey = keys[0]
@@ -395,7 +492,7 @@ func (rb *responseBuilder) isRangeValid(keys, vals [][]byte, hasGap bool) (*memo
 		startKey = rb.nextKey()
 	}
 
-	proof, err := rb.generateRangeProof(startKey, keys)
+	proof, err := rb.generateRangeProof(ctx, startKey, keys)
 	if err != nil {
 		return nil, false, false, err
 	}
@@ -6,6 +6,7 @@ package handlers
 import (
 	"bytes"
 	"context"
+	"math"
 	"math/rand"
 	"testing"
 
@@ -18,6 +19,7 @@ import (
 	"github.com/luxdefi/evm/plugin/evm/message"
 	"github.com/luxdefi/evm/sync/handlers/stats"
 	"github.com/luxdefi/evm/trie"
+	"github.com/luxdefi/evm/utils"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/rlp"
@@ -70,7 +72,7 @@ func TestLeafsRequestHandler_OnLeafsRequest(t *testing.T) {
 		}
 	}
 	snapshotProvider := &TestSnapshotProvider{}
-	leafsHandler := NewLeafsRequestHandler(trieDB, snapshotProvider, message.Codec, mockHandlerStats)
+	leafsHandler := NewLeafsRequestHandler(trieDB, snapshotProvider, message.Codec, mockHandlerStats, 0, nil, false)
 	snapConfig := snapshot.Config{
 		CacheSize:  64,
 		AsyncBuild: false,
@@ -700,3 +702,164 @@ func assertRangeProofIsValid(t *testing.T, request *message.LeafsRequest, respon
 	assert.NoError(t, err)
 	assert.Equal(t, expectMore, more)
 }
+
+func TestLeafsRequestHandler_MaxLeavesPerResponse(t *testing.T) {
+	const (
+		numAccounts = 500
+		maxLeaves   = 100
+	)
+	trieDB := trie.NewDatabase(memorydb.New())
+	root, _ := trie.FillAccounts(t, trieDB, common.Hash{}, numAccounts, nil)
+	leafsHandler := NewLeafsRequestHandler(trieDB, nil, message.Codec, stats.NewNoopHandlerStats(), maxLeaves, nil, false)
+
+	// Every response is capped, and the requester continues from the key
+	// following the last one served until all leaves are served.
+	var start []byte
+	for i := 0; i < numAccounts/maxLeaves; i++ {
+		request := message.LeafsRequest{
+			Root:  root,
+			Start: start,
+			End:   bytes.Repeat([]byte{0xff}, common.HashLength),
+			Limit: maxLeavesLimit,
+		}
+		response, err := leafsHandler.OnLeafsRequest(context.Background(), ids.GenerateTestNodeID(), 1, request)
+		assert.NoError(t, err)
+		var leafsResponse message.LeafsResponse
+		_, err = message.Codec.Unmarshal(response, &leafsResponse)
+		assert.NoError(t, err)
+		if !assert.Len(t, leafsResponse.Keys, maxLeaves) {
+			return
+		}
+		assertRangeProofIsValid(t, &request, &leafsResponse, i < numAccounts/maxLeaves-1)
+
+		start = common.CopyBytes(leafsResponse.Keys[len(leafsResponse.Keys)-1])
+		utils.IncrOne(start)
+	}
+}
+
+func TestLeafsRequestHandler_EmptyStorage(t *testing.T) {
+	trieDB := trie.NewDatabase(memorydb.New())
+	storageRoot, _, _ := trie.GenerateTrie(t, trieDB, 16, common.HashLength)
+	codeHash := crypto.Keccak256([]byte{0x60, 0x00})
+	// Accounts are EOAs, contracts with empty storage and contracts with storage in turn.
+	root, _ := trie.FillAccounts(t, trieDB, common.Hash{}, 30, func(t *testing.T, i int, acc types.StateAccount) types.StateAccount {
+		switch i % 3 {
+		case 1:
+			acc.CodeHash = codeHash
+		case 2:
+			acc.CodeHash = codeHash
+			acc.Root = storageRoot
+		}
+		return acc
+	})
+	// Eager and lazy account decoding serve the same flags.
+	for _, lazyAccounts := range []bool{false, true} {
+		leafsHandler := NewLeafsRequestHandler(trieDB, nil, message.Codec, stats.NewNoopHandlerStats(), 0, nil, lazyAccounts)
+		request := message.LeafsRequest{
+			Root:                root,
+			End:                 bytes.Repeat([]byte{0xff}, common.HashLength),
+			Limit:               maxLeavesLimit,
+			IncludeEmptyStorage: true,
+		}
+		responseBytes, err := leafsHandler.OnLeafsRequest(context.Background(), ids.GenerateTestNodeID(), 1, request)
+		assert.NoError(t, err)
+		var response message.LeafsResponse
+		version, err := message.Codec.Unmarshal(responseBytes, &response)
+		assert.NoError(t, err)
+		assert.Equal(t, message.StatusVersion, version)
+		assertRangeProofIsValid(t, &request, &response, false)
+		if !assert.Len(t, response.EmptyStorage, 30) {
+			continue
+		}
+		var eoas, emptyContracts, contracts int
+		for i, val := range response.Vals {
+			var acc types.StateAccount
+			assert.NoError(t, rlp.DecodeBytes(val, &acc))
+			switch {
+			case acc.Root != types.EmptyRootHash:
+				contracts++
+				assert.False(t, response.EmptyStorage[i])
+			case bytes.Equal(acc.CodeHash, types.EmptyCodeHash[:]):
+				eoas++
+				assert.True(t, response.EmptyStorage[i])
+			default:
+				emptyContracts++
+				assert.True(t, response.EmptyStorage[i])
+			}
+		}
+		assert.Equal(t, []int{10, 10, 10}, []int{eoas, emptyContracts, contracts})
+
+		// Requests that do not ask for the flags are answered as before.
+		request.IncludeEmptyStorage = false
+		responseBytes, err = leafsHandler.OnLeafsRequest(context.Background(), ids.GenerateTestNodeID(), 1, request)
+		assert.NoError(t, err)
+		response = message.LeafsResponse{}
+		version, err = message.Codec.Unmarshal(responseBytes, &response)
+		assert.NoError(t, err)
+		assert.Equal(t, message.Version, version)
+		assert.Len(t, response.Keys, 30)
+		assert.Nil(t, response.EmptyStorage)
+	}
+}
+
+func TestLeafsRequestHandler_ResponseSize(t *testing.T) {
+	const numAccounts = 100
+	trieDB := trie.NewDatabase(memorydb.New())
+	root, _ := trie.FillAccounts(t, trieDB, common.Hash{}, numAccounts, nil)
+	leafsHandler := NewLeafsRequestHandler(trieDB, nil, message.Codec, stats.NewNoopHandlerStats(), 0, nil, false)
+	request := message.LeafsRequest{
+		Root:  root,
+		End:   bytes.Repeat([]byte{0xff}, common.HashLength),
+		Limit: maxLeavesLimit,
+	}
+	onLeafsRequest := func(request message.LeafsRequest) message.LeafsResponse {
+		responseBytes, err := leafsHandler.OnLeafsRequest(context.Background(), ids.GenerateTestNodeID(), 1, request)
+		assert.NoError(t, err)
+		var response message.LeafsResponse
+		_, err = message.Codec.Unmarshal(responseBytes, &response)
+		assert.NoError(t, err)
+		return response
+	}
+
+	// the server limit is not reached by default
+	full := onLeafsRequest(request)
+	if !assert.Len(t, full.Keys, numAccounts) {
+		return
+	}
+	assertRangeProofIsValid(t, &request, &full, false)
+
+	size := 0
+	for i := 0; i < 10; i++ {
+		size += len(full.Keys[i]) + len(full.Vals[i])
+	}
+	tests := map[string]struct {
+		maxResponseSize uint32
+		expectedLeaves  int
+	}{
+		"handler_honors_requested_size": {
+			maxResponseSize: uint32(size),
+			expectedLeaves:  10,
+		},
+		"handler_returns_first_leaf_below_requested_size": {
+			maxResponseSize: 1,
+			expectedLeaves:  1,
+		},
+		"handler_clamps_requested_size": {
+			maxResponseSize: math.MaxUint32,
+			expectedLeaves:  numAccounts,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			request := request
+			request.MaxResponseSize = test.maxResponseSize
+			response := onLeafsRequest(request)
+			if !assert.Len(t, response.Keys, test.expectedLeaves) {
+				return
+			}
+			assert.Equal(t, full.Keys[:test.expectedLeaves], response.Keys)
+			// the truncated response is a valid partial range
+			assertRangeProofIsValid(t, &request, &response, test.expectedLeaves < numAccounts)
+		})
+	}
+}
@@ -0,0 +1,85 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package handlers
+
+import (
+	"context"
+	"sync"
+)
+
+// ProofWorkers bounds the number of proofs generated concurrently by the sync
+// handlers sharing it, across all requests and request types. Proofs are
+// assigned to workers in the order they are submitted, so that requests with
+// many proofs do not starve the others.
+// A nil *ProofWorkers generates proofs sequentially on the goroutine of the
+// request.
+type ProofWorkers struct {
+	slots chan struct{}
+}
+
+// NewProofWorkers returns a pool of [size] proof workers, or nil if [size] is
+// not positive.
+func NewProofWorkers(size int) *ProofWorkers {
+	if size <= 0 {
+		return nil
+	}
+	return &ProofWorkers{slots: make(chan struct{}, size)}
+}
+
+// size returns the number of proofs that may be generated concurrently.
+func (w *ProofWorkers) size() int {
+	if w == nil {
+		return 1
+	}
+	return cap(w.slots)
+}
+
+// run runs [tasks] concurrently on the workers of [w] and returns the first
+// error returned by a task. Returns ctx.Err() if [ctx] expires while waiting
+// for a worker. If [w] is nil, [tasks] run sequentially regardless of [ctx].
+func (w *ProofWorkers) run(ctx context.Context, tasks ...func() error) error {
+	if w == nil {
+		for _, task := range tasks {
+			if err := task(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var (
+		wg   sync.WaitGroup
+		errs = make(chan error, len(tasks))
+	)
+	for _, task := range tasks {
+		if err := ctx.Err(); err != nil {
+			wg.Wait()
+			return err
+		}
+		// Workers are acquired one at a time, so that tasks of other requests
+		// waiting for a worker are interleaved with these.
+		select {
+		case w.slots <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return ctx.Err()
+		}
+		wg.Add(1)
+		go func(task func() error) {
+			defer func() {
+				<-w.slots
+				wg.Done()
+			}()
+			errs <- task()
+		}(task)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
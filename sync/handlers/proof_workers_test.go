@@ -0,0 +1,52 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package handlers
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProofWorkers(t *testing.T) {
+	assert.Nil(t, NewProofWorkers(0))
+
+	workers := NewProofWorkers(2)
+	var running, maxRunning atomic.Int32
+	task := func() error {
+		n := running.Add(1)
+		for {
+			highest := maxRunning.Load()
+			if n <= highest || maxRunning.CompareAndSwap(highest, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		running.Add(-1)
+		return nil
+	}
+	// tasks of concurrent requests share the workers
+	done := make(chan error)
+	for i := 0; i < 3; i++ {
+		go func() { done <- workers.run(context.Background(), task, task, task) }()
+	}
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, <-done)
+	}
+	assert.EqualValues(t, 2, maxRunning.Load())
+
+	// the error of a task is returned once all tasks are done
+	errTest := errors.New("test error")
+	assert.ErrorIs(t, workers.run(context.Background(), task, func() error { return errTest }), errTest)
+	assert.Zero(t, running.Load())
+
+	// waiting for a worker stops once ctx expires
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, workers.run(ctx, task), context.Canceled)
+}
@@ -0,0 +1,134 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/luxdefi/node/codec"
+	"github.com/luxdefi/node/ids"
+	"github.com/luxdefi/node/utils/units"
+
+	"github.com/luxdefi/evm/plugin/evm/message"
+	"github.com/luxdefi/evm/sync/handlers/stats"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	// receiptsParentLimit specifies how many parents to retrieve receipts for given a starting hash
+	// This value overrides any specified limit in receiptsRequest.Parents if it is greater than this value
+	receiptsParentLimit = uint16(32)
+
+	// receiptsResponseSizeLimit caps the total size of encoded receipts returned in a single response
+	// so that the response stays well within the network message size limit.
+	receiptsResponseSizeLimit = 512 * units.KiB
+)
+
+// ReceiptsRequestHandler is a peer.RequestHandler for message.ReceiptsRequest
+// serving the receipts of requested blocks starting at specified hash
+type ReceiptsRequestHandler struct {
+	stats           stats.ReceiptsRequestHandlerStats
+	blockProvider   BlockProvider
+	receiptProvider ReceiptProvider
+	codec           codec.Manager
+}
+
+func NewReceiptsRequestHandler(blockProvider BlockProvider, receiptProvider ReceiptProvider, codec codec.Manager, handlerStats stats.ReceiptsRequestHandlerStats) *ReceiptsRequestHandler {
+	return &ReceiptsRequestHandler{
+		blockProvider:   blockProvider,
+		receiptProvider: receiptProvider,
+		codec:           codec,
+		stats:           handlerStats,
+	}
+}
+
+// OnReceiptsRequest handles incoming message.ReceiptsRequest, returning the receipts of blocks as requested
+// Never returns error
+// Expects returned errors to be treated as FATAL
+// Returns empty response or receipts of a subset of requested blocks if ctx expires during fetch
+// Stops once the requested response size, capped at receiptsResponseSizeLimit, is reached, but
+// always includes the receipts of the first block found
+// Assumes ctx is active
+func (r *ReceiptsRequestHandler) OnReceiptsRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, receiptsRequest message.ReceiptsRequest) ([]byte, error) {
+	startTime := time.Now()
+	r.stats.IncReceiptsRequest()
+
+	// override given Parents limit if it is greater than receiptsParentLimit
+	parents := receiptsRequest.Parents
+	if parents > receiptsParentLimit {
+		parents = receiptsParentLimit
+	}
+	receipts := make([][]byte, 0, parents)
+
+	// ensure metrics are captured properly on all return paths
+	defer func() {
+		r.stats.UpdateReceiptsRequestProcessingTime(time.Since(startTime))
+		r.stats.UpdateReceiptsReturned(uint16(len(receipts)))
+	}()
+
+	var (
+		hash       = receiptsRequest.Hash
+		height     = receiptsRequest.Height
+		sizeLimit  = responseSizeLimit(receiptsRequest.MaxResponseSize, receiptsResponseSizeLimit)
+		totalBytes = 0
+	)
+	for i := 0; i < int(parents); i++ {
+		// we return whatever we have until ctx errors, limit is exceeded, or we reach the genesis block
+		if ctx.Err() != nil {
+			break
+		}
+
+		if (hash == common.Hash{}) {
+			break
+		}
+
+		block := r.blockProvider.GetBlock(hash, height)
+		if block == nil {
+			r.stats.IncMissingReceipts()
+			break
+		}
+
+		// Note: a block without transactions has an empty (non-nil) receipt list,
+		// so a nil result indicates the receipts are not available locally.
+		blockReceipts := r.receiptProvider.GetReceiptsByHash(hash)
+		if blockReceipts == nil && len(block.Transactions()) > 0 {
+			r.stats.IncMissingReceipts()
+			break
+		}
+
+		receiptsBytes, err := rlp.EncodeToBytes(blockReceipts)
+		if err != nil {
+			log.Error("failed to RLP encode receipts", "hash", hash, "height", height, "err", err)
+			return nil, nil
+		}
+		if len(receipts) > 0 && totalBytes+len(receiptsBytes) > sizeLimit {
+			break
+		}
+
+		totalBytes += len(receiptsBytes)
+		receipts = append(receipts, receiptsBytes)
+		hash = block.ParentHash()
+		height--
+	}
+
+	if len(receipts) == 0 {
+		// drop this request
+		log.Debug("no requested receipts found, dropping request", "nodeID", nodeID, "requestID", requestID, "hash", receiptsRequest.Hash, "parents", receiptsRequest.Parents)
+		return nil, nil
+	}
+
+	response := message.ReceiptsResponse{
+		Receipts: receipts,
+	}
+	responseBytes, err := r.codec.Marshal(message.Version, response)
+	if err != nil {
+		log.Error("failed to marshal ReceiptsResponse, dropping request", "nodeID", nodeID, "requestID", requestID, "hash", receiptsRequest.Hash, "parents", receiptsRequest.Parents, "receiptsLen", len(response.Receipts), "err", err)
+		return nil, nil
+	}
+
+	return responseBytes, nil
+}
@@ -0,0 +1,188 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package handlers
+
+import (
+	"context"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/luxdefi/node/ids"
+	"github.com/luxdefi/evm/consensus/dummy"
+	"github.com/luxdefi/evm/core"
+	"github.com/luxdefi/evm/core/types"
+	"github.com/luxdefi/evm/params"
+	"github.com/luxdefi/evm/plugin/evm/message"
+	"github.com/luxdefi/evm/sync/handlers/stats"
+	"github.com/luxdefi/evm/trie"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReceiptsRequestHandler(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	gspec := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc:  core.GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+	}
+	signer := types.LatestSigner(params.TestChainConfig)
+	_, blocks, receipts, err := core.GenerateChainWithGenesis(gspec, dummy.NewETHFaker(), 48, 0, func(i int, b *core.BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(b.TxNonce(addr), common.Address{1}, big.NewInt(1), params.TxGas, b.BaseFee(), nil), signer, key)
+		require.NoError(t, err)
+		b.AddTx(tx)
+	})
+	require.NoError(t, err)
+
+	blocksDB := make(map[common.Hash]*types.Block, len(blocks))
+	receiptsDB := make(map[common.Hash]types.Receipts, len(blocks))
+	for i, blk := range blocks {
+		blocksDB[blk.Hash()] = blk
+		receiptsDB[blk.Hash()] = receipts[i]
+	}
+	blockProvider := &TestBlockProvider{
+		GetBlockFn: func(hash common.Hash, height uint64) *types.Block {
+			blk, ok := blocksDB[hash]
+			if !ok || blk.NumberU64() != height {
+				return nil
+			}
+			return blk
+		},
+	}
+	receiptProvider := &TestReceiptProvider{
+		GetReceiptsByHashFn: func(hash common.Hash) types.Receipts {
+			return receiptsDB[hash]
+		},
+	}
+	mockHandlerStats := &stats.MockHandlerStats{}
+	receiptsRequestHandler := NewReceiptsRequestHandler(blockProvider, receiptProvider, message.Codec, mockHandlerStats)
+
+	tests := map[string]struct {
+		startBlockIndex   int
+		startBlockHash    common.Hash
+		requestedParents  uint16
+		expectedReceipts  int
+		expectNilResponse bool
+	}{
+		"handler_returns_receipts_as_requested": {
+			startBlockIndex:  40,
+			requestedParents: 16,
+			expectedReceipts: 16,
+		},
+		"handler_caps_receipts_parent_limit": {
+			startBlockIndex:  47,
+			requestedParents: 48,
+			expectedReceipts: int(receiptsParentLimit),
+		},
+		"handler_unknown_block": {
+			startBlockHash:    common.BytesToHash([]byte("some block pls k thx bye")),
+			requestedParents:  16,
+			expectNilResponse: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			defer mockHandlerStats.Reset()
+
+			request := message.ReceiptsRequest{Parents: test.requestedParents}
+			if test.startBlockHash != (common.Hash{}) {
+				request.Hash = test.startBlockHash
+				request.Height = 1_000_000
+			} else {
+				request.Hash = blocks[test.startBlockIndex].Hash()
+				request.Height = blocks[test.startBlockIndex].NumberU64()
+			}
+
+			responseBytes, err := receiptsRequestHandler.OnReceiptsRequest(context.Background(), ids.GenerateTestNodeID(), 1, request)
+			require.NoError(t, err)
+			if test.expectNilResponse {
+				assert.Nil(t, responseBytes)
+				assert.Equal(t, uint32(1), mockHandlerStats.MissingReceiptsCount)
+				return
+			}
+
+			var response message.ReceiptsResponse
+			_, err = message.Codec.Unmarshal(responseBytes, &response)
+			require.NoError(t, err)
+			assert.Len(t, response.Receipts, test.expectedReceipts)
+			assert.Equal(t, uint32(test.expectedReceipts), mockHandlerStats.ReceiptsReturnedSum)
+
+			for i, receiptsBytes := range response.Receipts {
+				var blockReceipts types.Receipts
+				require.NoError(t, rlp.DecodeBytes(receiptsBytes, &blockReceipts))
+				block := blocks[test.startBlockIndex-i]
+				assert.Equal(t, block.ReceiptHash(), types.DeriveSha(blockReceipts, trie.NewStackTrie(nil)))
+			}
+		})
+	}
+}
+
+func TestReceiptsRequestHandlerResponseSize(t *testing.T) {
+	_, blocks, _, err := core.GenerateChainWithGenesis(&core.Genesis{Config: params.TestChainConfig}, dummy.NewETHFaker(), 32, 0, func(i int, b *core.BlockGen) {})
+	require.NoError(t, err)
+
+	blocksDB := make(map[common.Hash]*types.Block, len(blocks))
+	for _, blk := range blocks {
+		blocksDB[blk.Hash()] = blk
+	}
+	blockProvider := &TestBlockProvider{
+		GetBlockFn: func(hash common.Hash, height uint64) *types.Block {
+			return blocksDB[hash]
+		},
+	}
+	// serve large receipts so that the server limit is reached before receiptsParentLimit
+	largeReceipts := types.Receipts{{Logs: []*types.Log{{Data: make([]byte, 64*1024)}}}}
+	receiptProvider := &TestReceiptProvider{
+		GetReceiptsByHashFn: func(hash common.Hash) types.Receipts {
+			return largeReceipts
+		},
+	}
+	receiptsBytes, err := rlp.EncodeToBytes(largeReceipts)
+	require.NoError(t, err)
+	size := len(receiptsBytes)
+	receiptsRequestHandler := NewReceiptsRequestHandler(blockProvider, receiptProvider, message.Codec, &stats.MockHandlerStats{})
+
+	tests := map[string]struct {
+		maxResponseSize  uint32
+		expectedReceipts int
+	}{
+		"server_limit_by_default": {
+			expectedReceipts: receiptsResponseSizeLimit / size,
+		},
+		"handler_honors_requested_size": {
+			maxResponseSize:  uint32(3 * size),
+			expectedReceipts: 3,
+		},
+		"handler_returns_first_receipts_below_requested_size": {
+			maxResponseSize:  1,
+			expectedReceipts: 1,
+		},
+		"handler_clamps_requested_size": {
+			maxResponseSize:  math.MaxUint32,
+			expectedReceipts: receiptsResponseSizeLimit / size,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			request := message.ReceiptsRequest{
+				Hash:            blocks[len(blocks)-1].Hash(),
+				Height:          blocks[len(blocks)-1].NumberU64(),
+				Parents:         receiptsParentLimit,
+				MaxResponseSize: test.maxResponseSize,
+			}
+			responseBytes, err := receiptsRequestHandler.OnReceiptsRequest(context.Background(), ids.GenerateTestNodeID(), 1, request)
+			require.NoError(t, err)
+
+			var response message.ReceiptsResponse
+			_, err = message.Codec.Unmarshal(responseBytes, &response)
+			require.NoError(t, err)
+			assert.Len(t, response.Receipts, test.expectedReceipts)
+		})
+	}
+}
@@ -0,0 +1,124 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"time"
+
+	"github.com/luxdefi/node/codec"
+	"github.com/luxdefi/node/ids"
+
+	"github.com/luxdefi/evm/core/state/snapshot"
+	"github.com/luxdefi/evm/plugin/evm/message"
+	"github.com/luxdefi/evm/sync/handlers/stats"
+	"github.com/luxdefi/evm/trie"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// SnapshotDiffRequestHandler is a peer.RequestHandler for message.SnapshotDiffRequest
+// serving the changes made to the state by accepted blocks, so that followers
+// can track the head by applying them instead of executing the blocks
+type SnapshotDiffRequestHandler struct {
+	trieDB        *trie.Database
+	blockProvider BlockProvider
+	codec         codec.Manager
+	stats         stats.SnapshotDiffRequestHandlerStats
+}
+
+func NewSnapshotDiffRequestHandler(trieDB *trie.Database, blockProvider BlockProvider, codec codec.Manager, handlerStats stats.SnapshotDiffRequestHandlerStats) *SnapshotDiffRequestHandler {
+	return &SnapshotDiffRequestHandler{
+		trieDB:        trieDB,
+		blockProvider: blockProvider,
+		codec:         codec,
+		stats:         handlerStats,
+	}
+}
+
+// OnSnapshotDiffRequest handles incoming message.SnapshotDiffRequest, returning the
+// changes between the state of the requested block and the state of its parent
+// Never returns error
+// The diff is computed from the state tries, so it is only served if the states of
+// both blocks are available, which is the case for recent blocks
+// Returns nothing if the block or its states are not found, or if the diff does not
+// fit in a single response
+// Expects returned errors to be treated as FATAL
+// Assumes ctx is active
+func (s *SnapshotDiffRequestHandler) OnSnapshotDiffRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, snapshotDiffRequest message.SnapshotDiffRequest) ([]byte, error) {
+	startTime := time.Now()
+	s.stats.IncSnapshotDiffRequest()
+
+	var responseBytes []byte
+	// ensure metrics are captured properly on all return paths
+	defer func() {
+		s.stats.UpdateSnapshotDiffRequestProcessingTime(time.Since(startTime))
+		s.stats.UpdateSnapshotDiffBytesReturned(uint32(len(responseBytes)))
+	}()
+
+	if snapshotDiffRequest.Height == 0 {
+		log.Debug("snapshot diff requested for genesis, dropping request", "nodeID", nodeID, "requestID", requestID)
+		return nil, nil
+	}
+	block := s.blockProvider.GetBlock(snapshotDiffRequest.Hash, snapshotDiffRequest.Height)
+	if block == nil {
+		s.stats.IncMissingSnapshotDiffState()
+		log.Debug("block not found, dropping snapshot diff request", "nodeID", nodeID, "requestID", requestID, "hash", snapshotDiffRequest.Hash, "height", snapshotDiffRequest.Height)
+		return nil, nil
+	}
+	parent := s.blockProvider.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		s.stats.IncMissingSnapshotDiffState()
+		log.Debug("parent block not found, dropping snapshot diff request", "nodeID", nodeID, "requestID", requestID, "hash", snapshotDiffRequest.Hash, "height", snapshotDiffRequest.Height)
+		return nil, nil
+	}
+	destructs, accounts, storage, err := snapshot.DiffStates(s.trieDB, parent.Root(), block.Root())
+	if err != nil {
+		s.stats.IncMissingSnapshotDiffState()
+		log.Debug("failed to diff states, dropping snapshot diff request", "nodeID", nodeID, "requestID", requestID, "hash", snapshotDiffRequest.Hash, "height", snapshotDiffRequest.Height, "err", err)
+		return nil, nil
+	}
+	if ctx.Err() != nil {
+		return nil, nil
+	}
+
+	response := message.SnapshotDiffResponse{
+		ParentRoot: parent.Root(),
+		Root:       block.Root(),
+		Destructs:  sortedHashes(destructs),
+		Accounts:   make([]message.SnapshotDiffAccount, 0, len(accounts)),
+	}
+	for _, accountHash := range sortedHashes(accounts) {
+		account := message.SnapshotDiffAccount{
+			Hash:    accountHash,
+			Account: accounts[accountHash],
+			Keys:    sortedHashes(storage[accountHash]),
+		}
+		account.Vals = make([][]byte, len(account.Keys))
+		for i, key := range account.Keys {
+			account.Vals[i] = storage[accountHash][key]
+		}
+		response.Accounts = append(response.Accounts, account)
+	}
+	responseBytes, err = s.codec.Marshal(message.Version, response)
+	if err != nil {
+		log.Debug("failed to marshal SnapshotDiffResponse, dropping request", "nodeID", nodeID, "requestID", requestID, "hash", snapshotDiffRequest.Hash, "height", snapshotDiffRequest.Height, "accountsLen", len(response.Accounts), "err", err)
+		return nil, nil
+	}
+	return responseBytes, nil
+}
+
+// sortedHashes returns the keys of [m] in ascending order.
+func sortedHashes[V any](m map[common.Hash]V) []common.Hash {
+	hashes := make([]common.Hash, 0, len(m))
+	for hash := range m {
+		hashes = append(hashes, hash)
+	}
+	sort.Slice(hashes, func(i, j int) bool {
+		return bytes.Compare(hashes[i][:], hashes[j][:]) < 0
+	})
+	return hashes
+}
@@ -0,0 +1,315 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package handlers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/luxdefi/node/codec"
+	"github.com/luxdefi/node/ids"
+	"github.com/luxdefi/node/utils/units"
+
+	"github.com/luxdefi/evm/core/types"
+	"github.com/luxdefi/evm/plugin/evm/message"
+	"github.com/luxdefi/evm/sync/handlers/stats"
+	"github.com/luxdefi/evm/trie"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// stateProofResponseSizeLimit caps the total size of proof nodes returned in a single response
+// so that the response stays well within the network message size limit.
+const stateProofResponseSizeLimit = 512 * units.KiB
+
+var errUnknownBlock = errors.New("unknown block")
+
+// proofList collects the nodes of a merkle proof in the order they are written, starting with the root node.
+type proofList [][]byte
+
+func (p *proofList) Put(key []byte, value []byte) error {
+	*p = append(*p, value)
+	return nil
+}
+
+func (p *proofList) Delete(key []byte) error {
+	panic("not supported")
+}
+
+// size returns the total size of the nodes in the proof
+func (p proofList) size() int {
+	size := 0
+	for _, node := range p {
+		size += len(node)
+	}
+	return size
+}
+
+// StateProofRequestHandler is a peer.RequestHandler for message.StateProofRequest
+// serving proofs of an account and its storage in the state of a requested block
+type StateProofRequestHandler struct {
+	trieDB           *trie.Database
+	blockProvider    BlockProvider
+	snapshotProvider SnapshotProvider
+	codec            codec.Manager
+	stats            stats.StateProofRequestHandlerStats
+	workers          *ProofWorkers // Generates the storage proofs, may be nil
+}
+
+// NewStateProofRequestHandler returns a handler generating the storage proofs
+// of a request on [workers], if not nil.
+func NewStateProofRequestHandler(trieDB *trie.Database, blockProvider BlockProvider, snapshotProvider SnapshotProvider, codec codec.Manager, handlerStats stats.StateProofRequestHandlerStats, workers *ProofWorkers) *StateProofRequestHandler {
+	return &StateProofRequestHandler{
+		trieDB:           trieDB,
+		blockProvider:    blockProvider,
+		snapshotProvider: snapshotProvider,
+		codec:            codec,
+		stats:            handlerStats,
+		workers:          workers,
+	}
+}
+
+// OnStateProofRequest handles request to retrieve the proof of an account and its storage slots
+// in the state of the block specified in message.StateProofRequest
+// Never returns error
+// Responds with StateProofUnknownBlock or StateProofStateUnavailable if the block or its state is not available
+// Stops adding storage proofs once the response size limit is reached or ctx expires
+// Expects returned errors to be treated as FATAL
+// Assumes ctx is active
+func (s *StateProofRequestHandler) OnStateProofRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, stateProofRequest message.StateProofRequest) ([]byte, error) {
+	startTime := time.Now()
+	s.stats.IncStateProofRequest()
+
+	totalBytes := 0
+	// ensure metrics are captured properly on all return paths
+	defer func() {
+		s.stats.UpdateStateProofRequestProcessingTime(time.Since(startTime))
+		s.stats.UpdateStateProofBytesReturned(uint32(totalBytes))
+	}()
+
+	if len(stateProofRequest.Slots) > message.MaxStateProofSlotsPerRequest {
+		s.stats.IncInvalidStateProofRequest()
+		log.Debug("too many storage slots requested, dropping request", "nodeID", nodeID, "requestID", requestID, "numSlots", len(stateProofRequest.Slots))
+		return nil, nil
+	}
+
+	response, err := s.buildResponse(ctx, stateProofRequest)
+	if err != nil {
+		s.stats.IncMissingStateProofState()
+		log.Debug("state proof unavailable", "nodeID", nodeID, "requestID", requestID, "request", stateProofRequest, "err", err)
+	}
+	totalBytes = proofList(response.AccountProof).size()
+	for _, storageProof := range response.StorageProofs {
+		totalBytes += proofList(storageProof).size()
+	}
+
+	responseBytes, err := s.codec.Marshal(message.Version, response)
+	if err != nil {
+		log.Error("failed to marshal StateProofResponse, dropping request", "nodeID", nodeID, "requestID", requestID, "request", stateProofRequest, "err", err)
+		return nil, nil
+	}
+	return responseBytes, nil
+}
+
+// buildResponse proves the requested account and storage slots in the state of the requested block.
+// Returns a response with the status set and an error if the block or its state is not available.
+func (s *StateProofRequestHandler) buildResponse(ctx context.Context, request message.StateProofRequest) (message.StateProofResponse, error) {
+	accountProof, storageTrie, status, err := s.proveAccount(request.BlockHash, request.Height, request.Account)
+	if err != nil {
+		return message.StateProofResponse{Status: status}, err
+	}
+
+	totalBytes := accountProof.size()
+	storageProofs := make([][][]byte, 0, len(request.Slots))
+	err = s.proveSlots(ctx, storageTrie, request.Slots, func(storageProof proofList) bool {
+		if totalBytes+storageProof.size() > stateProofResponseSizeLimit {
+			return false
+		}
+		totalBytes += storageProof.size()
+		storageProofs = append(storageProofs, storageProof)
+		return true
+	})
+	if err != nil {
+		return message.StateProofResponse{Status: message.StateProofStateUnavailable}, err
+	}
+	return message.StateProofResponse{
+		Status:        message.StateProofOK,
+		AccountProof:  accountProof,
+		StorageProofs: storageProofs,
+	}, nil
+}
+
+// OnStateMultiproofRequest handles request to retrieve a single proof of an account and all of its
+// requested storage slots in the state of the block specified in message.StateMultiproofRequest
+// Never returns error
+// Responds with StateProofUnknownBlock or StateProofStateUnavailable if the block or its state is not available
+// Stops adding storage slots to the proof once the response size limit is reached or ctx expires
+// Expects returned errors to be treated as FATAL
+// Assumes ctx is active
+func (s *StateProofRequestHandler) OnStateMultiproofRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, multiproofRequest message.StateMultiproofRequest) ([]byte, error) {
+	startTime := time.Now()
+	s.stats.IncStateMultiproofRequest()
+
+	totalBytes := 0
+	// ensure metrics are captured properly on all return paths
+	defer func() {
+		s.stats.UpdateStateProofRequestProcessingTime(time.Since(startTime))
+		s.stats.UpdateStateProofBytesReturned(uint32(totalBytes))
+	}()
+
+	if len(multiproofRequest.Slots) > message.MaxStateProofSlotsPerRequest {
+		s.stats.IncInvalidStateProofRequest()
+		log.Debug("too many storage slots requested, dropping request", "nodeID", nodeID, "requestID", requestID, "numSlots", len(multiproofRequest.Slots))
+		return nil, nil
+	}
+
+	response, err := s.buildMultiproofResponse(ctx, multiproofRequest)
+	if err != nil {
+		s.stats.IncMissingStateProofState()
+		log.Debug("state multiproof unavailable", "nodeID", nodeID, "requestID", requestID, "request", multiproofRequest, "err", err)
+	}
+	totalBytes = proofList(response.AccountProof).size() + proofList(response.StorageProof).size()
+
+	responseBytes, err := s.codec.Marshal(message.Version, response)
+	if err != nil {
+		log.Error("failed to marshal StateMultiproofResponse, dropping request", "nodeID", nodeID, "requestID", requestID, "request", multiproofRequest, "err", err)
+		return nil, nil
+	}
+	return responseBytes, nil
+}
+
+// buildMultiproofResponse proves the requested account and storage slots in the state of the requested
+// block, including each storage trie node shared by the paths to several slots only once.
+// Returns a response with the status set and an error if the block or its state is not available.
+func (s *StateProofRequestHandler) buildMultiproofResponse(ctx context.Context, request message.StateMultiproofRequest) (message.StateMultiproofResponse, error) {
+	accountProof, storageTrie, status, err := s.proveAccount(request.BlockHash, request.Height, request.Account)
+	if err != nil {
+		return message.StateMultiproofResponse{Status: status}, err
+	}
+
+	var (
+		totalBytes   = accountProof.size()
+		storageProof proofList
+		seen         = make(map[common.Hash]struct{})
+		numSlots     uint16
+	)
+	err = s.proveSlots(ctx, storageTrie, request.Slots, func(slotProof proofList) bool {
+		// only the nodes not already on the path to a previous slot are added
+		var newNodes proofList
+		for _, node := range slotProof {
+			hash := crypto.Keccak256Hash(node)
+			if _, ok := seen[hash]; ok {
+				continue
+			}
+			seen[hash] = struct{}{}
+			newNodes = append(newNodes, node)
+		}
+		if totalBytes+newNodes.size() > stateProofResponseSizeLimit {
+			return false
+		}
+		totalBytes += newNodes.size()
+		storageProof = append(storageProof, newNodes...)
+		numSlots++
+		return true
+	})
+	if err != nil {
+		return message.StateMultiproofResponse{Status: message.StateProofStateUnavailable}, err
+	}
+	return message.StateMultiproofResponse{
+		Status:       message.StateProofOK,
+		AccountProof: accountProof,
+		StorageProof: storageProof,
+		NumSlots:     numSlots,
+	}, nil
+}
+
+// proveSlots proves [slots] in [storageTrie] and passes their proofs in order to [add], until it
+// returns false or ctx expires. The slots are proven concurrently on the proof workers, as many
+// at a time as there are workers, so that few slots are proven past the response size limit.
+// Returns an error if a slot could not be proven.
+func (s *StateProofRequestHandler) proveSlots(ctx context.Context, storageTrie *trie.StateTrie, slots []common.Hash, add func(proofList) bool) error {
+	batchSize := s.workers.size()
+	for start := 0; start < len(slots); start += batchSize {
+		// we return whatever we have until ctx errors or the size limit is exceeded
+		if ctx.Err() != nil {
+			return nil
+		}
+		batch := slots[start:min(start+batchSize, len(slots))]
+		proofs := make([]proofList, len(batch))
+		tasks := make([]func() error, len(batch))
+		for i, slot := range batch {
+			i, slot := i, slot
+			tasks[i] = func() error {
+				return storageTrie.Prove(crypto.Keccak256(slot[:]), 0, &proofs[i])
+			}
+		}
+		if err := s.workers.run(ctx, tasks...); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		for _, proof := range proofs {
+			if !add(proof) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// proveAccount proves [account] in the state of the block with [blockHash] at [height] and opens its
+// storage trie. Returns the status to respond with and an error if the block or its state is not available.
+func (s *StateProofRequestHandler) proveAccount(blockHash common.Hash, height uint64, account common.Address) (proofList, *trie.StateTrie, message.StateProofStatus, error) {
+	block := s.blockProvider.GetBlock(blockHash, height)
+	if block == nil {
+		return nil, nil, message.StateProofUnknownBlock, errUnknownBlock
+	}
+	root := block.Root()
+	accountTrie, err := trie.NewStateTrie(trie.StateTrieID(root), s.trieDB)
+	if err != nil {
+		return nil, nil, message.StateProofStateUnavailable, err
+	}
+	var accountProof proofList
+	addrHash := crypto.Keccak256Hash(account.Bytes())
+	if err := accountTrie.Prove(addrHash[:], 0, &accountProof); err != nil {
+		return nil, nil, message.StateProofStateUnavailable, err
+	}
+	storageRoot, err := s.storageRoot(accountTrie, root, account)
+	if err != nil {
+		return nil, nil, message.StateProofStateUnavailable, err
+	}
+	storageTrie, err := trie.NewStateTrie(trie.StorageTrieID(root, addrHash, storageRoot), s.trieDB)
+	if err != nil {
+		return nil, nil, message.StateProofStateUnavailable, err
+	}
+	return accountProof, storageTrie, message.StateProofOK, nil
+}
+
+// storageRoot returns the storage root of [account] in the state with [root], reading
+// it from the snapshot if one is available for [root] and from [accountTrie] otherwise.
+func (s *StateProofRequestHandler) storageRoot(accountTrie *trie.StateTrie, root common.Hash, account common.Address) (common.Hash, error) {
+	if snaps := s.snapshotProvider.Snapshots(); snaps != nil {
+		if snap := snaps.Snapshot(root); snap != nil {
+			acc, err := snap.Account(crypto.Keccak256Hash(account.Bytes()))
+			if err == nil {
+				if acc == nil || len(acc.Root) == 0 {
+					return types.EmptyRootHash, nil
+				}
+				return common.BytesToHash(acc.Root), nil
+			}
+			// fall back to the trie if the snapshot cannot be read, eg. while it is generated
+		}
+	}
+	acc, err := accountTrie.GetAccount(account)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if acc == nil {
+		return types.EmptyRootHash, nil
+	}
+	return acc.Root, nil
+}
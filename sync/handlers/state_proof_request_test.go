@@ -0,0 +1,315 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/luxdefi/node/ids"
+	"github.com/luxdefi/evm/core/rawdb"
+	"github.com/luxdefi/evm/core/state"
+	"github.com/luxdefi/evm/core/state/snapshot"
+	"github.com/luxdefi/evm/core/types"
+	"github.com/luxdefi/evm/ethdb/memorydb"
+	"github.com/luxdefi/evm/plugin/evm/message"
+	"github.com/luxdefi/evm/sync/handlers/stats"
+	"github.com/luxdefi/evm/trie"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/assert"
+)
+
+// verifyProof verifies [proof] of [key] against [root] and returns the proven value.
+func verifyProof(t *testing.T, root common.Hash, key []byte, proof [][]byte) []byte {
+	proofDB := memorydb.New()
+	for _, node := range proof {
+		assert.NoError(t, proofDB.Put(crypto.Keccak256(node), node))
+	}
+	value, err := trie.VerifyProof(root, key, proofDB)
+	assert.NoError(t, err)
+	return value
+}
+
+func TestStateProofRequestHandler(t *testing.T) {
+	var (
+		diskDB  = rawdb.NewMemoryDatabase()
+		trieDB  = trie.NewDatabase(diskDB)
+		account = common.Address{0x01}
+		balance = big.NewInt(1_000_000)
+		slots   = make(map[common.Hash]common.Hash)
+	)
+	statedb, err := state.New(types.EmptyRootHash, state.NewDatabaseWithNodeDB(diskDB, trieDB), nil)
+	assert.NoError(t, err)
+	statedb.SetBalance(account, balance)
+	for i := int64(1); i <= 20; i++ {
+		slot, value := common.BigToHash(big.NewInt(i)), common.BigToHash(big.NewInt(i*i))
+		slots[slot] = value
+		statedb.SetState(account, slot, value)
+	}
+	root, err := statedb.Commit(false, false)
+	assert.NoError(t, err)
+	assert.NoError(t, trieDB.Commit(root, false))
+
+	block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1), Root: root})
+	prunedBlock := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(2), Root: common.Hash{0xde, 0xad}})
+	blockProvider := &TestBlockProvider{
+		GetBlockFn: func(hash common.Hash, height uint64) *types.Block {
+			for _, b := range []*types.Block{block, prunedBlock} {
+				if b.Hash() == hash && b.NumberU64() == height {
+					return b
+				}
+			}
+			return nil
+		},
+	}
+	snap, err := snapshot.New(snapshot.Config{CacheSize: 64, SkipVerify: true}, diskDB, trieDB, block.Hash(), root)
+	assert.NoError(t, err)
+
+	requestedSlots := []common.Hash{common.BigToHash(big.NewInt(3)), common.BigToHash(big.NewInt(100)), common.BigToHash(big.NewInt(17))}
+	tests := map[string]struct {
+		snapshotProvider *TestSnapshotProvider
+		request          message.StateProofRequest
+		expectedStatus   message.StateProofStatus
+		verifyStats      func(t *testing.T, stats *stats.MockHandlerStats)
+	}{
+		"proof from trie": {
+			snapshotProvider: &TestSnapshotProvider{},
+			request:          message.StateProofRequest{BlockHash: block.Hash(), Height: 1, Account: account, Slots: requestedSlots},
+			expectedStatus:   message.StateProofOK,
+			verifyStats: func(t *testing.T, stats *stats.MockHandlerStats) {
+				assert.EqualValues(t, 1, stats.StateProofRequestCount)
+				assert.EqualValues(t, 0, stats.MissingStateProofStateCount)
+				assert.NotZero(t, stats.StateProofBytesReturnedSum)
+			},
+		},
+		"proof with snapshot": {
+			snapshotProvider: &TestSnapshotProvider{Snapshot: snap},
+			request:          message.StateProofRequest{BlockHash: block.Hash(), Height: 1, Account: account, Slots: requestedSlots},
+			expectedStatus:   message.StateProofOK,
+			verifyStats: func(t *testing.T, stats *stats.MockHandlerStats) {
+				assert.EqualValues(t, 1, stats.StateProofRequestCount)
+				assert.EqualValues(t, 0, stats.MissingStateProofStateCount)
+			},
+		},
+		"proof of missing account": {
+			snapshotProvider: &TestSnapshotProvider{},
+			request:          message.StateProofRequest{BlockHash: block.Hash(), Height: 1, Account: common.Address{0x02}, Slots: requestedSlots[:1]},
+			expectedStatus:   message.StateProofOK,
+			verifyStats: func(t *testing.T, stats *stats.MockHandlerStats) {
+				assert.EqualValues(t, 1, stats.StateProofRequestCount)
+				assert.EqualValues(t, 0, stats.MissingStateProofStateCount)
+			},
+		},
+		"unknown block": {
+			snapshotProvider: &TestSnapshotProvider{},
+			request:          message.StateProofRequest{BlockHash: common.Hash{0x01}, Height: 1, Account: account},
+			expectedStatus:   message.StateProofUnknownBlock,
+			verifyStats: func(t *testing.T, stats *stats.MockHandlerStats) {
+				assert.EqualValues(t, 1, stats.StateProofRequestCount)
+				assert.EqualValues(t, 1, stats.MissingStateProofStateCount)
+			},
+		},
+		"unavailable state": {
+			snapshotProvider: &TestSnapshotProvider{},
+			request:          message.StateProofRequest{BlockHash: prunedBlock.Hash(), Height: 2, Account: account},
+			expectedStatus:   message.StateProofStateUnavailable,
+			verifyStats: func(t *testing.T, stats *stats.MockHandlerStats) {
+				assert.EqualValues(t, 1, stats.StateProofRequestCount)
+				assert.EqualValues(t, 1, stats.MissingStateProofStateCount)
+			},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockHandlerStats := &stats.MockHandlerStats{}
+			handler := NewStateProofRequestHandler(trieDB, blockProvider, test.snapshotProvider, message.Codec, mockHandlerStats, nil)
+			responseBytes, err := handler.OnStateProofRequest(context.Background(), ids.GenerateTestNodeID(), 1, test.request)
+			assert.NoError(t, err)
+			assert.NotEmpty(t, responseBytes)
+
+			var response message.StateProofResponse
+			_, err = message.Codec.Unmarshal(responseBytes, &response)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedStatus, response.Status)
+			test.verifyStats(t, mockHandlerStats)
+			if response.Status != message.StateProofOK {
+				assert.Empty(t, response.AccountProof)
+				assert.Empty(t, response.StorageProofs)
+				return
+			}
+
+			// verify the served proofs against the state root of the block
+			storageRoot := types.EmptyRootHash
+			accountRLP := verifyProof(t, root, crypto.Keccak256(test.request.Account.Bytes()), response.AccountProof)
+			if test.request.Account == account {
+				var acc types.StateAccount
+				assert.NoError(t, rlp.DecodeBytes(accountRLP, &acc))
+				assert.Zero(t, balance.Cmp(acc.Balance))
+				storageRoot = acc.Root
+			} else {
+				assert.Nil(t, accountRLP)
+			}
+			assert.Len(t, response.StorageProofs, len(test.request.Slots))
+			for i, slot := range test.request.Slots {
+				if storageRoot == types.EmptyRootHash {
+					// the storage of a missing account is proven by the account proof
+					assert.Empty(t, response.StorageProofs[i])
+					continue
+				}
+				value := verifyProof(t, storageRoot, crypto.Keccak256(slot[:]), response.StorageProofs[i])
+				expected, ok := slots[slot]
+				if !ok {
+					assert.Nil(t, value)
+					continue
+				}
+				_, content, _, err := rlp.Split(value)
+				assert.NoError(t, err)
+				assert.Equal(t, expected, common.BytesToHash(content))
+			}
+		})
+	}
+}
+
+func TestStateProofRequestHandler_TooManySlots(t *testing.T) {
+	mockHandlerStats := &stats.MockHandlerStats{}
+	handler := NewStateProofRequestHandler(trie.NewDatabase(memorydb.New()), &TestBlockProvider{}, &TestSnapshotProvider{}, message.Codec, mockHandlerStats, nil)
+	request := message.StateProofRequest{Slots: make([]common.Hash, message.MaxStateProofSlotsPerRequest+1)}
+	responseBytes, err := handler.OnStateProofRequest(context.Background(), ids.GenerateTestNodeID(), 1, request)
+	assert.NoError(t, err)
+	assert.Nil(t, responseBytes)
+	assert.EqualValues(t, 1, mockHandlerStats.InvalidStateProofRequestCount)
+}
+
+func TestStateMultiproofRequestHandler(t *testing.T) {
+	var (
+		diskDB  = rawdb.NewMemoryDatabase()
+		trieDB  = trie.NewDatabase(diskDB)
+		account = common.Address{0x01}
+		slots   = make([]common.Hash, 0, 20)
+		values  = make(map[common.Hash]common.Hash)
+	)
+	statedb, err := state.New(types.EmptyRootHash, state.NewDatabaseWithNodeDB(diskDB, trieDB), nil)
+	assert.NoError(t, err)
+	statedb.SetBalance(account, big.NewInt(1_000_000))
+	for i := int64(1); i <= 20; i++ {
+		slot, value := common.BigToHash(big.NewInt(i)), common.BigToHash(big.NewInt(i*i))
+		slots = append(slots, slot)
+		values[slot] = value
+		statedb.SetState(account, slot, value)
+	}
+	root, err := statedb.Commit(false, false)
+	assert.NoError(t, err)
+	assert.NoError(t, trieDB.Commit(root, false))
+
+	block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1), Root: root})
+	blockProvider := &TestBlockProvider{
+		GetBlockFn: func(hash common.Hash, height uint64) *types.Block {
+			if hash == block.Hash() && height == block.NumberU64() {
+				return block
+			}
+			return nil
+		},
+	}
+	mockHandlerStats := &stats.MockHandlerStats{}
+	handler := NewStateProofRequestHandler(trieDB, blockProvider, &TestSnapshotProvider{}, message.Codec, mockHandlerStats, nil)
+	request := message.StateMultiproofRequest{BlockHash: block.Hash(), Height: 1, Account: account, Slots: slots}
+	responseBytes, err := handler.OnStateMultiproofRequest(context.Background(), ids.GenerateTestNodeID(), 1, request)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, responseBytes)
+	assert.EqualValues(t, 1, mockHandlerStats.StateMultiproofRequestCount)
+
+	var response message.StateMultiproofResponse
+	_, err = message.Codec.Unmarshal(responseBytes, &response)
+	assert.NoError(t, err)
+	assert.Equal(t, message.StateProofOK, response.Status)
+	assert.EqualValues(t, len(slots), response.NumSlots)
+
+	// the multiproof is smaller than the per-slot proofs of the same slots,
+	// and includes each node once
+	unique := make(map[common.Hash]struct{})
+	for _, node := range response.StorageProof {
+		unique[crypto.Keccak256Hash(node)] = struct{}{}
+	}
+	assert.Len(t, unique, len(response.StorageProof))
+	proofResponseBytes, err := handler.OnStateProofRequest(context.Background(), ids.GenerateTestNodeID(), 1, message.StateProofRequest(request))
+	assert.NoError(t, err)
+	var proofResponse message.StateProofResponse
+	_, err = message.Codec.Unmarshal(proofResponseBytes, &proofResponse)
+	assert.NoError(t, err)
+	perSlotNodes := 0
+	for _, storageProof := range proofResponse.StorageProofs {
+		perSlotNodes += len(storageProof)
+	}
+	assert.Less(t, len(response.StorageProof), perSlotNodes)
+
+	// verify every slot from the one proof against the storage root of the account
+	var acc types.StateAccount
+	accountRLP := verifyProof(t, root, crypto.Keccak256(account.Bytes()), response.AccountProof)
+	assert.NoError(t, rlp.DecodeBytes(accountRLP, &acc))
+	for _, slot := range slots {
+		value := verifyProof(t, acc.Root, crypto.Keccak256(slot[:]), response.StorageProof)
+		_, content, _, err := rlp.Split(value)
+		assert.NoError(t, err)
+		assert.Equal(t, values[slot], common.BytesToHash(content))
+	}
+}
+
+func BenchmarkStateProofRequestHandler(b *testing.B) {
+	var (
+		diskDB  = rawdb.NewMemoryDatabase()
+		trieDB  = trie.NewDatabase(diskDB)
+		account = common.Address{0x01}
+		slots   []common.Hash
+	)
+	statedb, err := state.New(types.EmptyRootHash, state.NewDatabaseWithNodeDB(diskDB, trieDB), nil)
+	assert.NoError(b, err)
+	for i := int64(1); i <= message.MaxStateProofSlotsPerRequest; i++ {
+		slot := common.BigToHash(big.NewInt(i))
+		slots = append(slots, slot)
+		statedb.SetState(account, slot, common.BigToHash(big.NewInt(i*i)))
+	}
+	root, err := statedb.Commit(false, false)
+	assert.NoError(b, err)
+	assert.NoError(b, trieDB.Commit(root, false))
+
+	block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1), Root: root})
+	blockProvider := &TestBlockProvider{
+		GetBlockFn: func(hash common.Hash, height uint64) *types.Block { return block },
+	}
+	request := message.StateProofRequest{BlockHash: block.Hash(), Height: 1, Account: account, Slots: slots}
+
+	for _, workers := range []int{0, runtime.NumCPU()} {
+		for _, clients := range []int{1, 4} {
+			b.Run(fmt.Sprintf("workers=%d/clients=%d", workers, clients), func(b *testing.B) {
+				handler := NewStateProofRequestHandler(trieDB, blockProvider, &TestSnapshotProvider{}, message.Codec, stats.NewNoopHandlerStats(), NewProofWorkers(workers))
+				var (
+					wg       sync.WaitGroup
+					requests atomic.Int64
+				)
+				b.ResetTimer()
+				// each client sends its next request once served, until b.N requests are served
+				for i := 0; i < clients; i++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						for requests.Add(1) <= int64(b.N) {
+							if _, err := handler.OnStateProofRequest(context.Background(), ids.GenerateTestNodeID(), 1, request); err != nil {
+								b.Error(err)
+								return
+							}
+						}
+					}()
+				}
+				wg.Wait()
+			})
+		}
+	}
+}
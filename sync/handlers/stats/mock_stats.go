@@ -42,6 +42,30 @@ type MockHandlerStats struct {
 	SnapshotReadTime,
 	GenerateRangeProofTime,
 	LeafRequestProcessingTimeSum time.Duration
+
+	ReceiptsRequestCount,
+	MissingReceiptsCount,
+	ReceiptsReturnedSum uint32
+	ReceiptsRequestProcessingTimeSum time.Duration
+
+	TrieNodeRequestCount,
+	TooManyTrieNodesRequestedCount,
+	MissingTrieNodeCount,
+	TrieNodesServedSum,
+	TrieNodeBytesReturnedSum uint32
+	TrieNodeRequestProcessingTimeSum time.Duration
+
+	StateProofRequestCount,
+	StateMultiproofRequestCount,
+	InvalidStateProofRequestCount,
+	MissingStateProofStateCount,
+	StateProofBytesReturnedSum uint32
+	StateProofRequestProcessingTimeSum time.Duration
+
+	SnapshotDiffRequestCount,
+	MissingSnapshotDiffStateCount,
+	SnapshotDiffBytesReturnedSum uint32
+	SnapshotDiffRequestProcessingTimeSum time.Duration
 }
 
 func (m *MockHandlerStats) Reset() {
@@ -73,6 +97,26 @@ func (m *MockHandlerStats) Reset() {
 	m.SnapshotReadTime = 0
 	m.GenerateRangeProofTime = 0
 	m.LeafRequestProcessingTimeSum = 0
+	m.ReceiptsRequestCount = 0
+	m.MissingReceiptsCount = 0
+	m.ReceiptsReturnedSum = 0
+	m.ReceiptsRequestProcessingTimeSum = 0
+	m.TrieNodeRequestCount = 0
+	m.TooManyTrieNodesRequestedCount = 0
+	m.MissingTrieNodeCount = 0
+	m.TrieNodesServedSum = 0
+	m.TrieNodeBytesReturnedSum = 0
+	m.TrieNodeRequestProcessingTimeSum = 0
+	m.StateProofRequestCount = 0
+	m.StateMultiproofRequestCount = 0
+	m.InvalidStateProofRequestCount = 0
+	m.MissingStateProofStateCount = 0
+	m.StateProofBytesReturnedSum = 0
+	m.StateProofRequestProcessingTimeSum = 0
+	m.SnapshotDiffRequestCount = 0
+	m.MissingSnapshotDiffStateCount = 0
+	m.SnapshotDiffBytesReturnedSum = 0
+	m.SnapshotDiffRequestProcessingTimeSum = 0
 }
 
 func (m *MockHandlerStats) IncBlockRequest() {
@@ -230,3 +274,123 @@ func (m *MockHandlerStats) IncSnapshotSegmentInvalid() {
 	defer m.lock.Unlock()
 	m.SnapshotSegmentInvalidCount++
 }
+
+func (m *MockHandlerStats) IncReceiptsRequest() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.ReceiptsRequestCount++
+}
+
+func (m *MockHandlerStats) IncMissingReceipts() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.MissingReceiptsCount++
+}
+
+func (m *MockHandlerStats) UpdateReceiptsReturned(num uint16) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.ReceiptsReturnedSum += uint32(num)
+}
+
+func (m *MockHandlerStats) UpdateReceiptsRequestProcessingTime(duration time.Duration) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.ReceiptsRequestProcessingTimeSum += duration
+}
+
+func (m *MockHandlerStats) IncTrieNodeRequest() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.TrieNodeRequestCount++
+}
+
+func (m *MockHandlerStats) IncTooManyTrieNodesRequested() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.TooManyTrieNodesRequestedCount++
+}
+
+func (m *MockHandlerStats) IncMissingTrieNode() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.MissingTrieNodeCount++
+}
+
+func (m *MockHandlerStats) UpdateTrieNodesServed(num uint16) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.TrieNodesServedSum += uint32(num)
+}
+
+func (m *MockHandlerStats) UpdateTrieNodeBytesReturned(bytes uint32) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.TrieNodeBytesReturnedSum += bytes
+}
+
+func (m *MockHandlerStats) UpdateTrieNodeRequestProcessingTime(duration time.Duration) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.TrieNodeRequestProcessingTimeSum += duration
+}
+
+func (m *MockHandlerStats) IncStateProofRequest() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.StateProofRequestCount++
+}
+
+func (m *MockHandlerStats) IncStateMultiproofRequest() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.StateMultiproofRequestCount++
+}
+
+func (m *MockHandlerStats) IncInvalidStateProofRequest() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.InvalidStateProofRequestCount++
+}
+
+func (m *MockHandlerStats) IncMissingStateProofState() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.MissingStateProofStateCount++
+}
+
+func (m *MockHandlerStats) UpdateStateProofBytesReturned(bytes uint32) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.StateProofBytesReturnedSum += bytes
+}
+
+func (m *MockHandlerStats) UpdateStateProofRequestProcessingTime(duration time.Duration) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.StateProofRequestProcessingTimeSum += duration
+}
+
+func (m *MockHandlerStats) IncSnapshotDiffRequest() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.SnapshotDiffRequestCount++
+}
+
+func (m *MockHandlerStats) IncMissingSnapshotDiffState() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.MissingSnapshotDiffStateCount++
+}
+
+func (m *MockHandlerStats) UpdateSnapshotDiffBytesReturned(bytes uint32) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.SnapshotDiffBytesReturnedSum += bytes
+}
+
+func (m *MockHandlerStats) UpdateSnapshotDiffRequestProcessingTime(duration time.Duration) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.SnapshotDiffRequestProcessingTimeSum += duration
+}
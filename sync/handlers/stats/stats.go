@@ -14,6 +14,10 @@ type HandlerStats interface {
 	BlockRequestHandlerStats
 	CodeRequestHandlerStats
 	LeafsRequestHandlerStats
+	ReceiptsRequestHandlerStats
+	TrieNodeRequestHandlerStats
+	StateProofRequestHandlerStats
+	SnapshotDiffRequestHandlerStats
 }
 
 type BlockRequestHandlerStats interface {
@@ -51,6 +55,38 @@ type LeafsRequestHandlerStats interface {
 	IncSnapshotSegmentInvalid()
 }
 
+type ReceiptsRequestHandlerStats interface {
+	IncReceiptsRequest()
+	IncMissingReceipts()
+	UpdateReceiptsReturned(num uint16)
+	UpdateReceiptsRequestProcessingTime(duration time.Duration)
+}
+
+type TrieNodeRequestHandlerStats interface {
+	IncTrieNodeRequest()
+	IncTooManyTrieNodesRequested()
+	IncMissingTrieNode()
+	UpdateTrieNodesServed(num uint16)
+	UpdateTrieNodeBytesReturned(bytes uint32)
+	UpdateTrieNodeRequestProcessingTime(duration time.Duration)
+}
+
+type StateProofRequestHandlerStats interface {
+	IncStateProofRequest()
+	IncStateMultiproofRequest()
+	IncInvalidStateProofRequest()
+	IncMissingStateProofState()
+	UpdateStateProofBytesReturned(bytes uint32)
+	UpdateStateProofRequestProcessingTime(duration time.Duration)
+}
+
+type SnapshotDiffRequestHandlerStats interface {
+	IncSnapshotDiffRequest()
+	IncMissingSnapshotDiffState()
+	UpdateSnapshotDiffBytesReturned(bytes uint32)
+	UpdateSnapshotDiffRequestProcessingTime(duration time.Duration)
+}
+
 type handlerStats struct {
 	// BlockRequestHandler metrics
 	blockRequest               metrics.Counter
@@ -83,6 +119,34 @@ type handlerStats struct {
 	snapshotReadSuccess        metrics.Counter
 	snapshotSegmentValid       metrics.Counter
 	snapshotSegmentInvalid     metrics.Counter
+
+	// ReceiptsRequestHandler stats
+	receiptsRequest               metrics.Counter
+	missingReceipts               metrics.Counter
+	receiptsReturned              metrics.Histogram
+	receiptsRequestProcessingTime metrics.Timer
+
+	// TrieNodeRequestHandler stats
+	trieNodeRequest               metrics.Counter
+	tooManyTrieNodesRequested     metrics.Counter
+	missingTrieNode               metrics.Counter
+	trieNodesServed               metrics.Histogram
+	trieNodeBytesReturned         metrics.Histogram
+	trieNodeRequestProcessingTime metrics.Timer
+
+	// StateProofRequestHandler stats
+	stateProofRequest               metrics.Counter
+	stateMultiproofRequest          metrics.Counter
+	invalidStateProofRequest        metrics.Counter
+	missingStateProofState          metrics.Counter
+	stateProofBytesReturned         metrics.Histogram
+	stateProofRequestProcessingTime metrics.Timer
+
+	// SnapshotDiffRequestHandler stats
+	snapshotDiffRequest               metrics.Counter
+	missingSnapshotDiffState          metrics.Counter
+	snapshotDiffBytesReturned         metrics.Histogram
+	snapshotDiffRequestProcessingTime metrics.Timer
 }
 
 func (h *handlerStats) IncBlockRequest() {
@@ -166,6 +230,86 @@ func (h *handlerStats) IncSnapshotReadSuccess()    { h.snapshotReadSuccess.Inc(1
 func (h *handlerStats) IncSnapshotSegmentValid()   { h.snapshotSegmentValid.Inc(1) }
 func (h *handlerStats) IncSnapshotSegmentInvalid() { h.snapshotSegmentInvalid.Inc(1) }
 
+func (h *handlerStats) IncReceiptsRequest() {
+	h.receiptsRequest.Inc(1)
+}
+
+func (h *handlerStats) IncMissingReceipts() {
+	h.missingReceipts.Inc(1)
+}
+
+func (h *handlerStats) UpdateReceiptsReturned(num uint16) {
+	h.receiptsReturned.Update(int64(num))
+}
+
+func (h *handlerStats) UpdateReceiptsRequestProcessingTime(duration time.Duration) {
+	h.receiptsRequestProcessingTime.Update(duration)
+}
+
+func (h *handlerStats) IncTrieNodeRequest() {
+	h.trieNodeRequest.Inc(1)
+}
+
+func (h *handlerStats) IncTooManyTrieNodesRequested() {
+	h.tooManyTrieNodesRequested.Inc(1)
+}
+
+func (h *handlerStats) IncMissingTrieNode() {
+	h.missingTrieNode.Inc(1)
+}
+
+func (h *handlerStats) UpdateTrieNodesServed(num uint16) {
+	h.trieNodesServed.Update(int64(num))
+}
+
+func (h *handlerStats) UpdateTrieNodeBytesReturned(bytesLen uint32) {
+	h.trieNodeBytesReturned.Update(int64(bytesLen))
+}
+
+func (h *handlerStats) UpdateTrieNodeRequestProcessingTime(duration time.Duration) {
+	h.trieNodeRequestProcessingTime.Update(duration)
+}
+
+func (h *handlerStats) IncStateProofRequest() {
+	h.stateProofRequest.Inc(1)
+}
+
+func (h *handlerStats) IncStateMultiproofRequest() {
+	h.stateMultiproofRequest.Inc(1)
+}
+
+func (h *handlerStats) IncInvalidStateProofRequest() {
+	h.invalidStateProofRequest.Inc(1)
+}
+
+func (h *handlerStats) IncMissingStateProofState() {
+	h.missingStateProofState.Inc(1)
+}
+
+func (h *handlerStats) UpdateStateProofBytesReturned(bytesLen uint32) {
+	h.stateProofBytesReturned.Update(int64(bytesLen))
+}
+
+func (h *handlerStats) UpdateStateProofRequestProcessingTime(duration time.Duration) {
+	h.stateProofRequestProcessingTime.Update(duration)
+}
+
+func (h *handlerStats) IncSnapshotDiffRequest() {
+	h.snapshotDiffRequest.Inc(1)
+}
+
+func (h *handlerStats) IncMissingSnapshotDiffState() {
+	h.missingSnapshotDiffState.Inc(1)
+}
+
+func (h *handlerStats) UpdateSnapshotDiffBytesReturned(bytesLen uint32) {
+	h.snapshotDiffBytesReturned.Update(int64(bytesLen))
+}
+
+func (h *handlerStats) UpdateSnapshotDiffRequestProcessingTime(duration time.Duration) {
+	h.snapshotDiffRequestProcessingTime.Update(duration)
+}
+
 func NewHandlerStats(enabled bool) HandlerStats {
 	if !enabled {
 		return NewNoopHandlerStats()
@@ -202,6 +346,34 @@ func NewHandlerStats(enabled bool) HandlerStats {
 		snapshotReadSuccess:        metrics.GetOrRegisterCounter("leafs_request_snapshot_read_success", nil),
 		snapshotSegmentValid:       metrics.GetOrRegisterCounter("leafs_request_snapshot_segment_valid", nil),
 		snapshotSegmentInvalid:     metrics.GetOrRegisterCounter("leafs_request_snapshot_segment_invalid", nil),
+
+		// initialize receipts request stats
+		receiptsRequest:               metrics.GetOrRegisterCounter("receipts_request_count", nil),
+		missingReceipts:               metrics.GetOrRegisterCounter("receipts_request_missing_receipts", nil),
+		receiptsReturned:              metrics.GetOrRegisterHistogram("receipts_request_total_receipts", nil, metrics.NewExpDecaySample(1028, 0.015)),
+		receiptsRequestProcessingTime: metrics.GetOrRegisterTimer("receipts_request_processing_time", nil),
+
+		// initialize trie node request stats
+		trieNodeRequest:               metrics.GetOrRegisterCounter("trie_node_request_count", nil),
+		tooManyTrieNodesRequested:     metrics.GetOrRegisterCounter("trie_node_request_too_many_hashes", nil),
+		missingTrieNode:               metrics.GetOrRegisterCounter("trie_node_request_missing_node", nil),
+		trieNodesServed:               metrics.GetOrRegisterHistogram("trie_node_request_nodes_served", nil, metrics.NewExpDecaySample(1028, 0.015)),
+		trieNodeBytesReturned:         metrics.GetOrRegisterHistogram("trie_node_request_bytes_returned", nil, metrics.NewExpDecaySample(1028, 0.015)),
+		trieNodeRequestProcessingTime: metrics.GetOrRegisterTimer("trie_node_request_processing_time", nil),
+
+		// initialize state proof request stats
+		stateProofRequest:               metrics.GetOrRegisterCounter("state_proof_request_count", nil),
+		stateMultiproofRequest:          metrics.GetOrRegisterCounter("state_multiproof_request_count", nil),
+		invalidStateProofRequest:        metrics.GetOrRegisterCounter("state_proof_request_invalid", nil),
+		missingStateProofState:          metrics.GetOrRegisterCounter("state_proof_request_missing_state", nil),
+		stateProofBytesReturned:         metrics.GetOrRegisterHistogram("state_proof_request_bytes_returned", nil, metrics.NewExpDecaySample(1028, 0.015)),
+		stateProofRequestProcessingTime: metrics.GetOrRegisterTimer("state_proof_request_processing_time", nil),
+
+		// initialize snapshot diff request stats
+		snapshotDiffRequest:               metrics.GetOrRegisterCounter("snapshot_diff_request_count", nil),
+		missingSnapshotDiffState:          metrics.GetOrRegisterCounter("snapshot_diff_request_missing_state", nil),
+		snapshotDiffBytesReturned:         metrics.GetOrRegisterHistogram("snapshot_diff_request_bytes_returned", nil, metrics.NewExpDecaySample(1028, 0.015)),
+		snapshotDiffRequestProcessingTime: metrics.GetOrRegisterTimer("snapshot_diff_request_processing_time", nil),
 	}
 }
 
@@ -239,3 +411,24 @@ func (n *noopHandlerStats) IncSnapshotReadAttempt()
 func (n *noopHandlerStats) IncSnapshotReadSuccess()                             {}
 func (n *noopHandlerStats) IncSnapshotSegmentValid()                            {}
 func (n *noopHandlerStats) IncSnapshotSegmentInvalid()                          {}
+func (n *noopHandlerStats) IncReceiptsRequest()                                 {}
+func (n *noopHandlerStats) IncMissingReceipts()                                 {}
+func (n *noopHandlerStats) UpdateReceiptsReturned(uint16)                       {}
+func (n *noopHandlerStats) UpdateReceiptsRequestProcessingTime(time.Duration)   {}
+func (n *noopHandlerStats) IncTrieNodeRequest()                                 {}
+func (n *noopHandlerStats) IncTooManyTrieNodesRequested()                       {}
+func (n *noopHandlerStats) IncMissingTrieNode()                                 {}
+func (n *noopHandlerStats) UpdateTrieNodesServed(uint16)                        {}
+func (n *noopHandlerStats) UpdateTrieNodeBytesReturned(uint32)                  {}
+func (n *noopHandlerStats) UpdateTrieNodeRequestProcessingTime(time.Duration)   {}
+func (n *noopHandlerStats) IncStateProofRequest()                               {}
+func (n *noopHandlerStats) IncStateMultiproofRequest()                          {}
+func (n *noopHandlerStats) IncInvalidStateProofRequest()                        {}
+func (n *noopHandlerStats) IncMissingStateProofState()                          {}
+func (n *noopHandlerStats) UpdateStateProofBytesReturned(uint32)                {}
+func (n *noopHandlerStats) UpdateStateProofRequestProcessingTime(time.Duration) {}
+
+func (n *noopHandlerStats) IncSnapshotDiffRequest()                               {}
+func (n *noopHandlerStats) IncMissingSnapshotDiffState()                          {}
+func (n *noopHandlerStats) UpdateSnapshotDiffBytesReturned(uint32)                {}
+func (n *noopHandlerStats) UpdateSnapshotDiffRequestProcessingTime(time.Duration) {}
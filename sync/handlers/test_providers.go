@@ -12,6 +12,7 @@ import (
 var (
 	_ BlockProvider    = &TestBlockProvider{}
 	_ SnapshotProvider = &TestSnapshotProvider{}
+	_ ReceiptProvider  = &TestReceiptProvider{}
 )
 
 type TestBlockProvider struct {
@@ -29,3 +30,11 @@ type TestSnapshotProvider struct {
 func (t *TestSnapshotProvider) Snapshots() *snapshot.Tree {
 	return t.Snapshot
 }
+
+type TestReceiptProvider struct {
+	GetReceiptsByHashFn func(common.Hash) types.Receipts
+}
+
+func (t *TestReceiptProvider) GetReceiptsByHash(hash common.Hash) types.Receipts {
+	return t.GetReceiptsByHashFn(hash)
+}
@@ -0,0 +1,104 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/luxdefi/node/codec"
+	"github.com/luxdefi/node/ids"
+	"github.com/luxdefi/node/utils/units"
+
+	"github.com/luxdefi/evm/plugin/evm/message"
+	"github.com/luxdefi/evm/sync/handlers/stats"
+	"github.com/luxdefi/evm/trie"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// trieNodeResponseSizeLimit caps the total size of trie nodes returned in a single response
+// so that the response stays well within the network message size limit.
+const trieNodeResponseSizeLimit = 512 * units.KiB
+
+// TrieNodeRequestHandler is a peer.RequestHandler for message.TrieNodeRequest
+// serving requested trie nodes by their hashes
+type TrieNodeRequestHandler struct {
+	trieDB *trie.Database
+	codec  codec.Manager
+	stats  stats.TrieNodeRequestHandlerStats
+}
+
+func NewTrieNodeRequestHandler(trieDB *trie.Database, codec codec.Manager, handlerStats stats.TrieNodeRequestHandlerStats) *TrieNodeRequestHandler {
+	return &TrieNodeRequestHandler{
+		trieDB: trieDB,
+		codec:  codec,
+		stats:  handlerStats,
+	}
+}
+
+// OnTrieNodeRequest handles request to retrieve trie nodes by their hashes in message.TrieNodeRequest
+// Never returns error
+// Skips hashes that are not found and stops once the response size limit is reached
+// Returns nothing if none of the requested nodes are found
+// Expects returned errors to be treated as FATAL
+// Assumes ctx is active
+func (t *TrieNodeRequestHandler) OnTrieNodeRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, trieNodeRequest message.TrieNodeRequest) ([]byte, error) {
+	startTime := time.Now()
+	t.stats.IncTrieNodeRequest()
+
+	var (
+		nodes      [][]byte
+		totalBytes = 0
+	)
+	// ensure metrics are captured properly on all return paths
+	defer func() {
+		t.stats.UpdateTrieNodeRequestProcessingTime(time.Since(startTime))
+		t.stats.UpdateTrieNodesServed(uint16(len(nodes)))
+		t.stats.UpdateTrieNodeBytesReturned(uint32(totalBytes))
+	}()
+
+	if len(trieNodeRequest.Hashes) > message.MaxTrieNodeHashesPerRequest {
+		t.stats.IncTooManyTrieNodesRequested()
+		log.Debug("too many trie node hashes requested, dropping request", "nodeID", nodeID, "requestID", requestID, "numHashes", len(trieNodeRequest.Hashes))
+		return nil, nil
+	}
+
+	sizeLimit := responseSizeLimit(trieNodeRequest.MaxResponseSize, trieNodeResponseSizeLimit)
+	nodes = make([][]byte, 0, len(trieNodeRequest.Hashes))
+	for _, hash := range trieNodeRequest.Hashes {
+		// we return whatever we have until ctx errors or the size limit is exceeded
+		if ctx.Err() != nil {
+			break
+		}
+
+		blob, err := t.trieDB.Node(hash)
+		if err != nil || len(blob) == 0 {
+			t.stats.IncMissingTrieNode()
+			continue
+		}
+		if totalBytes+len(blob) > sizeLimit {
+			break
+		}
+
+		totalBytes += len(blob)
+		nodes = append(nodes, blob)
+	}
+
+	if len(nodes) == 0 {
+		// drop this request
+		log.Debug("no requested trie nodes found, dropping request", "nodeID", nodeID, "requestID", requestID, "numHashes", len(trieNodeRequest.Hashes))
+		return nil, nil
+	}
+
+	response := message.TrieNodeResponse{
+		Nodes: nodes,
+	}
+	responseBytes, err := t.codec.Marshal(message.Version, response)
+	if err != nil {
+		log.Error("failed to marshal TrieNodeResponse, dropping request", "nodeID", nodeID, "requestID", requestID, "numHashes", len(trieNodeRequest.Hashes), "nodesLen", len(response.Nodes), "err", err)
+		return nil, nil
+	}
+
+	return responseBytes, nil
+}
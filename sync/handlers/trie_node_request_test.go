@@ -0,0 +1,119 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package handlers
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/luxdefi/node/ids"
+	"github.com/luxdefi/evm/ethdb/memorydb"
+	"github.com/luxdefi/evm/plugin/evm/message"
+	"github.com/luxdefi/evm/sync/handlers/stats"
+	"github.com/luxdefi/evm/trie"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrieNodeRequestHandler(t *testing.T) {
+	rand.Seed(1)
+	trieDB := trie.NewDatabase(memorydb.New())
+	root, _, _ := trie.GenerateTrie(t, trieDB, 100, common.HashLength)
+
+	// collect the hashes of the trie's nodes and their encodings
+	tr, err := trie.New(trie.TrieID(root), trieDB)
+	assert.NoError(t, err)
+	var (
+		knownHashes []common.Hash
+		knownNodes  [][]byte
+	)
+	nodeIt := tr.NodeIterator(nil)
+	for nodeIt.Next(true) && len(knownHashes) < 10 {
+		if nodeIt.Hash() == (common.Hash{}) {
+			continue
+		}
+		blob, err := trieDB.Node(nodeIt.Hash())
+		assert.NoError(t, err)
+		knownHashes = append(knownHashes, nodeIt.Hash())
+		knownNodes = append(knownNodes, blob)
+	}
+	assert.NoError(t, nodeIt.Error())
+	assert.Len(t, knownHashes, 10)
+
+	unknownHashes := []common.Hash{
+		crypto.Keccak256Hash([]byte("unknown node 1")),
+		crypto.Keccak256Hash([]byte("unknown node 2")),
+	}
+
+	mockHandlerStats := &stats.MockHandlerStats{}
+	trieNodeRequestHandler := NewTrieNodeRequestHandler(trieDB, message.Codec, mockHandlerStats)
+
+	tests := map[string]struct {
+		hashes        []common.Hash
+		expectedNodes [][]byte
+		verifyStats   func(t *testing.T, stats *stats.MockHandlerStats)
+	}{
+		"known hashes": {
+			hashes:        knownHashes,
+			expectedNodes: knownNodes,
+			verifyStats: func(t *testing.T, stats *stats.MockHandlerStats) {
+				assert.EqualValues(t, 1, stats.TrieNodeRequestCount)
+				assert.EqualValues(t, 0, stats.MissingTrieNodeCount)
+				assert.EqualValues(t, len(knownNodes), stats.TrieNodesServedSum)
+			},
+		},
+		"mix of known and unknown hashes": {
+			hashes:        []common.Hash{unknownHashes[0], knownHashes[0], knownHashes[1], unknownHashes[1], knownHashes[2]},
+			expectedNodes: [][]byte{knownNodes[0], knownNodes[1], knownNodes[2]},
+			verifyStats: func(t *testing.T, stats *stats.MockHandlerStats) {
+				assert.EqualValues(t, 1, stats.TrieNodeRequestCount)
+				assert.EqualValues(t, 2, stats.MissingTrieNodeCount)
+				assert.EqualValues(t, 3, stats.TrieNodesServedSum)
+				assert.EqualValues(t, len(knownNodes[0])+len(knownNodes[1])+len(knownNodes[2]), stats.TrieNodeBytesReturnedSum)
+			},
+		},
+		"only unknown hashes": {
+			hashes:        unknownHashes,
+			expectedNodes: nil,
+			verifyStats: func(t *testing.T, stats *stats.MockHandlerStats) {
+				assert.EqualValues(t, 1, stats.TrieNodeRequestCount)
+				assert.EqualValues(t, 2, stats.MissingTrieNodeCount)
+				assert.EqualValues(t, 0, stats.TrieNodesServedSum)
+			},
+		},
+		"too many hashes": {
+			hashes:        make([]common.Hash, message.MaxTrieNodeHashesPerRequest+1),
+			expectedNodes: nil,
+			verifyStats: func(t *testing.T, stats *stats.MockHandlerStats) {
+				assert.EqualValues(t, 1, stats.TooManyTrieNodesRequestedCount)
+				assert.EqualValues(t, 0, stats.TrieNodesServedSum)
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockHandlerStats.Reset()
+			request := message.NewTrieNodeRequest(test.hashes)
+			responseBytes, err := trieNodeRequestHandler.OnTrieNodeRequest(context.Background(), ids.GenerateTestNodeID(), 1, request)
+			assert.NoError(t, err)
+			test.verifyStats(t, mockHandlerStats)
+
+			// If the expected response is empty, assert that the handler returns an empty response and return early.
+			if len(test.expectedNodes) == 0 {
+				assert.Len(t, responseBytes, 0, "expected response to be empty")
+				return
+			}
+			var response message.TrieNodeResponse
+			_, err = message.Codec.Unmarshal(responseBytes, &response)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedNodes, response.Nodes)
+			for _, node := range response.Nodes {
+				assert.Contains(t, test.hashes, crypto.Keccak256Hash(node))
+			}
+		})
+	}
+}
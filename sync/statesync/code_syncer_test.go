@@ -41,7 +41,7 @@ func testCodeSyncer(t *testing.T, test codeSyncerTest) {
 
 	// Set up mockClient
 	codeRequestHandler := handlers.NewCodeRequestHandler(serverDB, message.Codec, handlerstats.NewNoopHandlerStats())
-	mockClient := statesyncclient.NewMockClient(message.Codec, nil, codeRequestHandler, nil)
+	mockClient := statesyncclient.NewMockClient(message.Codec, nil, codeRequestHandler, nil, nil, nil)
 	mockClient.GetCodeIntercept = test.getCodeIntercept
 
 	clientDB := memorydb.New()
@@ -0,0 +1,250 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package statesync
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/luxdefi/evm/core/rawdb"
+	"github.com/luxdefi/evm/core/state/snapshot"
+	"github.com/luxdefi/evm/core/types"
+	"github.com/luxdefi/evm/ethdb"
+	"github.com/luxdefi/evm/plugin/evm/message"
+	syncclient "github.com/luxdefi/evm/sync/client"
+	"github.com/luxdefi/evm/trie"
+	"github.com/luxdefi/evm/trie/trienode"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+var (
+	errSnapshotDiffRootMismatch    = errors.New("snapshot diff does not result in the state root of the block")
+	errSnapshotDiffStorageMismatch = errors.New("snapshot diff does not result in the storage root of the account")
+	errMalformedSnapshotDiff       = errors.New("malformed snapshot diff")
+)
+
+// SyncSnapshotDiff fetches the diff of [block] from peers with [client] and
+// applies it on top of the state [parentRoot] of its parent with
+// ApplySnapshotDiff. The code of the accounts it modifies that is missing from
+// [chaindb] is fetched first, since the diff only carries their code hash.
+// Diffs that fail verification are requested again, from another peer if any,
+// until [ctx] is done.
+func SyncSnapshotDiff(ctx context.Context, client syncclient.Client, chaindb ethdb.Database, triedb *trie.Database, snaps *snapshot.Tree, block *types.Block, parentRoot common.Hash) error {
+	for {
+		diff, err := client.GetSnapshotDiff(ctx, block)
+		if err != nil {
+			return err
+		}
+		err = syncSnapshotDiffCode(ctx, client, chaindb, diff)
+		if err == nil {
+			err = ApplySnapshotDiff(triedb, snaps, block, parentRoot, diff)
+		}
+		if !errors.Is(err, errSnapshotDiffRootMismatch) && !errors.Is(err, errSnapshotDiffStorageMismatch) && !errors.Is(err, errMalformedSnapshotDiff) {
+			return err
+		}
+		log.Debug("invalid snapshot diff, retrying", "hash", block.Hash(), "height", block.NumberU64(), "err", err)
+		if ctx.Err() != nil {
+			return fmt.Errorf("could not sync snapshot diff (%s) with last error %w and ctx error %s", block.Hash(), err, ctx.Err())
+		}
+	}
+}
+
+// syncSnapshotDiffCode fetches the code of the accounts in [diff] that is
+// missing from [chaindb] with [client], and writes it to [chaindb]. Code is
+// keyed by its hash, so it is safe to write before the diff is verified.
+func syncSnapshotDiffCode(ctx context.Context, client syncclient.Client, chaindb ethdb.Database, diff message.SnapshotDiffResponse) error {
+	var (
+		missing []common.Hash
+		seen    = make(map[common.Hash]struct{})
+	)
+	for _, diffAccount := range diff.Accounts {
+		account, err := snapshot.FullAccount(diffAccount.Account)
+		if err != nil {
+			return fmt.Errorf("%w: invalid account %s: %v", errMalformedSnapshotDiff, diffAccount.Hash, err)
+		}
+		codeHash := common.BytesToHash(account.CodeHash)
+		if _, ok := seen[codeHash]; ok || codeHash == types.EmptyCodeHash || rawdb.HasCode(chaindb, codeHash) {
+			continue
+		}
+		seen[codeHash] = struct{}{}
+		missing = append(missing, codeHash)
+	}
+
+	batch := chaindb.NewBatch()
+	for start := 0; start < len(missing); start += message.MaxCodeHashesPerRequest {
+		end := start + message.MaxCodeHashesPerRequest
+		if end > len(missing) {
+			end = len(missing)
+		}
+		codeHashes := missing[start:end]
+		codes, err := client.GetCode(ctx, codeHashes)
+		if err != nil {
+			return err
+		}
+		for i, code := range codes {
+			rawdb.WriteCode(batch, codeHashes[i], code)
+		}
+	}
+	return batch.Write()
+}
+
+// ApplySnapshotDiff applies [diff], as served for [block] by a
+// SnapshotDiffRequestHandler, on top of the state [parentRoot] of the parent
+// of [block], so that a follower tracks the head without executing blocks.
+//
+// The diff is verified before anything is written: its hashes must be sorted
+// and unique, as served, with no account both destructed and modified, and
+// applying it to the state trie of [parentRoot] in [triedb] must result in the
+// storage root of every account it modifies and in the state root of [block]. The resulting trie
+// nodes are then added to [triedb], and the diff is added as a layer on top of
+// the parent block in [snaps] if it is not nil.
+func ApplySnapshotDiff(triedb *trie.Database, snaps *snapshot.Tree, block *types.Block, parentRoot common.Hash, diff message.SnapshotDiffResponse) error {
+	if diff.ParentRoot != parentRoot || diff.Root != block.Root() {
+		return fmt.Errorf("%w: diff from %s to %s, expected %s to %s", errSnapshotDiffRootMismatch, diff.ParentRoot, diff.Root, parentRoot, block.Root())
+	}
+	if err := validateSnapshotDiff(diff); err != nil {
+		return err
+	}
+	accountTrie, err := trie.New(trie.StateTrieID(parentRoot), triedb)
+	if err != nil {
+		return fmt.Errorf("failed to open state trie %s: %w", parentRoot, err)
+	}
+	var (
+		nodes     = trienode.NewMergedNodeSet()
+		destructs = make(map[common.Hash]struct{}, len(diff.Destructs))
+		accounts  = make(map[common.Hash][]byte, len(diff.Accounts))
+		storage   = make(map[common.Hash]map[common.Hash][]byte)
+	)
+	for _, accountHash := range diff.Destructs {
+		if err := accountTrie.Delete(accountHash[:]); err != nil {
+			return err
+		}
+		destructs[accountHash] = struct{}{}
+	}
+	for _, diffAccount := range diff.Accounts {
+		accountHash := diffAccount.Hash
+		account, err := snapshot.FullAccount(diffAccount.Account)
+		if err != nil {
+			return fmt.Errorf("%w: invalid account %s: %v", errMalformedSnapshotDiff, accountHash, err)
+		}
+
+		// Apply the slots to the storage of the parent account, if any.
+		parentStorageRoot := types.EmptyRootHash
+		parentBlob, err := accountTrie.Get(accountHash[:])
+		if err != nil {
+			return err
+		}
+		if len(parentBlob) > 0 {
+			var parentAccount types.StateAccount
+			if err := rlp.DecodeBytes(parentBlob, &parentAccount); err != nil {
+				return fmt.Errorf("invalid parent account %s: %w", accountHash, err)
+			}
+			parentStorageRoot = parentAccount.Root
+		}
+		storageRoot := parentStorageRoot
+		if len(diffAccount.Keys) > 0 {
+			storageTrie, err := trie.New(trie.StorageTrieID(parentRoot, accountHash, parentStorageRoot), triedb)
+			if err != nil {
+				return fmt.Errorf("failed to open storage trie of account %s: %w", accountHash, err)
+			}
+			slots := make(map[common.Hash][]byte, len(diffAccount.Keys))
+			for i, key := range diffAccount.Keys {
+				val := diffAccount.Vals[i]
+				if len(val) == 0 {
+					err = storageTrie.Delete(key[:])
+					val = nil
+				} else {
+					err = storageTrie.Update(key[:], val)
+				}
+				if err != nil {
+					return err
+				}
+				slots[key] = val
+			}
+			var set *trienode.NodeSet
+			storageRoot, set = storageTrie.Commit(false)
+			if set != nil {
+				if err := nodes.Merge(set); err != nil {
+					return err
+				}
+			}
+			storage[accountHash] = slots
+		}
+		if storageRoot != common.BytesToHash(account.Root) {
+			return fmt.Errorf("%w %s: (got %s) (expected %s)", errSnapshotDiffStorageMismatch, accountHash, storageRoot, common.BytesToHash(account.Root))
+		}
+
+		fullAccount, err := rlp.EncodeToBytes(account)
+		if err != nil {
+			return err
+		}
+		if err := accountTrie.Update(accountHash[:], fullAccount); err != nil {
+			return err
+		}
+		accounts[accountHash] = diffAccount.Account
+	}
+
+	root, set := accountTrie.Commit(false)
+	if root != block.Root() {
+		return fmt.Errorf("%w %s: (got %s) (expected %s)", errSnapshotDiffRootMismatch, block.Hash(), root, block.Root())
+	}
+	if set != nil {
+		if err := nodes.Merge(set); err != nil {
+			return err
+		}
+	}
+	if err := triedb.Update(root, parentRoot, nodes); err != nil {
+		return fmt.Errorf("failed to update trie database: %w", err)
+	}
+	if snaps == nil {
+		return nil
+	}
+	return snaps.Update(block.Hash(), root, block.ParentHash(), destructs, accounts, storage)
+}
+
+// validateSnapshotDiff checks that the hashes of [diff] are in strictly
+// ascending order, that no account is both destructed and modified, and that
+// every modified account has a value for each of its slot keys. Otherwise the
+// same account or slot could be applied twice, with the trie and the snapshot
+// layer keeping different versions of it.
+func validateSnapshotDiff(diff message.SnapshotDiffResponse) error {
+	if i := unsortedHash(diff.Destructs); i >= 0 {
+		return fmt.Errorf("%w: destruct %s is not sorted or unique", errMalformedSnapshotDiff, diff.Destructs[i])
+	}
+	destructs := make(map[common.Hash]struct{}, len(diff.Destructs))
+	for _, accountHash := range diff.Destructs {
+		destructs[accountHash] = struct{}{}
+	}
+	for i, diffAccount := range diff.Accounts {
+		accountHash := diffAccount.Hash
+		if i > 0 && bytes.Compare(diff.Accounts[i-1].Hash[:], accountHash[:]) >= 0 {
+			return fmt.Errorf("%w: account %s is not sorted or unique", errMalformedSnapshotDiff, accountHash)
+		}
+		if _, ok := destructs[accountHash]; ok {
+			return fmt.Errorf("%w: account %s is both destructed and modified", errMalformedSnapshotDiff, accountHash)
+		}
+		if len(diffAccount.Keys) != len(diffAccount.Vals) {
+			return fmt.Errorf("%w: account %s has %d slot keys and %d values", errMalformedSnapshotDiff, accountHash, len(diffAccount.Keys), len(diffAccount.Vals))
+		}
+		if j := unsortedHash(diffAccount.Keys); j >= 0 {
+			return fmt.Errorf("%w: slot %s of account %s is not sorted or unique", errMalformedSnapshotDiff, diffAccount.Keys[j], accountHash)
+		}
+	}
+	return nil
+}
+
+// unsortedHash returns the index of the first hash of [hashes] that is not
+// greater than the one before it, or -1 if they are strictly ascending.
+func unsortedHash(hashes []common.Hash) int {
+	for i := 1; i < len(hashes); i++ {
+		if bytes.Compare(hashes[i-1][:], hashes[i][:]) >= 0 {
+			return i
+		}
+	}
+	return -1
+}
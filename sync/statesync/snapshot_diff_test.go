@@ -0,0 +1,196 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package statesync
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"sort"
+	"testing"
+
+	"github.com/luxdefi/node/ids"
+	"github.com/luxdefi/evm/core/rawdb"
+	"github.com/luxdefi/evm/core/state"
+	"github.com/luxdefi/evm/core/state/snapshot"
+	"github.com/luxdefi/evm/core/types"
+	"github.com/luxdefi/evm/ethdb"
+	"github.com/luxdefi/evm/plugin/evm/message"
+	statesyncclient "github.com/luxdefi/evm/sync/client"
+	"github.com/luxdefi/evm/sync/handlers"
+	"github.com/luxdefi/evm/sync/handlers/stats"
+	"github.com/luxdefi/evm/trie"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	diffAddr1 = common.Address{0x01}
+	diffAddr2 = common.Address{0x02}
+	diffAddr3 = common.Address{0x03}
+
+	diffCode3 = []byte{0x60, 0x01}
+)
+
+// writeDiffGenesis writes the genesis state of the snapshot diff tests into
+// [diskDB] and returns its root.
+func writeDiffGenesis(t *testing.T, diskDB ethdb.Database, trieDB *trie.Database) common.Hash {
+	statedb, err := state.New(types.EmptyRootHash, state.NewDatabaseWithNodeDB(diskDB, trieDB), nil)
+	require.NoError(t, err)
+	statedb.SetBalance(diffAddr1, big.NewInt(1_000))
+	statedb.SetBalance(diffAddr2, big.NewInt(2_000))
+	statedb.SetCode(diffAddr2, []byte{0x60, 0x00})
+	for i := int64(1); i <= 10; i++ {
+		statedb.SetState(diffAddr1, common.BigToHash(big.NewInt(i)), common.BigToHash(big.NewInt(i)))
+		statedb.SetState(diffAddr2, common.BigToHash(big.NewInt(i)), common.BigToHash(big.NewInt(i)))
+	}
+	root, err := statedb.Commit(false, false)
+	require.NoError(t, err)
+	require.NoError(t, trieDB.Commit(root, false))
+	return root
+}
+
+func TestApplySnapshotDiff(t *testing.T) {
+	var (
+		serverDB     = rawdb.NewMemoryDatabase()
+		serverTrieDB = trie.NewDatabase(serverDB)
+		genesisRoot  = writeDiffGenesis(t, serverDB, serverTrieDB)
+		blocks       = []*types.Block{types.NewBlockWithHeader(&types.Header{Number: common.Big0, Root: genesisRoot})}
+	)
+	// Block 1 modifies the balance and storage of [diffAddr1] and creates
+	// [diffAddr3] with code unknown to the follower, block 2 destroys
+	// [diffAddr2].
+	modifications := []func(*state.StateDB){
+		func(statedb *state.StateDB) {
+			statedb.SetBalance(diffAddr1, big.NewInt(900))
+			statedb.SetState(diffAddr1, common.BigToHash(big.NewInt(1)), common.BigToHash(big.NewInt(100)))
+			statedb.SetState(diffAddr1, common.BigToHash(big.NewInt(2)), common.Hash{})
+			statedb.SetBalance(diffAddr3, big.NewInt(100))
+			statedb.SetCode(diffAddr3, diffCode3)
+			statedb.SetState(diffAddr3, common.BigToHash(big.NewInt(1)), common.BigToHash(big.NewInt(1)))
+		},
+		func(statedb *state.StateDB) {
+			statedb.Suicide(diffAddr2)
+			statedb.SetState(diffAddr3, common.BigToHash(big.NewInt(2)), common.BigToHash(big.NewInt(2)))
+		},
+	}
+	for _, modify := range modifications {
+		parent := blocks[len(blocks)-1]
+		statedb, err := state.New(parent.Root(), state.NewDatabaseWithNodeDB(serverDB, serverTrieDB), nil)
+		require.NoError(t, err)
+		modify(statedb)
+		root, err := statedb.Commit(true, false)
+		require.NoError(t, err)
+		blocks = append(blocks, types.NewBlockWithHeader(&types.Header{
+			ParentHash: parent.Hash(),
+			Number:     new(big.Int).Add(parent.Number(), common.Big1),
+			Root:       root,
+		}))
+	}
+	blockProvider := &handlers.TestBlockProvider{
+		GetBlockFn: func(hash common.Hash, height uint64) *types.Block {
+			if height >= uint64(len(blocks)) || blocks[height].Hash() != hash {
+				return nil
+			}
+			return blocks[height]
+		},
+	}
+	mockHandlerStats := &stats.MockHandlerStats{}
+	handler := handlers.NewSnapshotDiffRequestHandler(serverTrieDB, blockProvider, message.Codec, mockHandlerStats)
+	codeHandler := handlers.NewCodeRequestHandler(serverDB, message.Codec, mockHandlerStats)
+	client := statesyncclient.NewMockClient(message.Codec, nil, codeHandler, nil, nil, handler)
+
+	var (
+		followerDB     = rawdb.NewMemoryDatabase()
+		followerTrieDB = trie.NewDatabase(followerDB)
+	)
+	require.Equal(t, genesisRoot, writeDiffGenesis(t, followerDB, followerTrieDB))
+	snaps, err := snapshot.New(snapshot.Config{CacheSize: 64, SkipVerify: true}, followerDB, followerTrieDB, blocks[0].Hash(), genesisRoot)
+	require.NoError(t, err)
+
+	for _, block := range blocks[1:] {
+		diff, err := client.GetSnapshotDiff(context.Background(), block)
+		require.NoError(t, err)
+
+		parentRoot := blocks[block.NumberU64()-1].Root()
+		// Tampered diffs are rejected without modifying the follower.
+		tampered := diff
+		tampered.ParentRoot = common.Hash{0xde, 0xad}
+		assert.ErrorIs(t, ApplySnapshotDiff(followerTrieDB, snaps, block, parentRoot, tampered), errSnapshotDiffRootMismatch)
+		tampered = diff
+		tampered.Destructs = append(append([]common.Hash(nil), diff.Destructs...), common.BytesToHash(bytes.Repeat([]byte{0xff}, common.HashLength)))
+		tampered.Accounts = diff.Accounts[1:]
+		assert.ErrorIs(t, ApplySnapshotDiff(followerTrieDB, snaps, block, parentRoot, tampered), errSnapshotDiffRootMismatch)
+		for i, account := range diff.Accounts {
+			if len(account.Keys) == 0 {
+				continue
+			}
+			tampered = diff
+			tampered.Accounts = append([]message.SnapshotDiffAccount(nil), diff.Accounts...)
+			tampered.Accounts[i].Vals = append([][]byte{{0x7f}}, account.Vals[1:]...)
+			assert.ErrorIs(t, ApplySnapshotDiff(followerTrieDB, snaps, block, parentRoot, tampered), errSnapshotDiffStorageMismatch)
+
+			// Duplicate slots are rejected.
+			tampered.Accounts[i].Keys = append([]common.Hash{account.Keys[0]}, account.Keys...)
+			tampered.Accounts[i].Vals = append([][]byte{account.Vals[0]}, account.Vals...)
+			assert.ErrorIs(t, ApplySnapshotDiff(followerTrieDB, snaps, block, parentRoot, tampered), errMalformedSnapshotDiff)
+		}
+
+		// Accounts that are destructed and modified, duplicated or unsorted
+		// are rejected.
+		tampered = diff
+		tampered.Destructs = sortHashes(append(append([]common.Hash(nil), diff.Destructs...), diff.Accounts[0].Hash))
+		assert.ErrorIs(t, ApplySnapshotDiff(followerTrieDB, snaps, block, parentRoot, tampered), errMalformedSnapshotDiff)
+		tampered = diff
+		tampered.Accounts = append([]message.SnapshotDiffAccount{diff.Accounts[0]}, diff.Accounts...)
+		assert.ErrorIs(t, ApplySnapshotDiff(followerTrieDB, snaps, block, parentRoot, tampered), errMalformedSnapshotDiff)
+		if len(diff.Accounts) > 1 {
+			tampered.Accounts = append([]message.SnapshotDiffAccount{diff.Accounts[1], diff.Accounts[0]}, diff.Accounts[2:]...)
+			assert.ErrorIs(t, ApplySnapshotDiff(followerTrieDB, snaps, block, parentRoot, tampered), errMalformedSnapshotDiff)
+		}
+		if len(diff.Destructs) > 0 {
+			tampered = diff
+			tampered.Destructs = append([]common.Hash{diff.Destructs[0]}, diff.Destructs...)
+			assert.ErrorIs(t, ApplySnapshotDiff(followerTrieDB, snaps, block, parentRoot, tampered), errMalformedSnapshotDiff)
+		}
+
+		require.NoError(t, SyncSnapshotDiff(context.Background(), client, followerDB, followerTrieDB, snaps, block, parentRoot))
+		assert.NotNil(t, snaps.Snapshot(block.Root()))
+	}
+	assert.Equal(t, uint32(2*(len(blocks)-1)), mockHandlerStats.SnapshotDiffRequestCount)
+	assert.Zero(t, mockHandlerStats.MissingSnapshotDiffStateCount)
+	// Only the code of [diffAddr3] is missing from the follower.
+	assert.Equal(t, uint32(1), mockHandlerStats.CodeRequestCount)
+
+	// The follower tracks the head state without executing the blocks.
+	head := blocks[len(blocks)-1]
+	statedb, err := state.New(head.Root(), state.NewDatabaseWithNodeDB(followerDB, followerTrieDB), snaps)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(900), statedb.GetBalance(diffAddr1))
+	assert.Equal(t, common.BigToHash(big.NewInt(100)), statedb.GetState(diffAddr1, common.BigToHash(big.NewInt(1))))
+	assert.Equal(t, common.Hash{}, statedb.GetState(diffAddr1, common.BigToHash(big.NewInt(2))))
+	assert.False(t, statedb.Exist(diffAddr2))
+	assert.Equal(t, common.BigToHash(big.NewInt(2)), statedb.GetState(diffAddr3, common.BigToHash(big.NewInt(2))))
+	assert.Equal(t, diffCode3, statedb.GetCode(diffAddr3))
+
+	// Diffs are not served for genesis or unknown blocks.
+	for _, request := range []message.SnapshotDiffRequest{
+		{Hash: blocks[0].Hash(), Height: 0},
+		{Hash: common.Hash{0xde, 0xad}, Height: 1},
+	} {
+		responseBytes, err := handler.OnSnapshotDiffRequest(context.Background(), ids.GenerateTestNodeID(), 1, request)
+		require.NoError(t, err)
+		assert.Nil(t, responseBytes)
+	}
+	assert.Equal(t, uint32(1), mockHandlerStats.MissingSnapshotDiffStateCount)
+}
+
+// sortHashes sorts [hashes] in ascending order and returns it.
+func sortHashes(hashes []common.Hash) []common.Hash {
+	sort.Slice(hashes, func(i, j int) bool {
+		return bytes.Compare(hashes[i][:], hashes[j][:]) < 0
+	})
+	return hashes
+}
@@ -39,6 +39,7 @@ type Config struct {
 	Journal     string // Journal of clean cache to survive node restarts
 	Preimages   bool   // Flag whether the preimage of trie key is recorded
 	StatsPrefix string // Prefix for cache stats (disabled if empty)
+	PinnedCache int    // Memory allowance (MB) to use for pinning trie nodes in memory
 }
 
 // backend defines the methods needed to access/update trie nodes in different
@@ -83,6 +84,7 @@ type Database struct {
 	config    *Config        // Configuration for trie database
 	diskdb    ethdb.Database // Persistent database to store the snapshot
 	cleans    cache          // Megabytes permitted using for read caches
+	pinned    *pinnedCache   // Trie nodes pinned in memory in front of the read caches
 	preimages *preimageStore // The store for caching preimages
 	backend   backend        // The backend for managing trie nodes
 }
@@ -94,6 +96,10 @@ func prepare(diskdb ethdb.Database, config *Config) *Database {
 	if config != nil && config.Cache > 0 {
 		cleans = utils.NewMeteredCache(config.Cache*1024*1024, config.Journal, config.StatsPrefix, cacheStatsUpdateFrequency)
 	}
+	var pinned *pinnedCache
+	if config != nil && config.PinnedCache > 0 {
+		pinned = newPinnedCache(cleans, config.PinnedCache*1024*1024)
+	}
 	var preimages *preimageStore
 	if config != nil && config.Preimages {
 		preimages = newPreimageStore(diskdb)
@@ -102,6 +108,7 @@ func prepare(diskdb ethdb.Database, config *Config) *Database {
 		config:    config,
 		diskdb:    diskdb,
 		cleans:    cleans,
+		pinned:    pinned,
 		preimages: preimages,
 	}
 }
@@ -117,7 +124,11 @@ func NewDatabase(diskdb ethdb.Database) *Database {
 // hash-based scheme by default.
 func NewDatabaseWithConfig(diskdb ethdb.Database, config *Config) *Database {
 	db := prepare(diskdb, config)
-	db.backend = hashdb.New(diskdb, db.cleans, mptResolver{})
+	if db.pinned != nil {
+		db.backend = hashdb.New(diskdb, db.pinned, mptResolver{})
+	} else {
+		db.backend = hashdb.New(diskdb, db.cleans, mptResolver{})
+	}
 	return db
 }
 
@@ -175,6 +186,33 @@ func (db *Database) Initialized(genesisRoot common.Hash) bool {
 	return db.backend.Initialized(genesisRoot)
 }
 
+// Pin keeps [nodes] in memory on behalf of [owner], replacing any nodes
+// previously pinned for it. Pinned nodes are served ahead of the clean cache
+// and are never evicted until their owner is unpinned.
+func (db *Database) Pin(owner common.Hash, nodes map[common.Hash][]byte) error {
+	if db.pinned == nil {
+		return errPinningDisabled
+	}
+	return db.pinned.pin(owner, nodes)
+}
+
+// Unpin releases the nodes pinned on behalf of [owner] and reports whether
+// there were any.
+func (db *Database) Unpin(owner common.Hash) bool {
+	if db.pinned == nil {
+		return false
+	}
+	return db.pinned.unpin(owner)
+}
+
+// PinnedSize returns the storage size of the trie nodes pinned in memory.
+func (db *Database) PinnedSize() common.StorageSize {
+	if db.pinned == nil {
+		return 0
+	}
+	return common.StorageSize(db.pinned.usage())
+}
+
 // Scheme returns the node scheme used in the database.
 func (db *Database) Scheme() string {
 	return db.backend.Scheme()
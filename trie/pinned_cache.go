@@ -0,0 +1,180 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package trie
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/luxdefi/evm/metrics"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	// ErrPinLimitExceeded is returned when pinning a set of trie nodes would
+	// exceed the memory budget reserved for pinned nodes.
+	ErrPinLimitExceeded = errors.New("pinned trie nodes exceed memory budget")
+
+	errPinningDisabled = errors.New("trie node pinning is disabled")
+
+	pinnedHitMeter   = metrics.NewRegisteredMeter("trie/memcache/pinned/hit", nil)
+	pinnedSizeGauge  = metrics.NewRegisteredGauge("trie/memcache/pinned/size", nil)
+	pinnedNodesGauge = metrics.NewRegisteredGauge("trie/memcache/pinned/nodes", nil)
+)
+
+// pinnedNode is a trie node kept in memory on behalf of one or more owners.
+type pinnedNode struct {
+	blob []byte
+	refs int
+}
+
+// pinnedCache serves pinned trie nodes in front of the clean cache. Pinned
+// nodes are grouped by owner (the zero hash for the account trie and the
+// account hash for storage tries) and are never evicted: they are released
+// only when their owner is unpinned or pinned again with a different set.
+type pinnedCache struct {
+	cleans cache // Clean cache to fall back to, may be nil
+	limit  int   // Maximum number of bytes held by pinned nodes
+
+	lock   sync.RWMutex
+	size   int                           // Number of bytes held by pinned nodes
+	nodes  map[common.Hash]*pinnedNode   // Pinned nodes by hash
+	owners map[common.Hash][]common.Hash // Hashes of the nodes pinned by each owner
+}
+
+func newPinnedCache(cleans cache, limit int) *pinnedCache {
+	return &pinnedCache{
+		cleans: cleans,
+		limit:  limit,
+		nodes:  make(map[common.Hash]*pinnedNode),
+		owners: make(map[common.Hash][]common.Hash),
+	}
+}
+
+func pinnedNodeSize(blob []byte) int {
+	return common.HashLength + len(blob)
+}
+
+// HasGet returns the pinned node for [key] if there is one and otherwise
+// consults the clean cache.
+func (c *pinnedCache) HasGet(dst []byte, key []byte) ([]byte, bool) {
+	c.lock.RLock()
+	n := c.nodes[common.BytesToHash(key)]
+	c.lock.RUnlock()
+
+	if n != nil {
+		pinnedHitMeter.Mark(1)
+		return append(dst, n.blob...), true
+	}
+	if c.cleans == nil {
+		return nil, false
+	}
+	return c.cleans.HasGet(dst, key)
+}
+
+func (c *pinnedCache) Del(key []byte) {
+	if c.cleans != nil {
+		c.cleans.Del(key)
+	}
+}
+
+func (c *pinnedCache) Set(key []byte, value []byte) {
+	if c.cleans != nil {
+		c.cleans.Set(key, value)
+	}
+}
+
+// pin replaces the nodes pinned by [owner] with [nodes]. If the result would
+// exceed the memory budget, nothing is changed and ErrPinLimitExceeded is
+// returned.
+func (c *pinnedCache) pin(owner common.Hash, nodes map[common.Hash][]byte) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	old := c.owners[owner]
+	size := c.size
+	for hash, blob := range nodes {
+		if c.nodes[hash] == nil {
+			size += pinnedNodeSize(blob)
+		}
+	}
+	for _, hash := range old {
+		if n := c.nodes[hash]; n.refs == 1 {
+			if _, ok := nodes[hash]; !ok {
+				size -= pinnedNodeSize(n.blob)
+			}
+		}
+	}
+	if size > c.limit {
+		return fmt.Errorf("%w: %d bytes requested, limit is %d", ErrPinLimitExceeded, size, c.limit)
+	}
+
+	hashes := make([]common.Hash, 0, len(nodes))
+	for hash, blob := range nodes {
+		c.ref(hash, blob)
+		hashes = append(hashes, hash)
+	}
+	for _, hash := range old {
+		c.deref(hash)
+	}
+	if len(hashes) > 0 {
+		c.owners[owner] = hashes
+	} else {
+		delete(c.owners, owner)
+	}
+	c.updateMetrics()
+	return nil
+}
+
+// unpin releases the nodes pinned by [owner] and reports whether there were
+// any.
+func (c *pinnedCache) unpin(owner common.Hash) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	old, ok := c.owners[owner]
+	if !ok {
+		return false
+	}
+	for _, hash := range old {
+		c.deref(hash)
+	}
+	delete(c.owners, owner)
+	c.updateMetrics()
+	return true
+}
+
+// usage returns the number of bytes held by pinned nodes.
+func (c *pinnedCache) usage() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.size
+}
+
+// ref must be called with [c.lock] held.
+func (c *pinnedCache) ref(hash common.Hash, blob []byte) {
+	if n := c.nodes[hash]; n != nil {
+		n.refs++
+		return
+	}
+	c.nodes[hash] = &pinnedNode{blob: common.CopyBytes(blob), refs: 1}
+	c.size += pinnedNodeSize(blob)
+}
+
+// deref must be called with [c.lock] held.
+func (c *pinnedCache) deref(hash common.Hash) {
+	n := c.nodes[hash]
+	if n.refs--; n.refs == 0 {
+		delete(c.nodes, hash)
+		c.size -= pinnedNodeSize(n.blob)
+	}
+}
+
+// updateMetrics must be called with [c.lock] held.
+func (c *pinnedCache) updateMetrics() {
+	pinnedSizeGauge.Update(int64(c.size))
+	pinnedNodesGauge.Update(int64(len(c.nodes)))
+}
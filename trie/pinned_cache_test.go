@@ -0,0 +1,57 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package trie
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPinnedCache(t *testing.T) {
+	var (
+		owner1 = common.Hash{0x01}
+		owner2 = common.Hash{0x02}
+		shared = common.Hash{0xaa}
+		node1  = common.Hash{0xbb}
+		node2  = common.Hash{0xcc}
+		blob   = make([]byte, 8)
+		size   = pinnedNodeSize(blob)
+	)
+	c := newPinnedCache(nil, 3*size)
+
+	require.NoError(t, c.pin(owner1, map[common.Hash][]byte{shared: blob, node1: blob}))
+	require.Equal(t, 2*size, c.usage())
+
+	// Nodes shared between owners are only counted once.
+	require.NoError(t, c.pin(owner2, map[common.Hash][]byte{shared: blob, node2: blob}))
+	require.Equal(t, 3*size, c.usage())
+
+	got, ok := c.HasGet(nil, node2[:])
+	require.True(t, ok)
+	require.Equal(t, blob, got)
+
+	// Exceeding the budget leaves the pinned set unchanged.
+	err := c.pin(owner1, map[common.Hash][]byte{shared: blob, node1: blob, {0xdd}: blob})
+	require.ErrorIs(t, err, ErrPinLimitExceeded)
+	require.Equal(t, 3*size, c.usage())
+	_, ok = c.HasGet(nil, node1[:])
+	require.True(t, ok)
+
+	// Re-pinning replaces the previous set of the owner.
+	require.NoError(t, c.pin(owner1, map[common.Hash][]byte{{0xdd}: blob}))
+	require.Equal(t, 3*size, c.usage())
+	_, ok = c.HasGet(nil, node1[:])
+	require.False(t, ok)
+	_, ok = c.HasGet(nil, shared[:])
+	require.True(t, ok)
+
+	// The shared node is released with its last owner.
+	require.True(t, c.unpin(owner2))
+	require.Equal(t, size, c.usage())
+	_, ok = c.HasGet(nil, shared[:])
+	require.False(t, ok)
+	require.False(t, c.unpin(owner2))
+}
@@ -49,4 +49,5 @@ var (
 	ErrAddrProhibited              = errors.New("prohibited address cannot be sender or created contract address")
 	ErrInvalidCoinbase             = errors.New("invalid coinbase")
 	ErrSenderAddressNotAllowListed = errors.New("cannot issue transaction from non-allow listed address")
+	ErrPrecompileWriteLimit        = errors.New("precompile storage write limit per block exceeded")
 )
@@ -6,6 +6,7 @@ package aggregator
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/luxdefi/evm/params"
 
@@ -31,20 +32,43 @@ type signatureFetchResult struct {
 	weight uint64
 }
 
+// Config configures how an Aggregator fetches signatures.
+type Config struct {
+	// MaxSignatures is the number of signatures collected once the quorum is
+	// reached. The cap never prevents reaching the quorum. If 0, fetching
+	// stops at the quorum.
+	MaxSignatures int
+	// MaxConcurrency is the maximum number of signature requests in flight
+	// at once. If 0, all validators are queried at once.
+	MaxConcurrency int
+	// RequestTimeout bounds each signature request. If 0, requests are only
+	// bounded by the context of the aggregation.
+	RequestTimeout time.Duration
+}
+
 // Aggregator requests signatures from validators and
 // aggregates them into a single signature.
 type Aggregator struct {
 	validators  []*luxWarp.Validator
 	totalWeight uint64
 	client      SignatureGetter
+	config      Config
 }
 
 // New returns a signature aggregator that will attempt to aggregate signatures from [validators].
+// Signature fetching stops as soon as the requested quorum is reached.
 func New(client SignatureGetter, validators []*luxWarp.Validator, totalWeight uint64) *Aggregator {
+	return NewWithConfig(client, validators, totalWeight, Config{})
+}
+
+// NewWithConfig returns a signature aggregator that fetches signatures from
+// [validators] as specified by [config].
+func NewWithConfig(client SignatureGetter, validators []*luxWarp.Validator, totalWeight uint64, config Config) *Aggregator {
 	return &Aggregator{
 		client:      client,
 		validators:  validators,
 		totalWeight: totalWeight,
+		config:      config,
 	}
 }
 
@@ -55,8 +79,14 @@ func (a *Aggregator) AggregateSignatures(ctx context.Context, unsignedMessage *l
 	signatureFetchCtx, signatureFetchCancel := context.WithCancel(ctx)
 	defer signatureFetchCancel()
 
-	// Fetch signatures from validators concurrently.
-	signatureFetchResultChan := make(chan *signatureFetchResult)
+	// Fetch signatures from validators concurrently. The channel is buffered
+	// so that fetches completing after aggregation stops do not block.
+	signatureFetchResultChan := make(chan *signatureFetchResult, len(a.validators))
+	// If set, limits the number of signature requests in flight.
+	var inflight chan struct{}
+	if a.config.MaxConcurrency > 0 {
+		inflight = make(chan struct{}, a.config.MaxConcurrency)
+	}
 	for i, validator := range a.validators {
 		var (
 			i         = i
@@ -65,13 +95,29 @@ func (a *Aggregator) AggregateSignatures(ctx context.Context, unsignedMessage *l
 			nodeID = validator.NodeIDs[0]
 		)
 		go func() {
+			if inflight != nil {
+				select {
+				case inflight <- struct{}{}:
+					defer func() { <-inflight }()
+				case <-signatureFetchCtx.Done():
+					signatureFetchResultChan <- nil
+					return
+				}
+			}
+			requestCtx := signatureFetchCtx
+			if a.config.RequestTimeout > 0 {
+				var requestCancel context.CancelFunc
+				requestCtx, requestCancel = context.WithTimeout(signatureFetchCtx, a.config.RequestTimeout)
+				defer requestCancel()
+			}
+
 			log.Debug("Fetching warp signature",
 				"nodeID", nodeID,
 				"index", i,
 				"msgID", unsignedMessage.ID(),
 			)
 
-			signature, err := a.client.GetSignature(signatureFetchCtx, nodeID, unsignedMessage)
+			signature, err := a.client.GetSignature(requestCtx, nodeID, unsignedMessage)
 			if err != nil {
 				log.Debug("Failed to fetch warp signature",
 					"nodeID", nodeID,
@@ -129,16 +175,20 @@ func (a *Aggregator) AggregateSignatures(ctx context.Context, unsignedMessage *l
 			"msgID", unsignedMessage.ID(),
 		)
 
-		// If the signature weight meets the requested threshold, cancel signature fetching
-		if err := luxWarp.VerifyWeight(signaturesWeight, a.totalWeight, quorumNum, params.WarpQuorumDenominator); err == nil {
+		if !signaturesPassedThreshold {
+			signaturesPassedThreshold = luxWarp.VerifyWeight(signaturesWeight, a.totalWeight, quorumNum, params.WarpQuorumDenominator) == nil
+		}
+		// If the signature weight meets the requested threshold and enough
+		// signatures have been collected, cancel signature fetching
+		if signaturesPassedThreshold && len(signatures) >= a.config.MaxSignatures {
 			log.Debug("Verify weight passed, exiting aggregation early",
 				"quorumNum", quorumNum,
 				"totalWeight", a.totalWeight,
 				"signatureWeight", signaturesWeight,
+				"numSignatures", len(signatures),
 				"msgID", unsignedMessage.ID(),
 			)
 			signatureFetchCancel()
-			signaturesPassedThreshold = true
 			break
 		}
 	}
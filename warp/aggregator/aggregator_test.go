@@ -6,14 +6,19 @@ package aggregator
 import (
 	"context"
 	"errors"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
 	"go.uber.org/mock/gomock"
 
+	"github.com/luxdefi/evm/params"
+
 	"github.com/luxdefi/node/ids"
 	"github.com/luxdefi/node/utils/crypto/bls"
+	"github.com/luxdefi/node/utils/set"
 	luxWarp "github.com/luxdefi/node/vms/platformvm/warp"
 )
 
@@ -180,6 +185,52 @@ func TestAggregateSignatures(t *testing.T) {
 			expectedSigners: []*luxWarp.Validator{vdr2, vdr3},
 			expectedErr:     nil,
 		},
+		{
+			name: "2/3 validators reply with signature; quorum exceeded up to max signatures",
+			contextWithCancelFunc: func() (context.Context, context.CancelFunc) {
+				return context.Background(), nil
+			},
+			aggregatorFunc: func(ctrl *gomock.Controller, _ context.CancelFunc) *Aggregator {
+				client := NewMockSignatureGetter(ctrl)
+				client.EXPECT().GetSignature(gomock.Any(), nodeID1, gomock.Any()).Return(sig1, nil).Times(1)
+				client.EXPECT().GetSignature(gomock.Any(), nodeID2, gomock.Any()).Return(sig2, nil).Times(1)
+				// Only returns once aggregation stops.
+				client.EXPECT().GetSignature(gomock.Any(), nodeID3, gomock.Any()).DoAndReturn(
+					func(ctx context.Context, _ ids.NodeID, _ *luxWarp.UnsignedMessage) (*bls.Signature, error) {
+						<-ctx.Done()
+						return nil, ctx.Err()
+					},
+				).MaxTimes(1)
+				return NewWithConfig(client, vdrs, vdrWeight*uint64(len(vdrs)), Config{MaxSignatures: 2})
+			},
+			unsignedMsg:     unsignedMsg,
+			quorumNum:       30, // Require <1/3 of weight
+			expectedSigners: []*luxWarp.Validator{vdr1, vdr2},
+			expectedErr:     nil,
+		},
+		{
+			name: "2/3 validators reply with signature; max signatures below quorum",
+			contextWithCancelFunc: func() (context.Context, context.CancelFunc) {
+				return context.Background(), nil
+			},
+			aggregatorFunc: func(ctrl *gomock.Controller, _ context.CancelFunc) *Aggregator {
+				client := NewMockSignatureGetter(ctrl)
+				client.EXPECT().GetSignature(gomock.Any(), nodeID1, gomock.Any()).Return(sig1, nil).Times(1)
+				client.EXPECT().GetSignature(gomock.Any(), nodeID2, gomock.Any()).Return(sig2, nil).Times(1)
+				// Only returns once aggregation stops.
+				client.EXPECT().GetSignature(gomock.Any(), nodeID3, gomock.Any()).DoAndReturn(
+					func(ctx context.Context, _ ids.NodeID, _ *luxWarp.UnsignedMessage) (*bls.Signature, error) {
+						<-ctx.Done()
+						return nil, ctx.Err()
+					},
+				).MaxTimes(1)
+				return NewWithConfig(client, vdrs, vdrWeight*uint64(len(vdrs)), Config{MaxSignatures: 1})
+			},
+			unsignedMsg:     unsignedMsg,
+			quorumNum:       65, // Require <2/3 of weight
+			expectedSigners: []*luxWarp.Validator{vdr1, vdr2},
+			expectedErr:     nil,
+		},
 		{
 			name: "3/3 validators reply with signature; 3 invalid signatures; insufficient weight",
 			contextWithCancelFunc: func() (context.Context, context.CancelFunc) {
@@ -382,3 +433,223 @@ func TestAggregateSignatures(t *testing.T) {
 		})
 	}
 }
+
+// latencySignatureGetter responds to signature requests after a per-node
+// delay. Nodes without a delay never respond, so their requests only return
+// once their context is done.
+type latencySignatureGetter struct {
+	signatures map[ids.NodeID]*bls.Signature
+	delays     map[ids.NodeID]time.Duration
+
+	inflight    atomic.Int32
+	maxInflight atomic.Int32
+	cancelled   atomic.Int32
+}
+
+func (g *latencySignatureGetter) GetSignature(ctx context.Context, nodeID ids.NodeID, _ *luxWarp.UnsignedMessage) (*bls.Signature, error) {
+	inflight := g.inflight.Add(1)
+	defer g.inflight.Add(-1)
+	for {
+		highest := g.maxInflight.Load()
+		if inflight <= highest || g.maxInflight.CompareAndSwap(highest, inflight) {
+			break
+		}
+	}
+
+	delay, ok := g.delays[nodeID]
+	if !ok {
+		<-ctx.Done()
+		g.cancelled.Add(1)
+		return nil, ctx.Err()
+	}
+	select {
+	case <-time.After(delay):
+		return g.signatures[nodeID], nil
+	case <-ctx.Done():
+		g.cancelled.Add(1)
+		return nil, ctx.Err()
+	}
+}
+
+func TestAggregateSignaturesFastestResponders(t *testing.T) {
+	unsignedMsg := &luxWarp.UnsignedMessage{
+		NetworkID:     1338,
+		SourceChainID: ids.ID{'y', 'e', 'e', 't'},
+		Payload:       []byte("hello world"),
+	}
+	require.NoError(t, unsignedMsg.Initialize())
+
+	const numValidators = 5
+	var (
+		vdrWeight  = uint64(10)
+		vdrs       = make([]*luxWarp.Validator, numValidators)
+		signatures = make(map[ids.NodeID]*bls.Signature)
+	)
+	for i := range vdrs {
+		sk, vdr := newValidator(t, vdrWeight)
+		vdrs[i] = vdr
+		signatures[vdr.NodeIDs[0]] = bls.Sign(sk, unsignedMsg.Bytes())
+	}
+
+	tests := []struct {
+		name            string
+		config          Config
+		delays          map[int]time.Duration // Validators without a delay never respond
+		expectedSigners []int
+	}{
+		{
+			name: "all requests at once",
+			delays: map[int]time.Duration{
+				0: time.Second,
+				1: 30 * time.Millisecond,
+				2: 0,
+				3: 2 * time.Second,
+				4: 10 * time.Millisecond,
+			},
+			expectedSigners: []int{1, 2, 4},
+		},
+		{
+			name: "limited concurrency with request timeout",
+			config: Config{
+				MaxConcurrency: 2,
+				RequestTimeout: 20 * time.Millisecond,
+			},
+			// Requests to the unresponsive validators time out, making room
+			// for the responsive ones.
+			delays:          map[int]time.Duration{2: 0, 3: 10 * time.Millisecond, 4: 5 * time.Millisecond},
+			expectedSigners: []int{2, 3, 4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			client := &latencySignatureGetter{
+				signatures: signatures,
+				delays:     make(map[ids.NodeID]time.Duration),
+			}
+			for i, delay := range tt.delays {
+				client.delays[vdrs[i].NodeIDs[0]] = delay
+			}
+			a := NewWithConfig(client, vdrs, vdrWeight*numValidators, tt.config)
+
+			res, err := a.AggregateSignatures(context.Background(), unsignedMsg, 60) // Require 3/5 validators
+			require.NoError(err)
+			require.Equal(vdrWeight*uint64(len(tt.expectedSigners)), res.SignatureWeight)
+
+			gotBLSSig, ok := res.Message.Signature.(*luxWarp.BitSetSignature)
+			require.True(ok)
+			require.Equal(set.NewBits(tt.expectedSigners...).Bytes(), gotBLSSig.Signers)
+
+			// Outstanding requests are cancelled once the quorum is reached.
+			require.Eventually(func() bool {
+				return client.inflight.Load() == 0
+			}, time.Second, time.Millisecond)
+			if tt.config.MaxConcurrency == 0 {
+				require.Equal(int32(numValidators-len(tt.expectedSigners)), client.cancelled.Load())
+			} else {
+				require.LessOrEqual(client.maxInflight.Load(), int32(tt.config.MaxConcurrency))
+			}
+		})
+	}
+}
+
+func TestMultiSubnetAggregateSignatures(t *testing.T) {
+	unsignedMsg := &luxWarp.UnsignedMessage{
+		NetworkID:     1338,
+		SourceChainID: ids.ID{'y', 'e', 'e', 't'},
+		Payload:       []byte("hello world"),
+	}
+	require.NoError(t, unsignedMsg.Initialize())
+
+	// Each subnet has 3 validators of equal weight, and requires more than
+	// 2/3 of its weight.
+	const vdrWeight = 10
+	var (
+		subnetA, subnetB = ids.GenerateTestID(), ids.GenerateTestID()
+		vdrs             = make(map[ids.ID][]*luxWarp.Validator)
+		signatures       = make(map[ids.NodeID]*bls.Signature)
+	)
+	for _, subnetID := range []ids.ID{subnetA, subnetB} {
+		for i := 0; i < 3; i++ {
+			sk, vdr := newValidator(t, vdrWeight)
+			vdrs[subnetID] = append(vdrs[subnetID], vdr)
+			signatures[vdr.NodeIDs[0]] = bls.Sign(sk, unsignedMsg.Bytes())
+		}
+	}
+	quorums := func() []SubnetQuorum {
+		return []SubnetQuorum{
+			{SubnetID: subnetA, Validators: vdrs[subnetA], TotalWeight: 3 * vdrWeight, QuorumNum: 67},
+			{SubnetID: subnetB, Validators: vdrs[subnetB], TotalWeight: 3 * vdrWeight, QuorumNum: 67},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		unavailable []*luxWarp.Validator // Validators failing to sign
+		expectedErr error
+	}{
+		{
+			name: "both subnets reach quorum",
+		},
+		{
+			name:        "one subnet reaches quorum, the other does not",
+			unavailable: []*luxWarp.Validator{vdrs[subnetB][0], vdrs[subnetB][2]},
+			expectedErr: luxWarp.ErrInsufficientWeight,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			unavailable := set.NewSet[ids.NodeID](len(tt.unavailable))
+			for _, vdr := range tt.unavailable {
+				unavailable.Add(vdr.NodeIDs[0])
+			}
+			client := NewMockSignatureGetter(ctrl)
+			client.EXPECT().GetSignature(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, nodeID ids.NodeID, _ *luxWarp.UnsignedMessage) (*bls.Signature, error) {
+					if unavailable.Contains(nodeID) {
+						return nil, errors.New("unavailable")
+					}
+					return signatures[nodeID], nil
+				},
+			).AnyTimes()
+
+			results, err := NewMultiSubnet(client, quorums(), Config{}).AggregateSignatures(context.Background(), unsignedMsg)
+			require.ErrorIs(err, tt.expectedErr)
+			if err != nil {
+				require.Contains(err.Error(), subnetB.String())
+				require.NotContains(err.Error(), subnetA.String())
+				require.Nil(results)
+				return
+			}
+
+			require.Len(results, 2)
+			for _, subnet := range quorums() {
+				result := results[subnet.SubnetID]
+				require.NotNil(result)
+				require.Equal(subnet.TotalWeight, result.TotalWeight)
+				require.NoError(luxWarp.VerifyWeight(result.SignatureWeight, result.TotalWeight, subnet.QuorumNum, params.WarpQuorumDenominator))
+
+				// The signature of each subnet verifies against its own validators
+				signature, ok := result.Message.Signature.(*luxWarp.BitSetSignature)
+				require.True(ok)
+				signers := set.BitsFromBytes(signature.Signers)
+				var pks []*bls.PublicKey
+				for i, vdr := range subnet.Validators {
+					if signers.Contains(i) {
+						pks = append(pks, vdr.PublicKey)
+					}
+				}
+				aggPK, err := bls.AggregatePublicKeys(pks)
+				require.NoError(err)
+				sig, err := bls.SignatureFromBytes(signature.Signature[:])
+				require.NoError(err)
+				require.True(bls.Verify(aggPK, sig, unsignedMsg.Bytes()))
+			}
+		})
+	}
+}
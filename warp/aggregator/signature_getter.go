@@ -5,6 +5,7 @@ package aggregator
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -21,7 +22,13 @@ const (
 	retryBackoffFactor              = 2
 )
 
-var _ SignatureGetter = (*NetworkSignatureGetter)(nil)
+var (
+	_ SignatureGetter = (*NetworkSignatureGetter)(nil)
+
+	// errSignatureUnavailable is returned when the peer reports that it cannot
+	// provide the requested signature, so further requests are not retried.
+	errSignatureUnavailable = errors.New("signature unavailable")
+)
 
 // SignatureGetter defines the minimum network interface to perform signature aggregation
 type SignatureGetter interface {
@@ -79,36 +86,42 @@ func (s *NetworkSignatureGetter) GetSignature(ctx context.Context, nodeID ids.No
 	defer timer.Stop()
 	for {
 		signatureRes, err := s.Client.SendAppRequest(ctx, nodeID, signatureReqBytes)
-		// If the client fails to retrieve a response perform an exponential backoff.
-		// Note: it is up to the caller to ensure that [ctx] is eventually cancelled
-		if err != nil {
-			// Wait until the retry delay has elapsed before retrying.
-			if !timer.Stop() {
-				<-timer.C
-			}
-			timer.Reset(delay)
-
-			select {
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			case <-timer.C:
+		if err == nil {
+			var response message.SignatureResponse
+			if _, err := message.Codec.Unmarshal(signatureRes, &response); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal signature res: %w", err)
 			}
-
-			// Exponential backoff.
-			delay *= retryBackoffFactor
-			if delay > maxRetryFetchSignatureDelay {
-				delay = maxRetryFetchSignatureDelay
+			switch response.Status {
+			case message.SignatureOK:
+				blsSignature, err := bls.SignatureFromBytes(response.Signature[:])
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse signature from res: %w", err)
+				}
+				return blsSignature, nil
+			case message.SignatureSigningFailed:
+				// Signing failures are transient, so retry the request after the backoff below.
+			default:
+				return nil, fmt.Errorf("%w: %s", errSignatureUnavailable, response.Status)
 			}
-			continue
 		}
-		var response message.SignatureResponse
-		if _, err := message.Codec.Unmarshal(signatureRes, &response); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal signature res: %w", err)
+		// If the client fails to retrieve a signature perform an exponential backoff.
+		// Note: it is up to the caller to ensure that [ctx] is eventually cancelled
+		// Wait until the retry delay has elapsed before retrying.
+		if !timer.Stop() {
+			<-timer.C
 		}
-		blsSignature, err := bls.SignatureFromBytes(response.Signature[:])
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse signature from res: %w", err)
+		timer.Reset(delay)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		// Exponential backoff.
+		delay *= retryBackoffFactor
+		if delay > maxRetryFetchSignatureDelay {
+			delay = maxRetryFetchSignatureDelay
 		}
-		return blsSignature, nil
 	}
 }
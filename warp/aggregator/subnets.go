@@ -0,0 +1,108 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package aggregator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/luxdefi/node/ids"
+	"github.com/luxdefi/node/utils/set"
+	luxWarp "github.com/luxdefi/node/vms/platformvm/warp"
+)
+
+// SubnetQuorum is a subnet whose validators must sign a message, and the
+// quorum of their weight the signatures must reach.
+type SubnetQuorum struct {
+	SubnetID    ids.ID
+	Validators  []*luxWarp.Validator
+	TotalWeight uint64
+	QuorumNum   uint64
+}
+
+// MultiSubnetAggregator requests signatures of a message from the validators
+// of several subnets, and aggregates them per subnet.
+type MultiSubnetAggregator struct {
+	client  SignatureGetter
+	subnets []SubnetQuorum
+	config  Config
+}
+
+// NewMultiSubnet returns an aggregator collecting signatures from the
+// validators of each of [subnets] as specified by [config], until each subnet
+// reaches its quorum.
+func NewMultiSubnet(client SignatureGetter, subnets []SubnetQuorum, config Config) *MultiSubnetAggregator {
+	return &MultiSubnetAggregator{
+		client:  client,
+		subnets: subnets,
+		config:  config,
+	}
+}
+
+// AggregateSignatures returns an aggregate signature over [unsignedMessage]
+// for each subnet, by subnet ID. The signatures of each subnet are
+// aggregated independently, and the aggregation only succeeds if the weight
+// of every subnet meets its quorum. If some do not, the returned error wraps
+// ErrInsufficientWeight and lists them.
+func (a *MultiSubnetAggregator) AggregateSignatures(ctx context.Context, unsignedMessage *luxWarp.UnsignedMessage) (map[ids.ID]*AggregateSignatureResult, error) {
+	subnetIDs := set.NewSet[ids.ID](len(a.subnets))
+	for _, subnet := range a.subnets {
+		if subnetIDs.Contains(subnet.SubnetID) {
+			return nil, fmt.Errorf("duplicate subnet %s", subnet.SubnetID)
+		}
+		subnetIDs.Add(subnet.SubnetID)
+	}
+
+	var (
+		wg      sync.WaitGroup
+		results = make([]*AggregateSignatureResult, len(a.subnets))
+		errs    = make([]error, len(a.subnets))
+	)
+	for i, subnet := range a.subnets {
+		wg.Add(1)
+		go func(i int, subnet SubnetQuorum) {
+			defer wg.Done()
+			aggregator := NewWithConfig(a.client, subnet.Validators, subnet.TotalWeight, a.config)
+			results[i], errs[i] = aggregator.AggregateSignatures(ctx, unsignedMessage, subnet.QuorumNum)
+		}(i, subnet)
+	}
+	wg.Wait()
+
+	var (
+		subnetResults = make(map[ids.ID]*AggregateSignatureResult, len(a.subnets))
+		failed        []ids.ID
+	)
+	for i, subnet := range a.subnets {
+		if err := errs[i]; err != nil {
+			if !errors.Is(err, luxWarp.ErrInsufficientWeight) {
+				return nil, fmt.Errorf("failed to aggregate signatures of subnet %s: %w", subnet.SubnetID, err)
+			}
+			log.Debug("Subnet failed to reach quorum",
+				"subnetID", subnet.SubnetID,
+				"quorumNum", subnet.QuorumNum,
+				"totalWeight", subnet.TotalWeight,
+				"msgID", unsignedMessage.ID(),
+				"err", err,
+			)
+			failed = append(failed, subnet.SubnetID)
+			continue
+		}
+		log.Debug("Subnet reached quorum",
+			"subnetID", subnet.SubnetID,
+			"quorumNum", subnet.QuorumNum,
+			"totalWeight", subnet.TotalWeight,
+			"signatureWeight", results[i].SignatureWeight,
+			"msgID", unsignedMessage.ID(),
+		)
+		subnetResults[subnet.SubnetID] = results[i]
+	}
+	if len(failed) > 0 {
+		return nil, fmt.Errorf("%w: subnets %v did not reach their quorum", luxWarp.ErrInsufficientWeight, failed)
+	}
+	return subnetResults, nil
+}
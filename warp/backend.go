@@ -4,9 +4,13 @@
 package warp
 
 import (
+	"bytes"
 	"context"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/luxdefi/node/cache"
 	"github.com/luxdefi/node/database"
@@ -14,6 +18,7 @@ import (
 	"github.com/luxdefi/node/snow/choices"
 	"github.com/luxdefi/node/snow/consensus/snowman"
 	"github.com/luxdefi/node/utils/crypto/bls"
+	"github.com/luxdefi/node/utils/wrappers"
 	luxWarp "github.com/luxdefi/node/vms/platformvm/warp"
 	"github.com/luxdefi/node/vms/platformvm/warp/payload"
 	"github.com/luxdefi/evm/ethdb"
@@ -23,10 +28,45 @@ import (
 var (
 	_                         Backend = &backend{}
 	errParsingOffChainMessage         = errors.New("failed to parse off-chain message")
+
+	// ErrSigningFailed is returned when a known message or block could not be signed.
+	ErrSigningFailed = errors.New("failed to sign warp message")
+
+	// ErrSignatureMismatch is returned by ReSign when the signature served for
+	// a message differs from the signature derived from the stored message.
+	ErrSignatureMismatch = errors.New("warp message signature mismatch")
+
+	// ErrNoServedSignature is returned by ReSign when no signature of a
+	// message is cached, so there is no served signature to compare against.
+	ErrNoServedSignature = errors.New("no served warp message signature")
+
+	// ErrMessageIDMismatch is returned by ReSign when the message stored
+	// under an ID does not hash to that ID.
+	ErrMessageIDMismatch = errors.New("stored warp message does not match its ID")
+
+	// ErrUnknownPublicKey is returned by SignerPublicKey when the signer does
+	// not expose the public key of its secret key.
+	ErrUnknownPublicKey = errors.New("public key of the warp signer is unknown")
+
+	// ErrInvalidCursor is returned by ListMessages when the cursor was not
+	// returned by a previous call.
+	ErrInvalidCursor = errors.New("invalid warp message cursor")
 )
 
 const batchSize = ethdb.IdealBatchSize
 
+// messageIndexPrefix prefixes the keys indexing the stored messages by
+// insertion height, followed by the big-endian height and the message ID. It
+// is long enough that no message ID shares it in practice, but index keys are
+// still told apart from message IDs by their length.
+var messageIndexPrefix = []byte("warpMessageIndex")
+
+const messageCursorLen = wrappers.LongLen + ids.IDLen
+
+// compactionLimit is the end of the key range compacted after pruning, which
+// covers all message and block IDs stored in the db.
+var compactionLimit = bytes.Repeat([]byte{0xff}, ids.IDLen+1)
+
 type BlockClient interface {
 	GetBlock(ctx context.Context, blockID ids.ID) (snowman.Block, error)
 }
@@ -34,8 +74,16 @@ type BlockClient interface {
 // Backend tracks signature-eligible warp messages and provides an interface to fetch them.
 // The backend is also used to query for warp message signatures by the signature request handler.
 type Backend interface {
-	// AddMessage signs [unsignedMessage] and adds it to the warp backend database
-	AddMessage(unsignedMessage *luxWarp.UnsignedMessage) error
+	// AddMessage signs [unsignedMessage], sent by the block at [height], and
+	// adds it to the warp backend database
+	AddMessage(unsignedMessage *luxWarp.UnsignedMessage, height uint64) error
+
+	// ListMessages returns up to [limit] of the messages sent from this chain
+	// stored in the database, ordered by insertion height then message ID,
+	// starting at [cursor] or at the first message if [cursor] is empty. It
+	// also returns the cursor of the next message, which is nil if there is
+	// none left.
+	ListMessages(cursor []byte, limit int) ([]StoredMessage, []byte, error)
 
 	// GetMessageSignature returns the signature of the requested message hash.
 	GetMessageSignature(messageID ids.ID) ([bls.SignatureLen]byte, error)
@@ -43,11 +91,50 @@ type Backend interface {
 	// GetBlockSignature returns the signature of the requested message hash.
 	GetBlockSignature(blockID ids.ID) ([bls.SignatureLen]byte, error)
 
+	// GetBlockMessage returns the unsigned message signed by GetBlockSignature
+	// for the accepted block [blockID].
+	GetBlockMessage(blockID ids.ID) (*luxWarp.UnsignedMessage, error)
+
 	// GetMessage retrieves the [unsignedMessage] from the warp backend database if available
 	GetMessage(messageHash ids.ID) (*luxWarp.UnsignedMessage, error)
 
+	// WarmBlockSignatures signs the accepted blocks [blockIDs] ahead of any
+	// request, so that their signatures are served from the cache. Returns the
+	// number of blocks signed.
+	WarmBlockSignatures(blockIDs []ids.ID) int
+
+	// UpdateSigner replaces the signer used for warp messages, for example
+	// after a BLS key rotation. Signatures produced by the previous signer are
+	// discarded and messages are re-signed on demand with [warpSigner].
+	UpdateSigner(warpSigner PublicKeySigner)
+
+	// SignerPublicKey returns the BLS public key verifying the signatures of
+	// the current signer, which changes with UpdateSigner. Returns
+	// ErrUnknownPublicKey if the signer the backend was created with is not
+	// a PublicKeySigner.
+	SignerPublicKey() (*bls.PublicKey, error)
+
+	// ReSign recomputes the signature of the stored message [messageID],
+	// bypassing the caches, and returns it. Returns ErrSignatureMismatch if the
+	// cached signature served for the message differs from it,
+	// ErrNoServedSignature if no signature is cached for the message, and
+	// ErrMessageIDMismatch if the stored message does not hash to [messageID].
+	ReSign(messageID ids.ID) ([bls.SignatureLen]byte, error)
+
 	// Clear clears the entire db
 	Clear() error
+
+	// Prune clears the entire db like Clear. If more than [compactionThreshold]
+	// entries are removed, the db is then compacted in the background to
+	// reclaim the space held by their tombstones. 0 disables the compaction.
+	Prune(compactionThreshold int) error
+}
+
+// StoredMessage is a warp message stored in the database, and the height of
+// the block that added it.
+type StoredMessage struct {
+	MessageID ids.ID `json:"messageID"`
+	Height    uint64 `json:"height"`
 }
 
 // backend implements Backend, keeps track of warp messages, and generates message signatures.
@@ -55,15 +142,20 @@ type backend struct {
 	networkID                 uint32
 	sourceChainID             ids.ID
 	db                        database.Database
-	warpSigner                luxWarp.Signer
 	blockClient               BlockClient
+	signerLock                sync.RWMutex // Held while signing and caching signatures, see UpdateSigner
+	warpSigner                luxWarp.Signer
 	messageSignatureCache     *cache.LRU[ids.ID, [bls.SignatureLen]byte]
 	blockSignatureCache       *cache.LRU[ids.ID, [bls.SignatureLen]byte]
 	messageCache              *cache.LRU[ids.ID, *luxWarp.UnsignedMessage]
 	offchainAddressedCallMsgs map[ids.ID]*luxWarp.UnsignedMessage
+	dedupMessages             bool // Skip storing and signing messages that are already stored
+	stats                     *backendStats
+	compactionWg              sync.WaitGroup // Tracks background compactions started by Prune
 }
 
 // NewBackend creates a new Backend, and initializes the signature cache and message tracking database.
+// If [dedupMessages] is true, adding a message that is already stored neither stores nor signs it again.
 func NewBackend(
 	networkID uint32,
 	sourceChainID ids.ID,
@@ -71,6 +163,7 @@ func NewBackend(
 	blockClient BlockClient,
 	db database.Database,
 	cacheSize int,
+	dedupMessages bool,
 	offchainMessages [][]byte,
 ) (Backend, error) {
 	b := &backend{
@@ -83,6 +176,8 @@ func NewBackend(
 		blockSignatureCache:       &cache.LRU[ids.ID, [bls.SignatureLen]byte]{Size: cacheSize},
 		messageCache:              &cache.LRU[ids.ID, *luxWarp.UnsignedMessage]{Size: cacheSize},
 		offchainAddressedCallMsgs: make(map[ids.ID]*luxWarp.UnsignedMessage),
+		dedupMessages:             dedupMessages,
+		stats:                     newBackendStats(),
 	}
 	return b, b.initOffChainMessages(offchainMessages)
 }
@@ -111,16 +206,96 @@ func (b *backend) Clear() error {
 	return database.Clear(b.db, batchSize)
 }
 
-func (b *backend) AddMessage(unsignedMessage *luxWarp.UnsignedMessage) error {
+func (b *backend) Prune(compactionThreshold int) error {
+	numEntries, err := database.Count(b.db)
+	if err != nil {
+		return fmt.Errorf("failed to count warp db entries: %w", err)
+	}
+	if err := b.Clear(); err != nil {
+		return err
+	}
+	log.Info("Pruned warp db", "entries", numEntries)
+	if compactionThreshold == 0 || numEntries <= compactionThreshold {
+		return nil
+	}
+
+	// Compaction can take a while on a large db. It does not hold any lock
+	// of the backend, so signing is not blocked while it runs.
+	b.compactionWg.Add(1)
+	go func() {
+		defer b.compactionWg.Done()
+
+		start := time.Now()
+		if err := b.db.Compact(nil, compactionLimit); err != nil {
+			log.Warn("Failed to compact warp db after pruning", "err", err)
+			return
+		}
+		log.Info("Compacted warp db after pruning", "entries", numEntries, "duration", time.Since(start))
+	}()
+	return nil
+}
+
+func (b *backend) UpdateSigner(warpSigner PublicKeySigner) {
+	b.signerLock.Lock()
+	defer b.signerLock.Unlock()
+
+	if b.warpSigner == warpSigner {
+		return
+	}
+	// Only signatures are cached, the database holds the unsigned messages,
+	// so flushing the caches is enough to never serve a stale signature.
+	b.warpSigner = warpSigner
+	b.messageSignatureCache.Flush()
+	b.blockSignatureCache.Flush()
+	log.Info("Updated warp signer, flushed cached signatures")
+}
+
+func (b *backend) SignerPublicKey() (*bls.PublicKey, error) {
+	b.signerLock.RLock()
+	defer b.signerLock.RUnlock()
+
+	signer, ok := b.warpSigner.(PublicKeySigner)
+	if !ok {
+		return nil, ErrUnknownPublicKey
+	}
+	return signer.PublicKey(), nil
+}
+
+func (b *backend) AddMessage(unsignedMessage *luxWarp.UnsignedMessage, height uint64) error {
 	messageID := unsignedMessage.ID()
 
+	// The message ID is the hash of the full message, including the network and source chain IDs,
+	// so identical payloads only share an entry if they are sent from the same chain.
+	if b.dedupMessages {
+		stored, err := b.hasMessage(messageID)
+		if err != nil {
+			return fmt.Errorf("failed to check for duplicate warp message: %w", err)
+		}
+		if stored {
+			b.stats.messageDedupHits.Inc(1)
+			log.Debug("Skipping duplicate warp message", "messageID", messageID)
+			return nil
+		}
+	}
+
 	// In the case when a node restarts, and possibly changes its bls key, the cache gets emptied but the database does not.
 	// So to avoid having incorrect signatures saved in the database after a bls key change, we save the full message in the database.
 	// Whereas for the cache, after the node restart, the cache would be emptied so we can directly save the signatures.
-	if err := b.db.Put(messageID[:], unsignedMessage.Bytes()); err != nil {
+	start := time.Now()
+	err := b.db.Put(messageID[:], unsignedMessage.Bytes())
+	if err == nil && unsignedMessage.NetworkID == b.networkID && unsignedMessage.SourceChainID == b.sourceChainID {
+		// Messages sent from other chains cannot be signed, so they are not
+		// listed.
+		err = b.db.Put(messageIndexKey(height, messageID), nil)
+	}
+	b.stats.dbPutDuration.UpdateSince(start)
+	if err != nil {
 		return fmt.Errorf("failed to put warp signature in db: %w", err)
 	}
 
+	b.signerLock.RLock()
+	defer b.signerLock.RUnlock()
+
 	var signature [bls.SignatureLen]byte
 	sig, err := b.warpSigner.Sign(unsignedMessage)
 	if err != nil {
@@ -133,8 +308,64 @@ func (b *backend) AddMessage(unsignedMessage *luxWarp.UnsignedMessage) error {
 	return nil
 }
 
+// messageIndexKey returns the key indexing the message [messageID] added at
+// [height].
+func messageIndexKey(height uint64, messageID ids.ID) []byte {
+	key := make([]byte, len(messageIndexPrefix)+messageCursorLen)
+	copy(key, messageIndexPrefix)
+	binary.BigEndian.PutUint64(key[len(messageIndexPrefix):], height)
+	copy(key[len(messageIndexPrefix)+wrappers.LongLen:], messageID[:])
+	return key
+}
+
+func (b *backend) ListMessages(cursor []byte, limit int) ([]StoredMessage, []byte, error) {
+	if len(cursor) != 0 && len(cursor) != messageCursorLen {
+		return nil, nil, fmt.Errorf("%w: length %d", ErrInvalidCursor, len(cursor))
+	}
+	if limit <= 0 {
+		return nil, nil, fmt.Errorf("limit must be positive (%d)", limit)
+	}
+	start := make([]byte, 0, len(messageIndexPrefix)+len(cursor))
+	start = append(append(start, messageIndexPrefix...), cursor...)
+	it := b.db.NewIteratorWithStartAndPrefix(start, messageIndexPrefix)
+	defer it.Release()
+
+	var messages []StoredMessage
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(messageIndexPrefix)+messageCursorLen {
+			continue // a message ID starting with the prefix
+		}
+		entry := key[len(messageIndexPrefix):]
+		if len(messages) == limit {
+			return messages, append([]byte(nil), entry...), it.Error()
+		}
+		message := StoredMessage{Height: binary.BigEndian.Uint64(entry)}
+		copy(message.MessageID[:], entry[wrappers.LongLen:])
+		messages = append(messages, message)
+	}
+	if err := it.Error(); err != nil {
+		return nil, nil, fmt.Errorf("failed to iterate warp messages: %w", err)
+	}
+	return messages, nil, nil
+}
+
+// hasMessage returns true if the message with [messageID] has already been added.
+func (b *backend) hasMessage(messageID ids.ID) (bool, error) {
+	if _, ok := b.messageSignatureCache.Get(messageID); ok {
+		return true, nil
+	}
+	start := time.Now()
+	has, err := b.db.Has(messageID[:])
+	b.stats.dbGetDuration.UpdateSince(start)
+	return has, err
+}
+
 func (b *backend) GetMessageSignature(messageID ids.ID) ([bls.SignatureLen]byte, error) {
 	log.Debug("Getting warp message from backend", "messageID", messageID)
+	b.signerLock.RLock()
+	defer b.signerLock.RUnlock()
+
 	if sig, ok := b.messageSignatureCache.Get(messageID); ok {
 		return sig, nil
 	}
@@ -147,7 +378,7 @@ func (b *backend) GetMessageSignature(messageID ids.ID) ([bls.SignatureLen]byte,
 	var signature [bls.SignatureLen]byte
 	sig, err := b.warpSigner.Sign(unsignedMessage)
 	if err != nil {
-		return [bls.SignatureLen]byte{}, fmt.Errorf("failed to sign warp message: %w", err)
+		return [bls.SignatureLen]byte{}, fmt.Errorf("%w: %w", ErrSigningFailed, err)
 	}
 
 	copy(signature[:], sig)
@@ -155,48 +386,117 @@ func (b *backend) GetMessageSignature(messageID ids.ID) ([bls.SignatureLen]byte,
 	return signature, nil
 }
 
+func (b *backend) ReSign(messageID ids.ID) ([bls.SignatureLen]byte, error) {
+	b.signerLock.RLock()
+	defer b.signerLock.RUnlock()
+
+	unsignedMessage, err := b.readMessage(messageID)
+	if err != nil {
+		return [bls.SignatureLen]byte{}, err
+	}
+	if unsignedMessage.ID() != messageID {
+		log.Warn("Stored warp message does not match its ID", "messageID", messageID, "storedID", unsignedMessage.ID())
+		return [bls.SignatureLen]byte{}, fmt.Errorf("%w: message %s hashes to %s", ErrMessageIDMismatch, messageID, unsignedMessage.ID())
+	}
+
+	var signature [bls.SignatureLen]byte
+	sig, err := b.warpSigner.Sign(unsignedMessage)
+	if err != nil {
+		return [bls.SignatureLen]byte{}, fmt.Errorf("%w: %w", ErrSigningFailed, err)
+	}
+	copy(signature[:], sig)
+
+	cached, ok := b.messageSignatureCache.Get(messageID)
+	if !ok {
+		return signature, fmt.Errorf("%w for message %s", ErrNoServedSignature, messageID)
+	}
+	if cached != signature {
+		log.Warn("Cached warp message signature does not match the stored message", "messageID", messageID)
+		return signature, fmt.Errorf("%w for message %s", ErrSignatureMismatch, messageID)
+	}
+	return signature, nil
+}
+
 func (b *backend) GetBlockSignature(blockID ids.ID) ([bls.SignatureLen]byte, error) {
 	log.Debug("Getting block from backend", "blockID", blockID)
+	b.signerLock.RLock()
+	defer b.signerLock.RUnlock()
+
 	if sig, ok := b.blockSignatureCache.Get(blockID); ok {
 		return sig, nil
 	}
 
+	unsignedMessage, err := b.GetBlockMessage(blockID)
+	if err != nil {
+		return [bls.SignatureLen]byte{}, err
+	}
+
+	var signature [bls.SignatureLen]byte
+	sig, err := b.warpSigner.Sign(unsignedMessage)
+	if err != nil {
+		return [bls.SignatureLen]byte{}, fmt.Errorf("%w: %w", ErrSigningFailed, err)
+	}
+
+	copy(signature[:], sig)
+	b.blockSignatureCache.Put(blockID, signature)
+	return signature, nil
+}
+
+func (b *backend) WarmBlockSignatures(blockIDs []ids.ID) int {
+	warmed := 0
+	for _, blockID := range blockIDs {
+		if _, err := b.GetBlockSignature(blockID); err != nil {
+			log.Debug("Failed to warm warp block signature", "blockID", blockID, "err", err)
+			continue
+		}
+		warmed++
+	}
+	return warmed
+}
+
+func (b *backend) GetBlockMessage(blockID ids.ID) (*luxWarp.UnsignedMessage, error) {
 	block, err := b.blockClient.GetBlock(context.TODO(), blockID)
 	if err != nil {
-		return [bls.SignatureLen]byte{}, fmt.Errorf("failed to get block %s: %w", blockID, err)
+		return nil, fmt.Errorf("failed to get block %s: %w", blockID, err)
 	}
 	if block.Status() != choices.Accepted {
-		return [bls.SignatureLen]byte{}, fmt.Errorf("block %s was not accepted", blockID)
+		return nil, fmt.Errorf("block %s was not accepted", blockID)
 	}
 
-	var signature [bls.SignatureLen]byte
 	blockHashPayload, err := payload.NewHash(blockID)
 	if err != nil {
-		return [bls.SignatureLen]byte{}, fmt.Errorf("failed to create new block hash payload: %w", err)
+		return nil, fmt.Errorf("failed to create new block hash payload: %w", err)
 	}
 	unsignedMessage, err := luxWarp.NewUnsignedMessage(b.networkID, b.sourceChainID, blockHashPayload.Bytes())
 	if err != nil {
-		return [bls.SignatureLen]byte{}, fmt.Errorf("failed to create new unsigned warp message: %w", err)
-	}
-	sig, err := b.warpSigner.Sign(unsignedMessage)
-	if err != nil {
-		return [bls.SignatureLen]byte{}, fmt.Errorf("failed to sign warp message: %w", err)
+		return nil, fmt.Errorf("failed to create new unsigned warp message: %w", err)
 	}
-
-	copy(signature[:], sig)
-	b.blockSignatureCache.Put(blockID, signature)
-	return signature, nil
+	return unsignedMessage, nil
 }
 
 func (b *backend) GetMessage(messageID ids.ID) (*luxWarp.UnsignedMessage, error) {
 	if message, ok := b.messageCache.Get(messageID); ok {
 		return message, nil
 	}
+	unsignedMessage, err := b.readMessage(messageID)
+	if err != nil {
+		return nil, err
+	}
+	b.messageCache.Put(messageID, unsignedMessage)
+
+	return unsignedMessage, nil
+}
+
+// readMessage returns the off-chain message or the message stored in the db
+// with [messageID], without going through the message cache.
+func (b *backend) readMessage(messageID ids.ID) (*luxWarp.UnsignedMessage, error) {
 	if message, ok := b.offchainAddressedCallMsgs[messageID]; ok {
 		return message, nil
 	}
 
+	start := time.Now()
 	unsignedMessageBytes, err := b.db.Get(messageID[:])
+	b.stats.dbGetDuration.UpdateSince(start)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get warp message %s from db: %w", messageID.String(), err)
 	}
@@ -205,7 +505,5 @@ func (b *backend) GetMessage(messageID ids.ID) (*luxWarp.UnsignedMessage, error)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse unsigned message %s: %w", messageID.String(), err)
 	}
-	b.messageCache.Put(messageID, unsignedMessage)
-
 	return unsignedMessage, nil
 }
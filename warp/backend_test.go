@@ -6,8 +6,13 @@ package warp
 import (
 	"context"
 	"errors"
+	"io/fs"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/luxdefi/node/database"
+	"github.com/luxdefi/node/database/leveldb"
 	"github.com/luxdefi/node/database/memdb"
 	"github.com/luxdefi/node/ids"
 	"github.com/luxdefi/node/snow/choices"
@@ -17,8 +22,10 @@ import (
 	"github.com/luxdefi/node/utils"
 	"github.com/luxdefi/node/utils/crypto/bls"
 	"github.com/luxdefi/node/utils/hashing"
+	"github.com/luxdefi/node/utils/logging"
 	luxWarp "github.com/luxdefi/node/vms/platformvm/warp"
 	"github.com/luxdefi/node/vms/platformvm/warp/payload"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/require"
 )
 
@@ -47,7 +54,7 @@ func TestClearDB(t *testing.T) {
 	sk, err := bls.NewSecretKey()
 	require.NoError(t, err)
 	warpSigner := luxWarp.NewSigner(sk, networkID, sourceChainID)
-	backendIntf, err := NewBackend(networkID, sourceChainID, warpSigner, nil, db, 500, nil)
+	backendIntf, err := NewBackend(networkID, sourceChainID, warpSigner, nil, db, 500, false, nil)
 	require.NoError(t, err)
 	backend, ok := backendIntf.(*backend)
 	require.True(t, ok)
@@ -62,7 +69,7 @@ func TestClearDB(t *testing.T) {
 		require.NoError(t, err)
 		messageID := hashing.ComputeHash256Array(unsignedMsg.Bytes())
 		messageIDs = append(messageIDs, messageID)
-		err = backend.AddMessage(unsignedMsg)
+		err = backend.AddMessage(unsignedMsg, 1)
 		require.NoError(t, err)
 		// ensure that the message was added
 		_, err = backend.GetMessageSignature(messageID)
@@ -85,17 +92,69 @@ func TestClearDB(t *testing.T) {
 	}
 }
 
+// dirSize returns the total size of the files in [dir].
+func dirSize(t *testing.T, dir string) int64 {
+	var size int64
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	require.NoError(t, err)
+	return size
+}
+
+func TestPruneCompaction(t *testing.T) {
+	dir := t.TempDir()
+	db, err := leveldb.New(dir, nil, logging.NoLog{}, "", prometheus.NewRegistry())
+	require.NoError(t, err)
+	defer db.Close()
+
+	sk, err := bls.NewSecretKey()
+	require.NoError(t, err)
+	warpSigner := luxWarp.NewSigner(sk, networkID, sourceChainID)
+	backendIntf, err := NewBackend(networkID, sourceChainID, warpSigner, nil, db, 500, false, nil)
+	require.NoError(t, err)
+	backend, ok := backendIntf.(*backend)
+	require.True(t, ok)
+
+	// fill the db with enough messages to be flushed to disk
+	const numMessages = 10_000
+	for i := 0; i < numMessages; i++ {
+		messageID := ids.GenerateTestID()
+		require.NoError(t, db.Put(messageID[:], utils.RandomBytes(1024)))
+	}
+	require.NoError(t, db.Compact(nil, compactionLimit))
+	sizeBeforePrune := dirSize(t, dir)
+
+	require.NoError(t, backend.Prune(numMessages-1))
+	backend.compactionWg.Wait()
+	empty, err := database.IsEmpty(db)
+	require.NoError(t, err)
+	require.True(t, empty)
+	// obsolete table files are removed asynchronously after the compaction
+	require.Eventually(t, func() bool {
+		return dirSize(t, dir) < sizeBeforePrune/2
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
 func TestAddAndGetValidMessage(t *testing.T) {
 	db := memdb.New()
 
 	sk, err := bls.NewSecretKey()
 	require.NoError(t, err)
 	warpSigner := luxWarp.NewSigner(sk, networkID, sourceChainID)
-	backend, err := NewBackend(networkID, sourceChainID, warpSigner, nil, db, 500, nil)
+	backend, err := NewBackend(networkID, sourceChainID, warpSigner, nil, db, 500, false, nil)
 	require.NoError(t, err)
 
 	// Add testUnsignedMessage to the warp backend
-	err = backend.AddMessage(testUnsignedMessage)
+	err = backend.AddMessage(testUnsignedMessage, 1)
 	require.NoError(t, err)
 
 	// Verify that a signature is returned successfully, and compare to expected signature.
@@ -114,7 +173,7 @@ func TestAddAndGetUnknownMessage(t *testing.T) {
 	sk, err := bls.NewSecretKey()
 	require.NoError(t, err)
 	warpSigner := luxWarp.NewSigner(sk, networkID, sourceChainID)
-	backend, err := NewBackend(networkID, sourceChainID, warpSigner, nil, db, 500, nil)
+	backend, err := NewBackend(networkID, sourceChainID, warpSigner, nil, db, 500, false, nil)
 	require.NoError(t, err)
 
 	// Try getting a signature for a message that was not added.
@@ -146,7 +205,7 @@ func TestGetBlockSignature(t *testing.T) {
 	sk, err := bls.NewSecretKey()
 	require.NoError(err)
 	warpSigner := luxWarp.NewSigner(sk, networkID, sourceChainID)
-	backend, err := NewBackend(networkID, sourceChainID, warpSigner, testVM, db, 500, nil)
+	backend, err := NewBackend(networkID, sourceChainID, warpSigner, testVM, db, 500, false, nil)
 	require.NoError(err)
 
 	blockHashPayload, err := payload.NewHash(blkID)
@@ -164,6 +223,99 @@ func TestGetBlockSignature(t *testing.T) {
 	require.Error(err)
 }
 
+func TestWarmBlockSignatures(t *testing.T) {
+	require := require.New(t)
+
+	var (
+		blkIDs    = []ids.ID{ids.GenerateTestID(), ids.GenerateTestID()}
+		getBlocks int
+	)
+	testVM := &block.TestVM{
+		TestVM: common.TestVM{T: t},
+		GetBlockF: func(ctx context.Context, i ids.ID) (snowman.Block, error) {
+			getBlocks++
+			for _, blkID := range blkIDs {
+				if i == blkID {
+					return &snowman.TestBlock{
+						TestDecidable: choices.TestDecidable{
+							IDV:     blkID,
+							StatusV: choices.Accepted,
+						},
+					}, nil
+				}
+			}
+			return nil, errors.New("invalid blockID")
+		},
+	}
+	sk, err := bls.NewSecretKey()
+	require.NoError(err)
+	warpSigner := luxWarp.NewSigner(sk, networkID, sourceChainID)
+	backend, err := NewBackend(networkID, sourceChainID, warpSigner, testVM, memdb.New(), 500, false, nil)
+	require.NoError(err)
+
+	// Unknown blocks are skipped
+	require.Equal(len(blkIDs), backend.WarmBlockSignatures(append(blkIDs, ids.GenerateTestID())))
+	require.Equal(len(blkIDs)+1, getBlocks)
+
+	// The warmed signatures are served without looking up the blocks again
+	for _, blkID := range blkIDs {
+		blockHashPayload, err := payload.NewHash(blkID)
+		require.NoError(err)
+		unsignedMessage, err := luxWarp.NewUnsignedMessage(networkID, sourceChainID, blockHashPayload.Bytes())
+		require.NoError(err)
+		expectedSig, err := warpSigner.Sign(unsignedMessage)
+		require.NoError(err)
+
+		signature, err := backend.GetBlockSignature(blkID)
+		require.NoError(err)
+		require.Equal(expectedSig, signature[:])
+	}
+	require.Equal(len(blkIDs)+1, getBlocks)
+}
+
+func TestGetBlockMessage(t *testing.T) {
+	require := require.New(t)
+
+	blkID := ids.GenerateTestID()
+	testVM := &block.TestVM{
+		TestVM: common.TestVM{T: t},
+		GetBlockF: func(ctx context.Context, i ids.ID) (snowman.Block, error) {
+			if i == blkID {
+				return &snowman.TestBlock{
+					TestDecidable: choices.TestDecidable{
+						IDV:     blkID,
+						StatusV: choices.Accepted,
+					},
+				}, nil
+			}
+			return nil, errors.New("invalid blockID")
+		},
+	}
+	sk, err := bls.NewSecretKey()
+	require.NoError(err)
+	warpSigner := luxWarp.NewSigner(sk, networkID, sourceChainID)
+	backend, err := NewBackend(networkID, sourceChainID, warpSigner, testVM, memdb.New(), 500, false, nil)
+	require.NoError(err)
+
+	unsignedMessage, err := backend.GetBlockMessage(blkID)
+	require.NoError(err)
+	require.Equal(networkID, unsignedMessage.NetworkID)
+	require.Equal(sourceChainID, unsignedMessage.SourceChainID)
+	blockHashPayload, err := payload.ParseHash(unsignedMessage.Payload)
+	require.NoError(err)
+	require.Equal(blkID, blockHashPayload.Hash)
+
+	// The message is what the block signature is over.
+	signature, err := backend.GetBlockSignature(blkID)
+	require.NoError(err)
+	sig, err := bls.SignatureFromBytes(signature[:])
+	require.NoError(err)
+	require.True(bls.Verify(bls.PublicFromSecretKey(sk), sig, unsignedMessage.Bytes()))
+
+	_, err = backend.GetBlockMessage(ids.GenerateTestID())
+	require.Error(err)
+}
+
 func TestZeroSizedCache(t *testing.T) {
 	db := memdb.New()
 
@@ -172,11 +324,11 @@ func TestZeroSizedCache(t *testing.T) {
 	warpSigner := luxWarp.NewSigner(sk, networkID, sourceChainID)
 
 	// Verify zero sized cache works normally, because the lru cache will be initialized to size 1 for any size parameter <= 0.
-	backend, err := NewBackend(networkID, sourceChainID, warpSigner, nil, db, 0, nil)
+	backend, err := NewBackend(networkID, sourceChainID, warpSigner, nil, db, 0, false, nil)
 	require.NoError(t, err)
 
 	// Add testUnsignedMessage to the warp backend
-	err = backend.AddMessage(testUnsignedMessage)
+	err = backend.AddMessage(testUnsignedMessage, 1)
 	require.NoError(t, err)
 
 	// Verify that a signature is returned successfully, and compare to expected signature.
@@ -226,7 +378,7 @@ func TestOffChainMessages(t *testing.T) {
 			require := require.New(t)
 			db := memdb.New()
 
-			backend, err := NewBackend(networkID, sourceChainID, warpSigner, nil, db, 0, test.offchainMessages)
+			backend, err := NewBackend(networkID, sourceChainID, warpSigner, nil, db, 0, false, test.offchainMessages)
 			require.ErrorIs(err, test.err)
 			if test.check != nil {
 				test.check(require, backend)
@@ -234,3 +386,207 @@ func TestOffChainMessages(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateSigner(t *testing.T) {
+	require := require.New(t)
+
+	blkID := ids.GenerateTestID()
+	testVM := &block.TestVM{
+		TestVM: common.TestVM{T: t},
+		GetBlockF: func(ctx context.Context, i ids.ID) (snowman.Block, error) {
+			return &snowman.TestBlock{
+				TestDecidable: choices.TestDecidable{
+					IDV:     i,
+					StatusV: choices.Accepted,
+				},
+			}, nil
+		},
+	}
+	db := memdb.New()
+
+	sk, err := bls.NewSecretKey()
+	require.NoError(err)
+	oldSigner := luxWarp.NewSigner(sk, networkID, sourceChainID)
+	backend, err := NewBackend(networkID, sourceChainID, oldSigner, testVM, db, 500, false, nil)
+	require.NoError(err)
+
+	// Populate the signature caches with the old key.
+	require.NoError(backend.AddMessage(testUnsignedMessage, 1))
+	oldMessageSig, err := backend.GetMessageSignature(testUnsignedMessage.ID())
+	require.NoError(err)
+	oldBlockSig, err := backend.GetBlockSignature(blkID)
+	require.NoError(err)
+
+	newSK, err := bls.NewSecretKey()
+	require.NoError(err)
+	newSigner := NewPublicKeySigner(newSK, networkID, sourceChainID)
+	backend.UpdateSigner(newSigner)
+
+	// Signatures must now be produced by the new key.
+	messageSig, err := backend.GetMessageSignature(testUnsignedMessage.ID())
+	require.NoError(err)
+	require.NotEqual(oldMessageSig, messageSig)
+	expectedSig, err := newSigner.Sign(testUnsignedMessage)
+	require.NoError(err)
+	require.Equal(expectedSig, messageSig[:])
+
+	blockSig, err := backend.GetBlockSignature(blkID)
+	require.NoError(err)
+	require.NotEqual(oldBlockSig, blockSig)
+	blockHashPayload, err := payload.NewHash(blkID)
+	require.NoError(err)
+	unsignedMessage, err := luxWarp.NewUnsignedMessage(networkID, sourceChainID, blockHashPayload.Bytes())
+	require.NoError(err)
+	expectedSig, err = newSigner.Sign(unsignedMessage)
+	require.NoError(err)
+	require.Equal(expectedSig, blockSig[:])
+}
+
+// slowDatabase delays every Get and Put by [delay].
+type slowDatabase struct {
+	database.Database
+	delay time.Duration
+}
+
+func (db *slowDatabase) Get(key []byte) ([]byte, error) {
+	time.Sleep(db.delay)
+	return db.Database.Get(key)
+}
+
+func (db *slowDatabase) Put(key []byte, value []byte) error {
+	time.Sleep(db.delay)
+	return db.Database.Put(key, value)
+}
+
+func TestDatabaseLatencyMetrics(t *testing.T) {
+	require := require.New(t)
+
+	delay := 10 * time.Millisecond
+	db := &slowDatabase{Database: memdb.New(), delay: delay}
+	sk, err := bls.NewSecretKey()
+	require.NoError(err)
+	warpSigner := luxWarp.NewSigner(sk, networkID, sourceChainID)
+	backendIntf, err := NewBackend(networkID, sourceChainID, warpSigner, nil, db, 500, false, nil)
+	require.NoError(err)
+	backend, ok := backendIntf.(*backend)
+	require.True(ok)
+
+	// The timers are registered globally, so only count the new observations.
+	puts, gets := backend.stats.dbPutDuration.Count(), backend.stats.dbGetDuration.Count()
+
+	require.NoError(backend.AddMessage(testUnsignedMessage, 1))
+	require.Equal(puts+1, backend.stats.dbPutDuration.Count())
+	require.GreaterOrEqual(backend.stats.dbPutDuration.Max(), int64(delay))
+
+	// Served from the signature cache without touching the database.
+	_, err = backend.GetMessageSignature(testUnsignedMessage.ID())
+	require.NoError(err)
+	require.Equal(gets, backend.stats.dbGetDuration.Count())
+
+	backend.messageSignatureCache.Flush()
+	_, err = backend.GetMessageSignature(testUnsignedMessage.ID())
+	require.NoError(err)
+	require.Equal(gets+1, backend.stats.dbGetDuration.Count())
+	require.GreaterOrEqual(backend.stats.dbGetDuration.Max(), int64(delay))
+}
+
+// countingSigner counts the messages signed by the wrapped signer.
+type countingSigner struct {
+	luxWarp.Signer
+	signed int
+}
+
+func (s *countingSigner) Sign(msg *luxWarp.UnsignedMessage) ([]byte, error) {
+	s.signed++
+	return s.Signer.Sign(msg)
+}
+
+func TestAddMessageDedup(t *testing.T) {
+	for name, dedup := range map[string]bool{"enabled": true, "disabled": false} {
+		t.Run(name, func(t *testing.T) {
+			require := require.New(t)
+
+			sk, err := bls.NewSecretKey()
+			require.NoError(err)
+			warpSigner := &countingSigner{Signer: luxWarp.NewSigner(sk, networkID, sourceChainID)}
+			backendIntf, err := NewBackend(networkID, sourceChainID, warpSigner, nil, memdb.New(), 500, dedup, nil)
+			require.NoError(err)
+			backend, ok := backendIntf.(*backend)
+			require.True(ok)
+
+			// The counter is registered globally, so only count the new hits.
+			hits := backend.stats.messageDedupHits.Count()
+
+			// Adding the same message twice stores and signs it once.
+			require.NoError(backend.AddMessage(testUnsignedMessage, 1))
+			require.NoError(backend.AddMessage(testUnsignedMessage, 1))
+			expectedSigned, expectedHits := 1, hits+1
+			if !dedup {
+				expectedSigned, expectedHits = 2, hits
+			}
+			require.Equal(expectedSigned, warpSigner.signed)
+			require.Equal(expectedHits, backend.stats.messageDedupHits.Count())
+
+			signature, err := backend.GetMessageSignature(testUnsignedMessage.ID())
+			require.NoError(err)
+			expectedSig, err := warpSigner.Signer.Sign(testUnsignedMessage)
+			require.NoError(err)
+			require.Equal(expectedSig, signature[:])
+
+			// The same payload from another chain is a different message, so it
+			// is not deduplicated and fails to be signed by this chain.
+			otherChainMessage, err := luxWarp.NewUnsignedMessage(networkID, ids.GenerateTestID(), testUnsignedMessage.Payload)
+			require.NoError(err)
+			require.NotEqual(testUnsignedMessage.ID(), otherChainMessage.ID())
+			require.ErrorIs(backend.AddMessage(otherChainMessage, 1), luxWarp.ErrWrongSourceChainID)
+			require.Equal(expectedSigned+1, warpSigner.signed)
+			require.Equal(expectedHits, backend.stats.messageDedupHits.Count())
+		})
+	}
+}
+
+func TestReSign(t *testing.T) {
+	require := require.New(t)
+
+	sk, err := bls.NewSecretKey()
+	require.NoError(err)
+	warpSigner := luxWarp.NewSigner(sk, networkID, sourceChainID)
+	backendIntf, err := NewBackend(networkID, sourceChainID, warpSigner, nil, memdb.New(), 500, false, nil)
+	require.NoError(err)
+	backend, ok := backendIntf.(*backend)
+	require.True(ok)
+
+	_, err = backend.ReSign(testUnsignedMessage.ID())
+	require.ErrorIs(err, database.ErrNotFound)
+
+	require.NoError(backend.AddMessage(testUnsignedMessage, 1))
+	messageID := testUnsignedMessage.ID()
+	served, err := backend.GetMessageSignature(messageID)
+	require.NoError(err)
+
+	// The untampered signature matches the one derived again.
+	signature, err := backend.ReSign(messageID)
+	require.NoError(err)
+	require.Equal(served, signature)
+
+	// A tampered cache entry is detected, and the correct signature returned.
+	tampered := served
+	tampered[0] ^= 0xff
+	backend.messageSignatureCache.Put(messageID, tampered)
+	signature, err = backend.ReSign(messageID)
+	require.ErrorIs(err, ErrSignatureMismatch)
+	require.Equal(served, signature)
+
+	// Without a served signature there is nothing to compare against.
+	backend.messageSignatureCache.Flush()
+	signature, err = backend.ReSign(messageID)
+	require.ErrorIs(err, ErrNoServedSignature)
+	require.Equal(served, signature)
+
+	// A stored message that does not hash to its ID is rejected.
+	otherMessage, err := luxWarp.NewUnsignedMessage(networkID, sourceChainID, []byte("other"))
+	require.NoError(err)
+	require.NoError(backend.db.Put(messageID[:], otherMessage.Bytes()))
+	_, err = backend.ReSign(messageID)
+	require.ErrorIs(err, ErrMessageIDMismatch)
+}
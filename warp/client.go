@@ -18,7 +18,11 @@ type Client interface {
 	GetMessageSignature(ctx context.Context, messageID ids.ID) ([]byte, error)
 	GetMessageAggregateSignature(ctx context.Context, messageID ids.ID, quorumNum uint64, subnetIDStr string) ([]byte, error)
 	GetBlockSignature(ctx context.Context, blockID ids.ID) ([]byte, error)
+	GetBlockMessage(ctx context.Context, blockID ids.ID) ([]byte, error)
 	GetBlockAggregateSignature(ctx context.Context, blockID ids.ID, quorumNum uint64, subnetIDStr string) ([]byte, error)
+	GetMessageMultiSubnetAggregateSignature(ctx context.Context, messageID ids.ID, subnets []SubnetQuorumArgs) ([]SubnetSignedMessage, error)
+	GetBlockMultiSubnetAggregateSignature(ctx context.Context, blockID ids.ID, subnets []SubnetQuorumArgs) ([]SubnetSignedMessage, error)
+	ParseMessage(ctx context.Context, messageBytes []byte) (*ParsedMessage, error)
 }
 
 // client implementation for interacting with EVM [chain]
@@ -61,6 +65,14 @@ func (c *client) GetBlockSignature(ctx context.Context, blockID ids.ID) ([]byte,
 	return res, nil
 }
 
+func (c *client) GetBlockMessage(ctx context.Context, blockID ids.ID) ([]byte, error) {
+	var res hexutil.Bytes
+	if err := c.client.CallContext(ctx, &res, "warp_getBlockMessage", blockID); err != nil {
+		return nil, fmt.Errorf("call to warp_getBlockMessage failed. err: %w", err)
+	}
+	return res, nil
+}
+
 func (c *client) GetBlockAggregateSignature(ctx context.Context, blockID ids.ID, quorumNum uint64, subnetIDStr string) ([]byte, error) {
 	var res hexutil.Bytes
 	if err := c.client.CallContext(ctx, &res, "warp_getBlockAggregateSignature", blockID, quorumNum, subnetIDStr); err != nil {
@@ -68,3 +80,27 @@ func (c *client) GetBlockAggregateSignature(ctx context.Context, blockID ids.ID,
 	}
 	return res, nil
 }
+
+func (c *client) GetMessageMultiSubnetAggregateSignature(ctx context.Context, messageID ids.ID, subnets []SubnetQuorumArgs) ([]SubnetSignedMessage, error) {
+	var res []SubnetSignedMessage
+	if err := c.client.CallContext(ctx, &res, "warp_getMessageMultiSubnetAggregateSignature", messageID, subnets); err != nil {
+		return nil, fmt.Errorf("call to warp_getMessageMultiSubnetAggregateSignature failed. err: %w", err)
+	}
+	return res, nil
+}
+
+func (c *client) GetBlockMultiSubnetAggregateSignature(ctx context.Context, blockID ids.ID, subnets []SubnetQuorumArgs) ([]SubnetSignedMessage, error) {
+	var res []SubnetSignedMessage
+	if err := c.client.CallContext(ctx, &res, "warp_getBlockMultiSubnetAggregateSignature", blockID, subnets); err != nil {
+		return nil, fmt.Errorf("call to warp_getBlockMultiSubnetAggregateSignature failed. err: %w", err)
+	}
+	return res, nil
+}
+
+func (c *client) ParseMessage(ctx context.Context, messageBytes []byte) (*ParsedMessage, error) {
+	var res ParsedMessage
+	if err := c.client.CallContext(ctx, &res, "warp_parseMessage", hexutil.Bytes(messageBytes)); err != nil {
+		return nil, fmt.Errorf("call to warp_parseMessage failed. err: %w", err)
+	}
+	return &res, nil
+}
@@ -5,6 +5,8 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"time"
 
 	"github.com/luxdefi/node/codec"
@@ -20,53 +22,151 @@ type SignatureRequestHandler struct {
 	backend warp.Backend
 	codec   codec.Manager
 	stats   *handlerStats
+
+	// maxConcurrentPerPeer is the maximum number of requests from a single
+	// peer that are served at the same time (0 means no limit). [inflight]
+	// tracks the number of requests currently served for each peer.
+	maxConcurrentPerPeer int
+	inflightLock         sync.Mutex
+	inflight             map[ids.NodeID]int
+
+	// coalesceWindow is how long a message signature request waits for
+	// identical requests to share its signing with (0 means no coalescing).
+	// [pending] tracks the signings in progress by message ID.
+	coalesceWindow time.Duration
+	pendingLock    sync.Mutex
+	pending        map[ids.ID]*signatureCall
+}
+
+// signatureCall is a message signing shared by coalesced requests. [done] is
+// closed once [signature] and [err] are set.
+type signatureCall struct {
+	done      chan struct{}
+	signature [bls.SignatureLen]byte
+	err       error
 }
 
-func NewSignatureRequestHandler(backend warp.Backend, codec codec.Manager) *SignatureRequestHandler {
+// NewSignatureRequestHandler returns a handler that serves at most
+// [maxConcurrentPerPeer] requests at a time from any single peer, dropping
+// the requests above that limit. If [maxConcurrentPerPeer] is 0, the
+// number of concurrent requests is not limited.
+// Message signature requests for the same message ID received within
+// [coalesceWindow] of each other are served by a single signing. If
+// [coalesceWindow] is 0, every request is signed separately.
+func NewSignatureRequestHandler(backend warp.Backend, codec codec.Manager, maxConcurrentPerPeer int, coalesceWindow time.Duration) *SignatureRequestHandler {
 	return &SignatureRequestHandler{
-		backend: backend,
-		codec:   codec,
-		stats:   newStats(),
+		backend:              backend,
+		codec:                codec,
+		stats:                newStats(),
+		maxConcurrentPerPeer: maxConcurrentPerPeer,
+		inflight:             make(map[ids.NodeID]int),
+		coalesceWindow:       coalesceWindow,
+		pending:              make(map[ids.ID]*signatureCall),
+	}
+}
+
+// acquire reserves a slot for serving a request from [nodeID] and returns
+// false if the peer already has [maxConcurrentPerPeer] requests in flight.
+// If acquire returns true, release must be called once the request is served.
+func (s *SignatureRequestHandler) acquire(nodeID ids.NodeID) bool {
+	if s.maxConcurrentPerPeer <= 0 {
+		return true
+	}
+	s.inflightLock.Lock()
+	defer s.inflightLock.Unlock()
+
+	if s.inflight[nodeID] >= s.maxConcurrentPerPeer {
+		return false
+	}
+	s.inflight[nodeID]++
+	return true
+}
+
+func (s *SignatureRequestHandler) release(nodeID ids.NodeID) {
+	if s.maxConcurrentPerPeer <= 0 {
+		return
+	}
+	s.inflightLock.Lock()
+	defer s.inflightLock.Unlock()
+
+	if s.inflight[nodeID]--; s.inflight[nodeID] <= 0 {
+		delete(s.inflight, nodeID)
+	}
+}
+
+// getMessageSignature returns the signature of [messageID]. If coalescing is
+// enabled, the first request for [messageID] waits for [coalesceWindow]
+// before signing, and the requests received until the signing completes
+// receive its result instead of signing again.
+func (s *SignatureRequestHandler) getMessageSignature(messageID ids.ID) ([bls.SignatureLen]byte, error) {
+	if s.coalesceWindow <= 0 {
+		return s.backend.GetMessageSignature(messageID)
+	}
+
+	s.pendingLock.Lock()
+	if call, ok := s.pending[messageID]; ok {
+		s.pendingLock.Unlock()
+		s.stats.IncMessageSignatureCoalesced()
+		<-call.done
+		return call.signature, call.err
 	}
+	call := &signatureCall{done: make(chan struct{})}
+	s.pending[messageID] = call
+	s.pendingLock.Unlock()
+
+	time.Sleep(s.coalesceWindow)
+	call.signature, call.err = s.backend.GetMessageSignature(messageID)
+
+	s.pendingLock.Lock()
+	delete(s.pending, messageID)
+	s.pendingLock.Unlock()
+	close(call.done)
+	return call.signature, call.err
 }
 
 // OnMessageSignatureRequest handles message.MessageSignatureRequest, and retrieves a warp signature for the requested message ID.
 // Never returns an error
 // Expects returned errors to be treated as FATAL
-// Returns empty response if signature is not found
+// Returns a response with a non-OK status if the signature cannot be produced
 // Assumes ctx is active
 func (s *SignatureRequestHandler) OnMessageSignatureRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, signatureRequest message.MessageSignatureRequest) ([]byte, error) {
 	startTime := time.Now()
 	s.stats.IncMessageSignatureRequest()
 
+	if !s.acquire(nodeID) {
+		log.Debug("Dropping message signature request, too many concurrent requests from peer", "nodeID", nodeID, "requestID", requestID)
+		s.stats.IncConcurrencyLimitExceeded()
+		return nil, nil
+	}
+	defer s.release(nodeID)
+
 	// Always report signature request time
 	defer func() {
 		s.stats.UpdateMessageSignatureRequestTime(time.Since(startTime))
 	}()
 
-	signature, err := s.backend.GetMessageSignature(signatureRequest.MessageID)
+	signature, err := s.getMessageSignature(signatureRequest.MessageID)
 	if err != nil {
-		log.Debug("Unknown warp signature requested", "messageID", signatureRequest.MessageID)
+		log.Debug("Failed to get warp signature for requested message", "messageID", signatureRequest.MessageID, "err", err)
 		s.stats.IncMessageSignatureMiss()
-		signature = [bls.SignatureLen]byte{}
 	} else {
 		s.stats.IncMessageSignatureHit()
 	}
 
-	response := message.SignatureResponse{Signature: signature}
-	responseBytes, err := s.codec.Marshal(message.Version, &response)
-	if err != nil {
-		log.Error("could not marshal SignatureResponse, dropping request", "nodeID", nodeID, "requestID", requestID, "err", err)
-		return nil, nil
-	}
-
-	return responseBytes, nil
+	return s.marshalResponse(nodeID, requestID, signature, err)
 }
 
 func (s *SignatureRequestHandler) OnBlockSignatureRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, request message.BlockSignatureRequest) ([]byte, error) {
 	startTime := time.Now()
 	s.stats.IncBlockSignatureRequest()
 
+	if !s.acquire(nodeID) {
+		log.Debug("Dropping block signature request, too many concurrent requests from peer", "nodeID", nodeID, "requestID", requestID)
+		s.stats.IncConcurrencyLimitExceeded()
+		return nil, nil
+	}
+	defer s.release(nodeID)
+
 	// Always report signature request time
 	defer func() {
 		s.stats.UpdateBlockSignatureRequestTime(time.Since(startTime))
@@ -74,15 +174,35 @@ func (s *SignatureRequestHandler) OnBlockSignatureRequest(ctx context.Context, n
 
 	signature, err := s.backend.GetBlockSignature(request.BlockID)
 	if err != nil {
-		log.Debug("Unknown warp signature requested", "blockID", request.BlockID)
+		log.Debug("Failed to get warp signature for requested block", "blockID", request.BlockID, "err", err)
 		s.stats.IncBlockSignatureMiss()
-		signature = [bls.SignatureLen]byte{}
 	} else {
 		s.stats.IncBlockSignatureHit()
 	}
 
-	response := message.SignatureResponse{Signature: signature}
-	responseBytes, err := s.codec.Marshal(message.Version, &response)
+	return s.marshalResponse(nodeID, requestID, signature, err)
+}
+
+// marshalResponse encodes a SignatureResponse for [signature], or for the failure
+// described by [signErr] if non-nil.
+// Successful responses are encoded with message.Version, so they are unchanged for
+// peers that do not support message.StatusVersion. Failures are encoded with
+// message.StatusVersion to carry the status. Such peers cannot decode them, which
+// they handle the same way as the empty signatures previously sent on failure.
+func (s *SignatureRequestHandler) marshalResponse(nodeID ids.NodeID, requestID uint32, signature [bls.SignatureLen]byte, signErr error) ([]byte, error) {
+	var (
+		response = message.SignatureResponse{Signature: signature}
+		version  = message.Version
+	)
+	if signErr != nil {
+		response = message.SignatureResponse{Status: message.SignatureUnknown}
+		if errors.Is(signErr, warp.ErrSigningFailed) {
+			response.Status = message.SignatureSigningFailed
+		}
+		version = message.StatusVersion
+	}
+
+	responseBytes, err := s.codec.Marshal(version, &response)
 	if err != nil {
 		log.Error("could not marshal SignatureResponse, dropping request", "nodeID", nodeID, "requestID", requestID, "err", err)
 		return nil, nil
@@ -6,7 +6,9 @@ package handlers
 import (
 	"context"
 	"errors"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/luxdefi/node/database/memdb"
 	"github.com/luxdefi/node/ids"
@@ -39,13 +41,13 @@ func TestMessageSignatureHandler(t *testing.T) {
 	offchainMessage, err := luxWarp.NewUnsignedMessage(snowCtx.NetworkID, snowCtx.ChainID, addressedPayload.Bytes())
 	require.NoError(t, err)
 
-	backend, err := warp.NewBackend(snowCtx.NetworkID, snowCtx.ChainID, warpSigner, &block.TestVM{TestVM: common.TestVM{T: t}}, database, 100, [][]byte{offchainMessage.Bytes()})
+	backend, err := warp.NewBackend(snowCtx.NetworkID, snowCtx.ChainID, warpSigner, &block.TestVM{TestVM: common.TestVM{T: t}}, database, 100, false, [][]byte{offchainMessage.Bytes()})
 	require.NoError(t, err)
 
 	msg, err := luxWarp.NewUnsignedMessage(snowCtx.NetworkID, snowCtx.ChainID, []byte("test"))
 	require.NoError(t, err)
 	messageID := msg.ID()
-	require.NoError(t, backend.AddMessage(msg))
+	require.NoError(t, backend.AddMessage(msg, 1))
 	signature, err := backend.GetMessageSignature(messageID)
 	require.NoError(t, err)
 	offchainSignature, err := backend.GetMessageSignature(offchainMessage.ID())
@@ -56,8 +58,9 @@ func TestMessageSignatureHandler(t *testing.T) {
 	emptySignature := [bls.SignatureLen]byte{}
 
 	tests := map[string]struct {
-		setup       func() (request message.MessageSignatureRequest, expectedResponse []byte)
-		verifyStats func(t *testing.T, stats *handlerStats)
+		setup          func() (request message.MessageSignatureRequest, expectedResponse []byte)
+		expectedStatus message.SignatureStatus
+		verifyStats    func(t *testing.T, stats *handlerStats)
 	}{
 		"known message": {
 			setup: func() (request message.MessageSignatureRequest, expectedResponse []byte) {
@@ -95,6 +98,7 @@ func TestMessageSignatureHandler(t *testing.T) {
 					MessageID: unknownMessageID,
 				}, emptySignature[:]
 			},
+			expectedStatus: message.SignatureUnknown,
 			verifyStats: func(t *testing.T, stats *handlerStats) {
 				require.EqualValues(t, 1, stats.messageSignatureRequest.Count())
 				require.EqualValues(t, 0, stats.messageSignatureHit.Count())
@@ -108,7 +112,7 @@ func TestMessageSignatureHandler(t *testing.T) {
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			handler := NewSignatureRequestHandler(backend, message.Codec)
+			handler := NewSignatureRequestHandler(backend, message.Codec, 0, 0)
 			handler.stats.Clear()
 
 			request, expectedResponse := test.setup()
@@ -127,6 +131,7 @@ func TestMessageSignatureHandler(t *testing.T) {
 			require.NoError(t, err, "error unmarshalling SignatureResponse")
 
 			require.Equal(t, expectedResponse, response.Signature[:])
+			require.Equal(t, test.expectedStatus, response.Status)
 		})
 	}
 }
@@ -160,6 +165,7 @@ func TestBlockSignatureHandler(t *testing.T) {
 		testVM,
 		database,
 		100,
+		false,
 		nil,
 	)
 	require.NoError(t, err)
@@ -171,8 +177,9 @@ func TestBlockSignatureHandler(t *testing.T) {
 	emptySignature := [bls.SignatureLen]byte{}
 
 	tests := map[string]struct {
-		setup       func() (request message.BlockSignatureRequest, expectedResponse []byte)
-		verifyStats func(t *testing.T, stats *handlerStats)
+		setup          func() (request message.BlockSignatureRequest, expectedResponse []byte)
+		expectedStatus message.SignatureStatus
+		verifyStats    func(t *testing.T, stats *handlerStats)
 	}{
 		"known block": {
 			setup: func() (request message.BlockSignatureRequest, expectedResponse []byte) {
@@ -195,6 +202,7 @@ func TestBlockSignatureHandler(t *testing.T) {
 					BlockID: unknownMessageID,
 				}, emptySignature[:]
 			},
+			expectedStatus: message.SignatureUnknown,
 			verifyStats: func(t *testing.T, stats *handlerStats) {
 				require.EqualValues(t, 0, stats.messageSignatureRequest.Count())
 				require.EqualValues(t, 0, stats.messageSignatureHit.Count())
@@ -208,7 +216,7 @@ func TestBlockSignatureHandler(t *testing.T) {
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			handler := NewSignatureRequestHandler(backend, message.Codec)
+			handler := NewSignatureRequestHandler(backend, message.Codec, 0, 0)
 			handler.stats.Clear()
 
 			request, expectedResponse := test.setup()
@@ -227,6 +235,172 @@ func TestBlockSignatureHandler(t *testing.T) {
 			require.NoError(t, err, "error unmarshalling SignatureResponse")
 
 			require.Equal(t, expectedResponse, response.Signature[:])
+			require.Equal(t, test.expectedStatus, response.Status)
 		})
 	}
 }
+
+// failingSigner is a warp signer that always fails to sign.
+type failingSigner struct{}
+
+func (failingSigner) Sign(*luxWarp.UnsignedMessage) ([]byte, error) {
+	return nil, errors.New("signer unavailable")
+}
+
+func TestBlockSignatureHandlerSigningFailed(t *testing.T) {
+	snowCtx := utils.TestSnowContext()
+	blkID := ids.GenerateTestID()
+	testVM := &block.TestVM{
+		TestVM: common.TestVM{T: t},
+		GetBlockF: func(ctx context.Context, i ids.ID) (snowman.Block, error) {
+			return &snowman.TestBlock{
+				TestDecidable: choices.TestDecidable{
+					IDV:     i,
+					StatusV: choices.Accepted,
+				},
+			}, nil
+		},
+	}
+	backend, err := warp.NewBackend(snowCtx.NetworkID, snowCtx.ChainID, failingSigner{}, testVM, memdb.New(), 100, false, nil)
+	require.NoError(t, err)
+
+	handler := NewSignatureRequestHandler(backend, message.Codec, 0, 0)
+	handler.stats.Clear()
+
+	responseBytes, err := handler.OnBlockSignatureRequest(context.Background(), ids.GenerateTestNodeID(), 1, message.BlockSignatureRequest{BlockID: blkID})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, handler.stats.blockSignatureMiss.Count())
+
+	var response message.SignatureResponse
+	version, err := message.Codec.Unmarshal(responseBytes, &response)
+	require.NoError(t, err)
+	require.Equal(t, message.StatusVersion, version)
+	require.Equal(t, message.SignatureSigningFailed, response.Status)
+	require.Equal(t, [bls.SignatureLen]byte{}, response.Signature)
+}
+
+// blockingBackend is a warp backend whose message signature lookups block
+// until [unblock] is closed.
+type blockingBackend struct {
+	warp.Backend
+	entered chan struct{}
+	unblock chan struct{}
+}
+
+func (b *blockingBackend) GetMessageSignature(ids.ID) ([bls.SignatureLen]byte, error) {
+	b.entered <- struct{}{}
+	<-b.unblock
+	return [bls.SignatureLen]byte{1}, nil
+}
+
+func TestSignatureHandlerConcurrencyPerPeer(t *testing.T) {
+	const limit = 2
+	backend := &blockingBackend{
+		entered: make(chan struct{}, limit+1),
+		unblock: make(chan struct{}),
+	}
+	handler := NewSignatureRequestHandler(backend, message.Codec, limit, 0)
+	handler.stats.Clear()
+
+	var (
+		peer1   = ids.GenerateTestNodeID()
+		peer2   = ids.GenerateTestNodeID()
+		request = message.MessageSignatureRequest{MessageID: ids.GenerateTestID()}
+		results = make(chan []byte, limit+1)
+	)
+	serve := func(nodeID ids.NodeID, requestID uint32) {
+		// The handler never returns an error.
+		responseBytes, _ := handler.OnMessageSignatureRequest(context.Background(), nodeID, requestID, request)
+		results <- responseBytes
+	}
+
+	// Occupy all the slots of [peer1].
+	for i := 0; i < limit; i++ {
+		go serve(peer1, uint32(i))
+		<-backend.entered
+	}
+
+	// Additional concurrent requests from [peer1] are dropped.
+	responseBytes, err := handler.OnMessageSignatureRequest(context.Background(), peer1, limit, request)
+	require.NoError(t, err)
+	require.Nil(t, responseBytes)
+	require.EqualValues(t, 1, handler.stats.concurrencyLimitExceeded.Count())
+
+	// Other peers are not affected.
+	go serve(peer2, 0)
+	<-backend.entered
+
+	close(backend.unblock)
+	for i := 0; i < limit+1; i++ {
+		require.NotEmpty(t, <-results)
+	}
+
+	// Once its requests are served, [peer1] may send requests again.
+	go serve(peer1, limit+1)
+	<-backend.entered
+	require.NotEmpty(t, <-results)
+	require.EqualValues(t, 1, handler.stats.concurrencyLimitExceeded.Count())
+	require.EqualValues(t, limit+3, handler.stats.messageSignatureRequest.Count())
+}
+
+// countingBackend is a warp backend counting its message signings, which
+// block until [unblock] is closed.
+type countingBackend struct {
+	warp.Backend
+	calls   atomic.Int32
+	entered chan struct{}
+	unblock chan struct{}
+}
+
+func (b *countingBackend) GetMessageSignature(ids.ID) ([bls.SignatureLen]byte, error) {
+	b.calls.Add(1)
+	b.entered <- struct{}{}
+	<-b.unblock
+	return [bls.SignatureLen]byte{1}, nil
+}
+
+func TestSignatureHandlerCoalescesRequests(t *testing.T) {
+	const numRequests = 5
+	backend := &countingBackend{
+		entered: make(chan struct{}, numRequests),
+		unblock: make(chan struct{}),
+	}
+	handler := NewSignatureRequestHandler(backend, message.Codec, 0, 10*time.Millisecond)
+	handler.stats.Clear()
+
+	var (
+		request = message.MessageSignatureRequest{MessageID: ids.GenerateTestID()}
+		results = make(chan []byte, numRequests)
+	)
+	serve := func(requestID uint32) {
+		// The handler never returns an error.
+		responseBytes, _ := handler.OnMessageSignatureRequest(context.Background(), ids.GenerateTestNodeID(), requestID, request)
+		results <- responseBytes
+	}
+
+	// The first request signs the message, the identical requests received
+	// while it is in progress wait for its result.
+	go serve(0)
+	<-backend.entered
+	for i := 1; i < numRequests; i++ {
+		go serve(uint32(i))
+	}
+	require.Eventually(t, func() bool {
+		return handler.stats.messageSignatureCoalesced.Count() == numRequests-1
+	}, time.Second, time.Millisecond)
+
+	close(backend.unblock)
+	expected, err := message.Codec.Marshal(message.Version, &message.SignatureResponse{Signature: [bls.SignatureLen]byte{1}})
+	require.NoError(t, err)
+	for i := 0; i < numRequests; i++ {
+		require.Equal(t, expected, <-results)
+	}
+	require.EqualValues(t, 1, backend.calls.Load())
+
+	// Requests received after the signing completed sign again.
+	serve(numRequests)
+	<-backend.entered
+	require.Equal(t, expected, <-results)
+	require.EqualValues(t, 2, backend.calls.Load())
+	require.EqualValues(t, numRequests-1, handler.stats.messageSignatureCoalesced.Count())
+}
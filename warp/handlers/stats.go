@@ -15,11 +15,14 @@ type handlerStats struct {
 	messageSignatureHit             metrics.Counter
 	messageSignatureMiss            metrics.Counter
 	messageSignatureRequestDuration metrics.Gauge
+	messageSignatureCoalesced       metrics.Counter
 	// BlockSignatureRequestHandler metrics
 	blockSignatureRequest         metrics.Counter
 	blockSignatureHit             metrics.Counter
 	blockSignatureMiss            metrics.Counter
 	blockSignatureRequestDuration metrics.Gauge
+	// Requests dropped because their peer had too many requests in flight
+	concurrencyLimitExceeded metrics.Counter
 }
 
 func newStats() *handlerStats {
@@ -28,16 +31,19 @@ func newStats() *handlerStats {
 		messageSignatureHit:             metrics.GetOrRegisterCounter("message_signature_request_hit", nil),
 		messageSignatureMiss:            metrics.GetOrRegisterCounter("message_signature_request_miss", nil),
 		messageSignatureRequestDuration: metrics.GetOrRegisterGauge("message_signature_request_duration", nil),
+		messageSignatureCoalesced:       metrics.GetOrRegisterCounter("message_signature_request_coalesced", nil),
 		blockSignatureRequest:           metrics.GetOrRegisterCounter("block_signature_request_count", nil),
 		blockSignatureHit:               metrics.GetOrRegisterCounter("block_signature_request_hit", nil),
 		blockSignatureMiss:              metrics.GetOrRegisterCounter("block_signature_request_miss", nil),
 		blockSignatureRequestDuration:   metrics.GetOrRegisterGauge("block_signature_request_duration", nil),
+		concurrencyLimitExceeded:        metrics.GetOrRegisterCounter("signature_request_concurrency_limit_exceeded", nil),
 	}
 }
 
-func (h *handlerStats) IncMessageSignatureRequest() { h.messageSignatureRequest.Inc(1) }
-func (h *handlerStats) IncMessageSignatureHit()     { h.messageSignatureHit.Inc(1) }
-func (h *handlerStats) IncMessageSignatureMiss()    { h.messageSignatureMiss.Inc(1) }
+func (h *handlerStats) IncMessageSignatureRequest()   { h.messageSignatureRequest.Inc(1) }
+func (h *handlerStats) IncMessageSignatureHit()       { h.messageSignatureHit.Inc(1) }
+func (h *handlerStats) IncMessageSignatureMiss()      { h.messageSignatureMiss.Inc(1) }
+func (h *handlerStats) IncMessageSignatureCoalesced() { h.messageSignatureCoalesced.Inc(1) }
 func (h *handlerStats) UpdateMessageSignatureRequestTime(duration time.Duration) {
 	h.messageSignatureRequestDuration.Inc(int64(duration))
 }
@@ -47,13 +53,16 @@ func (h *handlerStats) IncBlockSignatureMiss()    { h.blockSignatureMiss.Inc(1)
 func (h *handlerStats) UpdateBlockSignatureRequestTime(duration time.Duration) {
 	h.blockSignatureRequestDuration.Inc(int64(duration))
 }
+func (h *handlerStats) IncConcurrencyLimitExceeded() { h.concurrencyLimitExceeded.Inc(1) }
 func (h *handlerStats) Clear() {
 	h.messageSignatureRequest.Clear()
 	h.messageSignatureHit.Clear()
 	h.messageSignatureMiss.Clear()
 	h.messageSignatureRequestDuration.Update(0)
+	h.messageSignatureCoalesced.Clear()
 	h.blockSignatureRequest.Clear()
 	h.blockSignatureHit.Clear()
 	h.blockSignatureMiss.Clear()
 	h.blockSignatureRequestDuration.Update(0)
+	h.concurrencyLimitExceeded.Clear()
 }
@@ -9,8 +9,8 @@ import (
 	"fmt"
 
 	"github.com/luxdefi/node/ids"
+	"github.com/luxdefi/node/utils/crypto/bls"
 	"github.com/luxdefi/node/vms/platformvm/warp"
-	"github.com/luxdefi/node/vms/platformvm/warp/payload"
 	"github.com/luxdefi/evm/peer"
 	"github.com/luxdefi/evm/warp/aggregator"
 	"github.com/luxdefi/evm/warp/validators"
@@ -18,7 +18,73 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 )
 
-var errNoValidators = errors.New("cannot aggregate signatures from subnet with no validators")
+// maxListMessagesLimit is the maximum number of messages returned by a call to
+// ListMessages.
+const maxListMessagesLimit = 1024
+
+var (
+	errNoValidators = errors.New("cannot aggregate signatures from subnet with no validators")
+	errNoSubnets    = errors.New("cannot aggregate signatures from no subnets")
+)
+
+// MalformedMessageError is returned by ParseMessage when the input is not a
+// valid signed or unsigned warp message.
+type MalformedMessageError struct {
+	Err error
+}
+
+func (e *MalformedMessageError) Error() string {
+	return fmt.Sprintf("malformed warp message: %v", e.Err)
+}
+
+func (e *MalformedMessageError) Unwrap() error { return e.Err }
+
+// ErrorCode returns the JSON-RPC invalid params error code.
+func (*MalformedMessageError) ErrorCode() int { return -32602 }
+
+// ParsedMessage is the decoded form of a warp message returned by ParseMessage.
+type ParsedMessage struct {
+	MessageID     ids.ID           `json:"messageID"`
+	NetworkID     uint32           `json:"networkID"`
+	SourceChainID ids.ID           `json:"sourceChainID"`
+	Payload       hexutil.Bytes    `json:"payload"`
+	Signature     *ParsedSignature `json:"signature,omitempty"` // nil if the message is unsigned
+}
+
+// ParsedSignature describes the signature of a signed warp message.
+type ParsedSignature struct {
+	Type       string        `json:"type"`
+	Signers    hexutil.Bytes `json:"signers"` // big-endian bitset of the indices of the signing validators
+	NumSigners int           `json:"numSigners"`
+	Signature  hexutil.Bytes `json:"signature"`
+}
+
+// BlockSignatureVerification is the result of VerifyBlockSignature.
+type BlockSignatureVerification struct {
+	Valid     bool          `json:"valid"`
+	PublicKey hexutil.Bytes `json:"publicKey"`       // compressed BLS public key of the current signer of the node
+	MessageID ids.ID        `json:"messageID"`       // ID of the block hash message the signature was checked against
+	Error     string        `json:"error,omitempty"` // why the signature could not be decoded, if it could not
+}
+
+// MessagePage is a page of the stored messages returned by ListMessages.
+type MessagePage struct {
+	Messages   []StoredMessage `json:"messages"`
+	NextCursor hexutil.Bytes   `json:"nextCursor,omitempty"` // nil if there are no messages left
+}
+
+// SubnetQuorumArgs is a subnet whose validators must sign a message, and the
+// quorum of their weight the signatures must reach.
+type SubnetQuorumArgs struct {
+	SubnetID  ids.ID `json:"subnetID"`
+	QuorumNum uint64 `json:"quorumNum"`
+}
+
+// SubnetSignedMessage is a message signed by the validators of a subnet.
+type SubnetSignedMessage struct {
+	SubnetID      ids.ID        `json:"subnetID"`
+	SignedMessage hexutil.Bytes `json:"signedMessage"`
+}
 
 // API introduces snowman specific functionality to the evm
 type API struct {
@@ -27,16 +93,18 @@ type API struct {
 	backend                       Backend
 	state                         *validators.State
 	client                        peer.NetworkClient
+	aggregatorConfig              aggregator.Config
 }
 
-func NewAPI(networkID uint32, sourceSubnetID ids.ID, sourceChainID ids.ID, state *validators.State, backend Backend, client peer.NetworkClient) *API {
+func NewAPI(networkID uint32, sourceSubnetID ids.ID, sourceChainID ids.ID, state *validators.State, backend Backend, client peer.NetworkClient, aggregatorConfig aggregator.Config) *API {
 	return &API{
-		networkID:      networkID,
-		sourceSubnetID: sourceSubnetID,
-		sourceChainID:  sourceChainID,
-		backend:        backend,
-		state:          state,
-		client:         client,
+		networkID:        networkID,
+		sourceSubnetID:   sourceSubnetID,
+		sourceChainID:    sourceChainID,
+		backend:          backend,
+		state:            state,
+		client:           client,
+		aggregatorConfig: aggregatorConfig,
 	}
 }
 
@@ -58,6 +126,71 @@ func (a *API) GetBlockSignature(ctx context.Context, blockID ids.ID) (hexutil.By
 	return signature[:], nil
 }
 
+// GetSignerPublicKey returns the compressed BLS public key verifying the
+// signatures served by the node, which follows rotations of its signing key.
+func (a *API) GetSignerPublicKey(ctx context.Context) (hexutil.Bytes, error) {
+	publicKey, err := a.backend.SignerPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	return bls.PublicKeyToBytes(publicKey), nil
+}
+
+// ListMessages returns up to [limit] of the warp messages sent from this chain
+// and stored by the node, with the heights of the blocks that sent them, in
+// order of height then message ID. Listing starts at [cursor], or at the first
+// message if it is empty, and continues from the returned next cursor.
+func (a *API) ListMessages(ctx context.Context, cursor hexutil.Bytes, limit int) (*MessagePage, error) {
+	if limit > maxListMessagesLimit {
+		return nil, fmt.Errorf("limit %d exceeds the maximum of %d", limit, maxListMessagesLimit)
+	}
+	messages, next, err := a.backend.ListMessages(cursor, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list messages with error %w", err)
+	}
+	if messages == nil {
+		messages = []StoredMessage{}
+	}
+	return &MessagePage{Messages: messages, NextCursor: next}, nil
+}
+
+// GetBlockMessage returns the bytes of the unsigned warp message that is
+// signed to attest to the acceptance of [blockID].
+func (a *API) GetBlockMessage(ctx context.Context, blockID ids.ID) (hexutil.Bytes, error) {
+	unsignedMessage, err := a.backend.GetBlockMessage(blockID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message for block %s with error %w", blockID, err)
+	}
+	return unsignedMessage.Bytes(), nil
+}
+
+// VerifyBlockSignature checks whether [signature] is a signature of the
+// current signer of the local node over the warp message for the accepted
+// block [blockID], so that relayers can detect a bad signature before
+// relaying it. A signature that cannot be decoded is reported as invalid
+// rather than as an error.
+func (a *API) VerifyBlockSignature(ctx context.Context, blockID ids.ID, signature hexutil.Bytes) (*BlockSignatureVerification, error) {
+	publicKey, err := a.backend.SignerPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	unsignedMessage, err := a.backend.GetBlockMessage(blockID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message for block %s with error %w", blockID, err)
+	}
+	result := &BlockSignatureVerification{
+		PublicKey: bls.PublicKeyToBytes(publicKey),
+		MessageID: unsignedMessage.ID(),
+	}
+	sig, err := bls.SignatureFromBytes(signature)
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	result.Valid = bls.Verify(publicKey, sig, unsignedMessage.Bytes())
+	return result, nil
+}
+
 // GetMessageAggregateSignature fetches the aggregate signature for the requested [messageID]
 func (a *API) GetMessageAggregateSignature(ctx context.Context, messageID ids.ID, quorumNum uint64, subnetIDStr string) (signedMessageBytes hexutil.Bytes, err error) {
 	unsignedMessage, err := a.backend.GetMessage(messageID)
@@ -69,16 +202,74 @@ func (a *API) GetMessageAggregateSignature(ctx context.Context, messageID ids.ID
 
 // GetBlockAggregateSignature fetches the aggregate signature for the requested [blockID]
 func (a *API) GetBlockAggregateSignature(ctx context.Context, blockID ids.ID, quorumNum uint64, subnetIDStr string) (signedMessageBytes hexutil.Bytes, err error) {
-	blockHashPayload, err := payload.NewHash(blockID)
+	unsignedMessage, err := a.backend.GetBlockMessage(blockID)
 	if err != nil {
 		return nil, err
 	}
-	unsignedMessage, err := warp.NewUnsignedMessage(a.networkID, a.sourceChainID, blockHashPayload.Bytes())
+
+	return a.aggregateSignatures(ctx, unsignedMessage, quorumNum, subnetIDStr)
+}
+
+// GetMessageMultiSubnetAggregateSignature fetches an aggregate signature for
+// the requested [messageID] from the validators of each of [subnets], in the
+// order of [subnets]. It fails unless every subnet reaches its quorum.
+func (a *API) GetMessageMultiSubnetAggregateSignature(ctx context.Context, messageID ids.ID, subnets []SubnetQuorumArgs) ([]SubnetSignedMessage, error) {
+	unsignedMessage, err := a.backend.GetMessage(messageID)
 	if err != nil {
 		return nil, err
 	}
+	return a.multiSubnetAggregateSignatures(ctx, unsignedMessage, subnets)
+}
 
-	return a.aggregateSignatures(ctx, unsignedMessage, quorumNum, subnetIDStr)
+// GetBlockMultiSubnetAggregateSignature fetches an aggregate signature for
+// the requested [blockID] from the validators of each of [subnets], in the
+// order of [subnets]. It fails unless every subnet reaches its quorum.
+func (a *API) GetBlockMultiSubnetAggregateSignature(ctx context.Context, blockID ids.ID, subnets []SubnetQuorumArgs) ([]SubnetSignedMessage, error) {
+	unsignedMessage, err := a.backend.GetBlockMessage(blockID)
+	if err != nil {
+		return nil, err
+	}
+	return a.multiSubnetAggregateSignatures(ctx, unsignedMessage, subnets)
+}
+
+// ParseMessage decodes [messageBytes] as a signed warp message or, failing that, as an
+// unsigned warp message.
+func (a *API) ParseMessage(ctx context.Context, messageBytes hexutil.Bytes) (*ParsedMessage, error) {
+	signedMessage, signedErr := warp.ParseMessage(messageBytes)
+	if signedErr != nil {
+		unsignedMessage, unsignedErr := warp.ParseUnsignedMessage(messageBytes)
+		if unsignedErr != nil {
+			return nil, &MalformedMessageError{Err: fmt.Errorf("not a signed message (%v) nor an unsigned message (%w)", signedErr, unsignedErr)}
+		}
+		return newParsedMessage(unsignedMessage), nil
+	}
+
+	parsed := newParsedMessage(&signedMessage.UnsignedMessage)
+	switch signature := signedMessage.Signature.(type) {
+	case *warp.BitSetSignature:
+		numSigners, err := signature.NumSigners()
+		if err != nil {
+			return nil, &MalformedMessageError{Err: fmt.Errorf("invalid signers bitset: %w", err)}
+		}
+		parsed.Signature = &ParsedSignature{
+			Type:       "BitSetSignature",
+			Signers:    signature.Signers,
+			NumSigners: numSigners,
+			Signature:  signature.Signature[:],
+		}
+	default:
+		return nil, &MalformedMessageError{Err: fmt.Errorf("unsupported signature type %T", signature)}
+	}
+	return parsed, nil
+}
+
+func newParsedMessage(unsignedMessage *warp.UnsignedMessage) *ParsedMessage {
+	return &ParsedMessage{
+		MessageID:     unsignedMessage.ID(),
+		NetworkID:     unsignedMessage.NetworkID,
+		SourceChainID: unsignedMessage.SourceChainID,
+		Payload:       unsignedMessage.Payload,
+	}
 }
 
 func (a *API) aggregateSignatures(ctx context.Context, unsignedMessage *warp.UnsignedMessage, quorumNum uint64, subnetIDStr string) (hexutil.Bytes, error) {
@@ -110,7 +301,7 @@ func (a *API) aggregateSignatures(ctx context.Context, unsignedMessage *warp.Uns
 		"totalWeight", totalWeight,
 	)
 
-	agg := aggregator.New(aggregator.NewSignatureGetter(a.client), validators, totalWeight)
+	agg := aggregator.NewWithConfig(aggregator.NewSignatureGetter(a.client), validators, totalWeight, a.aggregatorConfig)
 	signatureResult, err := agg.AggregateSignatures(ctx, unsignedMessage, quorumNum)
 	if err != nil {
 		return nil, err
@@ -120,3 +311,49 @@ func (a *API) aggregateSignatures(ctx context.Context, unsignedMessage *warp.Uns
 	// gotchas that could impact signed messages becoming invalid.
 	return hexutil.Bytes(signatureResult.Message.Bytes()), nil
 }
+
+func (a *API) multiSubnetAggregateSignatures(ctx context.Context, unsignedMessage *warp.UnsignedMessage, subnets []SubnetQuorumArgs) ([]SubnetSignedMessage, error) {
+	if len(subnets) == 0 {
+		return nil, errNoSubnets
+	}
+	pChainHeight, err := a.state.GetCurrentHeight(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	quorums := make([]aggregator.SubnetQuorum, len(subnets))
+	for i, subnet := range subnets {
+		validators, totalWeight, err := warp.GetCanonicalValidatorSet(ctx, a.state, pChainHeight, subnet.SubnetID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get validator set of subnet %s: %w", subnet.SubnetID, err)
+		}
+		if len(validators) == 0 {
+			return nil, fmt.Errorf("%w (SubnetID: %s, Height: %d)", errNoValidators, subnet.SubnetID, pChainHeight)
+		}
+		quorums[i] = aggregator.SubnetQuorum{
+			SubnetID:    subnet.SubnetID,
+			Validators:  validators,
+			TotalWeight: totalWeight,
+			QuorumNum:   subnet.QuorumNum,
+		}
+	}
+
+	log.Debug("Fetching signatures from multiple subnets",
+		"numSubnets", len(subnets),
+		"height", pChainHeight,
+	)
+
+	agg := aggregator.NewMultiSubnet(aggregator.NewSignatureGetter(a.client), quorums, a.aggregatorConfig)
+	signatureResults, err := agg.AggregateSignatures(ctx, unsignedMessage)
+	if err != nil {
+		return nil, err
+	}
+	signedMessages := make([]SubnetSignedMessage, len(subnets))
+	for i, subnet := range subnets {
+		signedMessages[i] = SubnetSignedMessage{
+			SubnetID:      subnet.SubnetID,
+			SignedMessage: signatureResults[subnet.SubnetID].Message.Bytes(),
+		}
+	}
+	return signedMessages, nil
+}
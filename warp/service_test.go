@@ -0,0 +1,291 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package warp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/luxdefi/node/database/memdb"
+	"github.com/luxdefi/node/ids"
+	"github.com/luxdefi/node/snow/choices"
+	"github.com/luxdefi/node/snow/consensus/snowman"
+	"github.com/luxdefi/node/snow/engine/common"
+	"github.com/luxdefi/node/snow/engine/snowman/block"
+	"github.com/luxdefi/node/utils/crypto/bls"
+	"github.com/luxdefi/node/utils/set"
+	luxWarp "github.com/luxdefi/node/vms/platformvm/warp"
+	"github.com/luxdefi/node/vms/platformvm/warp/payload"
+	"github.com/luxdefi/evm/warp/aggregator"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMessage(t *testing.T) {
+	require := require.New(t)
+	api := &API{}
+
+	// unsigned message
+	parsed, err := api.ParseMessage(context.Background(), testUnsignedMessage.Bytes())
+	require.NoError(err)
+	require.Equal(testUnsignedMessage.ID(), parsed.MessageID)
+	require.Equal(networkID, parsed.NetworkID)
+	require.Equal(sourceChainID, parsed.SourceChainID)
+	require.Equal(testUnsignedMessage.Payload, []byte(parsed.Payload))
+	require.Nil(parsed.Signature)
+
+	// BLS signed message, signed by the validators at indices 1 and 3
+	sk, err := bls.NewSecretKey()
+	require.NoError(err)
+	sigBytes, err := luxWarp.NewSigner(sk, networkID, sourceChainID).Sign(testUnsignedMessage)
+	require.NoError(err)
+	signers := set.NewBits(1, 3)
+	signature := &luxWarp.BitSetSignature{Signers: signers.Bytes()}
+	copy(signature.Signature[:], sigBytes)
+	signedMessage, err := luxWarp.NewMessage(testUnsignedMessage, signature)
+	require.NoError(err)
+
+	parsed, err = api.ParseMessage(context.Background(), signedMessage.Bytes())
+	require.NoError(err)
+	require.Equal(testUnsignedMessage.ID(), parsed.MessageID)
+	require.Equal(networkID, parsed.NetworkID)
+	require.Equal(sourceChainID, parsed.SourceChainID)
+	require.Equal(testUnsignedMessage.Payload, []byte(parsed.Payload))
+	require.NotNil(parsed.Signature)
+	require.Equal("BitSetSignature", parsed.Signature.Type)
+	require.Equal(signers.Bytes(), []byte(parsed.Signature.Signers))
+	require.Equal(2, parsed.Signature.NumSigners)
+	require.Equal(sigBytes, []byte(parsed.Signature.Signature))
+
+	// malformed inputs
+	for name, input := range map[string][]byte{
+		"empty":             nil,
+		"garbage":           []byte("not a warp message"),
+		"truncated":         signedMessage.Bytes()[:len(signedMessage.Bytes())-1],
+		"trailing bytes":    append(testUnsignedMessage.Bytes(), 0x01),
+		"padded signer set": mustMessageWithSigners(t, testUnsignedMessage, []byte{0x00, 0x01}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := api.ParseMessage(context.Background(), input)
+			var malformedErr *MalformedMessageError
+			require.True(t, errors.As(err, &malformedErr), "unexpected error %v", err)
+			require.Equal(t, -32602, malformedErr.ErrorCode())
+		})
+	}
+}
+
+func mustMessageWithSigners(t *testing.T, unsignedMessage *luxWarp.UnsignedMessage, signers []byte) []byte {
+	msg, err := luxWarp.NewMessage(unsignedMessage, &luxWarp.BitSetSignature{Signers: signers})
+	require.NoError(t, err)
+	return msg.Bytes()
+}
+
+func TestVerifyBlockSignature(t *testing.T) {
+	require := require.New(t)
+
+	sk, err := bls.NewSecretKey()
+	require.NoError(err)
+	pk := bls.PublicFromSecretKey(sk)
+	blockID := ids.GenerateTestID()
+	processingBlockID := ids.GenerateTestID()
+	testVM := &block.TestVM{
+		TestVM: common.TestVM{T: t},
+		GetBlockF: func(ctx context.Context, i ids.ID) (snowman.Block, error) {
+			status := choices.Accepted
+			if i == processingBlockID {
+				status = choices.Processing
+			}
+			return &snowman.TestBlock{
+				TestDecidable: choices.TestDecidable{
+					IDV:     i,
+					StatusV: status,
+				},
+			}, nil
+		},
+	}
+	backend, err := NewBackend(networkID, sourceChainID, NewPublicKeySigner(sk, networkID, sourceChainID), testVM, memdb.New(), 500, false, nil)
+	require.NoError(err)
+	api := NewAPI(networkID, ids.Empty, sourceChainID, nil, backend, nil, aggregator.Config{})
+
+	blockHashPayload, err := payload.NewHash(blockID)
+	require.NoError(err)
+	unsignedMessage, err := luxWarp.NewUnsignedMessage(networkID, sourceChainID, blockHashPayload.Bytes())
+	require.NoError(err)
+	sigBytes, err := luxWarp.NewSigner(sk, networkID, sourceChainID).Sign(unsignedMessage)
+	require.NoError(err)
+
+	result, err := api.VerifyBlockSignature(context.Background(), blockID, sigBytes)
+	require.NoError(err)
+	require.True(result.Valid)
+	require.Equal(bls.PublicKeyToBytes(pk), []byte(result.PublicKey))
+	require.Equal(unsignedMessage.ID(), result.MessageID)
+	require.Empty(result.Error)
+
+	// A signature of another block
+	result, err = api.VerifyBlockSignature(context.Background(), ids.GenerateTestID(), sigBytes)
+	require.NoError(err)
+	require.False(result.Valid)
+
+	// A signature by another key
+	otherSK, err := bls.NewSecretKey()
+	require.NoError(err)
+	otherSig, err := luxWarp.NewSigner(otherSK, networkID, sourceChainID).Sign(unsignedMessage)
+	require.NoError(err)
+	result, err = api.VerifyBlockSignature(context.Background(), blockID, otherSig)
+	require.NoError(err)
+	require.False(result.Valid)
+
+	// A tampered signature that no longer decodes
+	tampered := append([]byte{}, sigBytes...)
+	tampered[len(tampered)-1] ^= 0xff
+	result, err = api.VerifyBlockSignature(context.Background(), blockID, tampered)
+	require.NoError(err)
+	require.False(result.Valid)
+
+	result, err = api.VerifyBlockSignature(context.Background(), blockID, sigBytes[1:])
+	require.NoError(err)
+	require.False(result.Valid)
+	require.NotEmpty(result.Error)
+
+	// Blocks that are not accepted have no signature to verify
+	processingPayload, err := payload.NewHash(processingBlockID)
+	require.NoError(err)
+	processingMessage, err := luxWarp.NewUnsignedMessage(networkID, sourceChainID, processingPayload.Bytes())
+	require.NoError(err)
+	processingSig, err := luxWarp.NewSigner(sk, networkID, sourceChainID).Sign(processingMessage)
+	require.NoError(err)
+	_, err = api.VerifyBlockSignature(context.Background(), processingBlockID, processingSig)
+	require.ErrorContains(err, "was not accepted")
+
+	// Signatures are checked against the current signer after a rotation
+	backend.UpdateSigner(NewPublicKeySigner(otherSK, networkID, sourceChainID))
+	result, err = api.VerifyBlockSignature(context.Background(), blockID, otherSig)
+	require.NoError(err)
+	require.True(result.Valid)
+	require.Equal(bls.PublicKeyToBytes(bls.PublicFromSecretKey(otherSK)), []byte(result.PublicKey))
+	result, err = api.VerifyBlockSignature(context.Background(), blockID, sigBytes)
+	require.NoError(err)
+	require.False(result.Valid)
+}
+
+func TestGetSignerPublicKey(t *testing.T) {
+	require := require.New(t)
+
+	sk, err := bls.NewSecretKey()
+	require.NoError(err)
+	backend, err := NewBackend(networkID, sourceChainID, NewPublicKeySigner(sk, networkID, sourceChainID), nil, memdb.New(), 500, false, nil)
+	require.NoError(err)
+	api := NewAPI(networkID, ids.Empty, sourceChainID, nil, backend, nil, aggregator.Config{})
+
+	// verifies the signatures served by the backend, also after a rotation
+	otherSK, err := bls.NewSecretKey()
+	require.NoError(err)
+	for i, key := range []*bls.SecretKey{sk, otherSK} {
+		if i > 0 {
+			backend.UpdateSigner(NewPublicKeySigner(key, networkID, sourceChainID))
+		}
+		pkBytes, err := api.GetSignerPublicKey(context.Background())
+		require.NoError(err)
+		require.Equal(bls.PublicKeyToBytes(bls.PublicFromSecretKey(key)), []byte(pkBytes))
+
+		require.NoError(backend.AddMessage(testUnsignedMessage, 1))
+		sigBytes, err := backend.GetMessageSignature(testUnsignedMessage.ID())
+		require.NoError(err)
+		pk, err := bls.PublicKeyFromBytes(pkBytes)
+		require.NoError(err)
+		sig, err := bls.SignatureFromBytes(sigBytes[:])
+		require.NoError(err)
+		require.True(bls.Verify(pk, sig, testUnsignedMessage.Bytes()))
+	}
+
+	// the public key of a signer that does not expose it is unknown
+	backend, err = NewBackend(networkID, sourceChainID, luxWarp.NewSigner(sk, networkID, sourceChainID), nil, memdb.New(), 500, false, nil)
+	require.NoError(err)
+	api = NewAPI(networkID, ids.Empty, sourceChainID, nil, backend, nil, aggregator.Config{})
+	_, err = api.GetSignerPublicKey(context.Background())
+	require.ErrorIs(err, ErrUnknownPublicKey)
+}
+
+func TestListMessages(t *testing.T) {
+	require := require.New(t)
+
+	sk, err := bls.NewSecretKey()
+	require.NoError(err)
+	backend, err := NewBackend(networkID, sourceChainID, luxWarp.NewSigner(sk, networkID, sourceChainID), nil, memdb.New(), 500, false, nil)
+	require.NoError(err)
+	api := NewAPI(networkID, ids.Empty, sourceChainID, nil, backend, nil, aggregator.Config{})
+
+	page, err := api.ListMessages(context.Background(), nil, 2)
+	require.NoError(err)
+	require.Empty(page.Messages)
+	require.Nil(page.NextCursor)
+
+	// add messages out of height order, with two at the same height
+	var expected []StoredMessage
+	for i, height := range []uint64{3, 1, 2, 2, 5} {
+		msg, err := luxWarp.NewUnsignedMessage(networkID, sourceChainID, []byte{byte(i)})
+		require.NoError(err)
+		require.NoError(backend.AddMessage(msg, height))
+		expected = append(expected, StoredMessage{MessageID: msg.ID(), Height: height})
+	}
+	sort.Slice(expected, func(i, j int) bool {
+		if expected[i].Height != expected[j].Height {
+			return expected[i].Height < expected[j].Height
+		}
+		return bytes.Compare(expected[i].MessageID[:], expected[j].MessageID[:]) < 0
+	})
+
+	// messages stored from other chains are not listed
+	otherChainMessage, err := luxWarp.NewUnsignedMessage(networkID, ids.GenerateTestID(), []byte{0})
+	require.NoError(err)
+	require.ErrorIs(backend.AddMessage(otherChainMessage, 4), luxWarp.ErrWrongSourceChainID)
+
+	var (
+		listed []StoredMessage
+		cursor []byte
+		pages  int
+	)
+	for {
+		page, err := api.ListMessages(context.Background(), cursor, 2)
+		require.NoError(err)
+		require.LessOrEqual(len(page.Messages), 2)
+		listed = append(listed, page.Messages...)
+		pages++
+		if page.NextCursor == nil {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	require.Equal(expected, listed)
+	require.Equal(3, pages)
+
+	// a page can also resume from the middle of the same height
+	page, err = api.ListMessages(context.Background(), messageIndexKey(expected[2].Height, expected[2].MessageID)[len(messageIndexPrefix):], 10)
+	require.NoError(err)
+	require.Equal(expected[2:], page.Messages)
+	require.Nil(page.NextCursor)
+
+	_, err = api.ListMessages(context.Background(), []byte{1, 2, 3}, 2)
+	require.ErrorIs(err, ErrInvalidCursor)
+	_, err = api.ListMessages(context.Background(), nil, 0)
+	require.Error(err)
+	_, err = api.ListMessages(context.Background(), nil, maxListMessagesLimit+1)
+	require.Error(err)
+}
+
+func TestGetMessageMultiSubnetAggregateSignatureNoSubnets(t *testing.T) {
+	require := require.New(t)
+
+	sk, err := bls.NewSecretKey()
+	require.NoError(err)
+	backend, err := NewBackend(networkID, sourceChainID, luxWarp.NewSigner(sk, networkID, sourceChainID), nil, memdb.New(), 500, false, nil)
+	require.NoError(err)
+	require.NoError(backend.AddMessage(testUnsignedMessage, 1))
+	api := NewAPI(networkID, ids.Empty, sourceChainID, nil, backend, nil, aggregator.Config{})
+
+	_, err = api.GetMessageMultiSubnetAggregateSignature(context.Background(), testUnsignedMessage.ID(), nil)
+	require.ErrorIs(err, errNoSubnets)
+}
@@ -0,0 +1,38 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package warp
+
+import (
+	"github.com/luxdefi/node/ids"
+	"github.com/luxdefi/node/utils/crypto/bls"
+	luxWarp "github.com/luxdefi/node/vms/platformvm/warp"
+)
+
+// PublicKeySigner is a warp signer that knows the BLS public key of the secret
+// key it signs with, so that its signatures can be verified.
+type PublicKeySigner interface {
+	luxWarp.Signer
+
+	// PublicKey returns the public key verifying the signatures of the signer.
+	PublicKey() *bls.PublicKey
+}
+
+type publicKeySigner struct {
+	luxWarp.Signer
+	publicKey *bls.PublicKey
+}
+
+func (s *publicKeySigner) PublicKey() *bls.PublicKey { return s.publicKey }
+
+// NewPublicKeySigner returns a signer signing the messages of [networkID] and
+// [chainID] with [sk], exposing the public key derived from [sk].
+func NewPublicKeySigner(sk *bls.SecretKey, networkID uint32, chainID ids.ID) PublicKeySigner {
+	return WithPublicKey(luxWarp.NewSigner(sk, networkID, chainID), bls.PublicFromSecretKey(sk))
+}
+
+// WithPublicKey returns [signer] exposing [publicKey], which must be the public
+// key of the secret key [signer] signs with.
+func WithPublicKey(signer luxWarp.Signer, publicKey *bls.PublicKey) PublicKeySigner {
+	return &publicKeySigner{Signer: signer, publicKey: publicKey}
+}
@@ -0,0 +1,25 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package warp
+
+import (
+	"github.com/luxdefi/evm/metrics"
+)
+
+// backendStats reports the latency of the warp backend database operations,
+// so that slow signing can be attributed to disk I/O, and the number of
+// duplicate messages that were not stored again.
+type backendStats struct {
+	dbGetDuration    metrics.Timer
+	dbPutDuration    metrics.Timer
+	messageDedupHits metrics.Counter
+}
+
+func newBackendStats() *backendStats {
+	return &backendStats{
+		dbGetDuration:    metrics.GetOrRegisterTimer("warp_backend_db_get_duration", nil),
+		dbPutDuration:    metrics.GetOrRegisterTimer("warp_backend_db_put_duration", nil),
+		messageDedupHits: metrics.GetOrRegisterCounter("warp_backend_message_dedup_hits", nil),
+	}
+}
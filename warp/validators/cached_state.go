@@ -0,0 +1,81 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package validators
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/luxdefi/node/ids"
+	"github.com/luxdefi/node/snow/validators"
+	"github.com/luxdefi/node/utils/timer/mockable"
+)
+
+var _ validators.State = (*CachedState)(nil)
+
+// CachedState wraps a [validators.State], caching the last validator set
+// fetched for each subnet for [refreshInterval] before fetching it again from
+// the P-Chain.
+//
+// Cached sets are only served for the P-Chain height they were fetched at. The
+// messages of a block are all verified at the P-Chain height of the block, so
+// they are verified against the same set even if it is refreshed in between.
+type CachedState struct {
+	validators.State
+	refreshInterval time.Duration
+	clock           mockable.Clock
+
+	lock sync.Mutex
+	sets map[ids.ID]*cachedValidatorSet
+}
+
+type cachedValidatorSet struct {
+	height     uint64
+	fetched    time.Time
+	validators map[ids.NodeID]*validators.GetValidatorOutput
+}
+
+// NewCachedState returns a wrapper of [state] caching the validator set of
+// each subnet for [refreshInterval].
+func NewCachedState(state validators.State, refreshInterval time.Duration) *CachedState {
+	return &CachedState{
+		State:           state,
+		refreshInterval: refreshInterval,
+		sets:            make(map[ids.ID]*cachedValidatorSet),
+	}
+}
+
+// GetValidatorSet returns the validator set of [subnetID] at [height]. The
+// returned set may be shared with other callers and must not be modified.
+func (s *CachedState) GetValidatorSet(
+	ctx context.Context,
+	height uint64,
+	subnetID ids.ID,
+) (map[ids.NodeID]*validators.GetValidatorOutput, error) {
+	s.lock.Lock()
+	now := s.clock.Time()
+	set, ok := s.sets[subnetID]
+	s.lock.Unlock()
+	if ok && set.height == height && now.Sub(set.fetched) < s.refreshInterval {
+		return set.validators, nil
+	}
+
+	// Fetch without holding the lock, so that a slow P-Chain request does not
+	// block the lookups of other subnets.
+	vdrs, err := s.State.GetValidatorSet(ctx, height, subnetID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.sets[subnetID] = &cachedValidatorSet{
+		height:     height,
+		fetched:    now,
+		validators: vdrs,
+	}
+	return vdrs, nil
+}
@@ -0,0 +1,92 @@
+// (c) 2024, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package validators
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/luxdefi/node/ids"
+	"github.com/luxdefi/node/snow/validators"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCachedStateRefresh(t *testing.T) {
+	require := require.New(t)
+	ctrl := gomock.NewController(t)
+
+	subnetID := ids.GenerateTestID()
+	nodeID := ids.GenerateTestNodeID()
+	oldSet := map[ids.NodeID]*validators.GetValidatorOutput{nodeID: {NodeID: nodeID, Weight: 1}}
+	newSet := map[ids.NodeID]*validators.GetValidatorOutput{nodeID: {NodeID: nodeID, Weight: 2}}
+
+	mockState := validators.NewMockState(ctrl)
+	state := NewCachedState(mockState, time.Minute)
+	now := time.Unix(1_000_000, 0)
+	state.clock.Set(now)
+
+	// The first lookup fetches the set, later lookups within the interval are cached
+	mockState.EXPECT().GetValidatorSet(gomock.Any(), uint64(10), subnetID).Return(oldSet, nil).Times(1)
+	for i := 0; i < 3; i++ {
+		output, err := state.GetValidatorSet(context.Background(), 10, subnetID)
+		require.NoError(err)
+		require.Equal(oldSet, output)
+		state.clock.Set(now.Add(time.Duration(i) * 20 * time.Second))
+	}
+
+	// Once the interval elapsed the set is fetched again
+	state.clock.Set(now.Add(time.Minute))
+	mockState.EXPECT().GetValidatorSet(gomock.Any(), uint64(10), subnetID).Return(newSet, nil).Times(1)
+	output, err := state.GetValidatorSet(context.Background(), 10, subnetID)
+	require.NoError(err)
+	require.Equal(newSet, output)
+
+	// A different height is never served from the cache
+	mockState.EXPECT().GetValidatorSet(gomock.Any(), uint64(11), subnetID).Return(oldSet, nil).Times(1)
+	output, err = state.GetValidatorSet(context.Background(), 11, subnetID)
+	require.NoError(err)
+	require.Equal(oldSet, output)
+}
+
+func TestCachedStateFetchDoesNotBlockLookups(t *testing.T) {
+	require := require.New(t)
+	ctrl := gomock.NewController(t)
+
+	slowSubnetID := ids.GenerateTestID()
+	subnetID := ids.GenerateTestID()
+	nodeID := ids.GenerateTestNodeID()
+	set := map[ids.NodeID]*validators.GetValidatorOutput{nodeID: {NodeID: nodeID, Weight: 1}}
+
+	mockState := validators.NewMockState(ctrl)
+	state := NewCachedState(mockState, time.Minute)
+	mockState.EXPECT().GetValidatorSet(gomock.Any(), uint64(10), subnetID).Return(set, nil).Times(1)
+	_, err := state.GetValidatorSet(context.Background(), 10, subnetID)
+	require.NoError(err)
+
+	// While the set of another subnet is being fetched, cached sets are served
+	fetching := make(chan struct{})
+	release := make(chan struct{})
+	mockState.EXPECT().GetValidatorSet(gomock.Any(), uint64(10), slowSubnetID).DoAndReturn(
+		func(context.Context, uint64, ids.ID) (map[ids.NodeID]*validators.GetValidatorOutput, error) {
+			close(fetching)
+			<-release
+			return set, nil
+		},
+	).Times(1)
+	done := make(chan error)
+	go func() {
+		_, err := state.GetValidatorSet(context.Background(), 10, slowSubnetID)
+		done <- err
+	}()
+	<-fetching
+
+	output, err := state.GetValidatorSet(context.Background(), 10, subnetID)
+	require.NoError(err)
+	require.Equal(set, output)
+
+	close(release)
+	require.NoError(<-done)
+}
@@ -120,7 +120,7 @@ func (c *Config) Accept(acceptCtx *precompileconfig.AcceptContext, blockHash com
 		"logData", common.Bytes2Hex(logData),
 		"warpMessageID", unsignedMessage.ID(),
 	)
-	if err := acceptCtx.Warp.AddMessage(unsignedMessage); err != nil {
+	if err := acceptCtx.Warp.AddMessage(unsignedMessage, blockNumber); err != nil {
 		return fmt.Errorf("failed to add warp message during accept (TxHash: %s, LogIndex: %d): %w", txHash, logIndex, err)
 	}
 	return nil